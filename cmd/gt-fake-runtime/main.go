@@ -0,0 +1,86 @@
+// gt-fake-runtime is a stand-in agent CLI for exercising runtime lifecycle
+// code (start, readiness, messages, resume, cost, crashes) without an LLM.
+//
+// It records a session ID in .runtime/session_id, shows a prompt, answers
+// each line of input with "received: <line>" and a running cost, and exits
+// on /exit or EOF. Configure it as a generic runtime to drive it from gt:
+//
+//	"fake": {
+//	  "bin": "gt-fake-runtime",
+//	  "ready_pattern": "^fake>",
+//	  "resume_command": "gt-fake-runtime --resume {session_id}",
+//	  "cost_pattern": "Cost: \\$([0-9.]+)",
+//	  "exit_command": "/exit"
+//	}
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func main() {
+	var (
+		prompt     = flag.String("prompt", "fake> ", "Input prompt")
+		readyDelay = flag.Duration("ready-delay", 0, "Delay before showing the prompt")
+		resume     = flag.String("resume", "", "Session ID to resume")
+		costPerMsg = flag.Float64("cost-per-message", 0.01, "USD added to the cost per message")
+		crashAfter = flag.Int("crash-after", 0, "Exit with status 1 after this many messages (0 = never)")
+	)
+	flag.Parse()
+
+	sessionID := *resume
+	if sessionID == "" {
+		sessionID = fmt.Sprintf("fake-%d-%d", time.Now().Unix(), os.Getpid())
+		fmt.Printf("Fake runtime: new session %s\n", sessionID)
+	} else {
+		fmt.Printf("Fake runtime: resumed session %s\n", sessionID)
+	}
+	if err := writeSessionID(sessionID); err != nil {
+		fmt.Fprintf(os.Stderr, "gt-fake-runtime: recording session id: %v\n", err)
+	}
+
+	time.Sleep(*readyDelay)
+
+	var cost float64
+	messages := 0
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print(*prompt)
+		if !in.Scan() {
+			fmt.Println()
+			return
+		}
+		line := strings.TrimSpace(in.Text())
+		switch line {
+		case "":
+			continue
+		case "/exit", "/quit":
+			fmt.Printf("Total cost: $%.2f\n", cost)
+			return
+		}
+
+		messages++
+		cost += *costPerMsg
+		fmt.Printf("received: %s\n", line)
+		fmt.Printf("Cost: $%.2f\n", cost)
+		if *crashAfter > 0 && messages >= *crashAfter {
+			fmt.Println("Fake runtime: crashing")
+			os.Exit(1)
+		}
+	}
+}
+
+// writeSessionID records the session ID where gt looks for it on resume.
+func writeSessionID(id string) error {
+	dir := ".runtime"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "session_id"), []byte(id+"\n"), 0644) //nolint:gosec // G306: not sensitive
+}
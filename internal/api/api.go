@@ -0,0 +1,175 @@
+// Package api is the town's local control API.
+//
+// The daemon serves versioned JSON endpoints under /v1/ on a Unix socket
+// (daemon/api.sock), and optionally on a loopback TCP address set by
+// daemon.api_listen in mayor/config.json. Socket access is governed by file
+// permissions; TCP requests need the bearer token in daemon/api.token.
+//
+// The endpoints are described by the OpenAPI document at /v1/openapi.json.
+// Client wraps them for Go callers so tooling doesn't shell out to gt and
+// scrape its output.
+package api
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// Version is the API version prefix served by this package.
+const Version = "v1"
+
+// Files relative to the town root.
+const (
+	SocketFile = "daemon/api.sock"
+	TokenFile  = "daemon/api.token"
+)
+
+// SocketPath returns the API socket path for a town.
+func SocketPath(townRoot string) string {
+	return filepath.Join(townRoot, SocketFile)
+}
+
+// TokenPath returns the path of the token required for TCP access.
+func TokenPath(townRoot string) string {
+	return filepath.Join(townRoot, TokenFile)
+}
+
+// Status is the town overview returned by GET /v1/status.
+type Status struct {
+	APIVersion string       `json:"api_version"`
+	Town       string       `json:"town"`
+	Root       string       `json:"root"`
+	Daemon     DaemonStatus `json:"daemon"`
+	Rigs       []RigStatus  `json:"rigs"`
+}
+
+// DaemonStatus identifies the daemon serving the API.
+type DaemonStatus struct {
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// RigStatus summarizes a rig.
+type RigStatus struct {
+	Name            string   `json:"name"`
+	Polecats        []string `json:"polecats"`
+	Crew            []string `json:"crew"`
+	QueueDepth      int      `json:"queue_depth"`
+	WitnessRunning  bool     `json:"witness_running"`
+	RefineryRunning bool     `json:"refinery_running"`
+}
+
+// Convoy is a convoy bead.
+type Convoy struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status"`
+	Priority    int      `json:"priority"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// SlingRequest is the body of POST /v1/sling.
+type SlingRequest struct {
+	Bead     string `json:"bead"`                // issue or formula to sling
+	Target   string `json:"target,omitempty"`    // rig, agent or polecat
+	NoConvoy bool   `json:"no_convoy,omitempty"` // skip auto-convoy creation
+}
+
+// SlingResult is returned by POST /v1/sling: where the bead was hooked.
+// Formulas slung without a bead report only Bead.
+type SlingResult struct {
+	Bead     string `json:"bead"`
+	Status   string `json:"status,omitempty"`
+	Assignee string `json:"assignee,omitempty"` // agent address, e.g. gastown/polecats/Toast
+	Rig      string `json:"rig,omitempty"`
+	Polecat  string `json:"polecat,omitempty"` // set when slung to a polecat
+}
+
+// MailMessage is a message in a mailbox.
+type MailMessage struct {
+	ID       string    `json:"id"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Subject  string    `json:"subject"`
+	Body     string    `json:"body"`
+	Priority string    `json:"priority"`
+	Type     string    `json:"type"`
+	ThreadID string    `json:"thread_id,omitempty"`
+	Time     time.Time `json:"time"`
+	Read     bool      `json:"read"`
+}
+
+// SendMailRequest is the body of POST /v1/mail.
+type SendMailRequest struct {
+	From     string `json:"from,omitempty"` // default "overseer"
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body,omitempty"`
+	Priority string `json:"priority,omitempty"` // low, normal, high, urgent
+	Type     string `json:"type,omitempty"`     // task, scavenge, notification, reply
+}
+
+// MergeRequest is an entry in a rig's merge queue.
+type MergeRequest struct {
+	ID          string    `json:"id"`
+	Branch      string    `json:"branch"`
+	Target      string    `json:"target"`
+	SourceIssue string    `json:"source_issue"`
+	Worker      string    `json:"worker"`
+	Title       string    `json:"title,omitempty"`
+	Priority    int       `json:"priority"`
+	Score       float64   `json:"score"`
+	CreatedAt   time.Time `json:"created_at"`
+	RetryCount  int       `json:"retry_count,omitempty"`
+	ClaimedBy   string    `json:"claimed_by,omitempty"`
+	BlockedBy   string    `json:"blocked_by,omitempty"`
+	ConvoyID    string    `json:"convoy_id,omitempty"`
+	PRURL       string    `json:"pr_url,omitempty"`
+}
+
+// SubmitRequest is the body of POST /v1/rigs/{rig}/mq.
+type SubmitRequest struct {
+	Branch   string `json:"branch"`
+	Issue    string `json:"issue,omitempty"`    // default: parsed from the branch
+	Epic     string `json:"epic,omitempty"`     // target the epic's integration branch
+	Priority *int   `json:"priority,omitempty"` // default: inherited from the issue
+}
+
+// Polecat is a polecat in a rig.
+type Polecat struct {
+	Name    string `json:"name"`
+	Rig     string `json:"rig"`
+	State   string `json:"state"`
+	Issue   string `json:"issue,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	Session string `json:"session"`
+	Running bool   `json:"running"`
+}
+
+// AddPolecatRequest is the body of POST /v1/rigs/{rig}/polecats.
+type AddPolecatRequest struct {
+	Name string `json:"name"`
+}
+
+// NukeResult is returned by DELETE /v1/rigs/{rig}/polecats/{name}.
+type NukeResult struct {
+	Name           string `json:"name"`
+	Rig            string `json:"rig"`
+	SessionStopped bool   `json:"session_stopped"`
+	BranchDeleted  string `json:"branch_deleted,omitempty"`
+}
+
+// Error is the body of every non-2xx response, and the error type Client
+// returns for them. Output carries gt's output when a sling fails.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"error"`
+	Output     string `json:"output,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// setupTown creates a town with one rig, "gastown", holding one queued MR.
+func setupTown(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	rigsConfig := &config.RigsConfig{
+		Version: 1,
+		Rigs:    map[string]config.RigEntry{"gastown": {GitURL: "https://example.com/gastown.git"}},
+	}
+	if err := config.SaveRigsConfig(constants.MayorRigsPath(root), rigsConfig); err != nil {
+		t.Fatalf("saving rigs config: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "gastown", "mayor", "rig"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := mrqueue.New(filepath.Join(root, "gastown")).Submit(&mrqueue.MR{
+		Branch:      "polecat/Toast/gt-abc",
+		Target:      "main",
+		SourceIssue: "gt-abc",
+		Worker:      "Toast",
+		Priority:    1,
+	})
+	if err != nil {
+		t.Fatalf("submitting MR: %v", err)
+	}
+	return root
+}
+
+type gtCall struct {
+	dir  string
+	args []string
+}
+
+// fakeGT records gt invocations instead of running them.
+type fakeGT struct {
+	calls []gtCall
+	err   error
+}
+
+func (f *fakeGT) run(_ context.Context, dir string, args ...string) (string, error) {
+	f.calls = append(f.calls, gtCall{dir, args})
+	if f.err != nil {
+		return "partial output", f.err
+	}
+	return "done", nil
+}
+
+func newTestServer(t *testing.T, root string) (*Server, *fakeGT, *Client) {
+	t.Helper()
+	s, err := NewServer(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gt := &fakeGT{}
+	s.runGT = gt.run
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, gt, NewTCPClient(ts.URL, "")
+}
+
+func TestServer_Reads(t *testing.T) {
+	root := setupTown(t)
+	_, _, c := newTestServer(t, root)
+	ctx := context.Background()
+
+	status, err := c.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.APIVersion != Version || status.Root != root || status.Daemon.PID != os.Getpid() {
+		t.Errorf("Status = %+v", status)
+	}
+	if len(status.Rigs) != 1 || status.Rigs[0].Name != "gastown" || status.Rigs[0].QueueDepth != 1 {
+		t.Errorf("Status.Rigs = %+v", status.Rigs)
+	}
+
+	queue, err := c.Queue(ctx, "gastown")
+	if err != nil {
+		t.Fatalf("Queue: %v", err)
+	}
+	if len(queue) != 1 || queue[0].Branch != "polecat/Toast/gt-abc" || queue[0].Score == 0 {
+		t.Errorf("Queue = %+v", queue)
+	}
+
+	polecats, err := c.Polecats(ctx, "gastown")
+	if err != nil {
+		t.Fatalf("Polecats: %v", err)
+	}
+	if len(polecats) != 0 {
+		t.Errorf("Polecats = %+v, want none", polecats)
+	}
+
+	_, err = c.Queue(ctx, "nope")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Queue(nope) error = %v, want 404", err)
+	}
+
+	if _, err := c.Mail(ctx, "", false); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Mail without address error = %v, want 400", err)
+	}
+	if _, err := c.Convoys(ctx, "pending"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Convoys(pending) error = %v, want 400", err)
+	}
+}
+
+// initRigClone makes gastown/mayor/rig a clone of a local origin, with
+// polecat/Toast/gt-new pushed and polecat/Toast/gt-local committed only
+// locally.
+func initRigClone(t *testing.T, root string) {
+	t.Helper()
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	clone := filepath.Join(root, "gastown", "mayor", "rig")
+	if err := os.RemoveAll(clone); err != nil {
+		t.Fatal(err)
+	}
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run(root, "init", "--bare", "-b", "main", origin)
+	run(root, "clone", origin, clone)
+	run(clone, "commit", "--allow-empty", "-m", "initial")
+	run(clone, "push", "origin", "HEAD:main")
+	run(clone, "checkout", "-b", "polecat/Toast/gt-new")
+	run(clone, "commit", "--allow-empty", "-m", "work")
+	run(clone, "push", "origin", "polecat/Toast/gt-new")
+	run(clone, "checkout", "-b", "polecat/Toast/gt-local")
+	run(clone, "commit", "--allow-empty", "-m", "unpushed")
+	run(clone, "checkout", "main")
+}
+
+func TestServer_Commands(t *testing.T) {
+	root := setupTown(t)
+	initRigClone(t, root)
+	_, gt, c := newTestServer(t, root)
+	ctx := context.Background()
+	var apiErr *Error
+
+	t.Run("sling", func(t *testing.T) {
+		gt.calls = nil
+		res, err := c.Sling(ctx, SlingRequest{Bead: "gt-abc", Target: "gastown", NoConvoy: true})
+		if err != nil {
+			t.Fatalf("Sling: %v", err)
+		}
+		if res.Bead != "gt-abc" {
+			t.Errorf("result = %+v", res)
+		}
+		want := []gtCall{{root, []string{"sling", "gt-abc", "gastown", "--no-convoy"}}}
+		if !reflect.DeepEqual(gt.calls, want) {
+			t.Errorf("gt calls = %v, want %v", gt.calls, want)
+		}
+	})
+
+	t.Run("rejects flag injection", func(t *testing.T) {
+		gt.calls = nil
+		_, err := c.Sling(ctx, SlingRequest{Bead: "--help"})
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("error = %v, want 400", err)
+		}
+		if len(gt.calls) != 0 {
+			t.Errorf("gt ran: %v", gt.calls)
+		}
+	})
+
+	t.Run("sling failure", func(t *testing.T) {
+		gt.err = fmt.Errorf("gt sling: bead not found")
+		defer func() { gt.err = nil }()
+		_, err := c.Sling(ctx, SlingRequest{Bead: "gt-zzz"})
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("error = %v, want 422", err)
+		}
+		if apiErr.Message != "gt sling: bead not found" || apiErr.Output != "partial output" {
+			t.Errorf("error = %+v", apiErr)
+		}
+	})
+
+	t.Run("submit", func(t *testing.T) {
+		prio := 0
+		mr, err := c.Submit(ctx, "gastown", SubmitRequest{Branch: "polecat/Toast/gt-new", Priority: &prio})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+		if mr.SourceIssue != "gt-new" || mr.Worker != "Toast" || mr.Target != "main" || mr.Priority != 0 {
+			t.Errorf("MR = %+v", mr)
+		}
+		queue, _ := c.Queue(ctx, "gastown")
+		if len(queue) != 2 {
+			t.Errorf("queue has %d MRs, want 2", len(queue))
+		}
+
+		_, err = c.Submit(ctx, "gastown", SubmitRequest{Branch: "polecat/Toast/gt-local"})
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("unpushed branch error = %v, want 422", err)
+		}
+		_, err = c.Submit(ctx, "gastown", SubmitRequest{Branch: "feature"})
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("branch without issue error = %v, want 400", err)
+		}
+	})
+
+	t.Run("polecats", func(t *testing.T) {
+		p, err := c.AddPolecat(ctx, "gastown", "Nux")
+		if err != nil {
+			t.Fatalf("AddPolecat: %v", err)
+		}
+		if p.Name != "Nux" || p.Rig != "gastown" || p.Branch == "" || p.Running {
+			t.Errorf("polecat = %+v", p)
+		}
+		if _, err := os.Stat(filepath.Join(root, "gastown", "polecats", "Nux")); err != nil {
+			t.Errorf("worktree not created: %v", err)
+		}
+		_, err = c.AddPolecat(ctx, "gastown", "Nux")
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict {
+			t.Errorf("duplicate add error = %v, want 409", err)
+		}
+		_, err = c.StartPolecat(ctx, "gastown", "Ghost")
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			t.Errorf("start unknown error = %v, want 404", err)
+		}
+
+		res, err := c.NukePolecat(ctx, "gastown", "Nux", true)
+		if err != nil {
+			t.Fatalf("NukePolecat: %v", err)
+		}
+		if res.Name != "Nux" || res.BranchDeleted != p.Branch || res.SessionStopped {
+			t.Errorf("nuke = %+v", res)
+		}
+		if _, err := os.Stat(filepath.Join(root, "gastown", "polecats", "Nux")); !os.IsNotExist(err) {
+			t.Errorf("worktree still present: %v", err)
+		}
+	})
+}
+
+func TestServer_TokenAuth(t *testing.T) {
+	s, err := NewServer(setupTown(t), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.TCPHandler("secret"))
+	defer ts.Close()
+	ctx := context.Background()
+
+	_, err = NewTCPClient(ts.URL, "wrong").Status(ctx)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("wrong token error = %v, want 401", err)
+	}
+	if _, err := NewTCPClient(ts.URL, "secret").Status(ctx); err != nil {
+		t.Errorf("Status with token: %v", err)
+	}
+
+	for _, addr := range []string{"0.0.0.0:7070", "192.168.1.5:7070", ":7070"} {
+		if _, err := NewServer(t.TempDir(), Options{Listen: addr}); err == nil {
+			t.Errorf("NewServer accepted non-loopback address %s", addr)
+		}
+	}
+}
+
+func TestServer_Socket(t *testing.T) {
+	root := setupTown(t)
+	s, err := NewServer(root, Options{Listen: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	status, err := NewClient(root).Status(ctx)
+	if err != nil {
+		t.Fatalf("Status over socket: %v", err)
+	}
+	if status.Root != root {
+		t.Errorf("Root = %q, want %q", status.Root, root)
+	}
+
+	info, err := os.Stat(TokenPath(root))
+	if err != nil {
+		t.Fatalf("token not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	cancel()
+	done := make(chan struct{})
+	go func() { s.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	if _, err := os.Stat(SocketPath(root)); !os.IsNotExist(err) {
+		t.Errorf("socket not removed: %v", err)
+	}
+}
+
+// TestOpenAPICoversRoutes keeps openapi.json in step with the routes table.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("parsing openapi.json: %v", err)
+	}
+
+	described := 0
+	for _, ops := range doc.Paths {
+		described += len(ops)
+	}
+	if described != len(routes) {
+		t.Errorf("openapi.json describes %d operations, server has %d routes", described, len(routes))
+	}
+	for _, rt := range routes {
+		if _, ok := doc.Paths[rt.path][strings.ToLower(rt.method)]; !ok {
+			t.Errorf("openapi.json is missing %s %s", rt.method, rt.path)
+		}
+	}
+}
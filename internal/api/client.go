@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls a town's control API.
+type Client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+// NewClient returns a client for the daemon socket of the town at townRoot.
+func NewClient(townRoot string) *Client {
+	path := SocketPath(townRoot)
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{
+		base: "http://gt",
+		http: &http.Client{Transport: transport, Timeout: commandTimeout + 10*time.Second},
+	}
+}
+
+// NewTCPClient returns a client for the API at addr (host:port or a base
+// URL), authenticating with token.
+func NewTCPClient(addr, token string) *Client {
+	base := addr
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	return &Client{
+		base:  strings.TrimSuffix(base, "/"),
+		token: token,
+		http:  &http.Client{Timeout: commandTimeout + 10*time.Second},
+	}
+}
+
+// do sends a request and decodes the JSON response into out. Non-2xx
+// responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	u := c.base + "/" + Version + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = fmt.Sprintf("%s %s: %s", method, path, resp.Status)
+		}
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", path, err)
+	}
+	return nil
+}
+
+// Status returns the town overview.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var out Status
+	if err := c.do(ctx, http.MethodGet, "/status", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Convoys lists convoys with the given status ("open", "closed", "all";
+// empty means open).
+func (c *Client) Convoys(ctx context.Context, status string) ([]Convoy, error) {
+	q := url.Values{}
+	if status != "" {
+		q.Set("status", status)
+	}
+	var out []Convoy
+	err := c.do(ctx, http.MethodGet, "/convoys", q, nil, &out)
+	return out, err
+}
+
+// Convoy returns a convoy by ID.
+func (c *Client) Convoy(ctx context.Context, id string) (*Convoy, error) {
+	var out Convoy
+	if err := c.do(ctx, http.MethodGet, "/convoys/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Sling slings a bead to a target.
+func (c *Client) Sling(ctx context.Context, req SlingRequest) (*SlingResult, error) {
+	var out SlingResult
+	if err := c.do(ctx, http.MethodPost, "/sling", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Mail lists the messages in a mailbox, newest first.
+func (c *Client) Mail(ctx context.Context, address string, unreadOnly bool) ([]MailMessage, error) {
+	q := url.Values{"address": {address}}
+	if unreadOnly {
+		q.Set("unread", "true")
+	}
+	var out []MailMessage
+	err := c.do(ctx, http.MethodGet, "/mail", q, nil, &out)
+	return out, err
+}
+
+// Message returns one message from a mailbox.
+func (c *Client) Message(ctx context.Context, address, id string) (*MailMessage, error) {
+	var out MailMessage
+	if err := c.do(ctx, http.MethodGet, "/mail/"+url.PathEscape(id), url.Values{"address": {address}}, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// MarkRead marks a message read and returns it.
+func (c *Client) MarkRead(ctx context.Context, address, id string) (*MailMessage, error) {
+	var out MailMessage
+	if err := c.do(ctx, http.MethodPost, "/mail/"+url.PathEscape(id)+"/read", url.Values{"address": {address}}, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SendMail sends a message and returns it as sent.
+func (c *Client) SendMail(ctx context.Context, req SendMailRequest) (*MailMessage, error) {
+	var out MailMessage
+	if err := c.do(ctx, http.MethodPost, "/mail", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Queue lists a rig's merge queue in processing order.
+func (c *Client) Queue(ctx context.Context, rig string) ([]MergeRequest, error) {
+	var out []MergeRequest
+	err := c.do(ctx, http.MethodGet, "/rigs/"+url.PathEscape(rig)+"/mq", nil, nil, &out)
+	return out, err
+}
+
+// Submit submits a branch to a rig's merge queue.
+func (c *Client) Submit(ctx context.Context, rig string, req SubmitRequest) (*MergeRequest, error) {
+	var out MergeRequest
+	if err := c.do(ctx, http.MethodPost, "/rigs/"+url.PathEscape(rig)+"/mq", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Polecats lists a rig's polecats.
+func (c *Client) Polecats(ctx context.Context, rig string) ([]Polecat, error) {
+	var out []Polecat
+	err := c.do(ctx, http.MethodGet, polecatsPath(rig, ""), nil, nil, &out)
+	return out, err
+}
+
+// AddPolecat adds a polecat to a rig.
+func (c *Client) AddPolecat(ctx context.Context, rig, name string) (*Polecat, error) {
+	return c.polecat(ctx, http.MethodPost, polecatsPath(rig, ""), AddPolecatRequest{Name: name})
+}
+
+// StartPolecat starts a polecat's session.
+func (c *Client) StartPolecat(ctx context.Context, rig, name string) (*Polecat, error) {
+	return c.polecat(ctx, http.MethodPost, polecatsPath(rig, name)+"/start", nil)
+}
+
+// StopPolecat stops a polecat's session.
+func (c *Client) StopPolecat(ctx context.Context, rig, name string) (*Polecat, error) {
+	return c.polecat(ctx, http.MethodPost, polecatsPath(rig, name)+"/stop", nil)
+}
+
+// RestartPolecat restarts a polecat's session.
+func (c *Client) RestartPolecat(ctx context.Context, rig, name string) (*Polecat, error) {
+	return c.polecat(ctx, http.MethodPost, polecatsPath(rig, name)+"/restart", nil)
+}
+
+// NukePolecat removes a polecat. force stops a running session and skips
+// the hooked- and uncommitted-work checks.
+func (c *Client) NukePolecat(ctx context.Context, rig, name string, force bool) (*NukeResult, error) {
+	var q url.Values
+	if force {
+		q = url.Values{"force": {"true"}}
+	}
+	var out NukeResult
+	if err := c.do(ctx, http.MethodDelete, polecatsPath(rig, name), q, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) polecat(ctx context.Context, method, path string, in interface{}) (*Polecat, error) {
+	var out Polecat
+	if err := c.do(ctx, method, path, nil, in, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func polecatsPath(rig, name string) string {
+	p := "/rigs/" + url.PathEscape(rig) + "/polecats"
+	if name != "" {
+		p += "/" + url.PathEscape(name)
+	}
+	return p
+}
@@ -0,0 +1,592 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/util"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+func (s *Server) rigs() *rig.Manager {
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(s.townRoot))
+	if err != nil {
+		rigsConfig = &config.RigsConfig{Rigs: make(map[string]config.RigEntry)}
+	}
+	return rig.NewManager(s.townRoot, rigsConfig, git.NewGit(s.townRoot))
+}
+
+// rig resolves the {rig} path parameter, writing a 404 if it isn't one.
+func (s *Server) rig(w http.ResponseWriter, r *http.Request) (*rig.Rig, bool) {
+	name := r.PathValue("rig")
+	rg, err := s.rigs().GetRig(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "rig not found: %s", name)
+		return nil, false
+	}
+	return rg, true
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	rigs, err := s.rigs().DiscoverRigs()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "discovering rigs: %v", err)
+		return
+	}
+	town, _ := workspace.GetTownName(s.townRoot)
+	status := Status{
+		APIVersion: Version,
+		Town:       town,
+		Root:       s.townRoot,
+		Daemon:     DaemonStatus{PID: os.Getpid(), StartedAt: s.started},
+		Rigs:       make([]RigStatus, 0, len(rigs)),
+	}
+	t := tmux.NewTmux()
+	for _, rg := range rigs {
+		rs := RigStatus{
+			Name:       rg.Name,
+			Polecats:   nonNil(rg.Polecats),
+			Crew:       nonNil(rg.Crew),
+			QueueDepth: mrqueue.New(rg.Path).Count(),
+		}
+		rs.WitnessRunning, _ = t.HasSession(session.WitnessSessionName(rg.Name))
+		rs.RefineryRunning, _ = t.HasSession(session.RefinerySessionName(rg.Name))
+		status.Rigs = append(status.Rigs, rs)
+	}
+	sort.Slice(status.Rigs, func(i, j int) bool { return status.Rigs[i].Name < status.Rigs[j].Name })
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *Server) handleConvoys(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "open"
+	}
+	if status != "open" && status != "closed" && status != "all" {
+		writeError(w, http.StatusBadRequest, "status must be open, closed or all")
+		return
+	}
+	issues, err := beads.SharedStore(s.townRoot).List(beads.ListOptions{Type: "convoy", Status: status, Priority: -1})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "listing convoys: %v", err)
+		return
+	}
+	convoys := make([]Convoy, 0, len(issues))
+	for _, issue := range issues {
+		convoys = append(convoys, convoyOf(issue))
+	}
+	writeJSON(w, http.StatusOK, convoys)
+}
+
+func (s *Server) handleConvoy(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	issue, err := beads.SharedStore(s.townRoot).Show(id)
+	if errors.Is(err, beads.ErrNotFound) || (err == nil && issue.Type != "convoy") {
+		writeError(w, http.StatusNotFound, "convoy not found: %s", id)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "reading convoy %s: %v", id, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, convoyOf(issue))
+}
+
+func convoyOf(issue *beads.Issue) Convoy {
+	return Convoy{
+		ID:          issue.ID,
+		Title:       issue.Title,
+		Description: issue.Description,
+		Status:      issue.Status,
+		Priority:    issue.Priority,
+		CreatedAt:   issue.CreatedAt,
+		UpdatedAt:   issue.UpdatedAt,
+		Labels:      issue.Labels,
+	}
+}
+
+func (s *Server) handleSling(w http.ResponseWriter, r *http.Request) {
+	var req SlingRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !util.IsSafeName(req.Bead) {
+		writeError(w, http.StatusBadRequest, "invalid bead %q", req.Bead)
+		return
+	}
+	args := []string{"sling", req.Bead}
+	if req.Target != "" {
+		if !util.IsSafeName(req.Target) {
+			writeError(w, http.StatusBadRequest, "invalid target %q", req.Target)
+			return
+		}
+		args = append(args, req.Target)
+	}
+	if req.NoConvoy {
+		args = append(args, "--no-convoy")
+	}
+	// Slinging resolves targets, spawns polecats and creates convoys in gt
+	// itself, so it runs as a command; the result is read back from the bead.
+	output, err := s.runGT(r.Context(), s.townRoot, args...)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, &Error{Message: err.Error(), Output: output})
+		return
+	}
+	writeJSON(w, http.StatusOK, s.slingResult(req.Bead))
+}
+
+// slingResult reports where a slung bead went. Formulas slung without a
+// bead have no issue to read, so only the bead is set.
+func (s *Server) slingResult(bead string) SlingResult {
+	res := SlingResult{Bead: bead}
+	store := beads.SharedStore(s.townRoot)
+	store.Invalidate()
+	issue, err := store.Show(bead)
+	if err != nil {
+		return res
+	}
+	res.Status = issue.Status
+	res.Assignee = issue.Assignee
+	if rigName, rest, ok := strings.Cut(issue.Assignee, "/"); ok {
+		res.Rig = rigName
+		res.Polecat, _ = strings.CutPrefix(rest, "polecats/")
+		if res.Polecat == rest {
+			res.Polecat = ""
+		}
+	}
+	return res
+}
+
+// mailbox opens the mailbox named by the address query parameter.
+func (s *Server) mailbox(w http.ResponseWriter, r *http.Request) (*mail.Mailbox, bool) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeError(w, http.StatusBadRequest, "address is required")
+		return nil, false
+	}
+	mailbox, err := mail.NewRouterWithTownRoot(s.townRoot, s.townRoot).GetMailbox(address)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "opening mailbox %s: %v", address, err)
+		return nil, false
+	}
+	return mailbox, true
+}
+
+func (s *Server) handleMailList(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := s.mailbox(w, r)
+	if !ok {
+		return
+	}
+	list := mailbox.List
+	if r.URL.Query().Get("unread") == "true" {
+		list = mailbox.ListUnread
+	}
+	messages, err := list()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "listing mail: %v", err)
+		return
+	}
+	out := make([]MailMessage, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, messageOf(m))
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time.After(out[j].Time) })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleMailGet(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := s.mailbox(w, r)
+	if !ok {
+		return
+	}
+	m, err := mailbox.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, mailStatus(err), "reading message: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, messageOf(m))
+}
+
+func (s *Server) handleMailRead(w http.ResponseWriter, r *http.Request) {
+	mailbox, ok := s.mailbox(w, r)
+	if !ok {
+		return
+	}
+	m, err := mailbox.Get(r.PathValue("id"))
+	if err != nil {
+		writeError(w, mailStatus(err), "reading message: %v", err)
+		return
+	}
+	if err := mailbox.MarkRead(m.ID); err != nil {
+		writeError(w, mailStatus(err), "marking message read: %v", err)
+		return
+	}
+	m.Read = true
+	writeJSON(w, http.StatusOK, messageOf(m))
+}
+
+func (s *Server) handleMailSend(w http.ResponseWriter, r *http.Request) {
+	var req SendMailRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.To == "" || req.Subject == "" {
+		writeError(w, http.StatusBadRequest, "to and subject are required")
+		return
+	}
+	if req.From == "" {
+		req.From = "overseer"
+	}
+	msg := mail.NewMessage(req.From, req.To, req.Subject, req.Body)
+	if req.Priority != "" {
+		if mail.ParsePriority(req.Priority) != mail.Priority(req.Priority) {
+			writeError(w, http.StatusBadRequest, "invalid priority %q", req.Priority)
+			return
+		}
+		msg.Priority = mail.Priority(req.Priority)
+	}
+	if req.Type != "" {
+		if mail.ParseMessageType(req.Type) != mail.MessageType(req.Type) {
+			writeError(w, http.StatusBadRequest, "invalid type %q", req.Type)
+			return
+		}
+		msg.Type = mail.MessageType(req.Type)
+	}
+	if err := mail.NewRouterWithTownRoot(s.townRoot, s.townRoot).Send(msg); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "sending mail: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, messageOf(msg))
+}
+
+func mailStatus(err error) int {
+	if errors.Is(err, mail.ErrMessageNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func messageOf(m *mail.Message) MailMessage {
+	return MailMessage{
+		ID:       m.ID,
+		From:     m.From,
+		To:       m.To,
+		Subject:  m.Subject,
+		Body:     m.Body,
+		Priority: string(m.Priority),
+		Type:     string(m.Type),
+		ThreadID: m.ThreadID,
+		Time:     m.Timestamp,
+		Read:     m.Read,
+	}
+}
+
+func (s *Server) handleMQList(w http.ResponseWriter, r *http.Request) {
+	rg, ok := s.rig(w, r)
+	if !ok {
+		return
+	}
+	mrs, err := mrqueue.New(rg.Path).ListByScore()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "reading merge queue: %v", err)
+		return
+	}
+	now := time.Now()
+	out := make([]MergeRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		out = append(out, mergeRequestOf(mr, now))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func mergeRequestOf(mr *mrqueue.MR, now time.Time) MergeRequest {
+	return MergeRequest{
+		ID:          mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		SourceIssue: mr.SourceIssue,
+		Worker:      mr.Worker,
+		Title:       mr.Title,
+		Priority:    mr.Priority,
+		Score:       mr.ScoreAt(now),
+		CreatedAt:   mr.CreatedAt,
+		RetryCount:  mr.RetryCount,
+		ClaimedBy:   mr.ClaimedBy,
+		BlockedBy:   mr.BlockedBy,
+		ConvoyID:    mr.ConvoyID,
+		PRURL:       mr.PRURL,
+	}
+}
+
+func (s *Server) handleMQSubmit(w http.ResponseWriter, r *http.Request) {
+	rg, ok := s.rig(w, r)
+	if !ok {
+		return
+	}
+	var req SubmitRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Branch == "" {
+		writeError(w, http.StatusBadRequest, "branch is required")
+		return
+	}
+	for _, f := range []struct{ name, value string }{
+		{"branch", req.Branch}, {"issue", req.Issue}, {"epic", req.Epic},
+	} {
+		if f.value != "" && !util.IsSafeName(f.value) {
+			writeError(w, http.StatusBadRequest, "invalid %s %q", f.name, f.value)
+			return
+		}
+	}
+	if req.Priority != nil && (*req.Priority < 0 || *req.Priority > 4) {
+		writeError(w, http.StatusBadRequest, "priority must be 0-4")
+		return
+	}
+
+	// Polecat branches are polecat/<worker>/<issue>
+	issue, worker := req.Issue, ""
+	if rest, ok := strings.CutPrefix(req.Branch, "polecat/"); ok {
+		if name, branchIssue, ok := strings.Cut(rest, "/"); ok {
+			worker = name
+			if issue == "" {
+				issue = branchIssue
+			}
+		}
+	}
+	if issue == "" {
+		writeError(w, http.StatusBadRequest, "issue is required for branch %q", req.Branch)
+		return
+	}
+	target := rg.DefaultBranch()
+	if req.Epic != "" {
+		target = "integration/" + req.Epic
+	}
+	if req.Branch == target {
+		writeError(w, http.StatusBadRequest, "cannot submit %s to its own merge queue", target)
+		return
+	}
+
+	// The refinery merges from origin, so the branch must be pushed
+	pushed, err := git.NewGit(rigClone(rg)).RemoteBranchExists("origin", req.Branch)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "checking branch %s: %v", req.Branch, err)
+		return
+	}
+	if !pushed {
+		writeError(w, http.StatusUnprocessableEntity, "branch %s is not pushed to origin", req.Branch)
+		return
+	}
+
+	priority := 2
+	if req.Priority != nil {
+		priority = *req.Priority
+	} else if src, err := beads.SharedStore(rg.Path).Show(issue); err == nil {
+		priority = src.Priority
+	}
+
+	mr := &mrqueue.MR{
+		Branch:      req.Branch,
+		Target:      target,
+		SourceIssue: issue,
+		Worker:      worker,
+		Rig:         rg.Name,
+		Title:       "Merge: " + issue,
+		Priority:    priority,
+	}
+	if group, err := mrqueue.NewLandingStore(s.townRoot).GroupFor(rg.Name, issue); err == nil && group != nil {
+		mr.LandingGroup = group.ID
+	}
+	if err := mrqueue.New(rg.Path).Submit(mr); err != nil {
+		writeError(w, http.StatusInternalServerError, "submitting to merge queue: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mergeRequestOf(mr, time.Now()))
+}
+
+// rigClone returns the rig's canonical clone, or the rig itself if it
+// has none.
+func rigClone(rg *rig.Rig) string {
+	dir := filepath.Join(rg.Path, "mayor", "rig")
+	if _, err := os.Stat(dir); err != nil {
+		return rg.Path
+	}
+	return dir
+}
+
+func (s *Server) handlePolecats(w http.ResponseWriter, r *http.Request) {
+	rg, ok := s.rig(w, r)
+	if !ok {
+		return
+	}
+	polecats, err := polecat.NewManager(rg, git.NewGit(rg.Path)).List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "listing polecats: %v", err)
+		return
+	}
+	t := tmux.NewTmux()
+	out := make([]Polecat, 0, len(polecats))
+	for _, p := range polecats {
+		out = append(out, polecatOf(t, rg, p))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func polecatOf(t *tmux.Tmux, rg *rig.Rig, p *polecat.Polecat) Polecat {
+	pc := Polecat{
+		Name:    p.Name,
+		Rig:     rg.Name,
+		State:   string(p.State),
+		Issue:   p.Issue,
+		Branch:  p.Branch,
+		Session: session.PolecatSessionName(rg.Name, p.Name),
+	}
+	pc.Running, _ = t.HasSession(pc.Session)
+	return pc
+}
+
+func (s *Server) handlePolecatAdd(w http.ResponseWriter, r *http.Request) {
+	rg, ok := s.rig(w, r)
+	if !ok {
+		return
+	}
+	var req AddPolecatRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !util.IsSafeName(req.Name) || strings.Contains(req.Name, "/") {
+		writeError(w, http.StatusBadRequest, "invalid polecat name %q", req.Name)
+		return
+	}
+	p, err := polecat.NewManager(rg, git.NewGit(rg.Path)).Add(req.Name)
+	if errors.Is(err, polecat.ErrPolecatExists) {
+		writeError(w, http.StatusConflict, "polecat %s/%s already exists", rg.Name, req.Name)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "adding polecat: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, polecatOf(tmux.NewTmux(), rg, p))
+}
+
+// polecat resolves the {rig} and {name} path parameters, writing a 404 if
+// the polecat doesn't exist.
+func (s *Server) polecat(w http.ResponseWriter, r *http.Request) (*rig.Rig, *polecat.Manager, *polecat.Polecat, bool) {
+	rg, ok := s.rig(w, r)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	name := r.PathValue("name")
+	mgr := polecat.NewManager(rg, git.NewGit(rg.Path))
+	p, err := mgr.Get(name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "polecat not found: %s/%s", rg.Name, name)
+		return nil, nil, nil, false
+	}
+	return rg, mgr, p, true
+}
+
+func (s *Server) handlePolecatSession(w http.ResponseWriter, r *http.Request) {
+	rg, _, p, ok := s.polecat(w, r)
+	if !ok {
+		return
+	}
+	t := tmux.NewTmux()
+	sessions := session.NewManager(t, rg)
+
+	// The route is .../{name}/{start|stop|restart}.
+	var err error
+	switch action := filepath.Base(r.URL.Path); action {
+	case "start":
+		err = sessions.Start(p.Name, session.StartOptions{})
+	case "stop":
+		err = sessions.Stop(p.Name, false)
+	case "restart":
+		err = sessions.Stop(p.Name, false)
+		if errors.Is(err, session.ErrSessionNotFound) {
+			err = nil
+		}
+		if err == nil {
+			err = sessions.Start(p.Name, session.StartOptions{})
+		}
+	}
+	switch {
+	case errors.Is(err, session.ErrSessionRunning):
+		writeError(w, http.StatusConflict, "session for %s/%s is already running", rg.Name, p.Name)
+	case errors.Is(err, session.ErrSessionNotFound):
+		writeError(w, http.StatusConflict, "session for %s/%s is not running", rg.Name, p.Name)
+	case err != nil:
+		writeError(w, http.StatusUnprocessableEntity, "%v", err)
+	default:
+		writeJSON(w, http.StatusOK, polecatOf(t, rg, p))
+	}
+}
+
+func (s *Server) handlePolecatNuke(w http.ResponseWriter, r *http.Request) {
+	rg, mgr, p, ok := s.polecat(w, r)
+	if !ok {
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+	if !force && p.Issue != "" {
+		writeError(w, http.StatusConflict, "polecat %s/%s has work on hook (%s); use force=true", rg.Name, p.Name, p.Issue)
+		return
+	}
+
+	res := NukeResult{Name: p.Name, Rig: rg.Name}
+	sessions := session.NewManager(tmux.NewTmux(), rg)
+	if running, _ := sessions.IsRunning(p.Name); running {
+		if !force {
+			writeError(w, http.StatusConflict, "polecat %s/%s is running; stop it first or use force=true", rg.Name, p.Name)
+			return
+		}
+		if err := sessions.Stop(p.Name, true); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "stopping session: %v", err)
+			return
+		}
+		res.SessionStopped = true
+	}
+
+	// Without force, uncommitted, stashed or unpushed work blocks removal
+	err := mgr.RemoveWithOptions(p.Name, force, force)
+	var uncommitted *polecat.UncommittedWorkError
+	switch {
+	case errors.As(err, &uncommitted):
+		writeError(w, http.StatusConflict, "%v; use force=true", err)
+		return
+	case err != nil && !errors.Is(err, polecat.ErrPolecatNotFound):
+		writeError(w, http.StatusUnprocessableEntity, "removing polecat: %v", err)
+		return
+	}
+
+	if p.Branch != "" && git.NewGit(rigClone(rg)).DeleteBranch(p.Branch, true) == nil {
+		res.BranchDeleted = p.Branch
+	}
+	// The agent bead may not exist; closing it is best-effort
+	_ = beads.New(rigClone(rg)).CloseWithReason("nuked", beads.PolecatBeadID(rg.Name, p.Name))
+	writeJSON(w, http.StatusOK, res)
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// commandTimeout bounds the gt commands the API runs. Slinging to a rig
+// spawns a polecat.
+const commandTimeout = 2 * time.Minute
+
+// maxBody bounds request bodies.
+const maxBody = 1 << 20
+
+// Options configures a Server.
+type Options struct {
+	// Listen is a loopback TCP address (e.g. "127.0.0.1:7070") to serve on
+	// in addition to the socket. Empty disables TCP.
+	Listen string
+
+	// Token authenticates TCP requests. When empty, the token in
+	// daemon/api.token is used, and generated if missing.
+	Token string
+}
+
+// route is one API endpoint. The OpenAPI document must describe every
+// route; the tests check it.
+type route struct {
+	method  string
+	path    string // OpenAPI path, e.g. /v1/rigs/{rig}/mq
+	handler func(s *Server, w http.ResponseWriter, r *http.Request)
+}
+
+var routes = []route{
+	{http.MethodGet, "/v1/openapi.json", (*Server).handleOpenAPI},
+	{http.MethodGet, "/v1/status", (*Server).handleStatus},
+	{http.MethodGet, "/v1/convoys", (*Server).handleConvoys},
+	{http.MethodGet, "/v1/convoys/{id}", (*Server).handleConvoy},
+	{http.MethodPost, "/v1/sling", (*Server).handleSling},
+	{http.MethodGet, "/v1/mail", (*Server).handleMailList},
+	{http.MethodPost, "/v1/mail", (*Server).handleMailSend},
+	{http.MethodGet, "/v1/mail/{id}", (*Server).handleMailGet},
+	{http.MethodPost, "/v1/mail/{id}/read", (*Server).handleMailRead},
+	{http.MethodGet, "/v1/rigs/{rig}/mq", (*Server).handleMQList},
+	{http.MethodPost, "/v1/rigs/{rig}/mq", (*Server).handleMQSubmit},
+	{http.MethodGet, "/v1/rigs/{rig}/polecats", (*Server).handlePolecats},
+	{http.MethodPost, "/v1/rigs/{rig}/polecats", (*Server).handlePolecatAdd},
+	{http.MethodPost, "/v1/rigs/{rig}/polecats/{name}/start", (*Server).handlePolecatSession},
+	{http.MethodPost, "/v1/rigs/{rig}/polecats/{name}/stop", (*Server).handlePolecatSession},
+	{http.MethodPost, "/v1/rigs/{rig}/polecats/{name}/restart", (*Server).handlePolecatSession},
+	{http.MethodDelete, "/v1/rigs/{rig}/polecats/{name}", (*Server).handlePolecatNuke},
+}
+
+// Server serves the control API for a town.
+type Server struct {
+	townRoot string
+	opts     Options
+	mux      *http.ServeMux
+	started  time.Time
+
+	// runGT runs a gt command in dir and returns its combined output.
+	runGT func(ctx context.Context, dir string, args ...string) (string, error)
+
+	servers []*http.Server
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a server for a town. It refuses non-loopback TCP
+// addresses.
+func NewServer(townRoot string, opts Options) (*Server, error) {
+	if opts.Listen != "" {
+		if err := config.ValidateLoopback(opts.Listen); err != nil {
+			return nil, fmt.Errorf("api listen address: %w", err)
+		}
+	}
+	s := &Server{
+		townRoot: townRoot,
+		opts:     opts,
+		mux:      http.NewServeMux(),
+		started:  time.Now(),
+		runGT:    runGT,
+	}
+	for _, rt := range routes {
+		rt := rt
+		s.mux.HandleFunc(rt.method+" "+rt.path, func(w http.ResponseWriter, r *http.Request) {
+			rt.handler(s, w, r)
+		})
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint: %s %s", r.Method, r.URL.Path)
+	})
+	return s, nil
+}
+
+// Handler returns the API handler without authentication, as served on the
+// socket.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// TCPHandler returns the API handler requiring the bearer token, as served
+// on the TCP address.
+func (s *Server) TCPHandler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		s.mux.ServeHTTP(w, r)
+	})
+}
+
+// Start listens on the socket (replacing a stale one left by a previous
+// daemon) and, if configured, the TCP address, and serves until ctx is
+// cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	path := SocketPath(s.townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating socket directory: %w", err)
+	}
+	_ = os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return fmt.Errorf("restricting %s: %w", path, err)
+	}
+	s.serve(ctx, l, s.Handler())
+
+	if s.opts.Listen != "" {
+		token, err := s.token()
+		if err != nil {
+			return err
+		}
+		tl, err := net.Listen("tcp", s.opts.Listen)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", s.opts.Listen, err)
+		}
+		s.serve(ctx, tl, s.TCPHandler(token))
+	}
+	return nil
+}
+
+func (s *Server) serve(ctx context.Context, l net.Listener, h http.Handler) {
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	s.servers = append(s.servers, srv)
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		_ = srv.Serve(l)
+	}()
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+}
+
+// Wait blocks until the server has shut down.
+func (s *Server) Wait() {
+	s.wg.Wait()
+	_ = os.Remove(SocketPath(s.townRoot))
+}
+
+// token returns the TCP token, reading or creating daemon/api.token.
+func (s *Server) token() (string, error) {
+	if s.opts.Token != "" {
+		return s.opts.Token, nil
+	}
+	path := TokenPath(s.townRoot)
+	if data, err := os.ReadFile(path); err == nil { //nolint:gosec // G304: path is within the town
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating api token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := util.AtomicWriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("writing api token: %w", err)
+	}
+	return token, nil
+}
+
+// runGT runs the gt binary the daemon was started from.
+func runGT(ctx context.Context, dir string, args ...string) (string, error) {
+	gt, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("locating gt: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, gt, args...) //nolint:gosec // G204: args are validated by the handlers
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	output := strings.TrimSpace(out.String())
+	if err != nil {
+		if output == "" {
+			return "", fmt.Errorf("gt %s: %w", args[0], err)
+		}
+		lines := strings.Split(output, "\n")
+		return output, fmt.Errorf("gt %s: %s", args[0], strings.TrimSpace(lines[len(lines)-1]))
+	}
+	return output, nil
+}
+
+// decode reads a JSON request body into v.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, &Error{Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
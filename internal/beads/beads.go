@@ -169,7 +169,7 @@ type DelegationTerms struct {
 
 // ListOptions specifies filters for listing issues.
 type ListOptions struct {
-	Status     string // "open", "closed", "all"
+	Status     string // "open", "closed", "all"; "" lists everything but closed
 	Type       string // "task", "bug", "feature", "epic"
 	Priority   int    // 0-4, -1 for no filter
 	Parent     string // filter by parent ID
@@ -400,6 +400,10 @@ func (b *Beads) ReadyWithType(issueType string) ([]*Issue, error) {
 
 // Show returns detailed information about an issue.
 func (b *Beads) Show(id string) (*Issue, error) {
+	if IsHopRef(id) {
+		return b.showRemote(id)
+	}
+
 	out, err := b.run("show", id, "--json")
 	if err != nil {
 		return nil, err
@@ -425,6 +429,27 @@ func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 		return make(map[string]*Issue), nil
 	}
 
+	// hop:// references are resolved from remote snapshots, not bd
+	var local []string
+	remote := make(map[string]*Issue)
+	for _, id := range ids {
+		if !IsHopRef(id) {
+			local = append(local, id)
+		} else if issue, err := b.showRemote(id); err == nil {
+			remote[id] = issue
+		}
+	}
+	if len(local) < len(ids) {
+		result, err := b.ShowMultiple(local)
+		if err != nil {
+			return nil, err
+		}
+		for id, issue := range remote {
+			result[id] = issue
+		}
+		return result, nil
+	}
+
 	// bd show supports multiple IDs
 	args := append([]string{"show", "--json"}, ids...)
 	out, err := b.run(args...)
@@ -570,6 +595,9 @@ func (b *Beads) CreateWithID(id string, opts CreateOptions) (*Issue, error) {
 
 // Update updates an existing issue.
 func (b *Beads) Update(id string, opts UpdateOptions) error {
+	if IsHopRef(id) {
+		return fmt.Errorf("updating %s: %w", id, ErrRemoteReadOnly)
+	}
 	args := []string{"update", id}
 
 	if opts.Title != nil {
@@ -612,6 +640,11 @@ func (b *Beads) Close(ids ...string) error {
 	if len(ids) == 0 {
 		return nil
 	}
+	for _, id := range ids {
+		if IsHopRef(id) {
+			return fmt.Errorf("closing %s: %w", id, ErrRemoteReadOnly)
+		}
+	}
 
 	args := append([]string{"close"}, ids...)
 
@@ -3,6 +3,7 @@ package beads
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -492,6 +493,68 @@ func FormatSynthesisFields(fields *SynthesisFields) string {
 	return strings.Join(lines, "\n")
 }
 
+// stepOutputPrefix prefixes the keys of step outputs recorded in a molecule
+// step's description, as "output.<name>: value" lines.
+const stepOutputPrefix = "output."
+
+// ParseStepOutputs extracts the outputs recorded on a molecule step.
+// Returns nil if the step has none.
+func ParseStepOutputs(issue *Issue) map[string]string {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+
+	var outputs map[string]string
+	for _, line := range strings.Split(issue.Description, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || !strings.HasPrefix(key, stepOutputPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSpace(key), stepOutputPrefix)
+		if name == "" {
+			continue
+		}
+		if outputs == nil {
+			outputs = make(map[string]string)
+		}
+		outputs[name] = strings.TrimSpace(value)
+	}
+	return outputs
+}
+
+// SetStepOutputs returns the step's description with the given outputs
+// recorded, replacing earlier values of the same outputs.
+func SetStepOutputs(issue *Issue, outputs map[string]string) string {
+	var lines []string
+	if issue != nil && issue.Description != "" {
+		for _, line := range strings.Split(issue.Description, "\n") {
+			key, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+			if ok && strings.HasPrefix(key, stepOutputPrefix) {
+				if _, replaced := outputs[strings.TrimPrefix(strings.TrimSpace(key), stepOutputPrefix)]; replaced {
+					continue
+				}
+			}
+			lines = append(lines, line)
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(lines) > 0 && len(names) > 0 {
+		lines = append(lines, "")
+	}
+	for _, name := range names {
+		lines = append(lines, stepOutputPrefix+name+": "+outputs[name])
+	}
+	return strings.Join(lines, "\n")
+}
+
 // RoleConfig holds structured lifecycle configuration for role beads.
 // These fields are stored as "key: value" lines in the role bead description.
 // This enables agents to self-register their lifecycle configuration,
@@ -22,7 +22,17 @@ func HandoffBeadTitle(role string) string {
 // FindHandoffBead finds the pinned handoff bead for a role by title.
 // Returns nil if not found (not an error).
 func (b *Beads) FindHandoffBead(role string) (*Issue, error) {
-	issues, err := b.List(ListOptions{Status: StatusPinned, Priority: -1})
+	return findHandoffBead(b, role)
+}
+
+// FindHandoffBead finds the pinned handoff bead for a role from the cached
+// snapshot.
+func (s *CachedStore) FindHandoffBead(role string) (*Issue, error) {
+	return findHandoffBead(s, role)
+}
+
+func findHandoffBead(s Store, role string) (*Issue, error) {
+	issues, err := s.List(ListOptions{Status: StatusPinned, Priority: -1})
 	if err != nil {
 		return nil, fmt.Errorf("listing pinned issues: %w", err)
 	}
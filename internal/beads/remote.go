@@ -0,0 +1,481 @@
+package beads
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Federation remotes
+//
+// A remote is another town whose issues this town may reference as
+// hop://entity/chain[/rig]/issue-id. Remotes are registered in
+// mayor/remotes.json with a source: the root of a town on this machine, or
+// a git repository holding a town or a bare beads database. Fetching a
+// remote copies every rig's issues into a read-only snapshot under
+// .runtime/remotes/<name>/; hop references are resolved from that snapshot
+// only, so lookups never touch the remote and never write to it.
+
+// RemotesFileName is the remote town registry in mayor/.
+const RemotesFileName = "remotes.json"
+
+// HopScheme is the URI scheme of cross-town references.
+const HopScheme = "hop://"
+
+// hqRig names a town's own (hq-*) database in remote snapshots.
+const hqRig = "hq"
+
+// ErrRemoteReadOnly is returned for writes to issues in a remote town.
+var ErrRemoteReadOnly = errors.New("remote issues are read-only")
+
+// Remote is a registered remote town.
+type Remote struct {
+	// Name is the local name of the remote (e.g., "acme").
+	Name string `json:"name"`
+
+	// URI is the remote town's hop://entity/chain identity.
+	URI string `json:"uri"`
+
+	// Path is the root of a town on this machine.
+	Path string `json:"path,omitempty"`
+
+	// Git is the URL of a git repository holding the town's beads.
+	Git string `json:"git,omitempty"`
+
+	// Branch is the git branch to fetch (default: the remote's HEAD).
+	Branch string `json:"branch,omitempty"`
+
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Source returns where the remote is fetched from.
+func (r *Remote) Source() string {
+	if r.Git != "" {
+		if r.Branch != "" {
+			return r.Git + "#" + r.Branch
+		}
+		return r.Git
+	}
+	return r.Path
+}
+
+// remotesFile is the JSON structure of mayor/remotes.json.
+type remotesFile struct {
+	Version int                `json:"version"`
+	Remotes map[string]*Remote `json:"remotes"`
+}
+
+// RemotesPath returns the path of the town's remote registry.
+func RemotesPath(townRoot string) string {
+	return filepath.Join(townRoot, "mayor", RemotesFileName)
+}
+
+// RemoteCacheDir returns the directory holding a remote's cached snapshot.
+func RemoteCacheDir(townRoot, name string) string {
+	return filepath.Join(townRoot, ".runtime", "remotes", name)
+}
+
+func loadRemotesFile(townRoot string) (*remotesFile, error) {
+	rf := &remotesFile{Version: 1}
+	data, err := os.ReadFile(RemotesPath(townRoot)) //nolint:gosec // G304: path is constructed internally
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading remotes: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, rf); err != nil {
+			return nil, fmt.Errorf("parsing remotes: %w", err)
+		}
+	}
+	if rf.Remotes == nil {
+		rf.Remotes = make(map[string]*Remote)
+	}
+	return rf, nil
+}
+
+func saveRemotesFile(townRoot string, rf *remotesFile) error {
+	data, err := json.MarshalIndent(rf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling remotes: %w", err)
+	}
+	path := RemotesPath(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating mayor directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644) //nolint:gosec // G306: registry is not sensitive
+}
+
+// LoadRemotes returns the town's registered remotes sorted by name.
+func LoadRemotes(townRoot string) ([]*Remote, error) {
+	rf, err := loadRemotesFile(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	remotes := make([]*Remote, 0, len(rf.Remotes))
+	for _, r := range rf.Remotes {
+		remotes = append(remotes, r)
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Name < remotes[j].Name })
+	return remotes, nil
+}
+
+// GetRemote returns a registered remote by name.
+func GetRemote(townRoot, name string) (*Remote, error) {
+	rf, err := loadRemotesFile(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := rf.Remotes[name]
+	if !ok {
+		return nil, fmt.Errorf("remote '%s' not found", name)
+	}
+	return r, nil
+}
+
+// AddRemote registers a remote. Names and URIs must be unique.
+func AddRemote(townRoot string, r *Remote) error {
+	if r.Name == "" || strings.ContainsAny(r.Name, "/:") {
+		return fmt.Errorf("invalid remote name %q", r.Name)
+	}
+	if (r.Path == "") == (r.Git == "") {
+		return fmt.Errorf("remote %s needs exactly one of a path or a git URL", r.Name)
+	}
+	if _, err := parseTownURI(r.URI); err != nil {
+		return err
+	}
+
+	rf, err := loadRemotesFile(townRoot)
+	if err != nil {
+		return err
+	}
+	if _, ok := rf.Remotes[r.Name]; ok {
+		return fmt.Errorf("remote '%s' already exists", r.Name)
+	}
+	for _, other := range rf.Remotes {
+		if other.URI == r.URI {
+			return fmt.Errorf("%s is already registered as remote '%s'", r.URI, other.Name)
+		}
+	}
+	if r.AddedAt.IsZero() {
+		r.AddedAt = time.Now().UTC()
+	}
+	rf.Remotes[r.Name] = r
+	return saveRemotesFile(townRoot, rf)
+}
+
+// RemoveRemote unregisters a remote and deletes its cached snapshot.
+func RemoveRemote(townRoot, name string) error {
+	rf, err := loadRemotesFile(townRoot)
+	if err != nil {
+		return err
+	}
+	if _, ok := rf.Remotes[name]; !ok {
+		return fmt.Errorf("remote '%s' not found", name)
+	}
+	delete(rf.Remotes, name)
+	if err := saveRemotesFile(townRoot, rf); err != nil {
+		return err
+	}
+	return os.RemoveAll(RemoteCacheDir(townRoot, name))
+}
+
+// TownURI returns the hop://entity/chain identity of the town at root, from
+// its mayor/town.json owner (or public name) and name.
+func TownURI(root string) (string, error) {
+	town, err := config.LoadTownConfig(filepath.Join(root, "mayor", "town.json"))
+	if err != nil {
+		return "", err
+	}
+	entity := town.Owner
+	if entity == "" {
+		entity = town.PublicName
+	}
+	if entity == "" || town.Name == "" {
+		return "", fmt.Errorf("town at %s has no owner or name in mayor/town.json", root)
+	}
+	return HopScheme + entity + "/" + town.Name, nil
+}
+
+// HopRef is a parsed hop://entity/chain[/rig]/issue-id reference.
+type HopRef struct {
+	Entity string
+	Chain  string
+	Rig    string // empty when the reference omits it
+	ID     string
+}
+
+// IsHopRef reports whether s is a hop:// reference.
+func IsHopRef(s string) bool {
+	return strings.HasPrefix(s, HopScheme)
+}
+
+// ParseHopRef parses hop://entity/chain/rig/issue-id or the rig-less
+// hop://entity/chain/issue-id.
+func ParseHopRef(s string) (*HopRef, error) {
+	if !IsHopRef(s) {
+		return nil, fmt.Errorf("invalid hop reference %q: want %sentity/chain/rig/issue-id", s, HopScheme)
+	}
+	parts := strings.Split(strings.TrimPrefix(s, HopScheme), "/")
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid hop reference %q: empty segment", s)
+		}
+	}
+	switch len(parts) {
+	case 3:
+		return &HopRef{Entity: parts[0], Chain: parts[1], ID: parts[2]}, nil
+	case 4:
+		return &HopRef{Entity: parts[0], Chain: parts[1], Rig: parts[2], ID: parts[3]}, nil
+	}
+	return nil, fmt.Errorf("invalid hop reference %q: want %sentity/chain/rig/issue-id", s, HopScheme)
+}
+
+// TownURI returns the hop://entity/chain of the town the reference points into.
+func (h *HopRef) TownURI() string {
+	return HopScheme + h.Entity + "/" + h.Chain
+}
+
+func (h *HopRef) String() string {
+	if h.Rig == "" {
+		return h.TownURI() + "/" + h.ID
+	}
+	return h.TownURI() + "/" + h.Rig + "/" + h.ID
+}
+
+// parseTownURI checks a hop://entity/chain town identity.
+func parseTownURI(uri string) ([]string, error) {
+	parts := strings.Split(strings.TrimPrefix(uri, HopScheme), "/")
+	if !IsHopRef(uri) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid remote URI %q: want %sentity/chain", uri, HopScheme)
+	}
+	return parts, nil
+}
+
+// RemoteSnapshot is a remote town's issues as of its last fetch.
+type RemoteSnapshot struct {
+	Remote    string              `json:"remote"`
+	URI       string              `json:"uri"`
+	Source    string              `json:"source"`
+	FetchedAt time.Time           `json:"fetched_at"`
+	Rigs      map[string][]*Issue `json:"rigs"` // rig name ("hq" for town beads) -> issues
+}
+
+// IssueCount returns the number of issues in the snapshot.
+func (s *RemoteSnapshot) IssueCount() int {
+	n := 0
+	for _, issues := range s.Rigs {
+		n += len(issues)
+	}
+	return n
+}
+
+// Find returns the issue with the given ID, looking in rig first when set.
+func (s *RemoteSnapshot) Find(rig, id string) *Issue {
+	find := func(issues []*Issue) *Issue {
+		for _, issue := range issues {
+			if issue.ID == id {
+				return issue
+			}
+		}
+		return nil
+	}
+	if rig != "" {
+		if issue := find(s.Rigs[rig]); issue != nil {
+			return issue
+		}
+	}
+	for _, issues := range s.Rigs {
+		if issue := find(issues); issue != nil {
+			return issue
+		}
+	}
+	return nil
+}
+
+func remoteSnapshotPath(townRoot, name string) string {
+	return filepath.Join(RemoteCacheDir(townRoot, name), "snapshot.json")
+}
+
+// LoadRemoteSnapshot returns a remote's cached snapshot.
+func LoadRemoteSnapshot(townRoot, name string) (*RemoteSnapshot, error) {
+	data, err := os.ReadFile(remoteSnapshotPath(townRoot, name)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("remote '%s' has not been fetched (run: gt remote fetch %s)", name, name)
+		}
+		return nil, err
+	}
+	var snap RemoteSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parsing snapshot of remote '%s': %w", name, err)
+	}
+	return &snap, nil
+}
+
+// FetchRemote reads every database of the remote town and replaces its
+// cached snapshot. Git remotes are cloned (or updated) into the cache first.
+// If r.URI is empty it is filled in from the fetched town's identity.
+func FetchRemote(townRoot string, r *Remote) (*RemoteSnapshot, error) {
+	cacheDir := RemoteCacheDir(townRoot, r.Name)
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	root := r.Path
+	if r.Git != "" {
+		root = filepath.Join(cacheDir, "repo")
+		if err := syncRemoteRepo(r, root); err != nil {
+			return nil, err
+		}
+	}
+	if r.URI == "" {
+		uri, err := TownURI(root)
+		if err != nil {
+			return nil, fmt.Errorf("remote %s: %w (pass a hop:// URI)", r.Name, err)
+		}
+		r.URI = uri
+	}
+
+	snap := &RemoteSnapshot{
+		Remote:    r.Name,
+		URI:       r.URI,
+		Source:    r.Source(),
+		FetchedAt: time.Now().UTC(),
+		Rigs:      make(map[string][]*Issue),
+	}
+	for rig, beadsDir := range remoteBeadsDirs(root) {
+		// Read the files directly: bd would follow the remote's routes
+		// and daemon, and must never write to another town.
+		data, err := (&CachedStore{beadsDir: beadsDir}).load()
+		if err != nil {
+			continue
+		}
+		issues := make([]*Issue, 0, len(data.issues))
+		for _, issue := range data.issues {
+			issues = append(issues, issue)
+		}
+		sort.Slice(issues, func(i, j int) bool { return issues[i].ID < issues[j].ID })
+		snap.Rigs[rig] = issues
+	}
+	if len(snap.Rigs) == 0 {
+		return nil, fmt.Errorf("remote %s: no beads database found at %s", r.Name, root)
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := remoteSnapshotPath(townRoot, r.Name)
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp)                                    // left read-only by an interrupted fetch
+	if err := os.WriteFile(tmp, data, 0444); err != nil { //nolint:gosec // G306: read-only cache
+		return nil, fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return nil, fmt.Errorf("writing snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// remoteBeadsDirs returns the beads directories of the town (or bare beads
+// repository) at root, keyed by rig name, following its routes.
+func remoteBeadsDirs(root string) map[string]string {
+	dirs := map[string]string{hqRig: ResolveBeadsDir(root)}
+	routes, _ := LoadRoutes(filepath.Join(root, ".beads"))
+	for _, route := range routes {
+		path := strings.Trim(route.Path, "/")
+		if path == "" || path == "." {
+			continue
+		}
+		rig := strings.SplitN(path, "/", 2)[0]
+		dirs[rig] = ResolveBeadsDir(filepath.Join(root, path))
+	}
+	return dirs
+}
+
+// syncRemoteRepo clones a git remote into dir, or updates an existing clone.
+func syncRemoteRepo(r *Remote, dir string) error {
+	git := func(args ...string) error {
+		cmd := exec.Command("git", args...) //nolint:gosec // G204: URL is from the town's remote registry
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		_ = os.RemoveAll(dir)
+		args := []string{"clone", "--depth", "1"}
+		if r.Branch != "" {
+			args = append(args, "--branch", r.Branch)
+		}
+		return git(append(args, r.Git, dir)...)
+	}
+
+	ref := r.Branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := git("-C", dir, "fetch", "--depth", "1", "origin", ref); err != nil {
+		return err
+	}
+	return git("-C", dir, "reset", "--hard", "FETCH_HEAD")
+}
+
+// ResolveHopRef looks up a hop:// reference in the cached snapshot of the
+// registered remote whose URI it points into. The returned issue is a copy
+// whose ID is the reference itself.
+func ResolveHopRef(townRoot, ref string) (*Issue, *RemoteSnapshot, error) {
+	hop, err := ParseHopRef(ref)
+	if err != nil {
+		return nil, nil, err
+	}
+	remote, err := RemoteForURI(townRoot, hop.TownURI())
+	if err != nil {
+		return nil, nil, err
+	}
+	snap, err := LoadRemoteSnapshot(townRoot, remote.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := snap.Find(hop.Rig, hop.ID)
+	if found == nil {
+		return nil, snap, fmt.Errorf("%w: %s in remote '%s' (fetched %s)", ErrNotFound, hop.ID, remote.Name, snap.FetchedAt.Format(time.RFC3339))
+	}
+	issue := *found
+	issue.ID = ref
+	return &issue, snap, nil
+}
+
+// RemoteForURI returns the registered remote for a hop://entity/chain town.
+func RemoteForURI(townRoot, uri string) (*Remote, error) {
+	remotes, err := LoadRemotes(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range remotes {
+		if r.URI == uri {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("no remote registered for %s (run: gt remote add)", uri)
+}
+
+// showRemote resolves a hop:// reference from the enclosing town.
+func (b *Beads) showRemote(ref string) (*Issue, error) {
+	townRoot, err := workspace.Find(b.workDir)
+	if err != nil || townRoot == "" {
+		return nil, fmt.Errorf("resolving %s: not in a Gas Town workspace", ref)
+	}
+	issue, _, err := ResolveHopRef(townRoot, ref)
+	return issue, err
+}
@@ -0,0 +1,129 @@
+package beads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseHopRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    HopRef
+		wantErr bool
+	}{
+		{"hop://steve@example.com/main-town/greenplace/gp-xyz", HopRef{"steve@example.com", "main-town", "greenplace", "gp-xyz"}, false},
+		{"hop://acme.com/eng/ac-123", HopRef{"acme.com", "eng", "", "ac-123"}, false},
+		{"hop://acme.com/eng", HopRef{}, true},
+		{"hop://acme.com//rig/ac-1", HopRef{}, true},
+		{"beads://github/acme/backend/ac-123", HopRef{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHopRef(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHopRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (*got != tt.want || got.String() != tt.ref) {
+			t.Errorf("ParseHopRef(%q) = %+v (%s)", tt.ref, got, got)
+		}
+	}
+}
+
+// writeTown creates a town with a town.json, hq beads and routed rig beads.
+func writeTown(t *testing.T, owner, name string, rigs map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("mayor/town.json", `{"type":"town","version":2,"name":"`+name+`","owner":"`+owner+`"}`)
+	write(".beads/issues.jsonl", `{"id":"hq-1","title":"Town issue","status":"open","issue_type":"task"}`+"\n")
+	routes := `{"prefix":"hq-","path":"."}` + "\n"
+	for rig, issues := range rigs {
+		routes += `{"prefix":"` + rig[:2] + `-","path":"` + rig + `/mayor/rig"}` + "\n"
+		write(rig+"/mayor/rig/.beads/issues.jsonl", issues)
+	}
+	write(".beads/routes.jsonl", routes)
+	return root
+}
+
+func TestRemote_FetchAndResolve(t *testing.T) {
+	remoteTown := writeTown(t, "acme.com", "eng", map[string]string{
+		"backend": `{"id":"ba-1","title":"API","status":"closed","issue_type":"task"}` + "\n" +
+			`{"id":"ba-2","title":"Schema","status":"in_progress","issue_type":"task","assignee":"backend/polecats/nux"}` + "\n",
+	})
+	town := writeTown(t, "steve@example.com", "main", nil)
+
+	uri, err := TownURI(remoteTown)
+	if err != nil || uri != "hop://acme.com/eng" {
+		t.Fatalf("TownURI = %q, %v", uri, err)
+	}
+	remote := &Remote{Name: "acme", URI: uri, Path: remoteTown}
+	if err := AddRemote(town, remote); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddRemote(town, &Remote{Name: "acme2", URI: uri, Path: remoteTown}); err == nil {
+		t.Error("registering the same town twice should fail")
+	}
+
+	// Unfetched remotes point at gt remote fetch.
+	if _, _, err := ResolveHopRef(town, "hop://acme.com/eng/backend/ba-1"); err == nil {
+		t.Fatal("resolved a reference before fetching")
+	}
+
+	snap, err := FetchRemote(town, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.IssueCount() != 3 || len(snap.Rigs["backend"]) != 2 {
+		t.Errorf("snapshot has %d issues in %d rigs", snap.IssueCount(), len(snap.Rigs))
+	}
+
+	issue, _, err := ResolveHopRef(town, "hop://acme.com/eng/backend/ba-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.ID != "hop://acme.com/eng/backend/ba-2" || issue.Status != "in_progress" || issue.Title != "Schema" {
+		t.Errorf("resolved %+v", issue)
+	}
+	if _, _, err := ResolveHopRef(town, "hop://acme.com/eng/ba-404"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing issue: err = %v, want ErrNotFound", err)
+	}
+	if _, _, err := ResolveHopRef(town, "hop://other.org/x/ot-1"); err == nil {
+		t.Error("resolved a reference to an unregistered town")
+	}
+
+	// The snapshot is used, not the live remote.
+	if err := os.WriteFile(filepath.Join(remoteTown, "backend/mayor/rig/.beads/issues.jsonl"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Beads resolves hop references from anywhere in the town and refuses writes.
+	b := New(filepath.Join(town, "mayor"))
+	got, err := b.Show("hop://acme.com/eng/backend/ba-1")
+	if err != nil || got.Status != "closed" {
+		t.Fatalf("Show = %+v, %v", got, err)
+	}
+	many, err := b.ShowMultiple([]string{"hop://acme.com/eng/hq-1", "hop://acme.com/eng/backend/ba-404"})
+	if err != nil || len(many) != 1 || many["hop://acme.com/eng/hq-1"] == nil {
+		t.Errorf("ShowMultiple = %v, %v", many, err)
+	}
+	if err := b.Close("hop://acme.com/eng/backend/ba-2"); !errors.Is(err, ErrRemoteReadOnly) {
+		t.Errorf("Close of remote issue: err = %v, want ErrRemoteReadOnly", err)
+	}
+
+	if err := RemoveRemote(town, "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(RemoteCacheDir(town, "acme")); !os.IsNotExist(err) {
+		t.Errorf("snapshot left behind after remove: %v", err)
+	}
+}
@@ -0,0 +1,514 @@
+package beads
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is the issue store used by gt. *Beads implements it by running the
+// bd CLI; *CachedStore serves the read methods from a cached snapshot of
+// the .beads data files and passes writes through to bd.
+type Store interface {
+	List(opts ListOptions) ([]*Issue, error)
+	Show(id string) (*Issue, error)
+	ShowMultiple(ids []string) (map[string]*Issue, error)
+	ListAgentBeads() (map[string]*Issue, error)
+	Ready() ([]*Issue, error)
+	Blocked() ([]*Issue, error)
+
+	Create(opts CreateOptions) (*Issue, error)
+	Update(id string, opts UpdateOptions) error
+	Close(ids ...string) error
+}
+
+var (
+	_ Store = (*Beads)(nil)
+	_ Store = (*CachedStore)(nil)
+)
+
+// Data files read by CachedStore, relative to the beads directory.
+const (
+	storeDBFile    = "beads.db"
+	storeWALFile   = "beads.db-wal"
+	storeJSONLFile = "issues.jsonl"
+)
+
+// statusTombstone marks deleted issues; they are never returned.
+const statusTombstone = "tombstone"
+
+// CachedStore reads issues directly from a .beads directory: the SQLite
+// database when available, else the issues.jsonl export. Loading from
+// SQLite spawns the sqlite3 CLI (sqlite3 -readonly -json, one query per
+// table); there is no in-process SQLite reader. The parsed snapshot is
+// cached, so reads between reloads don't spawn anything, and is reloaded
+// when any data file's size or modification time changes, or after a
+// write through the store. If neither source can be read, calls fall back
+// to bd.
+//
+// Reads are not routed across databases: ShowMultiple fetches IDs missing
+// from the local snapshot through bd, which follows routes.
+type CachedStore struct {
+	cli      *Beads
+	beadsDir string
+
+	mu    sync.Mutex
+	snap  *storeSnapshot
+	stamp string
+}
+
+// storeSnapshot is one parsed view of the database.
+type storeSnapshot struct {
+	issues map[string]*Issue
+	source string // "sqlite" or "jsonl"
+}
+
+// NewCachedStore creates a cached store for the beads database of workDir,
+// following any .beads/redirect.
+func NewCachedStore(workDir string) *CachedStore {
+	return NewCachedStoreWithBeadsDir(workDir, ResolveBeadsDir(workDir))
+}
+
+// NewCachedStoreWithBeadsDir creates a cached store for an explicit beads
+// directory. bd is run in workDir for writes and fallbacks.
+func NewCachedStoreWithBeadsDir(workDir, beadsDir string) *CachedStore {
+	return &CachedStore{
+		cli:      NewWithBeadsDir(workDir, beadsDir),
+		beadsDir: beadsDir,
+	}
+}
+
+var (
+	sharedStoresMu sync.Mutex
+	sharedStores   = map[string]*CachedStore{}
+)
+
+// SharedStore returns the process-wide CachedStore for workDir's beads
+// database, so long-running processes (daemon, dashboard) and repeated
+// lookups within one command share a single snapshot.
+func SharedStore(workDir string) *CachedStore {
+	beadsDir := ResolveBeadsDir(workDir)
+
+	sharedStoresMu.Lock()
+	defer sharedStoresMu.Unlock()
+	if s, ok := sharedStores[beadsDir]; ok {
+		return s
+	}
+	s := NewCachedStoreWithBeadsDir(filepath.Dir(beadsDir), beadsDir)
+	sharedStores[beadsDir] = s
+	return s
+}
+
+// Invalidate drops the cached snapshot; the next read reloads it.
+func (s *CachedStore) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snap = nil
+	s.stamp = ""
+}
+
+// Source reports which data source served the current snapshot ("sqlite",
+// "jsonl"), or "bd" if reads are falling back to the CLI.
+func (s *CachedStore) Source() string {
+	snap, err := s.snapshot()
+	if err != nil {
+		return "bd"
+	}
+	return snap.source
+}
+
+// snapshot returns the cached snapshot, reloading it if the data files
+// changed since it was loaded.
+func (s *CachedStore) snapshot() (*storeSnapshot, error) {
+	stamp := s.fingerprint()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snap != nil && stamp == s.stamp {
+		return s.snap, nil
+	}
+
+	snap, err := s.load()
+	if err != nil {
+		s.snap, s.stamp = nil, ""
+		return nil, err
+	}
+	s.snap, s.stamp = snap, stamp
+	return snap, nil
+}
+
+// fingerprint summarizes the size and mtime of every data file.
+func (s *CachedStore) fingerprint() string {
+	var sb strings.Builder
+	for _, name := range []string{storeDBFile, storeWALFile, storeJSONLFile} {
+		info, err := os.Stat(filepath.Join(s.beadsDir, name))
+		if err != nil {
+			sb.WriteString("-;")
+			continue
+		}
+		fmt.Fprintf(&sb, "%d:%d;", info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
+
+// load parses the database, preferring SQLite (authoritative) over the
+// JSONL export (which bd flushes after a short delay).
+func (s *CachedStore) load() (*storeSnapshot, error) {
+	dbPath := filepath.Join(s.beadsDir, storeDBFile)
+	if _, err := os.Stat(dbPath); err == nil {
+		if snap, err := loadSQLite(dbPath); err == nil {
+			return snap, nil
+		}
+	}
+
+	jsonlPath := filepath.Join(s.beadsDir, storeJSONLFile)
+	if _, err := os.Stat(jsonlPath); err == nil {
+		return loadJSONL(jsonlPath)
+	}
+	return nil, ErrNotARepo
+}
+
+// storeRecord is an issue as stored on disk. Dependencies are edges
+// (issue_id → depends_on_id), not the resolved IssueDep list of bd show.
+type storeRecord struct {
+	Issue
+	Dependencies []storeDep `json:"dependencies,omitempty"`
+}
+
+// storeDep is one dependency edge.
+type storeDep struct {
+	IssueID     string `json:"issue_id"`
+	DependsOnID string `json:"depends_on_id"`
+	Type        string `json:"type"`
+}
+
+// loadJSONL parses an issues.jsonl export.
+func loadJSONL(path string) (*storeSnapshot, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*storeRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var rec storeRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			continue // Skip corrupt lines, as bd import does
+		}
+		for i := range rec.Dependencies {
+			if rec.Dependencies[i].IssueID == "" {
+				rec.Dependencies[i].IssueID = rec.ID
+			}
+		}
+		records = append(records, &rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	return buildSnapshot("jsonl", records), nil
+}
+
+// loadSQLite reads the issues, dependencies and labels tables with the
+// sqlite3 CLI.
+func loadSQLite(dbPath string) (*storeSnapshot, error) {
+	var records []*storeRecord
+	if err := querySQLite(dbPath, "SELECT * FROM issues", &records); err != nil {
+		return nil, err
+	}
+
+	var deps []storeDep
+	if err := querySQLite(dbPath, "SELECT issue_id, depends_on_id, type FROM dependencies", &deps); err != nil {
+		return nil, err
+	}
+	var labels []struct {
+		IssueID string `json:"issue_id"`
+		Label   string `json:"label"`
+	}
+	if err := querySQLite(dbPath, "SELECT issue_id, label FROM labels", &labels); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*storeRecord, len(records))
+	for _, rec := range records {
+		rec.Labels = nil
+		byID[rec.ID] = rec
+	}
+	for _, d := range deps {
+		if rec, ok := byID[d.IssueID]; ok {
+			rec.Dependencies = append(rec.Dependencies, d)
+		}
+	}
+	for _, l := range labels {
+		if rec, ok := byID[l.IssueID]; ok {
+			rec.Labels = append(rec.Labels, l.Label)
+		}
+	}
+
+	return buildSnapshot("sqlite", records), nil
+}
+
+// querySQLite runs a read-only query and decodes its JSON rows into v.
+func querySQLite(dbPath, query string, v interface{}) error {
+	cmd := exec.Command("sqlite3", "-readonly", "-json", dbPath, query) //nolint:gosec // G204: queries are constants
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sqlite3 %s: %s", query, strings.TrimSpace(stderr.String()))
+	}
+	// sqlite3 prints nothing for an empty result set
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	return json.Unmarshal(stdout.Bytes(), v)
+}
+
+// buildSnapshot resolves dependency edges into the derived Issue fields
+// that bd list/show report.
+func buildSnapshot(source string, records []*storeRecord) *storeSnapshot {
+	snap := &storeSnapshot{
+		issues: make(map[string]*Issue, len(records)),
+		source: source,
+	}
+	for _, rec := range records {
+		if rec.Status == statusTombstone {
+			continue
+		}
+		issue := rec.Issue
+		issue.Parent, issue.Children = "", nil
+		issue.DependsOn, issue.Blocks, issue.BlockedBy = nil, nil, nil
+		issue.Dependencies, issue.Dependents = nil, nil
+		snap.issues[issue.ID] = &issue
+	}
+
+	for _, rec := range records {
+		issue, ok := snap.issues[rec.ID]
+		if !ok {
+			continue
+		}
+		for _, d := range rec.Dependencies {
+			target := snap.issues[d.DependsOnID]
+			issue.Dependencies = append(issue.Dependencies, issueDep(d.DependsOnID, target, d.Type))
+			if target != nil {
+				target.Dependents = append(target.Dependents, issueDep(issue.ID, issue, d.Type))
+			}
+
+			switch d.Type {
+			case "parent-child":
+				issue.Parent = d.DependsOnID
+				if target != nil {
+					target.Children = append(target.Children, issue.ID)
+				}
+			case "blocks":
+				issue.DependsOn = append(issue.DependsOn, d.DependsOnID)
+				if target != nil {
+					target.Blocks = append(target.Blocks, issue.ID)
+					if target.Status != "closed" {
+						issue.BlockedBy = append(issue.BlockedBy, target.ID)
+					}
+				}
+			}
+		}
+	}
+
+	for _, issue := range snap.issues {
+		issue.DependencyCount = len(issue.Dependencies)
+		issue.DependentCount = len(issue.Dependents)
+		issue.BlockedByCount = len(issue.BlockedBy)
+	}
+	return snap
+}
+
+// issueDep describes the other end of a dependency edge. target is nil
+// for external or missing issues.
+func issueDep(id string, target *Issue, depType string) IssueDep {
+	dep := IssueDep{ID: id, DependencyType: depType}
+	if target != nil {
+		dep.Title = target.Title
+		dep.Status = target.Status
+		dep.Priority = target.Priority
+		dep.Type = target.Type
+	}
+	return dep
+}
+
+// matches reports whether an issue passes the list filters. Like bd list,
+// Status "" matches every issue that isn't closed and "all" matches every
+// status.
+func (o ListOptions) matches(issue *Issue) bool {
+	switch o.Status {
+	case "all":
+	case "":
+		if issue.Status == "closed" {
+			return false
+		}
+	default:
+		if issue.Status != o.Status {
+			return false
+		}
+	}
+	if o.Type != "" && issue.Type != o.Type {
+		return false
+	}
+	if o.Priority >= 0 && issue.Priority != o.Priority {
+		return false
+	}
+	if o.Parent != "" && issue.Parent != o.Parent {
+		return false
+	}
+	if o.Assignee != "" && issue.Assignee != o.Assignee {
+		return false
+	}
+	if o.NoAssignee && issue.Assignee != "" {
+		return false
+	}
+	return true
+}
+
+// filter returns copies of the issues matching keep, by priority then age.
+func (snap *storeSnapshot) filter(keep func(*Issue) bool) []*Issue {
+	var out []*Issue
+	for _, issue := range snap.issues {
+		if keep(issue) {
+			c := *issue
+			out = append(out, &c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Priority != out[j].Priority {
+			return out[i].Priority < out[j].Priority
+		}
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// isActive reports whether an issue is open work (not closed or deferred).
+func isActive(issue *Issue) bool {
+	return issue.Status == "open" || issue.Status == "in_progress" || issue.Status == "blocked"
+}
+
+// List returns issues matching the given options.
+func (s *CachedStore) List(opts ListOptions) ([]*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.List(opts)
+	}
+	return snap.filter(opts.matches), nil
+}
+
+// Show returns an issue by ID. IDs not in the local database are looked up
+// through bd.
+func (s *CachedStore) Show(id string) (*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.Show(id)
+	}
+	issue, ok := snap.issues[id]
+	if !ok {
+		return s.cli.Show(id)
+	}
+	c := *issue
+	return &c, nil
+}
+
+// ShowMultiple returns the issues with the given IDs. IDs not in the local
+// database are looked up through bd in a single call.
+func (s *CachedStore) ShowMultiple(ids []string) (map[string]*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.ShowMultiple(ids)
+	}
+
+	result := make(map[string]*Issue, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if issue, ok := snap.issues[id]; ok {
+			c := *issue
+			result[id] = &c
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		remote, err := s.cli.ShowMultiple(missing)
+		if err != nil {
+			return nil, err
+		}
+		for id, issue := range remote {
+			result[id] = issue
+		}
+	}
+	return result, nil
+}
+
+// ListAgentBeads returns all agent beads keyed by ID.
+func (s *CachedStore) ListAgentBeads() (map[string]*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.ListAgentBeads()
+	}
+	result := make(map[string]*Issue)
+	for _, issue := range snap.filter(func(i *Issue) bool { return i.Type == "agent" }) {
+		result[issue.ID] = issue
+	}
+	return result, nil
+}
+
+// Ready returns open issues with no open blockers.
+func (s *CachedStore) Ready() ([]*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.Ready()
+	}
+	return snap.filter(func(i *Issue) bool {
+		return (i.Status == "open" || i.Status == "in_progress") && len(i.BlockedBy) == 0
+	}), nil
+}
+
+// Blocked returns active issues with at least one open blocker.
+func (s *CachedStore) Blocked() ([]*Issue, error) {
+	snap, err := s.snapshot()
+	if err != nil {
+		return s.cli.Blocked()
+	}
+	return snap.filter(func(i *Issue) bool {
+		return isActive(i) && len(i.BlockedBy) > 0
+	}), nil
+}
+
+// Create creates an issue through bd.
+func (s *CachedStore) Create(opts CreateOptions) (*Issue, error) {
+	defer s.Invalidate()
+	return s.cli.Create(opts)
+}
+
+// Update updates an issue through bd.
+func (s *CachedStore) Update(id string, opts UpdateOptions) error {
+	defer s.Invalidate()
+	return s.cli.Update(id, opts)
+}
+
+// Close closes issues through bd.
+func (s *CachedStore) Close(ids ...string) error {
+	defer s.Invalidate()
+	return s.cli.Close(ids...)
+}
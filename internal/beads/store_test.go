@@ -0,0 +1,225 @@
+package beads
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+const storeJSONL = `{"id":"gt-epic","title":"Epic","status":"open","priority":1,"issue_type":"epic","created_at":"2026-01-01T00:00:00Z"}
+{"id":"gt-a","title":"Task A","status":"open","priority":2,"issue_type":"task","assignee":"gastown/polecats/nux","created_at":"2026-01-02T00:00:00Z","labels":["ui"],"dependencies":[{"issue_id":"gt-a","depends_on_id":"gt-epic","type":"parent-child"}]}
+{"id":"gt-b","title":"Task B","status":"open","priority":2,"issue_type":"task","created_at":"2026-01-03T00:00:00Z","dependencies":[{"issue_id":"gt-b","depends_on_id":"gt-a","type":"blocks"}]}
+{"id":"gt-c","title":"Task C","status":"closed","priority":0,"issue_type":"task","created_at":"2026-01-04T00:00:00Z"}
+{"id":"gt-gone","title":"Deleted","status":"tombstone","issue_type":"task"}
+not json
+{"id":"gt-mayor","title":"Mayor","status":"open","priority":2,"issue_type":"agent","hook_bead":"gt-a","created_at":"2026-01-01T00:00:00Z"}
+`
+
+func newJSONLStore(t *testing.T, content string) (*CachedStore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	beadsDir := filepath.Join(dir, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(beadsDir, "issues.jsonl")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return NewCachedStore(dir), path
+}
+
+func issueIDs(issues []*Issue) string {
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.ID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestCachedStore_JSONL(t *testing.T) {
+	s, _ := newJSONLStore(t, storeJSONL)
+	if src := s.Source(); src != "jsonl" {
+		t.Fatalf("Source = %q, want jsonl", src)
+	}
+
+	all, err := s.List(ListOptions{Status: "all", Priority: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := issueIDs(all); got != "gt-c,gt-epic,gt-mayor,gt-a,gt-b" {
+		t.Errorf("List(all) = %s", got)
+	}
+
+	tasks, _ := s.List(ListOptions{Status: "open", Type: "task", Priority: -1, Assignee: "gastown/polecats/nux"})
+	if got := issueIDs(tasks); got != "gt-a" {
+		t.Errorf("List(assignee) = %s", got)
+	}
+
+	a, err := s.Show("gt-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Parent != "gt-epic" || len(a.Blocks) != 1 || a.Blocks[0] != "gt-b" || len(a.Labels) != 1 {
+		t.Errorf("gt-a = %+v", a)
+	}
+	epic, _ := s.Show("gt-epic")
+	if len(epic.Children) != 1 || epic.Dependents[0].Title != "Task A" {
+		t.Errorf("gt-epic = %+v", epic)
+	}
+
+	ready, _ := s.Ready()
+	if got := issueIDs(ready); got != "gt-epic,gt-mayor,gt-a" {
+		t.Errorf("Ready = %s", got)
+	}
+	blocked, _ := s.Blocked()
+	if got := issueIDs(blocked); got != "gt-b" || blocked[0].BlockedByCount != 1 {
+		t.Errorf("Blocked = %s", got)
+	}
+
+	agents, _ := s.ListAgentBeads()
+	if agents["gt-mayor"] == nil || agents["gt-mayor"].HookBead != "gt-a" {
+		t.Errorf("ListAgentBeads = %v", agents)
+	}
+
+	// Callers get copies; the cache is not affected by mutation
+	a.Title = "changed"
+	if again, _ := s.Show("gt-a"); again.Title != "Task A" {
+		t.Error("Show returned a shared pointer into the cache")
+	}
+}
+
+// fakeBdList is a stand-in for `bd list --json` over $BEADS_DIR/issues.jsonl
+// with bd's status filtering: without --status, closed issues are left out.
+const fakeBdList = `#!/bin/sh
+status=
+for a; do case "$a" in --status=*) status=${a#--status=} ;; esac; done
+printf '['; sep=
+while IFS= read -r line; do
+	case "$line" in "{"*) ;; *) continue ;; esac
+	case "$line" in *'"status":"tombstone"'*) continue ;; esac
+	case "$status" in
+	"") case "$line" in *'"status":"closed"'*) continue ;; esac ;;
+	all) ;;
+	*) case "$line" in *"\"status\":\"$status\""*) ;; *) continue ;; esac ;;
+	esac
+	printf '%s%s' "$sep" "$line"; sep=,
+done < "$BEADS_DIR/issues.jsonl"
+echo ']'
+`
+
+func TestCachedStore_ListStatusMatchesBd(t *testing.T) {
+	s, path := newJSONLStore(t, storeJSONL)
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "bd"), []byte(fakeBdList), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	b := New(filepath.Dir(filepath.Dir(path)))
+
+	sorted := func(issues []*Issue) string {
+		var ids []string
+		for _, issue := range issues {
+			ids = append(ids, issue.ID)
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	for _, status := range []string{"", "all", "open", "closed"} {
+		opts := ListOptions{Status: status, Priority: -1}
+		fromBd, err := b.List(opts)
+		if err != nil {
+			t.Fatalf("Beads.List(%q): %v", status, err)
+		}
+		cached, err := s.List(opts)
+		if err != nil {
+			t.Fatalf("CachedStore.List(%q): %v", status, err)
+		}
+		if got, want := sorted(cached), sorted(fromBd); got != want {
+			t.Errorf("status %q: CachedStore.List = %s, Beads.List = %s", status, got, want)
+		}
+	}
+	if open, _ := s.List(ListOptions{Priority: -1}); strings.Contains(issueIDs(open), "gt-c") {
+		t.Errorf("default List included a closed issue: %s", issueIDs(open))
+	}
+}
+
+func TestCachedStore_ReloadsWhenFileChanges(t *testing.T) {
+	s, path := newJSONLStore(t, storeJSONL)
+	if _, err := s.Show("gt-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close gt-a: gt-b is no longer blocked
+	updated := strings.Replace(storeJSONL, `"id":"gt-a","title":"Task A","status":"open"`, `"id":"gt-a","title":"Task A","status":"closed"`, 1)
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	blocked, _ := s.Blocked()
+	if len(blocked) != 0 {
+		t.Errorf("Blocked after gt-a closed = %s", issueIDs(blocked))
+	}
+}
+
+func TestCachedStore_SQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+
+	dir := t.TempDir()
+	beadsDir := filepath.Join(dir, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	schema := `
+CREATE TABLE issues (id TEXT PRIMARY KEY, title TEXT, description TEXT, status TEXT, priority INTEGER,
+  issue_type TEXT, assignee TEXT, created_at TEXT, updated_at TEXT, closed_at TEXT, pinned INTEGER DEFAULT 0);
+CREATE TABLE dependencies (issue_id TEXT, depends_on_id TEXT, type TEXT, created_at TEXT);
+CREATE TABLE labels (issue_id TEXT, label TEXT);
+INSERT INTO issues (id, title, status, priority, issue_type) VALUES
+  ('hq-cv-1', 'Convoy', 'open', 2, 'convoy'),
+  ('gt-x', 'Tracked', 'in_progress', 1, 'task');
+INSERT INTO dependencies VALUES ('hq-cv-1', 'gt-x', 'tracks', NULL);
+INSERT INTO dependencies VALUES ('hq-cv-1', 'external:beads:bd-9', 'tracks', NULL);
+INSERT INTO labels VALUES ('gt-x', 'urgent');
+`
+	cmd := exec.Command("sqlite3", filepath.Join(beadsDir, "beads.db"))
+	cmd.Stdin = strings.NewReader(schema)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("creating db: %v\n%s", err, out)
+	}
+
+	s := NewCachedStore(dir)
+	if src := s.Source(); src != "sqlite" {
+		t.Fatalf("Source = %q, want sqlite", src)
+	}
+
+	convoy, err := s.Show("hq-cv-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(convoy.Dependencies) != 2 {
+		t.Fatalf("Dependencies = %+v", convoy.Dependencies)
+	}
+	dep := convoy.Dependencies[0]
+	if dep.ID != "gt-x" || dep.DependencyType != "tracks" || dep.Status != "in_progress" {
+		t.Errorf("tracked dep = %+v", dep)
+	}
+	if ext := convoy.Dependencies[1]; ext.ID != "external:beads:bd-9" || ext.Status != "" {
+		t.Errorf("external dep = %+v", ext)
+	}
+
+	x, _ := s.Show("gt-x")
+	if len(x.Labels) != 1 || x.Labels[0] != "urgent" {
+		t.Errorf("Labels = %v", x.Labels)
+	}
+}
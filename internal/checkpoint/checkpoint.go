@@ -47,6 +47,15 @@ type Checkpoint struct {
 
 	// Notes contains optional context from the session.
 	Notes string `json:"notes,omitempty"`
+
+	// Trigger records what wrote the checkpoint: TriggerManual,
+	// TriggerScheduled or TriggerPaneDied.
+	Trigger string `json:"trigger,omitempty"`
+
+	// WIPRef and WIPCommit locate a snapshot of uncommitted work, taken
+	// without touching the worktree, index or HEAD.
+	WIPRef    string `json:"wip_ref,omitempty"`
+	WIPCommit string `json:"wip_commit,omitempty"`
 }
 
 // Path returns the checkpoint file path for a given polecat directory.
@@ -0,0 +1,215 @@
+package checkpoint
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// Checkpoint triggers.
+const (
+	TriggerManual    = "manual"    // gt checkpoint write
+	TriggerScheduled = "scheduled" // daemon heartbeat
+	TriggerPaneDied  = "pane-died" // tmux pane-died hook
+	TriggerRestart   = "restart"   // daemon crash restart, when no checkpoint was fresh
+)
+
+// WIPRefPrefix namespaces the refs holding snapshots of uncommitted work.
+// They are local to the rig's repository and never pushed.
+const WIPRefPrefix = "refs/gastown/wip/"
+
+// WIPRef returns the snapshot ref for a polecat's branch, falling back to
+// the polecat name when HEAD is detached.
+func WIPRef(branch, polecat string) string {
+	if branch == "" || branch == "HEAD" {
+		return WIPRefPrefix + polecat
+	}
+	return WIPRefPrefix + branch
+}
+
+// Snapshot captures a polecat's state for crash recovery and writes it as
+// the polecat's checkpoint: git state, the agent's hooked bead and molecule
+// step, and a WIP commit of any uncommitted work. agent is the polecat's
+// address (e.g. "gastown/polecats/Toast"). Notes from an earlier checkpoint
+// for the same hooked bead are kept.
+func Snapshot(polecatDir, agent, trigger string) (*Checkpoint, error) {
+	cp, err := Capture(polecatDir)
+	if err != nil {
+		return nil, err
+	}
+	cp.Trigger = trigger
+	cp.HookedBead = DetectHookedBead(polecatDir, agent)
+	cp.MoleculeID, cp.CurrentStep, cp.StepTitle = DetectMolecule(polecatDir, agent)
+
+	if prev, _ := Read(polecatDir); prev != nil && prev.HookedBead == cp.HookedBead {
+		cp.Notes = prev.Notes
+		if cp.MoleculeID == "" {
+			cp.MoleculeID, cp.CurrentStep, cp.StepTitle = prev.MoleculeID, prev.CurrentStep, prev.StepTitle
+		}
+	}
+
+	if len(cp.ModifiedFiles) > 0 {
+		ref := WIPRef(cp.Branch, filepath.Base(polecatDir))
+		commit, err := SnapshotWIP(polecatDir, ref)
+		if err != nil {
+			return nil, fmt.Errorf("snapshotting uncommitted work: %w", err)
+		}
+		if commit != "" {
+			cp.WIPRef, cp.WIPCommit = ref, commit
+		}
+	}
+
+	if err := Write(polecatDir, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// SnapshotWIP commits the worktree's uncommitted changes, including
+// untracked files that aren't ignored, and points ref at the commit. HEAD,
+// the index and the files are left alone. It returns "" when the worktree
+// matches HEAD.
+func SnapshotWIP(dir, ref string) (string, error) {
+	tmp, err := os.MkdirTemp("", "gt-wip-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	// A scratch index keeps the real one (and anything staged) untouched.
+	env := append(os.Environ(),
+		"GIT_INDEX_FILE="+filepath.Join(tmp, "index"),
+		"GIT_AUTHOR_NAME=gastown", "GIT_AUTHOR_EMAIL=gastown@localhost",
+		"GIT_COMMITTER_NAME=gastown", "GIT_COMMITTER_EMAIL=gastown@localhost",
+	)
+	run := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...) //nolint:gosec // G204: args are constructed internally
+		cmd.Dir = dir
+		cmd.Env = env
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+
+	if _, err := run("read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := run("add", "-A"); err != nil {
+		return "", err
+	}
+	tree, err := run("write-tree")
+	if err != nil {
+		return "", err
+	}
+	headTree, err := run("rev-parse", "HEAD^{tree}")
+	if err != nil {
+		return "", err
+	}
+	if tree == headTree {
+		return "", nil
+	}
+	commit, err := run("commit-tree", tree, "-p", "HEAD", "-m", "WIP checkpoint "+time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return "", err
+	}
+	if _, err := run("update-ref", ref, commit); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// ClearWIP deletes a WIP snapshot ref. A missing ref is not an error.
+func ClearWIP(dir, ref string) error {
+	cmd := exec.Command("git", "update-ref", "-d", ref) //nolint:gosec // G204: ref is built by WIPRef
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("deleting %s: %s", ref, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// DetectHookedBead returns the bead on an agent's hook, if any.
+func DetectHookedBead(workDir, agent string) string {
+	hooked, err := beads.New(workDir).List(beads.ListOptions{
+		Status:   beads.StatusHooked,
+		Assignee: agent,
+		Priority: -1,
+	})
+	if err != nil || len(hooked) == 0 {
+		return ""
+	}
+	return hooked[0].ID
+}
+
+// DetectMolecule returns the molecule step an agent has in progress: the
+// first in-progress issue instantiated from a molecule.
+func DetectMolecule(workDir, agent string) (moleculeID, stepID, stepTitle string) {
+	issues, err := beads.New(workDir).List(beads.ListOptions{
+		Status:   "in_progress",
+		Assignee: agent,
+		Priority: -1,
+	})
+	if err != nil {
+		return "", "", ""
+	}
+	for _, issue := range issues {
+		for _, line := range strings.Split(issue.Description, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "instantiated_from:") {
+				return strings.TrimSpace(strings.TrimPrefix(line, "instantiated_from:")), issue.ID, issue.Title
+			}
+		}
+	}
+	return "", "", ""
+}
+
+// RecoveryPrompt is the instruction sent to a polecat restarted after a
+// crash, so it continues the checkpointed step instead of starting over.
+// It is a single line because it is typed into the agent's prompt.
+func (cp *Checkpoint) RecoveryPrompt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your previous session died; resume from its checkpoint (written %s ago).", cp.Age().Round(time.Minute))
+
+	switch {
+	case cp.CurrentStep != "" && cp.StepTitle != "":
+		fmt.Fprintf(&b, " You were on step %s %q of molecule %s.", cp.CurrentStep, cp.StepTitle, cp.MoleculeID)
+	case cp.CurrentStep != "":
+		fmt.Fprintf(&b, " You were on step %s of molecule %s.", cp.CurrentStep, cp.MoleculeID)
+	}
+	if cp.HookedBead != "" {
+		fmt.Fprintf(&b, " Hooked work: %s.", cp.HookedBead)
+	}
+	if cp.Branch != "" {
+		fmt.Fprintf(&b, " Branch %s", cp.Branch)
+		if cp.LastCommit != "" {
+			fmt.Fprintf(&b, " at %s", cp.LastCommit[:min(8, len(cp.LastCommit))])
+		}
+		b.WriteString(".")
+	}
+	if n := len(cp.ModifiedFiles); n > 0 {
+		shown := cp.ModifiedFiles[:min(5, n)]
+		fmt.Fprintf(&b, " Uncommitted files (%d): %s", n, strings.Join(shown, ", "))
+		if n > len(shown) {
+			b.WriteString(", ...")
+		}
+		b.WriteString(".")
+		if cp.WIPCommit != "" {
+			fmt.Fprintf(&b, " If any are missing, they are saved in %s (git checkout %s -- <file>).", cp.WIPRef, cp.WIPCommit[:min(12, len(cp.WIPCommit))])
+		}
+	}
+	if cp.Notes != "" {
+		fmt.Fprintf(&b, " Notes: %s.", strings.TrimSuffix(strings.Join(strings.Fields(cp.Notes), " "), "."))
+	}
+	b.WriteString(" Run `gt checkpoint read` for details, then continue that step; do not restart the molecule from the beginning.")
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package checkpoint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.email", "test@example.com")
+	git(t, dir, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func TestSnapshotWIP(t *testing.T) {
+	dir := initRepo(t)
+	ref := WIPRef("polecat/Toast", "Toast")
+
+	if commit, err := SnapshotWIP(dir, ref); err != nil || commit != "" {
+		t.Fatalf("clean tree: SnapshotWIP = %q, %v; want no snapshot", commit, err)
+	}
+
+	// A staged edit and an untracked file.
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "main.go")
+	if err := os.WriteFile(filepath.Join(dir, "new.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	head := git(t, dir, "rev-parse", "HEAD")
+	status := git(t, dir, "status", "--porcelain")
+
+	commit, err := SnapshotWIP(dir, ref)
+	if err != nil {
+		t.Fatalf("SnapshotWIP: %v", err)
+	}
+	if commit == "" || git(t, dir, "rev-parse", ref) != commit {
+		t.Fatalf("ref %s does not point at snapshot %q", ref, commit)
+	}
+	if files := git(t, dir, "show", "--name-only", "--format=", commit); files != "main.go\nnew.go" {
+		t.Errorf("snapshot files = %q, want main.go and new.go", files)
+	}
+	if got := git(t, dir, "rev-parse", "HEAD"); got != head {
+		t.Errorf("HEAD moved to %s", got)
+	}
+	if got := git(t, dir, "status", "--porcelain"); got != status {
+		t.Errorf("status changed:\n%s\nwant:\n%s", got, status)
+	}
+
+	if err := ClearWIP(dir, ref); err != nil {
+		t.Fatalf("ClearWIP: %v", err)
+	}
+	if err := ClearWIP(dir, ref); err != nil {
+		t.Errorf("ClearWIP of missing ref: %v", err)
+	}
+}
+
+func TestRecoveryPrompt(t *testing.T) {
+	cp := &Checkpoint{
+		Timestamp:     time.Now().Add(-20 * time.Minute),
+		MoleculeID:    "gt-mol-1",
+		CurrentStep:   "gt-step-3",
+		StepTitle:     "Write tests",
+		HookedBead:    "gt-abc",
+		Branch:        "polecat/Toast",
+		LastCommit:    "0123456789abcdef",
+		ModifiedFiles: []string{"a.go", "b.go"},
+		WIPRef:        "refs/gastown/wip/polecat/Toast",
+		WIPCommit:     "fedcba9876543210",
+		Notes:         "Half done.\nTable tests left.",
+	}
+	prompt := cp.RecoveryPrompt()
+
+	if strings.Contains(prompt, "\n") {
+		t.Errorf("prompt spans lines: %q", prompt)
+	}
+	for _, want := range []string{
+		`step gt-step-3 "Write tests" of molecule gt-mol-1`,
+		"Hooked work: gt-abc.",
+		"Branch polecat/Toast at 01234567.",
+		"Uncommitted files (2): a.go, b.go.",
+		"git checkout fedcba987654 -- <file>",
+		"Notes: Half done. Table tests left.",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
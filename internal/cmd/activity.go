@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/eventbus"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -23,6 +31,10 @@ var (
 	activityIssue     string
 	activityTo        string
 	activityCount     int
+
+	activityWatchTopics []string
+	activityWatchFrom   int64
+	activityWatchJSON   bool
 )
 
 var activityCmd = &cobra.Command{
@@ -32,9 +44,12 @@ var activityCmd = &cobra.Command{
 	Long: `Emit and view activity events for the Gas Town activity feed.
 
 Events are written to ~/gt/.events.jsonl and can be viewed with 'gt feed'.
+When the daemon is running, events are also delivered live on its event
+bus (daemon/events.sock).
 
 Subcommands:
-  emit    Emit an activity event`,
+  emit    Emit an activity event
+  watch   Stream events from the event bus`,
 }
 
 var activityEmitCmd = &cobra.Command{
@@ -83,7 +98,13 @@ func init() {
 	activityEmitCmd.Flags().StringVar(&activityTo, "to", "", "Escalation target (for escalation_sent: mayor, deacon)")
 	activityEmitCmd.Flags().IntVar(&activityCount, "count", 0, "Polecat count (for patrol events)")
 
+	// Watch command flags
+	activityWatchCmd.Flags().StringArrayVar(&activityWatchTopics, "topic", nil, "Topic pattern to follow (repeatable; e.g. merge.*, work.sling)")
+	activityWatchCmd.Flags().Int64Var(&activityWatchFrom, "from", eventbus.FromLive, "Replay from this log offset (0 for all history)")
+	activityWatchCmd.Flags().BoolVar(&activityWatchJSON, "json", false, "Output one JSON message per line")
+
 	activityCmd.AddCommand(activityEmitCmd)
+	activityCmd.AddCommand(activityWatchCmd)
 	rootCmd.AddCommand(activityCmd)
 }
 
@@ -91,7 +112,7 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 	eventType := args[0]
 
 	// Validate we're in a Gas Town workspace
-	_, err := workspace.FindFromCwdOrError()
+	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
@@ -181,9 +202,20 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Emit the event
-	if err := events.LogFeed(eventType, actor, payload); err != nil {
-		return fmt.Errorf("emitting event: %w", err)
+	// Emit the event, through the daemon's bus when it's up so subscribers
+	// see it at once
+	event := events.Event{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Source:     "gt",
+		Type:       eventType,
+		Actor:      actor,
+		Payload:    payload,
+		Visibility: events.VisibilityFeed,
+	}
+	if err := eventbus.Publish(townRoot, event); err != nil {
+		if err := events.Append(townRoot, event); err != nil {
+			return fmt.Errorf("emitting event: %w", err)
+		}
 	}
 
 	// Print confirmation
@@ -195,4 +227,74 @@ func runActivityEmit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var activityWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream events from the event bus",
+	Long: `Stream events from the daemon's event bus as they happen.
+
+Every event is published on a topic "<category>.<type>". Categories are
+work (sling, hook, unhook, handoff, done, convoy_stranded), mail, session
+(spawn, kill, nudge, boot, halt, session_start, session_end,
+polecat_crashed, autoscale), patrol (including digest and the
+escalation_acked/resolved/breached lifecycle), merge, and other.
+--topic accepts "*", a category ("merge" or "merge.*"), or an exact topic.
+
+Each JSON message carries the event's log offset and the "next" offset;
+pass --from with a saved "next" to resume without missing events.
+Requires a running daemon (gt daemon start).
+
+Examples:
+  gt activity watch
+  gt activity watch --topic merge.*
+  gt activity watch --topic work.sling --topic work.done --json
+  gt activity watch --from 0 --json     # replay all history, then follow`,
+	Args: cobra.NoArgs,
+	RunE: runActivityWatch,
+}
+
+func runActivityWatch(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stream, err := eventbus.Subscribe(ctx, townRoot, eventbus.SubscribeOptions{
+		Topics: activityWatchTopics,
+		From:   activityWatchFrom,
+	})
+	if err != nil {
+		return fmt.Errorf("%w (is the daemon running? try 'gt daemon start')", err)
+	}
+	defer stream.Close()
+
+	enc := json.NewEncoder(os.Stdout)
+	for msg := range stream.Messages() {
+		if activityWatchJSON {
+			if err := enc.Encode(msg); err != nil {
+				return err
+			}
+			continue
+		}
+		ts := msg.Event.Timestamp
+		if t, err := time.Parse(time.RFC3339, ts); err == nil {
+			ts = t.Local().Format("15:04:05")
+		}
+		var details []string
+		for k, v := range msg.Event.Payload {
+			details = append(details, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(details)
+		fmt.Printf("%s %s %s %s\n", style.Dim.Render(ts), style.Bold.Render(msg.Topic),
+			msg.Event.Actor, style.Dim.Render(strings.Join(details, " ")))
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return stream.Err()
+}
+
 // Note: detectActor is defined in sling.go and reused here
@@ -3,10 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/checkpoint"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -28,7 +26,12 @@ Checkpoint data includes:
 - Git branch and last commit
 - Timestamp
 
-Checkpoints are stored in .polecat-checkpoint.json in the polecat directory.`,
+Checkpoints are stored in .polecat-checkpoint.json in the polecat directory.
+
+The daemon also checkpoints polecats with hooked work on a schedule
+(daemon.checkpoint_interval in mayor/config.json, default 10m) and when a
+polecat's pane dies, snapshotting uncommitted work to refs/gastown/wip/<branch>.
+A polecat restarted after a crash is told to resume the checkpointed step.`,
 }
 
 var checkpointWriteCmd = &cobra.Command{
@@ -109,6 +112,7 @@ func runCheckpointWrite(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("capturing checkpoint: %w", err)
 	}
+	cp.Trigger = checkpoint.TriggerManual
 
 	// Add notes if provided
 	if checkpointNotes != "" {
@@ -200,6 +204,12 @@ func runCheckpointRead(cmd *cobra.Command, args []string) error {
 	if cp.SessionID != "" {
 		fmt.Printf("Session ID: %s\n", cp.SessionID)
 	}
+	if cp.Trigger != "" {
+		fmt.Printf("Trigger: %s\n", cp.Trigger)
+	}
+	if cp.WIPCommit != "" {
+		fmt.Printf("WIP Snapshot: %s (%s)\n", cp.WIPRef, cp.WIPCommit[:min(12, len(cp.WIPCommit))])
+	}
 
 	return nil
 }
@@ -210,9 +220,13 @@ func runCheckpointClear(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting current directory: %w", err)
 	}
 
+	cp, _ := checkpoint.Read(cwd)
 	if err := checkpoint.Remove(cwd); err != nil {
 		return fmt.Errorf("removing checkpoint: %w", err)
 	}
+	if cp != nil && cp.WIPRef != "" {
+		_ = checkpoint.ClearWIP(cwd, cp.WIPRef) // best-effort: the ref is only a safety net
+	}
 
 	fmt.Printf("%s Checkpoint cleared\n", style.Bold.Render("✓"))
 	return nil
@@ -220,73 +234,20 @@ func runCheckpointClear(cmd *cobra.Command, args []string) error {
 
 // detectMoleculeContext tries to detect the current molecule and step from beads.
 func detectMoleculeContext(workDir string, ctx RoleInfo) (moleculeID, stepID, stepTitle string) {
-	b := beads.New(workDir)
-
-	// Get agent identity for query
-	roleCtx := RoleContext{
-		Role:    ctx.Role,
-		Rig:     ctx.Rig,
-		Polecat: ctx.Polecat,
-	}
-	assignee := getAgentIdentity(roleCtx)
+	assignee := getAgentIdentity(RoleContext{Role: ctx.Role, Rig: ctx.Rig, Polecat: ctx.Polecat})
 	if assignee == "" {
 		return "", "", ""
 	}
-
-	// Find in-progress issues for this agent
-	issues, err := b.List(beads.ListOptions{
-		Status:   "in_progress",
-		Assignee: assignee,
-		Priority: -1,
-	})
-	if err != nil || len(issues) == 0 {
-		return "", "", ""
-	}
-
-	// Check for molecule metadata
-	for _, issue := range issues {
-		// Look for instantiated_from in description
-		lines := strings.Split(issue.Description, "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "instantiated_from:") {
-				moleculeID = strings.TrimSpace(strings.TrimPrefix(line, "instantiated_from:"))
-				stepID = issue.ID
-				stepTitle = issue.Title
-				return moleculeID, stepID, stepTitle
-			}
-		}
-	}
-
-	return "", "", ""
+	return checkpoint.DetectMolecule(workDir, assignee)
 }
 
 // detectHookedBead finds the currently hooked bead for the agent.
 func detectHookedBead(workDir string, ctx RoleInfo) string {
-	b := beads.New(workDir)
-
-	// Get agent identity
-	roleCtx := RoleContext{
-		Role:    ctx.Role,
-		Rig:     ctx.Rig,
-		Polecat: ctx.Polecat,
-	}
-	assignee := getAgentIdentity(roleCtx)
+	assignee := getAgentIdentity(RoleContext{Role: ctx.Role, Rig: ctx.Rig, Polecat: ctx.Polecat})
 	if assignee == "" {
 		return ""
 	}
-
-	// Find hooked beads for this agent
-	hookedBeads, err := b.List(beads.ListOptions{
-		Status:   beads.StatusHooked,
-		Assignee: assignee,
-		Priority: -1,
-	})
-	if err != nil || len(hookedBeads) == 0 {
-		return ""
-	}
-
-	return hookedBeads[0].ID
+	return checkpoint.DetectHookedBead(workDir, assignee)
 }
 
 func min(a, b int) int {
@@ -16,6 +16,10 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tui/convoy"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -31,6 +35,10 @@ func generateShortID() string {
 // looksLikeIssueID checks if a string looks like a beads issue ID.
 // Issue IDs have the format: prefix-id (e.g., gt-abc, bd-xyz, hq-123).
 func looksLikeIssueID(s string) bool {
+	// Issues in remote towns (gt remote add)
+	if beads.IsHopRef(s) {
+		return true
+	}
 	// Common beads prefixes
 	prefixes := []string{"gt-", "bd-", "hq-"}
 	for _, prefix := range prefixes {
@@ -62,6 +70,7 @@ func looksLikeIssueID(s string) bool {
 var (
 	convoyMolecule     string
 	convoyNotify       string
+	convoyBudget       float64
 	convoyStatusJSON   bool
 	convoyListJSON     bool
 	convoyListStatus   string
@@ -107,7 +116,8 @@ COMMANDS:
   create    Create a convoy tracking specified issues
   add       Add issues to an existing convoy (reopens if closed)
   status    Show convoy progress, tracked issues, and active workers
-  list      List convoys (the dashboard view)`,
+  list      List convoys (the dashboard view)
+  land      Land the convoy's MRs across rigs all-or-nothing`,
 }
 
 var convoyCreateCmd = &cobra.Command{
@@ -122,7 +132,8 @@ Examples:
   gt convoy create "Deploy v2.0" gt-abc bd-xyz
   gt convoy create "Release prep" gt-abc --notify           # defaults to mayor/
   gt convoy create "Release prep" gt-abc --notify ops/      # notify ops/
-  gt convoy create "Feature rollout" gt-a gt-b gt-c --molecule mol-release`,
+  gt convoy create "Feature rollout" gt-a gt-b gt-c --molecule mol-release
+  gt convoy create "Big refactor" gt-a gt-b --budget 50     # cap total spend at $50`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runConvoyCreate,
 }
@@ -132,7 +143,8 @@ var convoyStatusCmd = &cobra.Command{
 	Short: "Show convoy status",
 	Long: `Show detailed status for a convoy.
 
-Displays convoy metadata, tracked issues, and completion progress.
+Displays convoy metadata, tracked issues, and completion progress, plus
+the state of each rig's MRs if the convoy lands as a group (gt convoy land).
 Without an ID, shows status of all active convoys.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runConvoyStatus,
@@ -158,10 +170,12 @@ var convoyAddCmd = &cobra.Command{
 	Long: `Add issues to an existing convoy.
 
 If the convoy is closed, it will be automatically reopened.
+Issues in remote towns (gt remote add) are tracked by hop:// reference.
 
 Examples:
   gt convoy add hq-cv-abc gt-new-issue
-  gt convoy add hq-cv-abc gt-issue1 gt-issue2 gt-issue3`,
+  gt convoy add hq-cv-abc gt-issue1 gt-issue2 gt-issue3
+  gt convoy add hq-cv-abc hop://acme.com/eng/backend/be-123`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runConvoyAdd,
 }
@@ -204,6 +218,7 @@ func init() {
 	convoyCreateCmd.Flags().StringVar(&convoyMolecule, "molecule", "", "Associated molecule ID")
 	convoyCreateCmd.Flags().StringVar(&convoyNotify, "notify", "", "Address to notify on completion (default: mayor/ if flag used without value)")
 	convoyCreateCmd.Flags().Lookup("notify").NoOptDefVal = "mayor/"
+	convoyCreateCmd.Flags().Float64Var(&convoyBudget, "budget", 0, "Total cost cap in USD, enforced by the daemon")
 
 	// Status flags
 	convoyStatusCmd.Flags().BoolVar(&convoyStatusJSON, "json", false, "Output as JSON")
@@ -269,6 +284,12 @@ func runConvoyCreate(cmd *cobra.Command, args []string) error {
 	if convoyMolecule != "" {
 		description += fmt.Sprintf("\nMolecule: %s", convoyMolecule)
 	}
+	if convoyBudget < 0 {
+		return fmt.Errorf("--budget must be non-negative")
+	}
+	if convoyBudget > 0 {
+		description += "\n" + costs.FormatConvoyBudget(convoyBudget)
+	}
 
 	// Generate convoy ID with cv- prefix
 	convoyID := fmt.Sprintf("hq-cv-%s", generateShortID())
@@ -298,8 +319,13 @@ func runConvoyCreate(cmd *cobra.Command, args []string) error {
 	// Add 'tracks' relations for each tracked issue
 	trackedCount := 0
 	for _, issueID := range trackedIssues {
+		ref, err := convoyTrackRef(filepath.Dir(townBeads), issueID)
+		if err != nil {
+			style.PrintWarning("couldn't track %s: %v", issueID, err)
+			continue
+		}
 		// Use --type=tracks for non-blocking tracking relation
-		depArgs := []string{"dep", "add", convoyID, issueID, "--type=tracks"}
+		depArgs := []string{"dep", "add", convoyID, ref, "--type=tracks"}
 		depCmd := exec.Command("bd", depArgs...)
 		depCmd.Dir = townBeads
 
@@ -323,6 +349,9 @@ func runConvoyCreate(cmd *cobra.Command, args []string) error {
 	if convoyMolecule != "" {
 		fmt.Printf("  Molecule: %s\n", convoyMolecule)
 	}
+	if convoyBudget > 0 {
+		fmt.Printf("  Budget:   $%.2f\n", convoyBudget)
+	}
 
 	fmt.Printf("\n  %s\n", style.Dim.Render("Convoy auto-closes when all tracked issues complete"))
 
@@ -386,7 +415,12 @@ func runConvoyAdd(cmd *cobra.Command, args []string) error {
 	// Add 'tracks' relations for each issue
 	addedCount := 0
 	for _, issueID := range issuesToAdd {
-		depArgs := []string{"dep", "add", convoyID, issueID, "--type=tracks"}
+		ref, err := convoyTrackRef(filepath.Dir(townBeads), issueID)
+		if err != nil {
+			style.PrintWarning("couldn't add %s: %v", issueID, err)
+			continue
+		}
+		depArgs := []string{"dep", "add", convoyID, ref, "--type=tracks"}
 		depCmd := exec.Command("bd", depArgs...)
 		depCmd.Dir = townBeads
 
@@ -458,6 +492,11 @@ func runConvoyStranded(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Record for outbound notifications; notify routes throttle repeats
+	for _, s := range stranded {
+		_ = events.LogAudit(events.TypeConvoyStranded, detectActor(), events.StrandedPayload(s.ID, s.Title, s.ReadyCount))
+	}
+
 	if convoyStrandedJSON {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -574,6 +613,11 @@ func getBlockedIssueIDs() map[string]bool {
 // - not in blocked set
 // - no assignee OR assignee session is dead
 func isReadyIssue(t trackedIssueInfo, blockedIssues map[string]bool) bool {
+	// Remote towns dispatch their own work
+	if beads.IsHopRef(t.ID) {
+		return false
+	}
+
 	// Must be open status (not in_progress, closed, hooked)
 	if t.Status != "open" {
 		return false
@@ -777,14 +821,18 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Coordinated cross-rig landing, if declared (gt convoy land)
+	landing, _ := mrqueue.NewLandingStore(filepath.Dir(townBeads)).Get(convoyID)
+
 	if convoyStatusJSON {
 		type jsonStatus struct {
-			ID        string             `json:"id"`
-			Title     string             `json:"title"`
-			Status    string             `json:"status"`
-			Tracked   []trackedIssueInfo `json:"tracked"`
-			Completed int                `json:"completed"`
-			Total     int                `json:"total"`
+			ID        string                `json:"id"`
+			Title     string                `json:"title"`
+			Status    string                `json:"status"`
+			Tracked   []trackedIssueInfo    `json:"tracked"`
+			Completed int                   `json:"completed"`
+			Total     int                   `json:"total"`
+			Landing   *mrqueue.LandingGroup `json:"landing,omitempty"`
 		}
 		out := jsonStatus{
 			ID:        convoy.ID,
@@ -793,6 +841,7 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 			Tracked:   tracked,
 			Completed: completed,
 			Total:     len(tracked),
+			Landing:   landing,
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -803,6 +852,9 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 	fmt.Printf("🚚 %s %s\n\n", style.Bold.Render(convoy.ID+":"), convoy.Title)
 	fmt.Printf("  Status:    %s\n", formatConvoyStatus(convoy.Status))
 	fmt.Printf("  Progress:  %d/%d completed\n", completed, len(tracked))
+	if budget := costs.ParseConvoyBudget(convoy.Description); budget > 0 {
+		fmt.Printf("  Budget:    $%.2f\n", budget)
+	}
 	fmt.Printf("  Created:   %s\n", convoy.CreatedAt)
 	if convoy.ClosedAt != "" {
 		fmt.Printf("  Closed:    %s\n", convoy.ClosedAt)
@@ -830,6 +882,9 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 			}
 
 			line := fmt.Sprintf("    %s %s: %s [%s]", status, t.ID, t.Title, bracketContent)
+			if t.RemoteFetched != nil {
+				line += "  " + style.Dim.Render(fmt.Sprintf("remote %s, fetched %s ago", t.Remote, formatWorkerAge(time.Since(*t.RemoteFetched))))
+			}
 			if t.Worker != "" {
 				workerDisplay := "@" + t.Worker
 				if t.WorkerAge != "" {
@@ -841,6 +896,11 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if landing != nil {
+		fmt.Println()
+		printLandingGroup(landing)
+	}
+
 	return nil
 }
 
@@ -1025,6 +1085,47 @@ type trackedIssueInfo struct {
 	Assignee  string `json:"assignee,omitempty"`   // Assigned agent (e.g., gastown/polecats/goose)
 	Worker    string `json:"worker,omitempty"`     // Worker currently assigned (e.g., gastown/nux)
 	WorkerAge string `json:"worker_age,omitempty"` // How long worker has been on this issue
+
+	// Issues in remote towns (hop:// references) come from cached snapshots
+	Remote        string     `json:"remote,omitempty"`         // Remote name (gt remote list)
+	RemoteFetched *time.Time `json:"remote_fetched,omitempty"` // When the snapshot was fetched
+}
+
+// convoyTrackRef returns the dependency target for tracking an issue. Local
+// IDs are tracked as-is; hop:// references to a registered remote town are
+// stored as external refs, external:<remote>:<hop-ref>.
+func convoyTrackRef(townRoot, issueID string) (string, error) {
+	if !beads.IsHopRef(issueID) {
+		return issueID, nil
+	}
+	hop, err := beads.ParseHopRef(issueID)
+	if err != nil {
+		return "", err
+	}
+	remote, err := beads.RemoteForURI(townRoot, hop.TownURI())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("external:%s:%s", remote.Name, hop), nil
+}
+
+// fillRemoteTrackedIssue fills in a tracked hop:// issue from the snapshot
+// of its remote town. Remote issues never have local workers.
+func fillRemoteTrackedIssue(townRoot string, info *trackedIssueInfo) {
+	issue, snap, err := beads.ResolveHopRef(townRoot, info.ID)
+	if snap != nil {
+		info.Remote = snap.Remote
+		info.RemoteFetched = &snap.FetchedAt
+	}
+	if err != nil {
+		info.Title = fmt.Sprintf("(%v)", err)
+		info.Status = "unknown"
+		return
+	}
+	info.Title = issue.Title
+	info.Status = issue.Status
+	info.IssueType = issue.Type
+	info.Assignee = issue.Assignee
 }
 
 // getTrackedIssues queries SQLite directly to get issues tracked by a convoy.
@@ -1071,8 +1172,14 @@ func getTrackedIssues(townBeads, convoyID string) []trackedIssueInfo {
 		idToDepType[issueID] = dep.Type
 	}
 
-	// Single batch call to get all issue details
-	detailsMap := getIssueDetailsBatch(issueIDs)
+	// Single batch call to get all local issue details
+	localIDs := make([]string, 0, len(issueIDs))
+	for _, id := range issueIDs {
+		if !beads.IsHopRef(id) {
+			localIDs = append(localIDs, id)
+		}
+	}
+	detailsMap := getIssueDetailsBatch(localIDs)
 
 	// Get workers for these issues (only for non-closed issues)
 	openIssueIDs := make([]string, 0, len(issueIDs))
@@ -1090,6 +1197,11 @@ func getTrackedIssues(townBeads, convoyID string) []trackedIssueInfo {
 			ID:   issueID,
 			Type: idToDepType[issueID],
 		}
+		if beads.IsHopRef(issueID) {
+			fillRemoteTrackedIssue(filepath.Dir(townBeads), &info)
+			tracked = append(tracked, info)
+			continue
+		}
 
 		if details, ok := detailsMap[issueID]; ok {
 			info.Title = details.Title
@@ -1176,6 +1288,24 @@ func getIssueDetailsBatch(issueIDs []string) map[string]*issueDetails {
 // getIssueDetails fetches issue details by trying to show it via bd.
 // Prefer getIssueDetailsBatch for multiple issues to avoid N+1 subprocess calls.
 func getIssueDetails(issueID string) *issueDetails {
+	if beads.IsHopRef(issueID) {
+		townRoot, err := workspace.FindFromCwd()
+		if err != nil || townRoot == "" {
+			return nil
+		}
+		issue, _, err := beads.ResolveHopRef(townRoot, issueID)
+		if err != nil {
+			return nil
+		}
+		return &issueDetails{
+			ID:        issue.ID,
+			Title:     issue.Title,
+			Status:    issue.Status,
+			IssueType: issue.Type,
+			Assignee:  issue.Assignee,
+		}
+	}
+
 	// Use bd show with routing - it should find the issue in the right rig
 	showCmd := exec.Command("bd", "show", issueID, "--json")
 	var stdout bytes.Buffer
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// Convoy land flags
+var (
+	convoyLandOrder  []string
+	convoyLandRetry  bool
+	convoyLandCancel bool
+	convoyLandJSON   bool
+)
+
+var convoyLandCmd = &cobra.Command{
+	Use:   "land <convoy-id>",
+	Short: "Land a convoy's MRs across rigs all-or-nothing",
+	Long: `Land a convoy's merge requests as one atomic landing group.
+
+A change that spans repositories (an API and its client) must not land
+half-way. The first run declares the convoy's landing group with --order,
+the rigs in the order they land. From then on, MRs for the convoy's issues
+in those rigs are held in their rig's merge queue instead of landing alone.
+
+Each run advances the group:
+  1. MRs newly queued for the convoy's issues join the group
+  2. Each rig's MRs are stacked onto its target and tested (like a merge
+     train), unless already tested on the current target and branch tips
+  3. Once every rig has MRs and all are green, the tested commits are
+     pushed rig by rig in --order
+  4. If a rig's push fails after earlier rigs landed, those rigs get revert
+     commits and the group is rolled back; workers get MERGE_FAILED
+
+Failing MRs go back to their workers; run again after they push fixes.
+Use --retry to land a rolled-back group again (reverted MRs are reapplied),
+or --cancel to dissolve the group and let its MRs land individually.
+
+Landing progress is shown in 'gt convoy status'.
+
+Examples:
+  gt convoy land hq-cv-abc --order api,client
+  gt convoy land hq-cv-abc
+  gt convoy land hq-cv-abc --retry
+  gt convoy land hq-cv-abc --cancel`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConvoyLand,
+}
+
+func init() {
+	convoyLandCmd.Flags().StringSliceVar(&convoyLandOrder, "order", nil, "Rigs in landing order (comma-separated; required the first time)")
+	convoyLandCmd.Flags().BoolVar(&convoyLandRetry, "retry", false, "Land a rolled-back group again")
+	convoyLandCmd.Flags().BoolVar(&convoyLandCancel, "cancel", false, "Dissolve the landing group; its MRs land individually")
+	convoyLandCmd.Flags().BoolVar(&convoyLandJSON, "json", false, "Output the group as JSON")
+
+	convoyCmd.AddCommand(convoyLandCmd)
+}
+
+func runConvoyLand(cmd *cobra.Command, args []string) error {
+	townBeads, err := getTownBeadsDir()
+	if err != nil {
+		return err
+	}
+	townRoot := filepath.Dir(townBeads)
+	convoyID := args[0]
+	store := mrqueue.NewLandingStore(townRoot)
+
+	group, err := store.Get(convoyID)
+	switch {
+	case errors.Is(err, mrqueue.ErrNotFound):
+		if convoyLandCancel || convoyLandRetry {
+			return fmt.Errorf("convoy %s has no landing group", convoyID)
+		}
+		if group, err = createLandingGroup(store, townBeads, convoyID); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("loading landing group: %w", err)
+	case len(convoyLandOrder) > 0 && !slices.Equal(convoyLandOrder, group.Order):
+		if group.State != mrqueue.LandingWaiting {
+			return fmt.Errorf("landing group %s is %s; its order can no longer change", group.ID, group.State)
+		}
+		group.Order = convoyLandOrder
+		group.SortMembers()
+		if err := store.Save(group); err != nil {
+			return err
+		}
+	}
+
+	if convoyLandCancel {
+		return cancelLandingGroup(store, group)
+	}
+
+	engineers := make(map[string]*refinery.Engineer, len(group.Order))
+	for _, rigName := range group.Order {
+		_, r, err := getRig(rigName)
+		if err != nil {
+			return err
+		}
+		e := refinery.NewEngineer(r)
+		if err := e.LoadConfig(); err != nil {
+			return fmt.Errorf("loading %s merge queue config: %w", rigName, err)
+		}
+		engineers[rigName] = e
+
+		// Hold MRs queued before the group existed, or without the tag.
+		if err := tagLandingMembers(mrqueue.New(r.Path), group); err != nil {
+			return err
+		}
+	}
+
+	lander := refinery.NewLander(store, engineers)
+	if convoyLandRetry {
+		if err := lander.Retry(group); err != nil {
+			return err
+		}
+	}
+	if convoyLandJSON {
+		lander.SetOutput(os.Stderr)
+	}
+	landErr := lander.Advance(cmd.Context(), group)
+
+	if convoyLandJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(group); err != nil {
+			return err
+		}
+		return landErr
+	}
+	fmt.Println()
+	printLandingGroup(group)
+	return landErr
+}
+
+// createLandingGroup declares a convoy's landing group from --order and the
+// convoy's tracked issues.
+func createLandingGroup(store *mrqueue.LandingStore, townBeads, convoyID string) (*mrqueue.LandingGroup, error) {
+	if len(convoyLandOrder) == 0 {
+		return nil, fmt.Errorf("convoy %s has no landing group yet; declare one with --order <rig>,<rig>", convoyID)
+	}
+	for _, rigName := range convoyLandOrder {
+		if _, _, err := getRig(rigName); err != nil {
+			return nil, err
+		}
+	}
+
+	tracked := getTrackedIssues(townBeads, convoyID)
+	if len(tracked) == 0 {
+		return nil, fmt.Errorf("convoy %s not found or tracks no issues", convoyID)
+	}
+	issues := make([]string, 0, len(tracked))
+	for _, t := range tracked {
+		issues = append(issues, t.ID)
+	}
+
+	group := &mrqueue.LandingGroup{
+		ID:       convoyID,
+		ConvoyID: convoyID,
+		Order:    convoyLandOrder,
+		Issues:   issues,
+	}
+	if err := store.Create(group); err != nil {
+		return nil, err
+	}
+	fmt.Printf("%s Declared landing group %s: %s\n", style.Bold.Render("✓"), convoyID, strings.Join(group.Order, " → "))
+	return group, nil
+}
+
+// tagLandingMembers tags a rig's queued MRs for the group's issues.
+func tagLandingMembers(q *mrqueue.Queue, group *mrqueue.LandingGroup) error {
+	if group.Done() {
+		return nil
+	}
+	mrs, err := q.List()
+	if err != nil {
+		return fmt.Errorf("listing merge queue: %w", err)
+	}
+	for _, mr := range mrs {
+		if mr.LandingGroup == "" && group.HasIssue(mr.SourceIssue) {
+			if err := q.SetLandingGroup(mr.ID, group.ID); err != nil {
+				return fmt.Errorf("tagging %s: %w", mr.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// cancelLandingGroup releases a group's MRs to land individually and
+// deletes the group.
+func cancelLandingGroup(store *mrqueue.LandingStore, group *mrqueue.LandingGroup) error {
+	if group.State == mrqueue.LandingInProgress {
+		return fmt.Errorf("landing group %s is landing; run 'gt convoy land %s' to finish it first", group.ID, group.ID)
+	}
+	for _, rigName := range group.Order {
+		_, r, err := getRig(rigName)
+		if err != nil {
+			return err
+		}
+		q := mrqueue.New(r.Path)
+		mrs, err := q.ListGroup(group.ID)
+		if err != nil {
+			return fmt.Errorf("listing %s merge queue: %w", rigName, err)
+		}
+		for _, mr := range mrs {
+			if err := q.SetLandingGroup(mr.ID, ""); err != nil {
+				return fmt.Errorf("releasing %s: %w", mr.ID, err)
+			}
+		}
+	}
+	if err := store.Remove(group.ID); err != nil {
+		return fmt.Errorf("removing landing group: %w", err)
+	}
+	fmt.Printf("%s Dissolved landing group %s; its MRs land individually\n", style.Bold.Render("✓"), group.ID)
+	return nil
+}
+
+// printLandingGroup shows a landing group's order and member states.
+func printLandingGroup(group *mrqueue.LandingGroup) {
+	fmt.Printf("  %s %s  %s\n", style.Bold.Render("Landing:"), formatLandingState(group.State),
+		style.Dim.Render(strings.Join(group.Order, " → ")))
+	if group.Error != "" {
+		fmt.Printf("    %s\n", style.Dim.Render(group.Error))
+	}
+	for _, rigName := range group.Order {
+		members := group.RigMembers(rigName)
+		if len(members) == 0 {
+			fmt.Printf("    ○ %-12s %s\n", rigName, style.Dim.Render("(no MR queued yet)"))
+			continue
+		}
+		for _, m := range members {
+			line := fmt.Sprintf("    %s %-12s %s %s", landingMemberSymbol(m.State), rigName, m.MRID, style.Dim.Render(m.Branch+" → "+m.Target))
+			if m.Error != "" {
+				line += "  " + style.Dim.Render(truncateLine(m.Error, 80))
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
+func formatLandingState(state mrqueue.LandingState) string {
+	switch state {
+	case mrqueue.LandingLanded:
+		return style.Success.Render(string(state))
+	case mrqueue.LandingRolledBack:
+		return style.Error.Render("rolled back")
+	case mrqueue.LandingInProgress:
+		return style.Warning.Render(string(state))
+	default:
+		return string(state)
+	}
+}
+
+func landingMemberSymbol(state mrqueue.MemberState) string {
+	switch state {
+	case mrqueue.MemberGreen:
+		return "●"
+	case mrqueue.MemberRed:
+		return "✗"
+	case mrqueue.MemberLanded:
+		return "✓"
+	case mrqueue.MemberReverted:
+		return "↩"
+	default:
+		return "○"
+	}
+}
@@ -6,15 +6,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/costs"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
@@ -24,6 +26,9 @@ var (
 	costsByRole bool
 	costsByRig  bool
 
+	costsByConvoy bool
+	costsByIssue  bool
+
 	// Record subcommand flags
 	recordSession  string
 	recordWorkItem string
@@ -43,6 +48,8 @@ Examples:
   gt costs --week       # This week's total
   gt costs --by-role    # Breakdown by role (polecat, witness, etc.)
   gt costs --by-rig     # Breakdown by rig
+  gt costs --by-convoy  # Breakdown by convoy, with convoy budgets
+  gt costs --by-issue   # Breakdown by work item
   gt costs --json       # Output as JSON`,
 	RunE: runCosts,
 }
@@ -69,6 +76,8 @@ func init() {
 	costsCmd.Flags().BoolVar(&costsWeek, "week", false, "Show this week's total from session events")
 	costsCmd.Flags().BoolVar(&costsByRole, "by-role", false, "Show breakdown by role")
 	costsCmd.Flags().BoolVar(&costsByRig, "by-rig", false, "Show breakdown by rig")
+	costsCmd.Flags().BoolVar(&costsByConvoy, "by-convoy", false, "Show breakdown by convoy")
+	costsCmd.Flags().BoolVar(&costsByIssue, "by-issue", false, "Show breakdown by work item")
 
 	// Add record subcommand
 	costsCmd.AddCommand(costsRecordCmd)
@@ -86,33 +95,20 @@ type SessionCost struct {
 	Running bool    `json:"running"`
 }
 
-// CostEntry is a ledger entry for historical cost tracking.
-type CostEntry struct {
-	SessionID string    `json:"session_id"`
-	Role      string    `json:"role"`
-	Rig       string    `json:"rig,omitempty"`
-	Worker    string    `json:"worker,omitempty"`
-	CostUSD   float64   `json:"cost_usd"`
-	StartedAt time.Time `json:"started_at"`
-	EndedAt   time.Time `json:"ended_at"`
-	WorkItem  string    `json:"work_item,omitempty"`
-}
-
 // CostsOutput is the JSON output structure.
 type CostsOutput struct {
 	Sessions []SessionCost      `json:"sessions,omitempty"`
 	Total    float64            `json:"total_usd"`
 	ByRole   map[string]float64 `json:"by_role,omitempty"`
 	ByRig    map[string]float64 `json:"by_rig,omitempty"`
+	ByConvoy []costs.Rollup     `json:"by_convoy,omitempty"`
+	ByIssue  []costs.Rollup     `json:"by_issue,omitempty"`
 	Period   string             `json:"period,omitempty"`
 }
 
-// costRegex matches cost patterns like "$1.23" or "$12.34"
-var costRegex = regexp.MustCompile(`\$(\d+\.\d{2})`)
-
 func runCosts(cmd *cobra.Command, args []string) error {
 	// If querying ledger, use ledger functions
-	if costsToday || costsWeek || costsByRole || costsByRig {
+	if costsToday || costsWeek || costsByRole || costsByRig || costsByConvoy || costsByIssue {
 		return runCostsFromLedger()
 	}
 
@@ -129,7 +125,7 @@ func runLiveCosts() error {
 		return fmt.Errorf("listing sessions: %w", err)
 	}
 
-	var costs []SessionCost
+	var sessionCosts []SessionCost
 	var total float64
 
 	for _, session := range sessions {
@@ -139,7 +135,7 @@ func runLiveCosts() error {
 		}
 
 		// Parse session name to get role/rig/worker
-		role, rig, worker := parseSessionName(session)
+		role, rig, worker := costs.ParseSessionName(session)
 
 		// Capture pane content
 		content, err := t.CapturePaneAll(session)
@@ -148,12 +144,12 @@ func runLiveCosts() error {
 		}
 
 		// Extract cost from content
-		cost := extractCost(content)
+		cost := costs.Extract(content)
 
 		// Check if Claude is running
 		running := t.IsClaudeRunning(session)
 
-		costs = append(costs, SessionCost{
+		sessionCosts = append(sessionCosts, SessionCost{
 			Session: session,
 			Role:    role,
 			Rig:     rig,
@@ -165,23 +161,23 @@ func runLiveCosts() error {
 	}
 
 	// Sort by session name
-	sort.Slice(costs, func(i, j int) bool {
-		return costs[i].Session < costs[j].Session
+	sort.Slice(sessionCosts, func(i, j int) bool {
+		return sessionCosts[i].Session < sessionCosts[j].Session
 	})
 
 	if costsJSON {
 		return outputCostsJSON(CostsOutput{
-			Sessions: costs,
+			Sessions: sessionCosts,
 			Total:    total,
 		})
 	}
 
-	return outputCostsHuman(costs, total)
+	return outputCostsHuman(sessionCosts, total)
 }
 
 func runCostsFromLedger() error {
 	// Query session events from beads
-	entries, err := querySessionEvents()
+	entries, err := costs.QuerySessionEvents()
 	if err != nil {
 		return fmt.Errorf("querying session events: %w", err)
 	}
@@ -192,7 +188,7 @@ func runCostsFromLedger() error {
 	}
 
 	// Filter entries by time period
-	var filtered []CostEntry
+	var filtered []costs.Entry
 	now := time.Now()
 
 	for _, entry := range entries {
@@ -238,6 +234,15 @@ func runCostsFromLedger() error {
 	if costsByRig {
 		output.ByRig = byRig
 	}
+	if costsByConvoy || costsByIssue {
+		store := costsTownStore()
+		if costsByConvoy {
+			output.ByConvoy = convoyRollups(store, filtered)
+		}
+		if costsByIssue {
+			output.ByIssue = issueRollups(store, filtered)
+		}
+	}
 
 	// Set period label
 	if costsToday {
@@ -253,182 +258,65 @@ func runCostsFromLedger() error {
 	return outputLedgerHuman(output, filtered)
 }
 
-// SessionEvent represents a session.ended event from beads.
-type SessionEvent struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	EventKind string    `json:"event_kind"`
-	Actor     string    `json:"actor"`
-	Target    string    `json:"target"`
-	Payload   string    `json:"payload"`
-}
-
-// SessionPayload represents the JSON payload of a session event.
-type SessionPayload struct {
-	CostUSD   float64 `json:"cost_usd"`
-	SessionID string  `json:"session_id"`
-	Role      string  `json:"role"`
-	Rig       string  `json:"rig"`
-	Worker    string  `json:"worker"`
-	EndedAt   string  `json:"ended_at"`
-}
-
-// EventListItem represents an event from bd list (minimal fields).
-type EventListItem struct {
-	ID string `json:"id"`
-}
-
-// querySessionEvents queries beads for session.ended events and converts them to CostEntry.
-func querySessionEvents() ([]CostEntry, error) {
-	// Step 1: Get list of event IDs
-	listArgs := []string{
-		"list",
-		"--type=event",
-		"--all",
-		"--limit=0",
-		"--json",
-	}
-
-	listCmd := exec.Command("bd", listArgs...)
-	listOutput, err := listCmd.Output()
-	if err != nil {
-		// If bd fails (e.g., no beads database), return empty list
-		return nil, nil
-	}
-
-	var listItems []EventListItem
-	if err := json.Unmarshal(listOutput, &listItems); err != nil {
-		return nil, fmt.Errorf("parsing event list: %w", err)
-	}
-
-	if len(listItems) == 0 {
-		return nil, nil
+// costsTownStore returns the town beads store for convoy and issue lookups,
+// or nil outside a workspace.
+func costsTownStore() beads.Store {
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		return nil
 	}
+	return beads.SharedStore(townRoot)
+}
 
-	// Step 2: Get full details for all events using bd show
-	// (bd list doesn't include event_kind, actor, payload)
-	showArgs := []string{"show", "--json"}
-	for _, item := range listItems {
-		showArgs = append(showArgs, item.ID)
+// convoyRollups attributes ledger entries to the convoys tracking their work
+// items. Convoys with neither spend nor a budget are omitted.
+func convoyRollups(store beads.Store, entries []costs.Entry) []costs.Rollup {
+	if store == nil {
+		return nil
 	}
-
-	showCmd := exec.Command("bd", showArgs...)
-	showOutput, err := showCmd.Output()
+	convoys, err := costs.LoadConvoys(store, false)
 	if err != nil {
-		return nil, fmt.Errorf("showing events: %w", err)
-	}
-
-	var events []SessionEvent
-	if err := json.Unmarshal(showOutput, &events); err != nil {
-		return nil, fmt.Errorf("parsing event details: %w", err)
+		return nil
 	}
+	spend := costs.ByConvoy(entries, convoys)
 
-	var entries []CostEntry
-	for _, event := range events {
-		// Filter for session.ended events only
-		if event.EventKind != "session.ended" {
+	var rows []costs.Rollup
+	for _, c := range convoys {
+		if spend[c.ID] == 0 && c.BudgetUSD == 0 {
 			continue
 		}
-
-		// Parse payload
-		var payload SessionPayload
-		if event.Payload != "" {
-			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
-				continue // Skip malformed payloads
-			}
-		}
-
-		// Parse ended_at from payload, fall back to created_at
-		endedAt := event.CreatedAt
-		if payload.EndedAt != "" {
-			if parsed, err := time.Parse(time.RFC3339, payload.EndedAt); err == nil {
-				endedAt = parsed
-			}
-		}
-
-		entries = append(entries, CostEntry{
-			SessionID: payload.SessionID,
-			Role:      payload.Role,
-			Rig:       payload.Rig,
-			Worker:    payload.Worker,
-			CostUSD:   payload.CostUSD,
-			EndedAt:   endedAt,
-			WorkItem:  event.Target,
-		})
+		rows = append(rows, costs.Rollup{Key: c.ID, Title: c.Title, CostUSD: spend[c.ID], Budget: c.BudgetUSD})
 	}
-
-	return entries, nil
+	costs.SortRollups(rows)
+	return rows
 }
 
-// parseSessionName extracts role, rig, and worker from a session name.
-// Session names follow the pattern: gt-<rig>-<worker> or gt-<global-agent>
-// Examples:
-//   - gt-mayor -> role=mayor, rig="", worker="mayor"
-//   - gt-deacon -> role=deacon, rig="", worker="deacon"
-//   - gt-gastown-toast -> role=polecat, rig=gastown, worker=toast
-//   - gt-gastown-witness -> role=witness, rig=gastown, worker=""
-//   - gt-gastown-refinery -> role=refinery, rig=gastown, worker=""
-//   - gt-gastown-crew-joe -> role=crew, rig=gastown, worker=joe
-func parseSessionName(session string) (role, rig, worker string) {
-	// Remove gt- prefix
-	name := strings.TrimPrefix(session, constants.SessionPrefix)
-
-	// Check for global agents
-	switch name {
-	case "mayor":
-		return constants.RoleMayor, "", "mayor"
-	case "deacon":
-		return constants.RoleDeacon, "", "deacon"
+// issueRollups totals ledger entries per work item, with titles when the
+// store can resolve them.
+func issueRollups(store beads.Store, entries []costs.Entry) []costs.Rollup {
+	byIssue := costs.ByIssue(entries)
+	ids := make([]string, 0, len(byIssue))
+	for id := range byIssue {
+		ids = append(ids, id)
 	}
 
-	// Parse rig-based session: rig-worker or rig-crew-name
-	parts := strings.SplitN(name, "-", 3)
-	if len(parts) < 2 {
-		return "unknown", "", name
+	var issues map[string]*beads.Issue
+	if store != nil && len(ids) > 0 {
+		issues, _ = store.ShowMultiple(ids)
 	}
 
-	rig = parts[0]
-	worker = parts[1]
-
-	// Check for crew pattern: rig-crew-name
-	if worker == "crew" && len(parts) >= 3 {
-		return constants.RoleCrew, rig, parts[2]
-	}
-
-	// Check for special workers
-	switch worker {
-	case "witness":
-		return constants.RoleWitness, rig, ""
-	case "refinery":
-		return constants.RoleRefinery, rig, ""
-	}
-
-	// Default to polecat
-	return constants.RolePolecat, rig, worker
-}
-
-// extractCost finds the most recent cost value in pane content.
-// Claude Code displays cost in the format "$X.XX" in the status area.
-func extractCost(content string) float64 {
-	matches := costRegex.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		return 0.0
-	}
-
-	// Get the last (most recent) match
-	lastMatch := matches[len(matches)-1]
-	if len(lastMatch) < 2 {
-		return 0.0
+	rows := make([]costs.Rollup, 0, len(ids))
+	for _, id := range ids {
+		row := costs.Rollup{Key: id, CostUSD: byIssue[id]}
+		if issue := issues[id]; issue != nil {
+			row.Title = issue.Title
+		}
+		rows = append(rows, row)
 	}
-
-	var cost float64
-	_, _ = fmt.Sscanf(lastMatch[1], "%f", &cost)
-	return cost
+	costs.SortRollups(rows)
+	return rows
 }
 
-
 func outputCostsJSON(output CostsOutput) error {
 	enc := json.NewEncoder(os.Stdout)
 	enc.SetIndent("", "  ")
@@ -479,7 +367,7 @@ func outputCostsHuman(costs []SessionCost, total float64) error {
 	return nil
 }
 
-func outputLedgerHuman(output CostsOutput, entries []CostEntry) error {
+func outputLedgerHuman(output CostsOutput, entries []costs.Entry) error {
 	periodStr := ""
 	if output.Period != "" {
 		periodStr = fmt.Sprintf(" (%s)", output.Period)
@@ -507,6 +395,35 @@ func outputLedgerHuman(output CostsOutput, entries []CostEntry) error {
 		}
 	}
 
+	// By convoy breakdown
+	if costsByConvoy {
+		fmt.Printf("\n%s\n", style.Bold.Render("By Convoy:"))
+		if len(output.ByConvoy) == 0 {
+			fmt.Printf("  %s\n", style.Dim.Render("(no convoy spend)"))
+		}
+		for _, row := range output.ByConvoy {
+			amount := fmt.Sprintf("$%.2f", row.CostUSD)
+			if row.Budget > 0 {
+				amount += fmt.Sprintf(" / $%.2f", row.Budget)
+				if costs.Check(row.CostUSD, row.Budget, 0) == costs.LevelExceeded {
+					amount = style.Error.Render(amount)
+				}
+			}
+			fmt.Printf("  🚚 %-15s %-20s %s\n", row.Key, amount, style.Dim.Render(row.Title))
+		}
+	}
+
+	// By issue breakdown
+	if costsByIssue {
+		fmt.Printf("\n%s\n", style.Bold.Render("By Issue:"))
+		if len(output.ByIssue) == 0 {
+			fmt.Printf("  %s\n", style.Dim.Render("(no sessions recorded with --work-item)"))
+		}
+		for _, row := range output.ByIssue {
+			fmt.Printf("  %-15s $%-10.2f %s\n", row.Key, row.CostUSD, style.Dim.Render(row.Title))
+		}
+	}
+
 	// Session count
 	fmt.Printf("\n%s %d sessions\n", style.Dim.Render("Entries:"), len(entries))
 
@@ -543,10 +460,10 @@ func runCostsRecord(cmd *cobra.Command, args []string) error {
 	}
 
 	// Extract cost
-	cost := extractCost(content)
+	cost := costs.Extract(content)
 
 	// Parse session name
-	role, rig, worker := parseSessionName(session)
+	role, rig, worker := costs.ParseSessionName(session)
 
 	// Build agent path for actor field
 	agentPath := buildAgentPath(role, rig, worker)
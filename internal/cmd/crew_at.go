@@ -119,6 +119,7 @@ func runCrewAt(cmd *cobra.Command, args []string) error {
 		if err := t.NewSession(sessionID, worker.ClonePath); err != nil {
 			return fmt.Errorf("creating session: %w", err)
 		}
+		recordTranscript(t, sessionID)
 
 		// Set environment (non-fatal: session works without these)
 		_ = t.SetEnvironment(sessionID, "GT_ROLE", "crew")
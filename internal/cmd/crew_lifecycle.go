@@ -180,6 +180,7 @@ func runCrewRefresh(cmd *cobra.Command, args []string) error {
 	if err := t.NewSession(sessionID, worker.ClonePath); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
+	recordTranscript(t, sessionID)
 
 	// Wait for shell to be ready
 	if err := t.WaitForShellReady(sessionID, constants.ShellReadyTimeout); err != nil {
@@ -335,6 +336,7 @@ func runCrewRestart(cmd *cobra.Command, args []string) error {
 			lastErr = err
 			continue
 		}
+		recordTranscript(t, sessionID)
 
 		// Set environment
 		_ = t.SetEnvironment(sessionID, "GT_ROLE", "crew")
@@ -497,6 +499,7 @@ func restartCrewSession(rigName, crewName, clonePath string) error {
 	if err := t.NewSession(sessionID, clonePath); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
+	recordTranscript(t, sessionID)
 
 	// Apply rig-based theming
 	theme := getThemeForRig(rigName)
@@ -1,10 +1,16 @@
 package cmd
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -13,38 +19,72 @@ import (
 )
 
 var (
-	dashboardPort int
-	dashboardOpen bool
+	dashboardPort     int
+	dashboardOpen     bool
+	dashboardBind     string
+	dashboardToken    string
+	dashboardReadOnly bool
 )
 
 var dashboardCmd = &cobra.Command{
 	Use:     "dashboard",
 	GroupID: GroupDiag,
-	Short:   "Start the convoy tracking web dashboard",
-	Long: `Start a web server that displays the convoy tracking dashboard.
+	Short:   "Start the Gas Town web console",
+	Long: `Start a web server that serves the Gas Town console.
 
-The dashboard shows real-time convoy status with:
-- Convoy list with status indicators
-- Progress tracking for each convoy
-- Last activity indicator (green/yellow/red)
-- Auto-refresh every 30 seconds via htmx
+The console shows:
+- Convoys with progress and last activity
+- Per-rig pages: polecats, witness and refinery state
+- Each rig's merge queue, in processing order
+- Mail inboxes for any agent
 
-Example:
-  gt dashboard              # Start on default port 8080
+Pages update live: the server follows the daemon's event bus and pushes
+changes over server-sent events (/events). Without a running daemon, pages
+refresh every 10 seconds.
+
+Actions (sling an issue, nudge an agent, retry or reject an MR, nuke a
+polecat) are available from the pages and from the JSON API under /api/.
+They run the same gt commands you would, with the same safety checks.
+
+Every request needs the console token. The printed URL carries it once;
+the browser then keeps it in a cookie. API clients send
+"Authorization: Bearer <token>". The token is random per run unless set
+with --token or GT_DASHBOARD_TOKEN.
+
+JSON API:
+  GET  /api/convoys
+  GET  /api/rigs
+  GET  /api/rigs/<rig>
+  GET  /api/rigs/<rig>/mq
+  GET  /api/mail?address=<address>
+  POST /api/actions/sling         {"bead": "gt-abc", "target": "gastown"}
+  POST /api/actions/nudge         {"target": "gastown/nux", "message": "..."}
+  POST /api/actions/mq-retry      {"rig": "gastown", "mr": "gt-mr-abc"}
+  POST /api/actions/mq-reject     {"rig": "gastown", "mr": "gt-mr-abc", "reason": "..."}
+  POST /api/actions/polecat-nuke  {"rig": "gastown", "polecat": "nux", "force": false}
+
+Examples:
+  gt dashboard              # Start on localhost:8080
   gt dashboard --port 3000  # Start on port 3000
-  gt dashboard --open       # Start and open browser`,
+  gt dashboard --open       # Start and open browser
+  gt dashboard --read-only  # Disable actions
+  gt dashboard --bind 0.0.0.0 --token "$TOKEN"  # Reachable from other hosts`,
 	RunE: runDashboard,
 }
 
 func init() {
 	dashboardCmd.Flags().IntVar(&dashboardPort, "port", 8080, "HTTP port to listen on")
 	dashboardCmd.Flags().BoolVar(&dashboardOpen, "open", false, "Open browser automatically")
+	dashboardCmd.Flags().StringVar(&dashboardBind, "bind", "localhost", "Address to listen on")
+	dashboardCmd.Flags().StringVar(&dashboardToken, "token", "", "Console token (default: $GT_DASHBOARD_TOKEN or random)")
+	dashboardCmd.Flags().BoolVar(&dashboardReadOnly, "read-only", false, "Disable actions")
 	rootCmd.AddCommand(dashboardCmd)
 }
 
 func runDashboard(cmd *cobra.Command, args []string) error {
 	// Verify we're in a workspace
-	if _, err := workspace.FindFromCwdOrError(); err != nil {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
 		return fmt.Errorf("not in a Gas Town workspace: %w", err)
 	}
 
@@ -54,30 +94,58 @@ func runDashboard(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("creating convoy fetcher: %w", err)
 	}
 
+	token := dashboardToken
+	if token == "" {
+		token = os.Getenv("GT_DASHBOARD_TOKEN")
+	}
+	if token == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("generating console token: %w", err)
+		}
+		token = hex.EncodeToString(buf)
+	}
+
+	opts := web.ConsoleOptions{
+		Token:   token,
+		Updates: &web.BusUpdates{TownRoot: townRoot},
+	}
+	if !dashboardReadOnly {
+		opts.Actions = &web.CLIActions{TownRoot: townRoot}
+	}
+
 	// Create the handler
-	handler, err := web.NewConvoyHandler(fetcher)
+	handler, err := web.NewConsole(fetcher, web.NewLiveConsoleFetcher(townRoot), opts)
 	if err != nil {
-		return fmt.Errorf("creating convoy handler: %w", err)
+		return fmt.Errorf("creating console: %w", err)
 	}
 
 	// Build the URL
-	url := fmt.Sprintf("http://localhost:%d", dashboardPort)
+	addr := net.JoinHostPort(dashboardBind, strconv.Itoa(dashboardPort))
+	host := dashboardBind
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	consoleURL := fmt.Sprintf("http://%s/?token=%s", net.JoinHostPort(host, strconv.Itoa(dashboardPort)), url.QueryEscape(token))
 
 	// Open browser if requested
 	if dashboardOpen {
-		go openBrowser(url)
+		go openBrowser(consoleURL)
 	}
 
 	// Start the server with timeouts
-	fmt.Printf("🚚 Gas Town Dashboard starting at %s\n", url)
+	fmt.Printf("🚚 Gas Town Dashboard starting at %s\n", consoleURL)
+	if dashboardReadOnly {
+		fmt.Printf("   Read-only: actions are disabled\n")
+	}
 	fmt.Printf("   Press Ctrl+C to stop\n")
 
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", dashboardPort),
+		Addr:              addr,
 		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
-		WriteTimeout:      60 * time.Second,
+		WriteTimeout:      3 * time.Minute, // actions may spawn polecats; event streams lift it
 		IdleTimeout:       120 * time.Second,
 	}
 	return server.ListenAndServe()
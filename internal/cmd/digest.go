@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/wisp"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Digest command flags
+var (
+	digestAgent string
+	digestWeek  bool
+	digestMonth bool
+	digestAgo   int
+	digestJSON  bool
+)
+
+var digestCmd = &cobra.Command{
+	Use:     "digest",
+	GroupID: GroupDiag,
+	Short:   "Show patrol and work digests",
+	RunE:    requireSubcommand,
+	Long: `Show the digests left by squashed molecules and their rollups.
+
+'gt mol squash' records a digest for each squashed molecule (or, with a
+formula cadence, for each window of patrol cycles). The daemon rolls each
+finished day's digests into a daily rollup per agent, and daily rollups
+into weekly and monthly ones. Retention is set in settings/config.json:
+
+  "retention": {
+    "digest_active_days": 30,
+    "digest_archive_days": 365,
+    "rollup_weekly": true,
+    "rollup_monthly": true
+  }
+
+Individual digests are kept for digest_active_days and daily rollups for
+digest_archive_days. Weekly and monthly rollups are kept forever.
+
+Commands:
+  gt digest show     Summarize a day, week or month
+  gt digest rollup   Apply retention now (the daemon does this on its own)`,
+}
+
+var digestShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Summarize an agent's digests for a day, week or month",
+	Long: `Summarize digests for the current day, week or month, one summary per agent.
+
+Finished periods are read from their rollups; the current period is
+summarized from the digests recorded so far.
+
+Examples:
+  gt digest show                            # today, every agent
+  gt digest show --agent deacon --week      # this week's deacon patrols
+  gt digest show --agent gastown/refinery --month --ago 1
+  gt digest show --week --json`,
+	Args: cobra.NoArgs,
+	RunE: runDigestShow,
+}
+
+var digestRollupCmd = &cobra.Command{
+	Use:   "rollup",
+	Short: "Roll up finished days, weeks and months and prune old digests",
+	Args:  cobra.NoArgs,
+	RunE:  runDigestRollup,
+}
+
+func runDigestShow(cmd *cobra.Command, args []string) error {
+	if digestWeek && digestMonth {
+		return fmt.Errorf("--week and --month are mutually exclusive")
+	}
+	if digestAgo < 0 {
+		return fmt.Errorf("--ago must not be negative")
+	}
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	period := wisp.PeriodDay
+	at := time.Now().AddDate(0, 0, -digestAgo)
+	switch {
+	case digestWeek:
+		period = wisp.PeriodWeek
+		at = time.Now().AddDate(0, 0, -7*digestAgo)
+	case digestMonth:
+		period = wisp.PeriodMonth
+		// Step back from mid-month so short months aren't skipped
+		at = wisp.PeriodStart(wisp.PeriodMonth, time.Now()).AddDate(0, -digestAgo, 14)
+	}
+
+	summaries, err := wisp.Summarize(townRoot, digestAgent, period, at)
+	if err != nil {
+		return fmt.Errorf("reading digests: %w", err)
+	}
+
+	if digestJSON {
+		if summaries == nil {
+			summaries = []*wisp.Digest{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+
+	if len(summaries) == 0 {
+		start := wisp.PeriodStart(period, at)
+		who := "any agent"
+		if digestAgent != "" {
+			who = digestAgent
+		}
+		fmt.Printf("No digests for %s in the %s of %s.\n", who, period, start.Format("2006-01-02"))
+		return nil
+	}
+	for i, s := range summaries {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(s.Body)
+	}
+	return nil
+}
+
+func runDigestRollup(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return fmt.Errorf("loading town settings: %w", err)
+	}
+
+	flushed, err := wisp.FlushPending(townRoot, time.Now())
+	if err != nil {
+		return fmt.Errorf("flushing pending digests: %w", err)
+	}
+	result, err := wisp.ApplyRetention(townRoot, settings.Retention, time.Now())
+	if err != nil {
+		return fmt.Errorf("applying retention: %w", err)
+	}
+
+	for _, d := range flushed {
+		fmt.Printf("  %s Recorded pending digest for %s (%d cycles)\n", style.Success.Render("✓"), d.Agent, d.Cycles)
+	}
+	for _, r := range result.Rollups {
+		fmt.Printf("  %s %s rollup for %s from %s\n", style.Success.Render("✓"), r.Period, r.Agent, r.Start.Format("2006-01-02"))
+	}
+	if result.PrunedDays > 0 || result.PrunedRollups > 0 {
+		fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("Pruned %d day(s) of digests and %d daily rollup(s)", result.PrunedDays, result.PrunedRollups)))
+	}
+	if len(flushed) == 0 && len(result.Rollups) == 0 && result.PrunedDays == 0 && result.PrunedRollups == 0 {
+		fmt.Println("Digests are up to date.")
+	}
+	return nil
+}
+
+func init() {
+	digestShowCmd.Flags().StringVar(&digestAgent, "agent", "", "Agent to show (e.g., deacon, gastown/witness)")
+	digestShowCmd.Flags().BoolVar(&digestWeek, "week", false, "Summarize a week (Monday to Sunday)")
+	digestShowCmd.Flags().BoolVar(&digestMonth, "month", false, "Summarize a month")
+	digestShowCmd.Flags().IntVar(&digestAgo, "ago", 0, "Show the period this many days, weeks or months back")
+	digestShowCmd.Flags().BoolVar(&digestJSON, "json", false, "Output as JSON")
+
+	digestCmd.AddCommand(digestShowCmd)
+	digestCmd.AddCommand(digestRollupCmd)
+
+	rootCmd.AddCommand(digestCmd)
+}
@@ -1,18 +1,36 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/doctor"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 var (
-	doctorFix     bool
-	doctorVerbose bool
-	doctorRig     string
+	doctorFix      bool
+	doctorVerbose  bool
+	doctorRig      string
+	doctorJSON     bool
+	doctorJUnit    bool
+	doctorOnly     []string
+	doctorSkip     []string
+	doctorList     bool
+	doctorWatch    bool
+	doctorInterval time.Duration
 )
 
 var doctorCmd = &cobra.Command{
@@ -24,51 +42,52 @@ var doctorCmd = &cobra.Command{
 Doctor checks for common configuration issues, missing files,
 and other problems that could affect workspace operation.
 
-Workspace checks:
-  - town-config-exists       Check mayor/town.json exists
-  - town-config-valid        Check mayor/town.json is valid
-  - rigs-registry-exists     Check mayor/rigs.json exists (fixable)
-  - rigs-registry-valid      Check registered rigs exist (fixable)
-  - mayor-exists             Check mayor/ directory structure
-
-Infrastructure checks:
-  - daemon                   Check if daemon is running (fixable)
-  - repo-fingerprint         Check database has valid repo fingerprint (fixable)
-  - boot-health              Check Boot watchdog health (vet mode)
-
-Cleanup checks (fixable):
-  - orphan-sessions          Detect orphaned tmux sessions
-  - orphan-processes         Detect orphaned Claude processes
-  - wisp-gc                  Detect and clean abandoned wisps (>1h)
-
-Clone divergence checks:
-  - persistent-role-branches Detect crew/witness/refinery not on main
-  - clone-divergence         Detect clones significantly behind origin/main
-
-Rig checks (with --rig flag):
-  - rig-is-git-repo          Verify rig is a valid git repository
-  - git-exclude-configured   Check .git/info/exclude has Gas Town dirs (fixable)
-  - witness-exists           Verify witness/ structure exists (fixable)
-  - refinery-exists          Verify refinery/ structure exists (fixable)
-  - mayor-clone-exists       Verify mayor/rig/ clone exists (fixable)
-  - polecat-clones-valid     Verify polecat directories are valid clones
-  - beads-config-valid       Verify beads configuration (fixable)
-
-Routing checks (fixable):
-  - routes-config            Check beads routing configuration
-
-Session hook checks:
-  - session-hooks            Check settings.json use session-start.sh
-
-Patrol checks:
-  - patrol-molecules-exist   Verify patrol molecules exist
-  - patrol-hooks-wired       Verify daemon triggers patrols
-  - patrol-not-stuck         Detect stale wisps (>1h)
-  - patrol-plugins-accessible Verify plugin directories
-  - patrol-roles-have-prompts Verify role prompts exist
+Checks are grouped in categories (see 'gt doctor --list' for every check):
+
+  workspace        town config, rigs registry, mayor/ structure
+  infrastructure   daemon, repo fingerprint, Boot watchdog
+  beads            beads database, bd daemon, prefixes, routes, agent beads
+  cleanup          orphaned sessions and processes, abandoned wisps, lifecycle hygiene
+  git              town git, role branches, clone divergence, beads-sync orphans
+  sessions         identity collisions, linked panes, themes
+  patrol           patrol molecules, hooks, stuck wisps, plugins, role prompts
+  config           settings, session hooks, runtime gitignore, legacy layout
+  crew             crew state, provisioned commands
+  hooks            hook attachments, singleton hooks, orphaned attachments
+  rig              rig clones and structure (with --rig)
+  plugins          project checks from settings/doctor.d/ (default category)
+
+Project checks:
+  Towns and rigs can declare their own checks in settings/doctor.d/*.toml
+  (town checks run from the town root; rig checks are named <rig>/<name> and
+  run from the rig):
+
+    [[check]]
+    name = "go-toolchain"
+    description = "Go 1.24 or newer is installed"
+    category = "toolchain"          # default: plugins
+    command = "go version"          # run with sh -c
+    expect_exit = 0                 # default: 0
+    expect_output = 'go1\.(2[4-9]|[3-9][0-9])'   # regexp on stdout+stderr
+    reject_output = ""              # regexp that must not match
+    severity = "warning"            # error (default) or warning
+    timeout = "30s"
+    fix = ""                        # command run by --fix
+    fix_hint = "Install Go 1.24 from https://go.dev/dl/"
 
 Use --fix to attempt automatic fixes for issues that support it.
-Use --rig to check a specific rig instead of the entire workspace.`,
+Use --rig to check a specific rig instead of the entire workspace.
+Use --only and --skip with categories or check names to select checks.
+Use --json or --junit for CI; the exit status is non-zero on errors.
+Use --watch to re-run checks every --interval, printing status changes and
+emitting doctor_check events (routable to outbound notifications).
+
+Examples:
+  gt doctor
+  gt doctor --only patrol,hooks
+  gt doctor --skip cleanup --junit > doctor.xml
+  gt doctor --rig gastown --only plugins --json
+  gt doctor --watch --interval 2m`,
 	RunE: runDoctor,
 }
 
@@ -76,10 +95,27 @@ func init() {
 	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Attempt to automatically fix issues")
 	doctorCmd.Flags().BoolVarP(&doctorVerbose, "verbose", "v", false, "Show detailed output")
 	doctorCmd.Flags().StringVar(&doctorRig, "rig", "", "Check specific rig only")
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output the report as JSON")
+	doctorCmd.Flags().BoolVar(&doctorJUnit, "junit", false, "Output the report as JUnit XML")
+	doctorCmd.Flags().StringSliceVar(&doctorOnly, "only", nil, "Run only these categories or checks (comma-separated)")
+	doctorCmd.Flags().StringSliceVar(&doctorSkip, "skip", nil, "Skip these categories or checks (comma-separated)")
+	doctorCmd.Flags().BoolVar(&doctorList, "list", false, "List checks by category without running them")
+	doctorCmd.Flags().BoolVar(&doctorWatch, "watch", false, "Re-run checks on a timer and report status changes")
+	doctorCmd.Flags().DurationVar(&doctorInterval, "interval", 5*time.Minute, "Time between runs with --watch")
 	rootCmd.AddCommand(doctorCmd)
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorJSON && doctorJUnit {
+		return fmt.Errorf("--json and --junit are mutually exclusive")
+	}
+	if doctorWatch && (doctorFix || doctorJUnit) {
+		return fmt.Errorf("--watch can't be combined with --fix or --junit")
+	}
+	if doctorWatch && doctorInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
 	// Find town root
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
@@ -93,79 +129,216 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		Verbose:  doctorVerbose,
 	}
 
-	// Create doctor and register checks
+	d := newDoctor(townRoot)
+	if err := d.Filter(doctorOnly, doctorSkip); err != nil {
+		return err
+	}
+
+	if doctorList {
+		printDoctorChecks(d)
+		return nil
+	}
+	if doctorWatch {
+		return watchDoctor(d, ctx)
+	}
+
+	// Run checks
+	var report *doctor.Report
+	if doctorFix {
+		report = d.Fix(ctx)
+	} else {
+		report = d.Run(ctx)
+	}
+
+	// Print report
+	switch {
+	case doctorJSON:
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			return err
+		}
+	case doctorJUnit:
+		if err := report.WriteJUnit(os.Stdout); err != nil {
+			return err
+		}
+	default:
+		report.Print(os.Stdout, doctorVerbose)
+	}
+
+	// Exit with error code if there are errors
+	if report.HasErrors() {
+		return fmt.Errorf("doctor found %d error(s)", report.Summary.Errors)
+	}
+
+	return nil
+}
+
+// newDoctor registers the built-in checks by category, then the project
+// checks from the town's and rigs' settings/doctor.d/.
+func newDoctor(townRoot string) *doctor.Doctor {
 	d := doctor.NewDoctor()
 
 	// Register workspace-level checks first (fundamental)
-	d.RegisterAll(doctor.WorkspaceChecks()...)
-
-	// Register built-in checks
-	d.Register(doctor.NewTownGitCheck())
-	d.Register(doctor.NewDaemonCheck())
-	d.Register(doctor.NewRepoFingerprintCheck())
-	d.Register(doctor.NewBootHealthCheck())
-	d.Register(doctor.NewBeadsDatabaseCheck())
-	d.Register(doctor.NewBdDaemonCheck())
-	d.Register(doctor.NewPrefixConflictCheck())
-	d.Register(doctor.NewRoutesCheck())
-	d.Register(doctor.NewOrphanSessionCheck())
-	d.Register(doctor.NewOrphanProcessCheck())
-	d.Register(doctor.NewWispGCCheck())
-	d.Register(doctor.NewBranchCheck())
-	d.Register(doctor.NewBeadsSyncOrphanCheck())
-	d.Register(doctor.NewCloneDivergenceCheck())
-	d.Register(doctor.NewIdentityCollisionCheck())
-	d.Register(doctor.NewLinkedPaneCheck())
-	d.Register(doctor.NewThemeCheck())
+	d.RegisterIn(doctor.CategoryWorkspace, doctor.WorkspaceChecks()...)
+
+	d.RegisterIn(doctor.CategoryGit, doctor.NewTownGitCheck())
+	d.RegisterIn(doctor.CategoryInfrastructure,
+		doctor.NewDaemonCheck(),
+		doctor.NewRepoFingerprintCheck(),
+		doctor.NewBootHealthCheck(),
+	)
+	d.RegisterIn(doctor.CategoryBeads,
+		doctor.NewBeadsDatabaseCheck(),
+		doctor.NewBdDaemonCheck(),
+		doctor.NewPrefixConflictCheck(),
+		doctor.NewRoutesCheck(),
+	)
+	d.RegisterIn(doctor.CategoryCleanup,
+		doctor.NewOrphanSessionCheck(),
+		doctor.NewOrphanProcessCheck(),
+		doctor.NewWispGCCheck(),
+	)
+	d.RegisterIn(doctor.CategoryGit,
+		doctor.NewBranchCheck(),
+		doctor.NewBeadsSyncOrphanCheck(),
+		doctor.NewCloneDivergenceCheck(),
+	)
+	d.RegisterIn(doctor.CategorySessions,
+		doctor.NewIdentityCollisionCheck(),
+		doctor.NewLinkedPaneCheck(),
+		doctor.NewThemeCheck(),
+	)
 
 	// Patrol system checks
-	d.Register(doctor.NewPatrolMoleculesExistCheck())
-	d.Register(doctor.NewPatrolHooksWiredCheck())
-	d.Register(doctor.NewPatrolNotStuckCheck())
-	d.Register(doctor.NewPatrolPluginsAccessibleCheck())
-	d.Register(doctor.NewPatrolRolesHavePromptsCheck())
-	d.Register(doctor.NewAgentBeadsCheck())
+	d.RegisterIn(doctor.CategoryPatrol,
+		doctor.NewPatrolMoleculesExistCheck(),
+		doctor.NewPatrolHooksWiredCheck(),
+		doctor.NewPatrolNotStuckCheck(),
+		doctor.NewPatrolPluginsAccessibleCheck(),
+		doctor.NewPatrolRolesHavePromptsCheck(),
+	)
+	d.RegisterIn(doctor.CategoryBeads, doctor.NewAgentBeadsCheck())
 
 	// NOTE: StaleAttachmentsCheck removed - staleness detection belongs in Deacon molecule
 
 	// Config architecture checks
-	d.Register(doctor.NewSettingsCheck())
-	d.Register(doctor.NewSessionHookCheck())
-	d.Register(doctor.NewRuntimeGitignoreCheck())
-	d.Register(doctor.NewLegacyGastownCheck())
+	d.RegisterIn(doctor.CategoryConfig,
+		doctor.NewSettingsCheck(),
+		doctor.NewSessionHookCheck(),
+		doctor.NewRuntimeGitignoreCheck(),
+		doctor.NewLegacyGastownCheck(),
+	)
 
 	// Crew workspace checks
-	d.Register(doctor.NewCrewStateCheck())
-	d.Register(doctor.NewCommandsCheck())
+	d.RegisterIn(doctor.CategoryCrew,
+		doctor.NewCrewStateCheck(),
+		doctor.NewCommandsCheck(),
+	)
 
 	// Lifecycle hygiene checks
-	d.Register(doctor.NewLifecycleHygieneCheck())
+	d.RegisterIn(doctor.CategoryCleanup, doctor.NewLifecycleHygieneCheck())
 
 	// Hook attachment checks
-	d.Register(doctor.NewHookAttachmentValidCheck())
-	d.Register(doctor.NewHookSingletonCheck())
-	d.Register(doctor.NewOrphanedAttachmentsCheck())
+	d.RegisterIn(doctor.CategoryHooks,
+		doctor.NewHookAttachmentValidCheck(),
+		doctor.NewHookSingletonCheck(),
+		doctor.NewOrphanedAttachmentsCheck(),
+	)
 
 	// Rig-specific checks (only when --rig is specified)
 	if doctorRig != "" {
-		d.RegisterAll(doctor.RigChecks()...)
+		d.RegisterIn(doctor.CategoryRig, doctor.RigChecks()...)
 	}
 
-	// Run checks
-	var report *doctor.Report
-	if doctorFix {
-		report = d.Fix(ctx)
-	} else {
-		report = d.Run(ctx)
+	// Project checks: the town's, then the checked rig's (or every rig's)
+	d.RegisterPlugins(doctor.LoadPlugins(townRoot, "")...)
+	for _, rig := range doctorPluginRigs(townRoot) {
+		d.RegisterPlugins(doctor.LoadPlugins(filepath.Join(townRoot, rig), rig)...)
 	}
 
-	// Print report
-	report.Print(os.Stdout, doctorVerbose)
+	return d
+}
 
-	// Exit with error code if there are errors
-	if report.HasErrors() {
-		return fmt.Errorf("doctor found %d error(s)", report.Summary.Errors)
+// doctorPluginRigs returns the rigs whose doctor.d checks run: the --rig
+// rig, or every registered rig.
+func doctorPluginRigs(townRoot string) []string {
+	if doctorRig != "" {
+		return []string{doctorRig}
 	}
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil {
+		return nil
+	}
+	rigs := make([]string, 0, len(rigsConfig.Rigs))
+	for name := range rigsConfig.Rigs {
+		rigs = append(rigs, name)
+	}
+	sort.Strings(rigs)
+	return rigs
+}
 
-	return nil
+// printDoctorChecks lists the selected checks by category.
+func printDoctorChecks(d *doctor.Doctor) {
+	for _, category := range d.Categories() {
+		fmt.Printf("%s\n", style.Bold.Render(category))
+		for _, check := range d.Checks() {
+			if d.Category(check) != category {
+				continue
+			}
+			fix := ""
+			if check.CanFix() {
+				fix = style.Dim.Render(" (fixable)")
+			}
+			fmt.Printf("  %-28s %s%s\n", check.Name(), check.Description(), fix)
+		}
+	}
+}
+
+// watchDoctor re-runs the checks every --interval until interrupted. The
+// first run prints the full report; later runs print only checks whose
+// status changed. Every change (including checks failing on the first
+// run) is logged as a doctor_check event.
+func watchDoctor(d *doctor.Doctor, ctx *doctor.CheckContext) error {
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	enc := json.NewEncoder(os.Stdout)
+	var prev *doctor.Report
+	for {
+		report := d.Run(ctx)
+		if prev == nil && !doctorJSON {
+			report.Print(os.Stdout, doctorVerbose)
+			fmt.Printf("\n%s\n", style.Dim.Render(fmt.Sprintf("Watching; re-running every %v (Ctrl-C to stop)", doctorInterval)))
+		}
+
+		for _, change := range doctor.Changes(prev, report) {
+			from := ""
+			if change.From != nil {
+				from = statusName(*change.From)
+			}
+			to := statusName(change.To)
+			_ = events.LogFeed(events.TypeDoctorCheck, "doctor",
+				events.DoctorCheckPayload(change.Check, change.Category, from, to, change.Result.Message))
+
+			switch {
+			case doctorJSON:
+				_ = enc.Encode(change)
+			case prev != nil:
+				fmt.Printf("%s %s %s → %s: %s\n", report.Timestamp.Format("15:04:05"),
+					style.Bold.Render(change.Check), from, to, change.Result.Message)
+			}
+		}
+		prev = report
+
+		select {
+		case <-sigCtx.Done():
+			return nil
+		case <-time.After(doctorInterval):
+		}
+	}
+}
+
+// statusName is a status as it appears in JSON and events.
+func statusName(s doctor.CheckStatus) string {
+	text, _ := s.MarshalText()
+	return string(text)
 }
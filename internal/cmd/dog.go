@@ -37,6 +37,10 @@ Dogs are reusable helper workers managed by the Deacon for infrastructure
 and cleanup tasks. Unlike polecats (single-rig, ephemeral), dogs handle
 cross-rig infrastructure work with worktrees into each rig.
 
+Recurring infrastructure jobs (branch cleanup, worktree pruning, beads
+sync, dependency-update sweeps) are queued by the daemon on a schedule and
+run on idle dogs; see 'gt dog schedule', 'gt dog jobs' and 'gt dog history'.
+
 The kennel is located at ~/gt/deacon/dogs/.`,
 }
 
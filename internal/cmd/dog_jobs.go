@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/dog"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	dogJobsJSON     bool
+	dogScheduleJSON bool
+	dogHistoryJSON  bool
+	dogHistoryJob   string
+	dogHistoryLimit int
+)
+
+var dogJobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Show queued and running dog jobs",
+	Long: `Show the Deacon's dog job queue: jobs waiting for an idle dog, running,
+or waiting to be retried.
+
+The daemon queues recurring jobs when their schedule comes due (see
+'gt dog schedule') and starts queued jobs on idle dogs at each heartbeat.
+A failed attempt is retried with exponential backoff until the job's
+retries run out; every attempt is kept in 'gt dog history'.
+
+Examples:
+  gt dog jobs
+  gt dog jobs --json
+  gt dog jobs run dep-update
+  gt dog jobs cancel dep-update-20261016-a1b2c3`,
+	RunE: runDogJobs,
+}
+
+var dogJobsRunCmd = &cobra.Command{
+	Use:   "run <job>",
+	Short: "Queue a run of a job now",
+	Long: `Queue a run of a job now, outside its schedule.
+
+Disabled jobs can be run by hand. The daemon starts the run on an idle dog
+at its next heartbeat.
+
+Examples:
+  gt dog jobs run beads-sync`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDogJobsRun,
+}
+
+var dogJobsCancelCmd = &cobra.Command{
+	Use:   "cancel <run-id>",
+	Short: "Cancel a queued job run",
+	Long: `Cancel a queued job run, including one waiting to be retried.
+
+Running attempts can't be canceled; they end at the job's timeout.
+
+Examples:
+  gt dog jobs cancel dep-update-20261016-a1b2c3`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDogJobsCancel,
+}
+
+var dogScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Show recurring dog jobs and when they next run",
+	Long: `Show the recurring dog jobs, their schedules, next and last runs.
+
+Jobs are set in settings/config.json under "dog_jobs". Every town runs the
+default jobs below; settings on a default job replace its defaults, and
+"disabled": true turns it off:
+
+  "dog_jobs": {
+    "jobs": {
+      "branch-cleanup": {"schedule": "0 4 * * *", "timeout": "10m"},
+      "worktree-prune": {"schedule": "30 4 * * *", "timeout": "10m"},
+      "beads-sync":     {"schedule": "@every 1h", "timeout": "5m"},
+      "dep-update":     {"schedule": "0 6 * * 1", "rigs": ["gastown"]},
+      "docs-linkcheck": {"type": "command", "command": "make linkcheck",
+                         "rigs": ["gastown"], "schedule": "@daily",
+                         "concurrency": 1, "retries": 3, "backoff": "10m"}
+    },
+    "max_concurrent": 2
+  }
+
+Job types:
+  branch-cleanup   delete dog branches no dog is using, in each rig
+  worktree-prune   prune worktree entries whose directories are gone
+  beads-sync       run bd sync in the town and each rig
+  dep-update       list available dependency updates in each rig's dog
+                   worktree (Go modules, or "command" for other ecosystems)
+  command          run "command" in the town, or in the dog's worktree of
+                   each listed rig
+
+Schedules are five-field cron expressions in local time, @hourly, @daily,
+@weekly, @monthly or "@every <duration>". A job's type defaults to its
+name; timeout defaults to 30m, concurrency to 1, retries to 2 and the
+first retry backoff to 5m (doubling with each retry).
+
+Examples:
+  gt dog schedule
+  gt dog schedule --json`,
+	RunE: runDogSchedule,
+}
+
+var dogHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show finished dog job attempts",
+	Long: `Show finished dog job attempts, newest first, with their result.
+
+Examples:
+  gt dog history
+  gt dog history --job beads-sync --limit 5
+  gt dog history --json`,
+	RunE: runDogHistory,
+}
+
+func init() {
+	dogJobsCmd.Flags().BoolVar(&dogJobsJSON, "json", false, "Output as JSON")
+	dogScheduleCmd.Flags().BoolVar(&dogScheduleJSON, "json", false, "Output as JSON")
+	dogHistoryCmd.Flags().BoolVar(&dogHistoryJSON, "json", false, "Output as JSON")
+	dogHistoryCmd.Flags().StringVar(&dogHistoryJob, "job", "", "Only show attempts of this job")
+	dogHistoryCmd.Flags().IntVarP(&dogHistoryLimit, "limit", "n", 20, "Maximum attempts to show (0 for all)")
+
+	dogJobsCmd.AddCommand(dogJobsRunCmd)
+	dogJobsCmd.AddCommand(dogJobsCancelCmd)
+
+	dogCmd.AddCommand(dogJobsCmd)
+	dogCmd.AddCommand(dogScheduleCmd)
+	dogCmd.AddCommand(dogHistoryCmd)
+}
+
+// loadDogJobs returns the town's dog jobs and queue.
+func loadDogJobs() ([]*dog.Job, []error, *dog.Queue, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	var cfg *config.DogJobsConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot)); err == nil {
+		cfg = settings.DogJobs
+	}
+	jobs, errs := dog.LoadJobs(cfg)
+	return jobs, errs, dog.NewQueue(townRoot), nil
+}
+
+func runDogJobs(cmd *cobra.Command, args []string) error {
+	_, _, queue, err := loadDogJobs()
+	if err != nil {
+		return err
+	}
+	state, err := queue.Load()
+	if err != nil {
+		return err
+	}
+
+	if dogJobsJSON {
+		runs := state.Runs
+		if runs == nil {
+			runs = []*dog.JobRun{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runs)
+	}
+
+	if len(state.Runs) == 0 {
+		fmt.Println("No queued or running dog jobs.")
+		return nil
+	}
+
+	now := time.Now()
+	for _, run := range state.Runs {
+		var status string
+		var details []string
+		switch {
+		case run.Status == dog.RunRunning:
+			status = style.Bold.Render("running on " + run.Dog)
+			details = append(details, "for "+formatWorkerAge(now.Sub(*run.StartedAt)))
+		case run.NotBefore != nil:
+			status = style.Warning.Render("retry in " + formatWorkerAge(run.NotBefore.Sub(now)))
+			details = append(details, "last attempt failed: "+run.Error)
+		default:
+			status = style.Dim.Render("queued")
+			details = append(details, formatWorkerAge(now.Sub(run.QueuedAt))+" ago")
+		}
+		details = append(details, run.Trigger, fmt.Sprintf("%d/%d attempts", run.Attempt, run.MaxAttempts))
+		fmt.Printf("%s  %s\n", style.Bold.Render(run.ID), status)
+		fmt.Printf("    %s\n", style.Dim.Render(strings.Join(details, " · ")))
+	}
+	return nil
+}
+
+func runDogJobsRun(cmd *cobra.Command, args []string) error {
+	jobs, _, queue, err := loadDogJobs()
+	if err != nil {
+		return err
+	}
+	job := dog.FindJob(jobs, args[0])
+	if job == nil {
+		return fmt.Errorf("no valid dog job named %q (see 'gt dog schedule')", args[0])
+	}
+
+	run, err := queue.Enqueue(job, dog.TriggerManual, time.Now())
+	if err != nil {
+		return fmt.Errorf("queueing %s: %w", job.Name, err)
+	}
+	fmt.Printf("%s Queued %s\n", style.Bold.Render("✓"), run.ID)
+	fmt.Printf("  %s\n", style.Dim.Render("The daemon starts it on an idle dog at its next heartbeat."))
+	return nil
+}
+
+func runDogJobsCancel(cmd *cobra.Command, args []string) error {
+	_, _, queue, err := loadDogJobs()
+	if err != nil {
+		return err
+	}
+	run, err := queue.Cancel(args[0], time.Now())
+	if errors.Is(err, dog.ErrRunNotFound) {
+		return fmt.Errorf("no queued job run %s (see 'gt dog jobs')", args[0])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Canceled %s\n", style.Bold.Render("✓"), run.ID)
+	return nil
+}
+
+// DogScheduleItem is a recurring job as shown by gt dog schedule.
+type DogScheduleItem struct {
+	Job      string      `json:"job"`
+	Type     dog.JobType `json:"type"`
+	Schedule string      `json:"schedule"`
+	Rigs     []string    `json:"rigs,omitempty"`
+	Disabled bool        `json:"disabled,omitempty"`
+	NextRun  *time.Time  `json:"next_run,omitempty"`
+	LastRun  *dog.JobRun `json:"last_run,omitempty"`
+}
+
+func runDogSchedule(cmd *cobra.Command, args []string) error {
+	jobs, errs, queue, err := loadDogJobs()
+	if err != nil {
+		return err
+	}
+	state, err := queue.Load()
+	if err != nil {
+		return err
+	}
+	history, err := queue.History("", 0)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	items := make([]DogScheduleItem, 0, len(jobs))
+	for _, job := range jobs {
+		item := DogScheduleItem{
+			Job:      job.Name,
+			Type:     job.Type,
+			Schedule: job.ScheduleRaw,
+			Rigs:     job.Rigs,
+			Disabled: job.Disabled,
+		}
+		if !job.Disabled {
+			if next := state.NextRun(job, now); !next.IsZero() {
+				item.NextRun = &next
+			}
+		}
+		for _, run := range history {
+			if run.Job == job.Name {
+				item.LastRun = run
+				break
+			}
+		}
+		items = append(items, item)
+	}
+
+	if dogScheduleJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	}
+
+	fmt.Printf("%-16s %-15s %-14s %-16s %s\n", "JOB", "TYPE", "SCHEDULE", "NEXT RUN", "LAST RUN")
+	for _, item := range items {
+		next := "-"
+		switch {
+		case item.Disabled:
+			next = "disabled"
+		case item.NextRun != nil && !item.NextRun.After(now):
+			next = "due"
+		case item.NextRun != nil:
+			next = formatJobTime(*item.NextRun, now)
+		}
+		last := "-"
+		if item.LastRun != nil {
+			last = string(item.LastRun.Status)
+			if item.LastRun.Retrying() {
+				last = "failed, retrying"
+			}
+			if item.LastRun.FinishedAt != nil {
+				last += ", " + formatWorkerAge(now.Sub(*item.LastRun.FinishedAt)) + " ago"
+			}
+			switch item.LastRun.Status {
+			case dog.RunSucceeded:
+				last = style.Success.Render(last)
+			case dog.RunFailed:
+				last = style.Warning.Render(last)
+			}
+		}
+		fmt.Printf("%-16s %-15s %-14s %-16s %s\n", item.Job, item.Type, item.Schedule, next, last)
+	}
+	for _, err := range errs {
+		style.PrintWarning("invalid dog job: %v", err)
+	}
+	return nil
+}
+
+// formatJobTime formats an upcoming run time compactly.
+func formatJobTime(t, now time.Time) string {
+	if t.Sub(now) < 24*time.Hour {
+		return "in " + formatWorkerAge(t.Sub(now)) + " (" + t.Format("15:04") + ")"
+	}
+	return t.Format("Mon Jan 2 15:04")
+}
+
+func runDogHistory(cmd *cobra.Command, args []string) error {
+	_, _, queue, err := loadDogJobs()
+	if err != nil {
+		return err
+	}
+	runs, err := queue.History(dogHistoryJob, dogHistoryLimit)
+	if err != nil {
+		return err
+	}
+
+	if dogHistoryJSON {
+		if runs == nil {
+			runs = []*dog.JobRun{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runs)
+	}
+
+	if len(runs) == 0 {
+		fmt.Println("No dog job history.")
+		return nil
+	}
+
+	now := time.Now()
+	for _, run := range runs {
+		status := string(run.Status)
+		switch {
+		case run.Retrying():
+			status = style.Warning.Render("failed, retried")
+		case run.Status == dog.RunSucceeded:
+			status = style.Success.Render(status)
+		case run.Status == dog.RunFailed:
+			status = style.Warning.Render(status)
+		}
+		fmt.Printf("%s  %s\n", style.Bold.Render(run.ID), status)
+
+		details := []string{}
+		if run.FinishedAt != nil {
+			details = append(details, formatWorkerAge(now.Sub(*run.FinishedAt))+" ago")
+		}
+		if run.Dog != "" {
+			details = append(details, "on "+run.Dog)
+		}
+		details = append(details, run.Trigger)
+		if run.Attempt > 0 {
+			details = append(details, fmt.Sprintf("attempt %d/%d", run.Attempt, run.MaxAttempts))
+		}
+		if d := run.Duration(); d > 0 {
+			details = append(details, "took "+d.Round(time.Second).String())
+		}
+		fmt.Printf("    %s\n", style.Dim.Render(strings.Join(details, " · ")))
+		if run.Error != "" {
+			fmt.Printf("    %s\n", run.Error)
+		}
+		for _, line := range strings.Split(run.Output, "\n") {
+			if line != "" {
+				fmt.Printf("    %s\n", style.Dim.Render(line))
+			}
+		}
+	}
+	return nil
+}
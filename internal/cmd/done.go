@@ -13,6 +13,7 @@ import (
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/witness"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -302,25 +303,18 @@ func runDone(cmd *cobra.Command, args []string) error {
 	townRouter := mail.NewRouter(townRoot)
 	witnessAddr := fmt.Sprintf("%s/witness", rigName)
 
-	// Build notification body
-	var bodyLines []string
-	bodyLines = append(bodyLines, fmt.Sprintf("Exit: %s", exitType))
-	if issueID != "" {
-		bodyLines = append(bodyLines, fmt.Sprintf("Issue: %s", issueID))
-	}
-	if mrID != "" {
-		bodyLines = append(bodyLines, fmt.Sprintf("MR: %s", mrID))
-	}
-	if doneGate != "" {
-		bodyLines = append(bodyLines, fmt.Sprintf("Gate: %s", doneGate))
-	}
-	bodyLines = append(bodyLines, fmt.Sprintf("Branch: %s", branch))
-
 	doneNotification := &mail.Message{
 		To:      witnessAddr,
 		From:    sender,
 		Subject: fmt.Sprintf("POLECAT_DONE %s", polecatName),
-		Body:    strings.Join(bodyLines, "\n"),
+		Body: witness.FormatPolecatDone(witness.PolecatDonePayload{
+			PolecatName: polecatName,
+			Exit:        exitType,
+			IssueID:     issueID,
+			MRID:        mrID,
+			Branch:      branch,
+			Gate:        doneGate,
+		}),
 	}
 
 	fmt.Printf("\nNotifying Witness...\n")
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/escalation"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/style"
@@ -50,6 +51,16 @@ The escalation creates an audit trail bead and sends mail to the overseer
 with appropriate priority. All molecular algebra edge cases should escalate
 here rather than failing silently.
 
+Escalations are tracked from open to acknowledged to resolved. The daemon
+re-escalates ones that miss their per-severity SLA, nudging the mayor and
+re-sending through outbound notification channels (see 'gt escalate list').
+
+Commands:
+  gt escalate ack <id>       Acknowledge an escalation
+  gt escalate resolve <id>   Resolve an escalation
+  gt escalate list           Show open and acknowledged escalations
+  gt escalate report         Time-to-ack and time-to-resolve per rig
+
 Examples:
   gt escalate "Database migration failed"
   gt escalate -s CRITICAL "Data corruption detected in user table"
@@ -118,10 +129,10 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 		bodyParts = append(bodyParts, "")
 		bodyParts = append(bodyParts, escalateMessage)
 	}
-	body := strings.Join(bodyParts, "\n")
 
 	// Dry run mode
 	if escalateDryRun {
+		body := strings.Join(bodyParts, "\n")
 		fmt.Printf("Would create escalation:\n")
 		fmt.Printf("  Severity: %s\n", severity)
 		fmt.Printf("  Priority: %s\n", priority)
@@ -140,6 +151,22 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 		fmt.Printf("%s Created escalation bead: %s\n", style.Bold.Render("📋"), beadID)
 	}
 
+	// Track the escalation so its SLA can be enforced
+	esc := &escalation.Escalation{
+		Topic:    topic,
+		Message:  escalateMessage,
+		Severity: severity,
+		From:     agentID,
+		BeadID:   beadID,
+	}
+	if err := escalation.NewStore(townRoot).Create(esc); err != nil {
+		style.PrintWarning("could not record escalation: %v", err)
+		esc = nil
+	} else {
+		bodyParts = append(bodyParts, "", fmt.Sprintf("Acknowledge with: gt escalate ack %s", esc.ID))
+	}
+	body := strings.Join(bodyParts, "\n")
+
 	// Send mail to overseer
 	router := mail.NewRouter(townRoot)
 	msg := &mail.Message{
@@ -157,9 +184,15 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 	// Log to activity feed
 	payload := events.EscalationPayload("", agentID, "overseer", topic)
 	payload["severity"] = severity
+	if escalateMessage != "" {
+		payload["message"] = escalateMessage
+	}
 	if beadID != "" {
 		payload["bead"] = beadID
 	}
+	if esc != nil {
+		payload["escalation"] = esc.ID
+	}
 	_ = events.LogFeed(events.TypeEscalationSent, agentID, payload)
 
 	// Print confirmation with severity-appropriate styling
@@ -175,6 +208,9 @@ func runEscalate(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("%s Escalation sent to overseer [%s]\n", emoji, severity)
 	fmt.Printf("   Topic: %s\n", topic)
+	if esc != nil {
+		fmt.Printf("   ID:    %s\n", esc.ID)
+	}
 	if beadID != "" {
 		fmt.Printf("   Bead:  %s\n", beadID)
 	}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/escalation"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	escalateNote       string
+	escalateListAll    bool
+	escalateListRig    string
+	escalateListJSON   bool
+	escalateSince      string
+	escalateReportJSON bool
+)
+
+var escalateAckCmd = &cobra.Command{
+	Use:   "ack <id>",
+	Short: "Acknowledge an escalation",
+	Long: `Acknowledge an escalation, stopping re-escalation for its ack SLA.
+
+The escalation can be named by its ID (esc-...) or its bead ID. The agent
+that raised it is mailed that someone is on it. An acknowledged escalation
+is re-escalated again if it isn't resolved within its resolve SLA.
+
+Examples:
+  gt escalate ack esc-20260310-a1b2c3
+  gt escalate ack gt-x7k2m -m "Looking at the migration now"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEscalateAck,
+}
+
+var escalateResolveCmd = &cobra.Command{
+	Use:   "resolve <id>",
+	Short: "Resolve an escalation",
+	Long: `Resolve an escalation and close its bead.
+
+An escalation resolved without being acknowledged counts as acknowledged
+at the same time. The agent that raised it is mailed the resolution.
+
+Examples:
+  gt escalate resolve esc-20260310-a1b2c3 -m "Rebased by hand and pushed"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEscalateResolve,
+}
+
+var escalateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List escalations and their SLA status",
+	Long: `List open and acknowledged escalations with their age and SLA status.
+
+SLAs are set per severity in settings/config.json; both timers run from
+when the escalation was raised, and "0" disables one:
+
+  "escalations": {
+    "sla": {
+      "CRITICAL": {"ack": "15m", "resolve": "4h"},
+      "HIGH":     {"ack": "1h",  "resolve": "24h"},
+      "MEDIUM":   {"ack": "4h",  "resolve": "72h", "renotify": "8h"}
+    },
+    "max_renotify": 10
+  }
+
+The values shown are the defaults. Once an SLA is missed the daemon
+nudges the mayor and re-sends the escalation on outbound notification
+routes for escalation_sent (or escalation_breached), repeating every
+"renotify" interval (default: the missed timer) until it is acknowledged
+or resolved.
+
+Examples:
+  gt escalate list
+  gt escalate list --all --rig gastown
+  gt escalate list --json`,
+	Args: cobra.NoArgs,
+	RunE: runEscalateList,
+}
+
+var escalateReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report time-to-ack and time-to-resolve per rig",
+	Long: `Summarize escalations per rig: how many are open, acknowledged and
+resolved, how many missed an SLA, and the mean and worst time to
+acknowledge and to resolve. Escalations raised by town-level agents
+(mayor, deacon) are reported under "town".
+
+Examples:
+  gt escalate report
+  gt escalate report --since 7d
+  gt escalate report --since 30d --json`,
+	Args: cobra.NoArgs,
+	RunE: runEscalateReport,
+}
+
+func init() {
+	escalateAckCmd.Flags().StringVarP(&escalateNote, "message", "m", "", "Note for the escalating agent")
+	escalateResolveCmd.Flags().StringVarP(&escalateNote, "message", "m", "", "How the escalation was resolved")
+
+	escalateListCmd.Flags().BoolVarP(&escalateListAll, "all", "a", false, "Include resolved escalations")
+	escalateListCmd.Flags().StringVar(&escalateListRig, "rig", "", "Only show escalations from this rig (\"town\" for town-level agents)")
+	escalateListCmd.Flags().BoolVar(&escalateListJSON, "json", false, "Output as JSON")
+
+	escalateReportCmd.Flags().StringVar(&escalateSince, "since", "", "Only escalations raised within this window (e.g., 24h, 7d)")
+	escalateReportCmd.Flags().BoolVar(&escalateReportJSON, "json", false, "Output as JSON")
+
+	escalateCmd.AddCommand(escalateAckCmd)
+	escalateCmd.AddCommand(escalateResolveCmd)
+	escalateCmd.AddCommand(escalateListCmd)
+	escalateCmd.AddCommand(escalateReportCmd)
+}
+
+func runEscalateAck(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	actor := detectSender()
+
+	esc, err := escalation.NewStore(townRoot).Ack(args[0], actor, escalateNote, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if esc.BeadID != "" {
+		if err := beads.New(townRoot).Update(esc.BeadID, beads.UpdateOptions{AddLabels: []string{"acknowledged"}}); err != nil {
+			style.PrintWarning("could not label escalation bead %s: %v", esc.BeadID, err)
+		}
+	}
+	replyToEscalator(townRoot, esc, actor, "Escalation acknowledged", escalateNote)
+	_ = events.LogFeed(events.TypeEscalationAcked, actor,
+		events.EscalationLifecyclePayload(esc.ID, esc.Rig, esc.Severity, esc.Topic, actor, escalateNote))
+
+	ack, _ := esc.TimeToAck()
+	fmt.Printf("%s Acknowledged %s after %s\n", style.Success.Render("✓"), esc.ID, formatWorkerAge(ack))
+	fmt.Printf("   Topic: %s\n", esc.Topic)
+	return nil
+}
+
+func runEscalateResolve(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	actor := detectSender()
+
+	esc, err := escalation.NewStore(townRoot).Resolve(args[0], actor, escalateNote, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if esc.BeadID != "" {
+		reason := escalateNote
+		if reason == "" {
+			reason = "Escalation resolved by " + actor
+		}
+		if err := beads.New(townRoot).CloseWithReason(reason, esc.BeadID); err != nil {
+			style.PrintWarning("could not close escalation bead %s: %v", esc.BeadID, err)
+		}
+	}
+	replyToEscalator(townRoot, esc, actor, "Escalation resolved", escalateNote)
+	_ = events.LogFeed(events.TypeEscalationResolved, actor,
+		events.EscalationLifecyclePayload(esc.ID, esc.Rig, esc.Severity, esc.Topic, actor, escalateNote))
+
+	resolved, _ := esc.TimeToResolve()
+	fmt.Printf("%s Resolved %s after %s\n", style.Success.Render("✓"), esc.ID, formatWorkerAge(resolved))
+	fmt.Printf("   Topic: %s\n", esc.Topic)
+	return nil
+}
+
+// replyToEscalator mails the agent that raised an escalation about its
+// progress. Failures are warnings: the escalation has already moved on.
+func replyToEscalator(townRoot string, esc *escalation.Escalation, actor, what, note string) {
+	if esc.From == "" || esc.From == "unknown" || esc.From == actor {
+		return
+	}
+	body := fmt.Sprintf("%s by %s\nEscalation: %s\nSeverity: %s", what, actor, esc.ID, esc.Severity)
+	if note != "" {
+		body += "\n\n" + note
+	}
+	msg := &mail.Message{
+		From:    actor,
+		To:      esc.From,
+		Subject: fmt.Sprintf("%s: %s", what, esc.Topic),
+		Body:    body,
+	}
+	if err := mail.NewRouter(townRoot).Send(msg); err != nil {
+		style.PrintWarning("could not mail %s: %v", esc.From, err)
+	}
+}
+
+// escalationSLAStatus describes where an unresolved escalation stands
+// against its SLA.
+func escalationSLAStatus(esc *escalation.Escalation, cfg *config.EscalationConfig, now time.Time) string {
+	ack, resolve, _ := cfg.Timers(esc.Severity)
+	limit, what := ack, "ack"
+	if esc.State == escalation.StateAcknowledged {
+		limit, what = resolve, "resolve"
+	}
+	if limit <= 0 {
+		return "no SLA"
+	}
+	age := now.Sub(esc.CreatedAt)
+	if age >= limit {
+		status := fmt.Sprintf("%s SLA missed by %s", what, formatWorkerAge(age-limit))
+		if esc.Renotified > 0 {
+			status += fmt.Sprintf(", re-escalated %d×", esc.Renotified)
+		}
+		return status
+	}
+	return fmt.Sprintf("%s due in %s", what, formatWorkerAge(limit-age))
+}
+
+func runEscalateList(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	all, err := escalation.NewStore(townRoot).List()
+	if err != nil {
+		return fmt.Errorf("reading escalations: %w", err)
+	}
+
+	var list []*escalation.Escalation
+	for _, esc := range all {
+		if !escalateListAll && esc.State == escalation.StateResolved {
+			continue
+		}
+		if escalateListRig != "" && esc.Rig != escalateListRig && (escalateListRig != "town" || esc.Rig != "") {
+			continue
+		}
+		list = append(list, esc)
+	}
+
+	if escalateListJSON {
+		if list == nil {
+			list = []*escalation.Escalation{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No open escalations.")
+		return nil
+	}
+
+	var cfg *config.EscalationConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot)); err == nil {
+		cfg = settings.Escalations
+	}
+	now := time.Now()
+	for _, esc := range list {
+		state := esc.State
+		switch esc.State {
+		case escalation.StateOpen:
+			state = style.Warning.Render(state)
+		case escalation.StateResolved:
+			state = style.Success.Render(state)
+		}
+		fmt.Printf("%s  %-8s %s  %s\n", style.Bold.Render(esc.ID), esc.Severity, state, esc.Topic)
+
+		details := []string{"from " + esc.From, formatWorkerAge(now.Sub(esc.CreatedAt)) + " old"}
+		if esc.AckedBy != "" {
+			details = append(details, "acked by "+esc.AckedBy)
+		}
+		if esc.State == escalation.StateResolved {
+			details = append(details, "resolved by "+esc.ResolvedBy)
+		} else {
+			details = append(details, escalationSLAStatus(esc, cfg, now))
+		}
+		if esc.BeadID != "" {
+			details = append(details, esc.BeadID)
+		}
+		fmt.Printf("    %s\n", style.Dim.Render(strings.Join(details, " · ")))
+	}
+	return nil
+}
+
+func runEscalateReport(cmd *cobra.Command, args []string) error {
+	var since time.Time
+	if escalateSince != "" {
+		window, err := parseDuration(escalateSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = time.Now().Add(-window)
+	}
+
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	all, err := escalation.NewStore(townRoot).List()
+	if err != nil {
+		return fmt.Errorf("reading escalations: %w", err)
+	}
+	reports := escalation.Report(all, since)
+
+	if escalateReportJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No escalations in this window.")
+		return nil
+	}
+
+	fmt.Printf("%-14s %5s %5s %5s %5s %7s  %-14s %-14s\n",
+		"RIG", "TOTAL", "OPEN", "ACKED", "DONE", "MISSED", "ACK (avg/max)", "RESOLVE (avg/max)")
+	for _, r := range reports {
+		rig := r.Rig
+		if rig == "" {
+			rig = "town"
+		}
+		fmt.Printf("%-14s %5d %5d %5d %5d %7d  %-14s %-14s\n",
+			rig, r.Total, r.Open, r.Acknowledged, r.Resolved, r.Breached,
+			formatReportTimes(r.MeanAck, r.MaxAck), formatReportTimes(r.MeanResolve, r.MaxResolve))
+	}
+	return nil
+}
+
+// formatReportTimes formats a mean and max duration as "avg/max", or "-"
+// when nothing was measured.
+func formatReportTimes(mean, maxD time.Duration) string {
+	if maxD == 0 {
+		return "-"
+	}
+	return formatWorkerAge(mean) + "/" + formatWorkerAge(maxD)
+}
@@ -55,7 +55,8 @@ By default, launches an interactive TUI dashboard with:
 
 The feed combines multiple event sources:
   - Beads activity: Issue creates, updates, completions (from bd activity)
-  - GT events: Agent activity like patrol, sling, handoff (from .events.jsonl)
+  - GT events: Agent activity like patrol, sling, handoff (from the daemon's
+    event bus, or .events.jsonl when no daemon is running)
   - Convoy status: In-progress and recently-landed convoys (refreshes every 10s)
 
 Use --plain for simple text output (wraps bd activity only).
@@ -218,9 +219,11 @@ func runFeedTUI(workDir string) error {
 		sources = append(sources, mqSource)
 	}
 
-	// Create GT events source (optional - don't fail if not available)
-	gtSource, err := feed.NewGtEventsSource(townRoot)
-	if err == nil {
+	// Create GT events source (optional - don't fail if not available).
+	// Prefer the daemon's event bus; fall back to tailing .events.jsonl.
+	if busSource, err := feed.NewBusSource(townRoot); err == nil {
+		sources = append(sources, busSource)
+	} else if gtSource, err := feed.NewGtEventsSource(townRoot); err == nil {
 		sources = append(sources, gtSource)
 	}
 
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/workspace"
 	"golang.org/x/text/cases"
@@ -24,6 +25,7 @@ var (
 	formulaRunPR      int
 	formulaRunRig     string
 	formulaRunDryRun  bool
+	formulaRunVars    []string
 	formulaCreateType string
 )
 
@@ -103,16 +105,37 @@ This command:
 
 For PR-based workflows, use --pr to specify the GitHub PR number.
 
+Workflow formulas are executed step by step in dependency order. Steps with
+a run command execute here via sh; other steps are created as beads, slung
+to the rig, and waited on until closed. Steps support when conditions,
+foreach fan-out, timeouts, retries and named outputs:
+
+  [[steps]]
+  id = "build"
+  needs = ["version"]
+  foreach = "{{targets}}"
+  when = "{{env}} != dev"
+  run = "make build TARGET={{item}} VERSION={{steps.version.tag}}"
+  timeout = "10m"
+  retry = { attempts = 3, backoff = "30s" }
+  outputs = ["artifact"]    # written as name=value lines to $GT_OUTPUT
+
+Values substituted into a run command are shell-quoted, so each one is a
+single literal word. These execution fields apply only to gt formula run;
+molecules created with bd cook / gt mol ignore them.
+
 Options:
-  --pr=N      Run formula on GitHub PR #N
-  --rig=NAME  Target specific rig (default: current or gastown)
-  --dry-run   Show what would happen without executing
+  --pr=N            Run formula on GitHub PR #N
+  --rig=NAME        Target specific rig (default: current or gastown)
+  --var NAME=VALUE  Set a formula variable (repeatable)
+  --dry-run         Show what would happen without executing
 
 Examples:
   gt formula run shiny                    # Run formula in current rig
   gt formula run shiny --pr=123           # Run on PR #123
   gt formula run security-audit --rig=beads  # Run in specific rig
-  gt formula run release --dry-run        # Preview execution`,
+  gt formula run release --dry-run        # Preview execution
+  gt formula run release --var targets=linux,darwin  # Workflow with vars`,
 	Args: cobra.ExactArgs(1),
 	RunE: runFormulaRun,
 }
@@ -149,6 +172,7 @@ func init() {
 	formulaRunCmd.Flags().IntVar(&formulaRunPR, "pr", 0, "GitHub PR number to run formula on")
 	formulaRunCmd.Flags().StringVar(&formulaRunRig, "rig", "", "Target rig (default: current or gastown)")
 	formulaRunCmd.Flags().BoolVar(&formulaRunDryRun, "dry-run", false, "Preview execution without running")
+	formulaRunCmd.Flags().StringArrayVar(&formulaRunVars, "var", nil, "Formula variable as name=value (repeatable)")
 
 	// Create flags
 	formulaCreateCmd.Flags().StringVar(&formulaCreateType, "type", "task", "Formula type: task, workflow, or patrol")
@@ -223,16 +247,32 @@ func runFormulaRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Workflow formulas go through the step engine
+	if f.Type == "workflow" {
+		wf, err := formula.ParseFile(formulaPath)
+		if err != nil {
+			return fmt.Errorf("parsing formula: %w", err)
+		}
+		if formulaRunDryRun {
+			return dryRunWorkflowFormula(wf, formulaName, targetRig)
+		}
+		vars, err := parseFormulaVars(formulaRunVars)
+		if err != nil {
+			return err
+		}
+		return executeWorkflowFormula(wf, formulaName, targetRig, vars)
+	}
+
 	// Handle dry-run mode
 	if formulaRunDryRun {
 		return dryRunFormula(f, formulaName, targetRig)
 	}
 
-	// Currently only convoy formulas are supported for execution
+	// Expansion and aspect formulas are cooked into molecules by bd
 	if f.Type != "convoy" {
 		fmt.Printf("%s Formula type '%s' not yet supported for execution.\n",
 			style.Dim.Render("Note:"), f.Type)
-		fmt.Printf("Currently only 'convoy' formulas can be run.\n")
+		fmt.Printf("Only 'convoy' and 'workflow' formulas can be run.\n")
 		fmt.Printf("\nTo run '%s' manually:\n", formulaName)
 		fmt.Printf("  1. View formula:   gt formula show %s\n", formulaName)
 		fmt.Printf("  2. Cook to proto:  bd cook %s\n", formulaName)
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/formula"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// agentStepPollInterval is how often an agent step's bead is checked for
+// completion.
+const agentStepPollInterval = 15 * time.Second
+
+// parseFormulaVars turns --var name=value flags into a map.
+func parseFormulaVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid --var %q: want name=value", pair)
+		}
+		vars[strings.TrimSpace(name)] = value
+	}
+	return vars, nil
+}
+
+// dryRunWorkflowFormula prints the execution plan for a workflow formula.
+func dryRunWorkflowFormula(f *formula.Formula, formulaName, targetRig string) error {
+	order, err := f.TopologicalSort()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s Would execute formula:\n", style.Dim.Render("[dry-run]"))
+	fmt.Printf("  Formula: %s\n", style.Bold.Render(formulaName))
+	fmt.Printf("  Type:    %s\n", f.Type)
+	fmt.Printf("  Rig:     %s\n", targetRig)
+
+	fmt.Printf("\n  Steps (%d):\n", len(order))
+	for _, id := range order {
+		step := f.GetStep(id)
+		kind := "agent"
+		if step.Run != "" {
+			kind = "shell"
+		}
+		fmt.Printf("    • %s [%s]", id, kind)
+		if step.Title != "" {
+			fmt.Printf(": %s", step.Title)
+		}
+		fmt.Println()
+
+		var details []string
+		if len(step.Needs) > 0 {
+			details = append(details, "needs "+strings.Join(step.Needs, ", "))
+		}
+		if step.When != "" {
+			details = append(details, "when "+step.When)
+		}
+		if step.Foreach != "" {
+			details = append(details, "foreach "+step.Foreach)
+		}
+		if step.Timeout != "" {
+			details = append(details, "timeout "+step.Timeout)
+		}
+		if step.Retry != nil && step.Retry.Attempts > 1 {
+			details = append(details, fmt.Sprintf("retry %dx", step.Retry.Attempts))
+		}
+		if len(step.Outputs) > 0 {
+			details = append(details, "outputs "+strings.Join(step.Outputs, ", "))
+		}
+		if len(details) > 0 {
+			fmt.Printf("      %s\n", style.Dim.Render(strings.Join(details, "; ")))
+		}
+	}
+
+	return nil
+}
+
+// executeWorkflowFormula runs a workflow formula step by step. Shell steps
+// run here; agent steps become beads slung to the target rig.
+func executeWorkflowFormula(f *formula.Formula, formulaName, targetRig string, vars map[string]string) error {
+	resolved, err := f.ResolveVars(vars)
+	if err != nil {
+		return err
+	}
+
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		return fmt.Errorf("not in a Gas Town workspace")
+	}
+
+	cwd, _ := os.Getwd()
+	runner := &workflowStepRunner{
+		shell: &formula.ShellRunner{
+			Dir:    cwd,
+			Env:    []string{"GT_FORMULA=" + formulaName},
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		},
+		townRoot: townRoot,
+		rig:      targetRig,
+	}
+
+	engine := &formula.Engine{
+		Runner: runner,
+		OnStart: func(run *formula.StepRun) {
+			line := fmt.Sprintf("%s %s", style.Bold.Render("▶"), run.Instance)
+			if run.Title != "" {
+				line += ": " + run.Title
+			}
+			if run.Attempt > 1 {
+				line += style.Dim.Render(fmt.Sprintf(" (attempt %d)", run.Attempt))
+			}
+			fmt.Println(line)
+		},
+		OnFinish: func(res *formula.StepResult) {
+			switch res.Status {
+			case formula.StepDone:
+				fmt.Printf("%s %s\n", style.Success.Render("✓"), res.ID)
+			case formula.StepSkipped:
+				fmt.Printf("%s %s %s\n", style.Dim.Render("⊘"), res.ID, style.Dim.Render("skipped ("+res.Reason+")"))
+			case formula.StepBlocked:
+				fmt.Printf("%s %s %s\n", style.Warning.Render("⊘"), res.ID, style.Dim.Render("blocked ("+res.Reason+")"))
+			case formula.StepFailed:
+				fmt.Printf("%s %s: %s\n", style.Error.Render("✗"), res.ID, res.Error)
+			}
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("%s Executing workflow formula: %s\n\n", style.Bold.Render("⚙"), formulaName)
+	result, err := engine.Execute(ctx, f, resolved)
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[formula.StepStatus]int)
+	for _, s := range result.Steps {
+		counts[s.Status]++
+	}
+	fmt.Printf("\n%d done, %d skipped, %d failed, %d blocked\n",
+		counts[formula.StepDone], counts[formula.StepSkipped], counts[formula.StepFailed], counts[formula.StepBlocked])
+
+	if result.Failed() {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+// workflowStepRunner runs shell steps locally and dispatches agent steps
+// (steps without a run command) as beads slung to the rig.
+type workflowStepRunner struct {
+	shell    *formula.ShellRunner
+	townRoot string
+	rig      string
+}
+
+// RunStep implements formula.Runner.
+func (r *workflowStepRunner) RunStep(ctx context.Context, run *formula.StepRun) (map[string]string, error) {
+	if run.Run != "" {
+		return r.shell.RunStep(ctx, run)
+	}
+	return r.runAgentStep(ctx, run)
+}
+
+// runAgentStep creates a task bead for the step, slings it to the rig and
+// waits for it to close. Outputs are read back from "<name>: <value>" lines
+// the agent adds to the bead description.
+func (r *workflowStepRunner) runAgentStep(ctx context.Context, run *formula.StepRun) (map[string]string, error) {
+	rigPath := filepath.Join(r.townRoot, r.rig)
+	b := beads.New(rigPath)
+
+	title := run.Title
+	if title == "" {
+		title = run.Instance
+	}
+	description := run.Description
+	if len(run.Outputs) > 0 {
+		description += "\n\n---\nWhen done, record these outputs as lines in this bead's description before closing it:\n"
+		for _, name := range run.Outputs {
+			description += fmt.Sprintf("  %s: <value>\n", name)
+		}
+	}
+
+	issue, err := b.Create(beads.CreateOptions{
+		Title:       title,
+		Type:        "task",
+		Priority:    2,
+		Description: description,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating step bead: %w", err)
+	}
+	fmt.Printf("  %s Created %s\n", style.Dim.Render("○"), issue.ID)
+
+	slingCmd := exec.CommandContext(ctx, "gt", "sling", issue.ID, r.rig) //nolint:gosec // G204: args are constructed internally
+	slingCmd.Stdout = os.Stdout
+	slingCmd.Stderr = os.Stderr
+	if err := slingCmd.Run(); err != nil {
+		return nil, fmt.Errorf("slinging %s: %w", issue.ID, err)
+	}
+
+	store := beads.SharedStore(rigPath)
+	ticker := time.NewTicker(agentStepPollInterval)
+	defer ticker.Stop()
+	for {
+		current, err := store.Show(issue.ID)
+		if err == nil && current.Status == "closed" {
+			return parseStepOutputs(current.Description, run.Outputs), nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseStepOutputs reads "<name>: <value>" lines for the declared outputs.
+func parseStepOutputs(description string, names []string) map[string]string {
+	outputs := make(map[string]string)
+	for _, line := range strings.Split(description, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		for _, name := range names {
+			if key == name && strings.TrimSpace(value) != "<value>" {
+				outputs[name] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return outputs
+}
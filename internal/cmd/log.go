@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/checkpoint"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/townlog"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -342,6 +344,11 @@ func runLogCrash(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Capture a crashed polecat's progress so its restart can resume it
+	if eventType == townlog.EventCrash {
+		checkpointCrashedPolecat(townRoot, crashAgent)
+	}
+
 	// Log the event
 	logger := townlog.NewLogger(townRoot)
 	if err := logger.Log(eventType, crashAgent, context); err != nil {
@@ -351,6 +358,21 @@ func runLogCrash(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// checkpointCrashedPolecat snapshots a polecat whose pane died. agent is
+// the hook's "rig/name" address; other agents have no checkpoint. It is
+// best-effort: the daemon snapshots on restart if this fails.
+func checkpointCrashedPolecat(townRoot, agent string) {
+	rigName, polecatName, ok := strings.Cut(agent, "/")
+	if !ok || strings.Contains(polecatName, "/") {
+		return
+	}
+	polecatDir := filepath.Join(townRoot, rigName, "polecats", polecatName)
+	if _, err := os.Stat(polecatDir); err != nil {
+		return
+	}
+	_, _ = checkpoint.Snapshot(polecatDir, rigName+"/polecats/"+polecatName, checkpoint.TriggerPaneDied)
+}
+
 // LogEvent is a helper that logs an event from anywhere in the codebase.
 // It finds the town root and logs the event.
 func LogEvent(eventType townlog.EventType, agent, context string) error {
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/connection"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Machine command flags
+var (
+	machineJSON     bool
+	machineKey      string
+	machineTownPath string
+)
+
+var machineCmd = &cobra.Command{
+	Use:     "machine",
+	GroupID: GroupConfig,
+	Short:   "Manage machines that can host rigs",
+	RunE:    requireSubcommand,
+	Long: `Manage the machines registered in mayor/machines.json.
+
+A rig added with 'gt rig add --machine <name>' runs its polecats, witness
+and refinery on that machine over SSH. The remote needs git, tmux, gt and
+the agent runtime installed, and key-based SSH access (no password prompts).
+
+Commands:
+  gt machine list                        List registered machines
+  gt machine add <name> <user@host>      Register an SSH machine
+  gt machine remove <name>               Unregister a machine
+  gt machine check <name>                Verify SSH connectivity`,
+}
+
+var machineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered machines",
+	RunE:  runMachineList,
+}
+
+var machineAddCmd = &cobra.Command{
+	Use:   "add <name> <user@host>",
+	Short: "Register an SSH machine",
+	Long: `Register a machine reachable over SSH.
+
+The town path is where rig workspaces are mirrored on the remote. It
+defaults to the same path as the local town root.
+
+Examples:
+  gt machine add buildbox gt@build.internal
+  gt machine add gpu gt@10.0.0.7 --key ~/.ssh/gt_ed25519 --town-path /srv/gt`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMachineAdd,
+}
+
+var machineRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a machine",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMachineRemove,
+}
+
+var machineCheckCmd = &cobra.Command{
+	Use:   "check <name>",
+	Short: "Verify SSH connectivity to a machine",
+	Long: `Connect to a machine and check that the tools agents need are present.
+
+Examples:
+  gt machine check buildbox`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMachineCheck,
+}
+
+// loadMachineRegistry opens the town's machine registry.
+func loadMachineRegistry() (*connection.MachineRegistry, string, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, "", fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	registry, err := connection.NewMachineRegistry(constants.MayorMachinesPath(townRoot))
+	if err != nil {
+		return nil, "", err
+	}
+	return registry, townRoot, nil
+}
+
+func runMachineList(cmd *cobra.Command, args []string) error {
+	registry, _, err := loadMachineRegistry()
+	if err != nil {
+		return err
+	}
+
+	machines := registry.List()
+	sort.Slice(machines, func(i, j int) bool {
+		return machines[i].Name < machines[j].Name
+	})
+
+	if machineJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(machines)
+	}
+
+	fmt.Printf("%s\n\n", style.Bold.Render("Machines"))
+	for _, m := range machines {
+		fmt.Printf("  %s  %s", style.Bold.Render(m.Name), style.Dim.Render(m.Type))
+		if m.Host != "" {
+			fmt.Printf("  %s", m.Host)
+		}
+		if m.TownPath != "" {
+			fmt.Printf("  %s", style.Dim.Render(m.TownPath))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func runMachineAdd(cmd *cobra.Command, args []string) error {
+	name, host := args[0], args[1]
+
+	registry, townRoot, err := loadMachineRegistry()
+	if err != nil {
+		return err
+	}
+	if _, err := registry.Get(name); err == nil {
+		return fmt.Errorf("machine '%s' already exists", name)
+	}
+
+	townPath := machineTownPath
+	if townPath == "" {
+		townPath = townRoot
+	}
+
+	if err := registry.Add(&connection.Machine{
+		Name:     name,
+		Type:     "ssh",
+		Host:     host,
+		KeyPath:  machineKey,
+		TownPath: townPath,
+	}); err != nil {
+		return fmt.Errorf("adding machine: %w", err)
+	}
+
+	fmt.Printf("%s Added machine %s (%s)\n", style.Success.Render("✓"), style.Bold.Render(name), host)
+	fmt.Printf("  Town path: %s\n", townPath)
+	fmt.Printf("\nNext: gt machine check %s\n", name)
+	return nil
+}
+
+func runMachineRemove(cmd *cobra.Command, args []string) error {
+	registry, _, err := loadMachineRegistry()
+	if err != nil {
+		return err
+	}
+	if err := registry.Remove(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("%s Removed machine %s\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+func runMachineCheck(cmd *cobra.Command, args []string) error {
+	registry, _, err := loadMachineRegistry()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = registry.Close() }()
+
+	conn, err := registry.Connection(args[0])
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, tool := range []string{"git", "tmux", "gt"} {
+		out, err := conn.Exec("sh", "-c", "command -v "+tool)
+		if err != nil {
+			var connErr *connection.ConnectionError
+			if errors.As(err, &connErr) {
+				return err
+			}
+			fmt.Printf("  %s %s not found\n", style.Warning.Render("!"), tool)
+			failed = true
+			continue
+		}
+		fmt.Printf("  %s %s %s\n", style.Success.Render("✓"), tool, style.Dim.Render(strings.TrimSpace(string(out))))
+	}
+
+	if failed {
+		return fmt.Errorf("machine %s is missing required tools", args[0])
+	}
+	fmt.Printf("%s Machine %s is ready\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+func init() {
+	machineListCmd.Flags().BoolVar(&machineJSON, "json", false, "Output as JSON")
+
+	machineAddCmd.Flags().StringVar(&machineKey, "key", "", "SSH private key path")
+	machineAddCmd.Flags().StringVar(&machineTownPath, "town-path", "", "Town root on the remote (default: same as local)")
+
+	machineCmd.AddCommand(machineListCmd)
+	machineCmd.AddCommand(machineAddCmd)
+	machineCmd.AddCommand(machineRemoveCmd)
+	machineCmd.AddCommand(machineCheckCmd)
+
+	rootCmd.AddCommand(machineCmd)
+}
@@ -17,7 +17,10 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/protocol"
+	"github.com/steveyegge/gastown/internal/protocol/envelope"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/witness"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -58,6 +61,10 @@ var (
 
 	// Clear flags
 	mailClearAll bool
+
+	// Validate flags
+	mailValidateAll  bool
+	mailValidateJSON bool
 )
 
 var mailCmd = &cobra.Command{
@@ -396,6 +403,30 @@ Examples:
 	RunE: runMailAnnounces,
 }
 
+var mailValidateCmd = &cobra.Command{
+	Use:   "validate [address]",
+	Short: "Report malformed protocol mail",
+	Long: `Check protocol messages in an inbox against their schema.
+
+Protocol mail (MERGE_READY, MERGED, MERGE_FAILED, REWORK_REQUEST,
+POLECAT_DONE, HELP) carries a versioned JSON envelope after its
+human-readable "Key: value" text. Messages without an envelope are parsed in
+the legacy text format.
+
+A message is reported as malformed when its envelope is unreadable, newer
+than this build understands, or for a different message type than its
+subject, or when required fields are missing. Other mail is ignored.
+
+Exits non-zero if any message is malformed.
+
+Examples:
+  gt mail validate                    # Current context's inbox
+  gt mail validate greenplace/witness # Witness inbox
+  gt mail validate --all --json       # Include valid messages`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMailValidate,
+}
+
 func init() {
 	// Send flags
 	mailSendCmd.Flags().StringVarP(&mailSubject, "subject", "s", "", "Message subject (required)")
@@ -445,6 +476,10 @@ func init() {
 	// Announces flags
 	mailAnnouncesCmd.Flags().BoolVar(&mailAnnouncesJSON, "json", false, "Output as JSON")
 
+	// Validate flags
+	mailValidateCmd.Flags().BoolVar(&mailValidateAll, "all", false, "Also list valid protocol messages")
+	mailValidateCmd.Flags().BoolVar(&mailValidateJSON, "json", false, "Output as JSON")
+
 	// Clear flags
 	mailClearCmd.Flags().BoolVar(&mailClearAll, "all", false, "Clear all messages (default behavior)")
 
@@ -463,6 +498,7 @@ func init() {
 	mailCmd.AddCommand(mailClearCmd)
 	mailCmd.AddCommand(mailSearchCmd)
 	mailCmd.AddCommand(mailAnnouncesCmd)
+	mailCmd.AddCommand(mailValidateCmd)
 
 	rootCmd.AddCommand(mailCmd)
 }
@@ -1788,6 +1824,90 @@ func runMailSearch(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// mailValidation is one protocol message's validation result.
+type mailValidation struct {
+	ID      string `json:"id"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	*envelope.Validation
+}
+
+func runMailValidate(cmd *cobra.Command, args []string) error {
+	address := detectSender()
+	if len(args) > 0 {
+		address = args[0]
+	}
+
+	// All mail uses town beads (two-level architecture)
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	router := mail.NewRouter(workDir)
+	mailbox, err := router.GetMailbox(address)
+	if err != nil {
+		return fmt.Errorf("getting mailbox: %w", err)
+	}
+	messages, err := mailbox.List()
+	if err != nil {
+		return fmt.Errorf("listing messages: %w", err)
+	}
+
+	var results []mailValidation
+	checked, malformed := 0, 0
+	for _, msg := range messages {
+		v := protocol.Validate(msg.Subject, msg.Body)
+		if v == nil {
+			v = witness.Validate(msg.Subject, msg.Body)
+		}
+		if v == nil {
+			continue
+		}
+		checked++
+		if !v.Valid() {
+			malformed++
+		} else if !mailValidateAll {
+			continue
+		}
+		results = append(results, mailValidation{ID: msg.ID, From: msg.From, Subject: msg.Subject, Validation: v})
+	}
+
+	if mailValidateJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%s Protocol mail in %s: %d checked, %d malformed\n\n",
+			style.Bold.Render("🔎"), address, checked, malformed)
+		if len(results) == 0 && malformed == 0 {
+			fmt.Printf("  %s\n", style.Dim.Render("(no problems)"))
+		}
+		for _, r := range results {
+			format := fmt.Sprintf("v%d", r.Version)
+			if r.Legacy() {
+				format = "legacy"
+			}
+			marker := style.Success.Render("✓")
+			if !r.Valid() {
+				marker = style.Error.Render("✗")
+			}
+			fmt.Printf("  %s %s %s\n", marker, r.Subject, style.Dim.Render("("+format+")"))
+			fmt.Printf("    %s from %s\n", style.Dim.Render(r.ID), r.From)
+			for _, problem := range r.Problems {
+				fmt.Printf("    - %s\n", problem)
+			}
+		}
+	}
+
+	if malformed > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
 // runMailAnnounces lists announce channels or reads messages from a channel.
 func runMailAnnounces(cmd *cobra.Command, args []string) error {
 	// Find workspace
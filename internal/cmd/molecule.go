@@ -7,6 +7,11 @@ import (
 // Molecule command flags
 var (
 	moleculeJSON bool
+
+	moleculeSummary        string
+	moleculeSquashMetrics  []string
+	moleculeSquashTemplate string
+	moleculeSquashFlush    bool
 )
 
 var moleculeCmd = &cobra.Command{
@@ -200,7 +205,23 @@ The digest preserves:
 - Summary of results
 
 Use this for patrol cycles and other operational work that should have
-a permanent (but compact) record.`,
+a permanent (but compact) record.
+
+The digest body is rendered from a Go template with the molecule's steps,
+the outputs recorded on them (gt mol step done --output), the --summary
+and the --metric values. Numeric step outputs are summed into metrics. The
+template comes from --template, the formula's [squash] section, or the
+default patrol or work digest template.
+
+A formula's [squash] cadence (e.g., "1h") folds patrol cycles into one
+pending digest until the cadence is due, so frequent patrols leave one
+digest per window rather than one per cycle. Digests are rolled up into
+daily, weekly and monthly rollups (see 'gt digest show').
+
+Examples:
+  gt mol squash --summary "2 polecats nudged, queue empty"
+  gt mol squash --summary "Merged 3 branches" --metric merges=3 --metric failures=0
+  gt mol squash --flush        # record the pending digest now`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runMoleculeSquash,
 }
@@ -242,6 +263,10 @@ func init() {
 
 	// Squash flags
 	moleculeSquashCmd.Flags().BoolVar(&moleculeJSON, "json", false, "Output as JSON")
+	moleculeSquashCmd.Flags().StringVarP(&moleculeSummary, "summary", "s", "", "Summary of what the molecule did")
+	moleculeSquashCmd.Flags().StringArrayVar(&moleculeSquashMetrics, "metric", nil, "Metric for the digest as name=value (repeatable)")
+	moleculeSquashCmd.Flags().StringVar(&moleculeSquashTemplate, "template", "", "Go template file for the digest body")
+	moleculeSquashCmd.Flags().BoolVar(&moleculeSquashFlush, "flush", false, "Record the digest now, ignoring the formula's cadence")
 
 	// Add step subcommand with its children
 	moleculeStepCmd.AddCommand(moleculeStepDoneCmd)
@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/formula"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/wisp"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -172,100 +176,130 @@ func runMoleculeSquash(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("finding handoff bead: %w", err)
 	}
-	if handoff == nil {
-		return fmt.Errorf("no handoff bead found for %s", target)
-	}
 
-	// Check for attached molecule
+	// Check for attached molecule, then for a hooked patrol wisp
 	attachment := beads.ParseAttachmentFields(handoff)
-	if attachment == nil || attachment.AttachedMolecule == "" {
-		fmt.Printf("%s No molecule attached to %s - nothing to squash\n",
-			style.Dim.Render("ℹ"), target)
-		return nil
+	attached := attachment != nil && attachment.AttachedMolecule != ""
+	if !attached {
+		hooked, _ := b.List(beads.ListOptions{Status: beads.StatusHooked, Assignee: target, Priority: -1})
+		if len(hooked) == 0 {
+			if handoff == nil {
+				return fmt.Errorf("no handoff bead found for %s", target)
+			}
+			fmt.Printf("%s No molecule attached to %s - nothing to squash\n",
+				style.Dim.Render("ℹ"), target)
+			return nil
+		}
+		attachment = &beads.AttachmentFields{AttachedMolecule: hooked[0].ID, AttachedAt: hooked[0].CreatedAt}
 	}
 
 	moleculeID := attachment.AttachedMolecule
 
+	metrics, err := parseSquashMetrics(moleculeSquashMetrics)
+	if err != nil {
+		return err
+	}
+
+	// Build the digest from the steps as they stand, before closing them
+	digest, squash := buildSquashDigest(b, moleculeID, target, role, attachment, metrics)
+	digestTemplate := ""
+	if squash != nil {
+		digestTemplate = squash.Template
+	}
+	if moleculeSquashTemplate != "" {
+		data, err := os.ReadFile(moleculeSquashTemplate)
+		if err != nil {
+			return fmt.Errorf("reading template: %w", err)
+		}
+		digestTemplate = string(data)
+	}
+	var cadence time.Duration
+	if squash != nil && squash.Cadence != "" && !moleculeSquashFlush {
+		cadence, _ = time.ParseDuration(squash.Cadence)
+	}
+
 	// Recursively close all descendant step issues before squashing
 	// This prevents orphaned step issues from accumulating (gt-psj76.1)
 	childrenClosed := closeDescendants(b, moleculeID)
 
-	// Get progress info for the digest
-	progress, _ := getMoleculeProgressInfo(b, moleculeID)
-
-	// Create a digest issue
-	digestTitle := fmt.Sprintf("Digest: %s", moleculeID)
-	digestDesc := fmt.Sprintf(`Squashed molecule execution.
-
-molecule: %s
-agent: %s
-squashed_at: %s
-`, moleculeID, target, time.Now().UTC().Format(time.RFC3339))
-
-	if progress != nil {
-		digestDesc += fmt.Sprintf(`
-## Execution Summary
-- Steps: %d/%d completed
-- Status: %s
-`, progress.DoneSteps, progress.TotalSteps, func() string {
-			if progress.Complete {
-				return "complete"
-			}
-			return "partial"
-		}())
-	}
-
-	// Create the digest bead
-	digestIssue, err := b.Create(beads.CreateOptions{
-		Title:       digestTitle,
-		Description: digestDesc,
-		Type:        "task",
-		Priority:    4, // P4 - backlog priority for digests
-		Actor:       target,
-	})
+	// Fold the cycle into the agent's pending digest; it is recorded once
+	// the formula's cadence is due
+	due, err := wisp.Fold(townRoot, digest, cadence, digestTemplate)
 	if err != nil {
-		return fmt.Errorf("creating digest: %w", err)
+		return fmt.Errorf("recording digest: %w", err)
 	}
 
-	// Add the digest label (non-fatal: digest works without label)
-	_ = b.Update(digestIssue.ID, beads.UpdateOptions{
-		AddLabels: []string{"digest"},
-	})
+	var digestID string
+	if due != nil {
+		if err := wisp.RenderDigest(due, digestTemplate); err != nil {
+			style.PrintWarning("digest template failed, using the default: %v", err)
+		}
+		digestIssue, err := createDigestBead(b, due, target)
+		if err != nil {
+			return err
+		}
+		digestID = digestIssue.ID
+		due.BeadID = digestID
+		if err := wisp.SetPendingBead(townRoot, due); err != nil {
+			style.PrintWarning("%v", err)
+		}
+		if err := wisp.AppendDigest(townRoot, due); err != nil {
+			style.PrintWarning("couldn't record digest for rollups, retrying on the next squash: %v", err)
+		} else if err := wisp.ClearPending(townRoot, due.Agent); err != nil {
+			style.PrintWarning("%v", err)
+		}
+		_ = events.LogFeed(events.TypeDigest, target, events.DigestPayload(target, due.Period, moleculeID, digestID, due.Cycles))
+	}
 
-	// Close the digest immediately
-	closedStatus := "closed"
-	err = b.Update(digestIssue.ID, beads.UpdateOptions{
-		Status: &closedStatus,
-	})
-	if err != nil {
-		style.PrintWarning("Created digest but couldn't close it: %v", err)
+	reason := fmt.Sprintf("molecule squashed to digest %s", digestID)
+	if due == nil {
+		reason = "molecule squashed into pending digest"
 	}
 
-	// Detach the molecule from the handoff bead with audit logging
-	_, err = b.DetachMoleculeWithAudit(handoff.ID, beads.DetachOptions{
-		Operation: "squash",
-		Agent:     target,
-		Reason:    fmt.Sprintf("molecule squashed to digest %s", digestIssue.ID),
-	})
-	if err != nil {
-		return fmt.Errorf("detaching molecule: %w", err)
+	if attached {
+		// Detach the molecule from the handoff bead with audit logging
+		_, err = b.DetachMoleculeWithAudit(handoff.ID, beads.DetachOptions{
+			Operation: "squash",
+			Agent:     target,
+			Reason:    reason,
+		})
+		if err != nil {
+			return fmt.Errorf("detaching molecule: %w", err)
+		}
+	} else if err := b.CloseWithReason(reason, moleculeID); err != nil {
+		// A hooked patrol wisp is finished by closing its root
+		return fmt.Errorf("closing molecule: %w", err)
 	}
 
 	if moleculeJSON {
 		result := map[string]interface{}{
 			"squashed":        moleculeID,
-			"digest_id":       digestIssue.ID,
+			"digest_id":       digestID,
+			"folded":          due == nil,
 			"from":            target,
-			"handoff_id":      handoff.ID,
 			"children_closed": childrenClosed,
 		}
+		if attached {
+			result["handoff_id"] = handoff.ID
+		}
+		if due != nil {
+			result["cycles"] = due.Cycles
+		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(result)
 	}
 
-	fmt.Printf("%s Squashed molecule %s → digest %s\n",
-		style.Bold.Render("📦"), moleculeID, digestIssue.ID)
+	if due == nil {
+		fmt.Printf("%s Squashed molecule %s into pending digest (every %s)\n",
+			style.Bold.Render("📦"), moleculeID, cadence)
+	} else {
+		fmt.Printf("%s Squashed molecule %s → digest %s\n",
+			style.Bold.Render("📦"), moleculeID, digestID)
+		if due.Cycles > 1 {
+			fmt.Printf("  Covers %d cycles since %s\n", due.Cycles, due.Start.Local().Format("15:04"))
+		}
+	}
 	if childrenClosed > 0 {
 		fmt.Printf("  Closed %d step issues\n", childrenClosed)
 	}
@@ -273,6 +307,122 @@ squashed_at: %s
 	return nil
 }
 
+// patrolRoles are the agents whose molecules are patrol cycles.
+var patrolRoles = map[string]bool{"deacon": true, "witness": true, "refinery": true}
+
+// buildSquashDigest builds the cycle digest for a molecule from its steps,
+// their recorded outputs and the --summary and --metric flags. The squash
+// settings of the molecule's formula are returned when it has them.
+func buildSquashDigest(b *beads.Beads, moleculeID, agent, role string, attachment *beads.AttachmentFields, metrics map[string]float64) (*wisp.Digest, *formula.Squash) {
+	now := time.Now()
+	digest := &wisp.Digest{
+		Period:   wisp.PeriodCycle,
+		Kind:     wisp.KindWork,
+		Agent:    agent,
+		Molecule: moleculeID,
+		Start:    now,
+		End:      now,
+		Cycles:   1,
+		Metrics:  metrics,
+	}
+	if patrolRoles[role] {
+		digest.Kind = wisp.KindPatrol
+	}
+	if t, err := time.Parse(time.RFC3339, attachment.AttachedAt); err == nil {
+		digest.Start = t
+	}
+
+	if children, err := b.List(beads.ListOptions{Parent: moleculeID, Status: "all", Priority: -1}); err == nil {
+		for _, child := range children {
+			digest.Steps = append(digest.Steps, wisp.DigestStep{
+				ID:      child.ID,
+				Title:   child.Title,
+				Status:  child.Status,
+				Outputs: beads.ParseStepOutputs(child),
+			})
+			if digest.Formula == "" {
+				digest.Formula = extractMoleculeID(child.Description)
+			}
+		}
+	}
+	if digest.Formula == "" {
+		if root, err := b.Show(moleculeID); err == nil && strings.HasPrefix(root.Title, "mol-") {
+			digest.Formula = root.Title
+		}
+	}
+
+	if moleculeSummary != "" {
+		digest.Summaries = []string{moleculeSummary}
+	}
+	digest.AddOutputMetrics()
+
+	var squash *formula.Squash
+	if digest.Formula != "" {
+		if path, err := findFormulaFile(digest.Formula); err == nil {
+			if f, err := formula.ParseFile(path); err == nil {
+				squash = f.Squash
+			}
+		}
+	}
+	if squash != nil && squash.TemplateType != "" {
+		digest.Kind = squash.TemplateType
+	}
+	return digest, squash
+}
+
+// parseSquashMetrics parses --metric name=value flags.
+func parseSquashMetrics(pairs []string) (map[string]float64, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	metrics := make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if !ok || name == "" || err != nil {
+			return nil, fmt.Errorf("invalid metric %q: want name=number", pair)
+		}
+		metrics[strings.TrimSpace(name)] = v
+	}
+	return metrics, nil
+}
+
+// createDigestBead records a due digest as a closed digest bead.
+func createDigestBead(b *beads.Beads, digest *wisp.Digest, agent string) (*beads.Issue, error) {
+	digestDesc := fmt.Sprintf(`Squashed molecule execution.
+
+molecule: %s
+agent: %s
+squashed_at: %s
+cycles: %d
+
+%s`, digest.Molecule, agent, digest.End.UTC().Format(time.RFC3339), digest.Cycles, digest.Body)
+
+	// Create the digest bead
+	digestIssue, err := b.Create(beads.CreateOptions{
+		Title:       fmt.Sprintf("Digest: %s", digest.Molecule),
+		Description: digestDesc,
+		Type:        "task",
+		Priority:    4, // P4 - backlog priority for digests
+		Actor:       agent,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating digest: %w", err)
+	}
+
+	// Add the digest label (non-fatal: digest works without label)
+	_ = b.Update(digestIssue.ID, beads.UpdateOptions{
+		AddLabels: []string{"digest", "digest:" + digest.Kind},
+	})
+
+	// Close the digest immediately
+	closedStatus := "closed"
+	if err := b.Update(digestIssue.ID, beads.UpdateOptions{Status: &closedStatus}); err != nil {
+		style.PrintWarning("Created digest but couldn't close it: %v", err)
+	}
+	return digestIssue, nil
+}
+
 // closeDescendants recursively closes all descendant issues of a parent.
 // Returns the count of issues closed. Logs warnings on errors but doesn't fail.
 func closeDescendants(b *beads.Beads, parentID string) int {
@@ -36,18 +36,24 @@ This command handles the step-to-step transition for polecats:
 IMPORTANT: This is the canonical way to complete molecule steps. Do NOT manually
 close steps with 'bd close' - it skips the auto-continuation logic.
 
+--output records a named result on the step. Outputs are carried into the
+molecule's digest when it is squashed; numeric ones are summed as metrics.
+
 Example:
-  gt mol step done gt-abc.1    # Complete step 1 of molecule gt-abc`,
+  gt mol step done gt-abc.1    # Complete step 1 of molecule gt-abc
+  gt mol step done gt-abc.3 --output issues_filed=2 --output queue=empty`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMoleculeStepDone,
 }
 
 var (
-	moleculeStepDryRun bool
+	moleculeStepDryRun  bool
+	moleculeStepOutputs []string
 )
 
 func init() {
 	moleculeStepDoneCmd.Flags().BoolVarP(&moleculeStepDryRun, "dry-run", "n", false, "Show what would be done without executing")
+	moleculeStepDoneCmd.Flags().StringArrayVar(&moleculeStepOutputs, "output", nil, "Record a step output as name=value (repeatable)")
 	moleculeStepDoneCmd.Flags().BoolVar(&moleculeJSON, "json", false, "Output as JSON")
 }
 
@@ -104,11 +110,22 @@ func runMoleculeStepDone(cmd *cobra.Command, args []string) error {
 		MoleculeID: moleculeID,
 	}
 
-	// Step 3: Close the step
+	outputs, err := parseStepOutputFlags(moleculeStepOutputs)
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Close the step, recording its outputs first
 	if moleculeStepDryRun {
 		fmt.Printf("[dry-run] Would close step: %s\n", stepID)
 		result.StepClosed = true
 	} else {
+		if len(outputs) > 0 {
+			desc := beads.SetStepOutputs(step, outputs)
+			if err := b.Update(stepID, beads.UpdateOptions{Description: &desc}); err != nil {
+				return fmt.Errorf("recording step outputs: %w", err)
+			}
+		}
 		if err := b.Close(stepID); err != nil {
 			return fmt.Errorf("closing step: %w", err)
 		}
@@ -400,3 +417,17 @@ func handleMoleculeComplete(cwd, townRoot, moleculeID string, dryRun bool) error
 }
 
 // getGitRoot is defined in prime.go
+
+// parseStepOutputFlags turns --output name=value flags into a map.
+func parseStepOutputFlags(pairs []string) (map[string]string, error) {
+	outputs := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, ": \t") {
+			return nil, fmt.Errorf("invalid --output %q: want name=value", pair)
+		}
+		outputs[name] = strings.TrimSpace(strings.ReplaceAll(value, "\n", " "))
+	}
+	return outputs, nil
+}
@@ -4,12 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // MRStatusOutput is the JSON output structure for gt mq status.
@@ -37,6 +40,19 @@ type MRStatusOutput struct {
 	// Dependencies
 	DependsOn []DependencyInfo `json:"depends_on,omitempty"`
 	Blocks    []DependencyInfo `json:"blocks,omitempty"`
+
+	// Batches the MR rode in, oldest first
+	Batches []BatchInfo `json:"batches,omitempty"`
+}
+
+// BatchInfo describes a merge batch an MR rode in.
+type BatchInfo struct {
+	ID       string `json:"id"`
+	Outcome  string `json:"outcome"`
+	Size     int    `json:"size"`
+	TestRuns int    `json:"test_runs"`
+	Reason   string `json:"reason,omitempty"`
+	Time     string `json:"time"`
 }
 
 // DependencyInfo represents a dependency or blocker.
@@ -119,6 +135,12 @@ func runMqStatus(cmd *cobra.Command, args []string) error {
 		})
 	}
 
+	rigName := ""
+	if mrFields != nil {
+		rigName = mrFields.Rig
+	}
+	output.Batches = mqBatches(workDir, rigName, issue.ID)
+
 	// JSON output
 	if mqStatusJSON {
 		enc := json.NewEncoder(os.Stdout)
@@ -127,11 +149,47 @@ func runMqStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Human-readable output
-	return printMqStatus(issue, mrFields)
+	return printMqStatus(issue, mrFields, output.Batches)
+}
+
+// mqBatches returns the merge batches mrID rode in, from the rig's MQ event
+// log. History is best-effort: an unreadable log yields no batches.
+func mqBatches(workDir, rigName, mrID string) []BatchInfo {
+	beadsDir := beads.ResolveBeadsDir(workDir)
+	if townRoot, err := workspace.FindFromCwd(); err == nil && townRoot != "" && rigName != "" {
+		beadsDir = filepath.Join(townRoot, rigName, ".beads")
+	}
+	events, err := mrqueue.NewEventLogger(beadsDir).Events("")
+	if err != nil {
+		return nil
+	}
+
+	sizes := make(map[string]int)
+	for _, e := range events {
+		if e.Type == mrqueue.EventBatched {
+			sizes[e.Batch]++
+		}
+	}
+
+	var batches []BatchInfo
+	for _, e := range events {
+		if e.Type != mrqueue.EventBatched || e.MRID != mrID {
+			continue
+		}
+		batches = append(batches, BatchInfo{
+			ID:       e.Batch,
+			Outcome:  e.Outcome,
+			Size:     sizes[e.Batch],
+			TestRuns: e.TestRuns,
+			Reason:   e.Reason,
+			Time:     e.Timestamp.Format(time.RFC3339),
+		})
+	}
+	return batches
 }
 
 // printMqStatus prints detailed MR status in human-readable format.
-func printMqStatus(issue *beads.Issue, mrFields *beads.MRFields) error {
+func printMqStatus(issue *beads.Issue, mrFields *beads.MRFields, batches []BatchInfo) error {
 	// Header
 	fmt.Printf("%s %s\n", style.Bold.Render("📋 Merge Request:"), issue.ID)
 	fmt.Printf("   %s\n\n", issue.Title)
@@ -186,6 +244,22 @@ func printMqStatus(issue *beads.Issue, mrFields *beads.MRFields) error {
 		}
 	}
 
+	// Merge batches this MR rode in
+	if len(batches) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("Batches"))
+		for _, b := range batches {
+			fmt.Printf("   %s %s %-9s %s %s\n",
+				getBatchOutcomeIcon(b.Outcome),
+				b.ID,
+				b.Outcome,
+				style.Dim.Render(fmt.Sprintf("%d MRs, %d test runs", b.Size, b.TestRuns)),
+				formatTimeAgo(b.Time))
+			if b.Reason != "" {
+				fmt.Printf("     %s\n", style.Dim.Render(truncateString(b.Reason, 70)))
+			}
+		}
+	}
+
 	// Dependencies (what this MR is waiting on)
 	if len(issue.Dependencies) > 0 {
 		fmt.Printf("\n%s\n", style.Bold.Render("Waiting On"))
@@ -253,6 +327,20 @@ func getStatusIcon(status string) string {
 	}
 }
 
+// getBatchOutcomeIcon returns an icon for a merge batch outcome.
+func getBatchOutcomeIcon(outcome string) string {
+	switch outcome {
+	case mrqueue.BatchLanded:
+		return "✓"
+	case mrqueue.BatchCulprit, mrqueue.BatchUnstacked:
+		return "✗"
+	case mrqueue.BatchRequeued:
+		return "↻"
+	default:
+		return "•"
+	}
+}
+
 // formatTimeAgo formats a timestamp as a relative time string.
 func formatTimeAgo(timestamp string) string {
 	// Try parsing common formats
@@ -29,6 +29,11 @@ Examples:
   gt notify normal    # Default notification level
   gt notify muted     # Enable DND mode
 
+Outbound notifications (webhooks, Slack, email) for humans away from
+the terminal are configured in mayor/config.json:
+  gt notify test      # Send a test notification to each channel
+  gt notify status    # Show channels, routes and the delivery outbox
+
 Related: gt dnd - quick toggle for DND mode`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runNotify,
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/notify"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	notifyTestMessage string
+	notifyStatusJSON  bool
+)
+
+var notifyTestCmd = &cobra.Command{
+	Use:   "test [channel...]",
+	Short: "Send a test outbound notification",
+	Long: `Send a test notification to outbound channels right away, bypassing
+routes and the outbox. With no arguments every configured channel is tested.
+
+Outbound notifications reach humans outside Gas Town. They are configured
+under "notify" in mayor/config.json:
+
+  "notify": {
+    "channels": {
+      "ops":   {"type": "slack", "url": "${SLACK_WEBHOOK_URL}"},
+      "pager": {"type": "webhook", "url": "https://example.com/hook",
+                "headers": {"Authorization": "Bearer ${PAGER_TOKEN}"}},
+      "email": {"type": "smtp", "smtp": {"host": "smtp.example.com",
+                "username": "gt", "password": "${SMTP_PASSWORD}",
+                "from": "gt@example.com", "to": ["oncall@example.com"]}}
+    },
+    "routes": [
+      {"events": ["escalation_sent", "merge_failed", "polecat_crashed"], "channels": ["ops"]},
+      {"events": ["convoy_stranded"], "channels": ["ops"], "throttle": "1h"},
+      {"mail_priority": ["urgent"], "channels": ["pager", "email"]}
+    ],
+    "retry": {"max_attempts": 5, "backoff": "30s", "max_backoff": "15m"}
+  }
+
+Routes match event types ("*" for all) or mail priorities, optionally
+limited to "rigs". Channel "template" (and "subject" for smtp) are Go
+templates over the notification: {{.Title}}, {{.Body}}, {{.Severity}},
+{{.Rig}}, {{.Actor}}, {{.Type}}, {{.Time}}, {{index .Fields "branch"}},
+and {{json .}}. ${VAR} in urls, headers and passwords reads the environment.
+
+The daemon delivers routed notifications from daemon/notify/outbox,
+retrying with exponential backoff; ones that give up land in
+daemon/notify/failed (see 'gt notify status').
+
+Examples:
+  gt notify test
+  gt notify test ops
+  gt notify test email -m "Checking SMTP relay"`,
+	RunE: runNotifyTest,
+}
+
+var notifyStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show outbound notification channels, routes and the outbox",
+	Long: `Show configured outbound notification channels and routes, plus
+notifications waiting for delivery and ones that gave up.
+
+Examples:
+  gt notify status
+  gt notify status --json`,
+	RunE: runNotifyStatus,
+}
+
+func init() {
+	notifyTestCmd.Flags().StringVarP(&notifyTestMessage, "message", "m", "", "Body of the test notification")
+	notifyStatusCmd.Flags().BoolVar(&notifyStatusJSON, "json", false, "Output as JSON")
+
+	notifyCmd.AddCommand(notifyTestCmd)
+	notifyCmd.AddCommand(notifyStatusCmd)
+}
+
+func runNotifyTest(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	cfg, err := notify.LoadConfig(townRoot)
+	if err != nil {
+		return err
+	}
+	if cfg == nil || len(cfg.Channels) == 0 {
+		return fmt.Errorf("no notify channels configured in mayor/config.json")
+	}
+
+	names := args
+	if len(names) == 0 {
+		for name := range cfg.Channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	body := notifyTestMessage
+	if body == "" {
+		body = "If you can read this, notifications from this town reach you."
+	}
+	note := &notify.Notification{
+		Source:   notify.SourceTest,
+		Type:     "test",
+		Title:    "Test notification from Gas Town",
+		Body:     body,
+		Severity: notify.SeverityNormal,
+		Actor:    detectActor(),
+		Time:     time.Now(),
+	}
+
+	failed := 0
+	for _, name := range names {
+		ch := cfg.Channels[name]
+		if ch == nil {
+			fmt.Printf("%s %s: not configured\n", style.Error.Render("✗"), name)
+			failed++
+			continue
+		}
+		start := time.Now()
+		if err := notify.Deliver(context.Background(), ch, note); err != nil {
+			fmt.Printf("%s %s (%s): %v\n", style.Error.Render("✗"), name, ch.Type, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s %s (%s) %s\n", style.Success.Render("✓"), name, ch.Type,
+			style.Dim.Render(time.Since(start).Round(time.Millisecond).String()))
+	}
+
+	if failed > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
+
+func runNotifyStatus(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	cfg, err := notify.LoadConfig(townRoot)
+	if err != nil {
+		return err
+	}
+	pending, err := notify.ListJobs(notify.OutboxDir(townRoot))
+	if err != nil {
+		return fmt.Errorf("reading outbox: %w", err)
+	}
+	failed, err := notify.ListJobs(notify.FailedDir(townRoot))
+	if err != nil {
+		return fmt.Errorf("reading failed notifications: %w", err)
+	}
+
+	if notifyStatusJSON {
+		out := map[string]interface{}{
+			"config":  cfg,
+			"pending": pending,
+			"failed":  failed,
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if cfg == nil || len(cfg.Channels) == 0 {
+		fmt.Println("No notify channels configured (see 'gt notify test --help').")
+	} else {
+		fmt.Printf("%s\n", style.Bold.Render("Channels:"))
+		var names []string
+		for name := range cfg.Channels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  %s %s\n", name, style.Dim.Render("("+cfg.Channels[name].Type+")"))
+		}
+
+		fmt.Printf("\n%s\n", style.Bold.Render("Routes:"))
+		for _, r := range cfg.Routes {
+			var match []string
+			if len(r.Events) > 0 {
+				match = append(match, "events "+strings.Join(r.Events, ","))
+			}
+			if len(r.MailPriority) > 0 {
+				match = append(match, "mail "+strings.Join(r.MailPriority, ","))
+			}
+			if len(r.Rigs) > 0 {
+				match = append(match, "rigs "+strings.Join(r.Rigs, ","))
+			}
+			line := fmt.Sprintf("  %s → %s", strings.Join(match, "; "), strings.Join(r.Channels, ", "))
+			if r.Throttle != "" {
+				line += style.Dim.Render(" (throttle " + r.Throttle + ")")
+			}
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Printf("\n%s %d pending, %d failed\n", style.Bold.Render("Outbox:"), len(pending), len(failed))
+	for _, job := range pending {
+		retry := ""
+		if job.Attempts > 0 {
+			retry = style.Dim.Render(fmt.Sprintf(" (attempt %d, retry %s: %s)",
+				job.Attempts, job.NextAttempt.Local().Format("15:04:05"), job.LastError))
+		}
+		fmt.Printf("  ⏳ %s → %s%s\n", job.Notification.Title, job.Channel, retry)
+	}
+	for _, job := range failed {
+		fmt.Printf("  %s %s → %s %s\n", style.Error.Render("✗"), job.Notification.Title, job.Channel,
+			style.Dim.Render(job.LastError))
+	}
+	return nil
+}
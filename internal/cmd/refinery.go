@@ -3,7 +3,9 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/mrqueue"
@@ -205,6 +207,150 @@ Examples:
 
 var refineryBlockedJSON bool
 
+var refineryProcessCmd = &cobra.Command{
+	Use:   "process [rig]",
+	Short: "Process the ready queue once in the configured mode",
+	Long: `Process the ready queue once, the way merge_queue is configured.
+
+With merge_queue.merge_train this runs one speculative merge train (see gt
+refinery train); with merge_queue.batch_size of 2 or more it runs one batch
+(see gt refinery batch). Otherwise the top ready MR is merged on its own:
+tested, pushed to its target and closed, or sent back to its worker.
+
+The worker ID is taken from GT_REFINERY_WORKER (default "refinery-1").
+
+Examples:
+  gt refinery process
+  gt refinery process greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryProcess,
+}
+
+var refineryProcessJSON bool
+
+var refineryTrainCmd = &cobra.Command{
+	Use:   "train [rig]",
+	Short: "Run one speculative merge train",
+	Long: `Run one speculative merge train over the ready queue.
+
+Takes the top N ready MRs (N = merge_queue.max_concurrent), stacks them onto
+successive speculative integration commits in scratch worktrees, and runs the
+test command on every car in parallel. The target branch is fast-forwarded to
+the longest passing prefix. The first failing MR is ejected back to its worker
+with a MERGE_FAILED message; MRs behind it are requeued for the next train.
+
+The worker ID is taken from GT_REFINERY_WORKER (default "refinery-1").
+
+Examples:
+  gt refinery train
+  gt refinery train greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryTrain,
+}
+
+var refineryTrainJSON bool
+
+var refineryBatchCmd = &cobra.Command{
+	Use:   "batch [rig]",
+	Short: "Test and land one batch of MRs",
+	Long: `Merge a batch of ready MRs, test it once and land it.
+
+Takes the top K ready MRs (K = merge_queue.batch_size), merges them one after
+another onto a temporary commit and runs the test command once. If the tests
+pass, the target branch is fast-forwarded to the batch. If they fail, the
+batch is bisected to find the culprit(s): each culprit is sent back to its
+worker with a MERGE_FAILED message and the remaining MRs land without it.
+
+Each MR gets a "batched" MQ event recording the batch it rode in and its
+outcome; gt mq status <id> shows it.
+
+The worker ID is taken from GT_REFINERY_WORKER (default "refinery-1").
+
+Examples:
+  gt refinery batch
+  gt refinery batch greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryBatch,
+}
+
+var refineryBatchJSON bool
+
+var refineryRebaseCmd = &cobra.Command{
+	Use:   "rebase <mr-id> [rig]",
+	Short: "Auto-rebase a conflicting MR onto its target",
+	Long: `Rebase an MR's branch onto its target, resolving common conflicts.
+
+The rebase runs in a scratch worktree. Whenever it stops, each conflicted
+file must match one of merge_queue.resolvers (by default CHANGELOG union
+merge and go.sum combining; lockfiles and generated files can be rebuilt
+with a command). The rebased branch is tested and force-pushed with a lease
+on its previous tip. If any file has no resolver, a resolver or the tests
+fail, nothing is pushed and the command exits non-zero so the MR can be
+assigned back.
+
+With merge_queue.on_conflict = "auto_rebase", merge trains and batches do
+this automatically for conflicting MRs.
+
+Examples:
+  gt refinery rebase gt-mr-abc123
+  gt refinery rebase gt-mr-abc123 greenplace --json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRefineryRebase,
+}
+
+var refineryRebaseJSON bool
+
+var refineryPRsCmd = &cobra.Command{
+	Use:   "prs [rig]",
+	Short: "Land ready MRs through forge pull requests (PR mode)",
+	Long: `Sync every ready MR with a pull request on the rig's forge.
+
+Requires merge_queue.pr_mode. Instead of pushing merge commits to the target
+branch, the refinery opens a pull request per MR (GitHub, GitLab or Gitea,
+see merge_queue.forge), waits for the required checks and any required
+approval, and merges through the forge API.
+
+Reviews requesting changes are sent to the polecat as REWORK_REQUEST mail
+with the review comments; failed checks are sent as MERGE_FAILED. Each is
+sent once (per review, or per head commit for checks). MRs that are still
+waiting are released for the next pass.
+
+The worker ID is taken from GT_REFINERY_WORKER (default "refinery-1").
+
+Examples:
+  gt refinery prs
+  gt refinery prs greenplace --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryPRs,
+}
+
+var refineryPRsJSON bool
+
+var refineryFlakyCmd = &cobra.Command{
+	Use:   "flaky [rig]",
+	Short: "Show per-test flake rates and quarantined tests",
+	Long: `Show the refinery's flaky-test history for a rig.
+
+The refinery parses test output (merge_queue.test_format) and records, per
+test, how often it failed and how often it flaked (failed, then passed on a
+retry of the same commit). A test that flakes on merge_queue.quarantine_flaky_after
+distinct branches is quarantined: its failures no longer bounce MRs.
+
+Use --release to lift a quarantine once the test has been fixed.
+
+Examples:
+  gt refinery flaky
+  gt refinery flaky greenplace --json
+  gt refinery flaky --release app.TestNetworkTimeout`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRefineryFlaky,
+}
+
+var (
+	refineryFlakyJSON    bool
+	refineryFlakyRelease string
+)
+
 func init() {
 	// Start flags
 	refineryStartCmd.Flags().BoolVar(&refineryForeground, "foreground", false, "Run in foreground (default: background)")
@@ -224,6 +370,25 @@ func init() {
 	// Blocked flags
 	refineryBlockedCmd.Flags().BoolVar(&refineryBlockedJSON, "json", false, "Output as JSON")
 
+	// Process flags
+	refineryProcessCmd.Flags().BoolVar(&refineryProcessJSON, "json", false, "Output as JSON")
+
+	// Train flags
+	refineryTrainCmd.Flags().BoolVar(&refineryTrainJSON, "json", false, "Output as JSON")
+
+	// Batch flags
+	refineryBatchCmd.Flags().BoolVar(&refineryBatchJSON, "json", false, "Output as JSON")
+
+	// Rebase flags
+	refineryRebaseCmd.Flags().BoolVar(&refineryRebaseJSON, "json", false, "Output as JSON")
+
+	// PRs flags
+	refineryPRsCmd.Flags().BoolVar(&refineryPRsJSON, "json", false, "Output as JSON")
+
+	// Flaky flags
+	refineryFlakyCmd.Flags().BoolVar(&refineryFlakyJSON, "json", false, "Output as JSON")
+	refineryFlakyCmd.Flags().StringVar(&refineryFlakyRelease, "release", "", "Lift the quarantine on a test")
+
 	// Add subcommands
 	refineryCmd.AddCommand(refineryStartCmd)
 	refineryCmd.AddCommand(refineryStopCmd)
@@ -236,6 +401,12 @@ func init() {
 	refineryCmd.AddCommand(refineryUnclaimedCmd)
 	refineryCmd.AddCommand(refineryReadyCmd)
 	refineryCmd.AddCommand(refineryBlockedCmd)
+	refineryCmd.AddCommand(refineryProcessCmd)
+	refineryCmd.AddCommand(refineryTrainCmd)
+	refineryCmd.AddCommand(refineryBatchCmd)
+	refineryCmd.AddCommand(refineryRebaseCmd)
+	refineryCmd.AddCommand(refineryPRsCmd)
+	refineryCmd.AddCommand(refineryFlakyCmd)
 
 	rootCmd.AddCommand(refineryCmd)
 }
@@ -707,3 +878,365 @@ func runRefineryBlocked(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runRefineryProcess(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if refineryProcessJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	result, err := eng.ProcessNext(cmd.Context(), getWorkerID())
+	if err == refinery.ErrNoQueue {
+		if refineryProcessJSON {
+			fmt.Println("null")
+			return nil
+		}
+		fmt.Printf("%s No ready MRs for '%s'\n", style.Dim.Render("○"), rigName)
+		return nil
+	}
+	if result == nil {
+		return fmt.Errorf("processing merge queue: %w", err)
+	}
+
+	// JSON output
+	if refineryProcessJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	// Human-readable output
+	switch {
+	case result.Train != nil:
+		printTrainResult(rigName, result.Train)
+	case result.Batch != nil:
+		printBatchResult(rigName, result.Batch)
+	case result.Result.Success:
+		fmt.Printf("%s Merged %s → %s at %s\n", style.Bold.Render("✓"), result.MR.ID, result.MR.Target, result.Result.MergeCommit)
+	default:
+		fmt.Printf("%s %s failed: %s\n", style.Bold.Render("✗"), result.MR.ID, result.Result.Error)
+	}
+	return err
+}
+
+func runRefineryTrain(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if refineryTrainJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	result, err := eng.RunTrain(cmd.Context(), getWorkerID())
+	if err == refinery.ErrNoQueue {
+		if refineryTrainJSON {
+			fmt.Println("null")
+			return nil
+		}
+		fmt.Printf("%s No ready MRs for '%s'\n", style.Dim.Render("○"), rigName)
+		return nil
+	}
+	if result == nil {
+		return fmt.Errorf("running merge train: %w", err)
+	}
+
+	// JSON output
+	if refineryTrainJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	printTrainResult(rigName, result)
+	return err
+}
+
+func printTrainResult(rigName string, result *refinery.TrainResult) {
+	fmt.Printf("\n%s Merge train for '%s' → %s\n\n", style.Bold.Render("🚂"), rigName, result.Target)
+	for _, car := range result.Landed {
+		fmt.Printf("  %s %s %s\n", style.Bold.Render("✓ landed  "), car.MR.ID, style.Dim.Render(car.MR.Branch))
+	}
+	if car := result.Ejected; car != nil {
+		fmt.Printf("  %s %s %s\n", style.Bold.Render("✗ ejected "), car.MR.ID, style.Dim.Render(car.Result.Error))
+	}
+	for _, car := range result.Unstacked {
+		fmt.Printf("  %s %s %s\n", style.Dim.Render("✗ unstacked"), car.MR.ID, style.Dim.Render(car.Result.Error))
+	}
+	for _, car := range result.Requeued {
+		fmt.Printf("  %s %s\n", style.Dim.Render("↻ requeued"), car.MR.ID)
+	}
+	if commit := result.MergeCommit(); commit != "" {
+		fmt.Printf("\n  %s is now at %s\n", result.Target, commit)
+	}
+}
+
+func runRefineryBatch(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if eng.Config().BatchSize < 2 {
+		return fmt.Errorf("batching is disabled for '%s': set merge_queue.batch_size to 2 or more", rigName)
+	}
+	if refineryBatchJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	result, err := eng.RunBatch(cmd.Context(), getWorkerID())
+	if err == refinery.ErrNoQueue {
+		if refineryBatchJSON {
+			fmt.Println("null")
+			return nil
+		}
+		fmt.Printf("%s No ready MRs for '%s'\n", style.Dim.Render("○"), rigName)
+		return nil
+	}
+	if result == nil {
+		return fmt.Errorf("running merge batch: %w", err)
+	}
+
+	// JSON output
+	if refineryBatchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(result); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	printBatchResult(rigName, result)
+	return err
+}
+
+func printBatchResult(rigName string, result *refinery.BatchResult) {
+	fmt.Printf("\n%s Merge batch %s for '%s' → %s (%d test runs)\n\n",
+		style.Bold.Render("📦"), result.ID, rigName, result.Target, result.TestRuns)
+	for _, car := range result.Landed {
+		fmt.Printf("  %s %s %s\n", style.Bold.Render("✓ landed   "), car.MR.ID, style.Dim.Render(car.MR.Branch))
+	}
+	for _, car := range result.Culprits {
+		fmt.Printf("  %s %s %s\n", style.Bold.Render("✗ culprit  "), car.MR.ID, style.Dim.Render(car.Result.Error))
+	}
+	for _, car := range result.Unstacked {
+		fmt.Printf("  %s %s %s\n", style.Dim.Render("✗ unstacked"), car.MR.ID, style.Dim.Render(car.Result.Error))
+	}
+	for _, car := range result.Requeued {
+		fmt.Printf("  %s %s\n", style.Dim.Render("↻ requeued "), car.MR.ID)
+	}
+	if commit := result.MergeCommit(); commit != "" {
+		fmt.Printf("\n  %s is now at %s\n", result.Target, commit)
+	}
+}
+
+func runRefineryRebase(cmd *cobra.Command, args []string) error {
+	mrID := args[0]
+	rigName := ""
+	if len(args) > 1 {
+		rigName = args[1]
+	}
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	mr, err := mrqueue.New(r.Path).Get(mrID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("MR %s not found in queue", mrID)
+		}
+		return fmt.Errorf("loading MR: %w", err)
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if refineryRebaseJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	result := eng.AutoRebase(cmd.Context(), mr)
+
+	// JSON output
+	if refineryRebaseJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	} else if result.Success {
+		fmt.Printf("%s Rebased %s onto %s at %s\n", style.Bold.Render("✓"), mr.Branch, mr.Target, result.Commit)
+		if len(result.Resolved) > 0 {
+			fmt.Printf("  Resolved: %s\n", strings.Join(result.Resolved, ", "))
+		}
+	}
+
+	if !result.Success {
+		return fmt.Errorf("auto-rebase of %s failed: %s", mrID, result.Error)
+	}
+	return nil
+}
+
+func runRefineryPRs(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		return fmt.Errorf("loading merge queue config: %w", err)
+	}
+	if refineryPRsJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	results, err := eng.RunPRs(cmd.Context(), getWorkerID())
+	if err == refinery.ErrNoQueue {
+		if refineryPRsJSON {
+			fmt.Println("[]")
+			return nil
+		}
+		fmt.Printf("%s No ready MRs for '%s'\n", style.Dim.Render("○"), rigName)
+		return nil
+	}
+	if err != nil && results == nil {
+		return err
+	}
+
+	// JSON output
+	if refineryPRsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if encErr := enc.Encode(results); encErr != nil {
+			return encErr
+		}
+		return err
+	}
+
+	// Human-readable output
+	fmt.Printf("\n%s Pull requests for '%s'\n\n", style.Bold.Render("🔀"), rigName)
+	for _, res := range results {
+		pr := style.Dim.Render("(no PR)")
+		if res.PR != nil {
+			pr = fmt.Sprintf("#%d", res.PR.Number)
+		}
+		switch res.Action {
+		case refinery.PRMerged:
+			fmt.Printf("  %s %s %s %s\n", style.Success.Render("✓ merged   "), res.MR.ID, pr, style.Dim.Render(shortCommit(res.Result.MergeCommit)))
+		case refinery.PRWaiting:
+			fmt.Printf("  %s %s %s %s\n", style.Dim.Render("○ waiting  "), res.MR.ID, pr, style.Dim.Render(res.Result.Error))
+		case refinery.PRChangesRequested:
+			fmt.Printf("  %s %s %s %s\n", style.Warning.Render("✎ changes  "), res.MR.ID, pr, style.Dim.Render(res.Result.Error))
+		default:
+			fmt.Printf("  %s %s %s %s\n", style.Error.Render("✗ failed   "), res.MR.ID, pr, style.Dim.Render(res.Result.Error))
+		}
+	}
+
+	return err
+}
+
+func runRefineryFlaky(cmd *cobra.Command, args []string) error {
+	rigName := ""
+	if len(args) > 0 {
+		rigName = args[0]
+	}
+
+	_, r, rigName, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	tracker, err := refinery.LoadFlakeTracker(r.Path)
+	if err != nil {
+		return err
+	}
+
+	if refineryFlakyRelease != "" {
+		if err := tracker.Release(refineryFlakyRelease); err != nil {
+			return err
+		}
+		if err := tracker.Save(); err != nil {
+			return fmt.Errorf("saving flake history: %w", err)
+		}
+		fmt.Printf("%s Released %s from quarantine\n", style.Bold.Render("✓"), refineryFlakyRelease)
+		return nil
+	}
+
+	stats := tracker.Stats()
+
+	// JSON output
+	if refineryFlakyJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	// Human-readable output
+	fmt.Printf("\n%s Flaky tests for '%s':\n\n", style.Bold.Render("🧪"), rigName)
+	if len(stats) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("(no failing tests recorded)"))
+		return nil
+	}
+	for _, s := range stats {
+		marker := "  "
+		if s.Quarantined() {
+			marker = style.Warning.Render("⚠ ")
+		}
+		fmt.Printf("  %s%s\n", marker, s.Name)
+		fmt.Printf("     flake rate %.0f%% (%d flakes, %d failures in %d runs)\n",
+			s.FlakeRate()*100, s.Flakes, s.Failures, s.Runs)
+		if s.Quarantined() {
+			fmt.Printf("     %s since %s\n", style.Warning.Render("quarantined"), s.QuarantinedAt.Format("2006-01-02 15:04"))
+		}
+	}
+
+	return nil
+}
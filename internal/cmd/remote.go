@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+// Remote command flags
+var (
+	remoteJSON    bool
+	remoteURI     string
+	remoteBranch  string
+	remoteNoFetch bool
+)
+
+var remoteCmd = &cobra.Command{
+	Use:     "remote",
+	GroupID: GroupConfig,
+	Short:   "Manage federated remote towns",
+	RunE:    requireSubcommand,
+	Long: `Manage the remote towns registered in mayor/remotes.json.
+
+A remote is another Gas Town whose issues can be referenced from this town
+as hop://entity/chain/rig/issue-id, for example as convoy dependencies:
+
+  gt convoy add hq-cv-abc hop://acme.com/eng/backend/be-123
+
+Remotes are read from a town root on this machine or from a git repository
+holding a town's (or a bare) beads database. 'gt remote fetch' caches a
+read-only snapshot of the remote's issues; references are resolved from
+that snapshot, never from the remote itself.
+
+Commands:
+  gt remote add <name> <path-or-git-url>   Register and fetch a remote town
+  gt remote list                           List remotes and snapshot ages
+  gt remote remove <name>                  Unregister a remote
+  gt remote fetch [name...]                Refresh remote snapshots`,
+}
+
+var remoteAddCmd = &cobra.Command{
+	Use:   "add <name> <path-or-git-url>",
+	Short: "Register a remote town",
+	Long: `Register a remote town and fetch its issues.
+
+The remote's identity (hop://entity/chain) is read from its mayor/town.json
+owner and name. Pass --uri for a repository without one, or to override it.
+
+Examples:
+  gt remote add acme ~/work/acme-town
+  gt remote add acme git@github.com:acme/town-beads.git --branch beads-sync
+  gt remote add vendor https://git.example.com/vendor/beads.git --uri hop://vendor.io/main`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRemoteAdd,
+}
+
+var remoteListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List remote towns",
+	Args:  cobra.NoArgs,
+	RunE:  runRemoteList,
+}
+
+var remoteRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a remote town and drop its snapshot",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRemoteRemove,
+}
+
+var remoteFetchCmd = &cobra.Command{
+	Use:   "fetch [name...]",
+	Short: "Refresh cached snapshots of remote towns",
+	Long: `Fetch the issues of remote towns into their read-only snapshots.
+
+With no names, every registered remote is fetched.
+
+Examples:
+  gt remote fetch
+  gt remote fetch acme`,
+	RunE: runRemoteFetch,
+}
+
+// remoteTownRoot returns the town root for remote commands.
+func remoteTownRoot() (string, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	return townRoot, nil
+}
+
+// isGitURL reports whether a remote location is a git URL rather than a path.
+func isGitURL(location string) bool {
+	return strings.Contains(location, "://") || strings.HasPrefix(location, "git@") || strings.HasSuffix(location, ".git")
+}
+
+func runRemoteAdd(cmd *cobra.Command, args []string) error {
+	name, location := args[0], args[1]
+	townRoot, err := remoteTownRoot()
+	if err != nil {
+		return err
+	}
+	if _, err := beads.GetRemote(townRoot, name); err == nil {
+		return fmt.Errorf("remote '%s' already exists", name)
+	}
+
+	remote := &beads.Remote{Name: name, URI: remoteURI, Branch: remoteBranch}
+	if isGitURL(location) {
+		remote.Git = location
+	} else {
+		path, err := filepath.Abs(location)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("remote town not found: %w", err)
+		}
+		if path == townRoot {
+			return fmt.Errorf("%s is this town", path)
+		}
+		remote.Path = path
+		if remote.URI == "" {
+			if remote.URI, err = beads.TownURI(path); err != nil {
+				return fmt.Errorf("%w (pass --uri hop://entity/chain)", err)
+			}
+		}
+	}
+
+	var snap *beads.RemoteSnapshot
+	if !remoteNoFetch || remote.URI == "" {
+		// Git remotes learn their identity from the fetched town.
+		if snap, err = beads.FetchRemote(townRoot, remote); err != nil {
+			_ = os.RemoveAll(beads.RemoteCacheDir(townRoot, name))
+			return fmt.Errorf("fetching %s: %w", name, err)
+		}
+	}
+	if err := beads.AddRemote(townRoot, remote); err != nil {
+		_ = os.RemoveAll(beads.RemoteCacheDir(townRoot, name))
+		return fmt.Errorf("adding remote: %w", err)
+	}
+
+	fmt.Printf("%s Added remote %s (%s)\n", style.Success.Render("✓"), style.Bold.Render(name), remote.URI)
+	fmt.Printf("  Source: %s\n", remote.Source())
+	if snap != nil {
+		fmt.Printf("  Issues: %d across %d database(s)\n", snap.IssueCount(), len(snap.Rigs))
+	}
+	fmt.Printf("\nReference its issues as %s/<rig>/<issue-id>\n", remote.URI)
+	return nil
+}
+
+// remoteListing is one remote in 'gt remote list' output.
+type remoteListing struct {
+	*beads.Remote
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+	Issues    int        `json:"issues"`
+}
+
+func runRemoteList(cmd *cobra.Command, args []string) error {
+	townRoot, err := remoteTownRoot()
+	if err != nil {
+		return err
+	}
+	remotes, err := beads.LoadRemotes(townRoot)
+	if err != nil {
+		return err
+	}
+
+	listings := make([]remoteListing, 0, len(remotes))
+	for _, r := range remotes {
+		l := remoteListing{Remote: r}
+		if snap, err := beads.LoadRemoteSnapshot(townRoot, r.Name); err == nil {
+			l.FetchedAt = &snap.FetchedAt
+			l.Issues = snap.IssueCount()
+		}
+		listings = append(listings, l)
+	}
+
+	if remoteJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+
+	if len(listings) == 0 {
+		fmt.Println("No remotes registered.")
+		fmt.Println("Add one with: gt remote add <name> <path-or-git-url>")
+		return nil
+	}
+	fmt.Printf("%s\n\n", style.Bold.Render("Remotes"))
+	for _, l := range listings {
+		fmt.Printf("  %s  %s\n", style.Bold.Render(l.Name), l.URI)
+		fetched := style.Warning.Render("never fetched")
+		if l.FetchedAt != nil {
+			fetched = fmt.Sprintf("%d issues, fetched %s", l.Issues, formatAge(*l.FetchedAt))
+		}
+		fmt.Printf("    %s  %s\n", style.Dim.Render(l.Source()), fetched)
+	}
+	return nil
+}
+
+func runRemoteRemove(cmd *cobra.Command, args []string) error {
+	townRoot, err := remoteTownRoot()
+	if err != nil {
+		return err
+	}
+	if err := beads.RemoveRemote(townRoot, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("%s Removed remote %s\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+func runRemoteFetch(cmd *cobra.Command, args []string) error {
+	townRoot, err := remoteTownRoot()
+	if err != nil {
+		return err
+	}
+
+	var remotes []*beads.Remote
+	if len(args) == 0 {
+		if remotes, err = beads.LoadRemotes(townRoot); err != nil {
+			return err
+		}
+		if len(remotes) == 0 {
+			fmt.Println("No remotes registered.")
+			return nil
+		}
+	}
+	for _, name := range args {
+		r, err := beads.GetRemote(townRoot, name)
+		if err != nil {
+			return err
+		}
+		remotes = append(remotes, r)
+	}
+
+	failed := 0
+	for _, r := range remotes {
+		snap, err := beads.FetchRemote(townRoot, r)
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", style.Warning.Render("!"), r.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("  %s %s: %d issues %s\n", style.Success.Render("✓"), r.Name, snap.IssueCount(), style.Dim.Render(r.URI))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d remote(s) failed to fetch", failed, len(remotes))
+	}
+	return nil
+}
+
+func init() {
+	remoteListCmd.Flags().BoolVar(&remoteJSON, "json", false, "Output as JSON")
+
+	remoteAddCmd.Flags().StringVar(&remoteURI, "uri", "", "Remote identity hop://entity/chain (default: from its mayor/town.json)")
+	remoteAddCmd.Flags().StringVar(&remoteBranch, "branch", "", "Git branch to fetch (git remotes)")
+	remoteAddCmd.Flags().BoolVar(&remoteNoFetch, "no-fetch", false, "Register without fetching a snapshot")
+
+	remoteCmd.AddCommand(remoteAddCmd)
+	remoteCmd.AddCommand(remoteListCmd)
+	remoteCmd.AddCommand(remoteRemoveCmd)
+	remoteCmd.AddCommand(remoteFetchCmd)
+
+	rootCmd.AddCommand(remoteCmd)
+}
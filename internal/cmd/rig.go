@@ -60,9 +60,15 @@ The command also:
   - Creates ~/gt/plugins/ (town-level) if it doesn't exist
   - Creates <rig>/plugins/ (rig-level)
 
+With --machine, the rig's polecats, witness and refinery run on a machine
+registered in mayor/machines.json (see 'gt machine add'). Their workspaces
+are mirrored under the machine's town path and reached over SSH; beads,
+mail and the mayor clone stay local.
+
 Example:
   gt rig add gastown https://github.com/steveyegge/gastown
-  gt rig add my-project git@github.com:user/repo.git --prefix mp`,
+  gt rig add my-project git@github.com:user/repo.git --prefix mp
+  gt rig add big-build git@github.com:user/repo.git --machine buildbox`,
 	Args: cobra.ExactArgs(2),
 	RunE: runRigAdd,
 }
@@ -253,6 +259,7 @@ var (
 	rigAddPrefix       string
 	rigAddLocalRepo    string
 	rigAddBranch       string
+	rigAddMachine      string
 	rigResetHandoff    bool
 	rigResetMail       bool
 	rigResetStale      bool
@@ -283,6 +290,7 @@ func init() {
 	rigAddCmd.Flags().StringVar(&rigAddPrefix, "prefix", "", "Beads issue prefix (default: derived from name)")
 	rigAddCmd.Flags().StringVar(&rigAddLocalRepo, "local-repo", "", "Local repo path to share git objects (optional)")
 	rigAddCmd.Flags().StringVar(&rigAddBranch, "branch", "", "Default branch name (default: auto-detected from remote)")
+	rigAddCmd.Flags().StringVar(&rigAddMachine, "machine", "", "Run the rig's agents on this registered machine (default: local)")
 
 	rigResetCmd.Flags().BoolVar(&rigResetHandoff, "handoff", false, "Clear handoff content")
 	rigResetCmd.Flags().BoolVar(&rigResetMail, "mail", false, "Clear stale mail messages")
@@ -337,6 +345,9 @@ func runRigAdd(cmd *cobra.Command, args []string) error {
 	if rigAddLocalRepo != "" {
 		fmt.Printf("  Local repo: %s\n", rigAddLocalRepo)
 	}
+	if rigAddMachine != "" {
+		fmt.Printf("  Machine: %s\n", rigAddMachine)
+	}
 
 	startTime := time.Now()
 
@@ -347,6 +358,7 @@ func runRigAdd(cmd *cobra.Command, args []string) error {
 		BeadsPrefix:   rigAddPrefix,
 		LocalRepo:     rigAddLocalRepo,
 		DefaultBranch: rigAddBranch,
+		Machine:       rigAddMachine,
 	})
 	if err != nil {
 		return fmt.Errorf("adding rig: %w", err)
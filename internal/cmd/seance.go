@@ -55,7 +55,9 @@ This loads the predecessor's full context without modifying their session.
 
 Sessions are discovered from:
   1. Events emitted by SessionStart hooks (~/gt/.events.jsonl)
-  2. The [GAS TOWN] beacon makes sessions searchable in /resume`,
+  2. The [GAS TOWN] beacon makes sessions searchable in /resume
+
+To find a session by what it printed, use gt transcripts search.`,
 	RunE: runSeance,
 }
 
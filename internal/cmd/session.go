@@ -170,7 +170,7 @@ Examples:
 func init() {
 	// Start flags
 	sessionStartCmd.Flags().StringVar(&sessionIssue, "issue", "", "Issue ID to work on")
-	sessionStartCmd.Flags().StringVar(&sessionRuntime, "runtime", "", "Runtime adapter to use (claude, codex)")
+	sessionStartCmd.Flags().StringVar(&sessionRuntime, "runtime", "", "Runtime adapter to use (claude, codex, gemini, aider, or one from ~/.gastown/runtimes.json)")
 
 	// Stop flags
 	sessionStopCmd.Flags().BoolVarP(&sessionForce, "force", "f", false, "Force immediate shutdown")
@@ -188,7 +188,7 @@ func init() {
 
 	// Restart flags
 	sessionRestartCmd.Flags().BoolVarP(&sessionForce, "force", "f", false, "Force immediate shutdown")
-	sessionRestartCmd.Flags().StringVar(&sessionRuntime, "runtime", "", "Runtime adapter to use (claude, codex)")
+	sessionRestartCmd.Flags().StringVar(&sessionRuntime, "runtime", "", "Runtime adapter to use (claude, codex, gemini, aider, or one from ~/.gastown/runtimes.json)")
 
 	// Add subcommands
 	sessionCmd.AddCommand(sessionStartCmd)
@@ -345,7 +345,13 @@ func ensureRefinerySession(rigName string, r *rig.Rig) (bool, error) {
 
 	// Launch Claude directly (no respawn loop - daemon handles restart)
 	// Export GT_ROLE and BD_ACTOR in the command since tmux SetEnvironment only affects new panes
-	if err := t.SendKeys(sessionName, config.BuildAgentStartupCommand("refinery", bdActor, r.Path, "")); err != nil {
+	// Remote rigs run the refinery on their machine behind ssh
+	startCmd, err := r.RemoteCommand(refineryRigDir, config.BuildAgentStartupCommand("refinery", bdActor, r.Path, ""))
+	if err != nil {
+		_ = t.KillSession(sessionName)
+		return false, fmt.Errorf("preparing remote session: %w", err)
+	}
+	if err := t.SendKeys(sessionName, startCmd); err != nil {
 		return false, fmt.Errorf("sending command: %w", err)
 	}
 
@@ -172,8 +172,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	allHookBeads := make(map[string]*beads.Issue)
 
 	// Fetch town-level agent beads (Mayor, Deacon) from town beads
-	townBeadsPath := beads.GetTownBeadsPath(townRoot)
-	townBeadsClient := beads.New(townBeadsPath)
+	townBeadsClient := beads.SharedStore(townRoot)
 	townAgentBeads, _ := townBeadsClient.ListAgentBeads()
 	for id, issue := range townAgentBeads {
 		allAgentBeads[id] = issue
@@ -203,7 +202,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	// Fetch rig-level agent beads
 	for _, r := range rigs {
 		rigBeadsPath := filepath.Join(r.Path, "mayor", "rig")
-		rigBeads := beads.New(rigBeadsPath)
+		rigBeads := beads.SharedStore(rigBeadsPath)
 		rigAgentBeads, _ := rigBeads.ListAgentBeads()
 		if rigAgentBeads == nil {
 			continue
@@ -641,7 +640,7 @@ func discoverRigHooks(r *rig.Rig, crews []string) []AgentHookInfo {
 	var hooks []AgentHookInfo
 
 	// Create beads instance for the rig
-	b := beads.New(r.Path)
+	b := beads.SharedStore(r.Path)
 
 	// Check polecats
 	for _, name := range r.Polecats {
@@ -900,7 +899,7 @@ func getMQSummary(r *rig.Rig) *MQSummary {
 	}
 
 	// Create beads instance for the rig
-	b := beads.New(r.BeadsPath())
+	b := beads.SharedStore(r.BeadsPath())
 
 	// Query for all open merge-request type issues
 	opts := beads.ListOptions{
@@ -968,7 +967,7 @@ func getMQSummary(r *rig.Rig) *MQSummary {
 }
 
 // getAgentHook retrieves hook status for a specific agent.
-func getAgentHook(b *beads.Beads, role, agentAddress, roleType string) AgentHookInfo {
+func getAgentHook(b *beads.CachedStore, role, agentAddress, roleType string) AgentHookInfo {
 	hook := AgentHookInfo{
 		Agent: agentAddress,
 		Role:  roleType,
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/transcript"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	transcriptsRig   string
+	transcriptsRole  string
+	transcriptsAgent string
+	transcriptsSince string
+	transcriptsLimit int
+	transcriptsJSON  bool
+
+	// Record subcommand flags
+	transcriptsRecordTown    string
+	transcriptsRecordSession string
+)
+
+var transcriptsCmd = &cobra.Command{
+	Use:     "transcripts",
+	GroupID: GroupDiag,
+	Short:   "Search recorded agent session output",
+	Long: `Search what agents saw in their sessions.
+
+Every polecat, crew, witness and refinery session is recorded as it runs
+(via tmux pipe-pane) into rotated transcripts under logs/transcripts/.
+Search finds which agent saw a message, when, and the Claude session to
+resume with gt seance.
+
+Examples:
+  gt transcripts search "nil pointer"
+  gt transcripts search "FAIL" --rig gastown --role polecat --since 2h
+  gt transcripts search "rebase conflict" --agent gastown/refinery --json
+  gt transcripts list --rig gastown`,
+	RunE: requireSubcommand,
+}
+
+var transcriptsSearchCmd = &cobra.Command{
+	Use:   "search <text>",
+	Short: "Find transcript lines containing text",
+	Long: `Find transcript lines containing text (case-insensitive), newest first.
+
+Each match shows the agent, its tmux session, the time and the line. When
+the agent's Claude session is known from session_start events, the match
+links to it for gt seance --talk.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTranscriptsSearch,
+}
+
+var transcriptsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recorded transcript segments",
+	RunE:  runTranscriptsList,
+}
+
+var transcriptsRecordCmd = &cobra.Command{
+	Use:    "record",
+	Short:  "Record a session's pane output from stdin (run by tmux pipe-pane)",
+	Hidden: true,
+	RunE:   runTranscriptsRecord,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{transcriptsSearchCmd, transcriptsListCmd} {
+		c.Flags().StringVar(&transcriptsRig, "rig", "", "Only this rig's agents")
+		c.Flags().StringVar(&transcriptsRole, "role", "", "Only this role (polecat, crew, witness, refinery)")
+		c.Flags().StringVar(&transcriptsAgent, "agent", "", "Only this agent (e.g., gastown/polecats/Toast)")
+		c.Flags().StringVar(&transcriptsSince, "since", "", "Only output since duration (e.g., 30m, 2h, 7d)")
+		c.Flags().BoolVar(&transcriptsJSON, "json", false, "Output as JSON")
+	}
+	transcriptsSearchCmd.Flags().IntVarP(&transcriptsLimit, "limit", "n", transcript.DefaultLimit, "Maximum matches to show")
+
+	transcriptsRecordCmd.Flags().StringVar(&transcriptsRecordTown, "town", "", "Town root")
+	transcriptsRecordCmd.Flags().StringVar(&transcriptsRecordSession, "session", "", "tmux session name")
+	_ = transcriptsRecordCmd.MarkFlagRequired("town")
+	_ = transcriptsRecordCmd.MarkFlagRequired("session")
+
+	transcriptsCmd.AddCommand(transcriptsSearchCmd)
+	transcriptsCmd.AddCommand(transcriptsListCmd)
+	transcriptsCmd.AddCommand(transcriptsRecordCmd)
+	rootCmd.AddCommand(transcriptsCmd)
+}
+
+// transcriptMatch is a search result with its seance session, if known.
+type transcriptMatch struct {
+	transcript.Match
+	SeanceID string `json:"seance_session_id,omitempty"`
+}
+
+func transcriptsQuery() (transcript.Query, error) {
+	q := transcript.Query{Rig: transcriptsRig, Role: transcriptsRole, Agent: transcriptsAgent}
+	if transcriptsSince != "" {
+		d, err := parseDuration(transcriptsSince)
+		if err != nil {
+			return q, fmt.Errorf("invalid --since: %w", err)
+		}
+		q.Since = time.Now().Add(-d)
+	}
+	return q, nil
+}
+
+func runTranscriptsSearch(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	q, err := transcriptsQuery()
+	if err != nil {
+		return err
+	}
+	q.Text = args[0]
+	q.Limit = transcriptsLimit
+
+	matches, err := transcript.Search(townRoot, q)
+	if err != nil {
+		return fmt.Errorf("searching transcripts: %w", err)
+	}
+
+	// Link each match to the Claude session the agent was running then.
+	sessions, _ := discoverSessions(townRoot)
+	results := make([]transcriptMatch, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, transcriptMatch{Match: m, SeanceID: seanceSessionAt(sessions, m.Agent, m.Time)})
+	}
+
+	if transcriptsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No transcript lines match %q.\n", q.Text)
+		fmt.Println(style.Dim.Render("Transcripts are recorded under logs/transcripts/ while agents run"))
+		return nil
+	}
+
+	fmt.Printf("%s\n\n", style.Bold.Render(fmt.Sprintf("Transcript matches for %q", q.Text)))
+	for _, r := range results {
+		agent := r.Agent
+		if agent == "" {
+			agent = r.Session
+		}
+		fmt.Printf("%s  %s  %s\n", style.Dim.Render(r.Time.Local().Format("2006-01-02 15:04:05")),
+			style.Bold.Render(agent), style.Dim.Render(fmt.Sprintf("%s:%d", r.File, r.LineNo)))
+		fmt.Printf("  %s\n", truncateLine(r.Text, 160))
+		if r.SeanceID != "" {
+			fmt.Printf("  %s\n", style.Dim.Render("gt seance --talk "+r.SeanceID))
+		}
+	}
+	return nil
+}
+
+// seanceSessionAt returns the Claude session an agent started most
+// recently before t. sessions are newest first.
+func seanceSessionAt(sessions []sessionEvent, agent string, t time.Time) string {
+	if agent == "" {
+		return ""
+	}
+	for _, s := range sessions {
+		if s.Actor != agent {
+			continue
+		}
+		started, err := time.Parse(time.RFC3339, s.Timestamp)
+		if err != nil || started.After(t) {
+			continue
+		}
+		return getPayloadString(s.Payload, "session_id")
+	}
+	return ""
+}
+
+func truncateLine(s string, n int) string {
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func runTranscriptsList(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	q, err := transcriptsQuery()
+	if err != nil {
+		return err
+	}
+	segs, err := transcript.List(townRoot, q)
+	if err != nil {
+		return fmt.Errorf("reading transcript index: %w", err)
+	}
+
+	if transcriptsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(segs)
+	}
+	if len(segs) == 0 {
+		fmt.Println("No transcripts recorded.")
+		return nil
+	}
+	for _, seg := range segs {
+		agent := seg.Agent
+		if agent == "" {
+			agent = seg.Session
+		}
+		fmt.Printf("%s  %-32s  %s\n", seg.Started.Local().Format("2006-01-02 15:04"), agent,
+			style.Dim.Render(seg.File))
+	}
+	return nil
+}
+
+// recordTranscript starts recording a session the CLI just created. It is
+// best-effort: the daemon starts recording any agent session missed here.
+func recordTranscript(t *tmux.Tmux, sessionName string) {
+	if townRoot, err := workspace.FindFromCwd(); err == nil && townRoot != "" {
+		_ = transcript.Start(t, townRoot, sessionName)
+	}
+}
+
+func runTranscriptsRecord(cmd *cobra.Command, args []string) error {
+	meta := transcript.Segment{Session: transcriptsRecordSession}
+	if id, err := session.ParseSessionName(transcriptsRecordSession); err == nil {
+		meta.Agent = id.Address()
+		meta.Rig = id.Rig
+		meta.Role = string(id.Role)
+	}
+	return transcript.NewRecorder(transcriptsRecordTown, meta).Record(os.Stdin)
+}
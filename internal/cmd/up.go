@@ -15,6 +15,7 @@ import (
 	"github.com/steveyegge/gastown/internal/daemon"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -329,7 +330,12 @@ func ensureWitness(t *tmux.Tmux, sessionName, rigPath, rigName string) error {
 
 	// Launch Claude using runtime config
 	// Export GT_ROLE and BD_ACTOR in the command since tmux SetEnvironment only affects new panes
-	claudeCmd := config.BuildAgentStartupCommand("witness", bdActor, rigPath, "")
+	// Remote rigs run the witness on their machine behind ssh
+	claudeCmd, err := rig.RemoteCommandForRig(filepath.Dir(rigPath), rigName, rigPath, config.BuildAgentStartupCommand("witness", bdActor, rigPath, ""))
+	if err != nil {
+		_ = t.KillSession(sessionName)
+		return fmt.Errorf("preparing remote session: %w", err)
+	}
 	if err := t.SendKeysDelayed(sessionName, claudeCmd, 200); err != nil {
 		return err
 	}
@@ -649,6 +655,18 @@ func startPolecatsWithWork(t *tmux.Tmux, townRoot, rigName string) ([]string, ma
 
 // ensurePolecatSession starts a polecat session.
 func ensurePolecatSession(t *tmux.Tmux, sessionName, polecatPath, rigName, polecatName string) error {
+	// polecatPath is like ~/gt/gastown/polecats/toast, so rig path is two dirs up
+	rigPath := filepath.Dir(filepath.Dir(polecatPath))
+
+	// Sandboxed rigs never start polecats unconfined
+	sandbox, err := config.LoadRigSandbox(rigPath)
+	if err != nil {
+		return fmt.Errorf("loading sandbox settings: %w", err)
+	}
+	if err := sandbox.Check(); err != nil {
+		return err
+	}
+
 	// Create session in polecat directory
 	if err := t.NewSession(sessionName, polecatPath); err != nil {
 		return err
@@ -666,9 +684,12 @@ func ensurePolecatSession(t *tmux.Tmux, sessionName, polecatPath, rigName, polec
 	_ = t.ConfigureGasTownSession(sessionName, theme, "", "Polecat", polecatName)
 
 	// Launch Claude using runtime config
-	// polecatPath is like ~/gt/gastown/polecats/toast, so rig path is two dirs up
-	rigPath := filepath.Dir(filepath.Dir(polecatPath))
-	claudeCmd := config.BuildPolecatStartupCommand(rigName, polecatName, rigPath, "")
+	// Remote rigs run the polecat on their machine behind ssh
+	claudeCmd, err := rig.RemoteCommandForRig(filepath.Dir(rigPath), rigName, polecatPath, config.BuildPolecatStartupCommand(rigName, polecatName, rigPath, ""))
+	if err != nil {
+		_ = t.KillSession(sessionName)
+		return fmt.Errorf("preparing remote session: %w", err)
+	}
 	if err := t.SendKeysDelayed(sessionName, claudeCmd, 200); err != nil {
 		return err
 	}
@@ -16,6 +16,7 @@ import (
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/transcript"
 	"github.com/steveyegge/gastown/internal/witness"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
@@ -298,8 +299,8 @@ func ensureWitnessSession(rigName string, r *rig.Rig) (bool, error) {
 
 	if running {
 		// Session exists - check if Claude is actually running (healthy vs zombie)
-		if t.IsClaudeRunning(sessionName) {
-			// Healthy - Claude is running
+		if t.IsAgentRunning(sessionName, r.IsRemote()) {
+			// Healthy - Claude is running (behind ssh for remote rigs)
 			return false, nil
 		}
 		// Zombie - tmux alive but Claude dead. Kill and recreate.
@@ -341,11 +342,20 @@ func ensureWitnessSession(rigName string, r *rig.Rig) (bool, error) {
 	theme := tmux.AssignTheme(rigName)
 	_ = t.ConfigureGasTownSession(sessionName, theme, rigName, "witness", "witness")
 
+	// Record the session's output for gt transcripts (non-fatal)
+	_ = transcript.Start(t, filepath.Dir(r.Path), sessionName)
+
 	// Launch Claude directly (no shell respawn loop)
 	// Restarts are handled by daemon via LIFECYCLE mail or deacon health-scan
 	// NOTE: No gt prime injection needed - SessionStart hook handles it automatically
 	// Export GT_ROLE and BD_ACTOR in the command since tmux SetEnvironment only affects new panes
-	if err := t.SendKeys(sessionName, config.BuildAgentStartupCommand("witness", bdActor, r.Path, "")); err != nil {
+	// Remote rigs run the witness on their machine behind ssh
+	startCmd, err := r.RemoteCommand(witnessDir, config.BuildAgentStartupCommand("witness", bdActor, r.Path, ""))
+	if err != nil {
+		_ = t.KillSession(sessionName)
+		return false, fmt.Errorf("preparing remote session: %w", err)
+	}
+	if err := t.SendKeys(sessionName, startCmd); err != nil {
 		return false, fmt.Errorf("sending command: %w", err)
 	}
 
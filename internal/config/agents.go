@@ -21,6 +21,8 @@ const (
 	AgentGemini AgentPreset = "gemini"
 	// AgentCodex is OpenAI Codex.
 	AgentCodex AgentPreset = "codex"
+	// AgentAider is Aider.
+	AgentAider AgentPreset = "aider"
 )
 
 // AgentPresetInfo contains the configuration details for an agent preset.
@@ -58,6 +60,10 @@ type AgentPresetInfo struct {
 
 	// NonInteractive contains settings for non-interactive mode.
 	NonInteractive *NonInteractiveConfig `json:"non_interactive,omitempty"`
+
+	// StatePaths are home-relative paths the agent writes (settings,
+	// credentials, session logs). Sandboxes keep them writable.
+	StatePaths []string `json:"state_paths,omitempty"`
 }
 
 // NonInteractiveConfig contains settings for running agents non-interactively.
@@ -97,6 +103,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		SupportsHooks:       true,
 		SupportsForkSession: true,
 		NonInteractive:      nil, // Claude is native non-interactive
+		StatePaths:          []string{".claude", ".claude.json"},
 	},
 	AgentGemini: {
 		Name:                AgentGemini,
@@ -111,6 +118,7 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 			PromptFlag: "-p",
 			OutputFlag: "--output-format json",
 		},
+		StatePaths: []string{".gemini"},
 	},
 	AgentCodex: {
 		Name:                AgentCodex,
@@ -125,6 +133,20 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 			Subcommand: "exec",
 			OutputFlag: "--json",
 		},
+		StatePaths: []string{".codex"},
+	},
+	AgentAider: {
+		Name:                AgentAider,
+		Command:             "aider",
+		Args:                []string{"--yes-always"},
+		SessionIDEnv:        "", // Aider keeps chat history per directory
+		ResumeFlag:          "", // Resumed with --restore-chat-history, no session ID
+		SupportsHooks:       false,
+		SupportsForkSession: false,
+		NonInteractive: &NonInteractiveConfig{
+			PromptFlag: "--message",
+		},
+		StatePaths: []string{".aider"},
 	},
 }
 
@@ -10,7 +10,7 @@ import (
 
 func TestBuiltinPresets(t *testing.T) {
 	// Ensure all built-in presets are accessible (E2E tested agents only)
-	presets := []AgentPreset{AgentClaude, AgentGemini, AgentCodex}
+	presets := []AgentPreset{AgentClaude, AgentGemini, AgentCodex, AgentAider}
 
 	for _, preset := range presets {
 		info := GetAgentPreset(preset)
@@ -34,7 +34,7 @@ func TestGetAgentPresetByName(t *testing.T) {
 		{"claude", AgentClaude, false},
 		{"gemini", AgentGemini, false},
 		{"codex", AgentCodex, false},
-		{"aider", "", true},    // Not built-in, can be added via config
+		{"aider", AgentAider, false},
 		{"opencode", "", true}, // Not built-in, can be added via config
 		{"unknown", "", true},
 	}
@@ -84,7 +84,7 @@ func TestIsKnownPreset(t *testing.T) {
 		{"claude", true},
 		{"gemini", true},
 		{"codex", true},
-		{"aider", false},    // Not built-in, can be added via config
+		{"aider", true},
 		{"opencode", false}, // Not built-in, can be added via config
 		{"unknown", false},
 		{"chatgpt", false},
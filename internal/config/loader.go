@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"sort"
@@ -209,6 +210,50 @@ func validateRigSettings(c *RigSettings) error {
 			return err
 		}
 	}
+	if c.Budget != nil {
+		if err := validateBudgetConfig(c.Budget); err != nil {
+			return err
+		}
+	}
+	if c.Sandbox != nil {
+		if err := validateSandboxConfig(c.Sandbox); err != nil {
+			return err
+		}
+	}
+	if c.Autoscale != nil {
+		if err := validateAutoscaleConfig(c.Autoscale); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAutoscaleConfig validates an AutoscaleConfig.
+func validateAutoscaleConfig(c *AutoscaleConfig) error {
+	if c.MaxPolecats < 0 || c.MaxSpawnPerCycle < 0 || c.MaxLoadPerCPU < 0 {
+		return fmt.Errorf("invalid autoscale limits: max_polecats, max_spawn_per_cycle and max_load_per_cpu must be non-negative")
+	}
+	if c.IdleCooldown != "" {
+		if d, err := time.ParseDuration(c.IdleCooldown); err != nil || d <= 0 {
+			return fmt.Errorf("invalid autoscale idle_cooldown '%s': want a positive duration like 15m", c.IdleCooldown)
+		}
+	}
+	return nil
+}
+
+// validateBudgetConfig validates a BudgetConfig.
+func validateBudgetConfig(c *BudgetConfig) error {
+	if c.DailyUSD < 0 || c.PolecatSessionUSD < 0 {
+		return fmt.Errorf("%w: budget caps must be non-negative", ErrMissingField)
+	}
+	if c.WarnAt < 0 || c.WarnAt > 1 {
+		return fmt.Errorf("invalid budget warn_at %v: want a fraction between 0 and 1", c.WarnAt)
+	}
+	switch c.OnExceed {
+	case "", BudgetActionWarn, BudgetActionPark, BudgetActionStop:
+	default:
+		return fmt.Errorf("invalid budget on_exceed '%s': want warn, park or stop", c.OnExceed)
+	}
 	return nil
 }
 
@@ -237,6 +282,28 @@ func validateMergeQueueConfig(c *MergeQueueConfig) error {
 	if c.MaxConcurrent < 0 {
 		return fmt.Errorf("%w: max_concurrent must be non-negative", ErrMissingField)
 	}
+	if c.QuarantineFlakyAfter < 0 {
+		return fmt.Errorf("%w: quarantine_flaky_after must be non-negative", ErrMissingField)
+	}
+	if c.QuarantineFlakyAfter > 0 && c.RetryFlakyTests < 2 {
+		return fmt.Errorf("%w: quarantine_flaky_after needs retry_flaky_tests of at least 2", ErrMissingField)
+	}
+
+	if c.BatchSize < 0 {
+		return fmt.Errorf("%w: batch_size must be non-negative", ErrMissingField)
+	}
+	for i := range c.Resolvers {
+		if err := c.Resolvers[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate test_format against the built-in parsers
+	switch c.TestFormat {
+	case "", "auto", "none", "go-json", "junit", "tap":
+	default:
+		return fmt.Errorf("invalid test_format '%s': want auto, none, go-json, junit or tap", c.TestFormat)
+	}
 
 	return nil
 }
@@ -357,6 +424,85 @@ func validateMayorConfig(c *MayorConfig) error {
 	if c.Version > CurrentMayorConfigVersion {
 		return fmt.Errorf("%w: got %d, max supported %d", ErrInvalidVersion, c.Version, CurrentMayorConfigVersion)
 	}
+	if c.Notify != nil {
+		if err := validateNotifyConfig(c.Notify); err != nil {
+			return err
+		}
+	}
+	if c.Daemon != nil && c.Daemon.APIListen != "" {
+		if err := ValidateLoopback(c.Daemon.APIListen); err != nil {
+			return fmt.Errorf("daemon.api_listen: %w", err)
+		}
+	}
+	if c.Daemon != nil && c.Daemon.CheckpointInterval != "" {
+		if d, err := time.ParseDuration(c.Daemon.CheckpointInterval); err != nil || d < 0 {
+			return fmt.Errorf("daemon.checkpoint_interval: invalid duration %q", c.Daemon.CheckpointInterval)
+		}
+	}
+	return nil
+}
+
+// ValidateLoopback checks that addr is a host:port on the loopback
+// interface. The control API has no TLS, so it must not be reachable from
+// other machines.
+func ValidateLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%q is not a loopback address", addr)
+}
+
+// validateNotifyConfig validates a NotifyConfig.
+func validateNotifyConfig(c *NotifyConfig) error {
+	for name, ch := range c.Channels {
+		if ch == nil {
+			return fmt.Errorf("notify channel %q: %w: type", name, ErrMissingField)
+		}
+		switch ch.Type {
+		case NotifyWebhook, NotifySlack:
+			if ch.URL == "" {
+				return fmt.Errorf("notify channel %q: %w: url", name, ErrMissingField)
+			}
+		case NotifySMTP:
+			if ch.SMTP == nil || ch.SMTP.Host == "" || ch.SMTP.From == "" || len(ch.SMTP.To) == 0 {
+				return fmt.Errorf("notify channel %q: %w: smtp host, from and to", name, ErrMissingField)
+			}
+		default:
+			return fmt.Errorf("notify channel %q: invalid type '%s': want webhook, slack or smtp", name, ch.Type)
+		}
+	}
+	for i, r := range c.Routes {
+		if len(r.Channels) == 0 {
+			return fmt.Errorf("notify route %d: %w: channels", i, ErrMissingField)
+		}
+		for _, name := range r.Channels {
+			if c.Channels[name] == nil {
+				return fmt.Errorf("notify route %d: unknown channel %q", i, name)
+			}
+		}
+		if r.Throttle != "" {
+			if _, err := time.ParseDuration(r.Throttle); err != nil {
+				return fmt.Errorf("notify route %d: invalid throttle: %w", i, err)
+			}
+		}
+	}
+	if c.Retry != nil {
+		for field, v := range map[string]string{"backoff": c.Retry.Backoff, "max_backoff": c.Retry.MaxBackoff} {
+			if v == "" {
+				continue
+			}
+			if _, err := time.ParseDuration(v); err != nil {
+				return fmt.Errorf("notify retry: invalid %s: %w", field, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -684,8 +830,10 @@ func ResolveRuntimeName(rigPath, override string) string {
 	}
 	rc := LoadRuntimeConfig(rigPath)
 	cmd := strings.ToLower(filepath.Base(rc.Command))
-	if strings.Contains(cmd, "codex") {
-		return "codex"
+	for _, name := range []string{"codex", "gemini", "aider"} {
+		if strings.Contains(cmd, name) {
+			return name
+		}
 	}
 	return "claude"
 }
@@ -866,6 +1014,12 @@ func GetRuntimeCommandWithPrompt(rigPath, prompt string) string {
 // rigPath is optional - if empty, uses defaults.
 // prompt is optional - if provided, appended as the initial prompt.
 func BuildStartupCommand(envVars map[string]string, rigPath, prompt string) string {
+	return buildStartupCommand(envVars, rigPath, prompt, nil)
+}
+
+// buildStartupCommand is BuildStartupCommand with the agent command run
+// inside sandbox, if it is enabled. The exports stay outside.
+func buildStartupCommand(envVars map[string]string, rigPath, prompt string, sandbox *SandboxConfig) string {
 	var rc *RuntimeConfig
 	if rigPath != "" {
 		// Derive town root from rig path
@@ -890,13 +1044,19 @@ func BuildStartupCommand(envVars map[string]string, rigPath, prompt string) stri
 	}
 
 	// Add runtime command
+	agentCmd := rc.BuildCommand()
 	if prompt != "" {
-		cmd += rc.BuildCommandWithPrompt(prompt)
-	} else {
-		cmd += rc.BuildCommand()
+		agentCmd = rc.BuildCommandWithPrompt(prompt)
+	}
+	if sandbox.Enabled() {
+		names := make([]string, 0, len(envVars))
+		for k := range envVars {
+			names = append(names, k)
+		}
+		agentCmd = sandbox.WrapCommand(agentCmd, rigPath, names)
 	}
 
-	return cmd
+	return cmd + agentCmd
 }
 
 // BuildTownStartupCommand builds a startup command using town-level runtime defaults.
@@ -951,6 +1111,9 @@ func BuildAgentStartupCommandForTown(role, bdActor, townRoot, prompt string) str
 
 // BuildPolecatStartupCommand builds the startup command for a polecat.
 // Sets GT_ROLE, GT_RIG, GT_POLECAT, BD_ACTOR, and GIT_AUTHOR_NAME.
+// If the rig has a sandbox, the agent runs inside it; unreadable settings
+// mean no sandbox here, so callers that must enforce it check
+// LoadRigSandbox first (as session.Manager.Start does).
 func BuildPolecatStartupCommand(rigName, polecatName, rigPath, prompt string) string {
 	bdActor := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
 	envVars := map[string]string{
@@ -960,7 +1123,11 @@ func BuildPolecatStartupCommand(rigName, polecatName, rigPath, prompt string) st
 		"BD_ACTOR":        bdActor,
 		"GIT_AUTHOR_NAME": polecatName,
 	}
-	return BuildStartupCommand(envVars, rigPath, prompt)
+	var sandbox *SandboxConfig
+	if rigPath != "" {
+		sandbox, _ = LoadRigSandbox(rigPath)
+	}
+	return buildStartupCommand(envVars, rigPath, prompt, sandbox)
 }
 
 // BuildCrewStartupCommand builds the startup command for a crew member.
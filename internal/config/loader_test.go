@@ -402,6 +402,30 @@ func TestMayorConfigRoundTrip(t *testing.T) {
 	}
 }
 
+func TestMayorConfigAPIListen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mayor", "config.json")
+
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:7070": true,
+		"localhost:7070": true,
+		"[::1]:7070":     true,
+		"0.0.0.0:7070":   false,
+		":7070":          false,
+		"10.0.0.2:7070":  false,
+		"127.0.0.1":      false,
+	} {
+		cfg := NewMayorConfig()
+		cfg.Daemon = &DaemonConfig{APIListen: addr}
+		err := SaveMayorConfig(path, cfg)
+		if ok && err != nil {
+			t.Errorf("api_listen %q rejected: %v", addr, err)
+		}
+		if !ok && err == nil {
+			t.Errorf("api_listen %q accepted", addr)
+		}
+	}
+}
+
 func TestLoadMayorConfigNotFound(t *testing.T) {
 	_, err := LoadMayorConfig("/nonexistent/path.json")
 	if err == nil {
@@ -0,0 +1,326 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Polecat sandboxes
+//
+// WrapCommand turns an agent command line into one that runs the agent
+// inside the rig's sandbox. The command is typed into the polecat's tmux
+// pane, whose working directory is the polecat's worktree, so the worktree
+// is referenced as "$PWD" and resolved by the pane's shell.
+
+// sandboxSystemPaths are the host directories every namespace sandbox
+// exposes read-only. Containers get these from their image.
+var sandboxSystemPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib64", "/lib32", "/etc", "/opt", "/nix"}
+
+// sandboxMemoryPattern matches Memory values: bytes with an optional suffix.
+var sandboxMemoryPattern = regexp.MustCompile(`^\d+[KMGT]?$`)
+
+// validateSandboxConfig validates a SandboxConfig.
+func validateSandboxConfig(c *SandboxConfig) error {
+	switch c.Mode {
+	case "", SandboxBwrap, SandboxUnshare:
+	case SandboxPodman, SandboxDocker:
+		if c.Image == "" {
+			return fmt.Errorf("%w: sandbox mode %s needs an image", ErrMissingField, c.Mode)
+		}
+	default:
+		return fmt.Errorf("invalid sandbox mode '%s': want bwrap, unshare, podman or docker", c.Mode)
+	}
+	switch c.Network {
+	case "", SandboxNetworkHost, SandboxNetworkNone:
+	default:
+		return fmt.Errorf("invalid sandbox network '%s': want host or none", c.Network)
+	}
+	if c.CPUs < 0 {
+		return fmt.Errorf("invalid sandbox cpus %v: must be non-negative", c.CPUs)
+	}
+	if c.Memory != "" && !sandboxMemoryPattern.MatchString(c.Memory) {
+		return fmt.Errorf("invalid sandbox memory '%s': want bytes with an optional K, M, G or T suffix", c.Memory)
+	}
+	for _, p := range append(append([]string(nil), c.ReadOnlyPaths...), c.ReadWritePaths...) {
+		if !filepath.IsAbs(p) && !strings.HasPrefix(p, "~/") {
+			return fmt.Errorf("invalid sandbox path '%s': must be absolute or start with ~/", p)
+		}
+	}
+	return nil
+}
+
+// LoadRigSandbox returns the rig's sandbox settings, or nil if the rig has
+// no settings or no sandbox.
+func LoadRigSandbox(rigPath string) (*SandboxConfig, error) {
+	settings, err := LoadRigSettings(RigSettingsPath(rigPath))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !settings.Sandbox.Enabled() {
+		return nil, nil
+	}
+	return settings.Sandbox, nil
+}
+
+// hasLimits reports whether CPU or memory caps are set.
+func (c *SandboxConfig) hasLimits() bool {
+	return c.CPUs > 0 || c.Memory != ""
+}
+
+// isContainer reports whether the mode runs a container engine.
+func (c *SandboxConfig) isContainer() bool {
+	return c.Mode == SandboxPodman || c.Mode == SandboxDocker
+}
+
+// Check reports whether the tools the sandbox needs are installed.
+func (c *SandboxConfig) Check() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if _, err := exec.LookPath(c.Mode); err != nil {
+		return fmt.Errorf("sandbox mode %s: %s not found in PATH", c.Mode, c.Mode)
+	}
+	if c.hasLimits() && !c.isContainer() {
+		if _, err := exec.LookPath("systemd-run"); err != nil {
+			return fmt.Errorf("sandbox cpu/memory limits need systemd-run, which is not in PATH")
+		}
+	}
+	return nil
+}
+
+// WrapCommand returns command, run from a polecat worktree in rigPath, as
+// a command that runs it inside the sandbox. envNames are variables the
+// caller exported for the agent; containers pass them through. The
+// program the command runs picks the agent whose install and state paths
+// are exposed.
+func (c *SandboxConfig) WrapCommand(command, rigPath string, envNames []string) string {
+	if !c.Enabled() {
+		return command
+	}
+	ro, rw := c.paths(rigPath, command)
+	switch c.Mode {
+	case SandboxBwrap:
+		return c.limitPrefix() + c.bwrapCommand(ro, rw, command)
+	case SandboxUnshare:
+		return c.limitPrefix() + c.unshareCommand(ro, rw, command)
+	default:
+		return c.containerCommand(ro, rw, envNames, command)
+	}
+}
+
+// paths returns the host paths exposed read-only and read-write, besides
+// the worktree itself.
+func (c *SandboxConfig) paths(rigPath, command string) (ro, rw []string) {
+	if !c.isContainer() {
+		ro = append(ro, sandboxSystemPaths...)
+		ro = append(ro, executablePaths(command)...)
+	}
+
+	// Town and rig configuration, read by gt inside the sandbox.
+	townRoot := filepath.Dir(rigPath)
+	ro = append(ro,
+		filepath.Join(townRoot, "mayor"),
+		filepath.Join(townRoot, "settings"),
+		filepath.Join(rigPath, "config.json"),
+		filepath.Join(rigPath, "settings"),
+	)
+	for _, p := range c.ReadOnlyPaths {
+		ro = append(ro, expandPath(p))
+	}
+
+	// Worktrees share the rig's git objects and refs; hooks, mail and the
+	// merge queue live in beads.
+	gitDir := filepath.Join(rigPath, ".repo.git")
+	if _, err := os.Stat(gitDir); err != nil {
+		gitDir = filepath.Join(rigPath, "mayor", "rig", ".git")
+	}
+	rw = append(rw, gitDir, filepath.Join(townRoot, ".beads"), filepath.Join(rigPath, ".beads"))
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, p := range agentStatePaths(command) {
+			rw = append(rw, filepath.Join(home, p))
+		}
+	}
+	for _, p := range c.ReadWritePaths {
+		rw = append(rw, expandPath(p))
+	}
+	return ro, rw
+}
+
+// executablePaths returns the directories holding gt and the command's
+// binary (and its symlink target), so both run inside the sandbox.
+func executablePaths(command string) []string {
+	var paths []string
+	add := func(bin string) {
+		if bin == "" {
+			return
+		}
+		paths = append(paths, filepath.Dir(bin))
+		if real, err := filepath.EvalSymlinks(bin); err == nil && filepath.Dir(real) != filepath.Dir(bin) {
+			paths = append(paths, filepath.Dir(real))
+		}
+	}
+	if exe, err := os.Executable(); err == nil {
+		add(exe)
+	}
+	if bin, err := exec.LookPath(commandName(command)); err == nil {
+		if abs, err := filepath.Abs(bin); err == nil {
+			add(abs)
+		}
+	}
+	return paths
+}
+
+// agentStatePaths returns the state paths of the preset whose binary the
+// command runs.
+func agentStatePaths(command string) []string {
+	name := filepath.Base(commandName(command))
+	for _, preset := range ListAgentPresets() {
+		info := GetAgentPresetByName(preset)
+		if info != nil && filepath.Base(info.Command) == name {
+			return info.StatePaths
+		}
+	}
+	return nil
+}
+
+// commandName returns the program a command line runs, skipping leading
+// exports and variable assignments ("export A=b && claude ...").
+func commandName(command string) string {
+	for _, field := range strings.Fields(command) {
+		switch {
+		case field == "export", field == "exec", field == "&&", strings.Contains(field, "="):
+			continue
+		}
+		return field
+	}
+	return ""
+}
+
+// limitPrefix runs the sandbox in a transient systemd scope carrying the
+// CPU and memory caps.
+func (c *SandboxConfig) limitPrefix() string {
+	if !c.hasLimits() {
+		return ""
+	}
+	args := []string{"systemd-run", "--scope", "--quiet", "--collect"}
+	if os.Getuid() != 0 {
+		args = append(args, "--user")
+	}
+	if c.CPUs > 0 {
+		args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", int(math.Round(c.CPUs*100))))
+	}
+	if c.Memory != "" {
+		args = append(args, "-p", "MemoryMax="+c.Memory)
+	}
+	return strings.Join(args, " ") + " -- "
+}
+
+// bwrapCommand builds a bubblewrap invocation. Later binds win, so
+// writable paths and the worktree go last.
+func (c *SandboxConfig) bwrapCommand(ro, rw []string, command string) string {
+	args := []string{"bwrap", "--die-with-parent", "--unshare-pid", "--unshare-ipc", "--unshare-uts"}
+	if c.Network == SandboxNetworkNone {
+		args = append(args, "--unshare-net")
+	}
+	args = append(args, "--proc", "/proc", "--dev", "/dev", "--tmpfs", "/tmp")
+	for _, p := range ro {
+		args = append(args, "--ro-bind-try", util.ShellQuote(p), util.ShellQuote(p))
+	}
+	for _, p := range rw {
+		args = append(args, "--bind-try", util.ShellQuote(p), util.ShellQuote(p))
+	}
+	args = append(args, "--bind", `"$PWD"`, `"$PWD"`, "--chdir", `"$PWD"`, "--", "sh", "-c", util.ShellQuote(command))
+	return strings.Join(args, " ")
+}
+
+// unshareCommand builds an unshare invocation. As root in a new user and
+// mount namespace, a setup script bind-mounts the exposed paths into a
+// fresh tmpfs root and pivots into it, then maps back to the caller's
+// user before running the agent (many agents refuse to run as root).
+func (c *SandboxConfig) unshareCommand(ro, rw []string, command string) string {
+	var script strings.Builder
+	script.WriteString(`set -e
+root=$(mktemp -d)
+mount -t tmpfs gt-sandbox "$root"
+bind() { [ -e "$2" ] || return 0; if [ -d "$2" ]; then mkdir -p "$root$2"; else mkdir -p "$root$(dirname "$2")"; touch "$root$2"; fi; mount --rbind "$2" "$root$2"; [ "$1" = rw ] || mount -o remount,bind,ro "$root$2"; }
+`)
+	for _, p := range ro {
+		fmt.Fprintf(&script, "bind ro %s\n", util.ShellQuote(p))
+	}
+	for _, p := range rw {
+		fmt.Fprintf(&script, "bind rw %s\n", util.ShellQuote(p))
+	}
+	script.WriteString(`bind rw "$1"
+mkdir -p "$root/proc" "$root/dev" "$root/tmp" "$root/.oldroot"
+mount -t proc proc "$root/proc"
+mount --rbind /dev "$root/dev"
+cd "$root"
+pivot_root . .oldroot
+umount -l /.oldroot
+rmdir /.oldroot
+cd "$1"
+`)
+	if uid := os.Getuid(); uid != 0 {
+		fmt.Fprintf(&script, "exec unshare --user --map-user=%d --map-group=%d sh -c \"$2\"\n", uid, os.Getgid())
+	} else {
+		script.WriteString("exec sh -c \"$2\"\n")
+	}
+
+	args := []string{"unshare", "--user", "--map-root-user", "--mount", "--pid", "--fork", "--ipc", "--uts"}
+	if c.Network == SandboxNetworkNone {
+		args = append(args, "--net")
+	}
+	args = append(args, "sh", "-c", util.ShellQuote(script.String()), "gt-sandbox", `"$PWD"`, util.ShellQuote(command))
+	return strings.Join(args, " ")
+}
+
+// containerCommand builds a podman or docker run. The container runs as
+// the caller's user with the same paths, so git worktree links resolve.
+func (c *SandboxConfig) containerCommand(ro, rw, envNames []string, command string) string {
+	args := []string{c.Mode, "run", "--rm", "-it", "--init"}
+	if c.Mode == SandboxPodman {
+		args = append(args, "--userns=keep-id")
+	} else {
+		args = append(args, fmt.Sprintf("--user=%d:%d", os.Getuid(), os.Getgid()))
+	}
+	if c.Network == SandboxNetworkNone {
+		args = append(args, "--network=none")
+	}
+	if c.CPUs > 0 {
+		args = append(args, fmt.Sprintf("--cpus=%g", c.CPUs))
+	}
+	if c.Memory != "" {
+		args = append(args, "--memory="+c.Memory)
+	}
+	// Engines fail on missing bind sources, so only existing paths.
+	for _, p := range ro {
+		if _, err := os.Stat(p); err == nil {
+			args = append(args, "-v", util.ShellQuote(p+":"+p+":ro"))
+		}
+	}
+	for _, p := range rw {
+		if _, err := os.Stat(p); err == nil {
+			args = append(args, "-v", util.ShellQuote(p+":"+p))
+		}
+	}
+	args = append(args, "-v", `"$PWD:$PWD"`, "-w", `"$PWD"`, "-e", "HOME")
+	names := append([]string(nil), envNames...)
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "-e", name)
+	}
+	args = append(args, util.ShellQuote(c.Image), "sh", "-c", util.ShellQuote(command))
+	return strings.Join(args, " ")
+}
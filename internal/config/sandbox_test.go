@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateSandboxConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SandboxConfig
+		wantErr bool
+	}{
+		{"bwrap with limits", SandboxConfig{Mode: "bwrap", CPUs: 1.5, Memory: "4G", Network: "none"}, false},
+		{"unshare", SandboxConfig{Mode: "unshare", ReadOnlyPaths: []string{"~/go", "/opt/tools"}}, false},
+		{"podman", SandboxConfig{Mode: "podman", Image: "ghcr.io/acme/agent:latest"}, false},
+		{"unknown mode", SandboxConfig{Mode: "firejail"}, true},
+		{"container without image", SandboxConfig{Mode: "docker"}, true},
+		{"bad network", SandboxConfig{Mode: "bwrap", Network: "lan"}, true},
+		{"bad memory", SandboxConfig{Mode: "bwrap", Memory: "4GB"}, true},
+		{"negative cpus", SandboxConfig{Mode: "bwrap", CPUs: -1}, true},
+		{"relative path", SandboxConfig{Mode: "bwrap", ReadWritePaths: []string{"cache"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSandboxConfig(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSandboxConfig() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildPolecatStartupCommand_Sandboxed(t *testing.T) {
+	rigPath := filepath.Join(t.TempDir(), "gastown")
+	settings := NewRigSettings()
+	settings.Sandbox = &SandboxConfig{Mode: SandboxBwrap, Network: SandboxNetworkNone, CPUs: 1.5, Memory: "2G"}
+	if err := SaveRigSettings(RigSettingsPath(rigPath), settings); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := BuildPolecatStartupCommand("gastown", "toast", rigPath, "")
+	exports, agent, ok := strings.Cut(cmd, " && ")
+	if !ok || !strings.Contains(exports, "GT_POLECAT=toast") {
+		t.Fatalf("exports not kept outside the sandbox: %s", cmd)
+	}
+	for _, want := range []string{
+		"systemd-run --scope",
+		"CPUQuota=150%",
+		"MemoryMax=2G",
+		"bwrap --die-with-parent",
+		"--unshare-net",
+		"--bind-try '" + filepath.Join(rigPath, "mayor", "rig", ".git") + "'",
+		"--ro-bind-try '" + filepath.Join(rigPath, "settings") + "'",
+		`--bind "$PWD" "$PWD" --chdir "$PWD"`,
+		"sh -c 'claude --dangerously-skip-permissions'",
+	} {
+		if !strings.Contains(agent, want) {
+			t.Errorf("sandboxed command missing %q:\n%s", want, agent)
+		}
+	}
+
+	// Other roles in the rig are not sandboxed.
+	if crew := BuildCrewStartupCommand("gastown", "max", rigPath, ""); strings.Contains(crew, "bwrap") {
+		t.Errorf("crew command sandboxed: %s", crew)
+	}
+}
+
+func TestSandboxConfig_ContainerCommand(t *testing.T) {
+	c := &SandboxConfig{Mode: SandboxPodman, Image: "agent:1", Network: SandboxNetworkNone, Memory: "1G"}
+	cmd := c.WrapCommand(`claude "do it"`, filepath.Join(t.TempDir(), "rig"), []string{"GT_ROLE", "BD_ACTOR"})
+	for _, want := range []string{
+		"podman run --rm -it --init --userns=keep-id --network=none --memory=1G",
+		`-v "$PWD:$PWD" -w "$PWD"`,
+		"-e BD_ACTOR -e GT_ROLE",
+		`'agent:1' sh -c 'claude "do it"'`,
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Errorf("container command missing %q:\n%s", want, cmd)
+		}
+	}
+}
+
+func TestSandboxConfig_UnshareConfines(t *testing.T) {
+	if err := exec.Command("unshare", "--user", "--map-root-user", "--mount", "true").Run(); err != nil {
+		t.Skip("unprivileged user namespaces not available")
+	}
+
+	town := t.TempDir()
+	rigPath := filepath.Join(town, "gastown")
+	workDir := filepath.Join(rigPath, "polecats", "toast")
+	for _, dir := range []string{workDir, filepath.Join(rigPath, "settings"), filepath.Join(rigPath, "polecats", "nux")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.Join(rigPath, "polecats", "nux", "secret")
+	if err := os.WriteFile(secret, []byte("hidden"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings := filepath.Join(rigPath, "settings", "config.json")
+	if err := os.WriteFile(settings, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(script string) (string, error) {
+		c := &SandboxConfig{Mode: SandboxUnshare, Network: SandboxNetworkNone}
+		cmd := exec.Command("sh", "-c", c.WrapCommand(script, rigPath, nil))
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "PWD="+workDir)
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := run("echo ok > result && cat " + settings); err != nil {
+		t.Fatalf("sandboxed command failed: %v\n%s", err, out)
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, "result")); err != nil || string(data) != "ok\n" {
+		t.Errorf("write in worktree not visible on host: %q, %v", data, err)
+	}
+	if out, err := run("cat " + secret); err == nil || !strings.Contains(out, "No such file") {
+		t.Errorf("another polecat's worktree is visible: %s", out)
+	}
+	if out, err := run("touch " + settings); err == nil || !strings.Contains(out, "Read-only") {
+		t.Errorf("rig settings are writable: %s", out)
+	}
+}
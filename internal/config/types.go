@@ -2,7 +2,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path"
+	"slices"
 	"strings"
 	"time"
 )
@@ -27,6 +31,7 @@ type MayorConfig struct {
 	Deacon          *DeaconConfig    `json:"deacon,omitempty"`            // deacon settings
 	DefaultCrewName string           `json:"default_crew_name,omitempty"` // default crew name for new rigs
 	RuntimeDefault  string           `json:"runtime_default,omitempty"`   // default runtime adapter (claude, codex)
+	Notify          *NotifyConfig    `json:"notify,omitempty"`            // outbound notifications
 }
 
 // CurrentTownSettingsVersion is the current schema version for TownSettings.
@@ -49,6 +54,214 @@ type TownSettings struct {
 	// Values override or extend the built-in presets.
 	// Example: {"gemini": {"command": "/custom/path/to/gemini"}}
 	Agents map[string]*RuntimeConfig `json:"agents,omitempty"`
+
+	// Retention controls how long wisp digests and their rollups are kept.
+	Retention *RetentionConfig `json:"retention,omitempty"`
+
+	// Escalations sets the acknowledgement and resolution SLAs of gt escalate.
+	Escalations *EscalationConfig `json:"escalations,omitempty"`
+
+	// DogJobs configures the recurring infrastructure jobs run by dogs.
+	DogJobs *DogJobsConfig `json:"dog_jobs,omitempty"`
+}
+
+// RetentionConfig controls the digests left by squashed molecules. Each
+// finished day's digests are rolled into a daily rollup per agent, and daily
+// rollups into weekly and monthly ones, which are kept forever.
+type RetentionConfig struct {
+	// DigestActiveDays is how long individual digests are kept.
+	// Default: 30
+	DigestActiveDays int `json:"digest_active_days,omitempty"`
+
+	// DigestArchiveDays is how long daily rollups are kept.
+	// Default: 365
+	DigestArchiveDays int `json:"digest_archive_days,omitempty"`
+
+	// RollupWeekly rolls daily rollups into weekly ones. Default: true
+	RollupWeekly *bool `json:"rollup_weekly,omitempty"`
+
+	// RollupMonthly rolls daily rollups into monthly ones. Default: true
+	RollupMonthly *bool `json:"rollup_monthly,omitempty"`
+}
+
+// Retention defaults.
+const (
+	DefaultDigestActiveDays  = 30
+	DefaultDigestArchiveDays = 365
+)
+
+// ActiveDays returns DigestActiveDays, or the default. Safe on a nil config.
+func (c *RetentionConfig) ActiveDays() int {
+	if c == nil || c.DigestActiveDays <= 0 {
+		return DefaultDigestActiveDays
+	}
+	return c.DigestActiveDays
+}
+
+// ArchiveDays returns DigestArchiveDays, or the default. Safe on a nil config.
+func (c *RetentionConfig) ArchiveDays() int {
+	if c == nil || c.DigestArchiveDays <= 0 {
+		return DefaultDigestArchiveDays
+	}
+	return c.DigestArchiveDays
+}
+
+// Weekly reports whether weekly rollups are made. Safe on a nil config.
+func (c *RetentionConfig) Weekly() bool {
+	return c == nil || c.RollupWeekly == nil || *c.RollupWeekly
+}
+
+// Monthly reports whether monthly rollups are made. Safe on a nil config.
+func (c *RetentionConfig) Monthly() bool {
+	return c == nil || c.RollupMonthly == nil || *c.RollupMonthly
+}
+
+// EscalationConfig sets per-severity SLAs for escalations. The daemon
+// re-escalates an escalation that isn't acknowledged or resolved in time,
+// nudging the mayor and re-sending it through outbound notification
+// channels, then again every renotify interval until it is.
+type EscalationConfig struct {
+	// SLA maps a severity (CRITICAL, HIGH, MEDIUM) to its timers.
+	// Severities that aren't listed use the defaults.
+	SLA map[string]*EscalationSLA `json:"sla,omitempty"`
+
+	// MaxRenotify caps the re-escalations for each missed SLA.
+	// Default: 0 (no cap)
+	MaxRenotify int `json:"max_renotify,omitempty"`
+}
+
+// EscalationSLA is the timers for one severity. Both are measured from
+// when the escalation was raised; "0" disables a timer.
+type EscalationSLA struct {
+	Ack      string `json:"ack,omitempty"`      // time to acknowledge, e.g. "15m"
+	Resolve  string `json:"resolve,omitempty"`  // time to resolve, e.g. "4h"
+	Renotify string `json:"renotify,omitempty"` // between re-escalations; default: the breached timer
+}
+
+// DefaultEscalationSLA holds the timers used when a severity has none.
+var DefaultEscalationSLA = map[string]EscalationSLA{
+	"CRITICAL": {Ack: "15m", Resolve: "4h"},
+	"HIGH":     {Ack: "1h", Resolve: "24h"},
+	"MEDIUM":   {Ack: "4h", Resolve: "72h"},
+}
+
+// Timers returns the ack and resolve SLAs and the renotify interval for a
+// severity, with defaults applied. A zero ack or resolve means no SLA; a
+// zero renotify means each breach re-escalates at the breached timer's
+// interval. Safe on a nil config.
+func (c *EscalationConfig) Timers(severity string) (ack, resolve, renotify time.Duration) {
+	severity = strings.ToUpper(severity)
+	def := DefaultEscalationSLA[severity]
+	var sla *EscalationSLA
+	if c != nil {
+		sla = c.SLA[severity]
+	}
+	// An unparseable timer falls back to the default rather than
+	// silently disabling the SLA.
+	pick := func(set func(*EscalationSLA) string, fallback string) time.Duration {
+		if sla != nil && set(sla) != "" {
+			if d, err := time.ParseDuration(set(sla)); err == nil && d >= 0 {
+				return d
+			}
+		}
+		d, _ := time.ParseDuration(fallback)
+		return d
+	}
+	ack = pick(func(s *EscalationSLA) string { return s.Ack }, def.Ack)
+	resolve = pick(func(s *EscalationSLA) string { return s.Resolve }, def.Resolve)
+	renotify = pick(func(s *EscalationSLA) string { return s.Renotify }, def.Renotify)
+	return ack, resolve, renotify
+}
+
+// DogJobsConfig configures the recurring jobs the daemon queues for the
+// Deacon's dogs. Each job is queued when its schedule comes due and runs
+// on an idle dog; failed runs are retried with exponential backoff.
+type DogJobsConfig struct {
+	// Jobs adds jobs or overrides the defaults, keyed by job name. Fields
+	// set on a default job replace its defaults; "disabled": true turns
+	// it off.
+	Jobs map[string]*DogJobSpec `json:"jobs,omitempty"`
+
+	// MaxConcurrent caps the jobs running at once across the kennel.
+	// Default: 0 (one per idle dog)
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+}
+
+// DogJobSpec is one recurring dog job.
+type DogJobSpec struct {
+	// Type is the job type: branch-cleanup, worktree-prune, beads-sync,
+	// dep-update or command. Default: the job name
+	Type string `json:"type,omitempty"`
+
+	// Schedule is a cron expression ("0 4 * * *"), @hourly, @daily,
+	// @weekly, @monthly or "@every <duration>".
+	Schedule string `json:"schedule,omitempty"`
+
+	// Rigs limits the job to these rigs. Default: all rigs
+	Rigs []string `json:"rigs,omitempty"`
+
+	// Command is the shell command of a command job, or replaces the
+	// update check of a dep-update job.
+	Command string `json:"command,omitempty"`
+
+	Timeout     string `json:"timeout,omitempty"`     // per run, e.g. "10m"; default 30m
+	Concurrency int    `json:"concurrency,omitempty"` // runs at once; default 1
+	Retries     *int   `json:"retries,omitempty"`     // retries after a failed run; default 2
+	Backoff     string `json:"backoff,omitempty"`     // first retry delay, doubled each retry; default 5m
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+// DefaultDogJobs holds the jobs every town runs unless disabled.
+var DefaultDogJobs = map[string]DogJobSpec{
+	"branch-cleanup": {Schedule: "0 4 * * *", Timeout: "10m"},
+	"worktree-prune": {Schedule: "30 4 * * *", Timeout: "10m"},
+	"beads-sync":     {Schedule: "@every 1h", Timeout: "5m"},
+	"dep-update":     {Schedule: "0 6 * * 1"},
+}
+
+// Specs returns every configured job, defaults merged with overrides,
+// including disabled ones. Safe on a nil config.
+func (c *DogJobsConfig) Specs() map[string]DogJobSpec {
+	specs := make(map[string]DogJobSpec, len(DefaultDogJobs))
+	for name, spec := range DefaultDogJobs {
+		specs[name] = spec
+	}
+	if c == nil {
+		return specs
+	}
+	for name, override := range c.Jobs {
+		if override == nil {
+			continue
+		}
+		spec := specs[name]
+		if override.Type != "" {
+			spec.Type = override.Type
+		}
+		if override.Schedule != "" {
+			spec.Schedule = override.Schedule
+		}
+		if override.Rigs != nil {
+			spec.Rigs = override.Rigs
+		}
+		if override.Command != "" {
+			spec.Command = override.Command
+		}
+		if override.Timeout != "" {
+			spec.Timeout = override.Timeout
+		}
+		if override.Concurrency != 0 {
+			spec.Concurrency = override.Concurrency
+		}
+		if override.Retries != nil {
+			spec.Retries = override.Retries
+		}
+		if override.Backoff != "" {
+			spec.Backoff = override.Backoff
+		}
+		spec.Disabled = override.Disabled
+		specs[name] = spec
+	}
+	return specs
 }
 
 // NewTownSettings creates a new TownSettings with defaults.
@@ -65,6 +278,16 @@ func NewTownSettings() *TownSettings {
 type DaemonConfig struct {
 	HeartbeatInterval string `json:"heartbeat_interval,omitempty"` // e.g., "30s"
 	PollInterval      string `json:"poll_interval,omitempty"`      // e.g., "10s"
+
+	// APIListen is a loopback address (e.g. "127.0.0.1:7070") on which to
+	// serve the control API in addition to daemon/api.sock. TCP requests
+	// need the token in daemon/api.token.
+	APIListen string `json:"api_listen,omitempty"`
+
+	// CheckpointInterval is how often the daemon checkpoints polecats with
+	// hooked work (e.g. "10m", the default). "0" disables scheduled
+	// checkpoints; pane-died checkpoints still happen.
+	CheckpointInterval string `json:"checkpoint_interval,omitempty"`
 }
 
 // DeaconConfig represents deacon process settings.
@@ -72,6 +295,95 @@ type DeaconConfig struct {
 	PatrolInterval string `json:"patrol_interval,omitempty"` // e.g., "5m"
 }
 
+// NotifyConfig routes town events and mail to channels outside Gas Town,
+// so humans away from the terminal hear about escalations and failures.
+// Delivery is done by the daemon with retry and backoff.
+type NotifyConfig struct {
+	// Channels are named delivery targets.
+	Channels map[string]*NotifyChannel `json:"channels,omitempty"`
+
+	// Routes select what is sent to which channels.
+	Routes []NotifyRoute `json:"routes,omitempty"`
+
+	// Retry controls redelivery of failed notifications.
+	Retry *NotifyRetry `json:"retry,omitempty"`
+}
+
+// Notify channel types.
+const (
+	NotifyWebhook = "webhook" // generic HTTP POST
+	NotifySlack   = "slack"   // Slack-compatible incoming webhook
+	NotifySMTP    = "smtp"    // email
+)
+
+// NotifyChannel is a delivery target. URL, header values and the SMTP
+// password may reference environment variables as ${VAR}.
+type NotifyChannel struct {
+	// Type is "webhook", "slack" or "smtp".
+	Type string `json:"type"`
+
+	// URL is the webhook or Slack incoming-webhook URL.
+	URL string `json:"url,omitempty"`
+
+	// Headers are extra HTTP headers for webhooks.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Template is a Go text/template rendered with the notification.
+	// Defaults: JSON of the notification (webhook), "*title*\nbody" (slack),
+	// the notification body (smtp).
+	Template string `json:"template,omitempty"`
+
+	// Subject is the email subject template (smtp only).
+	// Default: "[gt] {{.Title}}"
+	Subject string `json:"subject,omitempty"`
+
+	// SMTP settings (smtp only).
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+}
+
+// SMTPConfig configures an SMTP channel.
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port,omitempty"` // default 587
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"` // usually "${SMTP_PASSWORD}"
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// NotifyRoute sends matching events and mail to channels.
+type NotifyRoute struct {
+	// Events are event types to forward (e.g. "escalation_sent",
+	// "merge_failed", "convoy_stranded", "polecat_crashed"). "*" matches all.
+	Events []string `json:"events,omitempty"`
+
+	// MailPriority forwards mail of these priorities ("urgent", "high").
+	MailPriority []string `json:"mail_priority,omitempty"`
+
+	// Rigs limits the route to events and mail about these rigs.
+	Rigs []string `json:"rigs,omitempty"`
+
+	// Channels are the channel names to deliver to.
+	Channels []string `json:"channels"`
+
+	// Throttle suppresses repeats of the same notification within this
+	// window (e.g. "1h"), for conditions that are reported every patrol.
+	Throttle string `json:"throttle,omitempty"`
+}
+
+// NotifyRetry controls redelivery of failed notifications.
+type NotifyRetry struct {
+	// MaxAttempts is the total number of delivery attempts. Default: 5
+	MaxAttempts int `json:"max_attempts,omitempty"`
+
+	// Backoff is the delay before the first retry, doubled after each
+	// failure. Default: "30s"
+	Backoff string `json:"backoff,omitempty"`
+
+	// MaxBackoff caps the retry delay. Default: "15m"
+	MaxBackoff string `json:"max_backoff,omitempty"`
+}
+
 // CurrentMayorConfigVersion is the current schema version for MayorConfig.
 const CurrentMayorConfigVersion = 1
 
@@ -90,6 +402,7 @@ type RigEntry struct {
 	LocalRepo   string       `json:"local_repo,omitempty"`
 	AddedAt     time.Time    `json:"added_at"`
 	BeadsConfig *BeadsConfig `json:"beads,omitempty"`
+	Machine     string       `json:"machine,omitempty"` // machine hosting the rig's agents (mayor/machines.json)
 }
 
 // BeadsConfig represents beads configuration for a rig.
@@ -131,6 +444,9 @@ type RigSettings struct {
 	Namepool   *NamepoolConfig   `json:"namepool,omitempty"`    // polecat name pool settings
 	Crew       *CrewConfig       `json:"crew,omitempty"`        // crew startup settings
 	Runtime    *RuntimeConfig    `json:"runtime,omitempty"`     // LLM runtime settings (deprecated: use Agent)
+	Budget     *BudgetConfig     `json:"budget,omitempty"`      // cost caps enforced by the daemon
+	Sandbox    *SandboxConfig    `json:"sandbox,omitempty"`     // polecat isolation and resource limits
+	Autoscale  *AutoscaleConfig  `json:"autoscale,omitempty"`   // daemon-driven polecat spawning and reaping
 
 	// Agent selects which agent preset to use for this rig.
 	// Can be a built-in preset ("claude", "gemini", "codex")
@@ -140,6 +456,173 @@ type RigSettings struct {
 	Agent string `json:"agent,omitempty"`
 }
 
+// BudgetConfig represents cost caps for a rig. The daemon compares spend
+// from the session ledger and live tmux panes against these caps on each
+// heartbeat, mails the mayor as they are approached, and applies OnExceed
+// to polecats once a cap is reached. Convoy caps live on the convoy itself
+// (gt convoy create --budget) and use the OnExceed of each polecat's rig.
+type BudgetConfig struct {
+	// DailyUSD caps the rig's total spend per calendar day. 0 means no cap.
+	DailyUSD float64 `json:"daily_usd,omitempty"`
+
+	// PolecatSessionUSD caps a single polecat session. 0 means no cap.
+	PolecatSessionUSD float64 `json:"polecat_session_usd,omitempty"`
+
+	// WarnAt is the fraction of a cap at which the mayor is warned.
+	// Default: 0.8
+	WarnAt float64 `json:"warn_at,omitempty"`
+
+	// OnExceed is what happens to polecats over a cap: "warn" (mail only),
+	// "park" (interrupt and tell the polecat to wait), or "stop" (kill the
+	// session and don't restart it until the cap is lifted).
+	// Default: "park"
+	OnExceed string `json:"on_exceed,omitempty"`
+}
+
+// Budget OnExceed actions.
+const (
+	BudgetActionWarn = "warn"
+	BudgetActionPark = "park"
+	BudgetActionStop = "stop"
+)
+
+// Action returns the configured OnExceed action, defaulting to park.
+func (c *BudgetConfig) Action() string {
+	if c == nil || c.OnExceed == "" {
+		return BudgetActionPark
+	}
+	return c.OnExceed
+}
+
+// AutoscaleConfig lets the daemon size a rig's polecat pool to its ready
+// work. On each heartbeat it slings unassigned ready issues (convoy work
+// first) to new polecats, up to MaxPolecats, and nukes polecats that have
+// had nothing on their hook for IdleCooldown. Spawning pauses while the
+// rig's daily budget is at its warning threshold or the machine is loaded.
+type AutoscaleConfig struct {
+	// Enabled turns the autoscaler on for this rig.
+	Enabled bool `json:"enabled"`
+
+	// MaxPolecats caps the rig's polecats, idle ones included.
+	// Default (0): the name pool size. Never more than the name pool.
+	MaxPolecats int `json:"max_polecats,omitempty"`
+
+	// MaxSpawnPerCycle caps how many polecats one heartbeat may spawn.
+	// Default: 2
+	MaxSpawnPerCycle int `json:"max_spawn_per_cycle,omitempty"`
+
+	// IdleCooldown is how long a polecat must be idle before it is reaped.
+	// Default: "15m"
+	IdleCooldown string `json:"idle_cooldown,omitempty"`
+
+	// MaxLoadPerCPU pauses spawning while the 1-minute load average divided
+	// by the CPU count is above it. Default: 1.0
+	MaxLoadPerCPU float64 `json:"max_load_per_cpu,omitempty"`
+
+	// Types lists the issue types the autoscaler dispatches.
+	// Default: task, bug, feature, chore
+	Types []string `json:"types,omitempty"`
+}
+
+// Autoscale defaults.
+const (
+	DefaultAutoscaleSpawnPerCycle = 2
+	DefaultAutoscaleIdleCooldown  = 15 * time.Minute
+	DefaultAutoscaleLoadPerCPU    = 1.0
+)
+
+// DefaultAutoscaleTypes are the issue types dispatched when Types is unset.
+var DefaultAutoscaleTypes = []string{"task", "bug", "feature", "chore"}
+
+// SpawnPerCycle returns MaxSpawnPerCycle, or the default.
+func (c *AutoscaleConfig) SpawnPerCycle() int {
+	if c.MaxSpawnPerCycle <= 0 {
+		return DefaultAutoscaleSpawnPerCycle
+	}
+	return c.MaxSpawnPerCycle
+}
+
+// Cooldown returns IdleCooldown, or the default if unset or invalid.
+func (c *AutoscaleConfig) Cooldown() time.Duration {
+	d, err := time.ParseDuration(c.IdleCooldown)
+	if err != nil || d <= 0 {
+		return DefaultAutoscaleIdleCooldown
+	}
+	return d
+}
+
+// LoadPerCPU returns MaxLoadPerCPU, or the default.
+func (c *AutoscaleConfig) LoadPerCPU() float64 {
+	if c.MaxLoadPerCPU <= 0 {
+		return DefaultAutoscaleLoadPerCPU
+	}
+	return c.MaxLoadPerCPU
+}
+
+// Dispatchable reports whether issues of type t may be slung by the autoscaler.
+func (c *AutoscaleConfig) Dispatchable(t string) bool {
+	types := c.Types
+	if len(types) == 0 {
+		types = DefaultAutoscaleTypes
+	}
+	return slices.Contains(types, t)
+}
+
+// SandboxConfig isolates a rig's polecats. Each polecat's agent runs with
+// only its worktree, the rig's git and beads data, the agent's own state
+// (e.g. ~/.claude) and the declared paths visible; everything else on the
+// host is hidden. Limits are applied with cgroups (systemd-run for the
+// namespace modes, the engine's own flags for containers).
+type SandboxConfig struct {
+	// Mode selects the isolation: "bwrap" (bubblewrap), "unshare" (Linux
+	// namespaces via util-linux unshare), "podman" or "docker" (rootless
+	// containers, see Image). Empty means no sandbox.
+	Mode string `json:"mode,omitempty"`
+
+	// ReadOnlyPaths are extra host paths visible read-only, e.g. toolchains
+	// outside the system directories ("~/go", "/opt/node"). "~/" is the
+	// user's home.
+	ReadOnlyPaths []string `json:"read_only_paths,omitempty"`
+
+	// ReadWritePaths are extra host paths the polecat can write, e.g.
+	// shared build caches ("~/.cache/go-build").
+	ReadWritePaths []string `json:"read_write_paths,omitempty"`
+
+	// Network is "host" (default) or "none". "none" also cuts the agent off
+	// from its model API, so it suits local models or proxied runtimes.
+	Network string `json:"network,omitempty"`
+
+	// CPUs caps CPU time in cores (e.g. 2, or 0.5). 0 means no cap.
+	CPUs float64 `json:"cpus,omitempty"`
+
+	// Memory caps memory, in bytes with an optional K/M/G/T suffix
+	// (e.g. "4G"). Empty means no cap.
+	Memory string `json:"memory,omitempty"`
+
+	// Image is the container image for the podman and docker modes. It
+	// must provide the agent CLI, gt, bd and git.
+	Image string `json:"image,omitempty"`
+}
+
+// Sandbox modes.
+const (
+	SandboxBwrap   = "bwrap"
+	SandboxUnshare = "unshare"
+	SandboxPodman  = "podman"
+	SandboxDocker  = "docker"
+)
+
+// Sandbox network settings.
+const (
+	SandboxNetworkHost = "host"
+	SandboxNetworkNone = "none"
+)
+
+// Enabled reports whether polecats run sandboxed.
+func (c *SandboxConfig) Enabled() bool {
+	return c != nil && c.Mode != ""
+}
+
 // CrewConfig represents crew workspace settings for a rig.
 type CrewConfig struct {
 	// Startup is a natural language instruction for which crew to start on boot.
@@ -240,10 +723,41 @@ type RuntimeRegistryConfig struct {
 }
 
 // RuntimeAdapterConfig represents a configured runtime adapter.
+// Entries named after a built-in adapter (codex, gemini, aider) override
+// its defaults; any other name declares a new generic runtime.
 type RuntimeAdapterConfig struct {
-	Bin       string `json:"bin,omitempty"`
-	Delivery  string `json:"delivery,omitempty"`
-	Readiness string `json:"readiness,omitempty"`
+	Bin       string   `json:"bin,omitempty"`
+	Args      []string `json:"args,omitempty"`
+	Delivery  string   `json:"delivery,omitempty"`  // "tmux" | "stdin"
+	Readiness string   `json:"readiness,omitempty"` // "prompt" | "warmup"
+
+	// ReadyPattern is a regexp matched against the last lines of the pane.
+	// A match means the agent is waiting for input (readiness "prompt").
+	// Trailing spaces are stripped from pane lines before matching.
+	ReadyPattern string `json:"ready_pattern,omitempty"`
+
+	// ReadyTimeout bounds the wait for ReadyPattern (e.g. "60s", the default).
+	ReadyTimeout string `json:"ready_timeout,omitempty"`
+
+	// Warmup is how long to wait after the agent starts when there is no
+	// prompt to detect (readiness "warmup", e.g. "5s").
+	Warmup string `json:"warmup,omitempty"`
+
+	// ProcessNames are the pane commands that mean the agent is running.
+	// Defaults to the base name of Bin.
+	ProcessNames []string `json:"process_names,omitempty"`
+
+	// ResumeCommand starts the agent on a previous session. "{session_id}"
+	// is replaced with the ID recorded in the workdir's .runtime/session_id.
+	ResumeCommand string `json:"resume_command,omitempty"`
+
+	// CostPattern is a regexp whose first group is the session's cost in
+	// USD. The last match in the pane wins.
+	CostPattern string `json:"cost_pattern,omitempty"`
+
+	// ExitCommand is typed to ask the agent to quit before its session is
+	// killed (e.g. "/exit").
+	ExitCommand string `json:"exit_command,omitempty"`
 }
 
 // ThemeConfig represents tmux theme settings for a rig.
@@ -297,6 +811,10 @@ type MergeQueueConfig struct {
 	// OnConflict specifies conflict resolution strategy: "assign_back" or "auto_rebase".
 	OnConflict string `json:"on_conflict"`
 
+	// Resolvers resolve conflicts in matching files during auto_rebase.
+	// Nil uses DefaultConflictResolvers.
+	Resolvers []ConflictResolver `json:"resolvers,omitempty"`
+
 	// RunTests controls whether to run tests before merging.
 	RunTests bool `json:"run_tests"`
 
@@ -313,7 +831,28 @@ type MergeQueueConfig struct {
 	PollInterval string `json:"poll_interval"`
 
 	// MaxConcurrent is the maximum number of concurrent merges.
+	// In merge train mode this is the maximum train length.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// MergeTrain enables speculative parallel merge trains.
+	MergeTrain bool `json:"merge_train,omitempty"`
+
+	// BatchSize merges up to this many ready MRs onto one temporary commit,
+	// tests it once and bisects on failure. 0 or 1 disables batching.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// TestFormat is the test output format to parse for failing tests:
+	// "auto" (default), "go-json", "junit", "tap", or "none".
+	TestFormat string `json:"test_format,omitempty"`
+
+	// TestReport is a report file, relative to the work dir, that the test
+	// command writes (e.g., "junit.xml"). Parsed instead of command output.
+	TestReport string `json:"test_report,omitempty"`
+
+	// QuarantineFlakyAfter quarantines a test once it has flaked (failed,
+	// then passed on retry) on this many distinct branches. Needs
+	// RetryFlakyTests of at least 2. 0 disables it.
+	QuarantineFlakyAfter int `json:"quarantine_flaky_after,omitempty"`
 }
 
 // OnConflict strategy constants.
@@ -322,6 +861,83 @@ const (
 	OnConflictAutoRebase = "auto_rebase"
 )
 
+// ConflictResolver resolves rebase conflicts in files matching Paths.
+type ConflictResolver struct {
+	// Paths are glob patterns matched against the repo-relative path, or
+	// against the base name for patterns without a slash (e.g., "go.sum",
+	// "*.lock", "internal/gen/*.go").
+	Paths []string `json:"paths"`
+
+	// Strategy is how conflicts are resolved: "union" keeps the lines of
+	// both sides, "go-sum" merges and sorts go.sum entries, "target" or
+	// "branch" keeps one side, and "command" keeps the target's version
+	// and then runs Command to regenerate the files.
+	Strategy string `json:"strategy"`
+
+	// Command regenerates the files for the "command" strategy (e.g.,
+	// "npm install --package-lock-only" or "go generate ./..."). It runs
+	// via sh -c at the root of the rebase worktree.
+	Command string `json:"command,omitempty"`
+}
+
+// Conflict resolver strategies.
+const (
+	ResolveUnion   = "union"
+	ResolveGoSum   = "go-sum"
+	ResolveTarget  = "target"
+	ResolveBranch  = "branch"
+	ResolveCommand = "command"
+)
+
+// DefaultConflictResolvers returns the resolvers used when none are
+// configured: changelogs are union-merged and go.sum entries combined.
+// Lockfiles and generated files need a command, so they are opt-in.
+func DefaultConflictResolvers() []ConflictResolver {
+	return []ConflictResolver{
+		{Paths: []string{"CHANGELOG.md", "CHANGES.md"}, Strategy: ResolveUnion},
+		{Paths: []string{"go.sum"}, Strategy: ResolveGoSum},
+	}
+}
+
+// Validate checks the resolver's strategy, command and patterns.
+func (r *ConflictResolver) Validate() error {
+	if len(r.Paths) == 0 {
+		return errors.New("resolver needs paths")
+	}
+	for _, p := range r.Paths {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid resolver path %q: %w", p, err)
+		}
+	}
+	switch r.Strategy {
+	case ResolveUnion, ResolveGoSum, ResolveTarget, ResolveBranch:
+		if r.Command != "" {
+			return fmt.Errorf("resolver strategy %s takes no command", r.Strategy)
+		}
+	case ResolveCommand:
+		if r.Command == "" {
+			return errors.New("resolver strategy command needs a command")
+		}
+	default:
+		return fmt.Errorf("invalid resolver strategy '%s': want union, go-sum, target, branch or command", r.Strategy)
+	}
+	return nil
+}
+
+// Matches reports whether the repo-relative path file matches the resolver.
+func (r *ConflictResolver) Matches(file string) bool {
+	for _, p := range r.Paths {
+		name := file
+		if !strings.Contains(p, "/") {
+			name = path.Base(file)
+		}
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // DefaultMergeQueueConfig returns a MergeQueueConfig with sensible defaults.
 func DefaultMergeQueueConfig() *MergeQueueConfig {
 	return &MergeQueueConfig{
@@ -28,6 +28,7 @@ type registryData struct {
 type MachineRegistry struct {
 	path     string
 	machines map[string]*Machine
+	conns    map[string]*SSHConnection // pooled ssh connections by machine name
 	mu       sync.RWMutex
 }
 
@@ -37,6 +38,7 @@ func NewMachineRegistry(configPath string) (*MachineRegistry, error) {
 	r := &MachineRegistry{
 		path:     configPath,
 		machines: make(map[string]*Machine),
+		conns:    make(map[string]*SSHConnection),
 	}
 
 	// Load existing config if present
@@ -132,6 +134,9 @@ func (r *MachineRegistry) Add(m *Machine) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// Drop any pooled connection to the old definition
+	r.closeConnLocked(m.Name)
+
 	r.machines[m.Name] = m
 	return r.save()
 }
@@ -149,6 +154,7 @@ func (r *MachineRegistry) Remove(name string) error {
 		return fmt.Errorf("machine not found: %s", name)
 	}
 
+	r.closeConnLocked(name)
 	delete(r.machines, name)
 	return r.save()
 }
@@ -166,6 +172,8 @@ func (r *MachineRegistry) List() []*Machine {
 }
 
 // Connection returns a Connection for the named machine.
+// SSH connections are pooled: repeated calls for the same machine return the
+// same connection until it is removed or the registry is closed.
 func (r *MachineRegistry) Connection(name string) (Connection, error) {
 	m, err := r.Get(name)
 	if err != nil {
@@ -176,13 +184,53 @@ func (r *MachineRegistry) Connection(name string) (Connection, error) {
 	case "local":
 		return NewLocalConnection(), nil
 	case "ssh":
-		// SSH connection not yet implemented
-		return nil, fmt.Errorf("ssh connections not yet implemented")
+		return r.SSHConnection(name)
 	default:
 		return nil, fmt.Errorf("unknown machine type: %s", m.Type)
 	}
 }
 
+// SSHConnection returns the pooled SSH connection for the named machine.
+func (r *MachineRegistry) SSHConnection(name string) (*SSHConnection, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.machines[name]
+	if !ok {
+		return nil, fmt.Errorf("machine not found: %s", name)
+	}
+	if m.Type != "ssh" {
+		return nil, fmt.Errorf("machine %s is not an ssh machine", name)
+	}
+
+	if c, ok := r.conns[name]; ok {
+		return c, nil
+	}
+	c := NewSSHConnection(m)
+	r.conns[name] = c
+	return c, nil
+}
+
+// Close closes all pooled connections.
+func (r *MachineRegistry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name := range r.conns {
+		r.closeConnLocked(name)
+	}
+	return nil
+}
+
+// closeConnLocked closes and forgets the pooled connection for name.
+// Caller must hold r.mu.
+func (r *MachineRegistry) closeConnLocked(name string) {
+	if c, ok := r.conns[name]; ok {
+		_ = c.Close()
+		delete(r.conns, name)
+	}
+}
+
 // LocalConnection returns the local connection.
 // This is a convenience method for the common case.
 func (r *MachineRegistry) LocalConnection() *LocalConnection {
@@ -0,0 +1,529 @@
+package connection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// sshConnectionFailure is the exit status ssh uses for its own failures
+// (connection refused, auth failure, dropped master). A remote command can
+// exit 255 too, so the status alone doesn't mean the connection failed.
+const sshConnectionFailure = 255
+
+// sshTransportErrors are fragments of the messages ssh itself prints when it
+// can't reach the host or loses the connection. An exit 255 is only taken as
+// a connection failure when stderr carries one of them.
+var sshTransportErrors = []string{
+	"ssh: ",
+	"Connection closed by",
+	"Connection reset by",
+	"Connection timed out",
+	"Connection refused",
+	"closed by remote host",
+	"Permission denied (",
+	"Host key verification failed",
+	"kex_exchange_identification",
+	"mux_client_",
+	"Control socket",
+	"Broken pipe",
+}
+
+// Default reconnect behaviour for SSH connections.
+const (
+	DefaultSSHRetries    = 2
+	DefaultSSHRetryDelay = 500 * time.Millisecond
+	DefaultSSHPersist    = 10 * time.Minute
+)
+
+// SSHConnection implements Connection for a remote machine by shelling out
+// to the ssh binary, the same way the rest of Gas Town drives git and tmux.
+//
+// Connections are pooled with OpenSSH connection multiplexing: the first
+// command opens a control master that later commands reuse, so each
+// operation costs a round trip rather than a full handshake. If the master
+// dies (network blip, remote reboot), the stale socket is torn down and the
+// command is retried.
+type SSHConnection struct {
+	machine *Machine
+
+	// SSHPath is the ssh binary to invoke. Defaults to "ssh"; tests point it
+	// at a local stand-in.
+	SSHPath string
+
+	// ControlDir holds the multiplexing sockets. Empty disables pooling.
+	ControlDir string
+
+	// Retries is the number of reconnect attempts after a connection failure.
+	Retries int
+
+	// RetryDelay is the base delay between reconnect attempts; it grows
+	// linearly with each attempt.
+	RetryDelay time.Duration
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSSHConnection creates a connection to an ssh machine.
+func NewSSHConnection(m *Machine) *SSHConnection {
+	return &SSHConnection{
+		machine:    m,
+		SSHPath:    "ssh",
+		ControlDir: filepath.Join(os.TempDir(), fmt.Sprintf("gt-ssh-%d", os.Getuid())),
+		Retries:    DefaultSSHRetries,
+		RetryDelay: DefaultSSHRetryDelay,
+	}
+}
+
+// Name returns the machine name.
+func (c *SSHConnection) Name() string {
+	return c.machine.Name
+}
+
+// IsLocal returns false for SSH connections.
+func (c *SSHConnection) IsLocal() bool {
+	return false
+}
+
+// Machine returns the machine this connection targets.
+func (c *SSHConnection) Machine() *Machine {
+	return c.machine
+}
+
+// sshArgs returns the ssh options shared by every invocation.
+func (c *SSHConnection) sshArgs() []string {
+	args := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+	}
+	if c.ControlDir != "" {
+		args = append(args,
+			"-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(c.ControlDir, "%C"),
+			"-o", fmt.Sprintf("ControlPersist=%d", int(DefaultSSHPersist.Seconds())),
+		)
+	}
+	if c.machine.KeyPath != "" {
+		args = append(args, "-i", c.machine.KeyPath)
+	}
+	return args
+}
+
+// run executes a shell command line on the remote machine and returns its
+// stdout and stderr. Connection failures are retried with a fresh master;
+// a command that ran and failed, whatever its status, is never re-run.
+func (c *SSHConnection) run(remoteCmd string, stdin []byte) ([]byte, []byte, error) {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, nil, &ConnectionError{Op: "exec", Machine: c.machine.Name, Err: errors.New("connection closed")}
+	}
+
+	if c.ControlDir != "" {
+		if err := os.MkdirAll(c.ControlDir, 0700); err != nil {
+			return nil, nil, &ConnectionError{Op: "connect", Machine: c.machine.Name, Err: err}
+		}
+	}
+
+	args := append(c.sshArgs(), "--", c.machine.Host, remoteCmd)
+	for attempt := 0; ; attempt++ {
+		cmd := exec.Command(c.SSHPath, args...) //nolint:gosec // G204: host and command come from the machine registry
+		if stdin != nil {
+			cmd.Stdin = bytes.NewReader(stdin)
+		}
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err := cmd.Run()
+		if !isSSHFailure(err, stderr.Bytes()) {
+			return stdout.Bytes(), stderr.Bytes(), err
+		}
+		if attempt >= c.Retries {
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" {
+				msg = err.Error()
+			}
+			return stdout.Bytes(), stderr.Bytes(), &ConnectionError{Op: "exec", Machine: c.machine.Name, Err: errors.New(msg)}
+		}
+
+		// Tear down a possibly-stale master before reconnecting
+		c.exitMaster()
+		time.Sleep(c.RetryDelay * time.Duration(attempt+1))
+	}
+}
+
+// isSSHFailure reports whether err is ssh's own connection failure: exit
+// status 255 with one of ssh's transport errors on stderr.
+func isSSHFailure(err error, stderr []byte) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != sshConnectionFailure {
+		return false
+	}
+	msg := string(stderr)
+	for _, s := range sshTransportErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// exitMaster asks the control master to exit. Best-effort.
+func (c *SSHConnection) exitMaster() {
+	if c.ControlDir == "" {
+		return
+	}
+	args := append(c.sshArgs(), "-O", "exit", "--", c.machine.Host)
+	_ = exec.Command(c.SSHPath, args...).Run() //nolint:gosec // G204: see run
+}
+
+// Close shuts down the pooled master connection. Further operations fail.
+func (c *SSHConnection) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.exitMaster()
+	return nil
+}
+
+// shellJoin quotes and joins words into a command line.
+func shellJoin(words ...string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = util.ShellQuote(w)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// script builds a command line that runs body with args as $1, $2, ...
+func script(body string, args ...string) string {
+	return shellJoin(append([]string{"sh", "-c", body, "sh"}, args...)...)
+}
+
+// fileError maps a failed remote file operation to the connection error types.
+func (c *SSHConnection) fileError(op, p string, stderr []byte, err error) error {
+	var connErr *ConnectionError
+	if errors.As(err, &connErr) {
+		return err
+	}
+	msg := string(stderr)
+	switch {
+	case strings.Contains(msg, "No such file or directory"):
+		return &NotFoundError{Path: p}
+	case strings.Contains(msg, "Permission denied"):
+		return &PermissionError{Path: p, Op: op}
+	}
+	if msg = strings.TrimSpace(msg); msg != "" {
+		return fmt.Errorf("%s %s on %s: %s", op, p, c.machine.Name, msg)
+	}
+	return fmt.Errorf("%s %s on %s: %w", op, p, c.machine.Name, err)
+}
+
+// ReadFile reads the named file.
+func (c *SSHConnection) ReadFile(p string) ([]byte, error) {
+	stdout, stderr, err := c.run(shellJoin("cat", "--", p), nil)
+	if err != nil {
+		return nil, c.fileError("read", p, stderr, err)
+	}
+	return stdout, nil
+}
+
+// WriteFile writes data to the named file.
+func (c *SSHConnection) WriteFile(p string, data []byte, perm fs.FileMode) error {
+	body := `cat > "$1" && chmod "$2" "$1"`
+	_, stderr, err := c.run(script(body, p, fmt.Sprintf("%o", perm.Perm())), data)
+	if err != nil {
+		return c.fileError("write", p, stderr, err)
+	}
+	return nil
+}
+
+// MkdirAll creates a directory and all parent directories.
+func (c *SSHConnection) MkdirAll(p string, perm fs.FileMode) error {
+	_, stderr, err := c.run(shellJoin("mkdir", "-p", "-m", fmt.Sprintf("%o", perm.Perm()), "--", p), nil)
+	if err != nil {
+		return c.fileError("mkdir", p, stderr, err)
+	}
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (c *SSHConnection) Remove(p string) error {
+	body := `if [ -d "$1" ] && [ ! -L "$1" ]; then rmdir -- "$1"; else rm -f -- "$1"; fi`
+	_, stderr, err := c.run(script(body, p), nil)
+	if err != nil {
+		return c.fileError("remove", p, stderr, err)
+	}
+	return nil
+}
+
+// RemoveAll removes the named file or directory and any children.
+func (c *SSHConnection) RemoveAll(p string) error {
+	_, stderr, err := c.run(shellJoin("rm", "-rf", "--", p), nil)
+	if err != nil {
+		return c.fileError("remove", p, stderr, err)
+	}
+	return nil
+}
+
+// statScript prints "size perm mtime isdir", trying GNU then BSD stat.
+const statScript = `[ -e "$1" ] || { echo "$1: No such file or directory" >&2; exit 1; }
+if [ -d "$1" ]; then d=1; else d=0; fi
+s=$(stat -L -c '%s %a %Y' -- "$1" 2>/dev/null || stat -L -f '%z %Lp %m' -- "$1") || exit 1
+echo "$s $d"`
+
+// Stat returns file info for the named file.
+func (c *SSHConnection) Stat(p string) (FileInfo, error) {
+	stdout, stderr, err := c.run(script(statScript, p), nil)
+	if err != nil {
+		return nil, c.fileError("stat", p, stderr, err)
+	}
+
+	fields := strings.Fields(string(stdout))
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("stat %s on %s: unexpected output %q", p, c.machine.Name, stdout)
+	}
+	size, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: parsing size: %w", p, err)
+	}
+	perm, err := strconv.ParseUint(fields[1], 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: parsing mode: %w", p, err)
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("stat %s: parsing mtime: %w", p, err)
+	}
+
+	isDir := fields[3] == "1"
+	mode := fs.FileMode(perm) & fs.ModePerm
+	if isDir {
+		mode |= fs.ModeDir
+	}
+	return BasicFileInfo{
+		FileName:    path.Base(p),
+		FileSize:    size,
+		FileMode:    mode,
+		FileModTime: time.Unix(mtime, 0),
+		FileIsDir:   isDir,
+	}, nil
+}
+
+// globScript expands $1 unquoted with word splitting limited to newlines,
+// printing only matches that exist (an unmatched glob expands to itself).
+const globScript = `IFS='
+'
+for f in $1; do [ -e "$f" ] && printf '%s\n' "$f"; done
+exit 0`
+
+// Glob returns the names of all files matching the pattern.
+func (c *SSHConnection) Glob(pattern string) ([]string, error) {
+	// Reject malformed patterns the same way filepath.Glob does
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	stdout, _, err := c.run(script(globScript, pattern), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		if line != "" {
+			matches = append(matches, line)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Exists returns true if the path exists.
+func (c *SSHConnection) Exists(p string) (bool, error) {
+	_, stderr, err := c.run(shellJoin("test", "-e", p), nil)
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, c.fileError("stat", p, stderr, err)
+}
+
+// execLine runs a command line with stderr folded into stdout, matching
+// exec.Cmd.CombinedOutput for local connections.
+func (c *SSHConnection) execLine(line string) ([]byte, error) {
+	stdout, _, err := c.run("exec 2>&1; "+line, nil)
+	return stdout, err
+}
+
+// Exec runs a command and returns its combined output.
+func (c *SSHConnection) Exec(cmd string, args ...string) ([]byte, error) {
+	return c.execLine(shellJoin(append([]string{cmd}, args...)...))
+}
+
+// ExecDir runs a command in the specified directory.
+func (c *SSHConnection) ExecDir(dir, cmd string, args ...string) ([]byte, error) {
+	return c.execLine("cd " + util.ShellQuote(dir) + " && " + shellJoin(append([]string{cmd}, args...)...))
+}
+
+// ExecEnv runs a command with additional environment variables.
+func (c *SSHConnection) ExecEnv(env map[string]string, cmd string, args ...string) ([]byte, error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	words := []string{"env"}
+	for _, k := range keys {
+		words = append(words, k+"="+env[k])
+	}
+	words = append(words, cmd)
+	words = append(words, args...)
+	return c.execLine(shellJoin(words...))
+}
+
+// tmux runs a tmux command on the remote machine and returns trimmed stdout.
+func (c *SSHConnection) tmux(args ...string) (string, error) {
+	stdout, stderr, err := c.run(shellJoin(append([]string{"tmux"}, args...)...), nil)
+	if err != nil {
+		var connErr *ConnectionError
+		if errors.As(err, &connErr) {
+			return "", err
+		}
+		return "", fmt.Errorf("tmux %s on %s: %s", args[0], c.machine.Name, strings.TrimSpace(string(stderr)))
+	}
+	return strings.TrimSpace(string(stdout)), nil
+}
+
+// isNoTmuxServer reports whether err means no tmux server or session exists.
+func isNoTmuxServer(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "no server running") ||
+		strings.Contains(msg, "error connecting to") ||
+		strings.Contains(msg, "can't find session")
+}
+
+// TmuxNewSession creates a new tmux session.
+func (c *SSHConnection) TmuxNewSession(name, dir string) error {
+	args := []string{"new-session", "-d", "-s", name}
+	if dir != "" {
+		args = append(args, "-c", dir)
+	}
+	_, err := c.tmux(args...)
+	return err
+}
+
+// TmuxKillSession terminates a tmux session.
+func (c *SSHConnection) TmuxKillSession(name string) error {
+	_, err := c.tmux("kill-session", "-t", name)
+	return err
+}
+
+// TmuxSendKeys sends keys to a tmux session, followed by Enter.
+// Both sends happen in one round trip with the same debounce the local
+// tmux wrapper uses.
+func (c *SSHConnection) TmuxSendKeys(session, keys string) error {
+	line := shellJoin("tmux", "send-keys", "-t", session, "-l", keys) +
+		" && sleep 0.1 && " +
+		shellJoin("tmux", "send-keys", "-t", session, "Enter")
+	_, stderr, err := c.run(line, nil)
+	if err != nil {
+		var connErr *ConnectionError
+		if errors.As(err, &connErr) {
+			return err
+		}
+		return fmt.Errorf("tmux send-keys on %s: %s", c.machine.Name, strings.TrimSpace(string(stderr)))
+	}
+	return nil
+}
+
+// TmuxCapturePane captures the last N lines from a tmux pane.
+func (c *SSHConnection) TmuxCapturePane(session string, lines int) (string, error) {
+	return c.tmux("capture-pane", "-p", "-t", session, "-S", fmt.Sprintf("-%d", lines))
+}
+
+// TmuxHasSession returns true if the session exists.
+func (c *SSHConnection) TmuxHasSession(name string) (bool, error) {
+	_, err := c.tmux("has-session", "-t", "="+name)
+	if err != nil {
+		if isNoTmuxServer(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// TmuxListSessions returns all tmux session names.
+func (c *SSHConnection) TmuxListSessions() ([]string, error) {
+	out, err := c.tmux("list-sessions", "-F", "#{session_name}")
+	if err != nil {
+		if isNoTmuxServer(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// RemotePath maps a path under the local town root to the same relative
+// location under the machine's town root. Paths outside the town, or
+// machines without a town path, are returned unchanged.
+func (c *SSHConnection) RemotePath(townRoot, localPath string) string {
+	if c.machine.TownPath == "" {
+		return localPath
+	}
+	rel, err := filepath.Rel(townRoot, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return localPath
+	}
+	return path.Join(c.machine.TownPath, filepath.ToSlash(rel))
+}
+
+// WrapCommand returns a local shell command that runs command in dir on the
+// remote machine with a TTY. Agent sessions use this so the tmux session
+// stays local (and observable by the daemon and witness) while the agent
+// process itself runs on the remote box.
+func (c *SSHConnection) WrapCommand(dir, command string) string {
+	remote := command
+	if dir != "" {
+		remote = "cd " + util.ShellQuote(dir) + " && " + command
+	}
+	words := append([]string{c.SSHPath, "-t"}, c.sshArgs()...)
+	words = append(words, "--", c.machine.Host, remote)
+	return shellJoin(words...)
+}
+
+// Verify SSHConnection implements Connection.
+var _ Connection = (*SSHConnection)(nil)
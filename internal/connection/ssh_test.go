@@ -0,0 +1,267 @@
+package connection
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSH is a stand-in for the ssh binary: it skips ssh options and the
+// host, then runs the remote command line with the local shell. If
+// $FAKE_SSH_FAIL names a file, the first invocation removes it and exits
+// 255 to simulate a dropped connection.
+const fakeSSH = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+		-o|-i|-p|-O|-S) shift 2 ;;
+		--) shift; break ;;
+		-*) shift ;;
+		*) break ;;
+	esac
+done
+shift # host
+if [ -n "$FAKE_SSH_FAIL" ] && [ -f "$FAKE_SSH_FAIL" ]; then
+	rm -f "$FAKE_SSH_FAIL"
+	echo "ssh: connect to host fake port 22: Connection refused" >&2
+	exit 255
+fi
+[ $# -eq 0 ] && exit 0
+exec sh -c "$1"
+`
+
+// newFakeSSHConnection returns an SSHConnection wired to the fakeSSH stand-in.
+func newFakeSSHConnection(t *testing.T) *SSHConnection {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "ssh")
+	if err := os.WriteFile(bin, []byte(fakeSSH), 0755); err != nil {
+		t.Fatal(err)
+	}
+	c := NewSSHConnection(&Machine{Name: "vm", Type: "ssh", Host: "gt@fake", TownPath: "/srv/gt"})
+	c.SSHPath = bin
+	c.ControlDir = t.TempDir()
+	c.RetryDelay = time.Millisecond
+	return c
+}
+
+func TestSSHConnection_FileOps(t *testing.T) {
+	c := newFakeSSHConnection(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "sub dir", "it's.txt")
+
+	if err := c.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := c.WriteFile(file, []byte("hello\n$HOME `x`"), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	data, err := c.ReadFile(file)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "hello\n$HOME `x`" {
+		t.Errorf("ReadFile = %q", data)
+	}
+
+	fi, err := c.Stat(file)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if fi.Name() != "it's.txt" || fi.Size() != 15 || fi.IsDir() || fi.Mode().Perm() != 0600 {
+		t.Errorf("Stat = %+v", fi)
+	}
+	if fi, err := c.Stat(filepath.Dir(file)); err != nil || !fi.IsDir() || !fi.Mode().IsDir() {
+		t.Errorf("Stat dir = %+v, %v", fi, err)
+	}
+
+	if ok, err := c.Exists(file); err != nil || !ok {
+		t.Errorf("Exists(file) = %v, %v", ok, err)
+	}
+	if ok, err := c.Exists(filepath.Join(dir, "missing")); err != nil || ok {
+		t.Errorf("Exists(missing) = %v, %v", ok, err)
+	}
+
+	matches, err := c.Glob(filepath.Join(dir, "*", "*.txt"))
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	if len(matches) != 1 || matches[0] != file {
+		t.Errorf("Glob = %v, want [%s]", matches, file)
+	}
+	if matches, _ := c.Glob(filepath.Join(dir, "*.none")); len(matches) != 0 {
+		t.Errorf("Glob with no matches = %v", matches)
+	}
+
+	var nf *NotFoundError
+	if _, err := c.ReadFile(filepath.Join(dir, "missing")); !errors.As(err, &nf) {
+		t.Errorf("ReadFile(missing) error = %v, want NotFoundError", err)
+	}
+	if _, err := c.Stat(filepath.Join(dir, "missing")); !errors.As(err, &nf) {
+		t.Errorf("Stat(missing) error = %v, want NotFoundError", err)
+	}
+
+	if err := c.Remove(file); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := c.Remove(file); err != nil {
+		t.Errorf("Remove of missing file should succeed, got %v", err)
+	}
+	if err := c.RemoveAll(dir); err != nil {
+		t.Fatalf("RemoveAll: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", dir)
+	}
+}
+
+func TestSSHConnection_Exec(t *testing.T) {
+	c := newFakeSSHConnection(t)
+	dir := t.TempDir()
+
+	out, err := c.Exec("echo", "a b", "'c'")
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "a b 'c'" {
+		t.Errorf("Exec output = %q", out)
+	}
+
+	out, err = c.ExecDir(dir, "pwd")
+	if err != nil {
+		t.Fatalf("ExecDir: %v", err)
+	}
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(out))); got != mustEval(t, dir) {
+		t.Errorf("ExecDir pwd = %q, want %q", got, dir)
+	}
+
+	out, err = c.ExecEnv(map[string]string{"GT_TEST": "x y"}, "sh", "-c", "echo $GT_TEST; echo oops >&2")
+	if err != nil {
+		t.Fatalf("ExecEnv: %v", err)
+	}
+	if string(out) != "x y\noops\n" {
+		t.Errorf("ExecEnv output = %q, want stdout and stderr combined", out)
+	}
+
+	if _, err := c.Exec("false"); err == nil {
+		t.Error("expected error from failing command")
+	}
+}
+
+func mustEval(t *testing.T, p string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestSSHConnection_Reconnect(t *testing.T) {
+	c := newFakeSSHConnection(t)
+	marker := filepath.Join(t.TempDir(), "fail-once")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_SSH_FAIL", marker)
+
+	out, err := c.Exec("echo", "ok")
+	if err != nil {
+		t.Fatalf("expected retry to recover, got %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "ok" {
+		t.Errorf("Exec output = %q", out)
+	}
+}
+
+func TestSSHConnection_RemoteExit255NotRetried(t *testing.T) {
+	c := newFakeSSHConnection(t)
+	runs := filepath.Join(t.TempDir(), "runs")
+
+	_, err := c.Exec("sh", "-c", "echo run >> "+runs+"; exit 255")
+	var connErr *ConnectionError
+	if err == nil || errors.As(err, &connErr) {
+		t.Fatalf("expected the command's own failure, got %v", err)
+	}
+	if data, _ := os.ReadFile(runs); string(data) != "run\n" {
+		t.Errorf("command ran %d times, want once", strings.Count(string(data), "run"))
+	}
+}
+
+func TestSSHConnection_ConnectionFailure(t *testing.T) {
+	c := newFakeSSHConnection(t)
+	c.Retries = 0
+	marker := filepath.Join(t.TempDir(), "fail-once")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_SSH_FAIL", marker)
+
+	_, err := c.ReadFile("/etc/hostname")
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected ConnectionError, got %v", err)
+	}
+	if connErr.Machine != "vm" || !strings.Contains(connErr.Error(), "Connection refused") {
+		t.Errorf("unexpected error: %v", connErr)
+	}
+
+	_ = c.Close()
+	if _, err := c.Exec("true"); !errors.As(err, &connErr) {
+		t.Errorf("expected ConnectionError after Close, got %v", err)
+	}
+}
+
+func TestSSHConnection_RemotePathAndWrap(t *testing.T) {
+	c := newFakeSSHConnection(t)
+
+	if got := c.RemotePath("/home/me/gt", "/home/me/gt/gastown/polecats/nux"); got != "/srv/gt/gastown/polecats/nux" {
+		t.Errorf("RemotePath = %q", got)
+	}
+	if got := c.RemotePath("/home/me/gt", "/tmp/elsewhere"); got != "/tmp/elsewhere" {
+		t.Errorf("RemotePath outside town = %q", got)
+	}
+
+	// The wrapped command must be runnable by a local shell
+	dir := t.TempDir()
+	wrapped := c.WrapCommand(dir, "export GT_ROLE=polecat && echo $GT_ROLE && pwd")
+	out, err := NewLocalConnection().Exec("sh", "-c", wrapped)
+	if err != nil {
+		t.Fatalf("running wrapped command: %v\n%s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 || lines[0] != "polecat" || mustEval(t, lines[1]) != mustEval(t, dir) {
+		t.Errorf("wrapped command output = %q", out)
+	}
+}
+
+func TestMachineRegistry_PoolsSSHConnections(t *testing.T) {
+	r, err := NewMachineRegistry(filepath.Join(t.TempDir(), "machines.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(&Machine{Name: "vm", Type: "ssh", Host: "gt@vm"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c1, err := r.Connection("vm")
+	if err != nil {
+		t.Fatalf("Connection: %v", err)
+	}
+	c2, _ := r.Connection("vm")
+	if c1 != c2 {
+		t.Error("expected pooled connection to be reused")
+	}
+	if c1.IsLocal() || c1.Name() != "vm" {
+		t.Errorf("unexpected connection %s (local=%v)", c1.Name(), c1.IsLocal())
+	}
+
+	if err := r.Remove("vm"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Connection("vm"); err == nil {
+		t.Error("expected error for removed machine")
+	}
+}
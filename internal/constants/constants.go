@@ -71,6 +71,9 @@ const (
 
 	// FileAccountsJSON is the accounts configuration file in mayor/.
 	FileAccountsJSON = "accounts.json"
+
+	// FileMachinesJSON is the machine registry file in mayor/.
+	FileMachinesJSON = "machines.json"
 )
 
 // Git branch names.
@@ -215,6 +218,11 @@ func RigSettingsPath(rigPath string) string {
 	return rigPath + "/" + DirSettings
 }
 
+// MayorMachinesPath returns the path to mayor/machines.json within a town root.
+func MayorMachinesPath(townRoot string) string {
+	return townRoot + "/" + DirMayor + "/" + FileMachinesJSON
+}
+
 // MayorAccountsPath returns the path to mayor/accounts.json within a town root.
 func MayorAccountsPath(townRoot string) string {
 	return townRoot + "/" + DirMayor + "/" + FileAccountsJSON
@@ -0,0 +1,195 @@
+package costs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// Level is how far spending has progressed toward a cap.
+type Level int
+
+const (
+	// LevelOK is below the warning threshold (or no cap is set).
+	LevelOK Level = iota
+	// LevelWarn is at or above the warning threshold but below the cap.
+	LevelWarn
+	// LevelExceeded is at or above the cap.
+	LevelExceeded
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelWarn:
+		return "warn"
+	case LevelExceeded:
+		return "exceeded"
+	default:
+		return "ok"
+	}
+}
+
+// DefaultWarnAt is the fraction of a cap at which the mayor is warned
+// when a budget doesn't set its own threshold.
+const DefaultWarnAt = 0.8
+
+// Check compares spent against limit. A limit of zero or less means no cap.
+// warnAt is the warning threshold as a fraction of limit; zero uses DefaultWarnAt.
+func Check(spent, limit, warnAt float64) Level {
+	if limit <= 0 {
+		return LevelOK
+	}
+	if warnAt <= 0 {
+		warnAt = DefaultWarnAt
+	}
+	switch {
+	case spent >= limit:
+		return LevelExceeded
+	case spent >= limit*warnAt:
+		return LevelWarn
+	default:
+		return LevelOK
+	}
+}
+
+// convoyBudgetPrefix starts the description line holding a convoy's budget,
+// alongside the "Notify:" and "Molecule:" lines written by gt convoy create.
+const convoyBudgetPrefix = "Budget: $"
+
+// FormatConvoyBudget returns the convoy description line for a budget.
+func FormatConvoyBudget(usd float64) string {
+	return fmt.Sprintf("%s%.2f", convoyBudgetPrefix, usd)
+}
+
+// ParseConvoyBudget returns the budget from a convoy description, or 0 if
+// the convoy has none.
+func ParseConvoyBudget(description string) float64 {
+	for _, line := range strings.Split(description, "\n") {
+		if !strings.HasPrefix(line, convoyBudgetPrefix) {
+			continue
+		}
+		usd, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(line, convoyBudgetPrefix)), 64)
+		if err != nil || usd < 0 {
+			return 0
+		}
+		return usd
+	}
+	return 0
+}
+
+// Convoy is a convoy with its budget and the issues it tracks.
+type Convoy struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Status    string   `json:"status"`
+	BudgetUSD float64  `json:"budget_usd,omitempty"`
+	Tracked   []string `json:"tracked"`
+}
+
+// LoadConvoys reads convoys and their tracked issue IDs from the town store.
+// External refs (external:<prefix>:<id>) are normalized to the bare issue ID.
+func LoadConvoys(store beads.Store, openOnly bool) ([]Convoy, error) {
+	status := "all"
+	if openOnly {
+		status = "open"
+	}
+	issues, err := store.List(beads.ListOptions{Type: "convoy", Status: status, Priority: -1})
+	if err != nil {
+		return nil, fmt.Errorf("listing convoys: %w", err)
+	}
+
+	convoys := make([]Convoy, 0, len(issues))
+	for _, issue := range issues {
+		c := Convoy{
+			ID:        issue.ID,
+			Title:     issue.Title,
+			Status:    issue.Status,
+			BudgetUSD: ParseConvoyBudget(issue.Description),
+		}
+		// List output doesn't carry dependency details
+		if full, err := store.Show(issue.ID); err == nil {
+			for _, dep := range full.Dependencies {
+				if dep.DependencyType != "tracks" {
+					continue
+				}
+				c.Tracked = append(c.Tracked, normalizeTrackedID(dep.ID))
+			}
+		}
+		convoys = append(convoys, c)
+	}
+	return convoys, nil
+}
+
+// normalizeTrackedID strips the external:<prefix>: wrapper from cross-rig refs.
+func normalizeTrackedID(id string) string {
+	if strings.HasPrefix(id, "external:") {
+		if parts := strings.SplitN(id, ":", 3); len(parts) == 3 {
+			return parts[2]
+		}
+	}
+	return id
+}
+
+// SameDay reports whether t falls on the same local calendar day as day.
+func SameDay(t, day time.Time) bool {
+	t, day = t.Local(), day.Local()
+	return t.Year() == day.Year() && t.YearDay() == day.YearDay()
+}
+
+// RigSpend sums the entries for rig that ended on the same day as day.
+func RigSpend(entries []Entry, rig string, day time.Time) float64 {
+	var total float64
+	for _, e := range entries {
+		if e.Rig == rig && SameDay(e.EndedAt, day) {
+			total += e.CostUSD
+		}
+	}
+	return total
+}
+
+// ByIssue rolls entries up by work item. Entries recorded without a work
+// item are not included.
+func ByIssue(entries []Entry) map[string]float64 {
+	out := make(map[string]float64)
+	for _, e := range entries {
+		if e.WorkItem != "" {
+			out[e.WorkItem] += e.CostUSD
+		}
+	}
+	return out
+}
+
+// ByConvoy rolls entries up by convoy, attributing each entry to every
+// convoy that tracks its work item.
+func ByConvoy(entries []Entry, convoys []Convoy) map[string]float64 {
+	byIssue := ByIssue(entries)
+	out := make(map[string]float64)
+	for _, c := range convoys {
+		for _, id := range c.Tracked {
+			out[c.ID] += byIssue[id]
+		}
+	}
+	return out
+}
+
+// Rollup is one row of a cost breakdown.
+type Rollup struct {
+	Key     string  `json:"key"`
+	Title   string  `json:"title,omitempty"`
+	CostUSD float64 `json:"cost_usd"`
+	Budget  float64 `json:"budget_usd,omitempty"`
+}
+
+// SortRollups orders rows by cost, highest first, then by key.
+func SortRollups(rows []Rollup) {
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].CostUSD != rows[j].CostUSD {
+			return rows[i].CostUSD > rows[j].CostUSD
+		}
+		return rows[i].Key < rows[j].Key
+	})
+}
@@ -0,0 +1,60 @@
+package costs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		spent, limit, warnAt float64
+		want                 Level
+	}{
+		{5, 0, 0, LevelOK},
+		{7.99, 10, 0, LevelOK},
+		{8, 10, 0, LevelWarn},
+		{10, 10, 0, LevelExceeded},
+		{5, 10, 0.5, LevelWarn},
+	}
+	for _, tt := range tests {
+		if got := Check(tt.spent, tt.limit, tt.warnAt); got != tt.want {
+			t.Errorf("Check(%v, %v, %v) = %v, want %v", tt.spent, tt.limit, tt.warnAt, got, tt.want)
+		}
+	}
+}
+
+func TestConvoyBudgetRoundTrip(t *testing.T) {
+	desc := "Convoy tracking 2 issues\nNotify: mayor/\n" + FormatConvoyBudget(42.5)
+	if got := ParseConvoyBudget(desc); got != 42.5 {
+		t.Errorf("ParseConvoyBudget = %v, want 42.5", got)
+	}
+	if got := ParseConvoyBudget("Convoy tracking 2 issues"); got != 0 {
+		t.Errorf("ParseConvoyBudget(no budget) = %v", got)
+	}
+}
+
+func TestEntriesFromEventsAndRollups(t *testing.T) {
+	day := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	events := []SessionEvent{
+		{ID: "ev-1", EventKind: "session.ended", Target: "gt-a", CreatedAt: day,
+			Payload: `{"cost_usd":1.5,"session_id":"gt-gastown-toast","role":"polecat","rig":"gastown"}`},
+		{ID: "ev-2", EventKind: "session.ended", Target: "gt-b", CreatedAt: day,
+			Payload: `{"cost_usd":2,"rig":"gastown","ended_at":"2026-03-09T12:00:00Z"}`},
+		{ID: "ev-3", EventKind: "session.started", Payload: `{"cost_usd":99}`},
+		{ID: "ev-4", EventKind: "session.ended", Payload: `not json`},
+	}
+	entries := EntriesFromEvents(events)
+	if len(entries) != 2 || entries[0].EventID != "ev-1" || !entries[1].EndedAt.Before(day) {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	if got := RigSpend(entries, "gastown", day); got != 1.5 {
+		t.Errorf("RigSpend = %v, want 1.5", got)
+	}
+
+	convoys := []Convoy{{ID: "hq-cv-1", Tracked: []string{"gt-a", "gt-b"}}, {ID: "hq-cv-2", Tracked: []string{"gt-b"}}}
+	byConvoy := ByConvoy(entries, convoys)
+	if byConvoy["hq-cv-1"] != 3.5 || byConvoy["hq-cv-2"] != 2 {
+		t.Errorf("ByConvoy = %v", byConvoy)
+	}
+}
@@ -0,0 +1,238 @@
+// Package costs reads session costs from tmux panes and the session.ended
+// event ledger, and evaluates them against configured budgets.
+package costs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// Entry is a ledger entry for historical cost tracking.
+type Entry struct {
+	EventID   string    `json:"event_id,omitempty"`
+	SessionID string    `json:"session_id"`
+	Role      string    `json:"role"`
+	Rig       string    `json:"rig,omitempty"`
+	Worker    string    `json:"worker,omitempty"`
+	CostUSD   float64   `json:"cost_usd"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	WorkItem  string    `json:"work_item,omitempty"`
+}
+
+// SessionEvent represents a session.ended event from beads.
+type SessionEvent struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	EventKind string    `json:"event_kind"`
+	Actor     string    `json:"actor"`
+	Target    string    `json:"target"`
+	Payload   string    `json:"payload"`
+}
+
+// SessionPayload represents the JSON payload of a session event.
+type SessionPayload struct {
+	CostUSD   float64 `json:"cost_usd"`
+	SessionID string  `json:"session_id"`
+	Role      string  `json:"role"`
+	Rig       string  `json:"rig"`
+	Worker    string  `json:"worker"`
+	EndedAt   string  `json:"ended_at"`
+}
+
+// eventListItem represents an event from bd list (minimal fields).
+type eventListItem struct {
+	ID string `json:"id"`
+}
+
+// costRegex matches cost patterns like "$1.23" or "$12.34"
+var costRegex = regexp.MustCompile(`\$(\d+\.\d{2})`)
+
+// Extract finds the most recent cost value in pane content.
+// Claude Code displays cost in the format "$X.XX" in the status area.
+func Extract(content string) float64 {
+	matches := costRegex.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return 0.0
+	}
+
+	// Get the last (most recent) match
+	lastMatch := matches[len(matches)-1]
+	if len(lastMatch) < 2 {
+		return 0.0
+	}
+
+	var cost float64
+	_, _ = fmt.Sscanf(lastMatch[1], "%f", &cost)
+	return cost
+}
+
+// QuerySessionEvents queries the beads database in each dir for session.ended
+// events and converts them to entries. An empty dir means the current
+// directory. Events seen in more than one database are returned once.
+func QuerySessionEvents(dirs ...string) ([]Entry, error) {
+	if len(dirs) == 0 {
+		dirs = []string{""}
+	}
+
+	seen := make(map[string]bool)
+	var entries []Entry
+	for _, dir := range dirs {
+		dirEntries, err := querySessionEventsIn(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range dirEntries {
+			if seen[e.EventID] {
+				continue
+			}
+			seen[e.EventID] = true
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func querySessionEventsIn(dir string) ([]Entry, error) {
+	// Step 1: Get list of event IDs
+	listArgs := []string{
+		"list",
+		"--type=event",
+		"--all",
+		"--limit=0",
+		"--json",
+	}
+
+	listCmd := exec.Command("bd", listArgs...)
+	listCmd.Dir = dir
+	listOutput, err := listCmd.Output()
+	if err != nil {
+		// If bd fails (e.g., no beads database), return empty list
+		return nil, nil
+	}
+
+	var listItems []eventListItem
+	if err := json.Unmarshal(listOutput, &listItems); err != nil {
+		return nil, fmt.Errorf("parsing event list: %w", err)
+	}
+
+	if len(listItems) == 0 {
+		return nil, nil
+	}
+
+	// Step 2: Get full details for all events using bd show
+	// (bd list doesn't include event_kind, actor, payload)
+	showArgs := []string{"show", "--json"}
+	for _, item := range listItems {
+		showArgs = append(showArgs, item.ID)
+	}
+
+	showCmd := exec.Command("bd", showArgs...) //nolint:gosec // G204: args are event IDs from bd list
+	showCmd.Dir = dir
+	showOutput, err := showCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("showing events: %w", err)
+	}
+
+	var events []SessionEvent
+	if err := json.Unmarshal(showOutput, &events); err != nil {
+		return nil, fmt.Errorf("parsing event details: %w", err)
+	}
+
+	return EntriesFromEvents(events), nil
+}
+
+// EntriesFromEvents converts session.ended events to ledger entries,
+// skipping other event kinds and malformed payloads.
+func EntriesFromEvents(events []SessionEvent) []Entry {
+	var entries []Entry
+	for _, event := range events {
+		// Filter for session.ended events only
+		if event.EventKind != "session.ended" {
+			continue
+		}
+
+		// Parse payload
+		var payload SessionPayload
+		if event.Payload != "" {
+			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+				continue // Skip malformed payloads
+			}
+		}
+
+		// Parse ended_at from payload, fall back to created_at
+		endedAt := event.CreatedAt
+		if payload.EndedAt != "" {
+			if parsed, err := time.Parse(time.RFC3339, payload.EndedAt); err == nil {
+				endedAt = parsed
+			}
+		}
+
+		entries = append(entries, Entry{
+			EventID:   event.ID,
+			SessionID: payload.SessionID,
+			Role:      payload.Role,
+			Rig:       payload.Rig,
+			Worker:    payload.Worker,
+			CostUSD:   payload.CostUSD,
+			EndedAt:   endedAt,
+			WorkItem:  event.Target,
+		})
+	}
+	return entries
+}
+
+// ParseSessionName extracts role, rig, and worker from a session name.
+// Session names follow the pattern: gt-<rig>-<worker> or gt-<global-agent>
+// Examples:
+//   - gt-mayor -> role=mayor, rig="", worker="mayor"
+//   - gt-deacon -> role=deacon, rig="", worker="deacon"
+//   - gt-gastown-toast -> role=polecat, rig=gastown, worker=toast
+//   - gt-gastown-witness -> role=witness, rig=gastown, worker=""
+//   - gt-gastown-refinery -> role=refinery, rig=gastown, worker=""
+//   - gt-gastown-crew-joe -> role=crew, rig=gastown, worker=joe
+func ParseSessionName(session string) (role, rig, worker string) {
+	// Remove gt- prefix
+	name := strings.TrimPrefix(session, constants.SessionPrefix)
+
+	// Check for global agents
+	switch name {
+	case "mayor":
+		return constants.RoleMayor, "", "mayor"
+	case "deacon":
+		return constants.RoleDeacon, "", "deacon"
+	}
+
+	// Parse rig-based session: rig-worker or rig-crew-name
+	parts := strings.SplitN(name, "-", 3)
+	if len(parts) < 2 {
+		return "unknown", "", name
+	}
+
+	rig = parts[0]
+	worker = parts[1]
+
+	// Check for crew pattern: rig-crew-name
+	if worker == "crew" && len(parts) >= 3 {
+		return constants.RoleCrew, rig, parts[2]
+	}
+
+	// Check for special workers
+	switch worker {
+	case "witness":
+		return constants.RoleWitness, rig, ""
+	case "refinery":
+		return constants.RoleRefinery, rig, ""
+	}
+
+	// Default to polecat
+	return constants.RolePolecat, rig, worker
+}
@@ -0,0 +1,326 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// AutoscaleStateFile returns the path to the autoscaler state file.
+func AutoscaleStateFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "autoscale.json")
+}
+
+// autoscaleState is what the autoscaler remembers between heartbeats.
+type autoscaleState struct {
+	Rigs map[string]*rigAutoscaleState `json:"rigs,omitempty"`
+}
+
+// rigAutoscaleState tracks one rig's idle polecats and current hold.
+type rigAutoscaleState struct {
+	// IdleSince maps a polecat name to when it was first seen idle.
+	IdleSince map[string]time.Time `json:"idle_since,omitempty"`
+
+	// Hold is why spawning is paused with work waiting, so the hold is
+	// logged once rather than on every heartbeat.
+	Hold string `json:"hold,omitempty"`
+}
+
+func loadAutoscaleState(townRoot string) *autoscaleState {
+	state := &autoscaleState{}
+	if data, err := os.ReadFile(AutoscaleStateFile(townRoot)); err == nil {
+		_ = json.Unmarshal(data, state)
+	}
+	if state.Rigs == nil {
+		state.Rigs = make(map[string]*rigAutoscaleState)
+	}
+	return state
+}
+
+func saveAutoscaleState(townRoot string, state *autoscaleState) error {
+	path := AutoscaleStateFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, state)
+}
+
+// autoscalePolecat is a polecat as the autoscaler sees it.
+type autoscalePolecat struct {
+	Name string
+	Idle bool // nothing on its hook
+}
+
+// autoscaleInputs is everything one rig's autoscale pass looks at.
+type autoscaleInputs struct {
+	now         time.Time
+	cfg         *config.AutoscaleConfig
+	poolSize    int
+	ready       []*beads.Issue
+	convoyWork  map[string]string // issue -> open convoy tracking it
+	heldConvoys map[string]bool   // convoys over budget
+	polecats    []autoscalePolecat
+	idleSince   map[string]time.Time
+	budgetLevel string  // rig daily budget alert level, "" when OK
+	loadPerCPU  float64 // 1-minute load average per CPU
+}
+
+// autoscalePlan is what one rig's autoscale pass decided.
+type autoscalePlan struct {
+	Spawn     []string             // issues to sling to new polecats
+	Reap      []string             // idle polecats past their cool-down
+	Hold      string               // why ready work is left waiting, if it is
+	IdleSince map[string]time.Time // updated idle tracking
+}
+
+// planAutoscale decides which ready issues get new polecats and which idle
+// polecats are reaped. Spawning is bounded by the rig's polecat ceiling (the
+// configured max, never more than the name pool), the per-cycle limit, the
+// rig's daily budget and machine load; issues in convoys over budget wait.
+func planAutoscale(in *autoscaleInputs) *autoscalePlan {
+	plan := &autoscalePlan{IdleSince: make(map[string]time.Time)}
+
+	cooldown := in.cfg.Cooldown()
+	for _, p := range in.polecats {
+		if !p.Idle {
+			continue
+		}
+		since, ok := in.idleSince[p.Name]
+		if !ok {
+			since = in.now
+		}
+		plan.IdleSince[p.Name] = since
+		if in.now.Sub(since) >= cooldown {
+			plan.Reap = append(plan.Reap, p.Name)
+		}
+	}
+
+	var demand []*beads.Issue
+	heldByConvoy := 0
+	for _, issue := range in.ready {
+		if issue.Assignee != "" || !in.cfg.Dispatchable(issue.Type) {
+			continue
+		}
+		if convoy := in.convoyWork[issue.ID]; convoy != "" && in.heldConvoys[convoy] {
+			heldByConvoy++
+			continue
+		}
+		demand = append(demand, issue)
+	}
+	sort.SliceStable(demand, func(i, j int) bool {
+		a, b := demand[i], demand[j]
+		if ca, cb := in.convoyWork[a.ID] != "", in.convoyWork[b.ID] != ""; ca != cb {
+			return ca
+		}
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.ID < b.ID
+	})
+
+	if len(demand) == 0 {
+		if heldByConvoy > 0 {
+			plan.Hold = fmt.Sprintf("%d ready issue(s) in convoys over budget", heldByConvoy)
+		}
+		return plan
+	}
+
+	limit := in.poolSize
+	if in.cfg.MaxPolecats > 0 && in.cfg.MaxPolecats < limit {
+		limit = in.cfg.MaxPolecats
+	}
+	free := limit - len(in.polecats)
+
+	switch {
+	case in.budgetLevel != "":
+		plan.Hold = fmt.Sprintf("daily budget %s", in.budgetLevel)
+	case in.loadPerCPU > in.cfg.LoadPerCPU():
+		plan.Hold = fmt.Sprintf("load %.2f per CPU over %.2f", in.loadPerCPU, in.cfg.LoadPerCPU())
+	case free <= 0:
+		plan.Hold = fmt.Sprintf("at %d of %d polecats", len(in.polecats), limit)
+	default:
+		n := min(len(demand), free, in.cfg.SpawnPerCycle())
+		for _, issue := range demand[:n] {
+			plan.Spawn = append(plan.Spawn, issue.ID)
+		}
+	}
+	return plan
+}
+
+// autoscalePolecats runs the autoscaler for every rig that enables it:
+// polecats are spawned for ready work and idle ones reaped after their
+// cool-down, with each decision logged as an autoscale event.
+func (d *Daemon) autoscalePolecats() {
+	type rigConfig struct {
+		name     string
+		cfg      *config.AutoscaleConfig
+		poolSize int
+	}
+	var rigs []rigConfig
+	for _, rigName := range d.getKnownRigs() {
+		rigPath := filepath.Join(d.config.TownRoot, rigName)
+		settings, err := config.LoadRigSettings(config.RigSettingsPath(rigPath))
+		if err != nil || settings.Autoscale == nil || !settings.Autoscale.Enabled {
+			continue
+		}
+		poolSize := polecat.DefaultPoolSize
+		if settings.Namepool != nil && settings.Namepool.MaxBeforeNumbering > 0 {
+			poolSize = settings.Namepool.MaxBeforeNumbering
+		}
+		rigs = append(rigs, rigConfig{name: rigName, cfg: settings.Autoscale, poolSize: poolSize})
+	}
+
+	state := loadAutoscaleState(d.config.TownRoot)
+	if len(rigs) == 0 && len(state.Rigs) == 0 {
+		return
+	}
+
+	budgets := loadBudgetState(d.config.TownRoot)
+	convoyWork := make(map[string]string)
+	heldConvoys := make(map[string]bool)
+	if convoys, err := costs.LoadConvoys(d.beadsStore(), true); err == nil {
+		for _, c := range convoys {
+			for _, id := range c.Tracked {
+				convoyWork[id] = c.ID
+			}
+			if budgets.Alerts["convoy:"+c.ID] == costs.LevelExceeded.String() {
+				heldConvoys[c.ID] = true
+			}
+		}
+	}
+	load := loadPerCPU()
+	now := time.Now()
+
+	enabled := make(map[string]bool, len(rigs))
+	for _, rc := range rigs {
+		enabled[rc.name] = true
+		rs := state.Rigs[rc.name]
+		if rs == nil {
+			rs = &rigAutoscaleState{}
+			state.Rigs[rc.name] = rs
+		}
+
+		ready, err := beads.SharedStore(filepath.Join(d.config.TownRoot, rc.name, "mayor", "rig")).Ready()
+		if err != nil {
+			d.logger.Printf("Autoscale: %s: reading ready work: %v", rc.name, err)
+			continue
+		}
+		in := &autoscaleInputs{
+			now:         now,
+			cfg:         rc.cfg,
+			poolSize:    rc.poolSize,
+			ready:       ready,
+			convoyWork:  convoyWork,
+			heldConvoys: heldConvoys,
+			polecats:    d.autoscalePolecatsIn(rc.name),
+			idleSince:   rs.IdleSince,
+			budgetLevel: budgets.Alerts[rigBudgetKey(rc.name, now)],
+			loadPerCPU:  load,
+		}
+		plan := planAutoscale(in)
+		rs.IdleSince = plan.IdleSince
+
+		for _, name := range plan.Reap {
+			reason := fmt.Sprintf("idle for %s", now.Sub(plan.IdleSince[name]).Round(time.Minute))
+			if err := d.runGT("polecat", "nuke", rc.name+"/"+name); err != nil {
+				d.logger.Printf("Autoscale: %s: failed to reap %s: %v", rc.name, name, err)
+				continue
+			}
+			d.logger.Printf("Autoscale: %s: reaped %s (%s)", rc.name, name, reason)
+			delete(rs.IdleSince, name)
+			d.publishEvent(events.TypeAutoscale, events.AutoscalePayload(rc.name, events.AutoscaleReap, name, "", reason))
+		}
+
+		for _, id := range plan.Spawn {
+			reason := "ready work"
+			if convoy := convoyWork[id]; convoy != "" {
+				reason = "ready work in convoy " + convoy
+			}
+			if err := d.runGT("sling", id, rc.name); err != nil {
+				d.logger.Printf("Autoscale: %s: failed to sling %s: %v", rc.name, id, err)
+				continue
+			}
+			d.logger.Printf("Autoscale: %s: spawned polecat for %s (%s)", rc.name, id, reason)
+			d.publishEvent(events.TypeAutoscale, events.AutoscalePayload(rc.name, events.AutoscaleSpawn, "", id, reason))
+		}
+
+		if plan.Hold != rs.Hold && plan.Hold != "" {
+			d.logger.Printf("Autoscale: %s: holding spawns (%s)", rc.name, plan.Hold)
+			d.publishEvent(events.TypeAutoscale, events.AutoscalePayload(rc.name, events.AutoscaleHold, "", "", plan.Hold))
+		}
+		rs.Hold = plan.Hold
+	}
+
+	// Forget rigs whose autoscaler was turned off.
+	for name := range state.Rigs {
+		if !enabled[name] {
+			delete(state.Rigs, name)
+		}
+	}
+	if err := saveAutoscaleState(d.config.TownRoot, state); err != nil {
+		d.logger.Printf("Warning: failed to save autoscale state: %v", err)
+	}
+}
+
+// autoscalePolecatsIn lists a rig's polecats. A polecat whose agent bead
+// can't be read is treated as busy so it is never reaped by mistake.
+func (d *Daemon) autoscalePolecatsIn(rigName string) []autoscalePolecat {
+	entries, err := os.ReadDir(filepath.Join(d.config.TownRoot, rigName, "polecats"))
+	if err != nil {
+		return nil
+	}
+	var polecats []autoscalePolecat
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		p := autoscalePolecat{Name: entry.Name()}
+		if info, err := d.getAgentBeadInfo(beads.PolecatBeadID(rigName, entry.Name())); err == nil {
+			p.Idle = info.HookBead == ""
+		}
+		polecats = append(polecats, p)
+	}
+	return polecats
+}
+
+// runGT runs a gt command from the town root.
+func (d *Daemon) runGT(args ...string) error {
+	cmd := exec.Command("gt", args...) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// loadPerCPU returns the 1-minute load average divided by the CPU count,
+// or 0 where /proc/loadavg isn't available.
+func loadPerCPU() float64 {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0
+	}
+	return load / float64(runtime.NumCPU())
+}
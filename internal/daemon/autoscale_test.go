@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestPlanAutoscale(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	base := func() *autoscaleInputs {
+		return &autoscaleInputs{
+			now:      now,
+			cfg:      &config.AutoscaleConfig{Enabled: true, MaxPolecats: 4, MaxSpawnPerCycle: 3},
+			poolSize: 50,
+			ready: []*beads.Issue{
+				{ID: "gt-low", Type: "task", Priority: 3},
+				{ID: "gt-high", Type: "bug", Priority: 0},
+				{ID: "gt-mine", Type: "task", Assignee: "gastown/polecats/nux"},
+				{ID: "gt-epic", Type: "epic"},
+				{ID: "gt-cv", Type: "task", Priority: 2},
+				{ID: "gt-broke", Type: "task", Priority: 0},
+			},
+			convoyWork:  map[string]string{"gt-cv": "hq-cv-1", "gt-broke": "hq-cv-2"},
+			heldConvoys: map[string]bool{"hq-cv-2": true},
+			polecats: []autoscalePolecat{
+				{Name: "nux"},
+				{Name: "toast", Idle: true},
+			},
+			idleSince: map[string]time.Time{
+				"toast": now.Add(-20 * time.Minute),
+				"gone":  now.Add(-time.Hour),
+			},
+		}
+	}
+
+	// Convoy work first, then by priority; capped by the polecat ceiling.
+	plan := planAutoscale(base())
+	if want := []string{"gt-cv", "gt-high"}; !reflect.DeepEqual(plan.Spawn, want) {
+		t.Errorf("Spawn = %v, want %v", plan.Spawn, want)
+	}
+	if want := []string{"toast"}; !reflect.DeepEqual(plan.Reap, want) {
+		t.Errorf("Reap = %v, want %v", plan.Reap, want)
+	}
+	if _, ok := plan.IdleSince["gone"]; ok || len(plan.IdleSince) != 1 {
+		t.Errorf("IdleSince = %v, want only toast", plan.IdleSince)
+	}
+	if plan.Hold != "" {
+		t.Errorf("Hold = %q, want none", plan.Hold)
+	}
+
+	// A newly idle polecat starts its cool-down.
+	in := base()
+	in.idleSince = nil
+	plan = planAutoscale(in)
+	if len(plan.Reap) != 0 || !plan.IdleSince["toast"].Equal(now) {
+		t.Errorf("fresh idle polecat: Reap = %v, IdleSince = %v", plan.Reap, plan.IdleSince)
+	}
+
+	// The name pool bounds the ceiling, and the per-cycle limit applies.
+	in = base()
+	in.cfg.MaxPolecats = 0
+	in.poolSize = 5
+	in.cfg.MaxSpawnPerCycle = 0
+	plan = planAutoscale(in)
+	if want := []string{"gt-cv", "gt-high"}; !reflect.DeepEqual(plan.Spawn, want) {
+		t.Errorf("pool-bounded Spawn = %v, want %v", plan.Spawn, want)
+	}
+
+	holds := []struct {
+		name   string
+		modify func(*autoscaleInputs)
+		want   string
+	}{
+		{"budget", func(in *autoscaleInputs) { in.budgetLevel = "warn" }, "daily budget warn"},
+		{"load", func(in *autoscaleInputs) { in.loadPerCPU = 1.5 }, "load 1.50 per CPU"},
+		{"full", func(in *autoscaleInputs) { in.cfg.MaxPolecats = 2 }, "at 2 of 2 polecats"},
+		{"convoy over budget", func(in *autoscaleInputs) { in.ready = in.ready[5:] }, "1 ready issue(s) in convoys over budget"},
+	}
+	for _, tt := range holds {
+		t.Run(tt.name, func(t *testing.T) {
+			in := base()
+			tt.modify(in)
+			plan := planAutoscale(in)
+			if len(plan.Spawn) != 0 || !strings.Contains(plan.Hold, tt.want) {
+				t.Errorf("Spawn = %v, Hold = %q, want hold %q", plan.Spawn, plan.Hold, tt.want)
+			}
+			if len(plan.Reap) != 1 {
+				t.Errorf("idle polecats must still be reaped while holding, Reap = %v", plan.Reap)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/costs"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// BudgetStateFile returns the path to the budget enforcement state file.
+func BudgetStateFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "budgets.json")
+}
+
+// budgetState records what the daemon has already done about each budget,
+// so the mayor is mailed once per level and polecats are parked or stopped
+// once rather than on every heartbeat.
+type budgetState struct {
+	// Alerts maps a budget key to the highest level reported for it.
+	Alerts map[string]string `json:"alerts,omitempty"`
+
+	// Holds maps a polecat session to the budget action applied to it.
+	// Sessions held with "stop" are not restarted by crash recovery.
+	Holds map[string]*budgetHold `json:"holds,omitempty"`
+}
+
+// budgetHold is a park or stop applied to a polecat over a cap.
+type budgetHold struct {
+	Key      string    `json:"key"`
+	Action   string    `json:"action"`
+	HookBead string    `json:"hook_bead,omitempty"`
+	SpentUSD float64   `json:"spent_usd"`
+	LimitUSD float64   `json:"limit_usd"`
+	At       time.Time `json:"at"`
+}
+
+func loadBudgetState(townRoot string) *budgetState {
+	state := &budgetState{}
+	if data, err := os.ReadFile(BudgetStateFile(townRoot)); err == nil {
+		_ = json.Unmarshal(data, state)
+	}
+	if state.Alerts == nil {
+		state.Alerts = make(map[string]string)
+	}
+	if state.Holds == nil {
+		state.Holds = make(map[string]*budgetHold)
+	}
+	return state
+}
+
+func saveBudgetState(townRoot string, state *budgetState) error {
+	path := BudgetStateFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSON(path, state)
+}
+
+// liveSession is a running Gas Town session and the cost shown in its pane.
+type liveSession struct {
+	Name    string
+	Role    string
+	Rig     string
+	CostUSD float64
+}
+
+// budgetInputs is everything a budget pass looks at.
+type budgetInputs struct {
+	now      time.Time
+	ledger   []costs.Entry
+	sessions []liveSession
+	hooks    map[string]string               // polecat session -> hook bead
+	rigs     map[string]*config.BudgetConfig // rigs with a budget
+	convoys  []costs.Convoy                  // open convoys with a budget
+}
+
+// budgetFinding is a budget at or past its warning threshold.
+type budgetFinding struct {
+	Key      string
+	Scope    string
+	Level    costs.Level
+	SpentUSD float64
+	LimitUSD float64
+	Polecats []string // polecat sessions charged against this budget
+}
+
+// evaluateBudgets compares spend against every configured cap. Rig caps
+// count today's ledger plus all live sessions in the rig; convoy caps count
+// the ledger for tracked issues plus live polecats hooked to them; session
+// caps count a polecat's live pane.
+func evaluateBudgets(in *budgetInputs) []budgetFinding {
+	var findings []budgetFinding
+	add := func(f budgetFinding, warnAt float64) {
+		f.Level = costs.Check(f.SpentUSD, f.LimitUSD, warnAt)
+		if f.Level != costs.LevelOK {
+			findings = append(findings, f)
+		}
+	}
+
+	rigNames := make([]string, 0, len(in.rigs))
+	for rig := range in.rigs {
+		rigNames = append(rigNames, rig)
+	}
+	sort.Strings(rigNames)
+
+	for _, rig := range rigNames {
+		budget := in.rigs[rig]
+		if budget.DailyUSD > 0 {
+			f := budgetFinding{
+				Key:      rigBudgetKey(rig, in.now),
+				Scope:    fmt.Sprintf("rig %s (daily)", rig),
+				SpentUSD: costs.RigSpend(in.ledger, rig, in.now),
+				LimitUSD: budget.DailyUSD,
+			}
+			for _, s := range in.sessions {
+				if s.Rig != rig {
+					continue
+				}
+				f.SpentUSD += s.CostUSD
+				if s.Role == constants.RolePolecat {
+					f.Polecats = append(f.Polecats, s.Name)
+				}
+			}
+			add(f, budget.WarnAt)
+		}
+
+		if budget.PolecatSessionUSD > 0 {
+			for _, s := range in.sessions {
+				if s.Rig != rig || s.Role != constants.RolePolecat {
+					continue
+				}
+				add(budgetFinding{
+					Key:      polecatBudgetKey(s.Name, in.hooks[s.Name]),
+					Scope:    fmt.Sprintf("polecat session %s", s.Name),
+					SpentUSD: s.CostUSD,
+					LimitUSD: budget.PolecatSessionUSD,
+					Polecats: []string{s.Name},
+				}, budget.WarnAt)
+			}
+		}
+	}
+
+	byIssue := costs.ByIssue(in.ledger)
+	for _, c := range in.convoys {
+		tracked := make(map[string]bool, len(c.Tracked))
+		f := budgetFinding{
+			Key:      "convoy:" + c.ID,
+			Scope:    fmt.Sprintf("convoy %s (%s)", c.ID, c.Title),
+			LimitUSD: c.BudgetUSD,
+		}
+		for _, id := range c.Tracked {
+			tracked[id] = true
+			f.SpentUSD += byIssue[id]
+		}
+		for _, s := range in.sessions {
+			if hook := in.hooks[s.Name]; hook != "" && tracked[hook] {
+				f.SpentUSD += s.CostUSD
+				f.Polecats = append(f.Polecats, s.Name)
+			}
+		}
+		add(f, 0)
+	}
+
+	return findings
+}
+
+// polecatBudgetKey identifies a session cap by session and hooked work, so
+// a stop is lifted when the polecat is given different work.
+func polecatBudgetKey(session, hookBead string) string {
+	return fmt.Sprintf("polecat:%s:%s", session, hookBead)
+}
+
+// checkBudgets enforces cost budgets from rig settings and convoys. The
+// mayor is mailed when a budget crosses its warning threshold and again when
+// it is exceeded; polecats charged to an exceeded budget are then parked or
+// stopped according to their rig's on_exceed setting.
+func (d *Daemon) checkBudgets() {
+	in := &budgetInputs{
+		now:   time.Now(),
+		hooks: make(map[string]string),
+		rigs:  make(map[string]*config.BudgetConfig),
+	}
+
+	rigs := d.getKnownRigs()
+	for _, rigName := range rigs {
+		settings, err := config.LoadRigSettings(config.RigSettingsPath(filepath.Join(d.config.TownRoot, rigName)))
+		if err == nil && settings.Budget != nil {
+			in.rigs[rigName] = settings.Budget
+		}
+	}
+
+	if convoys, err := costs.LoadConvoys(d.beadsStore(), true); err == nil {
+		for _, c := range convoys {
+			if c.BudgetUSD > 0 {
+				in.convoys = append(in.convoys, c)
+			}
+		}
+	}
+
+	state := loadBudgetState(d.config.TownRoot)
+	if len(in.rigs) == 0 && len(in.convoys) == 0 && len(state.Holds) == 0 {
+		return // Nothing to enforce or release
+	}
+
+	// The ledger is spread across town and rig beads: sessions record their
+	// cost from their own working directory.
+	dirs := []string{d.config.TownRoot}
+	for _, rigName := range rigs {
+		dirs = append(dirs, filepath.Join(d.config.TownRoot, rigName))
+	}
+	ledger, err := costs.QuerySessionEvents(dirs...)
+	if err != nil {
+		d.logger.Printf("Budget check: reading cost ledger: %v", err)
+	}
+	in.ledger = ledger
+
+	sessions, err := d.tmux.ListSessions()
+	if err != nil {
+		d.logger.Printf("Budget check: listing sessions: %v", err)
+		return
+	}
+	for _, name := range sessions {
+		if !strings.HasPrefix(name, constants.SessionPrefix) {
+			continue
+		}
+		role, rig, worker := costs.ParseSessionName(name)
+		content, err := d.tmux.CapturePaneAll(name)
+		if err != nil {
+			continue
+		}
+		in.sessions = append(in.sessions, liveSession{
+			Name:    name,
+			Role:    role,
+			Rig:     rig,
+			CostUSD: costs.Extract(content),
+		})
+		if role == constants.RolePolecat {
+			if info, err := d.getAgentBeadInfo(beads.PolecatBeadID(rig, worker)); err == nil {
+				in.hooks[name] = info.HookBead
+			}
+		}
+	}
+
+	// Stopped polecats have no session, but their hook decides whether
+	// a session cap still applies
+	for session := range state.Holds {
+		if _, ok := in.hooks[session]; ok {
+			continue
+		}
+		_, rig, worker := costs.ParseSessionName(session)
+		if info, err := d.getAgentBeadInfo(beads.PolecatBeadID(rig, worker)); err == nil {
+			in.hooks[session] = info.HookBead
+		}
+	}
+
+	findings := evaluateBudgets(in)
+	d.applyBudgetFindings(state, in, findings)
+
+	if err := saveBudgetState(d.config.TownRoot, state); err != nil {
+		d.logger.Printf("Warning: failed to save budget state: %v", err)
+	}
+}
+
+// applyBudgetFindings mails the mayor about new findings, holds polecats
+// over exceeded budgets, and releases holds whose budget has recovered.
+func (d *Daemon) applyBudgetFindings(state *budgetState, in *budgetInputs, findings []budgetFinding) {
+	current := make(map[string]bool, len(findings))
+	exceeded := make(map[string]bool)
+	for _, f := range findings {
+		current[f.Key] = true
+		if f.Level == costs.LevelExceeded {
+			exceeded[f.Key] = true
+		}
+
+		if prev := state.Alerts[f.Key]; prev != f.Level.String() {
+			// Mail on first warning and on overrun, not when an overrun
+			// drops back to a warning because the cap was raised
+			if prev == "" || f.Level == costs.LevelExceeded {
+				d.notifyMayorOfBudget(f)
+			}
+			state.Alerts[f.Key] = f.Level.String()
+		}
+
+		if f.Level != costs.LevelExceeded {
+			continue
+		}
+		for _, session := range f.Polecats {
+			if state.Holds[session] != nil {
+				continue
+			}
+			_, rig, _ := costs.ParseSessionName(session)
+			action := in.rigs[rig].Action()
+			if action == config.BudgetActionWarn {
+				continue
+			}
+			if err := d.holdPolecat(session, action, in.hooks[session], f); err != nil {
+				d.logger.Printf("Budget: failed to %s %s: %v", action, session, err)
+				continue
+			}
+			state.Holds[session] = &budgetHold{
+				Key:      f.Key,
+				Action:   action,
+				HookBead: in.hooks[session],
+				SpentUSD: f.SpentUSD,
+				LimitUSD: f.LimitUSD,
+				At:       in.now,
+			}
+		}
+	}
+
+	for session, hold := range state.Holds {
+		if !budgetHoldActive(hold, session, in, exceeded) {
+			d.logger.Printf("Budget: releasing %s (%s no longer exceeded)", session, hold.Key)
+			delete(state.Holds, session)
+		}
+	}
+
+	// Forget alerts for budgets that have recovered so a later climb is
+	// reported again. Keys still backing a hold are kept.
+	held := make(map[string]bool)
+	for _, hold := range state.Holds {
+		held[hold.Key] = true
+	}
+	for key := range state.Alerts {
+		if !current[key] && !held[key] {
+			delete(state.Alerts, key)
+		}
+	}
+}
+
+// budgetHoldActive reports whether a hold should stay in place. A stopped
+// polecat no longer shows live spend, so a hold is kept while the cap it
+// crossed still sits at or below what was spent: a session cap until the
+// polecat's hook changes, a rig cap until the day rolls over, and a convoy
+// cap until the convoy closes.
+func budgetHoldActive(hold *budgetHold, session string, in *budgetInputs, exceeded map[string]bool) bool {
+	if exceeded[hold.Key] {
+		return true
+	}
+	_, rig, _ := costs.ParseSessionName(session)
+	budget := in.rigs[rig]
+
+	switch {
+	case strings.HasPrefix(hold.Key, "polecat:"):
+		if hook, ok := in.hooks[session]; ok && polecatBudgetKey(session, hook) != hold.Key {
+			return false
+		}
+		return budget != nil && budget.PolecatSessionUSD > 0 && hold.SpentUSD >= budget.PolecatSessionUSD
+
+	case strings.HasPrefix(hold.Key, "rig:"):
+		if hold.Key != rigBudgetKey(rig, in.now) {
+			return false
+		}
+		return budget != nil && budget.DailyUSD > 0 && hold.SpentUSD >= budget.DailyUSD
+
+	case strings.HasPrefix(hold.Key, "convoy:"):
+		for _, c := range in.convoys {
+			if "convoy:"+c.ID == hold.Key {
+				return hold.SpentUSD >= c.BudgetUSD
+			}
+		}
+	}
+	return false
+}
+
+// rigBudgetKey identifies a rig's daily cap for the day containing now.
+func rigBudgetKey(rig string, now time.Time) string {
+	return fmt.Sprintf("rig:%s:%s", rig, now.Local().Format("2006-01-02"))
+}
+
+// holdPolecat parks or stops a polecat session over budget. Parking
+// interrupts the agent and tells it to wait; stopping records the session's
+// cost in the ledger, so it still counts once the pane is gone, and kills it.
+func (d *Daemon) holdPolecat(session, action, hookBead string, f budgetFinding) error {
+	switch action {
+	case config.BudgetActionStop:
+		d.logger.Printf("Budget: stopping %s (%s at $%.2f of $%.2f)", session, f.Scope, f.SpentUSD, f.LimitUSD)
+		d.recordSessionCost(session, hookBead)
+		return d.tmux.KillSession(session)
+	default:
+		d.logger.Printf("Budget: parking %s (%s at $%.2f of $%.2f)", session, f.Scope, f.SpentUSD, f.LimitUSD)
+		_ = d.tmux.SendKeysRaw(session, "Escape")
+		msg := fmt.Sprintf("BUDGET EXCEEDED: %s has spent $%.2f of its $%.2f cap. Stop work now: commit any WIP, then wait for the mayor before continuing.",
+			f.Scope, f.SpentUSD, f.LimitUSD)
+		return d.tmux.NudgeSession(session, msg)
+	}
+}
+
+// recordSessionCost writes a session's final pane cost to the ledger with
+// gt costs record, attributed to its hooked work, as its Stop hook would
+// have done had it exited on its own.
+func (d *Daemon) recordSessionCost(session, hookBead string) {
+	args := []string{"costs", "record", "--session", session}
+	if hookBead != "" {
+		args = append(args, "--work-item", hookBead)
+	}
+	cmd := exec.Command("gt", args...) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	if _, rig, _ := costs.ParseSessionName(session); rig != "" {
+		cmd.Dir = filepath.Join(d.config.TownRoot, rig)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		d.logger.Printf("Budget: failed to record cost of %s: %v: %s", session, err, strings.TrimSpace(string(out)))
+	}
+}
+
+// budgetHeld reports whether a polecat session was stopped for budget, in
+// which case crash recovery must not restart it.
+func (d *Daemon) budgetHeld(session string) bool {
+	hold := loadBudgetState(d.config.TownRoot).Holds[session]
+	return hold != nil && hold.Action == config.BudgetActionStop
+}
+
+// notifyMayorOfBudget mails the mayor about a budget warning or overrun.
+func (d *Daemon) notifyMayorOfBudget(f budgetFinding) {
+	kind := "BUDGET_WARNING"
+	if f.Level == costs.LevelExceeded {
+		kind = "BUDGET_EXCEEDED"
+	}
+	subject := fmt.Sprintf("%s: %s at $%.2f of $%.2f", kind, f.Scope, f.SpentUSD, f.LimitUSD)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "budget: %s\nspent_usd: %.2f\nlimit_usd: %.2f\n", f.Key, f.SpentUSD, f.LimitUSD)
+	if len(f.Polecats) > 0 {
+		fmt.Fprintf(&body, "polecats: %s\n", strings.Join(f.Polecats, ", "))
+	}
+	if f.Level == costs.LevelExceeded {
+		body.WriteString("\nPolecats charged to this budget are being parked or stopped per rig settings.\nRaise the cap or reassign the work to resume.")
+	}
+
+	cmd := exec.Command("gt", "mail", "send", "mayor/", "-s", subject, "-m", body.String()) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = d.config.TownRoot
+	if err := cmd.Run(); err != nil {
+		d.logger.Printf("Warning: failed to notify mayor of budget: %v", err)
+	} else {
+		d.logger.Printf("Notified mayor: %s", subject)
+	}
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/costs"
+)
+
+func TestEvaluateBudgets(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	in := &budgetInputs{
+		now: now,
+		ledger: []costs.Entry{
+			{Rig: "gastown", CostUSD: 6, EndedAt: now.Add(-time.Hour), WorkItem: "gt-a"},
+			{Rig: "gastown", CostUSD: 50, EndedAt: now.AddDate(0, 0, -1), WorkItem: "gt-a"},
+			{Rig: "beads", CostUSD: 1, EndedAt: now},
+		},
+		sessions: []liveSession{
+			{Name: "gt-gastown-toast", Role: "polecat", Rig: "gastown", CostUSD: 2.5},
+			{Name: "gt-gastown-witness", Role: "witness", Rig: "gastown", CostUSD: 0.5},
+			{Name: "gt-beads-nux", Role: "polecat", Rig: "beads", CostUSD: 0.2},
+		},
+		hooks: map[string]string{"gt-gastown-toast": "gt-b", "gt-beads-nux": "bd-x"},
+		rigs: map[string]*config.BudgetConfig{
+			"gastown": {DailyUSD: 10, PolecatSessionUSD: 2},
+			"beads":   {DailyUSD: 100},
+		},
+		convoys: []costs.Convoy{
+			{ID: "hq-cv-1", Title: "Release", BudgetUSD: 70, Tracked: []string{"gt-a", "gt-b"}},
+		},
+	}
+
+	got := make(map[string]budgetFinding)
+	for _, f := range evaluateBudgets(in) {
+		got[f.Key] = f
+	}
+	if len(got) != 3 {
+		t.Fatalf("findings = %+v", got)
+	}
+
+	// Today's ledger (6) + live sessions (3) = 9 of 10: warning
+	rig := got["rig:gastown:2026-03-10"]
+	if rig.Level != costs.LevelWarn || rig.SpentUSD != 9 || len(rig.Polecats) != 1 {
+		t.Errorf("rig finding = %+v", rig)
+	}
+
+	session := got[polecatBudgetKey("gt-gastown-toast", "gt-b")]
+	if session.Level != costs.LevelExceeded || session.Polecats[0] != "gt-gastown-toast" {
+		t.Errorf("session finding = %+v", session)
+	}
+
+	// All-time ledger for gt-a (56) + toast hooked to gt-b (2.5) = 58.5 of 70
+	convoy := got["convoy:hq-cv-1"]
+	if convoy.Level != costs.LevelWarn || convoy.SpentUSD != 58.5 {
+		t.Errorf("convoy finding = %+v", convoy)
+	}
+}
+
+func TestBudgetHoldActive(t *testing.T) {
+	in := &budgetInputs{
+		hooks: map[string]string{"gt-gastown-toast": "gt-b"},
+		rigs:  map[string]*config.BudgetConfig{"gastown": {PolecatSessionUSD: 2}},
+	}
+	hold := &budgetHold{Key: polecatBudgetKey("gt-gastown-toast", "gt-b"), Action: "stop", SpentUSD: 2.5}
+
+	// Session is gone, so the cap is no longer in the findings, but the
+	// polecat still has the same work and the cap hasn't moved
+	if !budgetHoldActive(hold, "gt-gastown-toast", in, nil) {
+		t.Error("hold released while cap unchanged")
+	}
+
+	in.rigs["gastown"].PolecatSessionUSD = 5
+	if budgetHoldActive(hold, "gt-gastown-toast", in, nil) {
+		t.Error("hold kept after cap raised")
+	}
+
+	in.rigs["gastown"].PolecatSessionUSD = 2
+	in.hooks["gt-gastown-toast"] = "gt-c"
+	if budgetHoldActive(hold, "gt-gastown-toast", in, nil) {
+		t.Error("hold kept after hook changed")
+	}
+
+	day := time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)
+	in.now = day
+	in.rigs["gastown"].DailyUSD = 20
+	rigHold := &budgetHold{Key: rigBudgetKey("gastown", day), Action: "stop", SpentUSD: 21}
+	if !budgetHoldActive(rigHold, "gt-gastown-toast", in, map[string]bool{rigHold.Key: true}) {
+		t.Error("rig hold released while still exceeded")
+	}
+	// The stopped polecat's spend is no longer live, but the day's cap
+	// still holds it
+	if !budgetHoldActive(rigHold, "gt-gastown-toast", in, nil) {
+		t.Error("rig hold released while cap unchanged")
+	}
+	in.rigs["gastown"].DailyUSD = 30
+	if budgetHoldActive(rigHold, "gt-gastown-toast", in, nil) {
+		t.Error("rig hold kept after cap raised")
+	}
+	in.rigs["gastown"].DailyUSD = 20
+	in.now = day.Add(24 * time.Hour)
+	if budgetHoldActive(rigHold, "gt-gastown-toast", in, nil) {
+		t.Error("rig hold kept after the day rolled over")
+	}
+
+	convoyHold := &budgetHold{Key: "convoy:hq-cv-1", Action: "stop", SpentUSD: 60}
+	in.convoys = []costs.Convoy{{ID: "hq-cv-1", BudgetUSD: 50}}
+	if !budgetHoldActive(convoyHold, "gt-gastown-toast", in, nil) {
+		t.Error("convoy hold released while cap unchanged")
+	}
+	in.convoys[0].BudgetUSD = 100
+	if budgetHoldActive(convoyHold, "gt-gastown-toast", in, nil) {
+		t.Error("convoy hold kept after cap raised")
+	}
+	in.convoys = nil
+	if budgetHoldActive(convoyHold, "gt-gastown-toast", in, nil) {
+		t.Error("convoy hold kept after the convoy closed")
+	}
+}
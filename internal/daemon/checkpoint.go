@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/checkpoint"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// defaultCheckpointInterval is how often a polecat with hooked work is
+// checkpointed when mayor/config.json doesn't say.
+const defaultCheckpointInterval = 10 * time.Minute
+
+// recoveryCheckpointMaxAge bounds how old a checkpoint can be and still be
+// used to resume a restarted polecat; prime discards older ones too.
+const recoveryCheckpointMaxAge = 24 * time.Hour
+
+// recoveryPromptDelay gives a restarted agent time to reach its prompt
+// before the recovery prompt is typed.
+const recoveryPromptDelay = 8 * time.Second
+
+// checkpointInterval returns daemon.checkpoint_interval, or the default.
+// Zero disables scheduled checkpoints.
+func (d *Daemon) checkpointInterval() time.Duration {
+	cfg, err := config.LoadMayorConfig(constants.MayorConfigPath(d.config.TownRoot))
+	if err != nil || cfg.Daemon == nil || cfg.Daemon.CheckpointInterval == "" {
+		return defaultCheckpointInterval
+	}
+	interval, err := time.ParseDuration(cfg.Daemon.CheckpointInterval)
+	if err != nil {
+		return defaultCheckpointInterval
+	}
+	return interval
+}
+
+// checkpointPolecats snapshots each running polecat with hooked work whose
+// checkpoint is older than the checkpoint interval, so a crash loses at
+// most one interval of progress context.
+func (d *Daemon) checkpointPolecats() {
+	interval := d.checkpointInterval()
+	if interval <= 0 {
+		return
+	}
+	for _, rigName := range d.getKnownRigs() {
+		entries, err := os.ReadDir(filepath.Join(d.config.TownRoot, rigName, "polecats"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				d.checkpointPolecat(rigName, entry.Name(), interval)
+			}
+		}
+	}
+}
+
+func (d *Daemon) checkpointPolecat(rigName, polecatName string, interval time.Duration) {
+	polecatDir := filepath.Join(d.config.TownRoot, rigName, "polecats", polecatName)
+	if cp, _ := checkpoint.Read(polecatDir); cp != nil && cp.Age() < interval {
+		return
+	}
+
+	// Dead sessions keep the checkpoint their pane-died hook wrote.
+	sessionName := fmt.Sprintf("gt-%s-%s", rigName, polecatName)
+	if alive, err := d.tmux.HasSession(sessionName); err != nil || !alive {
+		return
+	}
+
+	// Idle polecats have nothing to resume.
+	info, err := d.getAgentBeadInfo(beads.PolecatBeadID(rigName, polecatName))
+	if err != nil || info.HookBead == "" {
+		return
+	}
+
+	agent := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
+	if _, err := checkpoint.Snapshot(polecatDir, agent, checkpoint.TriggerScheduled); err != nil {
+		d.logger.Printf("Warning: failed to checkpoint %s: %v", agent, err)
+	}
+}
+
+// recoveryCheckpoint returns the checkpoint a restarted polecat should
+// resume from. A missing or stale checkpoint is replaced by a fresh
+// snapshot of the worktree.
+func (d *Daemon) recoveryCheckpoint(rigName, polecatName, workDir string) *checkpoint.Checkpoint {
+	cp, _ := checkpoint.Read(workDir)
+	if cp != nil && cp.Age() < recoveryCheckpointMaxAge {
+		return cp
+	}
+	agent := fmt.Sprintf("%s/polecats/%s", rigName, polecatName)
+	cp, err := checkpoint.Snapshot(workDir, agent, checkpoint.TriggerRestart)
+	if err != nil {
+		d.logger.Printf("Warning: failed to checkpoint %s before restart: %v", agent, err)
+		return nil
+	}
+	return cp
+}
+
+// sendRecoveryPrompt types the checkpoint's recovery prompt into a
+// restarted polecat once it has had time to start.
+func (d *Daemon) sendRecoveryPrompt(sessionName string, cp *checkpoint.Checkpoint) {
+	select {
+	case <-d.ctx.Done():
+		return
+	case <-time.After(recoveryPromptDelay):
+	}
+	if err := d.tmux.NudgeSession(sessionName, cp.RecoveryPrompt()); err != nil {
+		d.logger.Printf("Warning: failed to send recovery prompt to %s: %v", sessionName, err)
+		return
+	}
+	d.logger.Printf("Sent recovery prompt to %s (%s)", sessionName, cp.Summary())
+}
@@ -13,15 +13,21 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/api"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/boot"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/deacon"
+	"github.com/steveyegge/gastown/internal/eventbus"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/feed"
+	"github.com/steveyegge/gastown/internal/notify"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/transcript"
 )
 
 // Daemon is the town-level background service.
@@ -35,6 +41,16 @@ type Daemon struct {
 	ctx     context.Context
 	cancel  context.CancelFunc
 	curator *feed.Curator
+	store   beads.Store // agent bead reads; nil uses the shared town store
+
+	// Event bus served on daemon/events.sock
+	bus       *eventbus.Bus
+	busServer *eventbus.Server
+	busCancel context.CancelFunc
+
+	// Control API served on daemon/api.sock
+	apiServer *api.Server
+	apiCancel context.CancelFunc
 }
 
 // New creates a new daemon instance.
@@ -110,9 +126,18 @@ func (d *Daemon) Run() error {
 
 	d.logger.Printf("Daemon running, recovery heartbeat interval %v", recoveryHeartbeatInterval)
 
-	// Start feed curator goroutine
+	// Start the event bus and serve it on the daemon socket
+	d.startEventBus()
+
+	// Deliver outbound notifications (mayor/config.json "notify")
+	d.startNotifier()
+
+	// Serve the control API on the daemon socket
+	d.startAPI()
+
+	// Start feed curator goroutine, fed by the bus
 	d.curator = feed.NewCurator(d.config.TownRoot)
-	if err := d.curator.Start(); err != nil {
+	if err := d.curator.StartWithBus(d.bus); err != nil {
 		d.logger.Printf("Warning: failed to start feed curator: %v", err)
 	} else {
 		d.logger.Println("Feed curator started")
@@ -198,6 +223,27 @@ func (d *Daemon) heartbeat(state *State) {
 	// This validates tmux sessions are still alive for polecats with work-on-hook
 	d.checkPolecatSessionHealth()
 
+	// 9. Enforce cost budgets (warn mayor, park or stop polecats over caps)
+	d.checkBudgets()
+
+	// 10. Record transcripts for agent sessions started without recording
+	d.ensureTranscripts()
+
+	// 11. Checkpoint polecats with hooked work (daemon.checkpoint_interval)
+	d.checkpointPolecats()
+
+	// 12. Spawn polecats for ready work and reap idle ones (rig autoscale settings)
+	d.autoscalePolecats()
+
+	// 13. Record due patrol digests and roll up finished days (town retention settings)
+	d.rollupDigests()
+
+	// 14. Re-escalate escalations past their SLA (town escalation settings)
+	d.checkEscalations()
+
+	// 15. Queue due dog jobs and start queued ones on idle dogs (town dog_jobs settings)
+	d.runDogJobs()
+
 	// Update state
 	state.LastHeartbeat = time.Now()
 	state.HeartbeatCount++
@@ -333,7 +379,7 @@ func (d *Daemon) ensureDeaconRunning() {
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
 	deaconDir := filepath.Join(d.config.TownRoot, "deacon")
 	sessionName := d.getDeaconSessionName()
-	if err := d.tmux.EnsureSessionFresh(sessionName, deaconDir); err != nil {
+	if err := d.tmux.EnsureSessionFresh(sessionName, deaconDir, false); err != nil {
 		d.logger.Printf("Error creating Deacon session: %v", err)
 		return
 	}
@@ -449,7 +495,7 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 		hasSession, sessionErr := d.tmux.HasSession(sessionName)
 		if sessionErr == nil && hasSession {
 			// Session exists - check if Claude is actually running in it
-			if d.tmux.IsClaudeRunning(sessionName) {
+			if d.tmux.IsAgentRunning(sessionName, rig.IsRemoteRig(d.config.TownRoot, rigName)) {
 				// Session is healthy - don't restart it
 				// The bead state may be stale; agent will update it on next activity
 				d.logger.Printf("Witness for %s session healthy (Claude running), skipping restart despite stale bead", rigName)
@@ -464,7 +510,7 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 	// Create session in witness directory
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
 	witnessDir := filepath.Join(d.config.TownRoot, rigName, "witness")
-	if err := d.tmux.EnsureSessionFresh(sessionName, witnessDir); err != nil {
+	if err := d.tmux.EnsureSessionFresh(sessionName, witnessDir, rig.IsRemoteRig(d.config.TownRoot, rigName)); err != nil {
 		d.logger.Printf("Error creating witness session for %s: %v", rigName, err)
 		return
 	}
@@ -482,7 +528,14 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 		"BD_ACTOR":        bdActor,
 		"GIT_AUTHOR_NAME": bdActor,
 	}
-	if err := d.tmux.SendKeys(sessionName, config.BuildStartupCommand(envVars, "", "")); err != nil {
+	// Remote rigs run the witness on their machine behind ssh
+	startCmd, err := rig.RemoteCommandForRig(d.config.TownRoot, rigName, witnessDir, config.BuildStartupCommand(envVars, "", ""))
+	if err != nil {
+		d.logger.Printf("Error preparing witness session for %s: %v", rigName, err)
+		_ = d.tmux.KillSession(sessionName)
+		return
+	}
+	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
 		d.logger.Printf("Error launching Claude in witness session for %s: %v", rigName, err)
 		return
 	}
@@ -536,7 +589,7 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 		hasSession, sessionErr := d.tmux.HasSession(sessionName)
 		if sessionErr == nil && hasSession {
 			// Session exists - check if Claude is actually running in it
-			if d.tmux.IsClaudeRunning(sessionName) {
+			if d.tmux.IsAgentRunning(sessionName, rig.IsRemoteRig(d.config.TownRoot, rigName)) {
 				// Session is healthy - don't restart it
 				// The bead state may be stale; agent will update it on next activity
 				d.logger.Printf("Refinery for %s session healthy (Claude running), skipping restart despite stale bead", rigName)
@@ -558,7 +611,7 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 
 	// Create session in refinery directory
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
-	if err := d.tmux.EnsureSessionFresh(sessionName, refineryDir); err != nil {
+	if err := d.tmux.EnsureSessionFresh(sessionName, refineryDir, rig.IsRemoteRig(d.config.TownRoot, rigName)); err != nil {
 		d.logger.Printf("Error creating refinery session for %s: %v", rigName, err)
 		return
 	}
@@ -586,7 +639,14 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 		"BD_ACTOR":        bdActor,
 		"GIT_AUTHOR_NAME": bdActor,
 	}
-	if err := d.tmux.SendKeys(sessionName, config.BuildStartupCommand(envVars, "", "")); err != nil {
+	// Remote rigs run the refinery on their machine behind ssh
+	startCmd, err := rig.RemoteCommandForRig(d.config.TownRoot, rigName, refineryDir, config.BuildStartupCommand(envVars, "", ""))
+	if err != nil {
+		d.logger.Printf("Error preparing refinery session for %s: %v", rigName, err)
+		_ = d.tmux.KillSession(sessionName)
+		return
+	}
+	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
 		d.logger.Printf("Error launching Claude in refinery session for %s: %v", rigName, err)
 		return
 	}
@@ -675,6 +735,67 @@ func (d *Daemon) processLifecycleRequests() {
 	d.ProcessLifecycleRequests()
 }
 
+// startEventBus starts the town event bus and its socket server. A socket
+// failure is logged but not fatal: the bus still feeds the curator, and
+// other processes fall back to tailing the events log.
+func (d *Daemon) startEventBus() {
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.busCancel = cancel
+	d.bus = eventbus.New(d.config.TownRoot)
+	go func() {
+		if err := d.bus.Run(ctx); err != nil {
+			d.logger.Printf("Warning: event bus stopped: %v", err)
+		}
+	}()
+
+	server := eventbus.NewServer(d.config.TownRoot, d.bus)
+	if err := server.Start(ctx); err != nil {
+		d.logger.Printf("Warning: failed to serve event bus: %v", err)
+		return
+	}
+	d.busServer = server
+	d.logger.Printf("Event bus listening on %s", eventbus.SocketPath(d.config.TownRoot))
+}
+
+// startNotifier starts outbound notification delivery. It always runs, so
+// that adding a notify section to mayor/config.json takes effect without a
+// daemon restart.
+func (d *Daemon) startNotifier() {
+	cfg, err := notify.LoadConfig(d.config.TownRoot)
+	if err != nil {
+		d.logger.Printf("Warning: invalid notify config: %v", err)
+	}
+	n := notify.New(d.config.TownRoot, cfg)
+	n.SetLogger(d.logger.Printf)
+	go n.Run(d.ctx, d.bus)
+}
+
+// startAPI serves the control API on daemon/api.sock, and on
+// daemon.api_listen if configured. A failure is logged but not fatal.
+func (d *Daemon) startAPI() {
+	var opts api.Options
+	if cfg, err := config.LoadMayorConfig(constants.MayorConfigPath(d.config.TownRoot)); err == nil && cfg.Daemon != nil {
+		opts.Listen = cfg.Daemon.APIListen
+	}
+	server, err := api.NewServer(d.config.TownRoot, opts)
+	if err != nil {
+		d.logger.Printf("Warning: failed to start control API: %v", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	if err := server.Start(ctx); err != nil {
+		cancel()
+		d.logger.Printf("Warning: failed to serve control API: %v", err)
+		return
+	}
+	d.apiServer = server
+	d.apiCancel = cancel
+	d.logger.Printf("Control API listening on %s", api.SocketPath(d.config.TownRoot))
+	if opts.Listen != "" {
+		d.logger.Printf("Control API listening on %s (token in %s)", opts.Listen, api.TokenPath(d.config.TownRoot))
+	}
+}
+
 // shutdown performs graceful shutdown.
 func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return kept for future use
 	d.logger.Println("Daemon shutting down")
@@ -685,6 +806,22 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("Feed curator stopped")
 	}
 
+	// Stop control API
+	if d.apiCancel != nil {
+		d.apiCancel()
+		d.apiServer.Wait()
+		d.logger.Println("Control API stopped")
+	}
+
+	// Stop event bus
+	if d.busCancel != nil {
+		d.busCancel()
+		if d.busServer != nil {
+			d.busServer.Wait()
+		}
+		d.logger.Println("Event bus stopped")
+	}
+
 	state.Running = false
 	if err := SaveState(d.config.TownRoot, state); err != nil {
 		d.logger.Printf("Warning: failed to save final state: %v", err)
@@ -773,6 +910,28 @@ func StopDaemon(townRoot string) error {
 	return nil
 }
 
+// ensureTranscripts starts transcript recording for agent sessions that
+// aren't being recorded, such as those started by older gt binaries or by
+// hand. Mayor, deacon and boot sessions are not recorded.
+func (d *Daemon) ensureTranscripts() {
+	sessions, err := d.tmux.ListSessions()
+	if err != nil {
+		return
+	}
+	for _, name := range sessions {
+		id, err := session.ParseSessionName(name)
+		if err != nil {
+			continue
+		}
+		switch id.Role {
+		case session.RolePolecat, session.RoleCrew, session.RoleWitness, session.RoleRefinery:
+			if err := transcript.Start(d.tmux, d.config.TownRoot, name); err != nil {
+				d.logger.Printf("Warning: failed to record transcript for %s: %v", name, err)
+			}
+		}
+	}
+}
+
 // checkPolecatSessionHealth proactively validates polecat tmux sessions.
 // This detects crashed polecats that:
 // 1. Have work-on-hook (assigned work)
@@ -838,18 +997,47 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 		return
 	}
 
+	// Polecats stopped for budget stay down until the cap is lifted
+	if d.budgetHeld(sessionName) {
+		return
+	}
+
 	// Polecat has work but session is dead - this is a crash!
 	d.logger.Printf("CRASH DETECTED: polecat %s/%s has hook_bead=%s but session %s is dead",
 		rigName, polecatName, info.HookBead, sessionName)
 
 	// Auto-restart the polecat
+	restartErr := ""
 	if err := d.restartPolecatSession(rigName, polecatName, sessionName); err != nil {
 		d.logger.Printf("Error restarting polecat %s/%s: %v", rigName, polecatName, err)
 		// Notify witness as fallback
 		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
+		restartErr = err.Error()
 	} else {
 		d.logger.Printf("Successfully restarted crashed polecat %s/%s", rigName, polecatName)
 	}
+	d.publishEvent(events.TypePolecatCrashed, events.CrashPayload(rigName, polecatName, info.HookBead, restartErr))
+}
+
+// publishEvent records a daemon event, through the bus when it's running.
+func (d *Daemon) publishEvent(eventType string, payload map[string]interface{}) {
+	event := events.Event{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Source:     "daemon",
+		Type:       eventType,
+		Actor:      "daemon",
+		Payload:    payload,
+		Visibility: events.VisibilityFeed,
+	}
+	var err error
+	if d.bus != nil {
+		err = d.bus.Publish(event)
+	} else {
+		err = events.Append(d.config.TownRoot, event)
+	}
+	if err != nil {
+		d.logger.Printf("Warning: failed to record %s event: %v", eventType, err)
+	}
 }
 
 // restartPolecatSession restarts a crashed polecat session.
@@ -862,12 +1050,25 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 		return fmt.Errorf("polecat worktree does not exist: %s", workDir)
 	}
 
+	// Sandboxed rigs never restart polecats unconfined
+	rigPath := filepath.Join(d.config.TownRoot, rigName)
+	sandbox, err := config.LoadRigSandbox(rigPath)
+	if err != nil {
+		return fmt.Errorf("loading sandbox settings: %w", err)
+	}
+	if err := sandbox.Check(); err != nil {
+		return err
+	}
+
 	// Pre-sync workspace (ensure beads are current)
 	d.syncWorkspace(workDir)
 
+	// Resume from the checkpoint rather than starting the work over
+	cp := d.recoveryCheckpoint(rigName, polecatName, workDir)
+
 	// Create new tmux session
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
-	if err := d.tmux.EnsureSessionFresh(sessionName, workDir); err != nil {
+	if err := d.tmux.EnsureSessionFresh(sessionName, workDir, rig.IsRemoteRig(d.config.TownRoot, rigName)); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
 
@@ -891,9 +1092,15 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 	// Set pane-died hook for future crash detection
 	agentID := fmt.Sprintf("%s/%s", rigName, polecatName)
 	_ = d.tmux.SetPaneDiedHook(sessionName, agentID)
+	_ = transcript.Start(d.tmux, d.config.TownRoot, sessionName)
 
 	// Launch Claude with environment exported inline
-	startCmd := config.BuildPolecatStartupCommand(rigName, polecatName, "", "")
+	// Remote rigs run the polecat on their machine behind ssh
+	startCmd, err := rig.RemoteCommandForRig(d.config.TownRoot, rigName, workDir, config.BuildPolecatStartupCommand(rigName, polecatName, rigPath, ""))
+	if err != nil {
+		_ = d.tmux.KillSession(sessionName)
+		return fmt.Errorf("preparing remote session: %w", err)
+	}
 	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
 		return fmt.Errorf("sending startup command: %w", err)
 	}
@@ -905,6 +1112,10 @@ func (d *Daemon) restartPolecatSession(rigName, polecatName, sessionName string)
 	}
 	_ = d.tmux.AcceptBypassPermissionsWarning(sessionName)
 
+	if cp != nil && (cp.HookedBead != "" || cp.CurrentStep != "" || len(cp.ModifiedFiles) > 0) {
+		go d.sendRecoveryPrompt(sessionName, cp)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/wisp"
+)
+
+// rollupDigests records the pending patrol digests whose cadence is due,
+// so a patrol that stopped squashing still leaves its digest, then applies
+// the town's digest retention: finished days, weeks and months are rolled
+// up and aged-out digests pruned.
+func (d *Daemon) rollupDigests() {
+	now := time.Now()
+
+	flushed, err := wisp.FlushPending(d.config.TownRoot, now)
+	if err != nil {
+		d.logger.Printf("Digests: failed to flush pending digests: %v", err)
+	}
+	for _, digest := range flushed {
+		d.logger.Printf("Digests: recorded pending digest for %s (%d cycles)", digest.Agent, digest.Cycles)
+		d.publishEvent(events.TypeDigest, events.DigestPayload(digest.Agent, digest.Period, digest.Molecule, "", digest.Cycles))
+	}
+
+	var retention *config.RetentionConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot)); err == nil {
+		retention = settings.Retention
+	}
+	result, err := wisp.ApplyRetention(d.config.TownRoot, retention, now)
+	if err != nil {
+		d.logger.Printf("Digests: retention failed: %v", err)
+		return
+	}
+	for _, r := range result.Rollups {
+		d.publishEvent(events.TypeDigest, events.DigestPayload(r.Agent, r.Period, "", "", r.Cycles))
+	}
+	if len(result.Rollups) > 0 || result.PrunedDays > 0 || result.PrunedRollups > 0 {
+		d.logger.Printf("Digests: %d rollup(s) created, %d day(s) of digests and %d daily rollup(s) pruned",
+			len(result.Rollups), result.PrunedDays, result.PrunedRollups)
+	}
+}
@@ -0,0 +1,128 @@
+package daemon
+
+import (
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/dog"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// runDogJobs drives the Deacon's dog job queue (town settings "dog_jobs"):
+// attempts left running by a previous daemon are failed (and retried),
+// jobs whose schedule has come due are queued, and queued runs are started
+// on idle dogs within each job's concurrency and the kennel-wide limit.
+// Runs execute in the background and record their result when done.
+func (d *Daemon) runDogJobs() {
+	var cfg *config.DogJobsConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot)); err == nil {
+		cfg = settings.DogJobs
+	}
+	jobs, errs := dog.LoadJobs(cfg)
+	for _, err := range errs {
+		d.logger.Printf("Dog jobs: skipping invalid job: %v", err)
+	}
+
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(d.config.TownRoot))
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to load rigs config: %v", err)
+		return
+	}
+	mgr := dog.NewManager(d.config.TownRoot, rigsConfig)
+	queue := dog.NewQueue(d.config.TownRoot)
+	now := time.Now()
+
+	reaped, err := queue.ReapOrphans(now)
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to reap interrupted runs: %v", err)
+	}
+	for _, run := range reaped {
+		if run.Dog != "" {
+			_ = mgr.ClearWork(run.Dog)
+		}
+		d.recordDogJob(run)
+	}
+
+	queued, err := queue.EnqueueDue(jobs, now)
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to queue due jobs: %v", err)
+		return
+	}
+	for _, run := range queued {
+		d.logger.Printf("Dog jobs: queued %s", run.ID)
+	}
+
+	dogs, err := mgr.List()
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to list dogs: %v", err)
+		return
+	}
+	var idle []string
+	for _, dg := range dogs {
+		if dg.State == dog.StateIdle {
+			idle = append(idle, dg.Name)
+		}
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	limit := 0
+	if cfg != nil {
+		limit = cfg.MaxConcurrent
+	}
+	started, err := queue.Claim(jobs, idle, limit, now)
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to claim queued runs: %v", err)
+		return
+	}
+	for _, run := range started {
+		job := dog.FindJob(jobs, run.Job)
+		if err := mgr.AssignWork(run.Dog, "job:"+run.ID); err != nil {
+			d.logger.Printf("Dog jobs: failed to assign %s to %s: %v", run.ID, run.Dog, err)
+		}
+		d.logger.Printf("Dog jobs: starting %s on %s (attempt %d/%d)", run.ID, run.Dog, run.Attempt, run.MaxAttempts)
+		go d.runDogJob(mgr, queue, job, run)
+	}
+}
+
+// runDogJob runs one attempt of a job and records its result.
+func (d *Daemon) runDogJob(mgr *dog.Manager, queue *dog.Queue, job *dog.Job, run *dog.JobRun) {
+	output, runErr := mgr.RunJob(d.ctx, job, run.Dog)
+	if err := mgr.ClearWork(run.Dog); err != nil {
+		d.logger.Printf("Dog jobs: failed to release %s: %v", run.Dog, err)
+	}
+	rec, err := queue.Finish(run.ID, output, runErr, time.Now())
+	if err != nil {
+		d.logger.Printf("Dog jobs: failed to record %s: %v", run.ID, err)
+		return
+	}
+	d.recordDogJob(rec)
+}
+
+// recordDogJob logs a finished attempt and publishes a dog_job event.
+func (d *Daemon) recordDogJob(rec *dog.JobRun) {
+	status := string(rec.Status)
+	message := rec.Error
+	switch {
+	case rec.Retrying():
+		status = "retrying"
+		message += "; retry at " + rec.NotBefore.Format("15:04")
+	case rec.Status == dog.RunSucceeded:
+		message = firstOutputLine(rec.Output)
+	}
+	d.logger.Printf("Dog jobs: %s %s on %s (attempt %d/%d, %v): %s",
+		rec.ID, status, rec.Dog, rec.Attempt, rec.MaxAttempts, rec.Duration().Round(time.Second), message)
+	d.publishEvent(events.TypeDogJob,
+		events.DogJobPayload(rec.Job, rec.ID, string(rec.Type), rec.Dog, status, rec.Attempt, message))
+}
+
+// firstOutputLine summarizes a job's output by its first line.
+func firstOutputLine(output string) string {
+	if line, _, more := strings.Cut(output, "\n"); more {
+		return line + " …"
+	}
+	return output
+}
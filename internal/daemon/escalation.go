@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/escalation"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/session"
+)
+
+// checkEscalations re-escalates escalations that have missed their ack or
+// resolve SLA (town settings "escalations"). Each is recorded on the
+// escalation first, so a failed nudge isn't retried every heartbeat, then
+// the mayor is nudged and an escalation_breached event is published, which
+// outbound notification routes for escalation_sent deliver again.
+func (d *Daemon) checkEscalations() {
+	store := escalation.NewStore(d.config.TownRoot)
+	list, err := store.List()
+	if err != nil {
+		d.logger.Printf("Escalations: failed to read escalations: %v", err)
+		return
+	}
+	if len(list) == 0 {
+		return
+	}
+
+	var cfg *config.EscalationConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(d.config.TownRoot)); err == nil {
+		cfg = settings.Escalations
+	}
+
+	now := time.Now()
+	for _, listed := range list {
+		if escalation.Due(listed, cfg, now) == "" {
+			continue
+		}
+
+		// Re-check under the store lock: the escalation may have been
+		// acknowledged since it was listed.
+		var breach string
+		esc, err := store.Update(listed.ID, func(e *escalation.Escalation) error {
+			breach = escalation.Due(e, cfg, now)
+			if breach == "" {
+				return errNotDue
+			}
+			e.MarkRenotified(breach, now)
+			return nil
+		})
+		if errors.Is(err, errNotDue) {
+			continue
+		}
+		if err != nil {
+			d.logger.Printf("Escalations: failed to record re-escalation: %v", err)
+			continue
+		}
+
+		age := now.Sub(esc.CreatedAt).Round(time.Minute)
+		what := "unacknowledged"
+		command := "gt escalate ack " + esc.ID
+		if breach == escalation.BreachResolve {
+			what = "unresolved"
+			command = "gt escalate resolve " + esc.ID
+		}
+		d.logger.Printf("Escalations: %s [%s] %s for %v, re-escalating (#%d): %s",
+			esc.ID, esc.Severity, what, age, esc.Renotified, esc.Topic)
+
+		d.nudgeMayorOfEscalation(esc, what, age, command)
+		detail := fmt.Sprintf("%s %s for %v (re-escalation #%d, raised by %s).\nRun: %s",
+			esc.ID, what, age, esc.Renotified, esc.From, command)
+		d.publishEvent(events.TypeEscalationBreached,
+			events.EscalationBreachedPayload(esc.ID, esc.Rig, esc.Severity, esc.Topic, breach, detail))
+	}
+}
+
+// errNotDue stops an escalation update that lost a race with an ack.
+var errNotDue = errors.New("escalation no longer due")
+
+// nudgeMayorOfEscalation nudges the mayor's session about an escalation
+// past its SLA, if the mayor is running.
+func (d *Daemon) nudgeMayorOfEscalation(esc *escalation.Escalation, what string, age time.Duration, command string) {
+	mayor := session.MayorSessionName()
+	if alive, err := d.tmux.HasSession(mayor); err != nil || !alive {
+		return
+	}
+	msg := fmt.Sprintf("ESCALATION_SLA: [%s] %s %s for %v: %s. Get the overseer's attention, then run: %s",
+		esc.Severity, esc.ID, what, age, esc.Topic, command)
+	if err := d.tmux.NudgeSession(mayor, msg); err != nil {
+		d.logger.Printf("Escalations: failed to nudge mayor: %v", err)
+	}
+}
@@ -340,9 +340,16 @@ func (d *Daemon) restartSession(sessionName, identity string) error {
 		d.syncWorkspace(workDir)
 	}
 
+	// Resolve the startup command first: polecats in sandboxed rigs must
+	// not be started at all if the sandbox can't be set up
+	startCmd, err := d.getStartCommand(config, parsed)
+	if err != nil {
+		return err
+	}
+
 	// Create session
 	// Use EnsureSessionFresh to handle zombie sessions that exist but have dead Claude
-	if err := d.tmux.EnsureSessionFresh(sessionName, workDir); err != nil {
+	if err := d.tmux.EnsureSessionFresh(sessionName, workDir, rig.IsRemoteRig(d.config.TownRoot, parsed.RigName)); err != nil {
 		return fmt.Errorf("creating session: %w", err)
 	}
 
@@ -352,8 +359,16 @@ func (d *Daemon) restartSession(sessionName, identity string) error {
 	// Apply theme (non-fatal: theming failure doesn't affect operation)
 	d.applySessionTheme(sessionName, parsed)
 
-	// Get and send startup command
-	startCmd := d.getStartCommand(config, parsed)
+	// Send startup command
+	switch parsed.RoleType {
+	case "witness", "refinery", "polecat":
+		// Remote rigs run these agents on their machine behind ssh
+		startCmd, err = rig.RemoteCommandForRig(d.config.TownRoot, parsed.RigName, workDir, startCmd)
+		if err != nil {
+			_ = d.tmux.KillSession(sessionName)
+			return fmt.Errorf("preparing remote session: %w", err)
+		}
+	}
 	if err := d.tmux.SendKeys(sessionName, startCmd); err != nil {
 		return fmt.Errorf("sending startup command: %w", err)
 	}
@@ -417,22 +432,33 @@ func (d *Daemon) getNeedsPreSync(config *beads.RoleConfig, parsed *ParsedIdentit
 
 // getStartCommand determines the startup command for an agent.
 // Uses role bead config if available, falls back to hardcoded defaults.
-func (d *Daemon) getStartCommand(roleConfig *beads.RoleConfig, parsed *ParsedIdentity) string {
+// Polecats of a rig whose sandbox settings can't be read, or whose sandbox
+// can't run here, get an error rather than an unconfined command.
+func (d *Daemon) getStartCommand(roleConfig *beads.RoleConfig, parsed *ParsedIdentity) (string, error) {
+	rigPath := filepath.Join(d.config.TownRoot, parsed.RigName)
+	if parsed.RoleType == "polecat" {
+		sandbox, err := config.LoadRigSandbox(rigPath)
+		if err != nil {
+			return "", fmt.Errorf("loading sandbox settings: %w", err)
+		}
+		if err := sandbox.Check(); err != nil {
+			return "", err
+		}
+	}
+
 	// If role bead has explicit config, use it
 	if roleConfig != nil && roleConfig.StartCommand != "" {
 		// Expand any patterns in the command
-		return beads.ExpandRolePattern(roleConfig.StartCommand, d.config.TownRoot, parsed.RigName, parsed.AgentName, parsed.RoleType)
+		return beads.ExpandRolePattern(roleConfig.StartCommand, d.config.TownRoot, parsed.RigName, parsed.AgentName, parsed.RoleType), nil
 	}
 
-	// Default command for all agents - use runtime config
-	defaultCmd := "exec " + config.GetRuntimeCommand("")
-
 	// Polecats need environment variables set in the command
 	if parsed.RoleType == "polecat" {
-		return config.BuildPolecatStartupCommand(parsed.RigName, parsed.AgentName, "", "")
+		return config.BuildPolecatStartupCommand(parsed.RigName, parsed.AgentName, rigPath, ""), nil
 	}
 
-	return defaultCmd
+	// Default command for all agents - use runtime config
+	return "exec " + config.GetRuntimeCommand(""), nil
 }
 
 // setSessionEnvironment sets environment variables for the tmux session.
@@ -544,35 +570,23 @@ func (d *Daemon) getAgentBeadState(agentBeadID string) (string, error) {
 	return info.State, nil
 }
 
+// beadsStore returns the store used for agent bead reads. The shared store
+// caches the town database in-process, so the heartbeat's per-agent lookups
+// don't each spawn bd.
+func (d *Daemon) beadsStore() beads.Store {
+	if d.store != nil {
+		return d.store
+	}
+	return beads.SharedStore(d.config.TownRoot)
+}
+
 // getAgentBeadInfo fetches and parses an agent bead by ID.
 func (d *Daemon) getAgentBeadInfo(agentBeadID string) (*AgentBeadInfo, error) {
-	cmd := exec.Command("bd", "show", agentBeadID, "--json")
-	cmd.Dir = d.config.TownRoot
-
-	output, err := cmd.Output()
+	issue, err := d.beadsStore().Show(agentBeadID)
 	if err != nil {
-		return nil, fmt.Errorf("bd show %s: %w", agentBeadID, err)
+		return nil, fmt.Errorf("showing agent bead %s: %w", agentBeadID, err)
 	}
 
-	// bd show --json returns an array with one element
-	var issues []struct {
-		ID          string `json:"id"`
-		Type        string `json:"issue_type"`
-		Description string `json:"description"`
-		UpdatedAt   string `json:"updated_at"`
-		HookBead    string `json:"hook_bead"`    // Read from database column
-		AgentState  string `json:"agent_state"` // Read from database column
-	}
-
-	if err := json.Unmarshal(output, &issues); err != nil {
-		return nil, fmt.Errorf("parsing bd show output: %w", err)
-	}
-
-	if len(issues) == 0 {
-		return nil, fmt.Errorf("agent bead not found: %s", agentBeadID)
-	}
-
-	issue := issues[0]
 	if issue.Type != "agent" {
 		return nil, fmt.Errorf("bead %s is not an agent bead (type=%s)", agentBeadID, issue.Type)
 	}
@@ -770,25 +784,9 @@ func (d *Daemon) checkGUPPViolations() {
 func (d *Daemon) checkRigGUPPViolations(rigName string) {
 	// List polecat agent beads for this rig
 	// Pattern: gt-polecat-<rig>-<name>
-	cmd := exec.Command("bd", "list", "--type=agent", "--json")
-	cmd.Dir = d.config.TownRoot
-
-	output, err := cmd.Output()
+	agents, err := d.beadsStore().ListAgentBeads()
 	if err != nil {
-		return // Silently fail - bd might not be available
-	}
-
-	var agents []struct {
-		ID          string `json:"id"`
-		Type        string `json:"issue_type"`
-		Description string `json:"description"`
-		UpdatedAt   string `json:"updated_at"`
-		HookBead    string `json:"hook_bead"` // Read from database column, not description
-		AgentState  string `json:"agent_state"`
-	}
-
-	if err := json.Unmarshal(output, &agents); err != nil {
-		return
+		return // Silently fail - beads might not be available
 	}
 
 	prefix := "gt-polecat-" + rigName + "-"
@@ -881,25 +879,11 @@ type deadAgentInfo struct {
 
 // getDeadAgents returns all agent beads with state=dead.
 func (d *Daemon) getDeadAgents() []deadAgentInfo {
-	cmd := exec.Command("bd", "list", "--type=agent", "--json")
-	cmd.Dir = d.config.TownRoot
-
-	output, err := cmd.Output()
+	agents, err := d.beadsStore().ListAgentBeads()
 	if err != nil {
 		return nil
 	}
 
-	var agents []struct {
-		ID         string `json:"id"`
-		Type       string `json:"issue_type"`
-		HookBead   string `json:"hook_bead"`    // Read from database column
-		AgentState string `json:"agent_state"` // Read from database column
-	}
-
-	if err := json.Unmarshal(output, &agents); err != nil {
-		return nil
-	}
-
 	var dead []deadAgentInfo
 	for _, agent := range agents {
 		if agent.AgentState == "dead" {
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
 // testDaemon creates a minimal Daemon for testing.
@@ -252,3 +254,60 @@ func TestBeadsMessage_Serialization(t *testing.T) {
 		t.Errorf("From mismatch")
 	}
 }
+
+func TestGetDeadAgents_ReadsBeadsStore(t *testing.T) {
+	d, _ := testDaemonWithTown(t, "test-town")
+	beadsDir := filepath.Join(d.config.TownRoot, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	jsonl := `{"id":"gt-polecat-gastown-max","issue_type":"agent","status":"open","agent_state":"dead","hook_bead":"gt-123"}
+{"id":"gt-polecat-gastown-nux","issue_type":"agent","status":"open","agent_state":"working","hook_bead":"gt-456"}
+{"id":"gt-123","issue_type":"task","status":"open","agent_state":"dead"}
+`
+	if err := os.WriteFile(filepath.Join(beadsDir, "issues.jsonl"), []byte(jsonl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d.store = beads.NewCachedStore(d.config.TownRoot)
+
+	dead := d.getDeadAgents()
+	if len(dead) != 1 || dead[0].ID != "gt-polecat-gastown-max" || dead[0].HookBead != "gt-123" {
+		t.Errorf("getDeadAgents = %+v", dead)
+	}
+
+	info, err := d.getAgentBeadInfo("gt-polecat-gastown-nux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.HookBead != "gt-456" {
+		t.Errorf("HookBead = %q, want gt-456", info.HookBead)
+	}
+	if _, err := d.getAgentBeadInfo("gt-123"); err == nil {
+		t.Error("expected error for non-agent bead")
+	}
+}
+
+func TestGetStartCommand_PolecatRefusesUnreadableSandbox(t *testing.T) {
+	d, cleanup := testDaemonWithTown(t, "town")
+	defer cleanup()
+	parsed := &ParsedIdentity{RoleType: "polecat", RigName: "gastown", AgentName: "Toast"}
+
+	if _, err := d.getStartCommand(nil, parsed); err != nil {
+		t.Fatalf("rig without settings: %v", err)
+	}
+
+	settingsDir := filepath.Join(d.config.TownRoot, "gastown", "settings")
+	if err := os.MkdirAll(settingsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(settingsDir, "config.json"), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cmd, err := d.getStartCommand(nil, parsed); err == nil {
+		t.Errorf("unreadable settings: got %q, want an error", cmd)
+	}
+	// Other roles don't run in the sandbox
+	if _, err := d.getStartCommand(nil, &ParsedIdentity{RoleType: "witness", RigName: "gastown"}); err != nil {
+		t.Errorf("witness: %v", err)
+	}
+}
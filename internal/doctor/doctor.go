@@ -1,14 +1,41 @@
 package doctor
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Check categories, used to select checks with --only and --skip and to
+// group them in reports. Plugin checks choose their own category and
+// default to CategoryPlugins.
+const (
+	CategoryWorkspace      = "workspace"
+	CategoryInfrastructure = "infrastructure"
+	CategoryBeads          = "beads"
+	CategoryCleanup        = "cleanup"
+	CategoryGit            = "git"
+	CategorySessions       = "sessions"
+	CategoryPatrol         = "patrol"
+	CategoryConfig         = "config"
+	CategoryCrew           = "crew"
+	CategoryHooks          = "hooks"
+	CategoryRig            = "rig"
+	CategoryPlugins        = "plugins"
+)
+
 // Doctor manages and executes health checks.
 type Doctor struct {
-	checks []Check
+	checks     []Check
+	categories map[Check]string
 }
 
 // NewDoctor creates a new Doctor with no registered checks.
 func NewDoctor() *Doctor {
 	return &Doctor{
-		checks: make([]Check, 0),
+		checks:     make([]Check, 0),
+		categories: make(map[Check]string),
 	}
 }
 
@@ -22,22 +49,109 @@ func (d *Doctor) RegisterAll(checks ...Check) {
 	d.checks = append(d.checks, checks...)
 }
 
+// RegisterIn adds checks to the doctor's check list under a category.
+func (d *Doctor) RegisterIn(category string, checks ...Check) {
+	for _, check := range checks {
+		d.categories[check] = category
+	}
+	d.checks = append(d.checks, checks...)
+}
+
+// RegisterPlugins adds plugin checks under the categories they declare.
+func (d *Doctor) RegisterPlugins(checks ...Check) {
+	for _, check := range checks {
+		category := CategoryPlugins
+		if c, ok := check.(interface{ Category() string }); ok {
+			category = c.Category()
+		}
+		d.RegisterIn(category, check)
+	}
+}
+
 // Checks returns the list of registered checks.
 func (d *Doctor) Checks() []Check {
 	return d.checks
 }
 
+// Category returns the category a check was registered in, or "".
+func (d *Doctor) Category(check Check) string {
+	return d.categories[check]
+}
+
+// Categories returns the categories of the registered checks, sorted.
+func (d *Doctor) Categories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, check := range d.checks {
+		if c := d.categories[check]; c != "" && !seen[c] {
+			seen[c] = true
+			categories = append(categories, c)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// Filter narrows the registered checks. Each selector names a category or
+// a check. With only, just the selected checks are kept; checks selected
+// by skip are then dropped. A selector matching nothing is an error, so a
+// typo doesn't silently run (or skip) everything.
+func (d *Doctor) Filter(only, skip []string) error {
+	matches := func(check Check, selector string) bool {
+		return selector == check.Name() || selector == d.categories[check]
+	}
+	for _, selector := range append(append([]string{}, only...), skip...) {
+		found := false
+		for _, check := range d.checks {
+			if matches(check, selector) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no check or category named %q (categories: %s)", selector, strings.Join(d.Categories(), ", "))
+		}
+	}
+
+	kept := d.checks[:0]
+	for _, check := range d.checks {
+		keep := len(only) == 0
+		for _, selector := range only {
+			keep = keep || matches(check, selector)
+		}
+		for _, selector := range skip {
+			keep = keep && !matches(check, selector)
+		}
+		if keep {
+			kept = append(kept, check)
+		}
+	}
+	d.checks = kept
+	return nil
+}
+
+// run executes one check, filling in the result's name, category and
+// duration.
+func (d *Doctor) run(check Check, ctx *CheckContext) *CheckResult {
+	start := time.Now()
+	result := check.Run(ctx)
+	// Ensure check name is populated
+	if result.Name == "" {
+		result.Name = check.Name()
+	}
+	if result.Category == "" {
+		result.Category = d.categories[check]
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
 // Run executes all registered checks and returns a report.
 func (d *Doctor) Run(ctx *CheckContext) *Report {
 	report := NewReport()
 
 	for _, check := range d.checks {
-		result := check.Run(ctx)
-		// Ensure check name is populated
-		if result.Name == "" {
-			result.Name = check.Name()
-		}
-		report.Add(result)
+		report.Add(d.run(check, ctx))
 	}
 
 	return report
@@ -49,20 +163,14 @@ func (d *Doctor) Fix(ctx *CheckContext) *Report {
 	report := NewReport()
 
 	for _, check := range d.checks {
-		result := check.Run(ctx)
-		if result.Name == "" {
-			result.Name = check.Name()
-		}
+		result := d.run(check, ctx)
 
 		// Attempt fix if check failed and is fixable
 		if result.Status != StatusOK && check.CanFix() {
 			err := check.Fix(ctx)
 			if err == nil {
 				// Re-run check to verify fix worked
-				result = check.Run(ctx)
-				if result.Name == "" {
-					result.Name = check.Name()
-				}
+				result = d.run(check, ctx)
 				// Update message to indicate fix was applied
 				if result.Status == StatusOK {
 					result.Message = result.Message + " (fixed)"
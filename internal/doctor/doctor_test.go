@@ -2,6 +2,8 @@ package doctor
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -358,3 +360,102 @@ func TestFixableCheck(t *testing.T) {
 		t.Error("FixableCheck.CanFix() should return true")
 	}
 }
+
+func TestDoctor_Filter(t *testing.T) {
+	newDoctor := func() *Doctor {
+		d := NewDoctor()
+		d.RegisterIn(CategoryPatrol, newMockCheck("patrol-a", StatusOK), newMockCheck("patrol-b", StatusOK))
+		d.RegisterIn(CategoryHooks, newMockCheck("hook-a", StatusOK))
+		return d
+	}
+	names := func(d *Doctor) []string {
+		var got []string
+		for _, c := range d.Checks() {
+			got = append(got, c.Name())
+		}
+		return got
+	}
+
+	d := newDoctor()
+	if err := d.Filter([]string{CategoryPatrol, "hook-a"}, []string{"patrol-b"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(d); len(got) != 2 || got[0] != "patrol-a" || got[1] != "hook-a" {
+		t.Errorf("only patrol,hook-a skip patrol-b = %v", got)
+	}
+
+	d = newDoctor()
+	_ = d.Filter(nil, []string{CategoryHooks})
+	if got := names(d); len(got) != 2 {
+		t.Errorf("skip hooks = %v", got)
+	}
+
+	if err := newDoctor().Filter([]string{"patorl"}, nil); err == nil || !strings.Contains(err.Error(), "hooks, patrol") {
+		t.Errorf("unknown selector error = %v", err)
+	}
+
+	report := newDoctor().Run(&CheckContext{})
+	if report.Checks[2].Category != CategoryHooks {
+		t.Errorf("result category = %q", report.Checks[2].Category)
+	}
+}
+
+func TestReport_WriteJSONAndJUnit(t *testing.T) {
+	report := NewReport()
+	report.Add(&CheckResult{Name: "daemon", Category: CategoryInfrastructure, Status: StatusOK, Message: "running"})
+	report.Add(&CheckResult{Name: "patrol-not-stuck", Category: CategoryPatrol, Status: StatusWarning, Message: "1 stale wisp"})
+	report.Add(&CheckResult{Name: "hook-singleton", Category: CategoryHooks, Status: StatusError, Message: "2 hooks", Details: []string{"gt-1", "gt-2"}})
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Checks []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"checks"`
+		Summary ReportSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Checks[1].Status != "warning" || decoded.Summary.Errors != 1 {
+		t.Errorf("JSON report = %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := report.WriteJUnit(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<testsuites name="gt doctor" tests="3" failures="1"`,
+		`<testsuite name="doctor.hooks" tests="1" failures="1"`,
+		`<failure message="2 hooks" type="error">2 hooks`,
+		`<system-out>warning: 1 stale wisp</system-out>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("JUnit missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	run := func(statuses ...CheckStatus) *Report {
+		r := NewReport()
+		for i, s := range statuses {
+			r.Add(&CheckResult{Name: string(rune('a' + i)), Status: s})
+		}
+		return r
+	}
+
+	first := run(StatusOK, StatusWarning)
+	if got := Changes(nil, first); len(got) != 1 || got[0].Check != "b" || got[0].From != nil {
+		t.Errorf("first run changes = %+v", got)
+	}
+	got := Changes(first, run(StatusError, StatusWarning, StatusOK))
+	if len(got) != 1 || got[0].Check != "a" || *got[0].From != StatusOK || got[0].To != StatusError {
+		t.Errorf("changes = %+v", got)
+	}
+}
@@ -0,0 +1,307 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultPluginTimeout bounds a plugin check's command when it sets none.
+const DefaultPluginTimeout = 30 * time.Second
+
+// maxPluginOutputLines caps the command output shown for a failed check.
+const maxPluginOutputLines = 20
+
+// PluginSpec is a declarative check from a settings/doctor.d/*.toml file:
+//
+//	[[check]]
+//	name = "go-toolchain"
+//	description = "Go 1.24 or newer is installed"
+//	category = "toolchain"
+//	command = "go version"
+//	expect_output = 'go1\.(2[4-9]|[3-9][0-9])'
+//	severity = "warning"
+//	fix_hint = "Install Go 1.24: https://go.dev/dl/"
+//
+// The command runs with sh -c in dir (relative to the town or rig root
+// the file belongs to). It passes when it exits with expect_exit (default
+// 0), its combined output matches expect_output and doesn't match
+// reject_output. 'gt doctor --fix' runs fix, then re-runs the check.
+type PluginSpec struct {
+	Name         string `toml:"name"`
+	Description  string `toml:"description"`
+	Category     string `toml:"category"`      // default: plugins
+	Command      string `toml:"command"`       // required
+	Dir          string `toml:"dir"`           // default: the town or rig root
+	Timeout      string `toml:"timeout"`       // default: 30s
+	ExpectExit   *int   `toml:"expect_exit"`   // default: 0
+	ExpectOutput string `toml:"expect_output"` // regexp the output must match
+	RejectOutput string `toml:"reject_output"` // regexp the output must not match
+	Severity     string `toml:"severity"`      // "error" (default) or "warning"
+	Fix          string `toml:"fix"`           // command run by --fix
+	FixHint      string `toml:"fix_hint"`
+}
+
+// pluginFile is the TOML structure of a doctor.d file.
+type pluginFile struct {
+	Check []PluginSpec `toml:"check"`
+}
+
+// PluginDir returns the doctor.d directory of a town or rig.
+func PluginDir(root string) string {
+	return filepath.Join(root, "settings", "doctor.d")
+}
+
+// PluginCheck runs a command declared in doctor.d.
+type PluginCheck struct {
+	spec    PluginSpec
+	name    string
+	root    string // town or rig root the spec belongs to
+	rig     string // rig name, for rig plugins
+	timeout time.Duration
+	expect  *regexp.Regexp
+	reject  *regexp.Regexp
+}
+
+// NewPluginCheck validates a spec and returns its check. root is the town
+// or rig directory whose doctor.d declared it; rig names the rig, if any,
+// and prefixes the check name ("<rig>/<name>").
+func NewPluginCheck(spec PluginSpec, root, rig string) (*PluginCheck, error) {
+	if spec.Name == "" {
+		return nil, errors.New("check has no name")
+	}
+	if strings.TrimSpace(spec.Command) == "" {
+		return nil, fmt.Errorf("check %q has no command", spec.Name)
+	}
+	c := &PluginCheck{spec: spec, name: spec.Name, root: root, rig: rig, timeout: DefaultPluginTimeout}
+	if rig != "" {
+		c.name = rig + "/" + spec.Name
+	}
+	if spec.Category == "" {
+		c.spec.Category = CategoryPlugins
+	}
+	switch spec.Severity {
+	case "", "error", "warning":
+	default:
+		return nil, fmt.Errorf("check %q: severity must be \"error\" or \"warning\", got %q", spec.Name, spec.Severity)
+	}
+	if spec.Timeout != "" {
+		d, err := time.ParseDuration(spec.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("check %q: invalid timeout %q", spec.Name, spec.Timeout)
+		}
+		c.timeout = d
+	}
+	var err error
+	if spec.ExpectOutput != "" {
+		if c.expect, err = regexp.Compile(spec.ExpectOutput); err != nil {
+			return nil, fmt.Errorf("check %q: expect_output: %w", spec.Name, err)
+		}
+	}
+	if spec.RejectOutput != "" {
+		if c.reject, err = regexp.Compile(spec.RejectOutput); err != nil {
+			return nil, fmt.Errorf("check %q: reject_output: %w", spec.Name, err)
+		}
+	}
+	return c, nil
+}
+
+// Name returns the check name.
+func (c *PluginCheck) Name() string {
+	return c.name
+}
+
+// Description returns the check description.
+func (c *PluginCheck) Description() string {
+	if c.spec.Description != "" {
+		return c.spec.Description
+	}
+	return c.spec.Command
+}
+
+// Category returns the check's category.
+func (c *PluginCheck) Category() string {
+	return c.spec.Category
+}
+
+// CanFix reports whether the check declares a fix command.
+func (c *PluginCheck) CanFix() bool {
+	return c.spec.Fix != ""
+}
+
+// runCommand runs a shell command for the check, bounded by its timeout.
+func (c *PluginCheck) runCommand(ctx *CheckContext, command string) (string, int, error) {
+	runCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, "sh", "-c", command) //nolint:gosec // G204: commands come from the town's own doctor.d
+	cmd.Dir = c.root
+	if c.spec.Dir != "" {
+		cmd.Dir = filepath.Join(c.root, c.spec.Dir)
+	}
+	cmd.Env = append(os.Environ(), "GT_TOWN_ROOT="+ctx.TownRoot)
+	if c.rig != "" {
+		cmd.Env = append(cmd.Env, "GT_RIG="+c.rig)
+	}
+	out, err := cmd.CombinedOutput()
+	if runCtx.Err() == context.DeadlineExceeded {
+		return string(out), -1, fmt.Errorf("timed out after %v", c.timeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return string(out), -1, err
+	}
+	return string(out), 0, nil
+}
+
+// Run executes the command and compares its exit code and output.
+func (c *PluginCheck) Run(ctx *CheckContext) *CheckResult {
+	result := &CheckResult{Name: c.name, Category: c.spec.Category, FixHint: c.spec.FixHint}
+	failed := StatusError
+	if c.spec.Severity == "warning" {
+		failed = StatusWarning
+	}
+
+	out, code, err := c.runCommand(ctx, c.spec.Command)
+	want := 0
+	if c.spec.ExpectExit != nil {
+		want = *c.spec.ExpectExit
+	}
+	switch {
+	case err != nil:
+		result.Status = failed
+		result.Message = fmt.Sprintf("command failed: %v", err)
+	case code != want:
+		result.Status = failed
+		result.Message = fmt.Sprintf("exited %d, expected %d", code, want)
+	case c.expect != nil && !c.expect.MatchString(out):
+		result.Status = failed
+		result.Message = fmt.Sprintf("output does not match %q", c.spec.ExpectOutput)
+	case c.reject != nil && c.reject.MatchString(out):
+		result.Status = failed
+		result.Message = fmt.Sprintf("output matches %q", c.spec.RejectOutput)
+	default:
+		result.Status = StatusOK
+		result.Message = c.Description()
+		if line := firstLine(out); line != "" {
+			result.Details = []string{line}
+		}
+		return result
+	}
+	result.Details = tailLines(out, maxPluginOutputLines)
+	return result
+}
+
+// Fix runs the declared fix command.
+func (c *PluginCheck) Fix(ctx *CheckContext) error {
+	if c.spec.Fix == "" {
+		return ErrCannotFix
+	}
+	out, code, err := c.runCommand(ctx, c.spec.Fix)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("fix exited %d: %s", code, strings.Join(tailLines(out, 3), "; "))
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// tailLines returns the last n non-empty lines of s.
+func tailLines(s string, n int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(s, "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+// pluginConfigCheck reports a doctor.d file that couldn't be loaded, so a
+// broken plugin fails the run instead of silently disappearing.
+type pluginConfigCheck struct {
+	BaseCheck
+	err error
+}
+
+// Category returns the plugins category.
+func (c *pluginConfigCheck) Category() string {
+	return CategoryPlugins
+}
+
+func (c *pluginConfigCheck) Run(ctx *CheckContext) *CheckResult {
+	return &CheckResult{
+		Name:     c.CheckName,
+		Category: CategoryPlugins,
+		Status:   StatusError,
+		Message:  c.err.Error(),
+		FixHint:  "Fix the check definition in " + c.CheckDescription,
+	}
+}
+
+// LoadPlugins returns the checks declared in root's settings/doctor.d/*.toml,
+// in file order. rig is the rig name for a rig's doctor.d, "" for the
+// town's. A file that fails to parse, or a check that fails validation,
+// becomes a check that reports the problem as an error.
+func LoadPlugins(root, rig string) []Check {
+	dir := PluginDir(root)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".toml") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var checks []Check
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		configName := "doctor.d/" + name
+		if rig != "" {
+			configName = rig + "/" + configName
+		}
+		broken := func(err error) Check {
+			return &pluginConfigCheck{BaseCheck: BaseCheck{CheckName: configName, CheckDescription: path}, err: err}
+		}
+
+		var file pluginFile
+		if _, err := toml.DecodeFile(path, &file); err != nil {
+			checks = append(checks, broken(err))
+			continue
+		}
+		for _, spec := range file.Check {
+			check, err := NewPluginCheck(spec, root, rig)
+			if err != nil {
+				checks = append(checks, broken(err))
+				continue
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
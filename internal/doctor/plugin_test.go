@@ -0,0 +1,87 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPlugins(t *testing.T) {
+	rig := t.TempDir()
+	dir := PluginDir(rig)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("10-checks.toml", `
+[[check]]
+name = "rig-env"
+category = "toolchain"
+command = "echo rig=$GT_RIG"
+expect_output = "rig=gastown"
+
+[[check]]
+name = "marker"
+description = "Marker file exists"
+command = "test -f marker"
+severity = "warning"
+fix = "touch marker"
+
+[[check]]
+name = "no-todo"
+command = "printf 'TODO: fix\n'; exit 3"
+expect_exit = 3
+reject_output = "TODO"
+
+[[check]]
+name = "bad-regexp"
+command = "true"
+expect_output = "("
+`)
+	writeFile("20-broken.toml", "[[check]\nname = ")
+	writeFile("README.md", "not a plugin")
+
+	checks := LoadPlugins(rig, "gastown")
+	if len(checks) != 5 {
+		t.Fatalf("loaded %d checks, want 5", len(checks))
+	}
+	ctx := &CheckContext{TownRoot: rig}
+
+	results := make(map[string]*CheckResult)
+	for _, c := range checks {
+		results[c.Name()] = c.Run(ctx)
+	}
+	if r := results["gastown/rig-env"]; r.Status != StatusOK || r.Category != "toolchain" {
+		t.Errorf("rig-env = %+v", r)
+	}
+	if r := results["gastown/marker"]; r.Status != StatusWarning || !strings.Contains(r.Message, "exited 1") {
+		t.Errorf("marker = %+v", r)
+	}
+	if r := results["gastown/no-todo"]; r.Status != StatusError || !strings.Contains(r.Message, "TODO") || r.Details[0] != "TODO: fix" {
+		t.Errorf("no-todo = %+v", r)
+	}
+	for _, name := range []string{"gastown/doctor.d/10-checks.toml", "gastown/doctor.d/20-broken.toml"} {
+		if r := results[name]; r == nil || r.Status != StatusError {
+			t.Errorf("%s = %+v", name, r)
+		}
+	}
+
+	// --fix runs the fix command and re-runs the check.
+	d := NewDoctor()
+	d.RegisterPlugins(checks...)
+	if err := d.Filter([]string{"gastown/marker"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	report := d.Fix(ctx)
+	if r := report.Checks[0]; r.Status != StatusOK || !strings.HasSuffix(r.Message, "(fixed)") || r.Category != CategoryPlugins {
+		t.Errorf("fixed marker = %+v", r)
+	}
+	if _, err := os.Stat(filepath.Join(rig, "marker")); err != nil {
+		t.Errorf("fix didn't run in the rig root: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package doctor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// JUnit XML structure, as read by CI systems.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, one test suite per category.
+// Errors are failures; warnings pass, with the warning in system-out, so
+// CI surfaces them without failing the build.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	root := junitTestSuites{Name: "gt doctor"}
+	suites := make(map[string]int)
+	var seconds []float64 // per suite
+	var total float64
+
+	for _, check := range r.Checks {
+		category := check.Category
+		if category == "" {
+			category = "other"
+		}
+		i, ok := suites[category]
+		if !ok {
+			i = len(root.Suites)
+			suites[category] = i
+			seconds = append(seconds, 0)
+			root.Suites = append(root.Suites, junitTestSuite{
+				Name:      "doctor." + category,
+				Timestamp: r.Timestamp.Format("2006-01-02T15:04:05"),
+			})
+		}
+		suite := &root.Suites[i]
+
+		took := check.Duration.Seconds()
+		tc := junitTestCase{
+			Name:      check.Name,
+			ClassName: "doctor." + category,
+			Time:      fmt.Sprintf("%.3f", took),
+		}
+		text := check.Message
+		if len(check.Details) > 0 {
+			text += "\n" + strings.Join(check.Details, "\n")
+		}
+		if check.FixHint != "" {
+			text += "\nFix: " + check.FixHint
+		}
+		switch check.Status {
+		case StatusError:
+			tc.Failure = &junitFailure{Message: check.Message, Type: "error", Text: text}
+			suite.Failures++
+			root.Failures++
+		case StatusWarning:
+			tc.SystemOut = "warning: " + text
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		root.Tests++
+		seconds[i] += took
+		total += took
+	}
+
+	for i := range root.Suites {
+		root.Suites[i].Time = fmt.Sprintf("%.3f", seconds[i])
+	}
+	root.Time = fmt.Sprintf("%.3f", total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -22,6 +22,11 @@ const (
 	StatusError
 )
 
+// MarshalText encodes a status as "ok", "warning" or "error".
+func (s CheckStatus) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
 // String returns a human-readable status.
 func (s CheckStatus) String() string {
 	switch s {
@@ -54,11 +59,13 @@ func (ctx *CheckContext) RigPath() string {
 
 // CheckResult represents the outcome of a health check.
 type CheckResult struct {
-	Name    string      // Check name
-	Status  CheckStatus // Result status
-	Message string      // Primary result message
-	Details []string    // Additional information
-	FixHint string      // Suggestion if not auto-fixable
+	Name     string        `json:"name"`               // Check name
+	Category string        `json:"category,omitempty"` // Category the check was registered in
+	Status   CheckStatus   `json:"status"`             // Result status
+	Message  string        `json:"message"`            // Primary result message
+	Details  []string      `json:"details,omitempty"`  // Additional information
+	FixHint  string        `json:"fix_hint,omitempty"` // Suggestion if not auto-fixable
+	Duration time.Duration `json:"duration_ns"`        // How long the check took
 }
 
 // Check defines the interface for a health check.
@@ -82,17 +89,17 @@ type Check interface {
 
 // ReportSummary summarizes the results of all checks.
 type ReportSummary struct {
-	Total    int
-	OK       int
-	Warnings int
-	Errors   int
+	Total    int `json:"total"`
+	OK       int `json:"ok"`
+	Warnings int `json:"warnings"`
+	Errors   int `json:"errors"`
 }
 
 // Report contains all check results and a summary.
 type Report struct {
-	Timestamp time.Time
-	Checks    []*CheckResult
-	Summary   ReportSummary
+	Timestamp time.Time      `json:"timestamp"`
+	Checks    []*CheckResult `json:"checks"`
+	Summary   ReportSummary  `json:"summary"`
 }
 
 // NewReport creates an empty report with the current timestamp.
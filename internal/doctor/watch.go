@@ -0,0 +1,40 @@
+package doctor
+
+// StatusChange is a check whose status differs between two runs.
+type StatusChange struct {
+	Check    string       `json:"check"`
+	Category string       `json:"category,omitempty"`
+	From     *CheckStatus `json:"from"` // nil when the check wasn't in the previous run
+	To       CheckStatus  `json:"to"`
+	Result   *CheckResult `json:"result"`
+}
+
+// Changes compares a run with the previous one (nil for the first run)
+// and returns the checks whose status changed, in report order. On the
+// first run, and for checks new since the previous one, only failing
+// checks count as changes.
+func Changes(prev, cur *Report) []StatusChange {
+	before := make(map[string]CheckStatus)
+	if prev != nil {
+		for _, r := range prev.Checks {
+			before[r.Name] = r.Status
+		}
+	}
+
+	var changes []StatusChange
+	for _, r := range cur.Checks {
+		old, seen := before[r.Name]
+		switch {
+		case seen && old == r.Status:
+			continue
+		case !seen && r.Status == StatusOK:
+			continue
+		}
+		change := StatusChange{Check: r.Name, Category: r.Category, To: r.Status, Result: r}
+		if seen {
+			change.From = &old
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
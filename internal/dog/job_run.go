@@ -0,0 +1,233 @@
+package dog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxJobOutputLines caps the output kept for a job run.
+const maxJobOutputLines = 40
+
+// defaultDepUpdateCommand lists the Go modules with newer versions.
+const defaultDepUpdateCommand = `go list -m -u -f '{{if .Update}}{{.Path}} {{.Version}} -> {{.Update.Version}}{{end}}' all`
+
+// RunJob runs one attempt of a job on a dog and returns what it did, one
+// line per rig. Work continues across rigs when one fails; the attempt
+// fails if any did. The attempt is bounded by the job's timeout.
+func (m *Manager) RunJob(ctx context.Context, job *Job, dogName string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	rigs, err := m.jobRigs(job)
+	if err != nil {
+		return "", err
+	}
+	var worktrees map[string]string
+	if d, err := m.Get(dogName); err == nil {
+		worktrees = d.Worktrees
+	}
+	env := []string{"GT_TOWN_ROOT=" + m.townRoot, "GT_DOG=" + dogName, "GT_JOB=" + job.Name}
+
+	// Each target is a rig, or "town" for the town itself.
+	type target struct {
+		name string
+		run  func(ctx context.Context) (string, error)
+	}
+	var targets []target
+	add := func(name string, run func(ctx context.Context) (string, error)) {
+		targets = append(targets, target{name, run})
+	}
+
+	switch job.Type {
+	case JobBranchCleanup:
+		for _, rig := range rigs {
+			add(rig, func(ctx context.Context) (string, error) {
+				repoGit, err := m.findRepoBase(filepath.Join(m.townRoot, rig))
+				if err != nil {
+					return "", err
+				}
+				deleted, err := m.cleanupStaleBranchesForRig(repoGit, rig)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("deleted %d stale dog branch(es)", deleted), nil
+			})
+		}
+
+	case JobWorktreePrune:
+		for _, rig := range rigs {
+			add(rig, func(ctx context.Context) (string, error) {
+				repoGit, err := m.findRepoBase(filepath.Join(m.townRoot, rig))
+				if err != nil {
+					return "", err
+				}
+				before, _ := repoGit.WorktreeList()
+				if err := repoGit.WorktreePrune(); err != nil {
+					return "", err
+				}
+				after, _ := repoGit.WorktreeList()
+				return fmt.Sprintf("pruned %d stale worktree(s)", max(len(before)-len(after), 0)), nil
+			})
+		}
+
+	case JobBeadsSync:
+		if len(job.Rigs) == 0 {
+			add("town", func(ctx context.Context) (string, error) {
+				_, err := runJobCommand(ctx, m.townRoot, env, "bd", "sync")
+				return "synced", err
+			})
+		}
+		for _, rig := range rigs {
+			add(rig, func(ctx context.Context) (string, error) {
+				_, err := runJobCommand(ctx, filepath.Join(m.townRoot, rig), append(env, "GT_RIG="+rig), "bd", "sync")
+				return "synced", err
+			})
+		}
+
+	case JobDepUpdate:
+		for _, rig := range rigs {
+			add(rig, func(ctx context.Context) (string, error) {
+				dir := worktrees[rig]
+				if dir == "" {
+					return "", fmt.Errorf("dog %s has no worktree for this rig (re-add the dog to create one)", dogName)
+				}
+				command := job.Command
+				if command == "" {
+					if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+						return "skipped (no go.mod; set a command for other ecosystems)", nil
+					}
+					command = defaultDepUpdateCommand
+				}
+				out, err := runJobCommand(ctx, dir, append(env, "GT_RIG="+rig), "sh", "-c", command)
+				if err != nil {
+					return "", err
+				}
+				updates := nonEmptyLines(out)
+				if len(updates) == 0 {
+					return "up to date", nil
+				}
+				return fmt.Sprintf("%d update(s) available:\n  %s", len(updates), strings.Join(updates, "\n  ")), nil
+			})
+		}
+
+	case JobCommand:
+		if len(job.Rigs) == 0 {
+			add("town", func(ctx context.Context) (string, error) {
+				out, err := runJobCommand(ctx, m.townRoot, env, "sh", "-c", job.Command)
+				return strings.TrimSpace(out), err
+			})
+		}
+		for _, rig := range job.Rigs {
+			add(rig, func(ctx context.Context) (string, error) {
+				dir := worktrees[rig]
+				if dir == "" {
+					dir = filepath.Join(m.townRoot, rig)
+				}
+				out, err := runJobCommand(ctx, dir, append(env, "GT_RIG="+rig), "sh", "-c", job.Command)
+				return strings.TrimSpace(out), err
+			})
+		}
+
+	default:
+		return "", fmt.Errorf("unknown job type %q", job.Type)
+	}
+
+	var lines, failed []string
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		out, err := t.run(ctx)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", t.name, err))
+			lines = append(lines, fmt.Sprintf("%s: FAILED: %v", t.name, err))
+			continue
+		}
+		if out != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", t.name, out))
+		}
+	}
+	output := strings.Join(tailJobOutput(lines), "\n")
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return output, fmt.Errorf("timed out after %v", job.Timeout)
+	}
+	if ctx.Err() != nil {
+		return output, ctx.Err()
+	}
+	if len(failed) > 0 {
+		return output, fmt.Errorf("%d of %d failed: %s", len(failed), len(targets), strings.Join(failed, "; "))
+	}
+	return output, nil
+}
+
+// jobRigs returns the rigs a job runs in, sorted.
+func (m *Manager) jobRigs(job *Job) ([]string, error) {
+	if len(job.Rigs) > 0 {
+		for _, rig := range job.Rigs {
+			if _, ok := m.rigsConfig.Rigs[rig]; !ok {
+				return nil, fmt.Errorf("rig %s not found in config", rig)
+			}
+		}
+		return job.Rigs, nil
+	}
+	rigs := make([]string, 0, len(m.rigsConfig.Rigs))
+	for rig := range m.rigsConfig.Rigs {
+		rigs = append(rigs, rig)
+	}
+	sort.Strings(rigs)
+	return rigs, nil
+}
+
+// runJobCommand runs a job's command, returning its combined output. A
+// failure includes the last lines of output.
+func runJobCommand(ctx context.Context, dir string, env []string, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...) //nolint:gosec // G204: job commands come from town settings
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	// Run in its own process group so a timeout kills the command's
+	// children too, rather than waiting on them for the output pipe.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 10 * time.Second
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if tail := tailJobOutput(nonEmptyLines(string(out))); len(tail) > 0 {
+			return string(out), fmt.Errorf("%w: %s", err, tail[len(tail)-1])
+		}
+		return string(out), err
+	}
+	return string(out), nil
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// tailJobOutput keeps the last maxJobOutputLines lines.
+func tailJobOutput(lines []string) []string {
+	if len(lines) > maxJobOutputLines {
+		return append([]string{fmt.Sprintf("(%d earlier lines omitted)", len(lines)-maxJobOutputLines)},
+			lines[len(lines)-maxJobOutputLines:]...)
+	}
+	return lines
+}
@@ -0,0 +1,161 @@
+package dog
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// JobType is the kind of infrastructure work a dog job does.
+type JobType string
+
+const (
+	// JobBranchCleanup deletes dog branches no dog is using, in each rig.
+	JobBranchCleanup JobType = "branch-cleanup"
+	// JobWorktreePrune prunes worktree entries whose directories are gone.
+	JobWorktreePrune JobType = "worktree-prune"
+	// JobBeadsSync runs bd sync in the town and each rig.
+	JobBeadsSync JobType = "beads-sync"
+	// JobDepUpdate reports available dependency updates in each rig.
+	JobDepUpdate JobType = "dep-update"
+	// JobCommand runs a configured shell command.
+	JobCommand JobType = "command"
+)
+
+// JobTypes lists the job types, in display order.
+var JobTypes = []JobType{JobBranchCleanup, JobWorktreePrune, JobBeadsSync, JobDepUpdate, JobCommand}
+
+// Job defaults, used when a job's settings leave them out.
+const (
+	DefaultJobTimeout = 30 * time.Minute
+	DefaultJobRetries = 2
+	DefaultJobBackoff = 5 * time.Minute
+
+	// maxJobBackoff caps the doubling retry delay.
+	maxJobBackoff = 2 * time.Hour
+)
+
+// Job is a recurring dog job, resolved from town settings.
+type Job struct {
+	Name        string
+	Type        JobType
+	Schedule    Schedule
+	ScheduleRaw string   // the schedule as configured
+	Rigs        []string // empty means all rigs
+	Command     string
+	Timeout     time.Duration
+	Concurrency int // runs of this job at once
+	Retries     int // retries after a failed run
+	Backoff     time.Duration
+	Disabled    bool
+}
+
+// NewJob validates a job's settings.
+func NewJob(name string, spec config.DogJobSpec) (*Job, error) {
+	job := &Job{
+		Name:        name,
+		Type:        JobType(spec.Type),
+		ScheduleRaw: spec.Schedule,
+		Rigs:        spec.Rigs,
+		Command:     spec.Command,
+		Timeout:     DefaultJobTimeout,
+		Concurrency: 1,
+		Retries:     DefaultJobRetries,
+		Backoff:     DefaultJobBackoff,
+		Disabled:    spec.Disabled,
+	}
+	if job.Type == "" {
+		job.Type = JobType(name)
+	}
+	known := false
+	for _, t := range JobTypes {
+		known = known || t == job.Type
+	}
+	if !known {
+		return nil, fmt.Errorf("job %s: unknown type %q", name, job.Type)
+	}
+	if job.Type == JobCommand && job.Command == "" {
+		return nil, fmt.Errorf("job %s: command jobs need a command", name)
+	}
+
+	if spec.Schedule == "" {
+		return nil, fmt.Errorf("job %s: no schedule", name)
+	}
+	schedule, err := ParseSchedule(spec.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("job %s: %w", name, err)
+	}
+	job.Schedule = schedule
+
+	if spec.Timeout != "" {
+		if job.Timeout, err = time.ParseDuration(spec.Timeout); err != nil || job.Timeout <= 0 {
+			return nil, fmt.Errorf("job %s: invalid timeout %q", name, spec.Timeout)
+		}
+	}
+	if spec.Backoff != "" {
+		if job.Backoff, err = time.ParseDuration(spec.Backoff); err != nil || job.Backoff <= 0 {
+			return nil, fmt.Errorf("job %s: invalid backoff %q", name, spec.Backoff)
+		}
+	}
+	if spec.Concurrency < 0 {
+		return nil, fmt.Errorf("job %s: concurrency must be positive", name)
+	}
+	if spec.Concurrency > 0 {
+		job.Concurrency = spec.Concurrency
+	}
+	if spec.Retries != nil {
+		if *spec.Retries < 0 {
+			return nil, fmt.Errorf("job %s: retries must not be negative", name)
+		}
+		job.Retries = *spec.Retries
+	}
+	return job, nil
+}
+
+// LoadJobs resolves the configured jobs (disabled ones included), sorted
+// by name. Jobs with invalid settings are left out and returned as errors.
+func LoadJobs(cfg *config.DogJobsConfig) ([]*Job, []error) {
+	specs := cfg.Specs()
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jobs []*Job
+	var errs []error
+	for _, name := range names {
+		job, err := NewJob(name, specs[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, errs
+}
+
+// FindJob returns the job with the given name, or nil.
+func FindJob(jobs []*Job, name string) *Job {
+	for _, job := range jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+// retryDelay is the backoff before retry n (1-based): the job's backoff,
+// doubled for each earlier retry, capped at maxJobBackoff.
+func retryDelay(backoff time.Duration, n int) time.Duration {
+	if backoff <= 0 {
+		backoff = DefaultJobBackoff
+	}
+	d := backoff
+	for i := 1; i < n && d < maxJobBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxJobBackoff)
+}
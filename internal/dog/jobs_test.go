@@ -0,0 +1,225 @@
+package dog
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2026, 3, 10, 14, 7, 30, 0, time.Local) // a Tuesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 10, 14, 15, 0, 0, time.Local)},
+		{"0 4 * * *", time.Date(2026, 3, 11, 4, 0, 0, 0, time.Local)},
+		{"30 14 * * *", time.Date(2026, 3, 10, 14, 30, 0, 0, time.Local)},
+		{"0 6 * * mon", time.Date(2026, 3, 16, 6, 0, 0, 0, time.Local)},
+		{"0 0 1 jan-jun/3 *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)},
+		{"0 9 15 * 5", time.Date(2026, 3, 13, 9, 0, 0, 0, time.Local)}, // Friday or the 15th
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.Local)},  // 7 is Sunday
+		{"@hourly", time.Date(2026, 3, 10, 15, 0, 0, 0, time.Local)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)},
+		{"@every 90m", base.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("%q: Next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	never, err := ParseSchedule("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := never.Next(base); !got.IsZero() {
+		t.Errorf("Feb 31: Next = %v, want zero", got)
+	}
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "@every 10s", "@yearly-ish"} {
+		if _, err := ParseSchedule(bad); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLoadJobs(t *testing.T) {
+	jobs, errs := LoadJobs(nil)
+	if len(errs) != 0 || len(jobs) != len(config.DefaultDogJobs) {
+		t.Fatalf("defaults: %d jobs, errors %v", len(jobs), errs)
+	}
+
+	zero := 0
+	cfg := &config.DogJobsConfig{Jobs: map[string]*config.DogJobSpec{
+		"beads-sync": {Disabled: true},
+		"dep-update": {Schedule: "@daily", Retries: &zero},
+		"lint":       {Type: "command", Command: "make lint", Schedule: "@hourly", Concurrency: 2},
+		"broken":     {Type: "command", Schedule: "@daily"},
+	}}
+	jobs, errs = LoadJobs(cfg)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken") {
+		t.Errorf("errors = %v, want one for broken", errs)
+	}
+	if job := FindJob(jobs, "beads-sync"); job == nil || !job.Disabled || job.Timeout != 5*time.Minute {
+		t.Errorf("beads-sync = %+v, want disabled with its default timeout", job)
+	}
+	if job := FindJob(jobs, "dep-update"); job == nil || job.Retries != 0 || job.ScheduleRaw != "@daily" {
+		t.Errorf("dep-update = %+v, want no retries on @daily", job)
+	}
+	if job := FindJob(jobs, "lint"); job == nil || job.Type != JobCommand || job.Concurrency != 2 || job.Retries != DefaultJobRetries {
+		t.Errorf("lint = %+v", job)
+	}
+}
+
+func TestQueueLifecycle(t *testing.T) {
+	q := NewQueue(t.TempDir())
+	hourly, err := NewJob("beads-sync", config.DogJobSpec{Schedule: "@every 1h", Backoff: "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := []*Job{hourly}
+	t0 := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// The first sighting starts the schedule; nothing is due yet.
+	if queued, err := q.EnqueueDue(jobs, t0); err != nil || len(queued) != 0 {
+		t.Fatalf("first EnqueueDue = %v, %v", queued, err)
+	}
+	queued, err := q.EnqueueDue(jobs, t0.Add(61*time.Minute))
+	if err != nil || len(queued) != 1 {
+		t.Fatalf("EnqueueDue after an hour = %v, %v", queued, err)
+	}
+	// A waiting run isn't queued twice.
+	if queued, _ := q.EnqueueDue(jobs, t0.Add(125*time.Minute)); len(queued) != 0 {
+		t.Errorf("queued %d more while one was waiting", len(queued))
+	}
+
+	// Concurrency 1: a manual run waits while the first one runs.
+	if _, err := q.Enqueue(hourly, TriggerManual, t0.Add(126*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	now := t0.Add(127 * time.Minute)
+	claimed, err := q.Claim(jobs, []string{"alpha", "bravo"}, 0, now)
+	if err != nil || len(claimed) != 1 || claimed[0].Dog != "alpha" || claimed[0].Attempt != 1 {
+		t.Fatalf("Claim = %+v, %v", claimed, err)
+	}
+	run := claimed[0]
+
+	// A failure is retried after the backoff; the next attempt backs off twice as long.
+	rec, err := q.Finish(run.ID, "", errors.New("bd exploded"), now)
+	if err != nil || !rec.Retrying() || !rec.NotBefore.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Finish = %+v, %v", rec, err)
+	}
+	claimed, _ = q.Claim(jobs, []string{"alpha"}, 0, now.Add(30*time.Second))
+	if len(claimed) != 1 || claimed[0].ID == run.ID {
+		t.Fatalf("during backoff claimed %+v, want only the manual run", claimed)
+	}
+	if _, err := q.Finish(claimed[0].ID, "town: synced", nil, now.Add(40*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(2 * time.Minute)
+	claimed, _ = q.Claim(jobs, []string{"alpha"}, 0, now)
+	if len(claimed) != 1 || claimed[0].ID != run.ID || claimed[0].Attempt != 2 {
+		t.Fatalf("after backoff claimed %+v", claimed)
+	}
+	rec, _ = q.Finish(run.ID, "", errors.New("still broken"), now)
+	if !rec.NotBefore.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("second retry at %v, want doubled backoff", rec.NotBefore)
+	}
+
+	// The last attempt fails for good and leaves the queue.
+	now = now.Add(3 * time.Minute)
+	claimed, _ = q.Claim(jobs, []string{"alpha"}, 0, now)
+	rec, _ = q.Finish(claimed[0].ID, "", errors.New("gave up"), now)
+	if rec.Status != RunFailed || rec.Retrying() || rec.Attempt != 3 {
+		t.Errorf("final attempt = %+v", rec)
+	}
+	state, _ := q.Load()
+	if len(state.Runs) != 0 {
+		t.Errorf("queue still holds %d runs", len(state.Runs))
+	}
+
+	history, err := q.History("", 0)
+	if err != nil || len(history) != 4 {
+		t.Fatalf("History = %d runs, %v", len(history), err)
+	}
+	if history[0].Error != "gave up" || history[3].Error != "bd exploded" {
+		t.Errorf("history not newest first: %q ... %q", history[0].Error, history[3].Error)
+	}
+	if got, _ := q.History("beads-sync", 2); len(got) != 2 {
+		t.Errorf("History limit 2 = %d runs", len(got))
+	}
+}
+
+func TestQueueReapOrphansAndCancel(t *testing.T) {
+	q := NewQueue(t.TempDir())
+	job, _ := NewJob("worktree-prune", config.DogJobSpec{Schedule: "@daily", Retries: new(int)})
+	now := time.Now()
+
+	running, _ := q.Enqueue(job, TriggerManual, now)
+	if _, err := q.Claim([]*Job{job}, []string{"alpha"}, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	// Pretend the attempt belongs to a runner that has exited.
+	if err := q.update(func(state *QueueState, _ func(*JobRun)) error {
+		state.Runs[0].PID = 1 << 30
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	waiting, _ := q.Enqueue(job, TriggerManual, now)
+
+	reaped, err := q.ReapOrphans(now)
+	if err != nil || len(reaped) != 1 || reaped[0].ID != running.ID || !strings.Contains(reaped[0].Error, "interrupted") {
+		t.Fatalf("ReapOrphans = %+v, %v", reaped, err)
+	}
+
+	if _, err := q.Cancel("nope", now); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("Cancel unknown = %v, want ErrRunNotFound", err)
+	}
+	if rec, err := q.Cancel(waiting.ID, now); err != nil || rec.Status != RunCanceled {
+		t.Errorf("Cancel = %+v, %v", rec, err)
+	}
+	if state, _ := q.Load(); len(state.Runs) != 0 {
+		t.Errorf("queue still holds %d runs", len(state.Runs))
+	}
+}
+
+func TestRunJobCommand(t *testing.T) {
+	townRoot := t.TempDir()
+	m := NewManager(townRoot, &config.RigsConfig{Rigs: map[string]config.RigEntry{}})
+
+	job, err := NewJob("hello", config.DogJobSpec{Type: "command", Schedule: "@daily", Command: `echo "$GT_JOB on $GT_DOG" > out.txt; cat out.txt`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := m.RunJob(context.Background(), job, "alpha")
+	if err != nil || out != "town: hello on alpha" {
+		t.Errorf("RunJob = %q, %v", out, err)
+	}
+	if _, err := os.Stat(filepath.Join(townRoot, "out.txt")); err != nil {
+		t.Errorf("command didn't run in the town root: %v", err)
+	}
+
+	job, _ = NewJob("slow", config.DogJobSpec{Type: "command", Schedule: "@daily", Command: "sleep 30", Timeout: "100ms"})
+	if _, err := m.RunJob(context.Background(), job, "alpha"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("slow job error = %v, want timeout", err)
+	}
+
+	job, _ = NewJob("fail", config.DogJobSpec{Type: "command", Schedule: "@daily", Command: "echo boom; exit 2"})
+	if _, err := m.RunJob(context.Background(), job, "alpha"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("failing job error = %v, want output in it", err)
+	}
+}
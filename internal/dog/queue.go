@@ -0,0 +1,485 @@
+package dog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// ErrRunNotFound is returned for an unknown or finished job run.
+var ErrRunNotFound = errors.New("job run not found")
+
+// RunStatus is the state of a job run.
+type RunStatus string
+
+const (
+	RunQueued    RunStatus = "queued"
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCanceled  RunStatus = "canceled"
+)
+
+// Run triggers.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// maxJobHistory caps the attempts kept in the job history.
+const maxJobHistory = 1000
+
+// JobRun is one queued or running job, or a finished attempt in the
+// history. A failed attempt that will be retried is recorded in the
+// history with NotBefore set to the retry time, and stays queued.
+type JobRun struct {
+	ID          string        `json:"id"`
+	Job         string        `json:"job"`
+	Type        JobType       `json:"type"`
+	Trigger     string        `json:"trigger"`
+	Status      RunStatus     `json:"status"`
+	Attempt     int           `json:"attempt"`      // attempts started
+	MaxAttempts int           `json:"max_attempts"` // 1 + retries
+	Backoff     time.Duration `json:"backoff_ns"`
+	Dog         string        `json:"dog,omitempty"`
+	PID         int           `json:"pid,omitempty"` // process running the attempt
+	QueuedAt    time.Time     `json:"queued_at"`
+	NotBefore   *time.Time    `json:"not_before,omitempty"` // retry backoff
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+	Output      string        `json:"output,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
+// Retrying reports whether a failed attempt will be retried.
+func (r *JobRun) Retrying() bool {
+	return r.Status == RunFailed && r.NotBefore != nil
+}
+
+// Duration returns how long a finished attempt ran.
+func (r *JobRun) Duration() time.Duration {
+	if r.StartedAt == nil || r.FinishedAt == nil {
+		return 0
+	}
+	return r.FinishedAt.Sub(*r.StartedAt)
+}
+
+// QueueState is the persistent queue: the runs waiting or running, oldest
+// first, and when each job's schedule last fired.
+type QueueState struct {
+	LastScheduled map[string]time.Time `json:"last_scheduled"`
+	Runs          []*JobRun            `json:"runs"`
+}
+
+// NextRun returns when a job's schedule next fires.
+func (s *QueueState) NextRun(job *Job, now time.Time) time.Time {
+	last, ok := s.LastScheduled[job.Name]
+	if !ok {
+		last = now
+	}
+	return job.Schedule.Next(last)
+}
+
+// pending reports whether a job has a run waiting to start.
+func (s *QueueState) pending(job string) bool {
+	for _, run := range s.Runs {
+		if run.Job == job && run.Status == RunQueued {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *QueueState) find(id string) (int, *JobRun) {
+	for i, run := range s.Runs {
+		if run.ID == id {
+			return i, run
+		}
+	}
+	return -1, nil
+}
+
+// Queue is the Deacon's job queue for dogs, stored in deacon/jobs/.
+// The daemon fills it from job schedules and hands queued runs to idle
+// dogs; finished attempts are kept in history.jsonl.
+type Queue struct {
+	dir string
+}
+
+// NewQueue returns the job queue of a town.
+func NewQueue(townRoot string) *Queue {
+	return &Queue{dir: filepath.Join(townRoot, "deacon", "jobs")}
+}
+
+// Dir returns the queue directory.
+func (q *Queue) Dir() string {
+	return q.dir
+}
+
+func (q *Queue) statePath() string {
+	return filepath.Join(q.dir, "queue.json")
+}
+
+func (q *Queue) historyPath() string {
+	return filepath.Join(q.dir, "history.jsonl")
+}
+
+// Load reads the queue.
+func (q *Queue) Load() (*QueueState, error) {
+	state := &QueueState{LastScheduled: make(map[string]time.Time)}
+	data, err := os.ReadFile(q.statePath())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading job queue: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parsing job queue: %w", err)
+	}
+	if state.LastScheduled == nil {
+		state.LastScheduled = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// update applies fn to the queue under an exclusive lock. fn hands
+// finished attempts to finish to record them in the history.
+func (q *Queue) update(fn func(state *QueueState, finish func(*JobRun)) error) error {
+	unlock, err := q.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := q.Load()
+	if err != nil {
+		return err
+	}
+	var finished []*JobRun
+	if err := fn(state, func(run *JobRun) { finished = append(finished, run) }); err != nil {
+		return err
+	}
+	if err := util.AtomicWriteJSON(q.statePath(), state); err != nil {
+		return fmt.Errorf("writing job queue: %w", err)
+	}
+	if len(finished) > 0 {
+		if err := q.appendHistory(finished); err != nil {
+			return fmt.Errorf("writing job history: %w", err)
+		}
+	}
+	return nil
+}
+
+// lock takes an exclusive lock on the queue.
+func (q *Queue) lock() (func(), error) {
+	if err := os.MkdirAll(q.dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating job queue dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(q.dir, ".lock"), os.O_CREATE|os.O_RDWR, 0644) //nolint:gosec // G304: path is within the queue
+	if err != nil {
+		return nil, fmt.Errorf("opening job queue lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("locking job queue: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+func newRun(job *Job, trigger string, now time.Time) *JobRun {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return &JobRun{
+		ID:          fmt.Sprintf("%s-%s-%s", job.Name, now.UTC().Format("20060102"), hex.EncodeToString(b)),
+		Job:         job.Name,
+		Type:        job.Type,
+		Trigger:     trigger,
+		Status:      RunQueued,
+		MaxAttempts: 1 + job.Retries,
+		Backoff:     job.Backoff,
+		QueuedAt:    now,
+	}
+}
+
+// Enqueue queues a run of a job, e.g. one started by hand.
+func (q *Queue) Enqueue(job *Job, trigger string, now time.Time) (*JobRun, error) {
+	run := newRun(job, trigger, now)
+	err := q.update(func(state *QueueState, _ func(*JobRun)) error {
+		state.Runs = append(state.Runs, run)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// EnqueueDue queues a run of every enabled job whose schedule has fired
+// since it last did. A job seen for the first time starts its schedule
+// from now. Missed firings (e.g. while the daemon was down) are coalesced
+// into one run, and no run is queued while one is already waiting.
+func (q *Queue) EnqueueDue(jobs []*Job, now time.Time) ([]*JobRun, error) {
+	var queued []*JobRun
+	err := q.update(func(state *QueueState, _ func(*JobRun)) error {
+		for _, job := range jobs {
+			if job.Disabled {
+				continue
+			}
+			last, ok := state.LastScheduled[job.Name]
+			if !ok {
+				state.LastScheduled[job.Name] = now
+				continue
+			}
+			next := job.Schedule.Next(last)
+			if next.IsZero() || next.After(now) {
+				continue
+			}
+			// Keep the schedule on its grid, unless it fell further behind.
+			state.LastScheduled[job.Name] = next
+			if after := job.Schedule.Next(next); !after.After(now) {
+				state.LastScheduled[job.Name] = now
+			}
+			if state.pending(job.Name) {
+				continue
+			}
+			run := newRun(job, TriggerSchedule, now)
+			state.Runs = append(state.Runs, run)
+			queued = append(queued, run)
+		}
+		return nil
+	})
+	return queued, err
+}
+
+// Claim starts queued runs that are past their backoff on the given idle
+// dogs, oldest first, one dog per run, keeping each job within its
+// concurrency and the queue within limit running runs (0: no limit). Runs
+// of jobs that are no longer configured are canceled.
+func (q *Queue) Claim(jobs []*Job, dogs []string, limit int, now time.Time) ([]*JobRun, error) {
+	var claimed []*JobRun
+	err := q.update(func(state *QueueState, finish func(*JobRun)) error {
+		running := make(map[string]int)
+		total := 0
+		for _, run := range state.Runs {
+			if run.Status == RunRunning {
+				running[run.Job]++
+				total++
+			}
+		}
+
+		kept := state.Runs[:0]
+		for _, run := range state.Runs {
+			job := FindJob(jobs, run.Job)
+			if run.Status == RunQueued && job == nil {
+				run.Status = RunCanceled
+				run.Error = "job is no longer configured"
+				run.FinishedAt = &now
+				finish(run)
+				continue
+			}
+			kept = append(kept, run)
+
+			switch {
+			case run.Status != RunQueued,
+				run.NotBefore != nil && now.Before(*run.NotBefore),
+				running[run.Job] >= job.Concurrency,
+				len(dogs) == 0,
+				limit > 0 && total >= limit:
+				continue
+			}
+			started := now
+			run.Status = RunRunning
+			run.Attempt++
+			run.Dog, dogs = dogs[0], dogs[1:]
+			run.PID = os.Getpid()
+			run.StartedAt = &started
+			run.NotBefore = nil
+			run.Error = ""
+			running[run.Job]++
+			total++
+			c := *run
+			claimed = append(claimed, &c)
+		}
+		state.Runs = kept
+		return nil
+	})
+	return claimed, err
+}
+
+// finishRun records the end of an attempt: the run leaves the queue, or
+// goes back to it with a backoff if it failed and has attempts left. It
+// returns the attempt as recorded in the history.
+func finishRun(state *QueueState, run *JobRun, output string, runErr error, now time.Time, finish func(*JobRun)) *JobRun {
+	i, _ := state.find(run.ID)
+	run.FinishedAt = &now
+	run.Output = output
+	run.Status = RunSucceeded
+	if runErr != nil {
+		run.Status = RunFailed
+		run.Error = runErr.Error()
+	}
+
+	rec := *run
+	if run.Status == RunFailed && run.Attempt < run.MaxAttempts {
+		retryAt := now.Add(retryDelay(run.Backoff, run.Attempt))
+		rec.NotBefore = &retryAt
+
+		run.Status = RunQueued
+		run.NotBefore = &retryAt
+		run.Dog = ""
+		run.PID = 0
+		run.StartedAt = nil
+		run.FinishedAt = nil
+	} else {
+		state.Runs = append(state.Runs[:i], state.Runs[i+1:]...)
+	}
+	finish(&rec)
+	return &rec
+}
+
+// Finish records the result of a running attempt and returns it as
+// recorded in the history.
+func (q *Queue) Finish(id, output string, runErr error, now time.Time) (*JobRun, error) {
+	var rec *JobRun
+	err := q.update(func(state *QueueState, finish func(*JobRun)) error {
+		_, run := state.find(id)
+		if run == nil || run.Status != RunRunning {
+			return ErrRunNotFound
+		}
+		rec = finishRun(state, run, output, runErr, now, finish)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// ReapOrphans fails running attempts whose process has exited (e.g. a
+// daemon restart), so they are retried, and returns them.
+func (q *Queue) ReapOrphans(now time.Time) ([]*JobRun, error) {
+	var reaped []*JobRun
+	err := q.update(func(state *QueueState, finish func(*JobRun)) error {
+		var orphans []*JobRun
+		for _, run := range state.Runs {
+			if run.Status == RunRunning && !util.ProcessExists(run.PID) {
+				orphans = append(orphans, run)
+			}
+		}
+		for _, run := range orphans {
+			err := fmt.Errorf("interrupted: runner (PID %d) exited", run.PID)
+			reaped = append(reaped, finishRun(state, run, "", err, now, finish))
+		}
+		return nil
+	})
+	return reaped, err
+}
+
+// Cancel removes a queued run.
+func (q *Queue) Cancel(id string, now time.Time) (*JobRun, error) {
+	var rec *JobRun
+	err := q.update(func(state *QueueState, finish func(*JobRun)) error {
+		i, run := state.find(id)
+		if run == nil {
+			return ErrRunNotFound
+		}
+		if run.Status != RunQueued {
+			return fmt.Errorf("%s is %s on %s; it can't be canceled", id, run.Status, run.Dog)
+		}
+		run.Status = RunCanceled
+		run.FinishedAt = &now
+		state.Runs = append(state.Runs[:i], state.Runs[i+1:]...)
+		finish(run)
+		rec = run
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// appendHistory adds finished attempts to the history, keeping the most
+// recent maxJobHistory. Called with the queue locked.
+func (q *Queue) appendHistory(runs []*JobRun) error {
+	lines, err := q.readHistory()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, data)
+	}
+	if len(lines) > maxJobHistory {
+		lines = lines[len(lines)-maxJobHistory:]
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return util.AtomicWriteFile(q.historyPath(), buf.Bytes(), 0644)
+}
+
+func (q *Queue) readHistory() ([][]byte, error) {
+	f, err := os.Open(q.historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), scanner.Bytes()...))
+	}
+	return lines, scanner.Err()
+}
+
+// History returns finished attempts, newest first, optionally only those
+// of one job, at most limit of them (0: all).
+func (q *Queue) History(job string, limit int) ([]*JobRun, error) {
+	lines, err := q.readHistory()
+	if err != nil {
+		return nil, fmt.Errorf("reading job history: %w", err)
+	}
+	var runs []*JobRun
+	for i := len(lines) - 1; i >= 0; i-- {
+		var run JobRun
+		if err := json.Unmarshal(lines[i], &run); err != nil {
+			continue // skip corrupt lines
+		}
+		if job != "" && run.Job != job {
+			continue
+		}
+		runs = append(runs, &run)
+		if limit > 0 && len(runs) == limit {
+			break
+		}
+	}
+	return runs, nil
+}
@@ -0,0 +1,282 @@
+// Package escalation tracks escalations raised with gt escalate.
+//
+// Each escalation is stored in .beads/escalations/<id>.json at the town
+// root and moves from open to acknowledged to resolved. The escalation
+// bead remains the audit trail; this store holds the lifecycle the daemon
+// checks against per-severity SLAs.
+package escalation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Escalation states.
+const (
+	StateOpen         = "open"
+	StateAcknowledged = "acknowledged"
+	StateResolved     = "resolved"
+)
+
+// ErrNotFound is returned when no escalation matches an ID or bead ID.
+var ErrNotFound = errors.New("escalation not found")
+
+// Escalation is one escalation and its lifecycle.
+type Escalation struct {
+	ID       string `json:"id"`
+	Topic    string `json:"topic"`
+	Message  string `json:"message,omitempty"`
+	Severity string `json:"severity"` // CRITICAL, HIGH or MEDIUM
+	From     string `json:"from"`     // escalating agent
+	Rig      string `json:"rig,omitempty"`
+	BeadID   string `json:"bead_id,omitempty"`
+	State    string `json:"state"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	AckedAt *time.Time `json:"acked_at,omitempty"`
+	AckedBy string     `json:"acked_by,omitempty"`
+	AckNote string     `json:"ack_note,omitempty"`
+
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy string     `json:"resolved_by,omitempty"`
+	Resolution string     `json:"resolution,omitempty"`
+
+	// Re-escalation by the daemon when an SLA is missed.
+	Breach           string     `json:"breach,omitempty"` // last breached SLA: ack or resolve
+	Renotified       int        `json:"renotified,omitempty"`
+	BreachRenotified int        `json:"breach_renotified,omitempty"` // re-escalations for Breach
+	LastRenotified   *time.Time `json:"last_renotified,omitempty"`
+}
+
+// TimeToAck returns how long the escalation took to acknowledge, and
+// false if it hasn't been.
+func (e *Escalation) TimeToAck() (time.Duration, bool) {
+	if e.AckedAt == nil {
+		return 0, false
+	}
+	return e.AckedAt.Sub(e.CreatedAt), true
+}
+
+// TimeToResolve returns how long the escalation took to resolve, and
+// false if it hasn't been.
+func (e *Escalation) TimeToResolve() (time.Duration, bool) {
+	if e.ResolvedAt == nil {
+		return 0, false
+	}
+	return e.ResolvedAt.Sub(e.CreatedAt), true
+}
+
+// RigOf returns the rig of an agent address like "gastown/polecats/nux",
+// or "" for town-level agents.
+func RigOf(agent string) string {
+	parts := strings.Split(strings.TrimSuffix(agent, "/"), "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	switch parts[0] {
+	case "mayor", "deacon", "overseer", "boot":
+		return ""
+	}
+	return parts[0]
+}
+
+// Store reads and writes a town's escalations.
+type Store struct {
+	dir string // .beads/escalations/
+}
+
+// NewStore returns the escalation store of a town.
+func NewStore(townRoot string) *Store {
+	return &Store{dir: filepath.Join(townRoot, ".beads", "escalations")}
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// generateID creates a unique escalation ID.
+func generateID(now time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("esc-%s-%s", now.UTC().Format("20060102"), hex.EncodeToString(b))
+}
+
+// Create records a new open escalation, filling in its ID, state and
+// creation time when unset.
+func (s *Store) Create(e *Escalation) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	if e.ID == "" {
+		e.ID = generateID(e.CreatedAt)
+	}
+	if e.State == "" {
+		e.State = StateOpen
+	}
+	if e.Rig == "" {
+		e.Rig = RigOf(e.From)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("creating escalation dir: %w", err)
+	}
+	return s.save(e)
+}
+
+func (s *Store) save(e *Escalation) error {
+	if err := util.AtomicWriteJSON(s.path(e.ID), e); err != nil {
+		return fmt.Errorf("writing escalation %s: %w", e.ID, err)
+	}
+	return nil
+}
+
+func (s *Store) load(path string) (*Escalation, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is within the store
+	if err != nil {
+		return nil, err
+	}
+	var e Escalation
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	return &e, nil
+}
+
+// Get returns an escalation by its ID or its bead ID.
+func (s *Store) Get(ref string) (*Escalation, error) {
+	if !strings.ContainsAny(ref, `/\`) {
+		if e, err := s.load(s.path(ref)); err == nil {
+			return e, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	all, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range all {
+		if e.BeadID != "" && e.BeadID == ref {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+}
+
+// List returns every escalation, oldest first. Unreadable files are skipped.
+func (s *Store) List() ([]*Escalation, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []*Escalation
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		e, err := s.load(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// Update re-reads an escalation, applies fn and writes it back while
+// holding the store lock, so the daemon recording a re-escalation can't
+// overwrite an acknowledgement made at the same moment.
+func (s *Store) Update(ref string, fn func(*Escalation) error) (*Escalation, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	e, err := s.Get(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(e); err != nil {
+		return nil, err
+	}
+	if err := s.save(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// lock takes an exclusive lock on the store.
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating escalation dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, ".lock"), os.O_CREATE|os.O_RDWR, 0644) //nolint:gosec // G304: path is within the store
+	if err != nil {
+		return nil, fmt.Errorf("opening escalation lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("locking escalations: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// Ack acknowledges an open escalation.
+func (s *Store) Ack(ref, by, note string, now time.Time) (*Escalation, error) {
+	return s.Update(ref, func(e *Escalation) error {
+		switch e.State {
+		case StateAcknowledged:
+			return fmt.Errorf("%s was already acknowledged by %s", e.ID, e.AckedBy)
+		case StateResolved:
+			return fmt.Errorf("%s is already resolved", e.ID)
+		}
+		e.State = StateAcknowledged
+		e.AckedAt = &now
+		e.AckedBy = by
+		e.AckNote = note
+		return nil
+	})
+}
+
+// Resolve resolves an escalation. An escalation resolved without being
+// acknowledged counts as acknowledged at the same time.
+func (s *Store) Resolve(ref, by, resolution string, now time.Time) (*Escalation, error) {
+	return s.Update(ref, func(e *Escalation) error {
+		if e.State == StateResolved {
+			return fmt.Errorf("%s is already resolved", e.ID)
+		}
+		if e.AckedAt == nil {
+			e.AckedAt = &now
+			e.AckedBy = by
+		}
+		e.State = StateResolved
+		e.ResolvedAt = &now
+		e.ResolvedBy = by
+		e.Resolution = resolution
+		return nil
+	})
+}
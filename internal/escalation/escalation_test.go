@@ -0,0 +1,145 @@
+package escalation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestStoreLifecycle(t *testing.T) {
+	s := NewStore(t.TempDir())
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	e := &Escalation{Topic: "Merge conflict", Severity: "HIGH", From: "gastown/polecats/nux", BeadID: "gt-abc", CreatedAt: start}
+	if err := s.Create(e); err != nil {
+		t.Fatal(err)
+	}
+	if e.State != StateOpen || e.Rig != "gastown" || e.ID == "" {
+		t.Fatalf("created %+v", e)
+	}
+
+	// Escalations can be referred to by bead ID.
+	got, err := s.Get("gt-abc")
+	if err != nil || got.ID != e.ID {
+		t.Fatalf("Get by bead = %+v, %v", got, err)
+	}
+	if _, err := s.Get("gt-nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get missing = %v", err)
+	}
+
+	acked, err := s.Ack(e.ID, "mayor/", "looking", start.Add(20*time.Minute))
+	if err != nil || acked.State != StateAcknowledged {
+		t.Fatalf("Ack = %+v, %v", acked, err)
+	}
+	if _, err := s.Ack(e.ID, "overseer", "", start.Add(time.Hour)); err == nil {
+		t.Error("second ack succeeded")
+	}
+
+	resolved, err := s.Resolve("gt-abc", "overseer", "rebased by hand", start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ack, _ := resolved.TimeToAck()
+	res, _ := resolved.TimeToResolve()
+	if resolved.State != StateResolved || resolved.AckedBy != "mayor/" || ack != 20*time.Minute || res != 2*time.Hour {
+		t.Errorf("resolved %+v", resolved)
+	}
+
+	// Resolving without an ack counts as acknowledged then.
+	quick := &Escalation{Topic: "Spec question", Severity: "MEDIUM", From: "mayor/", CreatedAt: start}
+	_ = s.Create(quick)
+	quick, _ = s.Resolve(quick.ID, "overseer", "", start.Add(time.Hour))
+	if d, ok := quick.TimeToAck(); !ok || d != time.Hour || quick.Rig != "" {
+		t.Errorf("resolved without ack: %+v", quick)
+	}
+
+	if list, _ := s.List(); len(list) != 2 {
+		t.Errorf("List returned %d escalations", len(list))
+	}
+}
+
+func TestDue(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	e := &Escalation{Severity: "CRITICAL", State: StateOpen, CreatedAt: start}
+	var cfg *config.EscalationConfig // defaults: ack 15m, resolve 4h
+
+	if got := Due(e, cfg, start.Add(10*time.Minute)); got != "" {
+		t.Errorf("before ack SLA: %q", got)
+	}
+	now := start.Add(16 * time.Minute)
+	if got := Due(e, cfg, now); got != BreachAck {
+		t.Fatalf("after ack SLA: %q", got)
+	}
+	e.MarkRenotified(BreachAck, now)
+	if got := Due(e, cfg, now.Add(10*time.Minute)); got != "" {
+		t.Errorf("within renotify interval: %q", got)
+	}
+	if got := Due(e, cfg, now.Add(15*time.Minute)); got != BreachAck {
+		t.Errorf("renotify interval passed: %q", got)
+	}
+
+	// Acknowledging stops ack re-escalation until the resolve SLA passes,
+	// which re-escalates at once.
+	e.State = StateAcknowledged
+	if got := Due(e, cfg, start.Add(3*time.Hour)); got != "" {
+		t.Errorf("acknowledged before resolve SLA: %q", got)
+	}
+	if got := Due(e, cfg, start.Add(4*time.Hour)); got != BreachResolve {
+		t.Errorf("acknowledged after resolve SLA: %q", got)
+	}
+
+	cfg = &config.EscalationConfig{
+		SLA:         map[string]*config.EscalationSLA{"CRITICAL": {Resolve: "0"}},
+		MaxRenotify: 5,
+	}
+	if got := Due(e, cfg, start.Add(48*time.Hour)); got != "" {
+		t.Errorf("disabled resolve SLA: %q", got)
+	}
+	for i := 0; i < 4; i++ {
+		e.MarkRenotified(BreachAck, start.Add(time.Duration(i)*time.Hour))
+	}
+	e.State = StateOpen
+	if got := Due(e, cfg, start.Add(48*time.Hour)); got != "" {
+		t.Errorf("renotify cap reached: %q", got)
+	}
+	// The cap counts each SLA separately.
+	e.State = StateAcknowledged
+	cfg.SLA = nil
+	if got := Due(e, cfg, start.Add(48*time.Hour)); got != BreachResolve {
+		t.Errorf("ack cap reached, resolve SLA missed: %q", got)
+	}
+
+	e.State = StateResolved
+	if got := Due(e, nil, start.Add(48*time.Hour)); got != "" {
+		t.Errorf("resolved: %q", got)
+	}
+}
+
+func TestReport(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time { t := start.Add(d); return &t }
+	list := []*Escalation{
+		{Rig: "gastown", State: StateResolved, CreatedAt: start, AckedAt: at(10 * time.Minute), ResolvedAt: at(time.Hour)},
+		{Rig: "gastown", State: StateAcknowledged, CreatedAt: start, AckedAt: at(30 * time.Minute), Renotified: 1},
+		{Rig: "gastown", State: StateOpen, CreatedAt: start},
+		{Rig: "", State: StateOpen, CreatedAt: start},
+		{Rig: "beads", State: StateOpen, CreatedAt: start.Add(-48 * time.Hour)},
+	}
+
+	reports := Report(list, start.Add(-time.Hour))
+	if len(reports) != 2 || reports[0].Rig != "" || reports[1].Rig != "gastown" {
+		t.Fatalf("reports = %+v", reports)
+	}
+	g := reports[1]
+	if g.Total != 3 || g.Open != 1 || g.Acknowledged != 1 || g.Resolved != 1 || g.Breached != 1 {
+		t.Errorf("gastown counts = %+v", g)
+	}
+	if g.MeanAck != 20*time.Minute || g.MaxAck != 30*time.Minute || g.MeanResolve != time.Hour {
+		t.Errorf("gastown times = %+v", g)
+	}
+	if all := Report(list, time.Time{}); len(all) != 3 {
+		t.Errorf("unfiltered report has %d rigs", len(all))
+	}
+}
@@ -0,0 +1,137 @@
+package escalation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// SLA names, recorded as an escalation's Breach.
+const (
+	BreachAck     = "ack"
+	BreachResolve = "resolve"
+)
+
+// Due returns the SLA an escalation has missed and is due to be
+// re-escalated for, or "" if it isn't due. An open escalation is due once
+// its ack SLA has passed, an acknowledged one once its resolve SLA has
+// passed; after a re-escalation it is due again every renotify interval
+// (by default the breached SLA) until acknowledged or resolved.
+func Due(e *Escalation, cfg *config.EscalationConfig, now time.Time) string {
+	ack, resolve, renotify := cfg.Timers(e.Severity)
+
+	var breach string
+	var limit time.Duration
+	switch e.State {
+	case StateOpen:
+		breach, limit = BreachAck, ack
+	case StateAcknowledged:
+		breach, limit = BreachResolve, resolve
+	default:
+		return ""
+	}
+	if limit <= 0 || now.Sub(e.CreatedAt) < limit {
+		return ""
+	}
+
+	// A breach of the same SLA repeats at the renotify interval, up to
+	// MaxRenotify times. Moving on to the next SLA re-escalates at once.
+	if e.Breach == breach {
+		if cfg != nil && cfg.MaxRenotify > 0 && e.BreachRenotified >= cfg.MaxRenotify {
+			return ""
+		}
+		interval := renotify
+		if interval <= 0 {
+			interval = limit
+		}
+		if e.LastRenotified != nil && now.Sub(*e.LastRenotified) < interval {
+			return ""
+		}
+	}
+	return breach
+}
+
+// MarkRenotified records a re-escalation for a breached SLA.
+func (e *Escalation) MarkRenotified(breach string, now time.Time) {
+	if e.Breach != breach {
+		e.BreachRenotified = 0
+	}
+	e.Breach = breach
+	e.Renotified++
+	e.BreachRenotified++
+	e.LastRenotified = &now
+}
+
+// RigReport summarizes a rig's escalations. Rig is "" for escalations
+// raised by town-level agents.
+type RigReport struct {
+	Rig          string        `json:"rig"`
+	Total        int           `json:"total"`
+	Open         int           `json:"open"`
+	Acknowledged int           `json:"acknowledged"`
+	Resolved     int           `json:"resolved"`
+	Breached     int           `json:"breached"` // re-escalated at least once
+	MeanAck      time.Duration `json:"mean_time_to_ack_ns"`
+	MaxAck       time.Duration `json:"max_time_to_ack_ns"`
+	MeanResolve  time.Duration `json:"mean_time_to_resolve_ns"`
+	MaxResolve   time.Duration `json:"max_time_to_resolve_ns"`
+
+	acks, resolves []time.Duration
+}
+
+// Report summarizes time-to-ack and time-to-resolve per rig for the
+// escalations raised since the given time (all of them when since is
+// zero), sorted by rig.
+func Report(list []*Escalation, since time.Time) []*RigReport {
+	byRig := make(map[string]*RigReport)
+	for _, e := range list {
+		if e.CreatedAt.Before(since) {
+			continue
+		}
+		r := byRig[e.Rig]
+		if r == nil {
+			r = &RigReport{Rig: e.Rig}
+			byRig[e.Rig] = r
+		}
+		r.Total++
+		switch e.State {
+		case StateOpen:
+			r.Open++
+		case StateAcknowledged:
+			r.Acknowledged++
+		case StateResolved:
+			r.Resolved++
+		}
+		if e.Renotified > 0 {
+			r.Breached++
+		}
+		if d, ok := e.TimeToAck(); ok {
+			r.acks = append(r.acks, d)
+		}
+		if d, ok := e.TimeToResolve(); ok {
+			r.resolves = append(r.resolves, d)
+		}
+	}
+
+	reports := make([]*RigReport, 0, len(byRig))
+	for _, r := range byRig {
+		r.MeanAck, r.MaxAck = meanMax(r.acks)
+		r.MeanResolve, r.MaxResolve = meanMax(r.resolves)
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Rig < reports[j].Rig })
+	return reports
+}
+
+func meanMax(ds []time.Duration) (mean, maxD time.Duration) {
+	if len(ds) == 0 {
+		return 0, 0
+	}
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+		maxD = max(maxD, d)
+	}
+	return sum / time.Duration(len(ds)), maxD
+}
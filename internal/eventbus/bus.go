@@ -0,0 +1,399 @@
+// Package eventbus provides the in-town event bus.
+//
+// Events are still appended to ~/gt/.events.jsonl, which stays the durable
+// sink. The bus, run by the daemon, tails that log once and fans each event
+// out to subscribers by topic, so consumers react as events land instead of
+// each polling the file. Byte offsets into the log identify events, which
+// lets a subscriber replay history from an offset and resume after a
+// disconnect without gaps or duplicates.
+//
+// Each subscriber has a bounded buffer. A subscriber that falls behind is
+// dropped with a LaggedError carrying the offset to resume from, so one slow
+// consumer never holds back the rest of the town.
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// FromLive subscribes to new events only, skipping history.
+const FromLive int64 = -1
+
+// DefaultBuffer is the per-subscriber buffer used when none is given.
+const DefaultBuffer = 256
+
+// pollInterval is how often the bus checks the log for events appended by
+// other processes. Events published through the bus are delivered at once.
+const pollInterval = 100 * time.Millisecond
+
+// Message is an event delivered to a subscriber.
+type Message struct {
+	// Offset is the byte offset of the event in the events log.
+	Offset int64 `json:"offset"`
+
+	// Next is the offset just past the event; subscribing from Next resumes
+	// after this event.
+	Next int64 `json:"next"`
+
+	Topic string       `json:"topic"`
+	Event events.Event `json:"event"`
+}
+
+// LaggedError is returned by a subscription that was dropped because its
+// buffer filled. Resume is the offset of the first undelivered event.
+type LaggedError struct {
+	Resume int64
+}
+
+func (e *LaggedError) Error() string {
+	return fmt.Sprintf("subscriber lagged; resume from offset %d", e.Resume)
+}
+
+// ErrClosed is returned when subscribing to a bus that has stopped.
+var ErrClosed = errors.New("event bus closed")
+
+// SubscribeOptions configures a subscription.
+type SubscribeOptions struct {
+	// Topics are patterns to match (see Match). Empty means all topics.
+	Topics []string `json:"topics,omitempty"`
+
+	// From is the log offset to replay from, or FromLive.
+	From int64 `json:"from"`
+
+	// Buffer is the number of undelivered messages held before the
+	// subscriber is dropped. Zero uses DefaultBuffer.
+	Buffer int `json:"buffer,omitempty"`
+}
+
+// Bus fans events from the town's events log out to subscribers.
+type Bus struct {
+	townRoot string
+	path     string
+	wake     chan struct{}
+	stopped  chan struct{}
+
+	mu     sync.Mutex
+	offset int64 // end of the last complete line dispatched
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// New creates a bus for the town at townRoot. Call Run to start it.
+// Events already in the log are only delivered to subscribers that ask
+// for a replay.
+func New(townRoot string) *Bus {
+	b := &Bus{
+		townRoot: townRoot,
+		path:     filepath.Join(townRoot, events.EventsFile),
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+		subs:     make(map[*Subscription]struct{}),
+	}
+	if info, err := os.Stat(b.path); err == nil {
+		b.offset = info.Size()
+	}
+	return b
+}
+
+// Offset returns the log offset up to which events have been dispatched.
+func (b *Bus) Offset() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.offset
+}
+
+// Run tails the events log until ctx is cancelled, dispatching new events
+// to subscribers.
+func (b *Bus) Run(ctx context.Context) error {
+	file, err := os.OpenFile(b.path, os.O_RDONLY|os.O_CREATE, 0644) //nolint:gosec // G302: events file is non-sensitive operational data
+	if err != nil {
+		b.shutdown()
+		return fmt.Errorf("opening events file: %w", err)
+	}
+	defer file.Close()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.shutdown()
+			return nil
+		case <-ticker.C:
+		case <-b.wake:
+		}
+		b.dispatchNew(file)
+	}
+}
+
+// Publish appends an event to the events log and wakes the bus so that
+// subscribers see it immediately. A missing timestamp or visibility is
+// filled in.
+func (b *Bus) Publish(event events.Event) error {
+	if event.Timestamp == "" {
+		event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	if event.Visibility == "" {
+		event.Visibility = events.VisibilityFeed
+	}
+	if event.Type == "" {
+		return fmt.Errorf("event type is required")
+	}
+	if err := events.Append(b.townRoot, event); err != nil {
+		return err
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// dispatchNew reads complete lines past the current offset and delivers
+// them to live subscribers.
+func (b *Bus) dispatchNew(file *os.File) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if info, err := file.Stat(); err == nil && info.Size() < b.offset {
+		// The log was truncated or rotated; start over from the top.
+		b.offset = 0
+	}
+
+	b.offset, _ = readLines(file, b.offset, -1, func(msg Message) bool {
+		for sub := range b.subs {
+			if sub.replaying || !matchAny(sub.topics, msg.Topic) {
+				continue
+			}
+			select {
+			case sub.ch <- msg:
+			default:
+				sub.err = &LaggedError{Resume: msg.Offset}
+				b.removeLocked(sub)
+			}
+		}
+		return true
+	})
+}
+
+// Subscribe registers a subscriber. Events from opts.From up to the live
+// position are replayed first, then live events follow without a gap.
+func (b *Bus) Subscribe(opts SubscribeOptions) (*Subscription, error) {
+	size := opts.Buffer
+	if size <= 0 {
+		size = DefaultBuffer
+	}
+	sub := &Subscription{
+		bus:    b,
+		topics: opts.Topics,
+		ch:     make(chan Message, size),
+		done:   make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	b.subs[sub] = struct{}{}
+	if opts.From >= 0 && opts.From < b.offset {
+		sub.replaying = true
+		go sub.replay(opts.From)
+	}
+	return sub, nil
+}
+
+// removeLocked drops a subscriber and closes its channel. b.mu must be held.
+func (b *Bus) removeLocked(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
+
+// shutdown closes every live subscriber. Replaying subscribers notice on
+// their next handover and close themselves.
+func (b *Bus) shutdown() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.stopped)
+	for sub := range b.subs {
+		if !sub.replaying {
+			sub.err = ErrClosed
+			b.removeLocked(sub)
+		}
+	}
+}
+
+// Subscription is a live feed of messages matching a set of topics.
+type Subscription struct {
+	bus    *Bus
+	topics []string
+	ch     chan Message
+	done   chan struct{}
+	once   sync.Once
+
+	// Guarded by bus.mu.
+	replaying bool
+	err       error
+}
+
+// Messages returns the channel messages are delivered on. It is closed
+// when the subscription ends; Err then reports why.
+func (s *Subscription) Messages() <-chan Message {
+	return s.ch
+}
+
+// Err returns the reason the subscription ended: a *LaggedError, ErrClosed,
+// or nil if it was closed by the subscriber.
+func (s *Subscription) Err() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.once.Do(func() { close(s.done) })
+
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if !s.replaying {
+		s.bus.removeLocked(s)
+	}
+	// A replaying subscription closes itself when it sees done.
+}
+
+// replay delivers history from offset from, then hands over to live
+// dispatch. Each pass reads up to the bus's current offset without holding
+// the lock; the handover happens under the lock once the replay has caught
+// up, so no event is skipped or delivered twice.
+func (s *Subscription) replay(from int64) {
+	b := s.bus
+	file, err := os.Open(b.path)
+	if err != nil {
+		b.mu.Lock()
+		s.err = fmt.Errorf("opening events file: %w", err)
+		s.replaying = false
+		b.removeLocked(s)
+		b.mu.Unlock()
+		return
+	}
+	defer file.Close()
+
+	cursor := alignToLine(file, from)
+	for {
+		b.mu.Lock()
+		target := b.offset
+		if cursor >= target || b.closed {
+			s.replaying = false
+			if b.closed {
+				s.err = ErrClosed
+				b.removeLocked(s)
+			}
+			select {
+			case <-s.done:
+				b.removeLocked(s)
+			default:
+			}
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		var stopErr error
+		stopped := false
+		next, err := readLines(file, cursor, target, func(msg Message) bool {
+			if !matchAny(s.topics, msg.Topic) {
+				return true
+			}
+			select {
+			case s.ch <- msg:
+				return true
+			case <-s.done:
+			case <-b.stopped:
+				stopErr = ErrClosed
+			}
+			stopped = true
+			return false
+		})
+		if err != nil {
+			stopErr, stopped = fmt.Errorf("reading events file: %w", err), true
+		}
+		if stopped {
+			b.mu.Lock()
+			s.replaying = false
+			s.err = stopErr
+			b.removeLocked(s)
+			b.mu.Unlock()
+			return
+		}
+		cursor = next
+	}
+}
+
+// alignToLine returns from if it starts a line, otherwise the start of the
+// next line, so a stale or hand-typed offset never yields half an event.
+func alignToLine(file *os.File, from int64) int64 {
+	if from <= 0 {
+		return 0
+	}
+	buf := make([]byte, 1)
+	if _, err := file.ReadAt(buf, from-1); err != nil || buf[0] == '\n' {
+		return from
+	}
+	reader := bufio.NewReader(io.NewSectionReader(file, from, 1<<62))
+	skipped, err := reader.ReadBytes('\n')
+	if err != nil {
+		return from
+	}
+	return from + int64(len(skipped))
+}
+
+// readLines calls fn for each complete line in file between start and end
+// (end < 0 reads to EOF) and returns the offset just past the last line
+// read. Malformed lines are skipped. It stops early when fn returns false.
+func readLines(file *os.File, start, end int64, fn func(Message) bool) (int64, error) {
+	limit := int64(1 << 62)
+	if end >= 0 {
+		limit = end - start
+	}
+	reader := bufio.NewReader(io.NewSectionReader(file, start, limit))
+	offset := start
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// A trailing partial line is picked up on the next read.
+			if errors.Is(err, io.EOF) {
+				return offset, nil
+			}
+			return offset, err
+		}
+		msg := Message{Offset: offset, Next: offset + int64(len(line))}
+		offset = msg.Next
+		if err := json.Unmarshal(line, &msg.Event); err != nil {
+			continue
+		}
+		msg.Topic = TopicFor(msg.Event.Type)
+		if !fn(msg) {
+			return offset, nil
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func TestTopicForAndMatch(t *testing.T) {
+	if got := TopicFor(events.TypeMergeFailed); got != "merge.merge_failed" {
+		t.Errorf("TopicFor(merge_failed) = %q", got)
+	}
+	if got := TopicFor("custom"); got != "other.custom" {
+		t.Errorf("TopicFor(custom) = %q", got)
+	}
+
+	tests := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"*", "work.sling", true},
+		{"merge.*", "merge.merged", true},
+		{"merge.*", "mail.mail", false},
+		{"merge", "merge.merged", true},
+		{"merge", "mergers.x", false},
+		{"work.sling", "work.sling", true},
+		{"work.sling", "work.slingshot", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+// startBus runs a bus for a temp town with n events already in the log.
+func startBus(t *testing.T, n int) (*Bus, string) {
+	t.Helper()
+	town := t.TempDir()
+	for i := 0; i < n; i++ {
+		if err := events.Append(town, events.Event{Type: events.TypeSling, Actor: fmt.Sprintf("old-%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus := New(town)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = bus.Run(ctx) }()
+	return bus, town
+}
+
+func recv(t *testing.T, ch <-chan Message) Message {
+	t.Helper()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return Message{}
+}
+
+func TestBus_LiveTopicFilter(t *testing.T) {
+	bus, _ := startBus(t, 2)
+
+	sub, err := bus.Subscribe(SubscribeOptions{Topics: []string{"merge.*"}, From: FromLive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	_ = bus.Publish(events.Event{Type: events.TypeSling, Actor: "a"})
+	_ = bus.Publish(events.Event{Type: events.TypeMerged, Actor: "b", Payload: events.MergePayload("mr-1", "nux", "polecat/nux", "")})
+
+	msg := recv(t, sub.Messages())
+	if msg.Topic != "merge.merged" || msg.Event.Actor != "b" {
+		t.Fatalf("got %+v", msg)
+	}
+	var p MergePayload
+	if err := msg.Decode(&p); err != nil || p.MR != "mr-1" || p.Worker != "nux" {
+		t.Errorf("Decode = %+v, %v", p, err)
+	}
+}
+
+func TestBus_ReplayThenLive(t *testing.T) {
+	bus, _ := startBus(t, 3)
+
+	sub, err := bus.Subscribe(SubscribeOptions{From: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	_ = bus.Publish(events.Event{Type: events.TypeDone, Actor: "new"})
+
+	want := []string{"old-0", "old-1", "old-2", "new"}
+	var last Message
+	for i, actor := range want {
+		msg := recv(t, sub.Messages())
+		if msg.Event.Actor != actor {
+			t.Fatalf("message %d actor = %q, want %q", i, msg.Event.Actor, actor)
+		}
+		if i > 0 && msg.Offset != last.Next {
+			t.Errorf("message %d offset %d, want %d", i, msg.Offset, last.Next)
+		}
+		last = msg
+	}
+
+	// Subscribing from a message's offset resumes at that message.
+	resumed, err := bus.Subscribe(SubscribeOptions{From: last.Offset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	if msg := recv(t, resumed.Messages()); msg.Event.Actor != "new" {
+		t.Errorf("resume from offset got %q", msg.Event.Actor)
+	}
+}
+
+func TestBus_SlowSubscriberLags(t *testing.T) {
+	bus, _ := startBus(t, 0)
+
+	slow, err := bus.Subscribe(SubscribeOptions{From: FromLive, Buffer: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, err := bus.Subscribe(SubscribeOptions{From: FromLive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fast.Close()
+
+	for i := 0; i < 5; i++ {
+		_ = bus.Publish(events.Event{Type: events.TypeMail, Actor: fmt.Sprintf("m%d", i)})
+	}
+	for i := 0; i < 5; i++ {
+		recv(t, fast.Messages())
+	}
+
+	var got []Message
+	for msg := range slow.Messages() {
+		got = append(got, msg)
+	}
+	var lagged *LaggedError
+	if !errors.As(slow.Err(), &lagged) {
+		t.Fatalf("Err = %v, want LaggedError", slow.Err())
+	}
+	if len(got) != 2 || lagged.Resume != got[1].Next {
+		t.Errorf("got %d messages, resume %d; want 2 and %d", len(got), lagged.Resume, got[len(got)-1].Next)
+	}
+}
+
+func TestServer_PublishAndSubscribe(t *testing.T) {
+	bus, town := startBus(t, 0)
+	if err := os.MkdirAll(filepath.Join(town, "daemon"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	server := NewServer(town, bus)
+	if err := server.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { cancel(); server.Wait() }()
+
+	if !Available(town) {
+		t.Fatal("bus should be available")
+	}
+
+	stream, err := Subscribe(ctx, town, SubscribeOptions{Topics: []string{"work"}, From: FromLive, Buffer: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// Wait for the server-side subscription before publishing.
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		bus.mu.Lock()
+		n := len(bus.subs)
+		bus.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// More events than the server-side buffer holds; if the subscriber
+	// lags, the stream reconnects from the resume offset, so every matching
+	// event still arrives exactly once and in order.
+	const n = 20
+	for i := 0; i < n; i++ {
+		if err := Publish(town, events.Event{Type: events.TypeHook, Actor: fmt.Sprintf("h%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+		_ = Publish(town, events.Event{Type: events.TypeMail, Actor: "skip"})
+	}
+	for i := 0; i < n; i++ {
+		msg := recv(t, stream.Messages())
+		if want := fmt.Sprintf("h%d", i); msg.Event.Actor != want {
+			t.Fatalf("message %d = %q, want %q", i, msg.Event.Actor, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// dialTimeout bounds connecting to the daemon's socket.
+const dialTimeout = 2 * time.Second
+
+// Available reports whether a daemon is serving the event bus for a town.
+func Available(townRoot string) bool {
+	conn, err := net.DialTimeout("unix", SocketPath(townRoot), dialTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// Publish sends an event to the town's bus, which appends it to the events
+// log and delivers it to subscribers immediately. It fails if no daemon is
+// serving the bus; callers that only need durability can use events.Append.
+func Publish(townRoot string, event events.Event) error {
+	conn, err := net.DialTimeout("unix", SocketPath(townRoot), dialTimeout)
+	if err != nil {
+		return fmt.Errorf("connecting to event bus: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	if err := json.NewEncoder(conn).Encode(Request{Op: "publish", Event: &event}); err != nil {
+		return fmt.Errorf("sending event: %w", err)
+	}
+	var resp frame
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("publishing event: %s", resp.Error)
+	}
+	return nil
+}
+
+// Stream is a subscription to a town's bus over the daemon socket.
+// When the daemon reports the subscriber lagged, the stream reconnects from
+// the resume offset, so consumers see every matching event exactly once.
+type Stream struct {
+	townRoot string
+	opts     SubscribeOptions
+	ch       chan Message
+	cancel   context.CancelFunc
+
+	mu   sync.Mutex
+	err  error
+	conn net.Conn
+}
+
+// Subscribe connects to the town's bus and streams matching events until
+// ctx is cancelled or the connection fails.
+func Subscribe(ctx context.Context, townRoot string, opts SubscribeOptions) (*Stream, error) {
+	size := opts.Buffer
+	if size <= 0 {
+		size = DefaultBuffer
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Stream{
+		townRoot: townRoot,
+		opts:     opts,
+		ch:       make(chan Message, size),
+		cancel:   cancel,
+	}
+	reader, err := s.connect(opts.From)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.run(ctx, reader)
+	return s, nil
+}
+
+// Messages returns the channel events are delivered on. It is closed when
+// the stream ends; Err then reports why.
+func (s *Stream) Messages() <-chan Message {
+	return s.ch
+}
+
+// Err returns the error that ended the stream, or nil if it was closed.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close ends the stream.
+func (s *Stream) Close() {
+	s.cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+// connect dials the socket and sends the subscribe request.
+func (s *Stream) connect(from int64) (*bufio.Reader, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(s.townRoot), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to event bus: %w", err)
+	}
+	req := Request{Op: "subscribe", Topics: s.opts.Topics, Buffer: s.opts.Buffer}
+	if from >= 0 {
+		req.From = &from
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("sending subscribe request: %w", err)
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	return bufio.NewReader(conn), nil
+}
+
+// run reads frames and forwards messages, reconnecting on lag.
+func (s *Stream) run(ctx context.Context, reader *bufio.Reader) {
+	defer close(s.ch)
+	for {
+		resume, err := s.forward(ctx, reader)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		var lagged *LaggedError
+		if !errors.As(err, &lagged) {
+			s.fail(err)
+			return
+		}
+		if reader, err = s.connect(resume); err != nil {
+			s.fail(err)
+			return
+		}
+	}
+}
+
+// forward copies message frames to the channel until the connection ends.
+// A lagged frame returns a *LaggedError and the offset to resume from.
+func (s *Stream) forward(ctx context.Context, reader *bufio.Reader) (int64, error) {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("event bus connection lost: %w", err)
+		}
+		var f frame
+		if err := json.Unmarshal(line, &f); err != nil {
+			return 0, fmt.Errorf("decoding event: %w", err)
+		}
+		switch {
+		case f.Error == errLagged && f.Resume != nil:
+			return *f.Resume, &LaggedError{Resume: *f.Resume}
+		case f.Error != "":
+			return 0, fmt.Errorf("event bus: %s", f.Error)
+		case f.Event == nil:
+			continue
+		}
+
+		msg := Message{Offset: f.Offset, Next: f.Next, Topic: f.Topic, Event: *f.Event}
+		select {
+		case s.ch <- msg:
+		case <-ctx.Done():
+			return 0, nil
+		}
+	}
+}
+
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
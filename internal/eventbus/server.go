@@ -0,0 +1,213 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// SocketFile is the event bus socket, relative to the town root.
+const SocketFile = "daemon/events.sock"
+
+// writeTimeout bounds how long the server waits on a stalled subscriber
+// connection before dropping it.
+const writeTimeout = 10 * time.Second
+
+// SocketPath returns the event bus socket path for a town.
+func SocketPath(townRoot string) string {
+	return filepath.Join(townRoot, SocketFile)
+}
+
+// Wire protocol: newline-delimited JSON over the Unix socket.
+//
+// A client sends one request per line. {"op":"publish","event":{...}} is
+// answered with {"ok":true} or {"error":"..."}, and any number of publishes
+// may share a connection. {"op":"subscribe","topics":[...],"from":N} turns
+// the connection into a stream of Message lines; if the subscriber lags,
+// the stream ends with {"error":"lagged","resume":N}.
+
+// Request is a client request on the bus socket.
+type Request struct {
+	Op     string        `json:"op"` // "subscribe" or "publish"
+	Event  *events.Event `json:"event,omitempty"`
+	Topics []string      `json:"topics,omitempty"`
+	From   *int64        `json:"from,omitempty"` // nil means live only
+	Buffer int           `json:"buffer,omitempty"`
+}
+
+// frame is a server response line. Message frames carry Topic and Event;
+// control frames carry OK or Error.
+type frame struct {
+	Offset int64         `json:"offset,omitempty"`
+	Next   int64         `json:"next,omitempty"`
+	Topic  string        `json:"topic,omitempty"`
+	Event  *events.Event `json:"event,omitempty"`
+	OK     bool          `json:"ok,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Resume *int64        `json:"resume,omitempty"`
+}
+
+// errLagged is the wire error for a dropped subscriber.
+const errLagged = "lagged"
+
+// Server serves a Bus on the town's Unix socket.
+type Server struct {
+	bus      *Bus
+	path     string
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a server for bus at the town's socket path.
+func NewServer(townRoot string, bus *Bus) *Server {
+	return &Server{bus: bus, path: SocketPath(townRoot)}
+}
+
+// Start listens on the socket, replacing a stale socket left by a previous
+// daemon, and serves connections until ctx is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("creating socket directory: %w", err)
+	}
+	_ = os.Remove(s.path)
+
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.path, err)
+	}
+	s.listener = l
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(ctx, conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the listener and all connections have finished.
+func (s *Server) Wait() {
+	s.wg.Wait()
+	_ = os.Remove(s.path)
+}
+
+// serve handles one client connection.
+func (s *Server) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+	enc := json.NewEncoder(conn)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			_ = enc.Encode(frame{Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+
+		switch req.Op {
+		case "publish":
+			resp := frame{OK: true}
+			if req.Event == nil {
+				resp = frame{Error: "publish requires an event"}
+			} else if err := s.bus.Publish(*req.Event); err != nil {
+				resp = frame{Error: err.Error()}
+			}
+			if err := enc.Encode(resp); err != nil {
+				return
+			}
+
+		case "subscribe":
+			s.stream(ctx, conn, enc, req)
+			return
+
+		default:
+			_ = enc.Encode(frame{Error: fmt.Sprintf("unknown op %q", req.Op)})
+			return
+		}
+	}
+}
+
+// stream forwards a subscription to the connection until the subscription
+// ends, the client hangs up, or the server stops.
+func (s *Server) stream(ctx context.Context, conn net.Conn, enc *json.Encoder, req Request) {
+	opts := SubscribeOptions{Topics: req.Topics, From: FromLive, Buffer: req.Buffer}
+	if req.From != nil {
+		opts.From = *req.From
+	}
+	sub, err := s.bus.Subscribe(opts)
+	if err != nil {
+		_ = enc.Encode(frame{Error: err.Error()})
+		return
+	}
+	defer sub.Close()
+
+	// Subscribers don't send anything after the request; a read returning
+	// means the client went away.
+	hangup := make(chan struct{})
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		close(hangup)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			return
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				var lagged *LaggedError
+				if errors.As(sub.Err(), &lagged) {
+					_ = enc.Encode(frame{Error: errLagged, Resume: &lagged.Resume})
+				} else if err := sub.Err(); err != nil {
+					_ = enc.Encode(frame{Error: err.Error()})
+				}
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			ev := msg.Event
+			if err := enc.Encode(frame{Offset: msg.Offset, Next: msg.Next, Topic: msg.Topic, Event: &ev}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package eventbus
+
+import (
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// Topic categories. Every event is published on "<category>.<type>", so
+// subscribers can follow a whole category ("merge.*") or a single event
+// type ("merge.merge_failed").
+const (
+	CategoryWork    = "work"
+	CategoryMail    = "mail"
+	CategorySession = "session"
+	CategoryPatrol  = "patrol"
+	CategoryMerge   = "merge"
+	CategoryOther   = "other"
+)
+
+// categories maps known event types to their topic category.
+var categories = map[string]string{
+	events.TypeSling:          CategoryWork,
+	events.TypeHook:           CategoryWork,
+	events.TypeUnhook:         CategoryWork,
+	events.TypeHandoff:        CategoryWork,
+	events.TypeDone:           CategoryWork,
+	events.TypeConvoyStranded: CategoryWork,
+
+	events.TypeMail: CategoryMail,
+
+	events.TypeSpawn:          CategorySession,
+	events.TypeKill:           CategorySession,
+	events.TypeNudge:          CategorySession,
+	events.TypeBoot:           CategorySession,
+	events.TypeHalt:           CategorySession,
+	events.TypeSessionStart:   CategorySession,
+	events.TypeSessionEnd:     CategorySession,
+	events.TypePolecatCrashed: CategorySession,
+	events.TypeAutoscale:      CategorySession,
+
+	events.TypePatrolStarted:  CategoryPatrol,
+	events.TypePolecatChecked: CategoryPatrol,
+	events.TypePolecatNudged:  CategoryPatrol,
+	events.TypeEscalationSent: CategoryPatrol,
+	events.TypePatrolComplete: CategoryPatrol,
+	events.TypeDigest:         CategoryPatrol,
+
+	events.TypeEscalationAcked:    CategoryPatrol,
+	events.TypeEscalationResolved: CategoryPatrol,
+	events.TypeEscalationBreached: CategoryPatrol,
+	events.TypeDogJob:             CategoryPatrol,
+
+	events.TypeMergeStarted: CategoryMerge,
+	events.TypeMerged:       CategoryMerge,
+	events.TypeMergeFailed:  CategoryMerge,
+	events.TypeMergeSkipped: CategoryMerge,
+}
+
+// TopicFor returns the topic an event type is published on.
+// Unknown types land in the "other" category.
+func TopicFor(eventType string) string {
+	category, ok := categories[eventType]
+	if !ok {
+		category = CategoryOther
+	}
+	return category + "." + eventType
+}
+
+// Match reports whether topic matches a subscription pattern.
+// Patterns are "*" (everything), "<category>.*" or "<category>" (a whole
+// category), or an exact topic.
+func Match(pattern, topic string) bool {
+	switch {
+	case pattern == "*" || pattern == "":
+		return true
+	case strings.HasSuffix(pattern, ".*"):
+		return strings.HasPrefix(topic, strings.TrimSuffix(pattern, "*"))
+	case !strings.Contains(pattern, "."):
+		return strings.HasPrefix(topic, pattern+".")
+	default:
+		return pattern == topic
+	}
+}
+
+// matchAny reports whether topic matches any of the patterns.
+// An empty pattern list matches everything.
+func matchAny(patterns []string, topic string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if Match(p, topic) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Typed payloads for the common event categories. They mirror the payload
+// helpers in the events package; decode a message into one with
+// Message.Decode. Fields absent from a given event type are left zero.
+
+// WorkPayload is the payload of work.* events (sling, hook, unhook, handoff, done).
+type WorkPayload struct {
+	Bead      string `json:"bead,omitempty"`
+	Target    string `json:"target,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	ToSession bool   `json:"to_session,omitempty"`
+}
+
+// MailPayload is the payload of mail.* events.
+type MailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+}
+
+// SessionPayload is the payload of session.* events (spawn, kill, nudge,
+// boot, halt, session_start, session_end).
+type SessionPayload struct {
+	Rig       string   `json:"rig,omitempty"`
+	Polecat   string   `json:"polecat,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	Agents    []string `json:"agents,omitempty"`
+	Services  []string `json:"services,omitempty"`
+	SessionID string   `json:"session_id,omitempty"`
+	Role      string   `json:"role,omitempty"`
+	Topic     string   `json:"topic,omitempty"`
+	Cwd       string   `json:"cwd,omitempty"`
+}
+
+// PatrolPayload is the payload of patrol.* events.
+type PatrolPayload struct {
+	Rig          string `json:"rig,omitempty"`
+	Polecat      string `json:"polecat,omitempty"`
+	Status       string `json:"status,omitempty"`
+	Issue        string `json:"issue,omitempty"`
+	Target       string `json:"target,omitempty"`
+	To           string `json:"to,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	Message      string `json:"message,omitempty"`
+	PolecatCount int    `json:"polecat_count,omitempty"`
+}
+
+// MergePayload is the payload of merge.* events.
+type MergePayload struct {
+	MR     string `json:"mr"`
+	Worker string `json:"worker"`
+	Branch string `json:"branch"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Decode unmarshals the event payload into v, which is usually one of the
+// typed payloads above.
+func (m *Message) Decode(v interface{}) error {
+	data, err := json.Marshal(m.Event.Payload)
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decoding %s payload: %w", m.Topic, err)
+	}
+	return nil
+}
@@ -62,6 +62,35 @@ const (
 	TypeMerged       = "merged"
 	TypeMergeFailed  = "merge_failed"
 	TypeMergeSkipped = "merge_skipped"
+
+	// Health events (emitted by the daemon and deacon patrols)
+	TypePolecatCrashed = "polecat_crashed"
+	TypeConvoyStranded = "convoy_stranded"
+
+	// Autoscaler decisions (emitted by the daemon)
+	TypeAutoscale = "autoscale"
+
+	// Wisp digests (emitted by gt mol squash and daemon rollups)
+	TypeDigest = "digest"
+
+	// Escalation lifecycle (gt escalate ack|resolve, and the daemon when an
+	// escalation misses its SLA)
+	TypeEscalationAcked    = "escalation_acked"
+	TypeEscalationResolved = "escalation_resolved"
+	TypeEscalationBreached = "escalation_breached"
+
+	// Health check status changes (emitted by gt doctor --watch)
+	TypeDoctorCheck = "doctor_check"
+
+	// Dog job results (queued and run by the daemon)
+	TypeDogJob = "dog_job"
+)
+
+// Autoscale actions recorded in autoscale event payloads.
+const (
+	AutoscaleSpawn = "spawn" // polecat slung ready work
+	AutoscaleReap  = "reap"  // idle polecat nuked after its cool-down
+	AutoscaleHold  = "hold"  // ready work left waiting (budget, load or pool)
 )
 
 // EventsFile is the name of the raw events log.
@@ -103,7 +132,12 @@ func write(event Event) error {
 		// Silently ignore - we're not in a Gas Town workspace
 		return nil
 	}
+	return Append(townRoot, event)
+}
 
+// Append writes an event to the events log of the town at townRoot.
+// The log is the durable sink for the event bus, which tails it.
+func Append(townRoot string, event Event) error {
 	eventsPath := filepath.Join(townRoot, EventsFile)
 
 	// Marshal event to JSON
@@ -251,6 +285,42 @@ func EscalationPayload(rig, target, to, reason string) map[string]interface{} {
 	}
 }
 
+// EscalationLifecyclePayload creates a payload for escalation_acked and
+// escalation_resolved events. by is who acted; note is optional.
+func EscalationLifecyclePayload(id, rig, severity, topic, by, note string) map[string]interface{} {
+	p := map[string]interface{}{
+		"escalation": id,
+		"severity":   severity,
+		"reason":     topic,
+		"by":         by,
+	}
+	if rig != "" {
+		p["rig"] = rig
+	}
+	if note != "" {
+		p["message"] = note
+	}
+	return p
+}
+
+// EscalationBreachedPayload creates a payload for escalation_breached
+// events. breach is the missed SLA (ack or resolve); note is optional.
+func EscalationBreachedPayload(id, rig, severity, topic, breach, note string) map[string]interface{} {
+	p := map[string]interface{}{
+		"escalation": id,
+		"severity":   severity,
+		"reason":     topic,
+		"breach":     breach,
+	}
+	if rig != "" {
+		p["rig"] = rig
+	}
+	if note != "" {
+		p["message"] = note
+	}
+	return p
+}
+
 // UnhookPayload creates a payload for unhook events.
 func UnhookPayload(beadID string) map[string]interface{} {
 	return map[string]interface{}{
@@ -293,3 +363,96 @@ func SessionPayload(sessionID, role, topic, cwd string) map[string]interface{} {
 	}
 	return p
 }
+
+// CrashPayload creates a payload for polecat_crashed events.
+// restartErr is empty when the automatic restart succeeded.
+func CrashPayload(rig, polecat, hookBead, restartErr string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":     rig,
+		"polecat": polecat,
+		"bead":    hookBead,
+	}
+	if restartErr != "" {
+		p["reason"] = restartErr
+	}
+	return p
+}
+
+// AutoscalePayload creates a payload for autoscale events. polecat and bead
+// are empty when they don't apply to the action.
+func AutoscalePayload(rig, action, polecat, bead, reason string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":    rig,
+		"action": action,
+		"reason": reason,
+	}
+	if polecat != "" {
+		p["polecat"] = polecat
+	}
+	if bead != "" {
+		p["bead"] = bead
+	}
+	return p
+}
+
+// DigestPayload creates a payload for digest events. molecule and bead are
+// empty for rollups.
+func DigestPayload(agent, period, molecule, bead string, cycles int) map[string]interface{} {
+	p := map[string]interface{}{
+		"agent":  agent,
+		"period": period,
+		"cycles": cycles,
+	}
+	if molecule != "" {
+		p["molecule"] = molecule
+	}
+	if bead != "" {
+		p["bead"] = bead
+	}
+	return p
+}
+
+// DoctorCheckPayload creates a payload for doctor_check events. from is
+// empty when the check had no previous status.
+func DoctorCheckPayload(check, category, from, to, message string) map[string]interface{} {
+	p := map[string]interface{}{
+		"check":   check,
+		"status":  to,
+		"message": message,
+	}
+	if category != "" {
+		p["category"] = category
+	}
+	if from != "" {
+		p["previous"] = from
+	}
+	return p
+}
+
+// DogJobPayload creates a payload for dog_job events. status is
+// succeeded, failed or retrying.
+func DogJobPayload(job, run, jobType, dog, status string, attempt int, message string) map[string]interface{} {
+	p := map[string]interface{}{
+		"job":     job,
+		"run":     run,
+		"type":    jobType,
+		"status":  status,
+		"attempt": attempt,
+	}
+	if dog != "" {
+		p["dog"] = dog
+	}
+	if message != "" {
+		p["message"] = message
+	}
+	return p
+}
+
+// StrandedPayload creates a payload for convoy_stranded events.
+func StrandedPayload(convoyID, title string, readyCount int) map[string]interface{} {
+	return map[string]interface{}{
+		"convoy":      convoyID,
+		"title":       title,
+		"ready_count": readyCount,
+	}
+}
@@ -1,7 +1,7 @@
 // Package feed provides the feed daemon that curates raw events into a user-facing feed.
 //
 // The curator:
-// 1. Tails ~/gt/.events.jsonl (raw events)
+// 1. Tails ~/gt/.events.jsonl (raw events), or subscribes to the event bus
 // 2. Filters by visibility tag (drops audit-only events)
 // 3. Deduplicates repeated updates (5 molecule updates → "agent active")
 // 4. Aggregates related events (3 issues closed → "batch complete")
@@ -12,6 +12,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -19,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/eventbus"
 	"github.com/steveyegge/gastown/internal/events"
 )
 
@@ -105,6 +107,21 @@ func (c *Curator) Start() error {
 	return nil
 }
 
+// StartWithBus begins curating from an event bus subscription instead of
+// tailing the events file. The daemon uses this so the curator shares the
+// bus's single tail of the log.
+func (c *Curator) StartWithBus(bus *eventbus.Bus) error {
+	sub, err := bus.Subscribe(eventbus.SubscribeOptions{From: eventbus.FromLive})
+	if err != nil {
+		return fmt.Errorf("subscribing to event bus: %w", err)
+	}
+
+	c.wg.Add(1)
+	go c.runBus(bus, sub)
+
+	return nil
+}
+
 // Stop gracefully stops the curator.
 func (c *Curator) Stop() {
 	c.cancel()
@@ -145,6 +162,42 @@ func (c *Curator) run(file *os.File) {
 	}
 }
 
+// runBus is the curator loop when fed by the event bus.
+func (c *Curator) runBus(bus *eventbus.Bus, sub *eventbus.Subscription) {
+	defer c.wg.Done()
+	defer func() { sub.Close() }()
+
+	cleanupTicker := time.NewTicker(time.Minute)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+
+		case <-cleanupTicker.C:
+			c.cleanupStaleState()
+
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				// Catch up from where we fell behind; stop if the bus closed.
+				var lagged *eventbus.LaggedError
+				if !errors.As(sub.Err(), &lagged) {
+					return
+				}
+				next, err := bus.Subscribe(eventbus.SubscribeOptions{From: lagged.Resume})
+				if err != nil {
+					return
+				}
+				sub = next
+				continue
+			}
+			event := msg.Event
+			c.processEvent(&event)
+		}
+	}
+}
+
 // processLine processes a single line from the events file.
 func (c *Curator) processLine(line string) {
 	if line == "" || line == "\n" {
@@ -155,19 +208,23 @@ func (c *Curator) processLine(line string) {
 	if err := json.Unmarshal([]byte(line), &rawEvent); err != nil {
 		return // Skip malformed lines
 	}
+	c.processEvent(&rawEvent)
+}
 
+// processEvent filters, dedupes and writes a single raw event.
+func (c *Curator) processEvent(rawEvent *events.Event) {
 	// Filter by visibility - only process feed-visible events
 	if rawEvent.Visibility != events.VisibilityFeed && rawEvent.Visibility != events.VisibilityBoth {
 		return
 	}
 
 	// Apply deduplication and aggregation
-	if c.shouldDedupe(&rawEvent) {
+	if c.shouldDedupe(rawEvent) {
 		return
 	}
 
 	// Write to feed
-	c.writeFeedEvent(&rawEvent)
+	c.writeFeedEvent(rawEvent)
 }
 
 // shouldDedupe checks if an event should be deduplicated.
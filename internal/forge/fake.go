@@ -0,0 +1,143 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Fake is an in-memory Forge for tests. Pull requests start with no checks
+// and no reviews; tests add them with SetChecks and AddReview.
+type Fake struct {
+	mu      sync.Mutex
+	next    int
+	prs     map[int]*PullRequest
+	checks  map[int][]Check
+	reviews map[int][]Review
+
+	// HeadSHAs maps branch names to the head SHA reported for new PRs.
+	HeadSHAs map[string]string
+
+	// MergeErr, if set, is returned by Merge.
+	MergeErr error
+}
+
+// NewFake creates an empty fake forge.
+func NewFake() *Fake {
+	return &Fake{
+		next:     1,
+		prs:      make(map[int]*PullRequest),
+		checks:   make(map[int][]Check),
+		reviews:  make(map[int][]Review),
+		HeadSHAs: make(map[string]string),
+	}
+}
+
+// Name implements Forge.
+func (f *Fake) Name() string { return "fake" }
+
+// FindPR implements Forge.
+func (f *Fake) FindPR(_ context.Context, head, base string) (*PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for n := 1; n < f.next; n++ {
+		pr := f.prs[n]
+		if pr != nil && pr.State == "open" && pr.Head == head && pr.Base == base {
+			cp := *pr
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreatePR implements Forge.
+func (f *Fake) CreatePR(_ context.Context, opts PROptions) (*PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.next
+	f.next++
+	pr := &PullRequest{
+		Number:  n,
+		URL:     fmt.Sprintf("https://forge.test/pulls/%d", n),
+		Head:    opts.Head,
+		Base:    opts.Base,
+		HeadSHA: f.HeadSHAs[opts.Head],
+		Title:   opts.Title,
+		Body:    opts.Body,
+		State:   "open",
+	}
+	f.prs[n] = pr
+	cp := *pr
+	return &cp, nil
+}
+
+// UpdatePR implements Forge.
+func (f *Fake) UpdatePR(_ context.Context, number int, opts PROptions) (*PullRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, ok := f.prs[number]
+	if !ok {
+		return nil, fmt.Errorf("pull request #%d not found", number)
+	}
+	pr.Title, pr.Body = opts.Title, opts.Body
+	cp := *pr
+	return &cp, nil
+}
+
+// Checks implements Forge.
+func (f *Fake) Checks(_ context.Context, pr *PullRequest) ([]Check, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Check(nil), f.checks[pr.Number]...), nil
+}
+
+// Reviews implements Forge.
+func (f *Fake) Reviews(_ context.Context, number int) ([]Review, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Review(nil), f.reviews[number]...), nil
+}
+
+// Merge implements Forge.
+func (f *Fake) Merge(_ context.Context, pr *PullRequest, _ string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.MergeErr != nil {
+		return "", f.MergeErr
+	}
+	stored, ok := f.prs[pr.Number]
+	if !ok || stored.State != "open" {
+		return "", fmt.Errorf("pull request #%d is not open", pr.Number)
+	}
+	stored.State = "merged"
+	return fmt.Sprintf("merge%04d", pr.Number), nil
+}
+
+// SetChecks replaces the checks reported for a pull request.
+func (f *Fake) SetChecks(number int, checks ...Check) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checks[number] = checks
+}
+
+// AddReview appends a review to a pull request.
+func (f *Fake) AddReview(number int, review Review) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reviews[number] = append(f.reviews[number], review)
+}
+
+// PR returns a copy of a pull request, or nil if it doesn't exist.
+func (f *Fake) PR(number int) *PullRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pr, ok := f.prs[number]
+	if !ok {
+		return nil
+	}
+	cp := *pr
+	return &cp
+}
+
+// Ensure Fake implements Forge.
+var _ Forge = (*Fake)(nil)
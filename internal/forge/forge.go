@@ -0,0 +1,334 @@
+// Package forge talks to code hosting services (GitHub, GitLab, Gitea) so
+// the refinery can land work through pull requests instead of pushing to
+// the target branch directly.
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Forge is a code host that can open, inspect and merge pull requests.
+// GitLab merge requests are treated as pull requests; Number is the IID.
+type Forge interface {
+	// Name identifies the forge type ("github", "gitlab", "gitea", "fake").
+	Name() string
+
+	// FindPR returns the open pull request from head into base, or nil if
+	// there is none.
+	FindPR(ctx context.Context, head, base string) (*PullRequest, error)
+
+	// CreatePR opens a pull request.
+	CreatePR(ctx context.Context, opts PROptions) (*PullRequest, error)
+
+	// UpdatePR updates the title and body of an open pull request.
+	UpdatePR(ctx context.Context, number int, opts PROptions) (*PullRequest, error)
+
+	// Checks returns the status checks reported for the pull request's
+	// head commit.
+	Checks(ctx context.Context, pr *PullRequest) ([]Check, error)
+
+	// Reviews returns the reviews on a pull request, oldest first.
+	Reviews(ctx context.Context, number int) ([]Review, error)
+
+	// Merge merges the pull request with the given method ("merge",
+	// "squash" or "rebase") and returns the resulting commit SHA.
+	Merge(ctx context.Context, pr *PullRequest, method string) (string, error)
+}
+
+// PullRequest is a pull (or merge) request on a forge.
+type PullRequest struct {
+	Number  int    `json:"number"`
+	URL     string `json:"url"`
+	Head    string `json:"head"`
+	Base    string `json:"base"`
+	HeadSHA string `json:"head_sha,omitempty"`
+	Title   string `json:"title"`
+	Body    string `json:"body,omitempty"`
+	State   string `json:"state"` // open, closed, merged
+}
+
+// PROptions describes a pull request to open or update.
+type PROptions struct {
+	Head  string
+	Base  string
+	Title string
+	Body  string
+}
+
+// CheckState is the state of a status check, or of a set of checks.
+type CheckState string
+
+const (
+	CheckPending CheckState = "pending"
+	CheckSuccess CheckState = "success"
+	CheckFailure CheckState = "failure"
+)
+
+// Check is one CI status or check run on a commit.
+type Check struct {
+	Name  string     `json:"name"`
+	State CheckState `json:"state"`
+	URL   string     `json:"url,omitempty"`
+}
+
+// ReviewState is the verdict of a review.
+type ReviewState string
+
+const (
+	ReviewApproved         ReviewState = "approved"
+	ReviewChangesRequested ReviewState = "changes_requested"
+	ReviewCommented        ReviewState = "commented"
+)
+
+// Review is a reviewer's verdict on a pull request with its inline comments.
+type Review struct {
+	ID          string          `json:"id"`
+	Author      string          `json:"author"`
+	State       ReviewState     `json:"state"`
+	Body        string          `json:"body,omitempty"`
+	Comments    []ReviewComment `json:"comments,omitempty"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+}
+
+// ReviewComment is an inline comment left as part of a review.
+type ReviewComment struct {
+	Author string `json:"author,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Body   string `json:"body"`
+}
+
+// Config selects and configures a forge. It lives in the merge_queue.forge
+// section of a rig's config.json.
+type Config struct {
+	// Type is "github", "gitlab" or "gitea". Empty detects it from the
+	// origin remote (github.com and gitlab hosts only).
+	Type string `json:"type,omitempty"`
+
+	// Repo is the repository path ("owner/name", or the full project path
+	// on GitLab). Empty derives it from the origin remote.
+	Repo string `json:"repo,omitempty"`
+
+	// URL is the forge base URL for GitLab and Gitea (e.g.,
+	// "https://gitea.example.com"). Empty derives it from the origin remote.
+	URL string `json:"url,omitempty"`
+
+	// TokenEnv names the environment variable holding the API token for
+	// GitLab and Gitea. Defaults to GITLAB_TOKEN or GITEA_TOKEN. GitHub
+	// uses the gh CLI's own authentication.
+	TokenEnv string `json:"token_env,omitempty"`
+
+	// MergeMethod is "merge" (default), "squash" or "rebase".
+	MergeMethod string `json:"merge_method,omitempty"`
+
+	// RequiredChecks names the checks that must pass before merging.
+	// Empty requires every reported check to pass.
+	RequiredChecks []string `json:"required_checks,omitempty"`
+
+	// RequireApproval holds the merge until at least one reviewer approves.
+	RequireApproval bool `json:"require_approval,omitempty"`
+}
+
+// Method returns the configured merge method, defaulting to "merge".
+func (c *Config) Method() string {
+	if c == nil || c.MergeMethod == "" {
+		return "merge"
+	}
+	return c.MergeMethod
+}
+
+// Validate checks the configuration for unknown values.
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Type {
+	case "", "github", "gitlab", "gitea":
+	default:
+		return fmt.Errorf("unknown forge type %q (want github, gitlab or gitea)", c.Type)
+	}
+	switch c.MergeMethod {
+	case "", "merge", "squash", "rebase":
+	default:
+		return fmt.Errorf("unknown merge_method %q (want merge, squash or rebase)", c.MergeMethod)
+	}
+	return nil
+}
+
+// New creates the forge described by cfg. remoteURL is the repository's
+// origin URL, used to fill in the type, repo and base URL when unset.
+func New(cfg *Config, remoteURL string) (Forge, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	host, path := ParseRemote(remoteURL)
+	forgeType := cfg.Type
+	if forgeType == "" {
+		forgeType = DetectType(host)
+		if forgeType == "" {
+			return nil, fmt.Errorf("cannot detect forge type for remote %q; set merge_queue.forge.type", remoteURL)
+		}
+	}
+	repo := cfg.Repo
+	if repo == "" {
+		repo = path
+	}
+	if repo == "" {
+		return nil, fmt.Errorf("cannot determine repository from remote %q; set merge_queue.forge.repo", remoteURL)
+	}
+	baseURL := strings.TrimSuffix(cfg.URL, "/")
+	if baseURL == "" && host != "" {
+		baseURL = "https://" + host
+	}
+
+	switch forgeType {
+	case "github":
+		return NewGitHub(repo), nil
+	case "gitlab":
+		if baseURL == "" {
+			baseURL = "https://gitlab.com"
+		}
+		return NewGitLab(baseURL, repo, token(cfg.TokenEnv, "GITLAB_TOKEN")), nil
+	case "gitea":
+		if baseURL == "" {
+			return nil, fmt.Errorf("gitea forge needs merge_queue.forge.url")
+		}
+		return NewGitea(baseURL, repo, token(cfg.TokenEnv, "GITEA_TOKEN")), nil
+	}
+	return nil, fmt.Errorf("unknown forge type %q", forgeType)
+}
+
+// token reads the API token from envName, or from fallback if unset.
+func token(envName, fallback string) string {
+	if envName == "" {
+		envName = fallback
+	}
+	return os.Getenv(envName)
+}
+
+// DetectType guesses the forge type from a remote host.
+// Returns "" when the host isn't recognizable.
+func DetectType(host string) string {
+	switch {
+	case host == "github.com" || strings.HasSuffix(host, ".github.com"):
+		return "github"
+	case strings.Contains(host, "gitlab"):
+		return "gitlab"
+	case strings.Contains(host, "gitea") || strings.Contains(host, "codeberg"):
+		return "gitea"
+	}
+	return ""
+}
+
+// ParseRemote splits a git remote URL into host and repository path.
+// Handles https://host/owner/repo.git, ssh://git@host/owner/repo and
+// scp-style git@host:owner/repo.git. Returns empty strings if unparseable.
+func ParseRemote(remote string) (host, path string) {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return "", ""
+	}
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", ""
+		}
+		host, path = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); at >= 0 {
+		rest := remote[at+1:]
+		colon := strings.Index(rest, ":")
+		if colon < 0 {
+			return "", ""
+		}
+		host, path = rest[:colon], rest[colon+1:]
+	} else {
+		return "", ""
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return host, path
+}
+
+// SummarizeChecks reduces checks to a single state. When required is set,
+// only those checks count and a required check that hasn't reported yet
+// is pending; otherwise every reported check must pass.
+func SummarizeChecks(checks []Check, required []string) (CheckState, []string) {
+	relevant := checks
+	if len(required) > 0 {
+		byName := make(map[string]Check, len(checks))
+		for _, c := range checks {
+			byName[c.Name] = c
+		}
+		relevant = nil
+		for _, name := range required {
+			c, ok := byName[name]
+			if !ok {
+				c = Check{Name: name, State: CheckPending}
+			}
+			relevant = append(relevant, c)
+		}
+	}
+
+	state := CheckSuccess
+	var failed []string
+	for _, c := range relevant {
+		switch c.State {
+		case CheckFailure:
+			failed = append(failed, c.Name)
+		case CheckPending:
+			if state == CheckSuccess {
+				state = CheckPending
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return CheckFailure, failed
+	}
+	return state, nil
+}
+
+// latestVerdicts returns each author's most recent approving or
+// change-requesting review. Comment-only reviews don't change a verdict.
+func latestVerdicts(reviews []Review) map[string]Review {
+	latest := make(map[string]Review)
+	for _, r := range reviews {
+		if r.State == ReviewApproved || r.State == ReviewChangesRequested {
+			latest[r.Author] = r
+		}
+	}
+	return latest
+}
+
+// ChangesRequested returns the reviews still requesting changes: for each
+// author, their latest verdict if it requests changes.
+func ChangesRequested(reviews []Review) []Review {
+	latest := latestVerdicts(reviews)
+	var out []Review
+	for _, r := range reviews {
+		if v, ok := latest[r.Author]; ok && v.ID == r.ID && r.State == ReviewChangesRequested {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Approved reports whether at least one reviewer approves and none still
+// request changes.
+func Approved(reviews []Review) bool {
+	approved := false
+	for _, v := range latestVerdicts(reviews) {
+		if v.State == ReviewChangesRequested {
+			return false
+		}
+		approved = true
+	}
+	return approved
+}
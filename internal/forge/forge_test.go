@@ -0,0 +1,277 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote, host, path string
+	}{
+		{"https://github.com/steveyegge/gastown.git", "github.com", "steveyegge/gastown"},
+		{"git@github.com:steveyegge/gastown.git", "github.com", "steveyegge/gastown"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/project", "gitlab.example.com", "group/sub/project"},
+		{"/srv/git/repo.git", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		host, path := ParseRemote(tt.remote)
+		if host != tt.host || path != tt.path {
+			t.Errorf("ParseRemote(%q) = %q, %q; want %q, %q", tt.remote, host, path, tt.host, tt.path)
+		}
+	}
+}
+
+func TestNew_DetectsType(t *testing.T) {
+	f, err := New(nil, "git@github.com:o/r.git")
+	if err != nil || f.Name() != "github" {
+		t.Fatalf("github remote: got %v, %v", f, err)
+	}
+	f, err = New(nil, "https://gitlab.com/group/project.git")
+	if err != nil || f.Name() != "gitlab" {
+		t.Fatalf("gitlab remote: got %v, %v", f, err)
+	}
+	if _, err := New(nil, "https://git.example.com/o/r.git"); err == nil {
+		t.Error("expected error for undetectable forge")
+	}
+	f, err = New(&Config{Type: "gitea"}, "https://git.example.com/o/r.git")
+	if err != nil || f.Name() != "gitea" {
+		t.Fatalf("explicit gitea: got %v, %v", f, err)
+	}
+	if _, err := New(&Config{MergeMethod: "octopus"}, "git@github.com:o/r.git"); err == nil {
+		t.Error("expected error for unknown merge method")
+	}
+}
+
+func TestSummarizeChecks(t *testing.T) {
+	checks := []Check{
+		{Name: "build", State: CheckSuccess},
+		{Name: "lint", State: CheckPending},
+		{Name: "flaky", State: CheckFailure},
+	}
+
+	state, failed := SummarizeChecks(checks, nil)
+	if state != CheckFailure || len(failed) != 1 || failed[0] != "flaky" {
+		t.Errorf("all checks: got %s %v", state, failed)
+	}
+
+	state, _ = SummarizeChecks(checks, []string{"build", "lint"})
+	if state != CheckPending {
+		t.Errorf("required build+lint: got %s, want pending", state)
+	}
+
+	state, _ = SummarizeChecks(checks, []string{"build"})
+	if state != CheckSuccess {
+		t.Errorf("required build: got %s, want success", state)
+	}
+
+	state, _ = SummarizeChecks(checks, []string{"deploy"})
+	if state != CheckPending {
+		t.Errorf("missing required check: got %s, want pending", state)
+	}
+
+	state, _ = SummarizeChecks(nil, nil)
+	if state != CheckSuccess {
+		t.Errorf("no checks: got %s, want success", state)
+	}
+}
+
+func TestChangesRequestedAndApproved(t *testing.T) {
+	reviews := []Review{
+		{ID: "1", Author: "alice", State: ReviewChangesRequested},
+		{ID: "2", Author: "bob", State: ReviewApproved},
+		{ID: "3", Author: "alice", State: ReviewCommented},
+	}
+	changes := ChangesRequested(reviews)
+	if len(changes) != 1 || changes[0].ID != "1" {
+		t.Fatalf("ChangesRequested = %+v", changes)
+	}
+	if Approved(reviews) {
+		t.Error("Approved should be false while alice requests changes")
+	}
+
+	reviews = append(reviews, Review{ID: "4", Author: "alice", State: ReviewApproved})
+	if changes := ChangesRequested(reviews); len(changes) != 0 {
+		t.Errorf("alice's approval should supersede her request, got %+v", changes)
+	}
+	if !Approved(reviews) {
+		t.Error("Approved should be true once everyone approves")
+	}
+}
+
+func TestGitHub_Flow(t *testing.T) {
+	var calls []string
+	g := NewGitHub("o/r")
+	g.api = func(_ context.Context, method, path string, body interface{}) ([]byte, error) {
+		calls = append(calls, method+" "+path)
+		switch {
+		case strings.HasPrefix(path, "repos/o/r/pulls?"):
+			if !strings.Contains(path, "head=o%3Apolecat%2Fnux") {
+				t.Errorf("FindPR query missing owner-qualified head: %s", path)
+			}
+			return []byte(`[{"number":7,"html_url":"https://github.com/o/r/pull/7","state":"open","head":{"ref":"polecat/nux","sha":"abc"},"base":{"ref":"main"}}]`), nil
+		case strings.HasSuffix(path, "/check-runs"):
+			return []byte(`{"check_runs":[{"name":"ci","status":"completed","conclusion":"failure"}]}`), nil
+		case strings.HasSuffix(path, "/status"):
+			return []byte(`{"statuses":[{"context":"legacy","state":"success"}]}`), nil
+		case strings.HasSuffix(path, "/merge"):
+			data, _ := json.Marshal(body)
+			if !strings.Contains(string(data), `"sha":"abc"`) {
+				t.Errorf("merge should pin head sha, got %s", data)
+			}
+			return []byte(`{"sha":"def","merged":true}`), nil
+		}
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	pr, err := g.FindPR(ctx, "polecat/nux", "main")
+	if err != nil || pr == nil || pr.Number != 7 || pr.HeadSHA != "abc" {
+		t.Fatalf("FindPR = %+v, %v", pr, err)
+	}
+	checks, err := g.Checks(ctx, pr)
+	if err != nil || len(checks) != 2 || checks[0].State != CheckFailure || checks[1].State != CheckSuccess {
+		t.Fatalf("Checks = %+v, %v", checks, err)
+	}
+	sha, err := g.Merge(ctx, pr, "squash")
+	if err != nil || sha != "def" {
+		t.Fatalf("Merge = %q, %v", sha, err)
+	}
+}
+
+func TestGitea_ReviewsAndMerge(t *testing.T) {
+	merged := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch {
+		case r.URL.Path == "/api/v1/repos/o/r/pulls/3/reviews":
+			_, _ = w.Write([]byte(`[
+				{"id":10,"user":{"login":"carol"},"state":"REQUEST_CHANGES","body":"needs work"},
+				{"id":11,"user":{"login":"dave"},"state":"APPROVED","dismissed":true}
+			]`))
+		case r.URL.Path == "/api/v1/repos/o/r/pulls/3/reviews/10/comments":
+			_, _ = w.Write([]byte(`[{"path":"main.go","position":12,"body":"nil check","user":{"login":"carol"}}]`))
+		case r.URL.Path == "/api/v1/repos/o/r/pulls/3/merge" && r.Method == "POST":
+			merged = true
+		case r.URL.Path == "/api/v1/repos/o/r/pulls/3":
+			_, _ = w.Write([]byte(`{"number":3,"merged":true,"merge_commit_sha":"feed"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGitea(srv.URL, "o/r", "secret")
+	ctx := context.Background()
+
+	reviews, err := g.Reviews(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].State != ReviewChangesRequested {
+		t.Fatalf("Reviews = %+v", reviews)
+	}
+	if c := reviews[0].Comments; len(c) != 1 || c[0].Path != "main.go" || c[0].Line != 12 {
+		t.Errorf("comments = %+v", c)
+	}
+
+	sha, err := g.Merge(ctx, &PullRequest{Number: 3}, "merge")
+	if err != nil || sha != "feed" || !merged {
+		t.Fatalf("Merge = %q, %v (merged=%v)", sha, err, merged)
+	}
+}
+
+func TestGitLab_UnresolvedDiscussionsRequestChanges(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "tok" {
+			t.Errorf("PRIVATE-TOKEN = %q", got)
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/merge_requests/5/approvals"):
+			_, _ = w.Write([]byte(`{"approved_by":[{"user":{"username":"erin"}}]}`))
+		case strings.HasSuffix(r.URL.Path, "/merge_requests/5/discussions"):
+			_, _ = w.Write([]byte(`[
+				{"id":"a","notes":[{"body":"rename this","author":{"username":"frank"},"resolvable":true,"resolved":false,"position":{"new_path":"x.go","new_line":3}}]},
+				{"id":"b","notes":[{"body":"done","author":{"username":"frank"},"resolvable":true,"resolved":true}]},
+				{"id":"c","notes":[{"body":"added 1 commit","system":true}]}
+			]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGitLab(srv.URL, "group/project", "tok")
+	reviews, err := g.Reviews(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := ChangesRequested(reviews)
+	if len(changes) != 1 || changes[0].Comments[0].Path != "x.go" {
+		t.Fatalf("ChangesRequested = %+v", changes)
+	}
+	if Approved(reviews) {
+		t.Error("unresolved discussion should block approval")
+	}
+}
+
+func TestGitLab_RebaseMergeWaitsForRebase(t *testing.T) {
+	polls, mergedSHA := 0, ""
+	mergeError := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/merge_requests/7/rebase") && r.Method == "PUT":
+			polls = 0
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"rebase_in_progress":true}`))
+		case strings.HasSuffix(r.URL.Path, "/merge_requests/7/merge") && r.Method == "PUT":
+			var body struct {
+				SHA string `json:"sha"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			mergedSHA = body.SHA
+			_, _ = w.Write([]byte(`{"state":"merged","sha":"rebased"}`))
+		case strings.HasSuffix(r.URL.Path, "/merge_requests/7"):
+			if r.URL.Query().Get("include_rebase_in_progress") != "true" {
+				t.Errorf("query = %q", r.URL.RawQuery)
+			}
+			polls++
+			if polls < 3 {
+				_, _ = w.Write([]byte(`{"iid":7,"sha":"old","rebase_in_progress":true}`))
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"iid": 7, "sha": "rebased", "merge_error": mergeError})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	g := NewGitLab(srv.URL, "group/project", "tok")
+	g.rebasePoll = time.Millisecond
+	ctx := context.Background()
+
+	sha, err := g.Merge(ctx, &PullRequest{Number: 7, HeadSHA: "old"}, "rebase")
+	if err != nil || sha != "rebased" {
+		t.Fatalf("Merge = %q, %v", sha, err)
+	}
+	if polls != 3 || mergedSHA != "rebased" {
+		t.Errorf("polled %d times, merged sha %q; want 3 polls and the rebased sha", polls, mergedSHA)
+	}
+
+	mergeError, mergedSHA = "Rebase failed: conflicts", ""
+	if _, err := g.Merge(ctx, &PullRequest{Number: 7}, "rebase"); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Errorf("Merge after failed rebase = %v, want the merge_error", err)
+	}
+	if mergedSHA != "" {
+		t.Error("merged despite a failed rebase")
+	}
+}
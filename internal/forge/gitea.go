@@ -0,0 +1,216 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Gitea is a Forge backed by the Gitea (and Forgejo) REST API.
+type Gitea struct {
+	repo string // owner/name
+	rest *restClient
+}
+
+// NewGitea creates a Gitea forge for repo ("owner/name") on the instance
+// at baseURL.
+func NewGitea(baseURL, repo, token string) *Gitea {
+	return &Gitea{
+		repo: repo,
+		rest: newRESTClient(baseURL+"/api/v1", "Authorization", "token ", token),
+	}
+}
+
+// Name implements Forge.
+func (g *Gitea) Name() string { return "gitea" }
+
+type giteaPR struct {
+	Number         int    `json:"number"`
+	HTMLURL        string `json:"html_url"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	State          string `json:"state"`
+	Merged         bool   `json:"merged"`
+	MergeCommitSHA string `json:"merge_commit_sha"`
+	Head           struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p *giteaPR) toPR() *PullRequest {
+	state := p.State
+	if p.Merged {
+		state = "merged"
+	}
+	return &PullRequest{
+		Number:  p.Number,
+		URL:     p.HTMLURL,
+		Head:    p.Head.Ref,
+		Base:    p.Base.Ref,
+		HeadSHA: p.Head.SHA,
+		Title:   p.Title,
+		Body:    p.Body,
+		State:   state,
+	}
+}
+
+func (g *Gitea) pullPath(number int) string {
+	return fmt.Sprintf("/repos/%s/pulls/%d", g.repo, number)
+}
+
+// FindPR implements Forge. Gitea can't filter pulls by branch, so open
+// pulls are listed and matched here.
+func (g *Gitea) FindPR(ctx context.Context, head, base string) (*PullRequest, error) {
+	for page := 1; ; page++ {
+		var prs []giteaPR
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&limit=50&page=%d", g.repo, page)
+		if err := g.rest.do(ctx, "GET", path, nil, &prs); err != nil {
+			return nil, err
+		}
+		for i := range prs {
+			if prs[i].Head.Ref == head && prs[i].Base.Ref == base {
+				return prs[i].toPR(), nil
+			}
+		}
+		if len(prs) < 50 {
+			return nil, nil
+		}
+	}
+}
+
+// CreatePR implements Forge.
+func (g *Gitea) CreatePR(ctx context.Context, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{"head": opts.Head, "base": opts.Base, "title": opts.Title, "body": opts.Body}
+	var pr giteaPR
+	if err := g.rest.do(ctx, "POST", fmt.Sprintf("/repos/%s/pulls", g.repo), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPR(), nil
+}
+
+// UpdatePR implements Forge.
+func (g *Gitea) UpdatePR(ctx context.Context, number int, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{"title": opts.Title, "body": opts.Body}
+	var pr giteaPR
+	if err := g.rest.do(ctx, "PATCH", g.pullPath(number), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPR(), nil
+}
+
+// Checks implements Forge. Gitea lists every status ever posted, newest
+// first, so only the latest status per context counts.
+func (g *Gitea) Checks(ctx context.Context, pr *PullRequest) ([]Check, error) {
+	var statuses []struct {
+		Context   string `json:"context"`
+		Status    string `json:"status"`
+		TargetURL string `json:"target_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/commits/%s/statuses?sort=newest", g.repo, pr.HeadSHA)
+	if err := g.rest.do(ctx, "GET", path, nil, &statuses); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var checks []Check
+	for _, s := range statuses {
+		if seen[s.Context] {
+			continue
+		}
+		seen[s.Context] = true
+		checks = append(checks, Check{Name: s.Context, State: statusState(s.Status), URL: s.TargetURL})
+	}
+	return checks, nil
+}
+
+// Reviews implements Forge.
+func (g *Gitea) Reviews(ctx context.Context, number int) ([]Review, error) {
+	var raw []struct {
+		ID   int64 `json:"id"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		State       string    `json:"state"`
+		Body        string    `json:"body"`
+		Stale       bool      `json:"stale"`
+		Dismissed   bool      `json:"dismissed"`
+		SubmittedAt time.Time `json:"submitted_at"`
+	}
+	if err := g.rest.do(ctx, "GET", g.pullPath(number)+"/reviews", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	var reviews []Review
+	for _, r := range raw {
+		if r.Dismissed {
+			continue
+		}
+		var state ReviewState
+		switch r.State {
+		case "APPROVED":
+			state = ReviewApproved
+		case "REQUEST_CHANGES":
+			state = ReviewChangesRequested
+		case "COMMENT":
+			state = ReviewCommented
+		default:
+			continue // PENDING or REQUEST_REVIEW
+		}
+		review := Review{
+			ID:          strconv.FormatInt(r.ID, 10),
+			Author:      r.User.Login,
+			State:       state,
+			Body:        r.Body,
+			SubmittedAt: r.SubmittedAt,
+		}
+		if state == ReviewChangesRequested {
+			var comments []struct {
+				Path     string `json:"path"`
+				Position int    `json:"position"`
+				Body     string `json:"body"`
+				User     struct {
+					Login string `json:"login"`
+				} `json:"user"`
+			}
+			path := fmt.Sprintf("%s/reviews/%d/comments", g.pullPath(number), r.ID)
+			if err := g.rest.do(ctx, "GET", path, nil, &comments); err != nil {
+				return nil, err
+			}
+			for _, c := range comments {
+				review.Comments = append(review.Comments, ReviewComment{
+					Author: c.User.Login, Path: c.Path, Line: c.Position, Body: c.Body,
+				})
+			}
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// Merge implements Forge. Gitea's merge endpoint returns no body, so the
+// merge commit is read back from the pull request.
+func (g *Gitea) Merge(ctx context.Context, pr *PullRequest, method string) (string, error) {
+	body := map[string]string{"Do": method}
+	if pr.HeadSHA != "" {
+		body["head_commit_id"] = pr.HeadSHA
+	}
+	if err := g.rest.do(ctx, "POST", g.pullPath(pr.Number)+"/merge", body, nil); err != nil {
+		return "", err
+	}
+
+	var merged giteaPR
+	if err := g.rest.do(ctx, "GET", g.pullPath(pr.Number), nil, &merged); err != nil {
+		return "", err
+	}
+	if !merged.Merged {
+		return "", fmt.Errorf("pull request #%d was not merged", pr.Number)
+	}
+	return merged.MergeCommitSHA, nil
+}
+
+// Ensure Gitea implements Forge.
+var _ Forge = (*Gitea)(nil)
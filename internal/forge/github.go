@@ -0,0 +1,281 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHub is a Forge backed by the GitHub REST API through the gh CLI, so it
+// uses whatever authentication gh is already configured with.
+type GitHub struct {
+	repo string // owner/name
+
+	// api performs a REST call; replaced in tests.
+	api func(ctx context.Context, method, path string, body interface{}) ([]byte, error)
+}
+
+// NewGitHub creates a GitHub forge for repo ("owner/name").
+func NewGitHub(repo string) *GitHub {
+	return &GitHub{repo: repo, api: ghAPI}
+}
+
+// ghAPI calls `gh api`, passing body as JSON on stdin.
+func ghAPI(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	args := []string{"api", "-X", method, path}
+	var stdin bytes.Buffer
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+		stdin.Write(data)
+		args = append(args, "--input", "-")
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", args...) //nolint:gosec // G204: gh is a trusted CLI, args are constructed internally
+	cmd.Stdin = &stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gh api %s %s: %s", method, path, msg)
+		}
+		return nil, fmt.Errorf("gh api %s %s: %w", method, path, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (g *GitHub) call(ctx context.Context, method, path string, body, out interface{}) error {
+	data, err := g.api(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parsing %s response: %w", path, err)
+	}
+	return nil
+}
+
+// Name implements Forge.
+func (g *GitHub) Name() string { return "github" }
+
+type githubPR struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	Merged  bool   `json:"merged"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+func (p *githubPR) toPR() *PullRequest {
+	state := p.State
+	if p.Merged {
+		state = "merged"
+	}
+	return &PullRequest{
+		Number:  p.Number,
+		URL:     p.HTMLURL,
+		Head:    p.Head.Ref,
+		Base:    p.Base.Ref,
+		HeadSHA: p.Head.SHA,
+		Title:   p.Title,
+		Body:    p.Body,
+		State:   state,
+	}
+}
+
+// FindPR implements Forge.
+func (g *GitHub) FindPR(ctx context.Context, head, base string) (*PullRequest, error) {
+	owner, _, _ := strings.Cut(g.repo, "/")
+	q := url.Values{}
+	q.Set("state", "open")
+	q.Set("head", owner+":"+head)
+	q.Set("base", base)
+
+	var prs []githubPR
+	if err := g.call(ctx, "GET", fmt.Sprintf("repos/%s/pulls?%s", g.repo, q.Encode()), nil, &prs); err != nil {
+		return nil, err
+	}
+	if len(prs) == 0 {
+		return nil, nil
+	}
+	return prs[0].toPR(), nil
+}
+
+// CreatePR implements Forge.
+func (g *GitHub) CreatePR(ctx context.Context, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{"head": opts.Head, "base": opts.Base, "title": opts.Title, "body": opts.Body}
+	var pr githubPR
+	if err := g.call(ctx, "POST", fmt.Sprintf("repos/%s/pulls", g.repo), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPR(), nil
+}
+
+// UpdatePR implements Forge.
+func (g *GitHub) UpdatePR(ctx context.Context, number int, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{"title": opts.Title, "body": opts.Body}
+	var pr githubPR
+	if err := g.call(ctx, "PATCH", fmt.Sprintf("repos/%s/pulls/%d", g.repo, number), body, &pr); err != nil {
+		return nil, err
+	}
+	return pr.toPR(), nil
+}
+
+// Checks implements Forge. It merges check runs (GitHub Actions and apps)
+// with legacy commit statuses.
+func (g *GitHub) Checks(ctx context.Context, pr *PullRequest) ([]Check, error) {
+	var runs struct {
+		CheckRuns []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			HTMLURL    string `json:"html_url"`
+		} `json:"check_runs"`
+	}
+	if err := g.call(ctx, "GET", fmt.Sprintf("repos/%s/commits/%s/check-runs", g.repo, pr.HeadSHA), nil, &runs); err != nil {
+		return nil, err
+	}
+	var status struct {
+		Statuses []struct {
+			Context   string `json:"context"`
+			State     string `json:"state"`
+			TargetURL string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	if err := g.call(ctx, "GET", fmt.Sprintf("repos/%s/commits/%s/status", g.repo, pr.HeadSHA), nil, &status); err != nil {
+		return nil, err
+	}
+
+	var checks []Check
+	for _, r := range runs.CheckRuns {
+		state := CheckPending
+		if r.Status == "completed" {
+			switch r.Conclusion {
+			case "success", "neutral", "skipped":
+				state = CheckSuccess
+			default:
+				state = CheckFailure
+			}
+		}
+		checks = append(checks, Check{Name: r.Name, State: state, URL: r.HTMLURL})
+	}
+	for _, s := range status.Statuses {
+		checks = append(checks, Check{Name: s.Context, State: statusState(s.State), URL: s.TargetURL})
+	}
+	return checks, nil
+}
+
+// statusState maps a commit status string (GitHub, Gitea, GitLab) to a
+// CheckState.
+func statusState(s string) CheckState {
+	switch strings.ToLower(s) {
+	case "success", "skipped":
+		return CheckSuccess
+	case "failure", "failed", "error", "canceled", "cancelled":
+		return CheckFailure
+	default:
+		return CheckPending
+	}
+}
+
+// Reviews implements Forge.
+func (g *GitHub) Reviews(ctx context.Context, number int) ([]Review, error) {
+	var raw []struct {
+		ID   int64 `json:"id"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		State       string    `json:"state"`
+		Body        string    `json:"body"`
+		SubmittedAt time.Time `json:"submitted_at"`
+	}
+	if err := g.call(ctx, "GET", fmt.Sprintf("repos/%s/pulls/%d/reviews?per_page=100", g.repo, number), nil, &raw); err != nil {
+		return nil, err
+	}
+	var comments []struct {
+		ReviewID int64 `json:"pull_request_review_id"`
+		User     struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Path string `json:"path"`
+		Line int    `json:"line"`
+		Body string `json:"body"`
+	}
+	if err := g.call(ctx, "GET", fmt.Sprintf("repos/%s/pulls/%d/comments?per_page=100", g.repo, number), nil, &comments); err != nil {
+		return nil, err
+	}
+
+	byReview := make(map[int64][]ReviewComment)
+	for _, c := range comments {
+		byReview[c.ReviewID] = append(byReview[c.ReviewID], ReviewComment{
+			Author: c.User.Login, Path: c.Path, Line: c.Line, Body: c.Body,
+		})
+	}
+
+	var reviews []Review
+	for _, r := range raw {
+		var state ReviewState
+		switch r.State {
+		case "APPROVED":
+			state = ReviewApproved
+		case "CHANGES_REQUESTED":
+			state = ReviewChangesRequested
+		case "COMMENTED":
+			state = ReviewCommented
+		default:
+			continue // PENDING or DISMISSED
+		}
+		reviews = append(reviews, Review{
+			ID:          strconv.FormatInt(r.ID, 10),
+			Author:      r.User.Login,
+			State:       state,
+			Body:        r.Body,
+			Comments:    byReview[r.ID],
+			SubmittedAt: r.SubmittedAt,
+		})
+	}
+	return reviews, nil
+}
+
+// Merge implements Forge. The head SHA is pinned so a push racing the
+// merge fails instead of landing untested commits.
+func (g *GitHub) Merge(ctx context.Context, pr *PullRequest, method string) (string, error) {
+	body := map[string]string{"merge_method": method}
+	if pr.HeadSHA != "" {
+		body["sha"] = pr.HeadSHA
+	}
+	var resp struct {
+		SHA    string `json:"sha"`
+		Merged bool   `json:"merged"`
+	}
+	if err := g.call(ctx, "PUT", fmt.Sprintf("repos/%s/pulls/%d/merge", g.repo, pr.Number), body, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Merged {
+		return "", fmt.Errorf("pull request #%d was not merged", pr.Number)
+	}
+	return resp.SHA, nil
+}
+
+// Ensure GitHub implements Forge.
+var _ Forge = (*GitHub)(nil)
@@ -0,0 +1,270 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GitLab is a Forge backed by the GitLab REST API (v4). Merge requests are
+// addressed by IID. GitLab has no change-requesting review state, so each
+// unresolved discussion thread counts as a review requesting changes, and
+// each approval as an approving review.
+type GitLab struct {
+	project string // URL-encoded project path
+	rest    *restClient
+
+	rebasePoll time.Duration // how often Merge checks on a rebase
+}
+
+// gitlabRebaseTimeout bounds how long Merge waits for GitLab to rebase.
+const gitlabRebaseTimeout = 10 * time.Minute
+
+// NewGitLab creates a GitLab forge for the project at path (e.g.,
+// "group/project") on the instance at baseURL.
+func NewGitLab(baseURL, path, token string) *GitLab {
+	return &GitLab{
+		project: url.PathEscape(path),
+		rest:    newRESTClient(baseURL+"/api/v4", "PRIVATE-TOKEN", "", token),
+
+		rebasePoll: 2 * time.Second,
+	}
+}
+
+// Name implements Forge.
+func (g *GitLab) Name() string { return "gitlab" }
+
+type gitlabMR struct {
+	IID          int    `json:"iid"`
+	WebURL       string `json:"web_url"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	State        string `json:"state"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	SHA          string `json:"sha"`
+}
+
+func (m *gitlabMR) toPR() *PullRequest {
+	state := m.State
+	if state == "opened" {
+		state = "open"
+	}
+	return &PullRequest{
+		Number:  m.IID,
+		URL:     m.WebURL,
+		Head:    m.SourceBranch,
+		Base:    m.TargetBranch,
+		HeadSHA: m.SHA,
+		Title:   m.Title,
+		Body:    m.Description,
+		State:   state,
+	}
+}
+
+func (g *GitLab) mrPath(iid int) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d", g.project, iid)
+}
+
+// FindPR implements Forge.
+func (g *GitLab) FindPR(ctx context.Context, head, base string) (*PullRequest, error) {
+	q := url.Values{}
+	q.Set("state", "opened")
+	q.Set("source_branch", head)
+	q.Set("target_branch", base)
+
+	var mrs []gitlabMR
+	if err := g.rest.do(ctx, "GET", fmt.Sprintf("/projects/%s/merge_requests?%s", g.project, q.Encode()), nil, &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+	return mrs[0].toPR(), nil
+}
+
+// CreatePR implements Forge.
+func (g *GitLab) CreatePR(ctx context.Context, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{
+		"source_branch": opts.Head,
+		"target_branch": opts.Base,
+		"title":         opts.Title,
+		"description":   opts.Body,
+	}
+	var mr gitlabMR
+	if err := g.rest.do(ctx, "POST", fmt.Sprintf("/projects/%s/merge_requests", g.project), body, &mr); err != nil {
+		return nil, err
+	}
+	return mr.toPR(), nil
+}
+
+// UpdatePR implements Forge.
+func (g *GitLab) UpdatePR(ctx context.Context, number int, opts PROptions) (*PullRequest, error) {
+	body := map[string]string{"title": opts.Title, "description": opts.Body}
+	var mr gitlabMR
+	if err := g.rest.do(ctx, "PUT", g.mrPath(number), body, &mr); err != nil {
+		return nil, err
+	}
+	return mr.toPR(), nil
+}
+
+// Checks implements Forge using the commit statuses of the head commit.
+func (g *GitLab) Checks(ctx context.Context, pr *PullRequest) ([]Check, error) {
+	var statuses []struct {
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		TargetURL string `json:"target_url"`
+	}
+	path := fmt.Sprintf("/projects/%s/repository/commits/%s/statuses", g.project, pr.HeadSHA)
+	if err := g.rest.do(ctx, "GET", path, nil, &statuses); err != nil {
+		return nil, err
+	}
+	checks := make([]Check, 0, len(statuses))
+	for _, s := range statuses {
+		checks = append(checks, Check{Name: s.Name, State: statusState(s.Status), URL: s.TargetURL})
+	}
+	return checks, nil
+}
+
+// Reviews implements Forge.
+func (g *GitLab) Reviews(ctx context.Context, number int) ([]Review, error) {
+	var approvals struct {
+		ApprovedBy []struct {
+			User struct {
+				Username string `json:"username"`
+			} `json:"user"`
+		} `json:"approved_by"`
+	}
+	if err := g.rest.do(ctx, "GET", g.mrPath(number)+"/approvals", nil, &approvals); err != nil {
+		return nil, err
+	}
+
+	var discussions []struct {
+		ID    string `json:"id"`
+		Notes []struct {
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+			Author struct {
+				Username string `json:"username"`
+			} `json:"author"`
+			Resolvable bool      `json:"resolvable"`
+			Resolved   bool      `json:"resolved"`
+			CreatedAt  time.Time `json:"created_at"`
+			Position   *struct {
+				NewPath string `json:"new_path"`
+				NewLine int    `json:"new_line"`
+			} `json:"position"`
+		} `json:"notes"`
+	}
+	if err := g.rest.do(ctx, "GET", g.mrPath(number)+"/discussions?per_page=100", nil, &discussions); err != nil {
+		return nil, err
+	}
+
+	var reviews []Review
+	for _, d := range discussions {
+		if len(d.Notes) == 0 || d.Notes[0].System || !d.Notes[0].Resolvable || d.Notes[0].Resolved {
+			continue
+		}
+		first := d.Notes[0]
+		review := Review{
+			ID:          "discussion-" + d.ID,
+			Author:      first.Author.Username,
+			State:       ReviewChangesRequested,
+			SubmittedAt: first.CreatedAt,
+		}
+		for _, n := range d.Notes {
+			if n.System {
+				continue
+			}
+			c := ReviewComment{Author: n.Author.Username, Body: n.Body}
+			if n.Position != nil {
+				c.Path, c.Line = n.Position.NewPath, n.Position.NewLine
+			}
+			review.Comments = append(review.Comments, c)
+		}
+		reviews = append(reviews, review)
+	}
+	for _, a := range approvals.ApprovedBy {
+		reviews = append(reviews, Review{
+			ID:     "approval-" + a.User.Username,
+			Author: a.User.Username,
+			State:  ReviewApproved,
+		})
+	}
+	return reviews, nil
+}
+
+// Merge implements Forge. "rebase" rebases the source branch onto the
+// target first and then merges, which fast-forwards on projects configured
+// for it.
+func (g *GitLab) Merge(ctx context.Context, pr *PullRequest, method string) (string, error) {
+	headSHA := pr.HeadSHA
+	if method == "rebase" {
+		if err := g.rest.do(ctx, "PUT", g.mrPath(pr.Number)+"/rebase", nil, nil); err != nil {
+			return "", err
+		}
+		sha, err := g.waitForRebase(ctx, pr.Number)
+		if err != nil {
+			return "", err
+		}
+		headSHA = sha
+	}
+
+	body := map[string]interface{}{"squash": method == "squash"}
+	if headSHA != "" {
+		body["sha"] = headSHA
+	}
+	var resp struct {
+		State           string `json:"state"`
+		MergeCommitSHA  string `json:"merge_commit_sha"`
+		SquashCommitSHA string `json:"squash_commit_sha"`
+		SHA             string `json:"sha"`
+	}
+	if err := g.rest.do(ctx, "PUT", g.mrPath(pr.Number)+"/merge", body, &resp); err != nil {
+		return "", err
+	}
+	if resp.State != "merged" {
+		return "", fmt.Errorf("merge request !%s was not merged (state %s)", strconv.Itoa(pr.Number), resp.State)
+	}
+	switch {
+	case resp.MergeCommitSHA != "":
+		return resp.MergeCommitSHA, nil
+	case resp.SquashCommitSHA != "":
+		return resp.SquashCommitSHA, nil
+	}
+	return resp.SHA, nil
+}
+
+// waitForRebase polls a merge request until GitLab's asynchronous rebase
+// finishes and returns the rebased head SHA. A rebase GitLab couldn't do
+// (conflicts, for one) is reported in merge_error.
+func (g *GitLab) waitForRebase(ctx context.Context, iid int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitlabRebaseTimeout)
+	defer cancel()
+	for {
+		var mr struct {
+			SHA              string `json:"sha"`
+			RebaseInProgress bool   `json:"rebase_in_progress"`
+			MergeError       string `json:"merge_error"`
+		}
+		if err := g.rest.do(ctx, "GET", g.mrPath(iid)+"?include_rebase_in_progress=true", nil, &mr); err != nil {
+			return "", fmt.Errorf("checking rebase of !%d: %w", iid, err)
+		}
+		if !mr.RebaseInProgress {
+			if mr.MergeError != "" {
+				return "", fmt.Errorf("rebasing merge request !%d: %s", iid, mr.MergeError)
+			}
+			return mr.SHA, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("waiting for rebase of !%d: %w", iid, ctx.Err())
+		case <-time.After(g.rebasePoll):
+		}
+	}
+}
+
+// Ensure GitLab implements Forge.
+var _ Forge = (*GitLab)(nil)
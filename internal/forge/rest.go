@@ -0,0 +1,80 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// restClient is a minimal JSON REST client for the GitLab and Gitea APIs.
+type restClient struct {
+	baseURL     string // API root, e.g. https://gitea.example.com/api/v1
+	tokenHeader string // header carrying the token
+	tokenPrefix string // prefix before the token value (e.g., "token ")
+	token       string
+	client      *http.Client
+}
+
+func newRESTClient(baseURL, tokenHeader, tokenPrefix, token string) *restClient {
+	return &restClient{
+		baseURL:     baseURL,
+		tokenHeader: tokenHeader,
+		tokenPrefix: tokenPrefix,
+		token:       token,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out (if non-nil).
+func (c *restClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set(c.tokenHeader, c.tokenPrefix+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg := bytes.TrimSpace(data)
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, msg)
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("parsing %s response: %w", path, err)
+	}
+	return nil
+}
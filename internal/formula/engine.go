@@ -0,0 +1,378 @@
+package formula
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StepStatus is the outcome of a workflow step in a run.
+type StepStatus string
+
+const (
+	// StepDone means every instance of the step succeeded.
+	StepDone StepStatus = "done"
+	// StepSkipped means the step's when condition was false.
+	StepSkipped StepStatus = "skipped"
+	// StepFailed means an instance failed after all retry attempts.
+	StepFailed StepStatus = "failed"
+	// StepBlocked means a step it needs failed or was blocked.
+	StepBlocked StepStatus = "blocked"
+)
+
+// StepRun is one execution of a step handed to a Runner. Foreach steps
+// produce one StepRun per item. Templates are already expanded.
+type StepRun struct {
+	StepID      string
+	Instance    string // step ID, or step ID with [index] for foreach items
+	Title       string
+	Description string
+	Run         string
+	Item        string
+	Index       int
+	InLoop      bool
+	Outputs     []string
+	Attempt     int
+	Timeout     time.Duration
+}
+
+// Runner executes a single step instance and returns its named outputs.
+type Runner interface {
+	RunStep(ctx context.Context, run *StepRun) (map[string]string, error)
+}
+
+// StepResult records what happened to one step.
+type StepResult struct {
+	ID        string            `json:"id"`
+	Status    StepStatus        `json:"status"`
+	Outputs   map[string]string `json:"outputs,omitempty"`
+	Attempts  int               `json:"attempts,omitempty"`
+	Instances int               `json:"instances,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+}
+
+// RunResult is the outcome of executing a workflow formula.
+type RunResult struct {
+	Formula string            `json:"formula"`
+	Vars    map[string]string `json:"vars"`
+	Steps   []*StepResult     `json:"steps"`
+}
+
+// Failed reports whether any step failed or was blocked.
+func (r *RunResult) Failed() bool {
+	for _, s := range r.Steps {
+		if s.Status == StepFailed || s.Status == StepBlocked {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveVars merges provided values with the formula's variable defaults.
+// Provided values for undeclared variables are passed through.
+func (f *Formula) ResolveVars(provided map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(f.Vars)+len(provided))
+	for name, v := range provided {
+		vars[name] = v
+	}
+
+	var missing []string
+	for name, def := range f.Vars {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		if def.Default != "" {
+			vars[name] = def.Default
+		} else if def.Required {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+	return vars, nil
+}
+
+// Engine executes workflow formulas: steps run in dependency order, with
+// when conditions, foreach fan-out, per-attempt timeouts, retries and named
+// outputs passed to later steps.
+type Engine struct {
+	Runner Runner
+
+	// OnStart is called before each step instance attempt. Optional.
+	OnStart func(run *StepRun)
+
+	// OnFinish is called when a step reaches its final status. Optional.
+	OnFinish func(result *StepResult)
+
+	// sleep waits between retries; replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Execute runs a workflow formula with the given (resolved) variables.
+// Step failures are reported in the result; the error is for problems
+// that prevent the run from starting.
+func (e *Engine) Execute(ctx context.Context, f *Formula, vars map[string]string) (*RunResult, error) {
+	if f.Type != TypeWorkflow {
+		return nil, fmt.Errorf("formula %s is a %s formula; only workflow formulas can be executed", f.Name, f.Type)
+	}
+	if e.Runner == nil {
+		return nil, fmt.Errorf("no step runner configured")
+	}
+	order, err := f.TopologicalSort()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RunResult{Formula: f.Name, Vars: vars}
+	statuses := make(map[string]StepStatus)
+	outputs := make(map[string]map[string]string)
+
+	for _, id := range order {
+		step := f.GetStep(id)
+		res := e.executeStep(ctx, step, statuses, &Scope{Vars: vars, Outputs: outputs})
+		statuses[id] = res.Status
+		if res.Status == StepDone {
+			outputs[id] = res.Outputs
+		}
+		result.Steps = append(result.Steps, res)
+		if e.OnFinish != nil {
+			e.OnFinish(res)
+		}
+	}
+
+	return result, nil
+}
+
+func (e *Engine) executeStep(ctx context.Context, step *Step, statuses map[string]StepStatus, scope *Scope) *StepResult {
+	res := &StepResult{ID: step.ID}
+
+	for _, need := range step.Needs {
+		if s := statuses[need]; s == StepFailed || s == StepBlocked {
+			res.Status = StepBlocked
+			res.Reason = fmt.Sprintf("needed step %s %s", need, s)
+			return res
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		res.Status = StepBlocked
+		res.Reason = err.Error()
+		return res
+	}
+
+	if step.When != "" {
+		ok, err := EvalCondition(step.When, scope)
+		if err != nil {
+			res.Status = StepFailed
+			res.Error = fmt.Sprintf("evaluating when: %v", err)
+			return res
+		}
+		if !ok {
+			res.Status = StepSkipped
+			res.Reason = "when: " + step.When
+			return res
+		}
+	}
+
+	// One instance, or one per foreach item
+	type instance struct {
+		item  string
+		index int
+	}
+	instances := []instance{{}}
+	inLoop := step.Foreach != ""
+	if inLoop {
+		instances = nil
+		for i, item := range SplitList(scope.renderStrict(step.Foreach)) {
+			instances = append(instances, instance{item: item, index: i})
+		}
+	}
+	res.Instances = len(instances)
+
+	collected := make(map[string][]string)
+	for _, inst := range instances {
+		s := *scope
+		s.Item, s.Index, s.InLoop = inst.item, inst.index, inLoop
+
+		run := &StepRun{
+			StepID:      step.ID,
+			Instance:    step.ID,
+			Title:       s.Render(step.Title),
+			Description: s.Render(step.Description),
+			Run:         s.RenderShell(step.Run),
+			Item:        inst.item,
+			Index:       inst.index,
+			InLoop:      inLoop,
+			Outputs:     step.Outputs,
+		}
+		if inLoop {
+			run.Instance = fmt.Sprintf("%s[%d]", step.ID, inst.index)
+		}
+		if step.Timeout != "" {
+			run.Timeout, _ = time.ParseDuration(step.Timeout)
+		}
+
+		out, attempts, err := e.runWithRetry(ctx, step, run)
+		res.Attempts += attempts
+		if err != nil {
+			res.Status = StepFailed
+			res.Error = fmt.Sprintf("%s: %v", run.Instance, err)
+			return res
+		}
+		for _, name := range step.Outputs {
+			collected[name] = append(collected[name], out[name])
+		}
+	}
+
+	// Foreach steps publish each output as the newline-joined item values,
+	// ready to feed another foreach
+	res.Outputs = make(map[string]string, len(step.Outputs))
+	for _, name := range step.Outputs {
+		res.Outputs[name] = strings.Join(collected[name], "\n")
+	}
+	res.Status = StepDone
+	return res
+}
+
+// runWithRetry runs one instance, retrying per the step's policy with
+// exponential backoff. It returns the outputs and the number of attempts.
+func (e *Engine) runWithRetry(ctx context.Context, step *Step, run *StepRun) (map[string]string, int, error) {
+	maxAttempts := 1
+	var backoff time.Duration
+	if step.Retry != nil {
+		if step.Retry.Attempts > 1 {
+			maxAttempts = step.Retry.Attempts
+		}
+		backoff, _ = time.ParseDuration(step.Retry.Backoff)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 && backoff > 0 {
+			if err := e.wait(ctx, backoff); err != nil {
+				return nil, attempt - 1, err
+			}
+			backoff *= 2
+		}
+
+		run.Attempt = attempt
+		if e.OnStart != nil {
+			e.OnStart(run)
+		}
+
+		out, err := e.runOnce(ctx, run)
+		if err == nil {
+			return out, attempt, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, attempt, err
+		}
+	}
+	return nil, maxAttempts, lastErr
+}
+
+// runOnce runs a single attempt, bounded by the step timeout.
+func (e *Engine) runOnce(ctx context.Context, run *StepRun) (map[string]string, error) {
+	attemptCtx := ctx
+	if run.Timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, run.Timeout)
+		defer cancel()
+	}
+
+	out, err := e.Runner.RunStep(attemptCtx, run)
+	if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("timed out after %s", run.Timeout)
+	}
+	return out, err
+}
+
+func (e *Engine) wait(ctx context.Context, d time.Duration) error {
+	if e.sleep != nil {
+		return e.sleep(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// ShellRunner runs a step's run command with sh -c. The command publishes
+// outputs by writing name=value lines to the file named by $GT_OUTPUT.
+// The current item and index are exported as GT_ITEM and GT_INDEX.
+type ShellRunner struct {
+	Dir    string
+	Env    []string
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// RunStep implements Runner.
+func (r *ShellRunner) RunStep(ctx context.Context, run *StepRun) (map[string]string, error) {
+	if strings.TrimSpace(run.Run) == "" {
+		return nil, fmt.Errorf("step has no run command")
+	}
+
+	outFile, err := os.CreateTemp("", "gt-formula-output-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating output file: %w", err)
+	}
+	outPath := outFile.Name()
+	_ = outFile.Close()
+	defer func() { _ = os.Remove(outPath) }()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", run.Run) //nolint:gosec // G204: run commands come from trusted formula files
+	cmd.Dir = r.Dir
+	cmd.Stdout = r.Stdout
+	cmd.Stderr = r.Stderr
+	cmd.Env = append(os.Environ(), r.Env...)
+	cmd.Env = append(cmd.Env,
+		"GT_OUTPUT="+outPath,
+		"GT_STEP="+run.StepID,
+		"GT_ATTEMPT="+strconv.Itoa(run.Attempt),
+	)
+	if run.InLoop {
+		cmd.Env = append(cmd.Env, "GT_ITEM="+run.Item, "GT_INDEX="+strconv.Itoa(run.Index))
+	}
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return readOutputFile(outPath)
+}
+
+// readOutputFile parses name=value lines written to $GT_OUTPUT. Later lines
+// for the same name win.
+func readOutputFile(path string) (map[string]string, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is a temp file we created
+	if err != nil {
+		return nil, fmt.Errorf("reading outputs: %w", err)
+	}
+	defer f.Close()
+
+	outputs := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		outputs[strings.TrimSpace(name)] = value
+	}
+	return outputs, scanner.Err()
+}
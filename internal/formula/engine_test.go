@@ -0,0 +1,279 @@
+package formula
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRunner records step instances and returns scripted outputs.
+type fakeRunner struct {
+	ran      []string
+	outputs  map[string]map[string]string // instance -> outputs
+	failures map[string]int               // instance -> attempts that fail
+}
+
+func (r *fakeRunner) RunStep(ctx context.Context, run *StepRun) (map[string]string, error) {
+	r.ran = append(r.ran, run.Instance+":"+run.Title)
+	if r.failures[run.Instance] >= run.Attempt {
+		return nil, errors.New("boom")
+	}
+	if run.Run == "hang" {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return r.outputs[run.Instance], nil
+}
+
+const engineFormula = `
+formula = "release"
+type = "workflow"
+
+[vars.env]
+default = "staging"
+
+[vars.targets]
+required = true
+
+[[steps]]
+id = "version"
+title = "Pick version"
+outputs = ["tag"]
+
+[[steps]]
+id = "build"
+title = "Build {{item}} at {{steps.version.tag}}"
+needs = ["version"]
+foreach = "{{targets}}"
+outputs = ["artifact"]
+
+[[steps]]
+id = "announce"
+title = "Announce {{steps.build.artifact}}"
+needs = ["build"]
+when = "{{env}} == prod"
+
+[[steps]]
+id = "flaky"
+title = "Flaky"
+needs = ["version"]
+retry = { attempts = 3, backoff = "1s" }
+`
+
+func TestEngine_Execute(t *testing.T) {
+	f, err := Parse([]byte(engineFormula))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.ResolveVars(nil); err == nil || !strings.Contains(err.Error(), "targets") {
+		t.Fatalf("ResolveVars without targets: %v", err)
+	}
+	vars, err := f.ResolveVars(map[string]string{"targets": "linux, darwin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := &fakeRunner{
+		outputs: map[string]map[string]string{
+			"version":  {"tag": "v1.2.0", "ignored": "x"},
+			"build[0]": {"artifact": "gt-linux"},
+			"build[1]": {"artifact": "gt-darwin"},
+		},
+		failures: map[string]int{"flaky": 2},
+	}
+	var slept []time.Duration
+	e := &Engine{Runner: runner, sleep: func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}}
+
+	res, err := e.Execute(context.Background(), f, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]*StepResult)
+	for _, s := range res.Steps {
+		got[s.ID] = s
+	}
+	if got["version"].Outputs["tag"] != "v1.2.0" || len(got["version"].Outputs) != 1 {
+		t.Errorf("version outputs = %v", got["version"].Outputs)
+	}
+	if got["build"].Instances != 2 || got["build"].Outputs["artifact"] != "gt-linux\ngt-darwin" {
+		t.Errorf("build = %+v", got["build"])
+	}
+	if got["announce"].Status != StepSkipped {
+		t.Errorf("announce = %+v, want skipped (env is staging)", got["announce"])
+	}
+	if got["flaky"].Status != StepDone || got["flaky"].Attempts != 3 {
+		t.Errorf("flaky = %+v", got["flaky"])
+	}
+	if len(slept) != 2 || slept[0] != time.Second || slept[1] != 2*time.Second {
+		t.Errorf("backoff = %v, want [1s 2s]", slept)
+	}
+	if res.Failed() {
+		t.Error("Failed() = true")
+	}
+
+	ran := strings.Join(runner.ran, "|")
+	if !strings.Contains(ran, "build[1]:Build darwin at v1.2.0") {
+		t.Errorf("ran = %s", ran)
+	}
+}
+
+func TestEngine_TimeoutAndBlocked(t *testing.T) {
+	f, err := Parse([]byte(`
+formula = "t"
+[[steps]]
+id = "slow"
+run = "hang"
+timeout = "10ms"
+[[steps]]
+id = "after"
+needs = ["slow"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := (&Engine{Runner: &fakeRunner{}}).Execute(context.Background(), f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Steps[0].Status != StepFailed || !strings.Contains(res.Steps[0].Error, "timed out after 10ms") {
+		t.Errorf("slow = %+v", res.Steps[0])
+	}
+	if res.Steps[1].Status != StepBlocked {
+		t.Errorf("after = %+v", res.Steps[1])
+	}
+	if !res.Failed() {
+		t.Error("Failed() = false")
+	}
+}
+
+func TestEvalCondition(t *testing.T) {
+	scope := &Scope{
+		Vars:    map[string]string{"env": "prod", "dry": "false", "note": "a && b"},
+		Outputs: map[string]map[string]string{"check": {"ok": "yes"}},
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"{{env}} == prod", true},
+		{"{{env}} == 'staging'", false},
+		{"{{env}} != staging && {{steps.check.ok}}", true},
+		{"{{dry}}", false},
+		{"!{{dry}}", true},
+		{"{{missing}} || {{env}} == prod", true},
+		{"{{note}} != x", true},
+	}
+	for _, tt := range tests {
+		got, err := EvalCondition(tt.expr, scope)
+		if err != nil {
+			t.Errorf("EvalCondition(%q): %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EvalCondition(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_StepExecution(t *testing.T) {
+	tests := []struct {
+		name, steps, wantErr string
+	}{
+		{"output of step not needed", `
+[[steps]]
+id = "a"
+outputs = ["x"]
+[[steps]]
+id = "b"
+title = "{{steps.a.x}}"`, "which it does not need"},
+		{"undeclared output", `
+[[steps]]
+id = "a"
+[[steps]]
+id = "b"
+needs = ["a"]
+title = "{{steps.a.x}}"`, "undeclared output"},
+		{"item outside foreach", `
+[[steps]]
+id = "a"
+title = "{{item}}"`, "only available inside foreach"},
+		{"bad timeout", `
+[[steps]]
+id = "a"
+timeout = "soon"`, "invalid timeout"},
+		{"bad when", `
+[[steps]]
+id = "a"
+when = "x == "`, "invalid when"},
+		{"transitive output ok", `
+[[steps]]
+id = "a"
+outputs = ["x"]
+[[steps]]
+id = "b"
+needs = ["a"]
+[[steps]]
+id = "c"
+needs = ["b"]
+foreach = "{{steps.a.x}}"
+title = "{{item}} {{index}} {{feature}}"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte("formula = \"f\"\n" + tt.steps))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestShellRunner_Outputs(t *testing.T) {
+	r := &ShellRunner{Dir: t.TempDir()}
+	out, err := r.RunStep(context.Background(), &StepRun{
+		StepID: "s", Run: `echo "greeting=hello $GT_ITEM" >> "$GT_OUTPUT"`, Item: "world", InLoop: true, Attempt: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["greeting"] != "hello world" {
+		t.Errorf("outputs = %v", out)
+	}
+}
+
+func TestShellRunner_QuotedValues(t *testing.T) {
+	dir := t.TempDir()
+	s := &Scope{
+		Vars:    map[string]string{"msg": `it's "$(touch pwned)"; touch pwned`},
+		Outputs: map[string]map[string]string{"prev": {"name": "`touch pwned`"}},
+	}
+	r := &ShellRunner{Dir: dir}
+	out, err := r.RunStep(context.Background(), &StepRun{
+		StepID: "s", Attempt: 1,
+		Run: s.RenderShell(`echo "msg="{{msg}} >> "$GT_OUTPUT"; echo name={{steps.prev.name}} >> "$GT_OUTPUT"`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["msg"] != s.Vars["msg"] || out["name"] != "`touch pwned`" {
+		t.Errorf("outputs = %v", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pwned")); err == nil {
+		t.Error("a template value ran as a shell command")
+	}
+}
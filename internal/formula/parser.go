@@ -3,6 +3,8 @@ package formula
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -62,6 +64,10 @@ func (f *Formula) Validate() error {
 		return fmt.Errorf("invalid formula type %q (must be convoy, workflow, expansion, or aspect)", f.Type)
 	}
 
+	if err := f.validateSquash(); err != nil {
+		return err
+	}
+
 	// Type-specific validation
 	switch f.Type {
 	case TypeConvoy:
@@ -77,6 +83,24 @@ func (f *Formula) Validate() error {
 	return nil
 }
 
+// validateSquash checks the [squash] section, if any.
+func (f *Formula) validateSquash() error {
+	if f.Squash == nil {
+		return nil
+	}
+	switch f.Squash.TemplateType {
+	case "", "patrol", "work":
+	default:
+		return fmt.Errorf("squash: invalid template_type %q (must be patrol or work)", f.Squash.TemplateType)
+	}
+	if f.Squash.Cadence != "" {
+		if d, err := time.ParseDuration(f.Squash.Cadence); err != nil || d <= 0 {
+			return fmt.Errorf("squash: invalid cadence %q", f.Squash.Cadence)
+		}
+	}
+	return nil
+}
+
 func (f *Formula) validateConvoy() error {
 	if len(f.Legs) == 0 {
 		return fmt.Errorf("convoy formula requires at least one leg")
@@ -137,9 +161,117 @@ func (f *Formula) validateWorkflow() error {
 		return err
 	}
 
+	for i := range f.Steps {
+		if err := f.validateStepExecution(&f.Steps[i]); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validateStepExecution checks a step's conditions, fan-out, timeout, retry
+// policy and outputs, and that every {{steps.<id>.<output>}} reference names
+// a declared output of a step this one (transitively) needs.
+func (f *Formula) validateStepExecution(step *Step) error {
+	if step.When != "" {
+		if _, err := parseCondition(step.When); err != nil {
+			return fmt.Errorf("step %q: invalid when: %w", step.ID, err)
+		}
+	}
+	if step.Timeout != "" {
+		if d, err := time.ParseDuration(step.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("step %q: invalid timeout %q", step.ID, step.Timeout)
+		}
+	}
+	if step.Retry != nil {
+		if step.Retry.Attempts < 0 {
+			return fmt.Errorf("step %q: retry attempts must be non-negative", step.ID)
+		}
+		if step.Retry.Backoff != "" {
+			if d, err := time.ParseDuration(step.Retry.Backoff); err != nil || d < 0 {
+				return fmt.Errorf("step %q: invalid retry backoff %q", step.ID, step.Retry.Backoff)
+			}
+		}
+	}
+
+	seenOutputs := make(map[string]bool)
+	for _, name := range step.Outputs {
+		if !outputNameRegex.MatchString(name) {
+			return fmt.Errorf("step %q: invalid output name %q", step.ID, name)
+		}
+		if seenOutputs[name] {
+			return fmt.Errorf("step %q: duplicate output %q", step.ID, name)
+		}
+		seenOutputs[name] = true
+	}
+
+	ancestors := f.ancestors(step.ID)
+	fields := []struct{ name, tmpl string }{
+		{"title", step.Title},
+		{"description", step.Description},
+		{"run", step.Run},
+		{"when", step.When},
+		{"foreach", step.Foreach},
+	}
+	for _, field := range fields {
+		for _, ref := range templateRefs(field.tmpl) {
+			if ref == "item" || ref == "index" {
+				if step.Foreach == "" || field.name == "foreach" {
+					return fmt.Errorf("step %q: {{%s}} in %s is only available inside foreach", step.ID, ref, field.name)
+				}
+				continue
+			}
+			if !strings.HasPrefix(ref, "steps.") {
+				continue // Variables may be supplied at run time
+			}
+			stepID, output, ok := splitStepRef(ref)
+			if !ok {
+				return fmt.Errorf("step %q: malformed reference {{%s}} in %s", step.ID, ref, field.name)
+			}
+			target := f.GetStep(stepID)
+			if target == nil {
+				return fmt.Errorf("step %q: {{%s}} references unknown step: %s", step.ID, ref, stepID)
+			}
+			if !ancestors[stepID] {
+				return fmt.Errorf("step %q: {{%s}} references step %s, which it does not need", step.ID, ref, stepID)
+			}
+			declared := false
+			for _, name := range target.Outputs {
+				if name == output {
+					declared = true
+					break
+				}
+			}
+			if !declared {
+				return fmt.Errorf("step %q: {{%s}} references undeclared output %q of step %s", step.ID, ref, output, stepID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ancestors returns the IDs of every step that id transitively needs.
+func (f *Formula) ancestors(id string) map[string]bool {
+	seen := make(map[string]bool)
+	var walk func(string)
+	walk = func(cur string) {
+		step := f.GetStep(cur)
+		if step == nil {
+			return
+		}
+		for _, need := range step.Needs {
+			if !seen[need] {
+				seen[need] = true
+				walk(need)
+			}
+		}
+	}
+	walk(id)
+	return seen
+}
+
 func (f *Formula) validateExpansion() error {
 	if len(f.Template) == 0 {
 		return fmt.Errorf("expansion formula requires at least one template")
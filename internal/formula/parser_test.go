@@ -222,6 +222,35 @@ needs = ["step1"]
 	}
 }
 
+func TestParse_Squash(t *testing.T) {
+	data := []byte(`
+formula = "mol-test-patrol"
+version = 1
+[[steps]]
+id = "step1"
+title = "Step 1"
+
+[squash]
+template_type = "patrol"
+cadence = "1h"
+template = "{{.Agent}}: {{.Cycles}} cycles"
+`)
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if f.Squash == nil || f.Squash.TemplateType != "patrol" || f.Squash.Cadence != "1h" {
+		t.Errorf("Squash = %+v", f.Squash)
+	}
+
+	for _, bad := range []string{`template_type = "daily"`, `cadence = "soon"`} {
+		if _, err := Parse([]byte("formula = \"t\"\n[[steps]]\nid = \"s\"\n[squash]\n" + bad + "\n")); err == nil {
+			t.Errorf("expected error for squash %s", bad)
+		}
+	}
+}
+
 func TestTopologicalSort(t *testing.T) {
 	data := []byte(`
 formula = "test"
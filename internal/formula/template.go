@@ -0,0 +1,227 @@
+package formula
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// templateRefRegex matches {{ref}} placeholders in step templates.
+var templateRefRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.\-]+)\s*\}\}`)
+
+// outputNameRegex is the allowed shape of a step output name.
+var outputNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_\-]*$`)
+
+// Scope holds the values a step template can reference.
+type Scope struct {
+	Vars    map[string]string
+	Outputs map[string]map[string]string // step ID -> output name -> value
+	Item    string
+	Index   int
+	InLoop  bool
+}
+
+// lookup resolves a template reference. ok is false for unknown references.
+func (s *Scope) lookup(ref string) (string, bool) {
+	switch {
+	case ref == "item" && s.InLoop:
+		return s.Item, true
+	case ref == "index" && s.InLoop:
+		return strconv.Itoa(s.Index), true
+	case strings.HasPrefix(ref, "steps."):
+		stepID, output, ok := splitStepRef(ref)
+		if !ok {
+			return "", false
+		}
+		v, ok := s.Outputs[stepID][output]
+		return v, ok
+	default:
+		v, ok := s.Vars[strings.TrimPrefix(ref, "vars.")]
+		return v, ok
+	}
+}
+
+// Render expands the placeholders in tmpl. Unknown references are left in
+// place so agents can still see what was intended.
+func (s *Scope) Render(tmpl string) string {
+	return templateRefRegex.ReplaceAllStringFunc(tmpl, func(m string) string {
+		ref := templateRefRegex.FindStringSubmatch(m)[1]
+		if v, ok := s.lookup(ref); ok {
+			return v
+		}
+		return m
+	})
+}
+
+// RenderShell expands the placeholders in a shell command, quoting each
+// value as a single word. Vars, items and step outputs can come from agents
+// or issue text, so they are never spliced into the command as shell
+// syntax. Unknown references are left in place.
+func (s *Scope) RenderShell(tmpl string) string {
+	return templateRefRegex.ReplaceAllStringFunc(tmpl, func(m string) string {
+		ref := templateRefRegex.FindStringSubmatch(m)[1]
+		if v, ok := s.lookup(ref); ok {
+			return util.ShellQuote(v)
+		}
+		return m
+	})
+}
+
+// renderStrict expands placeholders, replacing unknown references with "".
+// Used for conditions and foreach lists, where a literal placeholder would
+// be mistaken for a value.
+func (s *Scope) renderStrict(tmpl string) string {
+	return templateRefRegex.ReplaceAllStringFunc(tmpl, func(m string) string {
+		v, _ := s.lookup(templateRefRegex.FindStringSubmatch(m)[1])
+		return v
+	})
+}
+
+// splitStepRef splits "steps.<id>.<output>" into its parts. The output is
+// everything after the last dot, so step IDs may themselves contain dots.
+func splitStepRef(ref string) (stepID, output string, ok bool) {
+	rest := strings.TrimPrefix(ref, "steps.")
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 || i == len(rest)-1 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+// templateRefs returns the references used in tmpl.
+func templateRefs(tmpl string) []string {
+	var refs []string
+	for _, m := range templateRefRegex.FindAllStringSubmatch(tmpl, -1) {
+		refs = append(refs, m[1])
+	}
+	return refs
+}
+
+// SplitList turns an expanded foreach value into items: one per line, or
+// comma-separated when the value is a single line. Blank items are dropped.
+func SplitList(value string) []string {
+	sep := "\n"
+	if !strings.Contains(strings.TrimSpace(value), "\n") {
+		sep = ","
+	}
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// condClause is one comparison or truthiness test in a when condition.
+type condClause struct {
+	negate bool
+	left   string
+	op     string // "", "==" or "!="
+	right  string
+}
+
+// parseCondition parses a when expression into OR-groups of AND-clauses.
+// Operands stay unexpanded so values containing && or || can't change the
+// structure of the expression.
+func parseCondition(expr string) ([][]condClause, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("empty condition")
+	}
+	var groups [][]condClause
+	for _, orPart := range strings.Split(expr, "||") {
+		var group []condClause
+		for _, andPart := range strings.Split(orPart, "&&") {
+			clause, err := parseClause(andPart)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, clause)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func parseClause(s string) (condClause, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return condClause{}, fmt.Errorf("empty clause")
+	}
+	var c condClause
+	for _, op := range []string{"==", "!="} {
+		if i := strings.Index(s, op); i >= 0 {
+			c.left = strings.TrimSpace(s[:i])
+			c.op = op
+			c.right = strings.TrimSpace(s[i+len(op):])
+			if c.left == "" || c.right == "" {
+				return condClause{}, fmt.Errorf("clause %q is missing an operand", s)
+			}
+			if strings.Contains(c.right, "==") || strings.Contains(c.right, "!=") {
+				return condClause{}, fmt.Errorf("clause %q has more than one comparison", s)
+			}
+			return c, nil
+		}
+	}
+	if strings.HasPrefix(s, "!") {
+		c.negate = true
+		s = strings.TrimSpace(s[1:])
+		if s == "" {
+			return condClause{}, fmt.Errorf("negation is missing an operand")
+		}
+	}
+	c.left = s
+	return c, nil
+}
+
+// unquote strips one layer of matching single or double quotes.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// truthy reports whether a value counts as true in a condition.
+func truthy(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
+
+// EvalCondition evaluates a when expression against scope. Operands are
+// templates; quoted operands are compared without their quotes.
+func EvalCondition(expr string, scope *Scope) (bool, error) {
+	groups, err := parseCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		all := true
+		for _, c := range group {
+			left := unquote(scope.renderStrict(c.left))
+			var ok bool
+			switch c.op {
+			case "==":
+				ok = left == unquote(scope.renderStrict(c.right))
+			case "!=":
+				ok = left != unquote(scope.renderStrict(c.right))
+			default:
+				ok = truthy(left) != c.negate
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -46,6 +46,26 @@ type Formula struct {
 
 	// Aspect-specific (similar to convoy but for analysis)
 	Aspects []Aspect `toml:"aspects"`
+
+	// Squash configures the digest left when a molecule is squashed.
+	Squash *Squash `toml:"squash"`
+}
+
+// Squash configures how a formula's molecules are squashed into digests.
+//
+// Template is a Go text/template rendered with the digest: .Agent, .Cycles,
+// .Duration, .Steps, .Summary, {{.Metric "name"}} for metrics and numeric
+// step outputs, and {{.Output "name"}} for the latest value of a step output.
+type Squash struct {
+	// TemplateType picks the default template: "patrol" or "work".
+	TemplateType string `toml:"template_type"`
+
+	// Template replaces the default template for TemplateType.
+	Template string `toml:"template"`
+
+	// Cadence folds squashes into one digest until this long has passed
+	// since the first of them (e.g., "1h"). Empty: one digest per squash.
+	Cadence string `toml:"cadence"`
 }
 
 // Aspect represents a parallel analysis aspect in an aspect formula.
@@ -88,11 +108,47 @@ type Synthesis struct {
 }
 
 // Step represents a sequential step in a workflow formula.
+//
+// Title, Description, Run, When and Foreach are templates: {{name}} (or
+// {{vars.name}}) expands a variable, {{steps.<id>.<output>}} a named output
+// of an earlier step, and {{item}}/{{index}} the current foreach element.
 type Step struct {
 	ID          string   `toml:"id"`
 	Title       string   `toml:"title"`
 	Description string   `toml:"description"`
 	Needs       []string `toml:"needs"`
+
+	// Run is a shell command executed by gt formula run. Steps without
+	// one are dispatched to an agent as a bead.
+	Run string `toml:"run"`
+
+	// When is a condition; the step is skipped when it evaluates false.
+	// Clauses compare operands with == or != (or test one for truthiness,
+	// optionally negated with !) and are joined with && and ||.
+	When string `toml:"when"`
+
+	// Foreach fans the step out over a list: the expanded value split on
+	// newlines, or on commas when it is a single line.
+	Foreach string `toml:"foreach"`
+
+	// Timeout bounds each attempt (e.g., "10m").
+	Timeout string `toml:"timeout"`
+
+	// Retry re-runs a failed attempt.
+	Retry *RetryPolicy `toml:"retry"`
+
+	// Outputs names the values this step publishes to later steps.
+	Outputs []string `toml:"outputs"`
+}
+
+// RetryPolicy controls how a failed step is retried.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, including the first.
+	Attempts int `toml:"attempts"`
+
+	// Backoff is the delay before the first retry (e.g., "30s"),
+	// doubled for each retry after that.
+	Backoff string `toml:"backoff"`
 }
 
 // Template represents a template step in an expansion formula.
@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 )
 
@@ -48,6 +50,11 @@ func (g *Git) IsRepo() bool {
 
 // run executes a git command and returns stdout.
 func (g *Git) run(args ...string) (string, error) {
+	return g.runEnv(nil, args...)
+}
+
+// runEnv is run with extra environment variables (KEY=value) for git.
+func (g *Git) runEnv(env []string, args ...string) (string, error) {
 	// If gitDir is set (bare repo), prepend --git-dir flag
 	if g.gitDir != "" {
 		args = append([]string{"--git-dir=" + g.gitDir}, args...)
@@ -57,6 +64,9 @@ func (g *Git) run(args ...string) (string, error) {
 	if g.workDir != "" {
 		cmd.Dir = g.workDir
 	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -323,6 +333,17 @@ func (g *Git) MergeNoFF(branch, message string) error {
 	return err
 }
 
+// Revert commits the inverse of commit. For a merge commit, mainline is the
+// parent to revert to (1 for the branch merged into); 0 for other commits.
+func (g *Git) Revert(commit string, mainline int) error {
+	args := []string{"revert", "--no-edit"}
+	if mainline > 0 {
+		args = append(args, "-m", strconv.Itoa(mainline))
+	}
+	_, err := g.run(append(args, commit)...)
+	return err
+}
+
 // DeleteRemoteBranch deletes a branch on the remote.
 func (g *Git) DeleteRemoteBranch(remote, branch string) error {
 	_, err := g.run("push", remote, "--delete", branch)
@@ -436,6 +457,71 @@ func (g *Git) AbortRebase() error {
 	return err
 }
 
+// RebaseContinue continues a rebase once conflicts are resolved and staged,
+// keeping the original commit message. GIT_EDITOR overrides any editor
+// the user configured, so the rebase never waits on one.
+func (g *Git) RebaseContinue() error {
+	_, err := g.runEnv([]string{"GIT_EDITOR=true"}, "rebase", "--continue")
+	return err
+}
+
+// RebaseSkip drops the commit a rebase stopped on, e.g. when resolving its
+// conflicts left nothing to commit.
+func (g *Git) RebaseSkip() error {
+	_, err := g.run("rebase", "--skip")
+	return err
+}
+
+// ConflictingFiles returns the unmerged paths of a merge or rebase in progress.
+func (g *Git) ConflictingFiles() ([]string, error) {
+	return g.getConflictingFiles()
+}
+
+// CheckoutConflictSide resolves a conflicted path by taking one side:
+// "--ours" or "--theirs". During a rebase "ours" is the branch being
+// rebased onto and "theirs" is the commit being replayed.
+func (g *Git) CheckoutConflictSide(path, side string) error {
+	_, err := g.run("checkout", side, "--", path)
+	return err
+}
+
+// ConflictStages writes the base, ours and theirs versions of a conflicted
+// path to temporary files (see git checkout-index --temp) and returns their
+// paths relative to the work dir. A side that does not exist (e.g., no
+// common base for an add/add conflict) is returned as "". The caller
+// removes the files.
+func (g *Git) ConflictStages(path string) (base, ours, theirs string, err error) {
+	out, err := g.run("checkout-index", "--stage=all", "--temp", "--", path)
+	if err != nil {
+		return "", "", "", err
+	}
+	stages, _, _ := strings.Cut(out, "\t")
+	fields := strings.Fields(stages)
+	if len(fields) != 3 {
+		return "", "", "", fmt.Errorf("unexpected checkout-index output %q", out)
+	}
+	for i, f := range fields {
+		if f == "." {
+			fields[i] = ""
+		}
+	}
+	return fields[0], fields[1], fields[2], nil
+}
+
+// MergeFileUnion merges theirs into ours line-wise against base, keeping the
+// lines of both sides where they conflict. The result is written to ours.
+func (g *Git) MergeFileUnion(ours, base, theirs string) error {
+	_, err := g.run("merge-file", "--union", ours, base, theirs)
+	return err
+}
+
+// PushForceWithLease force-pushes refspec to remote, but only if the remote
+// branch is still at expect.
+func (g *Git) PushForceWithLease(remote, refspec, branch, expect string) error {
+	_, err := g.run("push", "--force-with-lease="+branch+":"+expect, remote, refspec)
+	return err
+}
+
 // CreateBranch creates a new branch.
 func (g *Git) CreateBranch(name string) error {
 	_, err := g.run("branch", name)
@@ -10,7 +10,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/notify"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -32,6 +34,7 @@ type Router struct {
 	workDir  string // fallback directory to run bd commands in
 	townRoot string // town root directory (e.g., ~/gt)
 	tmux     *tmux.Tmux
+	store    beads.Store // agent lookups; nil uses the shared town store
 }
 
 // NewRouter creates a new mail router.
@@ -444,42 +447,35 @@ func (r *Router) resolveAgentsByRig(rig string) ([]string, error) {
 	return addresses, nil
 }
 
-// queryAgents queries agent beads using bd list with description filtering.
+// queryAgents queries active agent beads whose description contains
+// descContains. Agent lookups run on every group address, so they are
+// served from the cached beads store rather than a bd call each.
 func (r *Router) queryAgents(descContains string) ([]*agentBead, error) {
-	beadsDir := r.resolveBeadsDir("")
-	args := []string{"list", "--type=agent", "--json", "--limit=0"}
-
-	if descContains != "" {
-		args = append(args, "--desc-contains="+descContains)
+	store := r.store
+	if store == nil {
+		store = beads.SharedStore(filepath.Dir(r.resolveBeadsDir("")))
 	}
 
-	cmd := exec.Command("bd", args...)
-	cmd.Env = append(cmd.Environ(), "BEADS_DIR="+beadsDir)
-	cmd.Dir = filepath.Dir(beadsDir)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		errMsg := strings.TrimSpace(stderr.String())
-		if errMsg != "" {
-			return nil, errors.New(errMsg)
-		}
+	issues, err := store.List(beads.ListOptions{Type: "agent", Priority: -1})
+	if err != nil {
 		return nil, fmt.Errorf("querying agents: %w", err)
 	}
 
-	var agents []*agentBead
-	if err := json.Unmarshal(stdout.Bytes(), &agents); err != nil {
-		return nil, fmt.Errorf("parsing agent query result: %w", err)
-	}
-
 	// Filter for open agents only (closed agents are inactive)
 	var active []*agentBead
-	for _, agent := range agents {
-		if agent.Status == "open" || agent.Status == "in_progress" {
-			active = append(active, agent)
+	for _, issue := range issues {
+		if issue.Status != "open" && issue.Status != "in_progress" {
+			continue
 		}
+		if descContains != "" && !strings.Contains(issue.Description, descContains) {
+			continue
+		}
+		active = append(active, &agentBead{
+			ID:          issue.ID,
+			Title:       issue.Title,
+			Description: issue.Description,
+			Status:      issue.Status,
+		})
 	}
 
 	return active, nil
@@ -518,6 +514,19 @@ func (r *Router) shouldBeWisp(msg *Message) bool {
 // - Queues (queue:name) - stores single message for worker claiming
 // - Announces (announce:name) - bulletin board, no claiming, retention-limited
 func (r *Router) Send(msg *Message) error {
+	if err := r.send(msg); err != nil {
+		return err
+	}
+
+	// Forward to outbound notification channels (mayor/config.json "notify")
+	if r.townRoot != "" {
+		_ = notify.QueueMail(r.townRoot, msg.From, msg.To, msg.Subject, msg.Body, string(msg.Priority))
+	}
+	return nil
+}
+
+// send routes a message by address type.
+func (r *Router) send(msg *Message) error {
 	// Check for mailing list address
 	if isListAddress(msg.To) {
 		return r.sendToList(msg)
@@ -666,7 +675,7 @@ func (r *Router) sendToList(msg *Message) error {
 		copy := *msg
 		copy.To = recipient
 
-		if err := r.Send(&copy); err != nil {
+		if err := r.send(&copy); err != nil {
 			lastErr = err
 			continue
 		}
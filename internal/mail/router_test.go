@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
 func TestDetectTownRoot(t *testing.T) {
@@ -813,3 +815,31 @@ func TestExpandAnnounceNoTownRoot(t *testing.T) {
 		t.Errorf("expandAnnounce error = %v, want containing 'no town root'", err)
 	}
 }
+
+func TestResolveAgentsByRig_ReadsBeadsStore(t *testing.T) {
+	townRoot := t.TempDir()
+	beadsDir := filepath.Join(townRoot, ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	jsonl := `{"id":"gt-gastown-witness","issue_type":"agent","status":"open","description":"role_type: witness\nrig: gastown"}
+{"id":"gt-gastown-crew-max","issue_type":"agent","status":"in_progress","description":"role_type: crew\nrig: gastown"}
+{"id":"gt-gastown-crew-old","issue_type":"agent","status":"closed","description":"role_type: crew\nrig: gastown"}
+{"id":"gt-beads-witness","issue_type":"agent","status":"open","description":"role_type: witness\nrig: beads"}
+{"id":"gt-task","issue_type":"task","status":"open","description":"rig: gastown"}
+`
+	if err := os.WriteFile(filepath.Join(beadsDir, "issues.jsonl"), []byte(jsonl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRouterWithTownRoot(townRoot, townRoot)
+	r.store = beads.NewCachedStore(townRoot)
+
+	got, err := r.resolveAgentsByRig("gastown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "gastown/max" || got[1] != "gastown/witness" {
+		t.Errorf("resolveAgentsByRig = %v, want [gastown/max gastown/witness]", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -22,20 +23,41 @@ const (
 	EventMergeFailed EventType = "merge_failed"
 	// EventMergeSkipped indicates an MR was skipped (already merged, etc.).
 	EventMergeSkipped EventType = "merge_skipped"
+	// EventReverted indicates a merged MR was reverted by a landing group rollback.
+	EventReverted EventType = "reverted"
+	// EventBatched records the outcome of an MR that rode in a test batch.
+	EventBatched EventType = "batched"
+)
+
+// Batch outcomes recorded in batched events.
+const (
+	// BatchLanded means the MR passed as part of the batch and landed.
+	BatchLanded = "landed"
+	// BatchCulprit means bisection identified the MR as a cause of failure.
+	BatchCulprit = "culprit"
+	// BatchUnstacked means the MR could not be merged into the batch
+	// (conflicts, missing branch).
+	BatchUnstacked = "unstacked"
+	// BatchRequeued means the MR was released for a later batch.
+	BatchRequeued = "requeued"
 )
 
 // Event represents a single MQ lifecycle event.
 type Event struct {
-	Timestamp   time.Time `json:"timestamp"`
-	Type        EventType `json:"type"`
-	MRID        string    `json:"mr_id"`
-	Branch      string    `json:"branch"`
-	Target      string    `json:"target"`
-	Worker      string    `json:"worker,omitempty"`
-	SourceIssue string    `json:"source_issue,omitempty"`
-	Rig         string    `json:"rig,omitempty"`
-	MergeCommit string    `json:"merge_commit,omitempty"` // For merged events
-	Reason      string    `json:"reason,omitempty"`       // For failed/skipped events
+	Timestamp    time.Time `json:"timestamp"`
+	Type         EventType `json:"type"`
+	MRID         string    `json:"mr_id"`
+	Branch       string    `json:"branch"`
+	Target       string    `json:"target"`
+	Worker       string    `json:"worker,omitempty"`
+	SourceIssue  string    `json:"source_issue,omitempty"`
+	Rig          string    `json:"rig,omitempty"`
+	MergeCommit  string    `json:"merge_commit,omitempty"`  // For merged and reverted events
+	RevertCommit string    `json:"revert_commit,omitempty"` // For reverted events
+	Reason       string    `json:"reason,omitempty"`        // For failed/skipped events
+	Batch        string    `json:"batch,omitempty"`         // For batched events
+	Outcome      string    `json:"outcome,omitempty"`       // For batched events
+	TestRuns     int       `json:"test_runs,omitempty"`     // For batched events: test runs the batch needed
 }
 
 // EventLogger handles writing MQ events to the event log.
@@ -146,6 +168,71 @@ func (l *EventLogger) LogMergeSkipped(mr *MR, reason string) error {
 	})
 }
 
+// LogReverted logs a reverted event.
+func (l *EventLogger) LogReverted(mr *MR, mergeCommit, revertCommit, reason string) error {
+	return l.LogEvent(Event{
+		Type:         EventReverted,
+		MRID:         mr.ID,
+		Branch:       mr.Branch,
+		Target:       mr.Target,
+		Worker:       mr.Worker,
+		SourceIssue:  mr.SourceIssue,
+		Rig:          mr.Rig,
+		MergeCommit:  mergeCommit,
+		RevertCommit: revertCommit,
+		Reason:       reason,
+	})
+}
+
+// LogBatched logs a batched event recording which batch mr rode in and
+// its outcome (one of the Batch* constants).
+func (l *EventLogger) LogBatched(mr *MR, batchID, outcome string, testRuns int, reason string) error {
+	return l.LogEvent(Event{
+		Type:        EventBatched,
+		MRID:        mr.ID,
+		Branch:      mr.Branch,
+		Target:      mr.Target,
+		Worker:      mr.Worker,
+		SourceIssue: mr.SourceIssue,
+		Rig:         mr.Rig,
+		Batch:       batchID,
+		Outcome:     outcome,
+		TestRuns:    testRuns,
+		Reason:      reason,
+	})
+}
+
+// Events reads the event log, keeping only events for mrID if it is
+// non-empty. A missing log yields no events.
+func (l *EventLogger) Events(mrID string) ([]Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	data, err := os.ReadFile(l.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading event log: %w", err)
+	}
+
+	var events []Event
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			continue // skip torn or foreign lines
+		}
+		if mrID != "" && event.MRID != mrID {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
 // LogPath returns the path to the event log file.
 func (l *EventLogger) LogPath() string {
 	return l.logPath
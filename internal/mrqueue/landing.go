@@ -0,0 +1,295 @@
+package mrqueue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Landing groups
+//
+// A landing group makes MRs in several rigs land all-or-nothing, for changes
+// that span repositories (an API and its client). Members are MRs tagged
+// with the group's ID; ListReady holds them back from normal processing.
+// Once every rig in the group's order has members and all of them are green
+// on the current target, they are pushed rig by rig in that order. If a
+// later rig's push fails, the rigs already landed are reverted.
+//
+// Groups belong to convoys (the group ID is the convoy ID) and are stored
+// at town level, in <town>/.beads/mq/landing/, since they span rig queues.
+
+// LandingState is the lifecycle state of a landing group.
+type LandingState string
+
+const (
+	// LandingWaiting means members are missing, untested or failing.
+	LandingWaiting LandingState = "waiting"
+	// LandingInProgress means members are being pushed in order.
+	LandingInProgress LandingState = "landing"
+	// LandingLanded means every member landed.
+	LandingLanded LandingState = "landed"
+	// LandingRolledBack means a push failed and earlier rigs were reverted.
+	LandingRolledBack LandingState = "rolled_back"
+)
+
+// MemberState is the state of one MR in a landing group.
+type MemberState string
+
+const (
+	// MemberPending means the MR has not been tested on the current target.
+	MemberPending MemberState = "pending"
+	// MemberGreen means the MR merged cleanly and passed tests.
+	MemberGreen MemberState = "green"
+	// MemberRed means the MR conflicted or failed tests.
+	MemberRed MemberState = "red"
+	// MemberLanded means the MR was pushed to its target.
+	MemberLanded MemberState = "landed"
+	// MemberReverted means the MR landed and was reverted by a rollback.
+	MemberReverted MemberState = "reverted"
+)
+
+// LandingMember is an MR in a landing group.
+type LandingMember struct {
+	Rig         string      `json:"rig"`
+	MRID        string      `json:"mr_id"`
+	Branch      string      `json:"branch"`
+	Target      string      `json:"target"`
+	SourceIssue string      `json:"source_issue,omitempty"`
+	State       MemberState `json:"state"`
+
+	// Head is the branch tip that was tested; a new tip needs a new test.
+	Head string `json:"head,omitempty"`
+	// Base is the target commit the MR was tested on.
+	Base string `json:"base,omitempty"`
+	// Commit is the tested merge commit, pushed as-is when the group lands.
+	Commit string `json:"commit,omitempty"`
+	// RevertCommits are the commits that backed Commit out in a rollback,
+	// in the order they were made. Landing again reverts them.
+	RevertCommits []string `json:"revert_commits,omitempty"`
+
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LandingGroup is a set of MRs across rigs that land together.
+type LandingGroup struct {
+	ID       string `json:"id"`
+	ConvoyID string `json:"convoy_id,omitempty"`
+
+	// Order lists the rigs in the order they land.
+	Order []string `json:"order"`
+
+	// Issues are the source issues whose MRs join the group when queued.
+	Issues []string `json:"issues,omitempty"`
+
+	State   LandingState     `json:"state"`
+	Members []*LandingMember `json:"members,omitempty"`
+	Error   string           `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RigMembers returns the members in rig, in the order they were added.
+func (g *LandingGroup) RigMembers(rig string) []*LandingMember {
+	var members []*LandingMember
+	for _, m := range g.Members {
+		if m.Rig == rig {
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// Member returns the member for an MR, or nil.
+func (g *LandingGroup) Member(mrID string) *LandingMember {
+	for _, m := range g.Members {
+		if m.MRID == mrID {
+			return m
+		}
+	}
+	return nil
+}
+
+// Ready reports whether the group can land: every rig in Order has at least
+// one member and every member is green.
+func (g *LandingGroup) Ready() bool {
+	for _, rig := range g.Order {
+		if len(g.RigMembers(rig)) == 0 {
+			return false
+		}
+	}
+	for _, m := range g.Members {
+		if m.State != MemberGreen {
+			return false
+		}
+	}
+	return len(g.Members) > 0
+}
+
+// Done reports whether the group reached a final state.
+func (g *LandingGroup) Done() bool {
+	return g.State == LandingLanded || g.State == LandingRolledBack
+}
+
+// HasIssue reports whether issue's MR belongs in the group.
+func (g *LandingGroup) HasIssue(issue string) bool {
+	return slices.Contains(g.Issues, issue)
+}
+
+// HasRig reports whether rig is in the group's landing order.
+func (g *LandingGroup) HasRig(rig string) bool {
+	return slices.Contains(g.Order, rig)
+}
+
+// SortMembers orders members by the group's rig order, keeping the relative
+// order of members within a rig.
+func (g *LandingGroup) SortMembers() {
+	rank := func(rig string) int {
+		if i := slices.Index(g.Order, rig); i >= 0 {
+			return i
+		}
+		return len(g.Order)
+	}
+	sort.SliceStable(g.Members, func(i, j int) bool {
+		return rank(g.Members[i].Rig) < rank(g.Members[j].Rig)
+	})
+}
+
+// LandingStore persists landing groups for a town.
+type LandingStore struct {
+	dir string // <town>/.beads/mq/landing/
+}
+
+// NewLandingStore creates a landing group store for the given town root.
+func NewLandingStore(townRoot string) *LandingStore {
+	return &LandingStore{
+		dir: filepath.Join(townRoot, ".beads", "mq", "landing"),
+	}
+}
+
+// Dir returns the store directory path.
+func (s *LandingStore) Dir() string {
+	return s.dir
+}
+
+// Create stores a new group. It fails if the group already exists.
+func (s *LandingStore) Create(g *LandingGroup) error {
+	if g.ID == "" || strings.ContainsAny(g.ID, `/\`) {
+		return fmt.Errorf("invalid landing group ID %q", g.ID)
+	}
+	if len(g.Order) == 0 {
+		return fmt.Errorf("landing group %s has no rigs", g.ID)
+	}
+	if _, err := s.Get(g.ID); err == nil {
+		return fmt.Errorf("landing group %s already exists", g.ID)
+	}
+	if g.State == "" {
+		g.State = LandingWaiting
+	}
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now()
+	}
+	return s.Save(g)
+}
+
+// Get loads a group by ID. A missing group returns ErrNotFound.
+func (s *LandingStore) Get(id string) (*LandingGroup, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var g LandingGroup
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("parsing landing group %s: %w", id, err)
+	}
+	return &g, nil
+}
+
+// Save writes a group, replacing any previous version.
+func (s *LandingStore) Save(g *LandingGroup) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("creating landing directory: %w", err)
+	}
+	g.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling landing group: %w", err)
+	}
+
+	path := filepath.Join(s.dir, g.ID+".json")
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath) // cleanup
+		return fmt.Errorf("renaming temp file: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a group. A missing group is not an error.
+func (s *LandingStore) Remove(id string) error {
+	err := os.Remove(filepath.Join(s.dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// List returns all groups, oldest first.
+func (s *LandingStore) List() ([]*LandingGroup, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading landing directory: %w", err)
+	}
+
+	var groups []*LandingGroup
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		g, err := s.Get(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue // Skip malformed files
+		}
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+	})
+	return groups, nil
+}
+
+// GroupFor returns the unfinished group that an MR for issue in rig joins,
+// or nil if it lands alone.
+func (s *LandingStore) GroupFor(rig, issue string) (*LandingGroup, error) {
+	if issue == "" {
+		return nil, nil
+	}
+	groups, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if !g.Done() && g.HasRig(rig) && g.HasIssue(issue) {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
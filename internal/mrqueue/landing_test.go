@@ -0,0 +1,92 @@
+package mrqueue
+
+import (
+	"testing"
+)
+
+func TestLandingGroup_Ready(t *testing.T) {
+	g := &LandingGroup{ID: "hq-cv-1", Order: []string{"api", "client"}}
+	if g.Ready() {
+		t.Error("empty group is ready")
+	}
+
+	g.Members = []*LandingMember{{Rig: "client", MRID: "mr-2", State: MemberGreen}}
+	if g.Ready() {
+		t.Error("group without an api MR is ready")
+	}
+
+	g.Members = append(g.Members, &LandingMember{Rig: "api", MRID: "mr-1", State: MemberPending})
+	g.SortMembers()
+	if g.Members[0].Rig != "api" {
+		t.Errorf("members not in landing order: first is %s", g.Members[0].Rig)
+	}
+	if g.Ready() {
+		t.Error("group with an untested MR is ready")
+	}
+
+	g.Members[0].State = MemberGreen
+	if !g.Ready() {
+		t.Error("group with every rig green is not ready")
+	}
+}
+
+func TestLandingStore_GroupFor(t *testing.T) {
+	store := NewLandingStore(t.TempDir())
+	g := &LandingGroup{ID: "hq-cv-1", Order: []string{"api", "client"}, Issues: []string{"gt-a", "cl-b"}}
+	if err := store.Create(g); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(g); err == nil {
+		t.Error("creating a group twice succeeded")
+	}
+
+	tests := []struct {
+		rig, issue string
+		want       bool
+	}{
+		{"api", "gt-a", true},
+		{"client", "cl-b", true},
+		{"api", "gt-other", false},
+		{"docs", "gt-a", false}, // rig not in the group
+	}
+	for _, tc := range tests {
+		got, err := store.GroupFor(tc.rig, tc.issue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got != nil) != tc.want {
+			t.Errorf("GroupFor(%s, %s) = %v, want group: %v", tc.rig, tc.issue, got, tc.want)
+		}
+	}
+
+	// Finished groups no longer collect MRs.
+	g.State = LandingLanded
+	if err := store.Save(g); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GroupFor("api", "gt-a"); got != nil {
+		t.Errorf("GroupFor matched a landed group")
+	}
+}
+
+func TestListReady_HoldsLandingGroupMembers(t *testing.T) {
+	q := New(t.TempDir())
+	for _, mr := range []*MR{{ID: "mr-alone"}, {ID: "mr-grouped", LandingGroup: "hq-cv-1"}} {
+		if err := q.Submit(mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ready, err := q.ListReady(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ready) != 1 || ready[0].ID != "mr-alone" {
+		t.Errorf("ready = %v, want only mr-alone", ready)
+	}
+
+	members, _ := q.ListGroup("hq-cv-1")
+	if len(members) != 1 || members[0].ID != "mr-grouped" {
+		t.Errorf("group members = %v, want mr-grouped", members)
+	}
+}
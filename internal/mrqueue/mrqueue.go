@@ -39,6 +39,15 @@ type MR struct {
 
 	// Blocking fields for non-blocking delegation
 	BlockedBy string `json:"blocked_by,omitempty"` // Task ID that blocks this MR (e.g., conflict resolution task)
+
+	// LandingGroup holds the MR until every MR in its cross-rig landing
+	// group is green (see LandingGroup). Empty for MRs that land alone.
+	LandingGroup string `json:"landing_group,omitempty"`
+
+	// Pull request fields for PR mode (merge_queue.pr_mode)
+	PRNumber   int      `json:"pr_number,omitempty"`   // Forge pull request number
+	PRURL      string   `json:"pr_url,omitempty"`      // Forge pull request URL
+	PRNotified []string `json:"pr_notified,omitempty"` // Review/check failures already sent to the worker
 }
 
 // Queue manages the MR storage.
@@ -381,6 +390,72 @@ func (q *Queue) SetBlockedBy(mrID, taskID string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// SetPR records the forge pull request for an MR and the failures already
+// reported to its worker, so later passes don't repeat them.
+func (q *Queue) SetPR(mrID string, number int, url string, notified []string) error {
+	path := filepath.Join(q.dir, mrID+".json")
+
+	mr, err := q.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("loading MR: %w", err)
+	}
+
+	mr.PRNumber = number
+	mr.PRURL = url
+	mr.PRNotified = notified
+
+	data, err := json.MarshalIndent(mr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling MR: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// SetLandingGroup tags an MR with a landing group, or untags it when group
+// is empty.
+func (q *Queue) SetLandingGroup(mrID, group string) error {
+	path := filepath.Join(q.dir, mrID+".json")
+
+	mr, err := q.load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("loading MR: %w", err)
+	}
+
+	mr.LandingGroup = group
+
+	data, err := json.MarshalIndent(mr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling MR: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// ListGroup returns the MRs tagged with a landing group, sorted by priority
+// then creation time.
+func (q *Queue) ListGroup(group string) ([]*MR, error) {
+	all, err := q.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []*MR
+	for _, mr := range all {
+		if mr.LandingGroup == group {
+			members = append(members, mr)
+		}
+	}
+
+	return members, nil
+}
+
 // ClearBlockedBy removes the blocking task from an MR.
 func (q *Queue) ClearBlockedBy(mrID string) error {
 	return q.SetBlockedBy(mrID, "")
@@ -410,6 +485,7 @@ type BeadStatusChecker func(beadID string) (isOpen bool, err error)
 // ListReady returns MRs that are ready for processing:
 // - Not claimed by another worker (or claim is stale)
 // - Not blocked by an open task
+// - Not held by a landing group (groups land through LandingGroup)
 // Sorted by priority score (highest first).
 // The checkStatus function is used to check if blocking tasks are still open.
 func (q *Queue) ListReady(checkStatus BeadStatusChecker) ([]*MR, error) {
@@ -428,6 +504,11 @@ func (q *Queue) ListReady(checkStatus BeadStatusChecker) ([]*MR, error) {
 			// Stale claim - include in ready list
 		}
 
+		// Skip if held for a coordinated cross-rig landing
+		if mr.LandingGroup != "" {
+			continue
+		}
+
 		// Skip if blocked by an open task
 		if mr.BlockedBy != "" && checkStatus != nil {
 			isOpen, err := checkStatus(mr.BlockedBy)
@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Default channel templates.
+const (
+	defaultSlackTemplate = "*{{.Title}}*{{if .Body}}\n{{.Body}}{{end}}"
+	defaultEmailSubject  = "[gt] {{.Title}}"
+	defaultEmailTemplate = `{{.Title}}
+
+{{if .Body}}{{.Body}}
+
+{{end}}Severity: {{.Severity}}
+{{if .Rig}}Rig: {{.Rig}}
+{{end}}{{if .Actor}}From: {{.Actor}}
+{{end}}Time: {{.Time.Format "2006-01-02 15:04:05 MST"}}
+`
+)
+
+const (
+	httpTimeout     = 15 * time.Second
+	smtpTimeout     = 30 * time.Second
+	defaultSMTPPort = 587
+)
+
+// permanentError marks a delivery failure that retrying won't fix, such
+// as a webhook rejecting the payload.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// IsPermanent reports whether a delivery error should not be retried.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// templateFuncs are available in channel templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"upper": strings.ToUpper,
+}
+
+// render executes a channel template against a notification.
+func render(name, text string, n *Notification) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", &permanentError{fmt.Errorf("parsing %s template: %w", name, err)}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return "", &permanentError{fmt.Errorf("rendering %s template: %w", name, err)}
+	}
+	return buf.String(), nil
+}
+
+// Deliver sends a notification to a channel once. Errors for which
+// IsPermanent is true should not be retried.
+func Deliver(ctx context.Context, ch *config.NotifyChannel, n *Notification) error {
+	switch ch.Type {
+	case config.NotifyWebhook:
+		return deliverWebhook(ctx, ch, n)
+	case config.NotifySlack:
+		return deliverSlack(ctx, ch, n)
+	case config.NotifySMTP:
+		return deliverSMTP(ctx, ch, n)
+	default:
+		return &permanentError{fmt.Errorf("unknown channel type %q", ch.Type)}
+	}
+}
+
+// deliverWebhook POSTs the rendered template, or the notification as JSON.
+func deliverWebhook(ctx context.Context, ch *config.NotifyChannel, n *Notification) error {
+	var body []byte
+	contentType := "application/json"
+	if ch.Template == "" {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return &permanentError{fmt.Errorf("encoding notification: %w", err)}
+		}
+		body = data
+	} else {
+		text, err := render("webhook", ch.Template, n)
+		if err != nil {
+			return err
+		}
+		body = []byte(text)
+		if !json.Valid(body) {
+			contentType = "text/plain; charset=utf-8"
+		}
+	}
+	return post(ctx, ch, body, contentType)
+}
+
+// deliverSlack posts {"text": ...} to an incoming webhook. A template that
+// renders to a JSON object is sent as the full payload (for blocks).
+func deliverSlack(ctx context.Context, ch *config.NotifyChannel, n *Notification) error {
+	tmpl := ch.Template
+	if tmpl == "" {
+		tmpl = defaultSlackTemplate
+	}
+	text, err := render("slack", tmpl, n)
+	if err != nil {
+		return err
+	}
+
+	body := []byte(text)
+	if !strings.HasPrefix(strings.TrimSpace(text), "{") || !json.Valid(body) {
+		body, err = json.Marshal(map[string]string{"text": text})
+		if err != nil {
+			return &permanentError{fmt.Errorf("encoding slack payload: %w", err)}
+		}
+	}
+	return post(ctx, ch, body, "application/json")
+}
+
+// post sends body to the channel URL. 4xx responses other than 408 and 429
+// are permanent; everything else is worth retrying.
+func post(ctx context.Context, ch *config.NotifyChannel, body []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(ch.URL), bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{fmt.Errorf("building request: %w", err)}
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "gastown-notify")
+	for k, v := range ch.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// deliverSMTP sends the notification as a plain-text email.
+func deliverSMTP(ctx context.Context, ch *config.NotifyChannel, n *Notification) error {
+	cfg := ch.SMTP
+	if cfg == nil {
+		return &permanentError{fmt.Errorf("smtp channel has no smtp settings")}
+	}
+
+	subjectTmpl := ch.Subject
+	if subjectTmpl == "" {
+		subjectTmpl = defaultEmailSubject
+	}
+	subject, err := render("subject", subjectTmpl, n)
+	if err != nil {
+		return err
+	}
+	bodyTmpl := ch.Template
+	if bodyTmpl == "" {
+		bodyTmpl = defaultEmailTemplate
+	}
+	body, err := render("email", bodyTmpl, n)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.ReplaceAll(subject, "\n", " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	port := cfg.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, os.ExpandEnv(cfg.Password), cfg.Host)
+	}
+
+	// smtp.SendMail has no context; bound it by running it aside.
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(addr, auth, cfg.From, cfg.To, msg.Bytes()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("sending mail via %s: %w", addr, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
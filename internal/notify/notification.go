@@ -0,0 +1,202 @@
+// Package notify sends town events and mail to humans outside Gas Town.
+//
+// Routes in mayor/config.json ("notify") select event types and mail
+// priorities and name the channels to deliver them to: generic webhooks,
+// Slack-compatible incoming webhooks, or SMTP. Matching notifications are
+// written to an outbox under daemon/notify/, and the daemon delivers them
+// with retry and exponential backoff, so a flaky endpoint or a daemon
+// restart doesn't lose an escalation.
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// Notification sources.
+const (
+	SourceEvent = "event"
+	SourceMail  = "mail"
+	SourceTest  = "test"
+)
+
+// Severity levels, from mail priority or escalation severity.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityNormal   = "normal"
+	SeverityLow      = "low"
+)
+
+// Notification is what channels render and deliver. Channel templates see
+// these fields, e.g. {{.Title}} or {{index .Fields "branch"}}.
+type Notification struct {
+	Source   string                 `json:"source"` // event, mail or test
+	Type     string                 `json:"type"`   // event type, or "mail"
+	Title    string                 `json:"title"`
+	Body     string                 `json:"body,omitempty"`
+	Severity string                 `json:"severity"`
+	Actor    string                 `json:"actor,omitempty"`
+	Rig      string                 `json:"rig,omitempty"`
+	Time     time.Time              `json:"time"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+}
+
+// key identifies repeats of the same notification for throttling.
+func (n *Notification) key() string {
+	return n.Type + "|" + n.Title
+}
+
+// FromEvent builds a notification for a town event.
+func FromEvent(ev events.Event) *Notification {
+	n := &Notification{
+		Source:   SourceEvent,
+		Type:     ev.Type,
+		Severity: SeverityNormal,
+		Actor:    ev.Actor,
+		Rig:      eventRig(ev),
+		Fields:   ev.Payload,
+	}
+	if t, err := time.Parse(time.RFC3339, ev.Timestamp); err == nil {
+		n.Time = t
+	} else {
+		n.Time = time.Now()
+	}
+
+	str := func(key string) string {
+		s, _ := ev.Payload[key].(string)
+		return s
+	}
+
+	switch ev.Type {
+	case events.TypeEscalationSent:
+		severity := strings.ToLower(str("severity"))
+		if severity == "" {
+			severity = SeverityHigh
+		}
+		n.Severity = severity
+		n.Title = fmt.Sprintf("Escalation from %s: %s", ev.Actor, str("reason"))
+		n.Body = str("message")
+
+	case events.TypeEscalationBreached:
+		n.Severity = strings.ToLower(str("severity"))
+		if n.Severity == "" {
+			n.Severity = SeverityHigh
+		}
+		what := "unacknowledged"
+		if str("breach") == "resolve" {
+			what = "unresolved"
+		}
+		n.Title = fmt.Sprintf("Escalation %s past its SLA: %s", what, str("reason"))
+		n.Body = str("message")
+
+	case events.TypeEscalationAcked, events.TypeEscalationResolved:
+		verb := "acknowledged"
+		if ev.Type == events.TypeEscalationResolved {
+			verb = "resolved"
+		}
+		n.Title = fmt.Sprintf("Escalation %s by %s: %s", verb, str("by"), str("reason"))
+		n.Body = str("message")
+
+	case events.TypeDoctorCheck:
+		switch str("status") {
+		case "error":
+			n.Severity = SeverityHigh
+		case "ok":
+			n.Severity = SeverityLow
+		}
+		n.Title = fmt.Sprintf("Doctor: %s is %s", str("check"), str("status"))
+		n.Body = str("message")
+
+	case events.TypeDogJob:
+		switch str("status") {
+		case "failed":
+			n.Severity = SeverityHigh
+			n.Title = fmt.Sprintf("Dog job %s failed", str("job"))
+		case "retrying":
+			n.Title = fmt.Sprintf("Dog job %s failed, retrying", str("job"))
+		default:
+			n.Severity = SeverityLow
+			n.Title = fmt.Sprintf("Dog job %s %s", str("job"), str("status"))
+		}
+		n.Body = fmt.Sprintf("%s (attempt %v on %s)\n%s", str("run"), ev.Payload["attempt"], str("dog"), str("message"))
+
+	case events.TypeMergeFailed:
+		n.Severity = SeverityHigh
+		n.Title = fmt.Sprintf("Merge failed: %s", str("branch"))
+		n.Body = str("reason")
+
+	case events.TypePolecatCrashed:
+		n.Title = fmt.Sprintf("Polecat %s/%s crashed", str("rig"), str("polecat"))
+		if reason := str("reason"); reason != "" {
+			n.Severity = SeverityHigh
+			n.Body = fmt.Sprintf("Automatic restart failed: %s\nHooked work: %s", reason, str("bead"))
+		} else {
+			n.Body = fmt.Sprintf("Restarted automatically. Hooked work: %s", str("bead"))
+		}
+
+	case events.TypeConvoyStranded:
+		n.Title = fmt.Sprintf("Convoy %s stranded: %s", str("convoy"), str("title"))
+		n.Body = fmt.Sprintf("%v ready issue(s) with no workers", ev.Payload["ready_count"])
+
+	default:
+		n.Title = fmt.Sprintf("%s from %s", ev.Type, ev.Actor)
+		n.Body = str("message")
+		if n.Body == "" {
+			n.Body = str("reason")
+		}
+	}
+	return n
+}
+
+// FromMail builds a notification for a mail message.
+func FromMail(from, to, subject, body, priority string) *Notification {
+	severity := SeverityNormal
+	switch priority {
+	case "urgent":
+		severity = SeverityCritical
+	case "high":
+		severity = SeverityHigh
+	case "low":
+		severity = SeverityLow
+	}
+	return &Notification{
+		Source:   SourceMail,
+		Type:     "mail",
+		Title:    subject,
+		Body:     body,
+		Severity: severity,
+		Actor:    from,
+		Rig:      actorRig(to),
+		Time:     time.Now(),
+		Fields: map[string]interface{}{
+			"from":     from,
+			"to":       to,
+			"priority": priority,
+		},
+	}
+}
+
+// eventRig returns the rig an event is about, from its payload or actor.
+func eventRig(ev events.Event) string {
+	if rig, ok := ev.Payload["rig"].(string); ok && rig != "" {
+		return rig
+	}
+	return actorRig(ev.Actor)
+}
+
+// actorRig extracts the rig from an address like "gastown/witness".
+func actorRig(actor string) string {
+	parts := strings.Split(actor, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+	switch parts[0] {
+	case "mayor", "deacon", "overseer":
+		return ""
+	}
+	return parts[0]
+}
@@ -0,0 +1,415 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/eventbus"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Retry defaults, used when mayor/config.json doesn't set notify.retry.
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 30 * time.Second
+	DefaultMaxBackoff  = 15 * time.Minute
+)
+
+// drainInterval is how often the daemon retries due notifications and
+// picks up mail queued by other processes.
+const drainInterval = 5 * time.Second
+
+// Job is a pending delivery of one notification to one channel.
+type Job struct {
+	ID           string        `json:"id"`
+	Channel      string        `json:"channel"`
+	Notification *Notification `json:"notification"`
+	Attempts     int           `json:"attempts"`
+	NextAttempt  time.Time     `json:"next_attempt"`
+	LastError    string        `json:"last_error,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// OutboxDir returns the directory of notifications awaiting delivery.
+func OutboxDir(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "notify", "outbox")
+}
+
+// FailedDir returns the directory of notifications that gave up.
+func FailedDir(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "notify", "failed")
+}
+
+// LoadConfig returns the town's notify config, or nil if none is set.
+func LoadConfig(townRoot string) (*config.NotifyConfig, error) {
+	cfg, err := config.LoadMayorConfig(filepath.Join(townRoot, "mayor", "config.json"))
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cfg.Notify, nil
+}
+
+// QueueMail queues notifications for a mail message if any route selects
+// its priority. The daemon delivers them. Called by the mail router for
+// every message sent; a town without notify config does nothing.
+func QueueMail(townRoot, from, to, subject, body, priority string) error {
+	cfg, err := LoadConfig(townRoot)
+	if err != nil || cfg == nil || len(cfg.Routes) == 0 {
+		return err
+	}
+	_, err = New(townRoot, cfg).Route(FromMail(from, to, subject, body, priority))
+	return err
+}
+
+// Notifier routes notifications to channels and delivers the outbox.
+type Notifier struct {
+	townRoot string
+	logf     func(format string, args ...interface{})
+	now      func() time.Time
+
+	mu   sync.Mutex
+	cfg  *config.NotifyConfig
+	sent map[string]time.Time // route index + notification key → last sent, for throttling
+}
+
+// New creates a notifier for a town with the given config.
+func New(townRoot string, cfg *config.NotifyConfig) *Notifier {
+	return &Notifier{
+		townRoot: townRoot,
+		cfg:      cfg,
+		logf:     func(string, ...interface{}) {},
+		now:      time.Now,
+		sent:     make(map[string]time.Time),
+	}
+}
+
+// SetLogger sets where delivery failures are reported.
+func (n *Notifier) SetLogger(logf func(format string, args ...interface{})) {
+	n.logf = logf
+}
+
+func (n *Notifier) config() *config.NotifyConfig {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.cfg
+}
+
+// reload re-reads mayor/config.json so edits apply without restarting the
+// daemon. A broken config keeps the previous one.
+func (n *Notifier) reload() {
+	cfg, err := LoadConfig(n.townRoot)
+	if err != nil {
+		n.logf("notify: keeping previous config: %v", err)
+		return
+	}
+	n.mu.Lock()
+	n.cfg = cfg
+	n.mu.Unlock()
+}
+
+// Route queues a notification for every channel of every matching route,
+// skipping routes that sent the same notification within their throttle
+// window. It returns the queued jobs.
+func (n *Notifier) Route(note *Notification) ([]*Job, error) {
+	cfg := n.config()
+	if cfg == nil {
+		return nil, nil
+	}
+
+	now := n.now()
+	channels := make(map[string]bool)
+	n.mu.Lock()
+	for i, route := range cfg.Routes {
+		if !routeMatches(&route, note) {
+			continue
+		}
+		if route.Throttle != "" {
+			window, _ := time.ParseDuration(route.Throttle)
+			key := fmt.Sprintf("%d|%s", i, note.key())
+			if last, ok := n.sent[key]; ok && now.Sub(last) < window {
+				continue
+			}
+			n.sent[key] = now
+		}
+		for _, name := range route.Channels {
+			channels[name] = true
+		}
+	}
+	n.mu.Unlock()
+
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jobs []*Job
+	for _, name := range names {
+		job, err := enqueue(n.townRoot, name, note, now)
+		if err != nil {
+			return jobs, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// routeAliases lets a route for an event type also carry its follow-ups:
+// an escalation that misses its SLA is re-sent wherever escalations go.
+var routeAliases = map[string]string{
+	events.TypeEscalationBreached: events.TypeEscalationSent,
+}
+
+// routeMatches reports whether a route selects a notification.
+func routeMatches(route *config.NotifyRoute, note *Notification) bool {
+	if len(route.Rigs) > 0 && !contains(route.Rigs, note.Rig) {
+		return false
+	}
+	switch note.Source {
+	case SourceEvent:
+		if alias, ok := routeAliases[note.Type]; ok && contains(route.Events, alias) {
+			return true
+		}
+		return contains(route.Events, "*") || contains(route.Events, note.Type)
+	case SourceMail:
+		priority, _ := note.Fields["priority"].(string)
+		return contains(route.MailPriority, priority)
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue writes a job to the outbox, due immediately.
+func enqueue(townRoot, channel string, note *Notification, now time.Time) (*Job, error) {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	job := &Job{
+		ID:           fmt.Sprintf("%s-%s", now.UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix)),
+		Channel:      channel,
+		Notification: note,
+		NextAttempt:  now,
+		CreatedAt:    now,
+	}
+	dir := OutboxDir(townRoot)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating outbox: %w", err)
+	}
+	if err := util.AtomicWriteJSON(filepath.Join(dir, job.ID+".json"), job); err != nil {
+		return nil, fmt.Errorf("queueing notification: %w", err)
+	}
+	return job, nil
+}
+
+// ListJobs returns the jobs in an outbox or failed directory, oldest first.
+func ListJobs(dir string) ([]*Job, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var jobs []*Job
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name())) //nolint:gosec // G304: path is within the outbox
+		if err != nil {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.Notification == nil {
+			continue
+		}
+		jobs = append(jobs, &job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs, nil
+}
+
+// DrainResult summarizes one pass over the outbox.
+type DrainResult struct {
+	Delivered int
+	Retrying  int
+	Failed    int
+}
+
+// Drain attempts every due job in the outbox. Delivered jobs are removed,
+// failed ones are rescheduled with exponential backoff, and jobs that run
+// out of attempts (or fail permanently) move to the failed directory.
+func (n *Notifier) Drain(ctx context.Context) DrainResult {
+	var result DrainResult
+	cfg := n.config()
+	jobs, err := ListJobs(OutboxDir(n.townRoot))
+	if err != nil {
+		n.logf("notify: reading outbox: %v", err)
+		return result
+	}
+
+	maxAttempts, backoff, maxBackoff := retryPolicy(cfg)
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		if job.NextAttempt.After(n.now()) {
+			continue
+		}
+
+		var ch *config.NotifyChannel
+		if cfg != nil {
+			ch = cfg.Channels[job.Channel]
+		}
+		if ch == nil {
+			err = &permanentError{fmt.Errorf("channel %q is not configured", job.Channel)}
+		} else {
+			err = Deliver(ctx, ch, job.Notification)
+		}
+		job.Attempts++
+
+		switch {
+		case err == nil:
+			_ = os.Remove(n.jobPath(job))
+			result.Delivered++
+
+		case IsPermanent(err) || job.Attempts >= maxAttempts:
+			job.LastError = err.Error()
+			n.fail(job)
+			result.Failed++
+			n.logf("notify: giving up on %s to %s after %d attempt(s): %v", job.ID, job.Channel, job.Attempts, err)
+
+		default:
+			job.LastError = err.Error()
+			job.NextAttempt = n.now().Add(Backoff(job.Attempts, backoff, maxBackoff))
+			if werr := util.AtomicWriteJSON(n.jobPath(job), job); werr != nil {
+				n.logf("notify: rescheduling %s: %v", job.ID, werr)
+			}
+			result.Retrying++
+		}
+	}
+	return result
+}
+
+func (n *Notifier) jobPath(job *Job) string {
+	return filepath.Join(OutboxDir(n.townRoot), job.ID+".json")
+}
+
+// fail moves a job from the outbox to the failed directory.
+func (n *Notifier) fail(job *Job) {
+	dir := FailedDir(n.townRoot)
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		if err := util.AtomicWriteJSON(filepath.Join(dir, job.ID+".json"), job); err != nil {
+			n.logf("notify: recording failed %s: %v", job.ID, err)
+		}
+	}
+	_ = os.Remove(n.jobPath(job))
+}
+
+// retryPolicy returns the configured retry settings with defaults applied.
+func retryPolicy(cfg *config.NotifyConfig) (maxAttempts int, backoff, maxBackoff time.Duration) {
+	maxAttempts, backoff, maxBackoff = DefaultMaxAttempts, DefaultBackoff, DefaultMaxBackoff
+	if cfg == nil || cfg.Retry == nil {
+		return
+	}
+	if cfg.Retry.MaxAttempts > 0 {
+		maxAttempts = cfg.Retry.MaxAttempts
+	}
+	if d, err := time.ParseDuration(cfg.Retry.Backoff); err == nil && d > 0 {
+		backoff = d
+	}
+	if d, err := time.ParseDuration(cfg.Retry.MaxBackoff); err == nil && d > 0 {
+		maxBackoff = d
+	}
+	return
+}
+
+// Backoff returns the delay after the given number of failed attempts:
+// base, 2×base, 4×base, ... capped at max.
+func Backoff(attempts int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempts && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d
+}
+
+// Run routes bus events and drains the outbox until ctx is cancelled.
+// The daemon runs it alongside the event bus; bus may be nil, in which
+// case only queued mail notifications are delivered.
+func (n *Notifier) Run(ctx context.Context, bus *eventbus.Bus) {
+	var sub *eventbus.Subscription
+	var messages <-chan eventbus.Message
+	if bus != nil {
+		if s, err := bus.Subscribe(eventbus.SubscribeOptions{From: eventbus.FromLive}); err == nil {
+			sub, messages = s, s.Messages()
+		} else {
+			n.logf("notify: subscribing to event bus: %v", err)
+		}
+	}
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	n.Drain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			n.reload()
+			n.Drain(ctx)
+
+		case msg, ok := <-messages:
+			if !ok {
+				// Catch up after lagging; stop listening if the bus closed.
+				messages = nil
+				var lagged *eventbus.LaggedError
+				if errors.As(sub.Err(), &lagged) {
+					if next, err := bus.Subscribe(eventbus.SubscribeOptions{From: lagged.Resume}); err == nil {
+						sub, messages = next, next.Messages()
+					}
+				}
+				continue
+			}
+			jobs, err := n.Route(FromEvent(msg.Event))
+			if err != nil {
+				n.logf("notify: %v", err)
+			}
+			if len(jobs) > 0 {
+				n.Drain(ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+func TestFromEvent(t *testing.T) {
+	payload := events.EscalationPayload("", "gastown/polecats/nux", "overseer", "Migration failed")
+	payload["severity"] = "CRITICAL"
+	n := FromEvent(events.Event{Type: events.TypeEscalationSent, Actor: "gastown/polecats/nux", Payload: payload})
+	if n.Severity != SeverityCritical || n.Rig != "gastown" || !strings.Contains(n.Title, "Migration failed") {
+		t.Errorf("escalation = %+v", n)
+	}
+
+	n = FromEvent(events.Event{Type: events.TypePolecatCrashed, Actor: "daemon",
+		Payload: events.CrashPayload("gastown", "nux", "gt-1", "worktree missing")})
+	if n.Severity != SeverityHigh || n.Title != "Polecat gastown/nux crashed" || !strings.Contains(n.Body, "worktree missing") {
+		t.Errorf("crash = %+v", n)
+	}
+
+	// A missed SLA re-sends on routes for escalation_sent.
+	breached := events.EscalationBreachedPayload("esc-1", "gastown", "CRITICAL", "Migration failed", "resolve", "")
+	n = FromEvent(events.Event{Type: events.TypeEscalationBreached, Actor: "daemon", Payload: breached})
+	if n.Severity != SeverityCritical || n.Rig != "gastown" || !strings.Contains(n.Title, "unresolved") {
+		t.Errorf("breach = %+v", n)
+	}
+	if !routeMatches(&config.NotifyRoute{Events: []string{events.TypeEscalationSent}}, n) {
+		t.Error("escalation_sent route should carry re-escalations")
+	}
+}
+
+func TestRoute_MatchesAndThrottles(t *testing.T) {
+	town := t.TempDir()
+	cfg := &config.NotifyConfig{
+		Channels: map[string]*config.NotifyChannel{
+			"ops":   {Type: config.NotifySlack, URL: "http://unused"},
+			"pager": {Type: config.NotifyWebhook, URL: "http://unused"},
+		},
+		Routes: []config.NotifyRoute{
+			{Events: []string{events.TypeMergeFailed}, Channels: []string{"ops"}},
+			{Events: []string{events.TypeConvoyStranded}, Channels: []string{"ops"}, Throttle: "1h"},
+			{MailPriority: []string{"urgent"}, Channels: []string{"pager", "ops"}},
+			{Events: []string{"*"}, Rigs: []string{"beacon"}, Channels: []string{"pager"}},
+		},
+	}
+	n := New(town, cfg)
+
+	jobs, _ := n.Route(FromEvent(events.Event{Type: events.TypeMergeFailed, Actor: "gastown/refinery"}))
+	if len(jobs) != 1 || jobs[0].Channel != "ops" {
+		t.Fatalf("merge_failed jobs = %+v", jobs)
+	}
+
+	stranded := events.Event{Type: events.TypeConvoyStranded, Payload: events.StrandedPayload("hq-cv1", "Auth", 2)}
+	if jobs, _ := n.Route(FromEvent(stranded)); len(jobs) != 1 {
+		t.Fatalf("first stranded report should notify, got %d jobs", len(jobs))
+	}
+	if jobs, _ := n.Route(FromEvent(stranded)); len(jobs) != 0 {
+		t.Errorf("repeat within throttle should be suppressed, got %d jobs", len(jobs))
+	}
+
+	if jobs, _ := n.Route(FromMail("mayor/", "overseer", "Help", "", "normal")); len(jobs) != 0 {
+		t.Errorf("normal mail should not notify, got %d jobs", len(jobs))
+	}
+	if jobs, _ := n.Route(FromMail("mayor/", "overseer", "Help", "", "urgent")); len(jobs) != 2 {
+		t.Errorf("urgent mail should reach pager and ops, got %d jobs", len(jobs))
+	}
+
+	jobs, _ = n.Route(FromEvent(events.Event{Type: events.TypeSling, Actor: "beacon/crew/max"}))
+	if len(jobs) != 1 || jobs[0].Channel != "pager" {
+		t.Errorf("rig-scoped route: %+v", jobs)
+	}
+
+	queued, _ := ListJobs(OutboxDir(town))
+	if len(queued) != 5 {
+		t.Errorf("outbox has %d jobs, want 5", len(queued))
+	}
+}
+
+func TestDrain_RetriesWithBackoffThenDelivers(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("X-Token") != "s3cret" {
+			t.Errorf("X-Token = %q", r.Header.Get("X-Token"))
+		}
+		data, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &got)
+	}))
+	defer srv.Close()
+
+	t.Setenv("NOTIFY_TEST_TOKEN", "s3cret")
+	town := t.TempDir()
+	cfg := &config.NotifyConfig{
+		Channels: map[string]*config.NotifyChannel{"ops": {Type: config.NotifySlack, URL: srv.URL,
+			Headers: map[string]string{"X-Token": "${NOTIFY_TEST_TOKEN}"}}},
+		Routes: []config.NotifyRoute{{Events: []string{events.TypeMergeFailed}, Channels: []string{"ops"}}},
+		Retry:  &config.NotifyRetry{Backoff: "1m"},
+	}
+	n := New(town, cfg)
+	now := time.Now()
+	n.now = func() time.Time { return now }
+
+	_, _ = n.Route(FromEvent(events.Event{Type: events.TypeMergeFailed,
+		Payload: events.MergePayload("mr-1", "nux", "polecat/nux", "tests failed")}))
+
+	ctx := context.Background()
+	if r := n.Drain(ctx); r.Retrying != 1 {
+		t.Fatalf("first drain = %+v, want one retry", r)
+	}
+	if r := n.Drain(ctx); r.Retrying != 0 || r.Delivered != 0 {
+		t.Fatalf("job should wait out its backoff, got %+v", r)
+	}
+
+	now = now.Add(time.Minute)
+	if r := n.Drain(ctx); r.Delivered != 1 {
+		t.Fatalf("drain after backoff = %+v, want delivered", r)
+	}
+	if !strings.Contains(got["text"], "Merge failed: polecat/nux") || !strings.Contains(got["text"], "tests failed") {
+		t.Errorf("slack text = %q", got["text"])
+	}
+	if jobs, _ := ListJobs(OutboxDir(town)); len(jobs) != 0 {
+		t.Errorf("outbox should be empty, has %d", len(jobs))
+	}
+}
+
+func TestDrain_PermanentFailureMovesToFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	town := t.TempDir()
+	cfg := &config.NotifyConfig{
+		Channels: map[string]*config.NotifyChannel{"hook": {Type: config.NotifyWebhook, URL: srv.URL,
+			Template: `{"summary": {{json .Title}}}`}},
+		Routes: []config.NotifyRoute{{Events: []string{"*"}, Channels: []string{"hook"}}},
+	}
+	n := New(town, cfg)
+	_, _ = n.Route(FromEvent(events.Event{Type: events.TypeMergeFailed}))
+
+	if r := n.Drain(context.Background()); r.Failed != 1 {
+		t.Fatalf("drain = %+v, want failed", r)
+	}
+	failed, _ := ListJobs(FailedDir(town))
+	if len(failed) != 1 || !strings.Contains(failed[0].LastError, "400") {
+		t.Errorf("failed = %+v", failed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, w := range want {
+		if got := Backoff(i+1, base, max); got != w {
+			t.Errorf("Backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// smtpStandIn is a minimal SMTP server that records one message.
+func smtpStandIn(t *testing.T) (host string, port int, received <-chan string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	ch := make(chan string, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+		reply("220 localhost ESMTP stand-in")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					ch <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port, ch
+}
+
+func TestDeliver_SMTP(t *testing.T) {
+	host, port, received := smtpStandIn(t)
+	ch := &config.NotifyChannel{
+		Type: config.NotifySMTP,
+		SMTP: &config.SMTPConfig{Host: host, Port: port, From: "gt@example.com", To: []string{"oncall@example.com"}},
+	}
+	n := FromMail("gastown/witness", "overseer", "Polecat stuck", "nux idle for 2h", "urgent")
+	if err := Deliver(context.Background(), ch, n); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		for _, want := range []string{"Subject: [gt] Polecat stuck", "To: oncall@example.com", "nux idle for 2h", "Severity: critical"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("message missing %q:\n%s", want, msg)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received on port " + strconv.Itoa(port))
+	}
+}
@@ -227,6 +227,12 @@ func (m *Manager) AddWithOptions(name string, opts AddOptions) (*Polecat, error)
 		return nil, fmt.Errorf("creating worktree: %w", err)
 	}
 
+	// Remote rigs run the polecat on their machine: mirror the worktree there
+	if err := m.rig.AddRemoteWorktree(polecatPath, branchName); err != nil {
+		_ = repoGit.WorktreeRemove(polecatPath, true) // best-effort rollback
+		return nil, fmt.Errorf("creating remote worktree: %w", err)
+	}
+
 	// NOTE: We intentionally do NOT write to CLAUDE.md here.
 	// Gas Town context is injected ephemerally via SessionStart hook (gt prime).
 	// Writing to CLAUDE.md would overwrite project instructions and could leak
@@ -343,6 +349,11 @@ func (m *Manager) RemoveWithOptions(name string, force, nuclear bool) error {
 	// Prune any stale worktree entries (non-fatal: cleanup only)
 	_ = repoGit.WorktreePrune()
 
+	// Remove the mirrored worktree on a remote rig's machine (non-fatal)
+	if err := m.rig.RemoveRemoteWorktree(polecatPath); err != nil {
+		fmt.Printf("Warning: could not remove remote worktree: %v\n", err)
+	}
+
 	// Release name back to pool if it's a pooled name (non-fatal: state file update)
 	m.namePool.Release(name)
 	_ = m.namePool.Save()
@@ -0,0 +1,179 @@
+// Package envelope encodes and decodes the versioned, machine-readable
+// envelope carried in protocol mail bodies.
+//
+// An envelope is a fenced JSON block appended to the human-readable
+// "Key: value" text of a message:
+//
+//	Branch: polecat/nux
+//	Issue: gt-abc
+//
+//	```gt-protocol
+//	{"v":1,"type":"MERGED","payload":{"branch":"polecat/nux",...}}
+//	```
+//
+// Readers prefer the envelope and fall back to the legacy text for bodies
+// without one (or with a version they do not understand).
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Version is the envelope version written by this build.
+// Bump it when a payload changes incompatibly; readers that see a newer
+// version fall back to the legacy text fields.
+const Version = 1
+
+// envelopeFence opens the fenced JSON block carrying the envelope. The
+// block follows the human-readable "Key: value" text so agents reading the
+// mail still see the familiar format, and older parsers keep working.
+const envelopeFence = "```gt-protocol"
+
+var (
+	// ErrNoEnvelope is returned when a body has no envelope (legacy format).
+	ErrNoEnvelope = errors.New("no protocol envelope")
+
+	// ErrUnsupportedVersion is returned for envelopes newer than Version.
+	ErrUnsupportedVersion = errors.New("unsupported protocol envelope version")
+)
+
+// Envelope is the versioned, machine-readable form of a protocol message.
+type Envelope struct {
+	// Version is the envelope schema version.
+	Version int `json:"v"`
+
+	// Type is the message type (e.g., "MERGE_FAILED", "POLECAT_DONE").
+	Type string `json:"type"`
+
+	// Payload is the type-specific payload.
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Encode appends an envelope carrying payload to the human-readable
+// text of a message body.
+func Encode(text, msgType string, payload interface{}) string {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		// Payloads are plain structs; fall back to the legacy text alone
+		return text
+	}
+	env, _ := json.Marshal(Envelope{Version: Version, Type: msgType, Payload: data})
+
+	var sb strings.Builder
+	sb.WriteString(strings.TrimRight(text, "\n"))
+	sb.WriteString("\n\n")
+	sb.WriteString(envelopeFence)
+	sb.WriteString("\n")
+	sb.Write(env)
+	sb.WriteString("\n```\n")
+	return sb.String()
+}
+
+// Split separates a body into its human-readable text and envelope.
+// A body without an envelope returns (body, nil, nil).
+func Split(body string) (string, *Envelope, error) {
+	start := strings.LastIndex(body, envelopeFence+"\n")
+	if start < 0 || (start > 0 && body[start-1] != '\n') {
+		return body, nil, nil
+	}
+	text := strings.TrimRight(body[:start], "\n") + "\n"
+
+	rest := body[start+len(envelopeFence)+1:]
+	end := strings.Index(rest, "\n```")
+	if end < 0 {
+		return text, nil, fmt.Errorf("unterminated %s block", envelopeFence)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal([]byte(rest[:end]), &env); err != nil {
+		return text, nil, fmt.Errorf("parsing envelope: %w", err)
+	}
+	return text, &env, nil
+}
+
+// Strip returns the human-readable text of a body.
+func Strip(body string) string {
+	text, _, _ := Split(body)
+	return text
+}
+
+// Decode decodes the envelope payload of a body into v, checking that
+// the envelope is for msgType. Returns ErrNoEnvelope for legacy bodies and
+// ErrUnsupportedVersion for envelopes this build cannot read; callers fall
+// back to parsing the text in both cases.
+func Decode(body, msgType string, v interface{}) error {
+	_, env, err := Split(body)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		return ErrNoEnvelope
+	}
+	if env.Version < 1 || env.Version > Version {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, env.Version)
+	}
+	if env.Type != msgType {
+		return fmt.Errorf("envelope type %s does not match %s", env.Type, msgType)
+	}
+	if err := json.Unmarshal(env.Payload, v); err != nil {
+		return fmt.Errorf("parsing %s payload: %w", msgType, err)
+	}
+	return nil
+}
+
+// Validation is the result of checking a protocol message.
+type Validation struct {
+	// Type is the protocol message type, from the subject.
+	Type string `json:"type"`
+
+	// Version is the envelope version, or 0 for legacy text bodies.
+	Version int `json:"version"`
+
+	// Problems lists everything wrong with the message.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Legacy reports whether the message uses the legacy text format.
+func (v *Validation) Legacy() bool {
+	return v.Version == 0
+}
+
+// Valid reports whether the message has no problems.
+func (v *Validation) Valid() bool {
+	return len(v.Problems) == 0
+}
+
+// Problemf records a problem.
+func (v *Validation) Problemf(format string, args ...interface{}) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
+
+// Require records a problem if a required field is empty.
+func (v *Validation) Require(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.Problemf("missing required field %q", field)
+	}
+}
+
+// Check starts a Validation for msgType, recording a problem if
+// the body's envelope is unreadable, too new, or for a different type.
+// Legacy bodies are not a problem; the caller checks their fields.
+func Check(msgType, body string, payload interface{}) *Validation {
+	v := &Validation{Type: msgType}
+	_, env, err := Split(body)
+	if err != nil {
+		v.Problemf("%v", err)
+		return v
+	}
+	if env == nil {
+		return v
+	}
+	v.Version = env.Version
+	if err := Decode(body, msgType, payload); err != nil {
+		v.Problemf("%v", err)
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package envelope
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type payload struct {
+	Branch string `json:"branch"`
+	Error  string `json:"error"`
+}
+
+func TestEncodeDecode(t *testing.T) {
+	p := payload{Branch: "polecat/nux", Error: "line one\nError: line two\n```"}
+	body := Encode("Branch: polecat/nux\nError: line one\n", "MERGE_FAILED", p)
+
+	if !strings.HasPrefix(body, "Branch: polecat/nux\nError: line one\n\n```gt-protocol\n") {
+		t.Errorf("legacy text should come first:\n%s", body)
+	}
+
+	var got payload
+	if err := Decode(body, "MERGE_FAILED", &got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got != p {
+		t.Errorf("Decode = %+v, want %+v", got, p)
+	}
+	if text := Strip(body); text != "Branch: polecat/nux\nError: line one\n" {
+		t.Errorf("Strip = %q", text)
+	}
+}
+
+func TestDecode_Fallbacks(t *testing.T) {
+	var p payload
+
+	if err := Decode("Branch: polecat/nux\n", "MERGED", &p); err != ErrNoEnvelope {
+		t.Errorf("legacy body: got %v, want ErrNoEnvelope", err)
+	}
+
+	future := "Branch: x\n\n```gt-protocol\n{\"v\":99,\"type\":\"MERGED\",\"payload\":{}}\n```\n"
+	if err := Decode(future, "MERGED", &p); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("future version: got %v, want ErrUnsupportedVersion", err)
+	}
+
+	body := Encode("", "MERGED", payload{Branch: "b"})
+	if err := Decode(body, "MERGE_FAILED", &p); err == nil {
+		t.Error("expected error decoding envelope of a different type")
+	}
+
+	broken := "Branch: x\n\n```gt-protocol\n{\"v\":1,\n"
+	if err := Decode(broken, "MERGED", &p); err == nil || errors.Is(err, ErrNoEnvelope) {
+		t.Errorf("unterminated envelope: got %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var p payload
+	if v := Check("MERGED", "Branch: x\n", &p); !v.Valid() || !v.Legacy() {
+		t.Errorf("legacy body: %+v", v)
+	}
+
+	v := Check("MERGED", Encode("", "MERGE_READY", payload{}), &p)
+	if v.Valid() || v.Version != Version {
+		t.Errorf("mismatched type: %+v", v)
+	}
+
+	v.Require("branch", " ")
+	if len(v.Problems) != 2 || !strings.Contains(v.Problems[1], `"branch"`) {
+		t.Errorf("Problems = %v", v.Problems)
+	}
+}
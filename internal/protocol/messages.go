@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/protocol/envelope"
 )
 
 // NewMergeReadyMessage creates a MERGE_READY protocol message.
@@ -36,6 +37,11 @@ func NewMergeReadyMessage(rig, polecat, branch, issue string) *mail.Message {
 
 // formatMergeReadyBody formats the body of a MERGE_READY message.
 func formatMergeReadyBody(p MergeReadyPayload) string {
+	return envelope.Encode(formatMergeReadyText(p), string(TypeMergeReady), p)
+}
+
+// formatMergeReadyText formats the human-readable fields of MERGE_READY.
+func formatMergeReadyText(p MergeReadyPayload) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Branch: %s\n", p.Branch))
 	sb.WriteString(fmt.Sprintf("Issue: %s\n", p.Issue))
@@ -76,6 +82,11 @@ func NewMergedMessage(rig, polecat, branch, issue, targetBranch, mergeCommit str
 
 // formatMergedBody formats the body of a MERGED message.
 func formatMergedBody(p MergedPayload) string {
+	return envelope.Encode(formatMergedText(p), string(TypeMerged), p)
+}
+
+// formatMergedText formats the human-readable fields of MERGED.
+func formatMergedText(p MergedPayload) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Branch: %s\n", p.Branch))
 	sb.WriteString(fmt.Sprintf("Issue: %s\n", p.Issue))
@@ -92,6 +103,12 @@ func formatMergedBody(p MergedPayload) string {
 // NewMergeFailedMessage creates a MERGE_FAILED protocol message.
 // Sent by Refinery to Witness when merge fails (tests, build, etc.).
 func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureType, errorMsg string) *mail.Message {
+	return NewTestFailureMessage(rig, polecat, branch, issue, targetBranch, failureType, errorMsg, nil, "")
+}
+
+// NewTestFailureMessage creates a MERGE_FAILED protocol message that names
+// the failing tests and carries their trimmed log.
+func NewTestFailureMessage(rig, polecat, branch, issue, targetBranch, failureType, errorMsg string, failedTests []string, testLog string) *mail.Message {
 	payload := MergeFailedPayload{
 		Branch:       branch,
 		Issue:        issue,
@@ -101,6 +118,8 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 		FailureType:  failureType,
 		Error:        errorMsg,
 		TargetBranch: targetBranch,
+		FailedTests:  failedTests,
+		TestLog:      testLog,
 	}
 
 	body := formatMergeFailedBody(payload)
@@ -119,6 +138,11 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 
 // formatMergeFailedBody formats the body of a MERGE_FAILED message.
 func formatMergeFailedBody(p MergeFailedPayload) string {
+	return envelope.Encode(formatMergeFailedText(p), string(TypeMergeFailed), p)
+}
+
+// formatMergeFailedText formats the human-readable fields of MERGE_FAILED.
+func formatMergeFailedText(p MergeFailedPayload) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Branch: %s\n", p.Branch))
 	sb.WriteString(fmt.Sprintf("Issue: %s\n", p.Issue))
@@ -128,9 +152,21 @@ func formatMergeFailedBody(p MergeFailedPayload) string {
 	sb.WriteString(fmt.Sprintf("Failed-At: %s\n", p.FailedAt.Format(time.RFC3339)))
 	sb.WriteString(fmt.Sprintf("Failure-Type: %s\n", p.FailureType))
 	sb.WriteString(fmt.Sprintf("Error: %s\n", p.Error))
+	if len(p.FailedTests) > 0 {
+		sb.WriteString(fmt.Sprintf("Failed-Tests: %s\n", strings.Join(p.FailedTests, ", ")))
+	}
+	if p.TestLog != "" {
+		sb.WriteString("\n" + testLogHeader + "\n")
+		sb.WriteString(p.TestLog)
+		sb.WriteString("\n")
+	}
 	return sb.String()
 }
 
+// testLogHeader introduces the multi-line test log section of MERGE_FAILED.
+// Everything after it is log text, so it must come after all fields.
+const testLogHeader = "Test-Log:"
+
 // NewReworkRequestMessage creates a REWORK_REQUEST protocol message.
 // Sent by Refinery to Witness when a branch needs rebasing due to conflicts.
 func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, conflictFiles []string) *mail.Message {
@@ -159,8 +195,40 @@ func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, c
 	return msg
 }
 
+// NewReviewReworkMessage creates a REWORK_REQUEST protocol message for a pull
+// request whose reviewers requested changes. Sent by Refinery in PR mode.
+func NewReviewReworkMessage(rig, polecat, branch, issue, targetBranch, prURL string, comments []ReviewComment) *mail.Message {
+	payload := ReworkRequestPayload{
+		Branch:         branch,
+		Issue:          issue,
+		Polecat:        polecat,
+		Rig:            rig,
+		RequestedAt:    time.Now(),
+		TargetBranch:   targetBranch,
+		PRURL:          prURL,
+		ReviewComments: comments,
+		Instructions:   formatReviewInstructions(branch, comments),
+	}
+
+	msg := mail.NewMessage(
+		fmt.Sprintf("%s/refinery", rig),
+		fmt.Sprintf("%s/witness", rig),
+		fmt.Sprintf("REWORK_REQUEST %s", polecat),
+		formatReworkRequestBody(payload),
+	)
+	msg.Priority = mail.PriorityHigh
+	msg.Type = mail.TypeTask
+
+	return msg
+}
+
 // formatReworkRequestBody formats the body of a REWORK_REQUEST message.
 func formatReworkRequestBody(p ReworkRequestPayload) string {
+	return envelope.Encode(formatReworkRequestText(p), string(TypeReworkRequest), p)
+}
+
+// formatReworkRequestText formats the human-readable fields of REWORK_REQUEST.
+func formatReworkRequestText(p ReworkRequestPayload) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Branch: %s\n", p.Branch))
 	sb.WriteString(fmt.Sprintf("Issue: %s\n", p.Issue))
@@ -172,6 +240,9 @@ func formatReworkRequestBody(p ReworkRequestPayload) string {
 	if len(p.ConflictFiles) > 0 {
 		sb.WriteString(fmt.Sprintf("Conflict-Files: %s\n", strings.Join(p.ConflictFiles, ", ")))
 	}
+	if p.PRURL != "" {
+		sb.WriteString(fmt.Sprintf("PR: %s\n", p.PRURL))
+	}
 
 	sb.WriteString("\n")
 	sb.WriteString(p.Instructions)
@@ -191,8 +262,41 @@ func formatRebaseInstructions(targetBranch string) string {
 The Refinery will retry the merge after rebase is complete.`, targetBranch, targetBranch)
 }
 
+// formatReviewInstructions lists the review comments to address.
+func formatReviewInstructions(branch string, comments []ReviewComment) string {
+	var sb strings.Builder
+	sb.WriteString("Reviewers requested changes:\n\n")
+	for _, c := range comments {
+		sb.WriteString("  - ")
+		if c.Path != "" {
+			sb.WriteString(c.Path)
+			if c.Line > 0 {
+				sb.WriteString(fmt.Sprintf(":%d", c.Line))
+			}
+			sb.WriteString(": ")
+		}
+		body := strings.ReplaceAll(strings.TrimSpace(c.Body), "\n", "\n    ")
+		if c.Author != "" {
+			body += fmt.Sprintf(" (@%s)", c.Author)
+		}
+		sb.WriteString(body)
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf(`
+Address the comments and push to %s; the pull request updates
+automatically. The Refinery merges once reviewers approve and checks pass.`, branch))
+	return sb.String()
+}
+
 // ParseMergeReadyPayload parses a MERGE_READY message body into a payload.
+// The envelope is preferred; legacy text bodies are parsed field by field.
 func ParseMergeReadyPayload(body string) *MergeReadyPayload {
+	var p MergeReadyPayload
+	if envelope.Decode(body, string(TypeMergeReady), &p) == nil {
+		return &p
+	}
+	body = envelope.Strip(body)
+
 	return &MergeReadyPayload{
 		Branch:    parseField(body, "Branch"),
 		Issue:     parseField(body, "Issue"),
@@ -205,6 +309,12 @@ func ParseMergeReadyPayload(body string) *MergeReadyPayload {
 
 // ParseMergedPayload parses a MERGED message body into a payload.
 func ParseMergedPayload(body string) *MergedPayload {
+	var p MergedPayload
+	if envelope.Decode(body, string(TypeMerged), &p) == nil {
+		return &p
+	}
+	body = envelope.Strip(body)
+
 	payload := &MergedPayload{
 		Branch:       parseField(body, "Branch"),
 		Issue:        parseField(body, "Issue"),
@@ -226,6 +336,12 @@ func ParseMergedPayload(body string) *MergedPayload {
 
 // ParseMergeFailedPayload parses a MERGE_FAILED message body into a payload.
 func ParseMergeFailedPayload(body string) *MergeFailedPayload {
+	var p MergeFailedPayload
+	if envelope.Decode(body, string(TypeMergeFailed), &p) == nil {
+		return &p
+	}
+	body = envelope.Strip(body)
+
 	payload := &MergeFailedPayload{
 		Branch:       parseField(body, "Branch"),
 		Issue:        parseField(body, "Issue"),
@@ -236,6 +352,16 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 		Error:        parseField(body, "Error"),
 	}
 
+	// Fields come before the log, so parse them from the header only
+	header, log, hasLog := strings.Cut(body, "\n"+testLogHeader+"\n")
+	if hasLog {
+		payload.Error = parseField(header, "Error")
+		payload.TestLog = strings.TrimRight(log, "\n")
+	}
+	if tests := parseField(header, "Failed-Tests"); tests != "" {
+		payload.FailedTests = strings.Split(tests, ", ")
+	}
+
 	// Parse timestamp
 	if ts := parseField(body, "Failed-At"); ts != "" {
 		if t, err := time.Parse(time.RFC3339, ts); err == nil {
@@ -248,12 +374,19 @@ func ParseMergeFailedPayload(body string) *MergeFailedPayload {
 
 // ParseReworkRequestPayload parses a REWORK_REQUEST message body into a payload.
 func ParseReworkRequestPayload(body string) *ReworkRequestPayload {
+	var p ReworkRequestPayload
+	if envelope.Decode(body, string(TypeReworkRequest), &p) == nil {
+		return &p
+	}
+	body = envelope.Strip(body)
+
 	payload := &ReworkRequestPayload{
 		Branch:       parseField(body, "Branch"),
 		Issue:        parseField(body, "Issue"),
 		Polecat:      parseField(body, "Polecat"),
 		Rig:          parseField(body, "Rig"),
 		TargetBranch: parseField(body, "Target"),
+		PRURL:        parseField(body, "PR"),
 	}
 
 	// Parse timestamp
@@ -136,6 +136,87 @@ func TestNewMergeFailedMessage(t *testing.T) {
 	}
 }
 
+func TestNewTestFailureMessage_RoundTrip(t *testing.T) {
+	log := "--- FAIL: TestBad\n    bad_test.go:9: Error: want 1"
+	msg := NewTestFailureMessage("gastown", "nux", "polecat/nux", "gt-abc", "main", "tests",
+		"2 tests failed", []string{"pkg.TestBad", "pkg.TestWorse"}, log)
+
+	if !strings.Contains(msg.Body, "Failed-Tests: pkg.TestBad, pkg.TestWorse") {
+		t.Errorf("Body missing failed tests: %s", msg.Body)
+	}
+
+	payload := ParseMergeFailedPayload(msg.Body)
+	if payload.Error != "2 tests failed" {
+		t.Errorf("Error = %q (log lines must not shadow fields)", payload.Error)
+	}
+	if len(payload.FailedTests) != 2 || payload.FailedTests[1] != "pkg.TestWorse" {
+		t.Errorf("FailedTests = %v", payload.FailedTests)
+	}
+	if payload.TestLog != log {
+		t.Errorf("TestLog = %q, want %q", payload.TestLog, log)
+	}
+}
+
+func TestMergeFailed_MultiLineError(t *testing.T) {
+	errMsg := "build failed:\nmain.go:3: undefined: foo\nBranch: not-a-field"
+	msg := NewMergeFailedMessage("gastown", "nux", "polecat/nux", "gt-abc", "main", "build", errMsg)
+
+	payload := ParseMergeFailedPayload(msg.Body)
+	if payload.Error != errMsg {
+		t.Errorf("Error = %q, want %q", payload.Error, errMsg)
+	}
+	if payload.Branch != "polecat/nux" {
+		t.Errorf("Branch = %q, want %q", payload.Branch, "polecat/nux")
+	}
+
+	// Readers without envelope support still see the legacy fields
+	if got := parseField(msg.Body, "Failure-Type"); got != "build" {
+		t.Errorf("legacy Failure-Type = %q", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	ready := NewMergeReadyMessage("gastown", "nux", "polecat/nux", "gt-abc")
+	legacyText := "Branch: polecat/nux\nIssue: gt-abc\nPolecat: nux\nRig: gastown\n"
+	tests := []struct {
+		name     string
+		subject  string
+		body     string
+		valid    bool
+		problem  string
+		isLegacy bool
+	}{
+		{"envelope", ready.Subject, ready.Body, true, "", false},
+		{"legacy", "MERGE_READY nux", legacyText, true, "", true},
+		{"legacy missing field", "MERGE_READY nux", "Branch: polecat/nux\n", false, `"rig"`, true},
+		{"subject mismatch", "MERGE_READY toast", ready.Body, false, "subject names polecat toast", false},
+		{"type mismatch", "MERGED nux", ready.Body, false, "does not match", false},
+		{"future version", "MERGE_READY nux",
+			legacyText + "\n```gt-protocol\n{\"v\":2,\"type\":\"MERGE_READY\",\"payload\":{}}\n```\n", false, "unsupported", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validate(tt.subject, tt.body)
+			if v == nil {
+				t.Fatal("Validate returned nil for protocol message")
+			}
+			if v.Valid() != tt.valid {
+				t.Errorf("Valid = %v, problems %v", v.Valid(), v.Problems)
+			}
+			if tt.problem != "" && !strings.Contains(strings.Join(v.Problems, "; "), tt.problem) {
+				t.Errorf("Problems = %v, want one containing %q", v.Problems, tt.problem)
+			}
+			if tt.valid && v.Legacy() != tt.isLegacy {
+				t.Errorf("Legacy = %v, want %v", v.Legacy(), tt.isLegacy)
+			}
+		})
+	}
+
+	if Validate("Hello", "Branch: x") != nil {
+		t.Error("expected nil for non-protocol mail")
+	}
+}
+
 func TestNewReworkRequestMessage(t *testing.T) {
 	conflicts := []string{"file1.go", "file2.go"}
 	msg := NewReworkRequestMessage("gastown", "nux", "polecat/nux/gt-abc", "gt-abc", "main", conflicts)
@@ -151,6 +232,30 @@ func TestNewReworkRequestMessage(t *testing.T) {
 	}
 }
 
+func TestNewReviewReworkMessage_RoundTrip(t *testing.T) {
+	comments := []ReviewComment{
+		{Author: "alice", Path: "widget.go", Line: 12, Body: "handle nil"},
+		{Author: "bob", Body: "please add a test"},
+	}
+	msg := NewReviewReworkMessage("gastown", "nux", "polecat/nux", "gt-abc", "main",
+		"https://github.com/o/r/pull/7", comments)
+
+	if msg.Subject != "REWORK_REQUEST nux" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "widget.go:12: handle nil (@alice)") {
+		t.Errorf("Body missing inline comment: %s", msg.Body)
+	}
+
+	p := ParseReworkRequestPayload(msg.Body)
+	if p.PRURL != "https://github.com/o/r/pull/7" || len(p.ReviewComments) != 2 {
+		t.Fatalf("parsed payload = %+v", p)
+	}
+	if v := Validate(msg.Subject, msg.Body); !v.Valid() {
+		t.Errorf("Validate: %v", v.Problems)
+	}
+}
+
 func TestParseMergeReadyPayload(t *testing.T) {
 	body := `Branch: polecat/nux/gt-abc
 Issue: gt-abc
@@ -8,6 +8,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // DefaultRefineryHandler provides the default implementation for Refinery protocol handlers.
@@ -75,6 +76,14 @@ func (h *DefaultRefineryHandler) HandleMergeReady(payload *MergeReadyPayload) er
 		CreatedAt:   time.Now(),
 	}
 
+	// Hold the MR for its convoy's coordinated landing, if it has one
+	if townRoot, err := workspace.Find(h.WorkDir); err == nil && townRoot != "" {
+		if group, err := mrqueue.NewLandingStore(townRoot).GroupFor(h.Rig, payload.Issue); err == nil && group != nil {
+			mr.LandingGroup = group.ID
+			_, _ = fmt.Fprintf(h.Output, "  Landing group: %s\n", group.ID)
+		}
+	}
+
 	// Add to queue
 	if err := h.Queue.Submit(mr); err != nil {
 		_, _ = fmt.Fprintf(h.Output, "[Refinery] Error adding to queue: %v\n", err)
@@ -128,6 +137,12 @@ type MergeOutcome struct {
 
 	// ConflictFiles lists files with conflicts (if Conflict is true).
 	ConflictFiles []string
+
+	// FailedTests names the failing tests (if FailureType is "tests").
+	FailedTests []string
+
+	// TestLog is the trimmed output of the failing tests.
+	TestLog string
 }
 
 // NotifyMergeOutcome sends the appropriate protocol message based on the outcome.
@@ -140,6 +155,12 @@ func (h *DefaultRefineryHandler) NotifyMergeOutcome(polecat, branch, issue, targ
 		return h.SendReworkRequest(polecat, branch, issue, targetBranch, outcome.ConflictFiles)
 	}
 
+	if len(outcome.FailedTests) > 0 || outcome.TestLog != "" {
+		msg := NewTestFailureMessage(h.Rig, polecat, branch, issue, targetBranch,
+			outcome.FailureType, outcome.Error, outcome.FailedTests, outcome.TestLog)
+		return h.Router.Send(msg)
+	}
+
 	return h.SendMergeFailed(polecat, branch, issue, targetBranch, outcome.FailureType, outcome.Error)
 }
 
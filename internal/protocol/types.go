@@ -135,6 +135,13 @@ type MergeFailedPayload struct {
 
 	// TargetBranch is the branch we tried to merge into.
 	TargetBranch string `json:"target_branch"`
+
+	// FailedTests names the failing tests, when the test output was parseable.
+	FailedTests []string `json:"failed_tests,omitempty"`
+
+	// TestLog is the trimmed output of the failing tests (or of the whole
+	// run if it could not be parsed).
+	TestLog string `json:"test_log,omitempty"`
 }
 
 // ReworkRequestPayload contains the data for a REWORK_REQUEST message.
@@ -163,6 +170,25 @@ type ReworkRequestPayload struct {
 
 	// Instructions provides specific rebase instructions.
 	Instructions string `json:"instructions,omitempty"`
+
+	// PRURL is the forge pull request, when the rework comes from review.
+	PRURL string `json:"pr_url,omitempty"`
+
+	// ReviewComments are the reviewer comments to address (PR mode).
+	ReviewComments []ReviewComment `json:"review_comments,omitempty"`
+}
+
+// ReviewComment is a forge review comment forwarded in REWORK_REQUEST.
+type ReviewComment struct {
+	// Author is the reviewer's forge username.
+	Author string `json:"author,omitempty"`
+
+	// Path and Line locate inline comments; empty for review summaries.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Body is the comment text.
+	Body string `json:"body"`
 }
 
 // IsProtocolMessage returns true if the subject matches a known protocol type.
@@ -0,0 +1,60 @@
+package protocol
+
+import "github.com/steveyegge/gastown/internal/protocol/envelope"
+
+// Validate checks a Witness/Refinery protocol message: the envelope (if
+// any) must be readable and match the subject, and required fields must be
+// present. Returns nil if the subject is not a protocol message.
+func Validate(subject, body string) *envelope.Validation {
+	msgType := ParseMessageType(subject)
+	if msgType == "" {
+		return nil
+	}
+
+	var v *envelope.Validation
+	var branch, polecat, rig string
+	switch msgType {
+	case TypeMergeReady:
+		var p MergeReadyPayload
+		v = envelope.Check(string(msgType), body, &p)
+		if v.Valid() {
+			p = *ParseMergeReadyPayload(body)
+			branch, polecat, rig = p.Branch, p.Polecat, p.Rig
+		}
+	case TypeMerged:
+		var p MergedPayload
+		v = envelope.Check(string(msgType), body, &p)
+		if v.Valid() {
+			p = *ParseMergedPayload(body)
+			branch, polecat, rig = p.Branch, p.Polecat, p.Rig
+			v.Require("target_branch", p.TargetBranch)
+		}
+	case TypeMergeFailed:
+		var p MergeFailedPayload
+		v = envelope.Check(string(msgType), body, &p)
+		if v.Valid() {
+			p = *ParseMergeFailedPayload(body)
+			branch, polecat, rig = p.Branch, p.Polecat, p.Rig
+			v.Require("failure_type", p.FailureType)
+		}
+	case TypeReworkRequest:
+		var p ReworkRequestPayload
+		v = envelope.Check(string(msgType), body, &p)
+		if v.Valid() {
+			p = *ParseReworkRequestPayload(body)
+			branch, polecat, rig = p.Branch, p.Polecat, p.Rig
+			v.Require("target_branch", p.TargetBranch)
+		}
+	}
+	if !v.Valid() {
+		return v
+	}
+
+	v.Require("branch", branch)
+	v.Require("rig", rig)
+	v.Require("polecat", polecat)
+	if name := ExtractPolecat(subject); polecat != "" && name != "" && name != polecat {
+		v.Problemf("subject names polecat %s but payload has %s", name, polecat)
+	}
+	return v
+}
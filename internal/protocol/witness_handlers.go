@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/witness"
@@ -87,6 +88,9 @@ func (h *DefaultWitnessHandler) HandleMergeFailed(payload *MergeFailedPayload) e
 	fmt.Fprintf(h.Output, "  Issue: %s\n", payload.Issue)
 	fmt.Fprintf(h.Output, "  Failure type: %s\n", payload.FailureType)
 	fmt.Fprintf(h.Output, "  Error: %s\n", payload.Error)
+	if len(payload.FailedTests) > 0 {
+		fmt.Fprintf(h.Output, "  Failed tests: %s\n", strings.Join(payload.FailedTests, ", "))
+	}
 
 	// Notify the polecat about the failure
 	if err := h.notifyPolecatFailed(payload); err != nil {
@@ -113,6 +117,16 @@ func (h *DefaultWitnessHandler) HandleReworkRequest(payload *ReworkRequestPayloa
 		fmt.Fprintf(h.Output, "  Conflicts in: %v\n", payload.ConflictFiles)
 	}
 
+	// Review feedback from a forge PR is forwarded as-is
+	if len(payload.ReviewComments) > 0 {
+		fmt.Fprintf(h.Output, "  PR: %s (%d review comments)\n", payload.PRURL, len(payload.ReviewComments))
+		if err := h.notifyPolecatReview(payload); err != nil {
+			fmt.Fprintf(h.Output, "[Witness] Warning: failed to notify polecat: %v\n", err)
+		}
+		fmt.Fprintf(h.Output, "[Witness] ⚠ Polecat %s needs to address review comments\n", payload.Polecat)
+		return nil
+	}
+
 	// Notify the polecat about the rebase requirement
 	if err := h.notifyPolecatRebase(payload); err != nil {
 		fmt.Fprintf(h.Output, "[Witness] Warning: failed to notify polecat: %v\n", err)
@@ -150,6 +164,17 @@ Thank you for your contribution! Your worktree will be cleaned up shortly.`,
 
 // notifyPolecatFailed sends a merge failure notification to a polecat.
 func (h *DefaultWitnessHandler) notifyPolecatFailed(payload *MergeFailedPayload) error {
+	testInfo := ""
+	if len(payload.FailedTests) > 0 {
+		testInfo = "\nFailing tests:\n"
+		for _, name := range payload.FailedTests {
+			testInfo += fmt.Sprintf("  - %s\n", name)
+		}
+	}
+	if payload.TestLog != "" {
+		testInfo += fmt.Sprintf("\nTest log:\n%s\n", payload.TestLog)
+	}
+
 	msg := mail.NewMessage(
 		fmt.Sprintf("%s/witness", h.Rig),
 		fmt.Sprintf("%s/%s", h.Rig, payload.Polecat),
@@ -160,12 +185,13 @@ Branch: %s
 Issue: %s
 Failure: %s
 Error: %s
-
+%s
 Please fix the issue and resubmit your work with 'gt done'.`,
 			payload.Branch,
 			payload.Issue,
 			payload.FailureType,
 			payload.Error,
+			testInfo,
 		),
 	)
 	msg.Priority = mail.PriorityHigh
@@ -214,5 +240,30 @@ Then run 'gt done' to resubmit for merge.`,
 	return h.Router.Send(msg)
 }
 
+// notifyPolecatReview forwards PR review comments to a polecat.
+func (h *DefaultWitnessHandler) notifyPolecatReview(payload *ReworkRequestPayload) error {
+	msg := mail.NewMessage(
+		fmt.Sprintf("%s/witness", h.Rig),
+		fmt.Sprintf("%s/%s", h.Rig, payload.Polecat),
+		"Review changes requested",
+		fmt.Sprintf(`Reviewers requested changes on your pull request.
+
+Branch: %s
+Issue: %s
+PR: %s
+
+%s`,
+			payload.Branch,
+			payload.Issue,
+			payload.PRURL,
+			payload.Instructions,
+		),
+	)
+	msg.Priority = mail.PriorityHigh
+	msg.Type = mail.TypeTask
+
+	return h.Router.Send(msg)
+}
+
 // Ensure DefaultWitnessHandler implements WitnessHandler.
 var _ WitnessHandler = (*DefaultWitnessHandler)(nil)
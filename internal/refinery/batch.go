@@ -0,0 +1,322 @@
+package refinery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Merge batching
+//
+// A batch trades latency on failure for far fewer test runs. Up to BatchSize
+// ready MRs are merged one after another in a single scratch worktree and
+// the test command runs once, on the last commit:
+//
+//	base ── MR1 ── MR2 ── MR3 ── MR4   (one test run)
+//
+// If it passes, the target is fast-forwarded to it. If it fails, the batch
+// is bisected over its prefixes (base+MR1..k) to find the first failing
+// prefix; its last MR is the culprit. The passing prefix before the culprit
+// is kept, the MRs after it are restacked without the culprit and tested
+// again, so one batch can find several culprits. With a single culprit a
+// batch of N MRs needs about 1+log2(N) test runs instead of N.
+
+// BatchResult is the outcome of testing and landing one batch.
+type BatchResult struct {
+	// ID identifies the batch in mrqueue batched events.
+	ID string `json:"id"`
+
+	// Target is the branch the batch lands on.
+	Target string `json:"target"`
+
+	// Base is the target SHA the batch was built on.
+	Base string `json:"base"`
+
+	// Landed are the MRs now on the target, in merge order.
+	Landed []*TrainCar `json:"landed,omitempty"`
+
+	// Culprits are the MRs bisection found to break the tests.
+	Culprits []*TrainCar `json:"culprits,omitempty"`
+
+	// Unstacked are MRs that could not be merged into the batch
+	// (conflicts, missing branches).
+	Unstacked []*TrainCar `json:"unstacked,omitempty"`
+
+	// Requeued are MRs that passed but could not be pushed because the
+	// target moved; they ride a later batch.
+	Requeued []*TrainCar `json:"requeued,omitempty"`
+
+	// TestRuns is the number of times the test command ran.
+	TestRuns int `json:"test_runs"`
+}
+
+// MergeCommit returns the commit the target was fast-forwarded to,
+// or empty if nothing landed.
+func (r *BatchResult) MergeCommit() string {
+	if len(r.Landed) == 0 {
+		return ""
+	}
+	return r.Landed[len(r.Landed)-1].Commit
+}
+
+// batchDir returns the directory holding merge batch worktrees.
+func (e *Engineer) batchDir() string {
+	return filepath.Join(e.workDir, ".runtime", "merge-batch")
+}
+
+// newBatchID returns a unique ID for a merge batch.
+func newBatchID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b) // crypto/rand.Read only fails on broken system
+	return "batch-" + hex.EncodeToString(b)
+}
+
+// SelectBatch picks the MRs for the next batch from a score-ordered ready
+// list: up to BatchSize MRs sharing the target of the highest-scored MR.
+func (e *Engineer) SelectBatch(ready []*mrqueue.MR) []*mrqueue.MR {
+	return selectByTarget(ready, e.config.BatchSize)
+}
+
+// ProcessBatch merges mrs onto one temporary commit, tests it, bisects on
+// failure and fast-forwards the target to the passing MRs.
+// The MRs must share a target branch (see SelectBatch).
+// Queue and bead bookkeeping is left to the caller (see RunBatch).
+func (e *Engineer) ProcessBatch(ctx context.Context, mrs []*mrqueue.MR) (*BatchResult, error) {
+	if len(mrs) == 0 {
+		return nil, ErrNoQueue
+	}
+
+	target, err := e.commonTarget(mrs)
+	if err != nil {
+		return nil, err
+	}
+	result := &BatchResult{ID: newBatchID(), Target: target}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Fetching %s from origin...\n", target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return nil, fmt.Errorf("fetching target %s: %w", target, err)
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		return nil, fmt.Errorf("resolving origin/%s: %w", target, err)
+	}
+	result.Base = base
+
+	if err := os.MkdirAll(e.batchDir(), 0755); err != nil {
+		return nil, fmt.Errorf("creating batch directory: %w", err)
+	}
+	stackPath := filepath.Join(e.batchDir(), result.ID)
+	testPath := filepath.Join(e.batchDir(), result.ID+"-test")
+	defer func() {
+		_ = e.git.WorktreeRemove(stackPath, true) // best-effort cleanup
+		_ = e.git.WorktreeRemove(testPath, true)
+		_ = e.git.WorktreePrune()
+	}()
+
+	pending := make([]*TrainCar, 0, len(mrs))
+	for _, mr := range mrs {
+		pending = append(pending, &TrainCar{MR: mr})
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Batch %s: %d MRs onto %s\n", result.ID, len(mrs), shortSHA(base))
+	tip := base
+	for len(pending) > 0 {
+		stacked, err := e.stackBatch(stackPath, tip, pending, result)
+		if err != nil {
+			return nil, err
+		}
+		if len(stacked) == 0 {
+			break
+		}
+
+		last := stacked[len(stacked)-1]
+		res := e.testBatchCommit(ctx, testPath, last, result)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("merge batch canceled: %w", ctx.Err())
+		}
+		if res.Success {
+			result.Landed = append(result.Landed, stacked...)
+			tip = last.Commit
+			break
+		}
+
+		// Bisect for the first failing prefix. Invariant: the prefix
+		// ending at hi fails and the prefix ending before lo passes.
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Batch failed, bisecting %d MRs...\n", len(stacked))
+		lo, hi := 0, len(stacked)-1
+		for lo < hi {
+			mid := (lo + hi) / 2
+			midRes := e.testBatchCommit(ctx, testPath, stacked[mid], result)
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("merge batch canceled: %w", ctx.Err())
+			}
+			if midRes.Success {
+				lo = mid + 1
+			} else {
+				hi = mid
+				res = midRes
+			}
+		}
+
+		culprit := stacked[hi]
+		culprit.Result = res
+		culprit.Result.TestsFailed = true
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Culprit: %s (%s)\n", culprit.MR.ID, culprit.MR.Branch)
+		result.Culprits = append(result.Culprits, culprit)
+		result.Landed = append(result.Landed, stacked[:hi]...)
+		if hi > 0 {
+			tip = stacked[hi-1].Commit
+		}
+		pending = stacked[hi+1:]
+	}
+
+	if tip == base {
+		return result, nil
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Fast-forwarding origin/%s to %s (%d MRs, %d test runs)...\n",
+		target, shortSHA(tip), len(result.Landed), result.TestRuns)
+	if err := e.git.Push("origin", tip+":refs/heads/"+target, false); err != nil {
+		// Target moved underneath us: nothing landed, retry in a later batch.
+		for _, car := range result.Landed {
+			car.Result = ProcessResult{Error: fmt.Sprintf("push failed: %v", err)}
+		}
+		result.Requeued = result.Landed
+		result.Landed = nil
+		return result, fmt.Errorf("pushing batch to origin/%s: %w", target, err)
+	}
+
+	for _, car := range result.Landed {
+		car.Result = ProcessResult{Success: true, MergeCommit: car.Commit}
+	}
+	return result, nil
+}
+
+// stackBatch merges cars one after another onto base in a scratch worktree
+// at path. Cars that fail to merge are added to result.Unstacked and
+// skipped; the rest are returned in order, each with its prefix commit.
+func (e *Engineer) stackBatch(path, base string, cars []*TrainCar, result *BatchResult) ([]*TrainCar, error) {
+	_ = e.git.WorktreeRemove(path, true) // previous round or a crashed batch
+	if err := e.git.WorktreeAddDetached(path, base); err != nil {
+		return nil, fmt.Errorf("creating batch worktree: %w", err)
+	}
+	wt := git.NewGit(path)
+
+	var stacked []*TrainCar
+	for _, car := range cars {
+		car.Commit = ""
+		car.Result = ProcessResult{}
+		if err := e.git.FetchBranch("origin", car.MR.Branch); err != nil {
+			car.Result = ProcessResult{Error: fmt.Sprintf("failed to fetch branch %s: %v", car.MR.Branch, err)}
+		} else {
+			e.mergeCar(wt, car)
+		}
+		if car.Commit == "" {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Unstacked %s: %s\n", car.MR.ID, car.Result.Error)
+			result.Unstacked = append(result.Unstacked, car)
+			continue
+		}
+		stacked = append(stacked, car)
+	}
+	return stacked, nil
+}
+
+// testBatchCommit runs the test command on the batch prefix ending at car,
+// in a scratch worktree at path.
+func (e *Engineer) testBatchCommit(ctx context.Context, path string, car *TrainCar, result *BatchResult) ProcessResult {
+	if !e.config.RunTests || e.config.TestCommand == "" {
+		return ProcessResult{Success: true}
+	}
+
+	_ = e.git.WorktreeRemove(path, true) // previous bisection step
+	if err := e.git.WorktreeAddDetached(path, car.Commit); err != nil {
+		return ProcessResult{Error: fmt.Sprintf("creating worktree: %v", err)}
+	}
+	defer func() { _ = e.git.WorktreeRemove(path, true) }()
+
+	result.TestRuns++
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Testing batch through %s (%s)...\n", car.MR.ID, shortSHA(car.Commit))
+	return e.runTestsIn(ctx, path, car.MR.Branch)
+}
+
+// RunBatch claims the next batch of ready MRs for workerID, processes it and
+// applies the outcome: landed MRs are closed, culprits are sent back to
+// their workers with MERGE_FAILED, conflicts are auto-rebased or get
+// resolution tasks, and requeued MRs are released. Every MR gets a batched
+// event recording the batch it rode in.
+func (e *Engineer) RunBatch(ctx context.Context, workerID string) (*BatchResult, error) {
+	if e.config.PRMode {
+		return nil, fmt.Errorf("merge batches push to the target branch; in PR mode use gt refinery prs")
+	}
+
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return nil, fmt.Errorf("listing ready MRs: %w", err)
+	}
+
+	var mrs []*mrqueue.MR
+	for _, mr := range e.SelectBatch(ready) {
+		if err := e.mrQueue.Claim(mr.ID, workerID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Skipping %s: %v\n", mr.ID, err)
+			continue
+		}
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
+		}
+		mrs = append(mrs, mr)
+	}
+	if len(mrs) == 0 {
+		return nil, ErrNoQueue
+	}
+
+	result, batchErr := e.ProcessBatch(ctx, mrs)
+	if result == nil {
+		for _, mr := range mrs {
+			_ = e.mrQueue.Release(mr.ID)
+		}
+		return nil, batchErr
+	}
+
+	for _, car := range result.Landed {
+		e.logBatched(result, car, mrqueue.BatchLanded)
+		e.handleSuccessFromQueue(car.MR, car.Result)
+	}
+	for _, car := range result.Culprits {
+		e.logBatched(result, car, mrqueue.BatchCulprit)
+		e.handleFailureFromQueue(car.MR, car.Result)
+		e.notifyMergeFailed(car)
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+	for _, car := range result.Unstacked {
+		if e.resolveConflictByRebase(ctx, car) {
+			e.logBatched(result, car, mrqueue.BatchRequeued)
+			continue
+		}
+		e.logBatched(result, car, mrqueue.BatchUnstacked)
+		e.handleFailureFromQueue(car.MR, car.Result)
+		if !car.Result.Conflict {
+			e.notifyMergeFailed(car)
+		}
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+	for _, car := range result.Requeued {
+		e.logBatched(result, car, mrqueue.BatchRequeued)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Requeued %s for next batch\n", car.MR.ID)
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+
+	return result, batchErr
+}
+
+// logBatched records which batch car rode in and its outcome.
+func (e *Engineer) logBatched(result *BatchResult, car *TrainCar, outcome string) {
+	if err := e.eventLogger.LogBatched(car.MR, result.ID, outcome, result.TestRuns, car.Result.Error); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log batched event: %v\n", err)
+	}
+}
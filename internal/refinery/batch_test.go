@@ -0,0 +1,110 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestRunBatch_BisectsToCulprits(t *testing.T) {
+	rigPath := setupTrainRig(t, map[string]string{
+		"polecat/nux":   "nux.txt",
+		"polecat/toast": "broken",
+		"polecat/ace":   "ace.txt",
+		"polecat/max":   "broken-too",
+	})
+
+	r := &rig.Rig{Name: "test-rig", Path: rigPath}
+	e := NewEngineer(r)
+	e.SetOutput(io.Discard)
+	e.config.BatchSize = 4
+	e.config.TestCommand = "test ! -f broken && test ! -f broken-too"
+
+	q := mrqueue.New(rigPath)
+	for i, mr := range []*mrqueue.MR{
+		{ID: "mr-nux", Branch: "polecat/nux", Worker: "nux"},
+		{ID: "mr-toast", Branch: "polecat/toast", Worker: "toast"},
+		{ID: "mr-ace", Branch: "polecat/ace", Worker: "ace"},
+		{ID: "mr-max", Branch: "polecat/max", Worker: "max"},
+	} {
+		mr.Target = "main"
+		mr.Rig = "test-rig"
+		mr.Priority = i // Score order: nux, toast, ace, max
+		if err := q.Submit(mr); err != nil {
+			t.Fatalf("submit %s: %v", mr.ID, err)
+		}
+	}
+
+	result, err := e.RunBatch(context.Background(), "refinery-1")
+	if err != nil {
+		t.Fatalf("RunBatch: %v", err)
+	}
+
+	ids := func(cars []*TrainCar) string {
+		var s []string
+		for _, car := range cars {
+			s = append(s, car.MR.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	if got := ids(result.Landed); got != "mr-nux,mr-ace" {
+		t.Errorf("landed = %s, want mr-nux,mr-ace", got)
+	}
+	if got := ids(result.Culprits); got != "mr-toast,mr-max" {
+		t.Errorf("culprits = %s, want mr-toast,mr-max", got)
+	}
+	// Full batch, bisect to toast (2), restacked batch, bisect to max (1).
+	if result.TestRuns != 5 {
+		t.Errorf("TestRuns = %d, want 5", result.TestRuns)
+	}
+
+	// origin/main holds the passing MRs and neither culprit
+	originMain := runGit(t, rigPath, "ls-remote", "origin", "refs/heads/main")
+	if !strings.HasPrefix(originMain, result.MergeCommit()) {
+		t.Errorf("origin/main = %q, want %s", originMain, result.MergeCommit())
+	}
+	files := runGit(t, rigPath, "ls-tree", "--name-only", result.MergeCommit())
+	if strings.Contains(files, "broken") || !strings.Contains(files, "ace.txt") {
+		t.Errorf("landed tree = %q", files)
+	}
+
+	// Every MR has a batched event for this batch
+	events, err := mrqueue.NewEventLoggerFromRig(rigPath).Events("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes := make(map[string]string)
+	for _, ev := range events {
+		if ev.Type == mrqueue.EventBatched && ev.Batch == result.ID {
+			outcomes[ev.MRID] = ev.Outcome
+		}
+	}
+	for id, want := range map[string]string{
+		"mr-nux":   mrqueue.BatchLanded,
+		"mr-toast": mrqueue.BatchCulprit,
+		"mr-ace":   mrqueue.BatchLanded,
+		"mr-max":   mrqueue.BatchCulprit,
+	} {
+		if outcomes[id] != want {
+			t.Errorf("%s outcome = %q, want %q", id, outcomes[id], want)
+		}
+	}
+
+	// Culprits stay queued for their workers
+	if _, err := q.Get("mr-ace"); !os.IsNotExist(err) {
+		t.Errorf("expected mr-ace to be removed from queue, got err=%v", err)
+	}
+	if mr, err := q.Get("mr-toast"); err != nil || mr.ClaimedBy != "" {
+		t.Errorf("expected mr-toast to remain unclaimed in queue: %+v, %v", mr, err)
+	}
+
+	entries, _ := os.ReadDir(e.batchDir())
+	if len(entries) != 0 {
+		t.Errorf("expected batch worktrees to be removed, found %d entries", len(entries))
+	}
+}
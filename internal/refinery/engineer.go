@@ -12,12 +12,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/forge"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/testresult"
 )
 
 // MergeQueueConfig holds configuration for the merge queue processor.
@@ -32,8 +36,15 @@ type MergeQueueConfig struct {
 	IntegrationBranches bool `json:"integration_branches"`
 
 	// OnConflict is the strategy for handling conflicts: "assign_back" or "auto_rebase".
+	// With auto_rebase the branch is rebased onto the target in a scratch
+	// worktree, conflicts are resolved by Resolvers, and the MR is only
+	// assigned back if that fails.
 	OnConflict string `json:"on_conflict"`
 
+	// Resolvers resolve conflicts in matching files during auto_rebase.
+	// Nil uses config.DefaultConflictResolvers.
+	Resolvers []config.ConflictResolver `json:"resolvers,omitempty"`
+
 	// RunTests controls whether to run tests before merging.
 	RunTests bool `json:"run_tests"`
 
@@ -50,7 +61,42 @@ type MergeQueueConfig struct {
 	PollInterval time.Duration `json:"poll_interval"`
 
 	// MaxConcurrent is the maximum number of MRs to process concurrently.
+	// In merge train mode this is the maximum train length.
 	MaxConcurrent int `json:"max_concurrent"`
+
+	// MergeTrain enables speculative merge trains: the top MaxConcurrent MRs
+	// are stacked onto successive integration commits and tested in parallel.
+	MergeTrain bool `json:"merge_train"`
+
+	// BatchSize enables batching: up to BatchSize ready MRs are merged onto
+	// one temporary commit and tested once. A failing batch is bisected to
+	// find the culprits. 0 or 1 disables batching.
+	BatchSize int `json:"batch_size"`
+
+	// TestFormat is the test output format used to find failing tests:
+	// "auto", "go-json", "junit", "tap", or "none" to skip parsing.
+	TestFormat string `json:"test_format"`
+
+	// TestReport is an optional report file, relative to the work dir, that
+	// the test command writes. When set it is parsed instead of the output.
+	TestReport string `json:"test_report"`
+
+	// QuarantineFlakyAfter quarantines a test once it has flaked on this many
+	// distinct branches; quarantined failures no longer block merges.
+	// Flakes are only observed when RetryFlakyTests is at least 2, so
+	// LoadConfig raises it to 2 while quarantine is on by default, and
+	// rejects an explicit quarantine with fewer attempts. 0 disables
+	// quarantine.
+	QuarantineFlakyAfter int `json:"quarantine_flaky_after"`
+
+	// PRMode lands MRs through forge pull requests instead of pushing merge
+	// commits to the target: the refinery opens a PR per MR, waits for the
+	// required checks (and approval, if configured), and merges via the API.
+	PRMode bool `json:"pr_mode"`
+
+	// Forge configures the code host used in PR mode. Optional; the forge
+	// is detected from the origin remote when unset.
+	Forge *forge.Config `json:"forge,omitempty"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
@@ -63,9 +109,11 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		RunTests:             true,
 		TestCommand:          "",
 		DeleteMergedBranches: true,
-		RetryFlakyTests:      1,
+		RetryFlakyTests:      2,
 		PollInterval:         30 * time.Second,
 		MaxConcurrent:        1,
+		TestFormat:           "auto",
+		QuarantineFlakyAfter: 2,
 	}
 }
 
@@ -81,6 +129,12 @@ type Engineer struct {
 	output      io.Writer // Output destination for user-facing messages
 	eventLogger *mrqueue.EventLogger
 
+	// flakeMu serializes flake history updates from parallel train cars
+	flakeMu sync.Mutex
+
+	// forge is the code host used in PR mode, created on first use
+	forge forge.Forge
+
 	// stopCh is used for graceful shutdown
 	stopCh chan struct{}
 }
@@ -138,16 +192,24 @@ func (e *Engineer) LoadConfig() error {
 	// Parse merge_queue section into our config struct
 	// We need special handling for poll_interval (string -> Duration)
 	var mqRaw struct {
-		Enabled              *bool   `json:"enabled"`
-		TargetBranch         *string `json:"target_branch"`
-		IntegrationBranches  *bool   `json:"integration_branches"`
-		OnConflict           *string `json:"on_conflict"`
-		RunTests             *bool   `json:"run_tests"`
-		TestCommand          *string `json:"test_command"`
-		DeleteMergedBranches *bool   `json:"delete_merged_branches"`
-		RetryFlakyTests      *int    `json:"retry_flaky_tests"`
-		PollInterval         *string `json:"poll_interval"`
-		MaxConcurrent        *int    `json:"max_concurrent"`
+		Enabled              *bool                     `json:"enabled"`
+		TargetBranch         *string                   `json:"target_branch"`
+		IntegrationBranches  *bool                     `json:"integration_branches"`
+		OnConflict           *string                   `json:"on_conflict"`
+		Resolvers            []config.ConflictResolver `json:"resolvers"`
+		RunTests             *bool                     `json:"run_tests"`
+		TestCommand          *string                   `json:"test_command"`
+		DeleteMergedBranches *bool                     `json:"delete_merged_branches"`
+		RetryFlakyTests      *int                      `json:"retry_flaky_tests"`
+		PollInterval         *string                   `json:"poll_interval"`
+		MaxConcurrent        *int                      `json:"max_concurrent"`
+		MergeTrain           *bool                     `json:"merge_train"`
+		BatchSize            *int                      `json:"batch_size"`
+		TestFormat           *string                   `json:"test_format"`
+		TestReport           *string                   `json:"test_report"`
+		QuarantineFlakyAfter *int                      `json:"quarantine_flaky_after"`
+		PRMode               *bool                     `json:"pr_mode"`
+		Forge                *forge.Config             `json:"forge"`
 	}
 
 	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
@@ -167,6 +229,14 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.OnConflict != nil {
 		e.config.OnConflict = *mqRaw.OnConflict
 	}
+	if mqRaw.Resolvers != nil {
+		for i := range mqRaw.Resolvers {
+			if err := mqRaw.Resolvers[i].Validate(); err != nil {
+				return fmt.Errorf("invalid merge_queue resolvers: %w", err)
+			}
+		}
+		e.config.Resolvers = mqRaw.Resolvers
+	}
 	if mqRaw.RunTests != nil {
 		e.config.RunTests = *mqRaw.RunTests
 	}
@@ -182,6 +252,30 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.MaxConcurrent != nil {
 		e.config.MaxConcurrent = *mqRaw.MaxConcurrent
 	}
+	if mqRaw.MergeTrain != nil {
+		e.config.MergeTrain = *mqRaw.MergeTrain
+	}
+	if mqRaw.BatchSize != nil {
+		e.config.BatchSize = *mqRaw.BatchSize
+	}
+	if mqRaw.TestFormat != nil {
+		e.config.TestFormat = *mqRaw.TestFormat
+	}
+	if mqRaw.TestReport != nil {
+		e.config.TestReport = *mqRaw.TestReport
+	}
+	if mqRaw.QuarantineFlakyAfter != nil {
+		e.config.QuarantineFlakyAfter = *mqRaw.QuarantineFlakyAfter
+	}
+	if mqRaw.PRMode != nil {
+		e.config.PRMode = *mqRaw.PRMode
+	}
+	if mqRaw.Forge != nil {
+		if err := mqRaw.Forge.Validate(); err != nil {
+			return fmt.Errorf("invalid forge config: %w", err)
+		}
+		e.config.Forge = mqRaw.Forge
+	}
 	if mqRaw.PollInterval != nil {
 		dur, err := time.ParseDuration(*mqRaw.PollInterval)
 		if err != nil {
@@ -190,6 +284,14 @@ func (e *Engineer) LoadConfig() error {
 		e.config.PollInterval = dur
 	}
 
+	// A flake is a failure that passes on retry: quarantine needs one.
+	if e.config.QuarantineFlakyAfter > 0 && e.config.RetryFlakyTests < 2 {
+		if mqRaw.QuarantineFlakyAfter != nil {
+			return fmt.Errorf("quarantine_flaky_after needs retry_flaky_tests of at least 2 (got %d)", e.config.RetryFlakyTests)
+		}
+		e.config.RetryFlakyTests = 2
+	}
+
 	return nil
 }
 
@@ -205,6 +307,13 @@ type ProcessResult struct {
 	Error       string
 	Conflict    bool
 	TestsFailed bool
+
+	// FailedTests names the failing tests when the test output was parseable.
+	FailedTests []string
+	// TestLog is the trimmed output of the failing tests (or the whole run).
+	TestLog string
+	// QuarantinedFailures lists quarantined tests that failed but were ignored.
+	QuarantinedFailures []string
 }
 
 // ProcessMR processes a single merge request from a beads issue.
@@ -224,12 +333,14 @@ func (e *Engineer) ProcessMR(ctx context.Context, mr *beads.Issue) ProcessResult
 	_, _ = fmt.Fprintf(e.output, "  Target: %s\n", mrFields.Target)
 	_, _ = fmt.Fprintf(e.output, "  Worker: %s\n", mrFields.Worker)
 
-	return e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue)
+	return e.doMerge(ctx, mrFields.Branch, mrFields.Target, mrFields.SourceIssue, nil)
 }
 
 // doMerge performs the actual git merge operation.
 // This is the core merge logic shared by ProcessMR and ProcessMRFromQueue.
-func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string) ProcessResult {
+// tested is the AutoRebase that already tested branch on top of target, or
+// nil; its tests are only trusted while neither has moved since.
+func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue string, tested *RebaseResult) ProcessResult {
 	// Step 1: Fetch the source branch from origin
 	_, _ = fmt.Fprintf(e.output, "[Engineer] Fetching branch %s from origin...\n", branch)
 	if err := e.git.FetchBranch("origin", branch); err != nil {
@@ -274,15 +385,12 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	}
 
 	// Step 4: Run tests if configured
-	if e.config.RunTests && e.config.TestCommand != "" {
+	if e.config.RunTests && e.config.TestCommand != "" && !e.stillTested(tested, branch, target) {
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
-		result := e.runTests(ctx)
+		result := e.runTests(ctx, branch)
 		if !result.Success {
-			return ProcessResult{
-				Success:     false,
-				TestsFailed: true,
-				Error:       result.Error,
-			}
+			result.TestsFailed = true
+			return result
 		}
 		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
 	}
@@ -334,7 +442,23 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 }
 
 // runTests runs the configured test command and returns the result.
-func (e *Engineer) runTests(ctx context.Context) ProcessResult {
+func (e *Engineer) runTests(ctx context.Context, branch string) ProcessResult {
+	return e.runTestsIn(ctx, e.workDir, branch)
+}
+
+// maxTestLogLines bounds the log attached to MERGE_FAILED.
+const maxTestLogLines = 60
+
+// runTestsIn runs the configured test command in dir and returns the result.
+// Merge trains use this to test speculative commits in their own worktrees.
+//
+// Each attempt's output is parsed (see TestFormat) so failures name the
+// broken tests. Tests that fail and then pass on retry are recorded as
+// flakes against branch; once a test is quarantined, a run whose only
+// failures are quarantined tests counts as passing, unless the report has
+// output it can't explain (a compiler or lint error) that may have failed
+// the run on its own.
+func (e *Engineer) runTestsIn(ctx context.Context, dir, branch string) ProcessResult {
 	if e.config.TestCommand == "" {
 		return ProcessResult{Success: true}
 	}
@@ -345,7 +469,12 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		maxRetries = 1
 	}
 
-	var lastErr error
+	var (
+		lastErr    error
+		lastOutput []byte
+		reports    []*testresult.Report
+		result     ProcessResult
+	)
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		if attempt > 1 {
 			_, _ = fmt.Fprintf(e.output, "[Engineer] Retrying tests (attempt %d/%d)...\n", attempt, maxRetries)
@@ -354,16 +483,19 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		// Note: TestCommand comes from rig's config.json (trusted infrastructure config),
 		// not from PR branches. Shell execution is intentional for flexibility (pipes, etc).
 		cmd := exec.CommandContext(ctx, "sh", "-c", e.config.TestCommand) //nolint:gosec // G204: TestCommand is from trusted rig config
-		cmd.Dir = e.workDir
-		var stdout, stderr bytes.Buffer
-		cmd.Stdout = &stdout
-		cmd.Stderr = &stderr
+		cmd.Dir = dir
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
 
 		err := cmd.Run()
+		reports = append(reports, e.parseTestOutput(dir, output.Bytes()))
 		if err == nil {
-			return ProcessResult{Success: true}
+			result = ProcessResult{Success: true}
+			break
 		}
 		lastErr = err
+		lastOutput = output.Bytes()
 
 		// Check if context was canceled
 		if ctx.Err() != nil {
@@ -374,11 +506,97 @@ func (e *Engineer) runTests(ctx context.Context) ProcessResult {
 		}
 	}
 
-	return ProcessResult{
+	tracker := e.recordFlakes(branch, reports)
+	if result.Success {
+		return result
+	}
+
+	// Tests failed on every attempt: report what the last attempt saw
+	last := reports[len(reports)-1]
+	result = ProcessResult{
 		Success:     false,
 		TestsFailed: true,
 		Error:       fmt.Sprintf("tests failed after %d attempts: %v", maxRetries, lastErr),
 	}
+	if last == nil || len(last.Failed()) == 0 {
+		// Unparseable output: attach the tail of the raw log
+		result.TestLog = testresult.TrimLog(string(lastOutput), maxTestLogLines)
+		return result
+	}
+
+	var logs []string
+	for _, tc := range last.Failed() {
+		if tracker != nil && tracker.IsQuarantined(tc.Name) {
+			result.QuarantinedFailures = append(result.QuarantinedFailures, tc.Name)
+			continue
+		}
+		result.FailedTests = append(result.FailedTests, tc.Name)
+		if tc.Output != "" {
+			logs = append(logs, "--- "+tc.Name+"\n"+tc.Output)
+		}
+	}
+
+	if len(result.FailedTests) == 0 && len(last.Unexplained) > 0 {
+		// The failures alone don't account for the exit status
+		result.Error = fmt.Sprintf("tests failed after %d attempts (only quarantined tests failed, but the run reported other errors): %v",
+			maxRetries, lastErr)
+		result.TestLog = testresult.TrimLog(strings.Join(last.Unexplained, "\n"), maxTestLogLines)
+		return result
+	}
+	if len(result.FailedTests) == 0 {
+		// Every failure is a known flake: don't bounce innocent work
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Ignoring quarantined test failures: %s\n",
+			strings.Join(result.QuarantinedFailures, ", "))
+		return ProcessResult{Success: true, QuarantinedFailures: result.QuarantinedFailures}
+	}
+
+	result.Error = fmt.Sprintf("%d test(s) failed after %d attempts: %s",
+		len(result.FailedTests), maxRetries, strings.Join(result.FailedTests, ", "))
+	result.TestLog = testresult.TrimLog(strings.Join(logs, "\n"), maxTestLogLines)
+	return result
+}
+
+// parseTestOutput parses one attempt's results, preferring the configured
+// report file. Returns nil when parsing is disabled or nothing was found.
+func (e *Engineer) parseTestOutput(dir string, output []byte) *testresult.Report {
+	if e.config.TestFormat == "none" {
+		return nil
+	}
+	if e.config.TestReport != "" {
+		data, err := os.ReadFile(filepath.Join(dir, e.config.TestReport))
+		if err != nil {
+			return nil
+		}
+		// Remove so the next attempt can't read a stale report
+		_ = os.Remove(filepath.Join(dir, e.config.TestReport))
+		output = data
+	}
+	report, err := testresult.Parse(e.config.TestFormat, output)
+	if err != nil {
+		return nil
+	}
+	return report
+}
+
+// recordFlakes folds a run's attempts into the rig's flake history and
+// announces newly quarantined tests. Returns nil if the history is unavailable.
+func (e *Engineer) recordFlakes(branch string, reports []*testresult.Report) *FlakeTracker {
+	e.flakeMu.Lock()
+	defer e.flakeMu.Unlock()
+
+	tracker, err := LoadFlakeTracker(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+		return nil
+	}
+	quarantined := tracker.Record(branch, reports, e.config.QuarantineFlakyAfter)
+	if err := tracker.Save(); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to save flake history: %v\n", err)
+	}
+	for _, name := range quarantined {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Quarantined flaky test: %s\n", name)
+	}
+	return tracker
 }
 
 // handleSuccess handles a successful merge completion.
@@ -465,8 +683,110 @@ func (e *Engineer) ProcessMRFromQueue(ctx context.Context, mr *mrqueue.MR) Proce
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
 	}
 
+	// In PR mode the forge does the merge
+	if e.config.PRMode {
+		return e.SyncPR(ctx, mr).Result
+	}
+
 	// Use the shared merge logic
-	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue)
+	result := e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue, nil)
+	if !result.Conflict || e.config.OnConflict != config.OnConflictAutoRebase {
+		return result
+	}
+
+	// Try to rebase away the conflict before assigning it back. AutoRebase
+	// has already tested the rebased branch, so the merge doesn't again
+	// unless the target moved meanwhile.
+	rb := e.AutoRebase(ctx, mr)
+	if !rb.Success {
+		result.Error = fmt.Sprintf("%s (auto-rebase failed: %s)", result.Error, rb.Error)
+		return result
+	}
+	return e.doMerge(ctx, mr.Branch, mr.Target, mr.SourceIssue, rb)
+}
+
+// stillTested reports whether an auto-rebase's tests still cover merging
+// branch into target: origin/<target> is still the base it tested against
+// and origin/<branch> is still the commit it pushed.
+func (e *Engineer) stillTested(rb *RebaseResult, branch, target string) bool {
+	if rb == nil || rb.Base == "" {
+		return false
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		return false
+	}
+	tip, err := e.git.Rev("origin/" + branch)
+	if err != nil {
+		return false
+	}
+	if base != rb.Base || tip != rb.Commit {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] %s or %s moved since the auto-rebase was tested; testing again\n", branch, target)
+		return false
+	}
+	return true
+}
+
+// QueueResult is the outcome of one ProcessNext pass. Train, Batch or MR
+// is set according to Mode.
+type QueueResult struct {
+	// Mode is how the pass ran: "train", "batch" or "single".
+	Mode string `json:"mode"`
+
+	Train *TrainResult `json:"train,omitempty"`
+	Batch *BatchResult `json:"batch,omitempty"`
+
+	// MR and Result are set in single mode.
+	MR     *mrqueue.MR    `json:"mr,omitempty"`
+	Result *ProcessResult `json:"result,omitempty"`
+}
+
+// ProcessNext processes the ready queue once for workerID in the mode the
+// merge queue is configured for: a speculative train with merge_train, a
+// batch with batch_size of 2 or more, and otherwise the top ready MR on
+// its own. Returns ErrNoQueue if nothing is ready.
+func (e *Engineer) ProcessNext(ctx context.Context, workerID string) (*QueueResult, error) {
+	if e.config.PRMode {
+		return nil, fmt.Errorf("in PR mode the forge merges; use gt refinery prs")
+	}
+
+	switch {
+	case e.config.MergeTrain:
+		train, err := e.RunTrain(ctx, workerID)
+		if train == nil {
+			return nil, err
+		}
+		return &QueueResult{Mode: "train", Train: train}, err
+	case e.config.BatchSize >= 2:
+		batch, err := e.RunBatch(ctx, workerID)
+		if batch == nil {
+			return nil, err
+		}
+		return &QueueResult{Mode: "batch", Batch: batch}, err
+	}
+
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return nil, fmt.Errorf("listing ready MRs: %w", err)
+	}
+	for _, mr := range ready {
+		if err := e.mrQueue.Claim(mr.ID, workerID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Skipping %s: %v\n", mr.ID, err)
+			continue
+		}
+		result := e.ProcessMRFromQueue(ctx, mr)
+		if result.Success {
+			e.handleSuccessFromQueue(mr, result)
+		} else {
+			e.handleFailureFromQueue(mr, result)
+			if !result.Conflict {
+				e.notifyMergeFailed(&TrainCar{MR: mr, Result: result})
+			}
+			_ = e.mrQueue.Release(mr.ID)
+		}
+		return &QueueResult{Mode: "single", MR: mr, Result: &result}, nil
+	}
+	return nil, ErrNoQueue
 }
 
 // handleSuccessFromQueue handles a successful merge from wisp queue.
@@ -186,6 +186,35 @@ func TestEngineer_LoadConfig_InvalidPollInterval(t *testing.T) {
 	}
 }
 
+func TestEngineer_LoadConfig_QuarantineNeedsRetries(t *testing.T) {
+	if cfg := DefaultMergeQueueConfig(); cfg.QuarantineFlakyAfter > 0 && cfg.RetryFlakyTests < 2 {
+		t.Fatalf("defaults can never quarantine: %+v", cfg)
+	}
+
+	load := func(mq map[string]interface{}) (*Engineer, error) {
+		tmpDir := t.TempDir()
+		data, _ := json.Marshal(map[string]interface{}{"merge_queue": mq})
+		if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		e := NewEngineer(&rig.Rig{Name: "test-rig", Path: tmpDir})
+		return e, e.LoadConfig()
+	}
+
+	// Quarantine on by default: a single attempt is raised to two.
+	e, err := load(map[string]interface{}{"retry_flaky_tests": 1})
+	if err != nil || e.config.RetryFlakyTests != 2 {
+		t.Errorf("default quarantine: retries = %d, err = %v", e.config.RetryFlakyTests, err)
+	}
+	e, err = load(map[string]interface{}{"retry_flaky_tests": 1, "quarantine_flaky_after": 0})
+	if err != nil || e.config.RetryFlakyTests != 1 {
+		t.Errorf("quarantine off: retries = %d, err = %v", e.config.RetryFlakyTests, err)
+	}
+	if _, err := load(map[string]interface{}{"retry_flaky_tests": 1, "quarantine_flaky_after": 3}); err == nil {
+		t.Error("expected explicit quarantine with one attempt to be rejected")
+	}
+}
+
 func TestNewEngineer(t *testing.T) {
 	r := &rig.Rig{
 		Name: "test-rig",
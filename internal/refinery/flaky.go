@@ -0,0 +1,217 @@
+package refinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/testresult"
+)
+
+// maxFlakeBranches caps how many distinct branches are remembered per test.
+const maxFlakeBranches = 20
+
+// FlakeStats is one test's history across merge requests.
+type FlakeStats struct {
+	// Name is the qualified test name.
+	Name string `json:"name"`
+
+	// Runs counts merge requests whose test run included this test.
+	Runs int `json:"runs"`
+
+	// Failures counts runs where the test failed at least once.
+	Failures int `json:"failures"`
+
+	// Flakes counts runs where the test failed and then passed on retry
+	// of the same commit.
+	Flakes int `json:"flakes"`
+
+	// FlakeBranches lists the distinct branches the test flaked on.
+	FlakeBranches []string `json:"flake_branches,omitempty"`
+
+	// LastFailure is when the test last failed.
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+
+	// QuarantinedAt is set while the test is quarantined: its failures
+	// no longer block merges.
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+}
+
+// FlakeRate is the fraction of runs in which the test flaked.
+func (s *FlakeStats) FlakeRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Flakes) / float64(s.Runs)
+}
+
+// Quarantined reports whether the test is quarantined.
+func (s *FlakeStats) Quarantined() bool {
+	return s.QuarantinedAt != nil
+}
+
+// FlakeTracker records per-test flake history for a rig and decides which
+// tests to quarantine. It is persisted in the rig's .runtime directory so
+// history survives refinery restarts.
+type FlakeTracker struct {
+	path string
+	mu   sync.Mutex
+
+	// Tests is keyed by qualified test name.
+	Tests map[string]*FlakeStats `json:"tests"`
+}
+
+// flakeTrackerPath returns the tracker file for a rig.
+func flakeTrackerPath(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "flaky-tests.json")
+}
+
+// LoadFlakeTracker loads the rig's flake history, starting empty if none exists.
+func LoadFlakeTracker(rigPath string) (*FlakeTracker, error) {
+	t := &FlakeTracker{
+		path:  flakeTrackerPath(rigPath),
+		Tests: make(map[string]*FlakeStats),
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return t, nil
+		}
+		return nil, fmt.Errorf("reading flake history: %w", err)
+	}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("parsing flake history: %w", err)
+	}
+	if t.Tests == nil {
+		t.Tests = make(map[string]*FlakeStats)
+	}
+	return t, nil
+}
+
+// Save writes the flake history to disk.
+func (t *FlakeTracker) Save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("creating runtime dir: %w", err)
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, data, 0644)
+}
+
+// Record folds one merge request's test attempts into the history. Attempts
+// are in run order; a test that fails in one attempt and passes in a later
+// one is a flake. When a test has flaked on quarantineAfter distinct
+// branches it is quarantined (quarantineAfter <= 0 disables quarantine).
+// Returns the tests newly quarantined by this call.
+func (t *FlakeTracker) Record(branch string, attempts []*testresult.Report, quarantineAfter int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	failedOnce := map[string]bool{}
+	flaked := map[string]bool{}
+	seen := map[string]bool{}
+	for _, report := range attempts {
+		if report == nil {
+			continue
+		}
+		for _, tc := range report.Tests {
+			seen[tc.Name] = true
+			switch tc.Status {
+			case testresult.StatusFail:
+				failedOnce[tc.Name] = true
+			case testresult.StatusPass:
+				if failedOnce[tc.Name] {
+					flaked[tc.Name] = true
+				}
+			}
+		}
+	}
+
+	var quarantined []string
+	for name := range seen {
+		s, ok := t.Tests[name]
+		if !ok {
+			s = &FlakeStats{Name: name}
+			t.Tests[name] = s
+		}
+		s.Runs++
+		if failedOnce[name] {
+			s.Failures++
+			s.LastFailure = &now
+		}
+		if !flaked[name] {
+			continue
+		}
+		s.Flakes++
+		if !contains(s.FlakeBranches, branch) && len(s.FlakeBranches) < maxFlakeBranches {
+			s.FlakeBranches = append(s.FlakeBranches, branch)
+		}
+		if quarantineAfter > 0 && !s.Quarantined() && len(s.FlakeBranches) >= quarantineAfter {
+			s.QuarantinedAt = &now
+			quarantined = append(quarantined, name)
+		}
+	}
+	sort.Strings(quarantined)
+	return quarantined
+}
+
+// IsQuarantined reports whether the named test is quarantined.
+func (t *FlakeTracker) IsQuarantined(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.Tests[name]
+	return ok && s.Quarantined()
+}
+
+// Release lifts the quarantine on a test, keeping its history.
+func (t *FlakeTracker) Release(name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.Tests[name]
+	if !ok || !s.Quarantined() {
+		return fmt.Errorf("test %s is not quarantined", name)
+	}
+	s.QuarantinedAt = nil
+	s.FlakeBranches = nil
+	return nil
+}
+
+// Stats returns the history of every test that has failed at least once,
+// most flaky first.
+func (t *FlakeTracker) Stats() []*FlakeStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var stats []*FlakeStats
+	for _, s := range t.Tests {
+		if s.Failures > 0 || s.Quarantined() {
+			stats = append(stats, s)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].FlakeRate() != stats[j].FlakeRate() {
+			return stats[i].FlakeRate() > stats[j].FlakeRate()
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// contains reports whether list includes s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
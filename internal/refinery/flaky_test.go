@@ -0,0 +1,174 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/testresult"
+)
+
+func report(status map[string]testresult.Status) *testresult.Report {
+	r := &testresult.Report{Format: "test"}
+	for name, s := range status {
+		r.Tests = append(r.Tests, testresult.TestCase{Name: name, Status: s})
+	}
+	return r
+}
+
+func TestFlakeTracker_RecordAndQuarantine(t *testing.T) {
+	tracker, err := LoadFlakeTracker(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flaky := []*testresult.Report{
+		report(map[string]testresult.Status{"TestNet": testresult.StatusFail, "TestMath": testresult.StatusPass}),
+		report(map[string]testresult.Status{"TestNet": testresult.StatusPass, "TestMath": testresult.StatusPass}),
+	}
+
+	if q := tracker.Record("polecat/nux", flaky, 2); len(q) != 0 {
+		t.Fatalf("quarantined after one branch: %v", q)
+	}
+	// A second flake on the same branch is not independent evidence
+	if q := tracker.Record("polecat/nux", flaky, 2); len(q) != 0 {
+		t.Fatalf("quarantined after repeat on same branch: %v", q)
+	}
+	q := tracker.Record("polecat/toast", flaky, 2)
+	if len(q) != 1 || q[0] != "TestNet" {
+		t.Fatalf("expected TestNet quarantined, got %v", q)
+	}
+	if !tracker.IsQuarantined("TestNet") || tracker.IsQuarantined("TestMath") {
+		t.Error("unexpected quarantine state")
+	}
+
+	stats := tracker.Stats()
+	if len(stats) != 1 || stats[0].Name != "TestNet" || stats[0].Runs != 3 || stats[0].Flakes != 3 {
+		t.Fatalf("Stats = %+v", stats[0])
+	}
+	if stats[0].FlakeRate() != 1 {
+		t.Errorf("FlakeRate = %v", stats[0].FlakeRate())
+	}
+
+	if err := tracker.Release("TestNet"); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if tracker.IsQuarantined("TestNet") {
+		t.Error("expected TestNet released")
+	}
+	if err := tracker.Release("TestMath"); err == nil {
+		t.Error("expected error releasing a test that is not quarantined")
+	}
+}
+
+// goJSONScript prints go test -json events for TestGood (pass) and
+// TestFlaky, which fails while $1 exists and passes once it is gone. Each
+// failing run deletes the marker, so the first attempt fails and the retry
+// passes.
+const goJSONScript = `marker="$1"
+echo '{"Action":"pass","Package":"app","Test":"TestGood"}'
+if [ -f "$marker" ]; then
+	rm -f "$marker"
+	printf '%s\n' '{"Action":"output","Package":"app","Test":"TestFlaky","Output":"flaky_test.go:7: timeout\n"}'
+	echo '{"Action":"fail","Package":"app","Test":"TestFlaky"}'
+	exit 1
+fi
+echo '{"Action":"pass","Package":"app","Test":"TestFlaky"}'
+`
+
+func newTestEngineer(t *testing.T) (*Engineer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run-tests.sh")
+	if err := os.WriteFile(script, []byte(goJSONScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: dir})
+	e.SetOutput(io.Discard)
+	return e, script
+}
+
+func TestRunTests_ReportsFailingTests(t *testing.T) {
+	e, script := newTestEngineer(t)
+	marker := filepath.Join(t.TempDir(), "fail")
+	e.config.TestCommand = "touch " + marker + " && sh " + script + " " + marker
+	e.config.RetryFlakyTests = 1
+
+	result := e.runTests(context.Background(), "polecat/nux")
+	if result.Success {
+		t.Fatal("expected tests to fail")
+	}
+	if len(result.FailedTests) != 1 || result.FailedTests[0] != "app.TestFlaky" {
+		t.Errorf("FailedTests = %v", result.FailedTests)
+	}
+	if !strings.Contains(result.TestLog, "flaky_test.go:7: timeout") {
+		t.Errorf("TestLog = %q", result.TestLog)
+	}
+	if !strings.Contains(result.Error, "app.TestFlaky") {
+		t.Errorf("Error = %q", result.Error)
+	}
+}
+
+func TestRunTests_UnparseableOutputAttachesLog(t *testing.T) {
+	e, _ := newTestEngineer(t)
+	e.config.TestCommand = "echo compiling; echo 'boom: exit 2'; exit 2"
+
+	result := e.runTests(context.Background(), "polecat/nux")
+	if result.Success || len(result.FailedTests) != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.TestLog != "compiling\nboom: exit 2" {
+		t.Errorf("TestLog = %q", result.TestLog)
+	}
+}
+
+func TestRunTests_QuarantinesFlakyTestAcrossBranches(t *testing.T) {
+	e, script := newTestEngineer(t)
+	marker := filepath.Join(t.TempDir(), "fail")
+	e.config.RetryFlakyTests = 2
+	e.config.QuarantineFlakyAfter = 2
+
+	// Flakes (fail, then pass on retry) on two unrelated branches
+	for _, branch := range []string{"polecat/nux", "polecat/toast"} {
+		if err := os.WriteFile(marker, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		e.config.TestCommand = "sh " + script + " " + marker
+		if result := e.runTests(context.Background(), branch); !result.Success {
+			t.Fatalf("%s: expected retry to pass, got %+v", branch, result)
+		}
+	}
+
+	tracker, err := LoadFlakeTracker(e.rig.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tracker.IsQuarantined("app.TestFlaky") {
+		t.Fatal("expected app.TestFlaky to be quarantined")
+	}
+
+	// Now it fails on every attempt, but it's quarantined: don't bounce the MR
+	e.config.TestCommand = "touch " + marker + " && sh " + script + " " + marker
+	result := e.runTests(context.Background(), "polecat/ace")
+	if !result.Success {
+		t.Fatalf("expected quarantined failure to be ignored, got %+v", result)
+	}
+	if len(result.QuarantinedFailures) != 1 || result.QuarantinedFailures[0] != "app.TestFlaky" {
+		t.Errorf("QuarantinedFailures = %v", result.QuarantinedFailures)
+	}
+
+	// A compile error alongside the quarantined failure still fails the run
+	e.config.TestCommand = "echo '# app/broken' >&2; echo 'broken.go:3:1: syntax error' >&2; " +
+		"touch " + marker + " && sh " + script + " " + marker
+	result = e.runTests(context.Background(), "polecat/ace")
+	if result.Success || !result.TestsFailed {
+		t.Fatalf("expected compile error to fail the run, got %+v", result)
+	}
+	if !strings.Contains(result.TestLog, "syntax error") {
+		t.Errorf("TestLog = %q", result.TestLog)
+	}
+}
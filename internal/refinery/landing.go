@@ -0,0 +1,427 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+)
+
+// Coordinated landing
+//
+// A Lander drives a cross-rig landing group (see mrqueue.LandingGroup)
+// using one Engineer per rig. Each rig's members are stacked onto its
+// target like a merge train and tested; when every rig is green, the tested
+// commits are pushed rig by rig in the group's order. Pushes never force,
+// so a target that moved since testing makes the push fail. If a rig's push
+// fails, the rigs already pushed get revert commits and the group is rolled
+// back. Queue and bead bookkeeping for the MRs happens only once every rig
+// has landed.
+
+// Lander advances landing groups.
+type Lander struct {
+	store     *mrqueue.LandingStore
+	engineers map[string]*Engineer
+	output    io.Writer
+}
+
+// NewLander creates a Lander. engineers maps each rig in the groups it will
+// advance to that rig's Engineer.
+func NewLander(store *mrqueue.LandingStore, engineers map[string]*Engineer) *Lander {
+	return &Lander{store: store, engineers: engineers, output: os.Stdout}
+}
+
+// SetOutput sets the output writer for user-facing messages.
+func (l *Lander) SetOutput(w io.Writer) {
+	l.output = w
+	for _, e := range l.engineers {
+		e.SetOutput(w)
+	}
+}
+
+// Advance moves a group as far as it can go: it picks up newly tagged MRs,
+// tests members whose branch or target moved, and lands the group once
+// every member is green. The group is saved after each step. An error is
+// returned if landing was attempted and rolled back.
+func (l *Lander) Advance(ctx context.Context, g *mrqueue.LandingGroup) error {
+	if g.Done() {
+		return nil
+	}
+	for _, rig := range g.Order {
+		e := l.engineers[rig]
+		if e == nil {
+			return fmt.Errorf("no engineer for rig %s", rig)
+		}
+		if e.config.PRMode {
+			return fmt.Errorf("rig %s is in PR mode; landing groups push to the target branch", rig)
+		}
+	}
+
+	// A landing interrupted part way resumes where it stopped.
+	if g.State == mrqueue.LandingInProgress {
+		return l.land(g)
+	}
+
+	if err := l.syncMembers(g); err != nil {
+		return err
+	}
+	for _, rig := range g.Order {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		l.verifyRig(ctx, g, rig)
+		if err := l.store.Save(g); err != nil {
+			return err
+		}
+	}
+
+	if !g.Ready() {
+		_, _ = fmt.Fprintf(l.output, "[Lander] %s waiting: not every rig is green\n", g.ID)
+		return nil
+	}
+	return l.land(g)
+}
+
+// syncMembers adds MRs newly tagged with the group and drops members whose
+// MR left its queue (rejected or removed by hand).
+func (l *Lander) syncMembers(g *mrqueue.LandingGroup) error {
+	queued := make(map[string]bool)
+	var joined []*mrqueue.LandingMember
+	for _, rig := range g.Order {
+		mrs, err := l.engineers[rig].mrQueue.ListGroup(g.ID)
+		if err != nil {
+			return fmt.Errorf("listing %s queue: %w", rig, err)
+		}
+		for _, mr := range mrs {
+			queued[mr.ID] = true
+			if g.Member(mr.ID) != nil {
+				continue
+			}
+			joined = append(joined, &mrqueue.LandingMember{
+				Rig:         rig,
+				MRID:        mr.ID,
+				Branch:      mr.Branch,
+				Target:      mr.Target,
+				SourceIssue: mr.SourceIssue,
+				State:       mrqueue.MemberPending,
+				UpdatedAt:   time.Now(),
+			})
+			_, _ = fmt.Fprintf(l.output, "[Lander] %s: %s joined (%s)\n", g.ID, mr.ID, rig)
+		}
+	}
+
+	// New members go last within their rig, behind those already tested.
+	var members []*mrqueue.LandingMember
+	for _, m := range g.Members {
+		if queued[m.MRID] {
+			members = append(members, m)
+		} else {
+			_, _ = fmt.Fprintf(l.output, "[Lander] %s: %s left the queue\n", g.ID, m.MRID)
+		}
+	}
+	g.Members = append(members, joined...)
+	g.SortMembers()
+	return l.store.Save(g)
+}
+
+// verifyRig stacks a rig's members onto its target and tests them, unless
+// they were already tested on the current target and branch tips.
+func (l *Lander) verifyRig(ctx context.Context, g *mrqueue.LandingGroup, rig string) {
+	members := g.RigMembers(rig)
+	if len(members) == 0 {
+		return
+	}
+	e := l.engineers[rig]
+
+	target := members[0].Target
+	if target == "" {
+		target = e.config.TargetBranch
+	}
+	for _, m := range members {
+		if m.Target != "" && m.Target != target {
+			setMember(m, mrqueue.MemberRed, fmt.Sprintf("targets %s but %s members target %s", m.Target, rig, target))
+			return
+		}
+	}
+
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		setMember(members[0], mrqueue.MemberRed, fmt.Sprintf("fetching target %s: %v", target, err))
+		return
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		setMember(members[0], mrqueue.MemberRed, fmt.Sprintf("resolving origin/%s: %v", target, err))
+		return
+	}
+
+	heads := make([]string, len(members))
+	stale := false
+	for i, m := range members {
+		if err := e.git.FetchBranch("origin", m.Branch); err == nil {
+			heads[i], _ = e.git.Rev("origin/" + m.Branch)
+		}
+		if m.State == mrqueue.MemberPending || m.Head != heads[i] || m.Base != base {
+			stale = true
+		}
+	}
+	if !stale {
+		return // results still hold, green or red
+	}
+
+	_, _ = fmt.Fprintf(l.output, "[Lander] %s: testing %d MR(s) in %s on %s\n", g.ID, len(members), rig, shortSHA(base))
+	dir := filepath.Join(e.workDir, ".runtime", "landing", g.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		setMember(members[0], mrqueue.MemberRed, fmt.Sprintf("creating landing directory: %v", err))
+		return
+	}
+
+	cars := make([]*TrainCar, 0, len(members))
+	prev := base
+	for i, m := range members {
+		mr, err := e.mrQueue.Get(m.MRID)
+		if err != nil {
+			setMember(m, mrqueue.MemberRed, fmt.Sprintf("loading MR: %v", err))
+			return
+		}
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			_, _ = fmt.Fprintf(l.output, "[Lander] Warning: failed to log merge_started event: %v\n", err)
+		}
+		car := &TrainCar{MR: mr}
+		cars = append(cars, car)
+		path := filepath.Join(dir, fmt.Sprintf("car-%d", i+1))
+		// A rolled-back MR is reapplied by reverting its reverts, since
+		// merging the branch again would be a no-op.
+		e.buildCar(car, path, prev, m.RevertCommits...)
+		if car.Commit == "" {
+			break // later members can't be stacked without this one
+		}
+		prev = car.Commit
+	}
+	defer e.cleanupTrain(cars)
+
+	e.testCars(ctx, cars)
+
+	var culprit string
+	for i, m := range members {
+		m.Head, m.Base = heads[i], base
+		m.Commit = ""
+		switch {
+		case culprit != "":
+			setMember(m, mrqueue.MemberRed, "stacked on failing "+culprit)
+		case i >= len(cars):
+			setMember(m, mrqueue.MemberRed, "not stacked")
+		case cars[i].Result.Success:
+			m.Commit = cars[i].Commit
+			setMember(m, mrqueue.MemberGreen, "")
+		default:
+			car := cars[i]
+			culprit = m.MRID
+			setMember(m, mrqueue.MemberRed, car.Result.Error)
+			if err := e.eventLogger.LogMergeFailed(car.MR, car.Result.Error); err != nil {
+				_, _ = fmt.Fprintf(l.output, "[Lander] Warning: failed to log merge_failed event: %v\n", err)
+			}
+			e.notifyMergeFailed(car)
+		}
+	}
+}
+
+// land pushes each rig's tested commits in order and rolls back on failure.
+func (l *Lander) land(g *mrqueue.LandingGroup) error {
+	g.State = mrqueue.LandingInProgress
+	g.Error = ""
+	if err := l.store.Save(g); err != nil {
+		return err
+	}
+
+	for _, rig := range g.Order {
+		members := g.RigMembers(rig)
+		if len(members) == 0 || members[len(members)-1].State == mrqueue.MemberLanded {
+			continue
+		}
+		e := l.engineers[rig]
+		target := members[0].Target
+		if target == "" {
+			target = e.config.TargetBranch
+		}
+		tip := members[len(members)-1].Commit
+
+		_, _ = fmt.Fprintf(l.output, "[Lander] %s: pushing %s to %s/%s\n", g.ID, shortSHA(tip), rig, target)
+		if err := e.git.Push("origin", tip+":refs/heads/"+target, false); err != nil {
+			reason := fmt.Sprintf("push to %s/%s failed: %v", rig, target, err)
+			if !anyLanded(g) {
+				// Nothing to undo: most likely the target moved, so retest.
+				for _, m := range members {
+					setMember(m, mrqueue.MemberPending, reason)
+				}
+				g.State = mrqueue.LandingWaiting
+				_ = l.store.Save(g) // best-effort: the members are retested either way
+				return fmt.Errorf("landing group %s: %s", g.ID, reason)
+			}
+			for _, m := range members {
+				m.Error = reason
+			}
+			l.rollback(g, reason)
+			return fmt.Errorf("landing group %s rolled back: %s", g.ID, reason)
+		}
+		for _, m := range members {
+			setMember(m, mrqueue.MemberLanded, "")
+		}
+		if err := l.store.Save(g); err != nil {
+			return err
+		}
+	}
+
+	// Every rig landed: now close out the MRs.
+	for _, m := range g.Members {
+		e := l.engineers[m.Rig]
+		mr, err := e.mrQueue.Get(m.MRID)
+		if err != nil {
+			continue // already cleaned up by an interrupted earlier pass
+		}
+		e.handleSuccessFromQueue(mr, ProcessResult{Success: true, MergeCommit: m.Commit})
+	}
+	g.State = mrqueue.LandingLanded
+	_, _ = fmt.Fprintf(l.output, "[Lander] ✓ %s landed in %d rig(s)\n", g.ID, len(g.Order))
+	return l.store.Save(g)
+}
+
+// rollback reverts the landed members, newest first, and marks the group
+// rolled back. Workers are told their MR was backed out.
+func (l *Lander) rollback(g *mrqueue.LandingGroup, reason string) {
+	_, _ = fmt.Fprintf(l.output, "[Lander] ✗ %s: %s; rolling back\n", g.ID, reason)
+
+	var errs []error
+	for i := len(g.Order) - 1; i >= 0; i-- {
+		var landed []*mrqueue.LandingMember
+		for _, m := range g.RigMembers(g.Order[i]) {
+			if m.State == mrqueue.MemberLanded {
+				landed = append(landed, m)
+			}
+		}
+		if len(landed) == 0 {
+			continue
+		}
+		if err := l.revertRig(g, g.Order[i], landed, reason); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	g.State = mrqueue.LandingRolledBack
+	g.Error = reason
+	if err := errors.Join(errs...); err != nil {
+		g.Error += "; " + err.Error()
+	}
+	_ = l.store.Save(g) // best-effort: the caller reports the rollback
+
+	for _, m := range g.Members {
+		e := l.engineers[m.Rig]
+		if mr, err := e.mrQueue.Get(m.MRID); err == nil {
+			e.notifyMergeFailed(&TrainCar{MR: mr, Result: ProcessResult{Error: "landing group " + g.ID + " rolled back: " + reason}})
+		}
+	}
+}
+
+// revertRig pushes revert commits for a rig's landed members.
+func (l *Lander) revertRig(g *mrqueue.LandingGroup, rig string, landed []*mrqueue.LandingMember, reason string) error {
+	e := l.engineers[rig]
+	target := landed[0].Target
+	if target == "" {
+		target = e.config.TargetBranch
+	}
+
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return fmt.Errorf("reverting %s: fetching %s: %w", rig, target, err)
+	}
+	path := filepath.Join(e.workDir, ".runtime", "landing", g.ID, "revert")
+	_ = e.git.WorktreeRemove(path, true)
+	if err := e.git.WorktreeAddDetached(path, "origin/"+target); err != nil {
+		return fmt.Errorf("reverting %s: creating worktree: %w", rig, err)
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(path, true) // best-effort cleanup
+		_ = e.git.WorktreePrune()
+	}()
+
+	// Revert each member's first-parent chain, newest first. A member is
+	// one merge commit, plus reapplied reverts if it was rolled back before.
+	wt := git.NewGit(path)
+	reverts := make([][]string, len(landed))
+	for i := len(landed) - 1; i >= 0; i-- {
+		m := landed[i]
+		prev := m.Base
+		if i > 0 {
+			prev = landed[i-1].Commit
+		}
+		for c := m.Commit; c != prev; {
+			mainline := 1
+			if _, err := wt.Rev(c + "^2"); err != nil {
+				mainline = 0 // not a merge
+			}
+			if err := wt.Revert(c, mainline); err != nil {
+				return fmt.Errorf("reverting %s in %s: %w", m.MRID, rig, err)
+			}
+			revert, _ := wt.Rev("HEAD")
+			reverts[i] = append(reverts[i], revert)
+			parent, err := wt.Rev(c + "^1")
+			if err != nil {
+				return fmt.Errorf("reverting %s in %s: %w", m.MRID, rig, err)
+			}
+			c = parent
+		}
+	}
+
+	head, err := wt.Rev("HEAD")
+	if err != nil {
+		return fmt.Errorf("reverting %s: %w", rig, err)
+	}
+	if err := e.git.Push("origin", head+":refs/heads/"+target, false); err != nil {
+		return fmt.Errorf("reverting %s: pushing to %s: %w", rig, target, err)
+	}
+
+	for i, m := range landed {
+		m.RevertCommits = reverts[i]
+		setMember(m, mrqueue.MemberReverted, reason)
+		if mr, err := e.mrQueue.Get(m.MRID); err == nil {
+			if err := e.eventLogger.LogReverted(mr, m.Commit, reverts[i][len(reverts[i])-1], reason); err != nil {
+				_, _ = fmt.Fprintf(l.output, "[Lander] Warning: failed to log reverted event: %v\n", err)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(l.output, "[Lander] Reverted %d MR(s) in %s/%s\n", len(landed), rig, target)
+	return nil
+}
+
+// Retry resets a rolled-back group so it can land again. Members that were
+// reverted are reapplied on their next test.
+func (l *Lander) Retry(g *mrqueue.LandingGroup) error {
+	if g.State != mrqueue.LandingRolledBack {
+		return fmt.Errorf("landing group %s is %s, not rolled back", g.ID, g.State)
+	}
+	for _, m := range g.Members {
+		setMember(m, mrqueue.MemberPending, "")
+	}
+	g.State = mrqueue.LandingWaiting
+	g.Error = ""
+	return l.store.Save(g)
+}
+
+func anyLanded(g *mrqueue.LandingGroup) bool {
+	for _, m := range g.Members {
+		if m.State == mrqueue.MemberLanded {
+			return true
+		}
+	}
+	return false
+}
+
+func setMember(m *mrqueue.LandingMember, state mrqueue.MemberState, errMsg string) {
+	m.State = state
+	m.Error = errMsg
+	m.UpdatedAt = time.Now()
+}
@@ -0,0 +1,141 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// setupLandingGroup creates rigs "api" and "client", each with one MR for
+// the group, and a Lander over them.
+func setupLandingGroup(t *testing.T) (*Lander, *mrqueue.LandingGroup, map[string]string) {
+	t.Helper()
+	store := mrqueue.NewLandingStore(t.TempDir())
+	engineers := make(map[string]*Engineer)
+	rigPaths := make(map[string]string)
+	for _, name := range []string{"api", "client"} {
+		branch := "polecat/" + name
+		rigPath := setupTrainRig(t, map[string]string{branch: name + ".txt"})
+		e := NewEngineer(&rig.Rig{Name: name, Path: rigPath})
+		e.config.TestCommand = "true"
+		engineers[name] = e
+		rigPaths[name] = rigPath
+
+		mr := &mrqueue.MR{ID: "mr-" + name, Branch: branch, Target: "main", Rig: name, Worker: name, LandingGroup: "hq-cv-1"}
+		if err := mrqueue.New(rigPath).Submit(mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := &mrqueue.LandingGroup{ID: "hq-cv-1", Order: []string{"api", "client"}}
+	if err := store.Create(g); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLander(store, engineers)
+	l.SetOutput(io.Discard)
+	return l, g, rigPaths
+}
+
+// originHas reports whether file exists on origin/main of the rig's repo.
+func originHas(t *testing.T, rigPath, file string) bool {
+	t.Helper()
+	runGit(t, rigPath, "fetch", "-q", "origin", "main")
+	return runGit(t, rigPath, "ls-tree", "--name-only", "origin/main", file) != ""
+}
+
+func TestLander_LandsAllRigsInOrder(t *testing.T) {
+	l, g, rigs := setupLandingGroup(t)
+
+	// Held MRs are not ready for individual processing.
+	if ready, _ := l.engineers["api"].ListReadyMRs(); len(ready) != 0 {
+		t.Fatalf("grouped MR is ready for individual landing: %+v", ready)
+	}
+
+	if err := l.Advance(context.Background(), g); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if g.State != mrqueue.LandingLanded {
+		t.Fatalf("state = %s, want landed (members: %+v)", g.State, g.Members)
+	}
+	for name, rigPath := range rigs {
+		if !originHas(t, rigPath, name+".txt") {
+			t.Errorf("%s: origin/main missing %s.txt", name, name)
+		}
+		if _, err := mrqueue.New(rigPath).Get("mr-" + name); !os.IsNotExist(err) {
+			t.Errorf("%s: MR still queued after landing (err=%v)", name, err)
+		}
+	}
+}
+
+func TestLander_WaitsForEveryRig(t *testing.T) {
+	l, g, rigs := setupLandingGroup(t)
+	l.engineers["client"].config.TestCommand = "false"
+
+	if err := l.Advance(context.Background(), g); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+	if g.State != mrqueue.LandingWaiting {
+		t.Fatalf("state = %s, want waiting", g.State)
+	}
+	if m := g.Member("mr-api"); m == nil || m.State != mrqueue.MemberGreen {
+		t.Errorf("mr-api = %+v, want green", m)
+	}
+	if m := g.Member("mr-client"); m == nil || m.State != mrqueue.MemberRed {
+		t.Errorf("mr-client = %+v, want red", m)
+	}
+	if originHas(t, rigs["api"], "api.txt") {
+		t.Error("api landed while client was red")
+	}
+}
+
+func TestLander_RollsBackAndRetries(t *testing.T) {
+	l, g, rigs := setupLandingGroup(t)
+
+	// Reject pushes to client's origin so its landing fails after api's.
+	origin := runGit(t, rigs["client"], "remote", "get-url", "origin")
+	hook := filepath.Join(origin, "hooks", "pre-receive")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho rejected >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Advance(context.Background(), g); err == nil {
+		t.Fatal("Advance succeeded with client pushes rejected")
+	}
+	if g.State != mrqueue.LandingRolledBack {
+		t.Fatalf("state = %s, want rolled_back", g.State)
+	}
+	api := g.Member("mr-api")
+	if api.State != mrqueue.MemberReverted || len(api.RevertCommits) != 1 {
+		t.Errorf("mr-api = %+v, want reverted once", api)
+	}
+	if originHas(t, rigs["api"], "api.txt") {
+		t.Error("api change still on origin/main after rollback")
+	}
+	if _, err := mrqueue.New(rigs["api"]).Get("mr-api"); err != nil {
+		t.Errorf("rolled-back MR left the queue: %v", err)
+	}
+
+	// Once pushes work again the group lands, reapplying the reverted MR.
+	if err := os.Remove(hook); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Retry(g); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if err := l.Advance(context.Background(), g); err != nil {
+		t.Fatalf("Advance after retry: %v", err)
+	}
+	if g.State != mrqueue.LandingLanded {
+		t.Fatalf("state = %s, want landed", g.State)
+	}
+	for name, rigPath := range rigs {
+		if !originHas(t, rigPath, name+".txt") {
+			t.Errorf("%s: origin/main missing %s.txt after retry", name, name)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/steveyegge/gastown/internal/mrqueue"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/transcript"
 	"github.com/steveyegge/gastown/internal/util"
 )
 
@@ -138,7 +139,7 @@ func (m *Manager) Start(foreground bool) error {
 	running, _ := t.HasSession(sessionID)
 	if running {
 		// Session exists - check if Claude is actually running (healthy vs zombie)
-		if t.IsClaudeRunning(sessionID) {
+		if t.IsAgentRunning(sessionID, m.rig.IsRemote()) {
 			// Healthy - Claude is running
 			return ErrAlreadyRunning
 		}
@@ -190,6 +191,9 @@ func (m *Manager) Start(foreground bool) error {
 	theme := tmux.AssignTheme(m.rig.Name)
 	_ = t.ConfigureGasTownSession(sessionID, theme, m.rig.Name, "refinery", "refinery")
 
+	// Record the session's output for gt transcripts (non-fatal)
+	_ = transcript.Start(t, filepath.Dir(m.rig.Path), sessionID)
+
 	// Update state to running
 	now := time.Now()
 	ref.State = StateRunning
@@ -205,6 +209,12 @@ func (m *Manager) Start(foreground bool) error {
 	// Restarts are handled by daemon via LIFECYCLE mail, not shell loops
 	// Export GT_ROLE and BD_ACTOR in the command since tmux SetEnvironment only affects new panes
 	command := config.BuildAgentStartupCommand("refinery", bdActor, "", "")
+	// Remote rigs run the refinery on their machine behind ssh
+	command, err = m.rig.RemoteCommand(refineryRigDir, command)
+	if err != nil {
+		_ = t.KillSession(sessionID)
+		return fmt.Errorf("preparing remote session: %w", err)
+	}
 	if err := t.SendKeys(sessionID, command); err != nil {
 		// Clean up the session on failure (best-effort cleanup)
 		_ = t.KillSession(sessionID)
@@ -0,0 +1,347 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/forge"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/protocol"
+)
+
+// PR mode
+//
+// With merge_queue.pr_mode the refinery never pushes to the target branch.
+// Each ready MR gets a pull request on the rig's forge, opened on first
+// sight and kept up to date after that. On every pass the refinery reads
+// the PR's reviews and checks:
+//
+//   - reviewers requesting changes: their comments go back to the polecat
+//     as REWORK_REQUEST (once per review) and the MR waits for a new verdict
+//   - a required check failed: MERGE_FAILED goes to the polecat (once per
+//     head commit) and the MR waits for a fixed push
+//   - PR just opened, checks pending or approval missing: the MR waits
+//   - otherwise the PR is merged through the forge API and the MR is
+//     closed exactly as a direct merge would be
+
+// PRAction is what PR mode did with an MR on one pass.
+type PRAction string
+
+const (
+	// PRMerged means the pull request was merged.
+	PRMerged PRAction = "merged"
+	// PRWaiting means checks are pending or approval is missing.
+	PRWaiting PRAction = "waiting"
+	// PRChangesRequested means reviewers requested changes.
+	PRChangesRequested PRAction = "changes_requested"
+	// PRChecksFailed means a required check failed on the head commit.
+	PRChecksFailed PRAction = "checks_failed"
+	// PRFailed means the forge could not be reached or refused the merge.
+	PRFailed PRAction = "failed"
+)
+
+// PRResult is the outcome of syncing one MR with its pull request.
+type PRResult struct {
+	// MR is the merge request.
+	MR *mrqueue.MR `json:"mr"`
+
+	// PR is the forge pull request (nil if it could not be opened).
+	PR *forge.PullRequest `json:"pr,omitempty"`
+
+	// Opened is true when the pull request was created on this pass.
+	Opened bool `json:"opened,omitempty"`
+
+	// Action is what happened.
+	Action PRAction `json:"action"`
+
+	// Checks is the combined state of the required checks.
+	Checks forge.CheckState `json:"checks,omitempty"`
+
+	// FailedChecks names the required checks that failed.
+	FailedChecks []string `json:"failed_checks,omitempty"`
+
+	// ChangesRequested are the reviews still requesting changes.
+	ChangesRequested []forge.Review `json:"changes_requested,omitempty"`
+
+	// Result carries the merge commit on success, or the reason the MR
+	// didn't merge.
+	Result ProcessResult `json:"result"`
+}
+
+// SetForge sets the forge used in PR mode. This is useful for testing.
+func (e *Engineer) SetForge(f forge.Forge) {
+	e.forge = f
+}
+
+// Forge returns the forge used in PR mode, creating it from the
+// merge_queue.forge config and the origin remote on first use.
+func (e *Engineer) Forge() (forge.Forge, error) {
+	if e.forge != nil {
+		return e.forge, nil
+	}
+	remote, _ := e.git.RemoteURL("origin")
+	f, err := forge.New(e.config.Forge, remote)
+	if err != nil {
+		return nil, fmt.Errorf("configuring forge: %w", err)
+	}
+	e.forge = f
+	return f, nil
+}
+
+// SyncPR opens or updates the pull request for mr, then merges it if the
+// reviews and checks allow. Mail and queue bookkeeping are left to the
+// caller (see RunPRs).
+func (e *Engineer) SyncPR(ctx context.Context, mr *mrqueue.MR) *PRResult {
+	res := &PRResult{MR: mr}
+	fail := func(format string, args ...interface{}) *PRResult {
+		res.Action = PRFailed
+		res.Result = ProcessResult{Error: fmt.Sprintf(format, args...)}
+		return res
+	}
+
+	f, err := e.Forge()
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	// Step 1: Open the pull request, or bring its title and body up to date
+	opts := forge.PROptions{Head: mr.Branch, Base: mr.Target, Title: prTitle(mr), Body: prBody(mr)}
+	pr, err := f.FindPR(ctx, mr.Branch, mr.Target)
+	if err != nil {
+		return fail("finding pull request: %v", err)
+	}
+	switch {
+	case pr == nil:
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Opening pull request %s → %s...\n", mr.Branch, mr.Target)
+		if pr, err = f.CreatePR(ctx, opts); err != nil {
+			return fail("opening pull request: %v", err)
+		}
+		res.Opened = true
+	case pr.Title != opts.Title || pr.Body != opts.Body:
+		if updated, err := f.UpdatePR(ctx, pr.Number, opts); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update PR #%d: %v\n", pr.Number, err)
+		} else {
+			pr = updated
+		}
+	}
+	res.PR = pr
+
+	// A new PR has no checks yet; give CI a pass to register them
+	if res.Opened {
+		res.Action = PRWaiting
+		res.Result = ProcessResult{Error: "waiting for checks to start"}
+		return res
+	}
+
+	// Step 2: Reviews requesting changes hold the merge
+	reviews, err := f.Reviews(ctx, pr.Number)
+	if err != nil {
+		return fail("reading reviews: %v", err)
+	}
+	if changes := forge.ChangesRequested(reviews); len(changes) > 0 {
+		res.Action = PRChangesRequested
+		res.ChangesRequested = changes
+		res.Result = ProcessResult{Error: fmt.Sprintf("%d review(s) requesting changes", len(changes))}
+		return res
+	}
+
+	// Step 3: Required checks must pass
+	checks, err := f.Checks(ctx, pr)
+	if err != nil {
+		return fail("reading checks: %v", err)
+	}
+	var required []string
+	if e.config.Forge != nil {
+		required = e.config.Forge.RequiredChecks
+	}
+	res.Checks, res.FailedChecks = forge.SummarizeChecks(checks, required)
+	switch res.Checks {
+	case forge.CheckFailure:
+		res.Action = PRChecksFailed
+		res.Result = ProcessResult{
+			TestsFailed: true,
+			FailedTests: res.FailedChecks,
+			Error:       fmt.Sprintf("checks failed: %s", strings.Join(res.FailedChecks, ", ")),
+		}
+		return res
+	case forge.CheckPending:
+		res.Action = PRWaiting
+		res.Result = ProcessResult{Error: "waiting for checks"}
+		return res
+	}
+
+	// Step 4: Approval, if required
+	if e.config.Forge != nil && e.config.Forge.RequireApproval && !forge.Approved(reviews) {
+		res.Action = PRWaiting
+		res.Result = ProcessResult{Error: "waiting for approval"}
+		return res
+	}
+
+	// Step 5: Merge via the forge
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Merging PR #%d (%s)...\n", pr.Number, e.config.Forge.Method())
+	sha, err := f.Merge(ctx, pr, e.config.Forge.Method())
+	if err != nil {
+		return fail("merging PR #%d: %v", pr.Number, err)
+	}
+	res.Action = PRMerged
+	res.Result = ProcessResult{Success: true, MergeCommit: sha}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Successfully merged PR #%d: %s\n", pr.Number, shortSHA(sha))
+	return res
+}
+
+// RunPRs syncs every ready MR with its pull request and applies the
+// outcome: merged MRs are closed, review comments and check failures are
+// sent back to the worker once each, and waiting MRs are released for the
+// next pass.
+func (e *Engineer) RunPRs(ctx context.Context, workerID string) ([]*PRResult, error) {
+	if !e.config.PRMode {
+		return nil, fmt.Errorf("PR mode is not enabled (merge_queue.pr_mode)")
+	}
+	if _, err := e.Forge(); err != nil {
+		return nil, err
+	}
+
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return nil, fmt.Errorf("listing ready MRs: %w", err)
+	}
+
+	var results []*PRResult
+	for _, mr := range ready {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if err := e.mrQueue.Claim(mr.ID, workerID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Skipping %s: %v\n", mr.ID, err)
+			continue
+		}
+		res := e.SyncPR(ctx, mr)
+		e.applyPRResult(res)
+		results = append(results, res)
+	}
+	if len(results) == 0 {
+		return nil, ErrNoQueue
+	}
+	return results, nil
+}
+
+// applyPRResult records the outcome of a PR sync in the queue, the event
+// log and the worker's mail.
+func (e *Engineer) applyPRResult(res *PRResult) {
+	mr := res.MR
+	if res.Opened {
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
+		}
+	}
+	if res.Action == PRMerged {
+		e.handleSuccessFromQueue(mr, res.Result)
+		return
+	}
+
+	notified := mr.PRNotified
+	seen := make(map[string]bool, len(notified))
+	for _, key := range notified {
+		seen[key] = true
+	}
+
+	switch res.Action {
+	case PRChangesRequested:
+		var fresh []forge.Review
+		for _, r := range res.ChangesRequested {
+			if key := "review:" + r.ID; !seen[key] {
+				fresh = append(fresh, r)
+				notified = append(notified, key)
+			}
+		}
+		if len(fresh) > 0 {
+			e.notifyReviewRework(mr, res.PR, fresh)
+		}
+	case PRChecksFailed, PRFailed:
+		if res.PR == nil {
+			break // forge unreachable; nothing for the worker to fix
+		}
+		key := "checks:" + res.PR.HeadSHA
+		failureType := "checks"
+		if res.Action == PRFailed {
+			key, failureType = "merge:"+res.PR.HeadSHA, "merge"
+		}
+		if !seen[key] {
+			notified = append(notified, key)
+			if err := e.eventLogger.LogMergeFailed(mr, res.Result.Error); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_failed event: %v\n", err)
+			}
+			e.notifyPRFailed(mr, res.PR, failureType, res.Result.Error)
+		}
+	}
+
+	if res.PR != nil {
+		if err := e.mrQueue.SetPR(mr.ID, res.PR.Number, res.PR.URL, notified); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to record PR for %s: %v\n", mr.ID, err)
+		}
+		mr.PRNumber, mr.PRURL, mr.PRNotified = res.PR.Number, res.PR.URL, notified
+	}
+	_ = e.mrQueue.Release(mr.ID)
+	_, _ = fmt.Fprintf(e.output, "[Engineer] %s: %s\n", mr.ID, res.Result.Error)
+}
+
+// notifyReviewRework sends REWORK_REQUEST with the review comments to the
+// rig's witness, which forwards them to the polecat.
+func (e *Engineer) notifyReviewRework(mr *mrqueue.MR, pr *forge.PullRequest, reviews []forge.Review) {
+	var comments []protocol.ReviewComment
+	for _, r := range reviews {
+		before := len(comments)
+		if body := strings.TrimSpace(r.Body); body != "" {
+			comments = append(comments, protocol.ReviewComment{Author: r.Author, Body: body})
+		}
+		for _, c := range r.Comments {
+			comments = append(comments, protocol.ReviewComment{Author: c.Author, Path: c.Path, Line: c.Line, Body: c.Body})
+		}
+		if len(comments) == before {
+			comments = append(comments, protocol.ReviewComment{Author: r.Author, Body: "Changes requested (no comment given)"})
+		}
+	}
+
+	msg := protocol.NewReviewReworkMessage(e.rig.Name, mr.Worker, mr.Branch, mr.SourceIssue, mr.Target, pr.URL, comments)
+	if err := mail.NewRouter(e.workDir).Send(msg); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to send REWORK_REQUEST for %s: %v\n", mr.ID, err)
+		return
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Sent %d review comment(s) on PR #%d to %s\n", len(comments), pr.Number, mr.Worker)
+}
+
+// notifyPRFailed sends MERGE_FAILED for a pull request to the rig's witness.
+func (e *Engineer) notifyPRFailed(mr *mrqueue.MR, pr *forge.PullRequest, failureType, errMsg string) {
+	errMsg = fmt.Sprintf("%s (PR %s). Push fixes to %s; the pull request updates automatically.", errMsg, pr.URL, mr.Branch)
+	msg := protocol.NewMergeFailedMessage(e.rig.Name, mr.Worker, mr.Branch, mr.SourceIssue, mr.Target, failureType, errMsg)
+	if err := mail.NewRouter(e.workDir).Send(msg); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to send MERGE_FAILED for %s: %v\n", mr.ID, err)
+	}
+}
+
+// prTitle returns the pull request title for an MR.
+func prTitle(mr *mrqueue.MR) string {
+	if mr.Title != "" {
+		return mr.Title
+	}
+	return "Merge " + mr.Branch
+}
+
+// prBody returns the pull request description for an MR.
+func prBody(mr *mrqueue.MR) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Merge request `%s` from the %s refinery.\n\n", mr.ID, mr.Rig))
+	if mr.SourceIssue != "" {
+		sb.WriteString(fmt.Sprintf("- Issue: %s\n", mr.SourceIssue))
+	}
+	if mr.Worker != "" {
+		sb.WriteString(fmt.Sprintf("- Worker: %s\n", mr.Worker))
+	}
+	if mr.ConvoyID != "" {
+		sb.WriteString(fmt.Sprintf("- Convoy: %s\n", mr.ConvoyID))
+	}
+	sb.WriteString("\nReview comments requesting changes are forwarded to the worker.\n")
+	return sb.String()
+}
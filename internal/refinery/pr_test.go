@@ -0,0 +1,169 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/forge"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// newPREngineer creates an engineer in PR mode backed by a fake forge, with
+// one MR for polecat/nux in the queue.
+func newPREngineer(t *testing.T, cfg *forge.Config) (*Engineer, *forge.Fake, *mrqueue.MR) {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.SetOutput(io.Discard)
+	e.config.PRMode = true
+	e.config.Forge = cfg
+
+	fake := forge.NewFake()
+	fake.HeadSHAs["polecat/nux"] = "sha1"
+	e.SetForge(fake)
+
+	mr := &mrqueue.MR{
+		Branch:      "polecat/nux",
+		Target:      "main",
+		SourceIssue: "gt-123",
+		Worker:      "nux",
+		Rig:         "test-rig",
+		Title:       "Fix the widget",
+		CreatedAt:   time.Now(),
+	}
+	if err := e.mrQueue.Submit(mr); err != nil {
+		t.Fatal(err)
+	}
+	return e, fake, mr
+}
+
+func TestRunPRs_OpensPRAndWaitsForChecks(t *testing.T) {
+	e, fake, mr := newPREngineer(t, &forge.Config{RequiredChecks: []string{"ci"}})
+	ctx := context.Background()
+
+	results, err := e.RunPRs(ctx, "refinery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Opened || results[0].Action != PRWaiting {
+		t.Fatalf("first pass: %+v", results[0])
+	}
+	pr := fake.PR(1)
+	if pr == nil || pr.Head != "polecat/nux" || pr.Base != "main" || pr.Title != "Fix the widget" {
+		t.Fatalf("PR = %+v", pr)
+	}
+
+	stored, err := e.mrQueue.Get(mr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.PRNumber != 1 || stored.PRURL == "" || stored.ClaimedBy != "" {
+		t.Errorf("stored MR = %+v", stored)
+	}
+
+	// Second pass reuses the PR
+	results, err = e.RunPRs(ctx, "refinery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Opened || results[0].PR.Number != 1 {
+		t.Errorf("second pass should reuse PR #1: %+v", results[0])
+	}
+}
+
+func TestRunPRs_MergesWhenChecksPass(t *testing.T) {
+	e, fake, mr := newPREngineer(t, &forge.Config{RequireApproval: true})
+	ctx := context.Background()
+
+	if _, err := e.RunPRs(ctx, "refinery-1"); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetChecks(1, forge.Check{Name: "ci", State: forge.CheckSuccess})
+	results, err := e.RunPRs(ctx, "refinery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != PRWaiting || results[0].Result.Error != "waiting for approval" {
+		t.Fatalf("without approval: %+v", results[0])
+	}
+
+	fake.AddReview(1, forge.Review{ID: "r1", Author: "alice", State: forge.ReviewApproved})
+	results, err = e.RunPRs(ctx, "refinery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != PRMerged || results[0].Result.MergeCommit == "" {
+		t.Fatalf("with approval: %+v", results[0])
+	}
+	if fake.PR(1).State != "merged" {
+		t.Errorf("PR state = %s, want merged", fake.PR(1).State)
+	}
+	if _, err := e.mrQueue.Get(mr.ID); err == nil {
+		t.Error("merged MR should be removed from the queue")
+	}
+}
+
+func TestRunPRs_ReviewCommentsNotifiedOnce(t *testing.T) {
+	e, fake, mr := newPREngineer(t, nil)
+	ctx := context.Background()
+
+	if _, err := e.RunPRs(ctx, "refinery-1"); err != nil {
+		t.Fatal(err)
+	}
+	fake.AddReview(1, forge.Review{
+		ID: "r1", Author: "alice", State: forge.ReviewChangesRequested,
+		Comments: []forge.ReviewComment{{Path: "widget.go", Line: 4, Body: "handle nil"}},
+	})
+
+	for pass := 0; pass < 2; pass++ {
+		results, err := e.RunPRs(ctx, "refinery-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results[0].Action != PRChangesRequested {
+			t.Fatalf("pass %d: %+v", pass, results[0])
+		}
+	}
+
+	stored, err := e.mrQueue.Get(mr.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.PRNotified) != 1 || stored.PRNotified[0] != "review:r1" {
+		t.Errorf("PRNotified = %v, want [review:r1]", stored.PRNotified)
+	}
+	if fake.PR(1).State != "open" {
+		t.Error("PR with changes requested must not be merged")
+	}
+}
+
+func TestRunPRs_FailedChecksRecordedPerHead(t *testing.T) {
+	e, fake, mr := newPREngineer(t, nil)
+	ctx := context.Background()
+
+	if _, err := e.RunPRs(ctx, "refinery-1"); err != nil {
+		t.Fatal(err)
+	}
+	fake.SetChecks(1, forge.Check{Name: "ci", State: forge.CheckFailure})
+	results, err := e.RunPRs(ctx, "refinery-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Action != PRChecksFailed || len(results[0].FailedChecks) != 1 {
+		t.Fatalf("%+v", results[0])
+	}
+
+	stored, _ := e.mrQueue.Get(mr.ID)
+	if len(stored.PRNotified) != 1 || stored.PRNotified[0] != "checks:sha1" {
+		t.Errorf("PRNotified = %v, want [checks:sha1]", stored.PRNotified)
+	}
+}
+
+func TestRunTrain_RefusedInPRMode(t *testing.T) {
+	e, _, _ := newPREngineer(t, nil)
+	if _, err := e.RunTrain(context.Background(), "refinery-1"); err == nil {
+		t.Error("expected RunTrain to refuse in PR mode")
+	}
+}
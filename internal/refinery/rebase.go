@@ -0,0 +1,316 @@
+package refinery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/testresult"
+)
+
+// Auto-rebase
+//
+// With merge_queue.on_conflict = "auto_rebase" a conflicting MR is not
+// assigned back straight away. Its branch is rebased onto the current target
+// in a scratch worktree, and every time the rebase stops each conflicted
+// file must match a resolver (see config.ConflictResolver), otherwise the
+// attempt is abandoned. Resolvers union-merge changelogs, combine go.sum
+// entries, keep one side, or keep the target's version and run a command to
+// regenerate lockfiles and generated code. The rebased branch is tested and,
+// if it passes, force-pushed with a lease on the original tip so the MR
+// merges cleanly next time. Only when a step fails is the MR assigned back.
+
+// RebaseResult is the outcome of an auto-rebase attempt.
+type RebaseResult struct {
+	// Success is true if the rebased branch passed tests and was pushed.
+	Success bool `json:"success"`
+
+	// Commit is the new branch tip.
+	Commit string `json:"commit,omitempty"`
+
+	// Base is the target commit the branch was rebased onto and tested
+	// against.
+	Base string `json:"base,omitempty"`
+
+	// Resolved lists files whose conflicts were resolved automatically.
+	Resolved []string `json:"resolved,omitempty"`
+
+	// Unresolved lists conflicted files that no resolver matched.
+	Unresolved []string `json:"unresolved,omitempty"`
+
+	// Error describes why the attempt failed.
+	Error string `json:"error,omitempty"`
+}
+
+// maxRebaseStops bounds how often one rebase may stop for conflicts.
+const maxRebaseStops = 100
+
+// rebaseDir returns the directory holding auto-rebase worktrees.
+func (e *Engineer) rebaseDir() string {
+	return filepath.Join(e.workDir, ".runtime", "auto-rebase")
+}
+
+// resolvers returns the configured conflict resolvers, or the defaults.
+func (e *Engineer) resolvers() []config.ConflictResolver {
+	if e.config.Resolvers != nil {
+		return e.config.Resolvers
+	}
+	return config.DefaultConflictResolvers()
+}
+
+// AutoRebase rebases mr's branch onto its target, resolving conflicts with
+// the configured resolvers, tests the result and force-pushes the branch.
+// The rig clone is left untouched; all work happens in a scratch worktree.
+func (e *Engineer) AutoRebase(ctx context.Context, mr *mrqueue.MR) *RebaseResult {
+	result := &RebaseResult{}
+	fail := func(format string, args ...any) *RebaseResult {
+		result.Error = fmt.Sprintf(format, args...)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Auto-rebase of %s failed: %s\n", mr.ID, result.Error)
+		return result
+	}
+
+	target := mr.Target
+	if target == "" {
+		target = e.config.TargetBranch
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Auto-rebasing %s onto %s...\n", mr.Branch, target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return fail("fetching target %s: %v", target, err)
+	}
+	if err := e.git.FetchBranch("origin", mr.Branch); err != nil {
+		return fail("fetching branch %s: %v", mr.Branch, err)
+	}
+	orig, err := e.git.Rev("origin/" + mr.Branch)
+	if err != nil {
+		return fail("resolving origin/%s: %v", mr.Branch, err)
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		return fail("resolving origin/%s: %v", target, err)
+	}
+	result.Base = base
+
+	if err := os.MkdirAll(e.rebaseDir(), 0755); err != nil {
+		return fail("creating rebase directory: %v", err)
+	}
+	path := filepath.Join(e.rebaseDir(), mr.ID)
+	_ = e.git.WorktreeRemove(path, true) // stale worktree from a crashed attempt
+	if err := e.git.WorktreeAddDetached(path, orig); err != nil {
+		return fail("creating worktree: %v", err)
+	}
+	defer func() {
+		_ = e.git.WorktreeRemove(path, true) // best-effort cleanup
+		_ = e.git.WorktreePrune()
+	}()
+
+	wt := git.NewGit(path)
+	rebaseErr := wt.Rebase(base)
+	for stops := 0; rebaseErr != nil; stops++ {
+		if stops >= maxRebaseStops {
+			_ = wt.AbortRebase()
+			return fail("rebase stopped more than %d times", maxRebaseStops)
+		}
+		conflicts, err := wt.ConflictingFiles()
+		if err != nil || len(conflicts) == 0 {
+			_ = wt.AbortRebase()
+			return fail("rebase failed: %v", rebaseErr)
+		}
+		if err := e.resolveConflicts(ctx, wt, conflicts, result); err != nil {
+			_ = wt.AbortRebase()
+			return fail("%v", err)
+		}
+
+		rebaseErr = wt.RebaseContinue()
+		if rebaseErr != nil {
+			// A commit whose changes are all superseded by the target is
+			// empty after resolution; drop it like git would without conflicts.
+			remaining, _ := wt.ConflictingFiles()
+			if dirty, err := wt.HasUncommittedChanges(); err == nil && !dirty && len(remaining) == 0 {
+				rebaseErr = wt.RebaseSkip()
+			}
+		}
+	}
+
+	commit, err := wt.Rev("HEAD")
+	if err != nil {
+		return fail("resolving rebased commit: %v", err)
+	}
+	result.Commit = commit
+	if len(result.Resolved) > 0 {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Resolved conflicts in: %s\n", strings.Join(result.Resolved, ", "))
+	}
+
+	if e.config.RunTests && e.config.TestCommand != "" {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Testing rebased %s...\n", mr.Branch)
+		if res := e.runTestsIn(ctx, path, mr.Branch); !res.Success {
+			return fail("tests failed after rebase: %s", res.Error)
+		}
+	}
+
+	// The lease keeps us from clobbering commits the polecat pushed meanwhile.
+	if err := e.git.PushForceWithLease("origin", commit+":refs/heads/"+mr.Branch, mr.Branch, orig); err != nil {
+		return fail("pushing rebased branch: %v", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✓ Rebased %s onto %s (%s)\n", mr.Branch, target, shortSHA(commit))
+	result.Success = true
+	return result
+}
+
+// resolveConflicts resolves every conflicted file with its resolver and
+// stages the result. Command resolvers run once per rebase stop, after the
+// file-level resolutions.
+func (e *Engineer) resolveConflicts(ctx context.Context, wt *git.Git, files []string, result *RebaseResult) error {
+	resolvers := e.resolvers()
+	var commands []string
+	for _, file := range files {
+		var r *config.ConflictResolver
+		for i := range resolvers {
+			if resolvers[i].Matches(file) {
+				r = &resolvers[i]
+				break
+			}
+		}
+		if r == nil {
+			result.Unresolved = append(result.Unresolved, file)
+			continue
+		}
+		if err := resolveFile(wt, file, r.Strategy); err != nil {
+			return fmt.Errorf("resolving %s (%s): %w", file, r.Strategy, err)
+		}
+		if r.Strategy == config.ResolveCommand && !slices.Contains(commands, r.Command) {
+			commands = append(commands, r.Command)
+		}
+		if !slices.Contains(result.Resolved, file) {
+			result.Resolved = append(result.Resolved, file)
+		}
+	}
+	if len(result.Unresolved) > 0 {
+		return fmt.Errorf("no resolver for %s", strings.Join(result.Unresolved, ", "))
+	}
+
+	for _, command := range commands {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Running resolver: %s\n", command)
+		cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // G204: command is from trusted rig config
+		cmd.Dir = wt.WorkDir()
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("resolver %q: %v\n%s", command, err, testresult.TrimLog(out.String(), 20))
+		}
+	}
+	if len(commands) > 0 {
+		// Regenerated files may lie outside the conflicted set.
+		if err := wt.Add("-u"); err != nil {
+			return fmt.Errorf("staging regenerated files: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolveFile resolves one conflicted file with strategy and stages it.
+// During a rebase "ours" is the target and "theirs" the polecat's commit.
+func resolveFile(wt *git.Git, file, strategy string) error {
+	switch strategy {
+	case config.ResolveTarget, config.ResolveCommand:
+		if err := wt.CheckoutConflictSide(file, "--ours"); err != nil {
+			return err
+		}
+	case config.ResolveBranch:
+		if err := wt.CheckoutConflictSide(file, "--theirs"); err != nil {
+			return err
+		}
+	case config.ResolveUnion, config.ResolveGoSum:
+		merged, err := mergeStages(wt, file, strategy)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(wt.WorkDir(), file), merged, 0644); err != nil { //nolint:gosec // G306: tracked source file
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown strategy %q", strategy)
+	}
+	return wt.Add(file)
+}
+
+// mergeStages combines both sides of a conflicted file: a line-wise union
+// merge against the base, or for go.sum the sorted set of both sides' lines.
+func mergeStages(wt *git.Git, file, strategy string) ([]byte, error) {
+	base, ours, theirs, err := wt.ConflictStages(file)
+	if err != nil {
+		return nil, err
+	}
+	dir := wt.WorkDir()
+	for _, f := range []string{base, ours, theirs} {
+		if f != "" {
+			defer os.Remove(filepath.Join(dir, f))
+		}
+	}
+	if ours == "" || theirs == "" {
+		return nil, fmt.Errorf("deleted on one side")
+	}
+
+	if strategy == config.ResolveGoSum {
+		seen := make(map[string]bool)
+		var lines []string
+		for _, f := range []string{ours, theirs} {
+			data, err := os.ReadFile(filepath.Join(dir, f)) //nolint:gosec // G304: path from git checkout-index
+			if err != nil {
+				return nil, err
+			}
+			for _, line := range strings.Split(string(data), "\n") {
+				if line != "" && !seen[line] {
+					seen[line] = true
+					lines = append(lines, line)
+				}
+			}
+		}
+		sort.Strings(lines)
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	}
+
+	if base == "" {
+		// Added on both sides: merge against an empty base.
+		empty, err := os.CreateTemp(dir, ".merge_base_")
+		if err != nil {
+			return nil, err
+		}
+		_ = empty.Close()
+		defer os.Remove(empty.Name())
+		base, _ = filepath.Rel(dir, empty.Name())
+	}
+	if err := wt.MergeFileUnion(ours, base, theirs); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(dir, ours)) //nolint:gosec // G304: path from git checkout-index
+}
+
+// resolveConflictByRebase tries an auto-rebase for a car that conflicted,
+// when on_conflict is auto_rebase. On success the MR is released to ride
+// again; on failure the reason is appended to the car's error so the
+// assign-back path reports it.
+func (e *Engineer) resolveConflictByRebase(ctx context.Context, car *TrainCar) bool {
+	if !car.Result.Conflict || e.config.OnConflict != config.OnConflictAutoRebase {
+		return false
+	}
+	rb := e.AutoRebase(ctx, car.MR)
+	if !rb.Success {
+		car.Result.Error = fmt.Sprintf("%s (auto-rebase failed: %s)", car.Result.Error, rb.Error)
+		return false
+	}
+	if err := e.eventLogger.LogMergeSkipped(car.MR, "auto-rebased onto "+car.MR.Target+", requeued"); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_skipped event: %v\n", err)
+	}
+	_ = e.mrQueue.Release(car.MR.ID)
+	return true
+}
@@ -0,0 +1,175 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// commitFiles writes files in dir and commits them.
+func commitFiles(t *testing.T, dir, msg string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", msg)
+}
+
+// lockOf renders a fake lockfile: the deps on one line, so any two
+// changes to the deps conflict.
+func lockOf(deps string) string {
+	return strings.ReplaceAll(deps, "\n", ",")
+}
+
+// setupConflictRig creates an origin where main and polecat/nux both
+// changed the same lines of CHANGELOG.md, go.sum and deps.lock, plus
+// README.md on polecat/toast. Returns the rig clone.
+func setupConflictRig(t *testing.T) string {
+	t.Helper()
+	rigPath := setupTrainRig(t, nil)
+	work := filepath.Join(filepath.Dir(rigPath), "work")
+
+	deps := "alpha\nbeta\ngamma\ndelta\nepsilon\n"
+	commitFiles(t, work, "base", map[string]string{
+		"CHANGELOG.md": "# Changelog\n\n## Unreleased\n\n## 1.0\n- first\n",
+		"go.sum":       "example.com/a v1.0.0 h1:a=\n",
+		"deps.txt":     deps,
+		"deps.lock":    lockOf(deps),
+	})
+	runGit(t, work, "push", "origin", "main")
+
+	runGit(t, work, "checkout", "-b", "polecat/nux")
+	nuxDeps := deps + "zeta\n"
+	commitFiles(t, work, "nux work", map[string]string{
+		"CHANGELOG.md": "# Changelog\n\n## Unreleased\n- nux feature\n\n## 1.0\n- first\n",
+		"go.sum":       "example.com/a v1.0.0 h1:a=\nexample.com/n v1.0.0 h1:n=\n",
+		"deps.txt":     nuxDeps,
+		"deps.lock":    lockOf(nuxDeps),
+		"nux.go":       "package nux\n",
+	})
+	runGit(t, work, "push", "origin", "polecat/nux")
+
+	runGit(t, work, "checkout", "-b", "polecat/toast", "main")
+	commitFiles(t, work, "toast work", map[string]string{"README.md": "# Toast\n"})
+	runGit(t, work, "push", "origin", "polecat/toast")
+
+	runGit(t, work, "checkout", "main")
+	mainDeps := "aardvark\n" + deps
+	commitFiles(t, work, "main moves on", map[string]string{
+		"CHANGELOG.md": "# Changelog\n\n## Unreleased\n- main fix\n\n## 1.0\n- first\n",
+		"go.sum":       "example.com/a v1.0.0 h1:a=\nexample.com/m v1.0.0 h1:m=\n",
+		"deps.txt":     mainDeps,
+		"deps.lock":    lockOf(mainDeps),
+		"README.md":    "# Main\n",
+	})
+	runGit(t, work, "push", "origin", "main")
+	return rigPath
+}
+
+func TestAutoRebase_ResolvesCommonConflicts(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(io.Discard)
+	e.config.TestCommand = "test -f nux.go"
+	e.config.Resolvers = append(config.DefaultConflictResolvers(), config.ConflictResolver{
+		Paths:    []string{"*.lock"},
+		Strategy: config.ResolveCommand,
+		Command:  "tr '\\n' , < deps.txt > deps.lock",
+	})
+
+	// An editor from the environment must not block rebase --continue
+	t.Setenv("GIT_EDITOR", "false")
+
+	mr := &mrqueue.MR{ID: "mr-nux", Branch: "polecat/nux", Target: "main"}
+	result := e.AutoRebase(context.Background(), mr)
+	if !result.Success {
+		t.Fatalf("AutoRebase failed: %s", result.Error)
+	}
+	if got := strings.Join(result.Resolved, ","); got != "CHANGELOG.md,deps.lock,go.sum" {
+		t.Errorf("resolved = %s", got)
+	}
+
+	// The rebased branch sits on main and keeps both sides
+	runGit(t, rigPath, "fetch", "origin")
+	if out := runGit(t, rigPath, "merge-base", "--is-ancestor", "origin/main", "origin/polecat/nux"); out != "" {
+		t.Errorf("merge-base output: %s", out)
+	}
+	show := func(file string) string { return runGit(t, rigPath, "show", "origin/polecat/nux:"+file) }
+	if cl := show("CHANGELOG.md"); !strings.Contains(cl, "- main fix") || !strings.Contains(cl, "- nux feature") {
+		t.Errorf("CHANGELOG.md not union-merged:\n%s", cl)
+	}
+	if sum := show("go.sum"); sum != "example.com/a v1.0.0 h1:a=\nexample.com/m v1.0.0 h1:m=\nexample.com/n v1.0.0 h1:n=" {
+		t.Errorf("go.sum = %q", sum)
+	}
+	if lock := show("deps.lock"); lock != "aardvark,alpha,beta,gamma,delta,epsilon,zeta," {
+		t.Errorf("deps.lock not regenerated: %q", lock)
+	}
+
+	entries, _ := os.ReadDir(e.rebaseDir())
+	if len(entries) != 0 {
+		t.Errorf("expected rebase worktree to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestAutoRebase_UnresolvedConflictPushesNothing(t *testing.T) {
+	rigPath := setupConflictRig(t)
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(io.Discard)
+
+	before := runGit(t, rigPath, "ls-remote", "origin", "refs/heads/polecat/toast")
+	result := e.AutoRebase(context.Background(), &mrqueue.MR{ID: "mr-toast", Branch: "polecat/toast", Target: "main"})
+	if result.Success {
+		t.Fatal("AutoRebase succeeded with a README.md conflict")
+	}
+	if len(result.Unresolved) != 1 || result.Unresolved[0] != "README.md" {
+		t.Errorf("unresolved = %v, want [README.md]", result.Unresolved)
+	}
+	if after := runGit(t, rigPath, "ls-remote", "origin", "refs/heads/polecat/toast"); after != before {
+		t.Errorf("branch was pushed: %s -> %s", before, after)
+	}
+}
+
+func TestDoMerge_RetestsWhenTargetMovedAfterRebase(t *testing.T) {
+	for _, moved := range []bool{false, true} {
+		rigPath := setupConflictRig(t)
+		e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+		e.SetOutput(io.Discard)
+		e.config.TestCommand = "test -f nux.go"
+		e.config.Resolvers = append(config.DefaultConflictResolvers(), config.ConflictResolver{
+			Paths:    []string{"*.lock"},
+			Strategy: config.ResolveCommand,
+			Command:  "tr '\\n' , < deps.txt > deps.lock",
+		})
+
+		mr := &mrqueue.MR{ID: "mr-nux", Branch: "polecat/nux", Target: "main"}
+		rb := e.AutoRebase(context.Background(), mr)
+		if !rb.Success || rb.Base == "" {
+			t.Fatalf("AutoRebase = %+v", rb)
+		}
+		if moved {
+			work := filepath.Join(filepath.Dir(rigPath), "work")
+			commitFiles(t, work, "main moves again", map[string]string{"other.txt": "x\n"})
+			runGit(t, work, "push", "origin", "main")
+		}
+
+		marker := filepath.Join(t.TempDir(), "tested")
+		e.config.TestCommand = "touch " + marker
+		result := e.doMerge(context.Background(), mr.Branch, mr.Target, "", rb)
+		if !result.Success {
+			t.Fatalf("moved=%v: doMerge failed: %s", moved, result.Error)
+		}
+		if _, err := os.Stat(marker); (err == nil) != moved {
+			t.Errorf("moved=%v: tests ran = %v", moved, err == nil)
+		}
+	}
+}
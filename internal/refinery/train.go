@@ -0,0 +1,414 @@
+package refinery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/protocol"
+)
+
+// Merge trains
+//
+// A merge train takes the top N ready MRs (by mrqueue score) and stacks them
+// onto successive speculative integration commits:
+//
+//	base ── car1 (base+MR1) ── car2 (car1+MR2) ── car3 (car2+MR3)
+//
+// Each car gets its own scratch worktree and the test command runs in all of
+// them in parallel. Because car i contains MRs 1..i, the first failing car
+// identifies the culprit: everything before it passed with exactly the same
+// history. The target is fast-forwarded to the last passing car, the culprit
+// is ejected back to its worker, and cars behind it are requeued for the
+// next train since their results were computed on top of the culprit.
+
+// TrainCar is one MR riding in a merge train.
+type TrainCar struct {
+	// MR is the merge request this car carries.
+	MR *mrqueue.MR `json:"mr"`
+
+	// Commit is the speculative integration commit (previous car + this MR).
+	// Empty if the MR could not be stacked (e.g., conflicts).
+	Commit string `json:"commit,omitempty"`
+
+	// Worktree is the scratch worktree holding Commit.
+	Worktree string `json:"-"`
+
+	// Result is the outcome of stacking and testing this car.
+	Result ProcessResult `json:"result"`
+}
+
+// TrainResult is the outcome of running a merge train.
+type TrainResult struct {
+	// Target is the branch the train lands on.
+	Target string `json:"target"`
+
+	// Base is the target SHA the train was built on.
+	Base string `json:"base"`
+
+	// Landed are the cars in the longest passing prefix, now on the target.
+	Landed []*TrainCar `json:"landed,omitempty"`
+
+	// Ejected is the first failing car, if any. Its MR goes back to the worker.
+	Ejected *TrainCar `json:"ejected,omitempty"`
+
+	// Unstacked are cars that could not be stacked onto the landed prefix
+	// (conflicts, missing branches). They never affected later cars.
+	Unstacked []*TrainCar `json:"unstacked,omitempty"`
+
+	// Requeued are cars behind the ejected car; they must ride a later train.
+	Requeued []*TrainCar `json:"requeued,omitempty"`
+}
+
+// MergeCommit returns the commit the target was fast-forwarded to,
+// or empty if nothing landed.
+func (r *TrainResult) MergeCommit() string {
+	if len(r.Landed) == 0 {
+		return ""
+	}
+	return r.Landed[len(r.Landed)-1].Commit
+}
+
+// trainDir returns the directory holding merge train worktrees.
+func (e *Engineer) trainDir() string {
+	return filepath.Join(e.workDir, ".runtime", "merge-train")
+}
+
+// commonTarget returns the branch all of mrs land on, defaulting to the
+// configured target, or an error if they disagree.
+func (e *Engineer) commonTarget(mrs []*mrqueue.MR) (string, error) {
+	target := mrs[0].Target
+	if target == "" {
+		target = e.config.TargetBranch
+	}
+	for _, mr := range mrs {
+		if mr.Target != "" && mr.Target != target {
+			return "", fmt.Errorf("MR %s targets %s, expected %s", mr.ID, mr.Target, target)
+		}
+	}
+	return target, nil
+}
+
+// SelectTrain picks the MRs for the next train from a score-ordered ready list.
+// The train is capped at MaxConcurrent and only contains MRs sharing the
+// target of the highest-scored MR, since a train lands on a single branch.
+func (e *Engineer) SelectTrain(ready []*mrqueue.MR) []*mrqueue.MR {
+	return selectByTarget(ready, e.config.MaxConcurrent)
+}
+
+// selectByTarget returns up to limit MRs (at least one) from a score-ordered
+// ready list, keeping only those sharing the target of the first MR.
+func selectByTarget(ready []*mrqueue.MR, limit int) []*mrqueue.MR {
+	if len(ready) == 0 {
+		return nil
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	target := ready[0].Target
+	var selected []*mrqueue.MR
+	for _, mr := range ready {
+		if mr.Target != target {
+			continue
+		}
+		selected = append(selected, mr)
+		if len(selected) >= limit {
+			break
+		}
+	}
+	return selected
+}
+
+// ProcessTrain builds a speculative merge train from mrs, tests every car in
+// parallel and fast-forwards the target to the longest passing prefix.
+// The MRs must share a target branch (see SelectTrain).
+// Queue and bead bookkeeping is left to the caller (see RunTrain).
+func (e *Engineer) ProcessTrain(ctx context.Context, mrs []*mrqueue.MR) (*TrainResult, error) {
+	if len(mrs) == 0 {
+		return nil, ErrNoQueue
+	}
+
+	target, err := e.commonTarget(mrs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrainResult{Target: target}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Fetching %s from origin...\n", target)
+	if err := e.git.FetchBranch("origin", target); err != nil {
+		return nil, fmt.Errorf("fetching target %s: %w", target, err)
+	}
+	base, err := e.git.Rev("origin/" + target)
+	if err != nil {
+		return nil, fmt.Errorf("resolving origin/%s: %w", target, err)
+	}
+	result.Base = base
+
+	if err := os.MkdirAll(e.trainDir(), 0755); err != nil {
+		return nil, fmt.Errorf("creating train directory: %w", err)
+	}
+
+	// Step 1: Stack each MR onto the previous car's commit.
+	cars := make([]*TrainCar, 0, len(mrs))
+	prev := base
+	for i, mr := range mrs {
+		car := &TrainCar{MR: mr}
+		cars = append(cars, car)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Train car %d: %s (%s)\n", i+1, mr.ID, mr.Branch)
+		e.buildCar(car, filepath.Join(e.trainDir(), fmt.Sprintf("car-%d", i+1)), prev)
+		if car.Commit != "" {
+			prev = car.Commit
+		}
+	}
+	defer e.cleanupTrain(cars)
+
+	// Step 2: Test all stacked cars in parallel.
+	e.testCars(ctx, cars)
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("merge train canceled: %w", ctx.Err())
+	}
+
+	// Step 3: Find the longest passing prefix. Everything after the
+	// first failing car was tested on top of it and must be requeued.
+	for _, car := range cars {
+		switch {
+		case result.Ejected != nil:
+			result.Requeued = append(result.Requeued, car)
+		case car.Commit == "":
+			result.Unstacked = append(result.Unstacked, car)
+		case car.Result.Success:
+			result.Landed = append(result.Landed, car)
+		default:
+			result.Ejected = car
+		}
+	}
+
+	if len(result.Landed) == 0 {
+		return result, nil
+	}
+
+	// Step 4: Fast-forward the target to the last passing car.
+	// A non-forced push only succeeds if origin/<target> is still at Base.
+	tip := result.MergeCommit()
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Fast-forwarding origin/%s to %s (%d MRs)...\n", target, shortSHA(tip), len(result.Landed))
+	if err := e.git.Push("origin", tip+":refs/heads/"+target, false); err != nil {
+		// Target moved underneath us: nothing landed, retry on the next train.
+		for _, car := range result.Landed {
+			car.Result = ProcessResult{Error: fmt.Sprintf("push failed: %v", err)}
+		}
+		result.Requeued = append(result.Landed, result.Requeued...)
+		result.Landed = nil
+		return result, fmt.Errorf("pushing train to origin/%s: %w", target, err)
+	}
+
+	for _, car := range result.Landed {
+		car.Result.MergeCommit = car.Commit
+	}
+	return result, nil
+}
+
+// buildCar creates the speculative commit for car on top of prev, in a
+// scratch worktree at path. Any reverts are reverted first, newest first.
+func (e *Engineer) buildCar(car *TrainCar, path, prev string, reverts ...string) {
+	mr := car.MR
+	if err := e.git.FetchBranch("origin", mr.Branch); err != nil {
+		car.Result = ProcessResult{Error: fmt.Sprintf("failed to fetch branch %s: %v", mr.Branch, err)}
+		return
+	}
+
+	_ = e.git.WorktreeRemove(path, true) // stale worktree from a crashed train
+	if err := e.git.WorktreeAddDetached(path, prev); err != nil {
+		car.Result = ProcessResult{Error: fmt.Sprintf("creating worktree: %v", err)}
+		return
+	}
+	car.Worktree = path
+
+	wt := git.NewGit(path)
+	for i := len(reverts) - 1; i >= 0; i-- {
+		if err := wt.Revert(reverts[i], 0); err != nil {
+			car.Result = ProcessResult{Conflict: true, Error: fmt.Sprintf("reapplying %s: %v", shortSHA(reverts[i]), err)}
+			return
+		}
+	}
+	e.mergeCar(wt, car)
+}
+
+// mergeCar merges car's branch into the worktree wt and records the
+// resulting commit. On failure the merge is aborted, leaving wt unchanged.
+// The branch must already be fetched.
+func (e *Engineer) mergeCar(wt *git.Git, car *TrainCar) {
+	mr := car.MR
+	mergeMsg := fmt.Sprintf("Merge %s into %s", mr.Branch, mr.Target)
+	if mr.SourceIssue != "" {
+		mergeMsg = fmt.Sprintf("Merge %s into %s (%s)", mr.Branch, mr.Target, mr.SourceIssue)
+	}
+	if err := wt.MergeNoFF("origin/"+mr.Branch, mergeMsg); err != nil {
+		_ = wt.AbortMerge()
+		if errors.Is(err, git.ErrMergeConflict) {
+			car.Result = ProcessResult{Conflict: true, Error: "merge conflict while stacking"}
+			return
+		}
+		car.Result = ProcessResult{Error: fmt.Sprintf("merge failed: %v", err)}
+		return
+	}
+
+	commit, err := wt.Rev("HEAD")
+	if err != nil {
+		car.Result = ProcessResult{Error: fmt.Sprintf("failed to get speculative commit: %v", err)}
+		return
+	}
+	car.Commit = commit
+}
+
+// testCars runs the test command for every stacked car concurrently.
+// Cars that failed to stack keep their existing result.
+func (e *Engineer) testCars(ctx context.Context, cars []*TrainCar) {
+	runTests := e.config.RunTests && e.config.TestCommand != ""
+
+	// Tests run concurrently, so serialize writes to the shared output.
+	out := e.output
+	e.output = &lockedWriter{w: out}
+	defer func() { e.output = out }()
+
+	var wg sync.WaitGroup
+	for _, car := range cars {
+		if car.Commit == "" {
+			continue
+		}
+		if !runTests {
+			car.Result = ProcessResult{Success: true}
+			continue
+		}
+		wg.Add(1)
+		go func(car *TrainCar) {
+			defer wg.Done()
+			car.Result = e.runTestsIn(ctx, car.Worktree, car.MR.Branch)
+			if car.Result.Success {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Tests passed: %s\n", car.MR.ID)
+			} else {
+				car.Result.TestsFailed = true
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Tests failed: %s\n", car.MR.ID)
+			}
+		}(car)
+	}
+	wg.Wait()
+}
+
+// cleanupTrain removes the scratch worktrees used by a train.
+func (e *Engineer) cleanupTrain(cars []*TrainCar) {
+	for _, car := range cars {
+		if car.Worktree == "" {
+			continue
+		}
+		_ = e.git.WorktreeRemove(car.Worktree, true) // best-effort cleanup
+		car.Worktree = ""
+	}
+	_ = e.git.WorktreePrune()
+}
+
+// RunTrain claims the next train of ready MRs for workerID, processes it and
+// applies the outcome: landed MRs are closed, the culprit is ejected back to
+// its worker with a MERGE_FAILED message, conflicts are auto-rebased or get
+// resolution tasks, and requeued MRs are released for the next train.
+func (e *Engineer) RunTrain(ctx context.Context, workerID string) (*TrainResult, error) {
+	if e.config.PRMode {
+		return nil, fmt.Errorf("merge trains push to the target branch; in PR mode use gt refinery prs")
+	}
+
+	ready, err := e.ListReadyMRs()
+	if err != nil {
+		return nil, fmt.Errorf("listing ready MRs: %w", err)
+	}
+
+	var mrs []*mrqueue.MR
+	for _, mr := range e.SelectTrain(ready) {
+		if err := e.mrQueue.Claim(mr.ID, workerID); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Skipping %s: %v\n", mr.ID, err)
+			continue
+		}
+		if err := e.eventLogger.LogMergeStarted(mr); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to log merge_started event: %v\n", err)
+		}
+		mrs = append(mrs, mr)
+	}
+	if len(mrs) == 0 {
+		return nil, ErrNoQueue
+	}
+
+	result, trainErr := e.ProcessTrain(ctx, mrs)
+	if result == nil {
+		for _, mr := range mrs {
+			_ = e.mrQueue.Release(mr.ID)
+		}
+		return nil, trainErr
+	}
+
+	for _, car := range result.Landed {
+		e.handleSuccessFromQueue(car.MR, car.Result)
+	}
+	for _, car := range result.Unstacked {
+		if e.resolveConflictByRebase(ctx, car) {
+			continue
+		}
+		e.handleFailureFromQueue(car.MR, car.Result)
+		if !car.Result.Conflict {
+			e.notifyMergeFailed(car)
+		}
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+	if car := result.Ejected; car != nil {
+		e.handleFailureFromQueue(car.MR, car.Result)
+		e.notifyMergeFailed(car)
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+	for _, car := range result.Requeued {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Requeued %s for next train\n", car.MR.ID)
+		_ = e.mrQueue.Release(car.MR.ID)
+	}
+
+	return result, trainErr
+}
+
+// notifyMergeFailed sends MERGE_FAILED for an ejected car to the rig's witness,
+// which forwards the failure to the polecat that owns the branch.
+func (e *Engineer) notifyMergeFailed(car *TrainCar) {
+	failureType := "tests"
+	if !car.Result.TestsFailed {
+		failureType = "merge"
+	}
+	msg := protocol.NewTestFailureMessage(e.rig.Name, car.MR.Worker, car.MR.Branch,
+		car.MR.SourceIssue, car.MR.Target, failureType, car.Result.Error,
+		car.Result.FailedTests, car.Result.TestLog)
+	if err := mail.NewRouter(e.workDir).Send(msg); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to send MERGE_FAILED for %s: %v\n", car.MR.ID, err)
+	}
+}
+
+// lockedWriter serializes writes from concurrent train cars.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// shortSHA abbreviates a commit SHA for display.
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,200 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+// runGit runs a git command in dir and fails the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setupTrainRig creates a bare origin with a main branch and one polecat
+// branch per entry in branches (branch -> file to add), plus a rig clone.
+func setupTrainRig(t *testing.T, branches map[string]string) string {
+	t.Helper()
+	tmp := t.TempDir()
+	origin := filepath.Join(tmp, "origin.git")
+	work := filepath.Join(tmp, "work")
+	rigPath := filepath.Join(tmp, "rig")
+
+	runGit(t, tmp, "init", "--bare", "-b", "main", origin)
+	runGit(t, tmp, "clone", origin, work)
+	runGit(t, work, "config", "user.email", "test@test.com")
+	runGit(t, work, "config", "user.name", "Test User")
+	runGit(t, work, "checkout", "-b", "main")
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("# Test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "-m", "initial")
+	runGit(t, work, "push", "origin", "main")
+
+	for branch, file := range branches {
+		runGit(t, work, "checkout", "-b", branch, "main")
+		if err := os.WriteFile(filepath.Join(work, file), []byte(branch+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, work, "add", ".")
+		runGit(t, work, "commit", "-m", "work on "+branch)
+		runGit(t, work, "push", "origin", branch)
+	}
+
+	runGit(t, tmp, "clone", origin, rigPath)
+	runGit(t, rigPath, "config", "user.email", "refinery@test.com")
+	runGit(t, rigPath, "config", "user.name", "Refinery")
+	return rigPath
+}
+
+func TestSelectTrain(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.config.MaxConcurrent = 2
+
+	ready := []*mrqueue.MR{
+		{ID: "mr-1", Target: "main"},
+		{ID: "mr-2", Target: "develop"},
+		{ID: "mr-3", Target: "main"},
+		{ID: "mr-4", Target: "main"},
+	}
+
+	train := e.SelectTrain(ready)
+	if len(train) != 2 {
+		t.Fatalf("expected 2 cars, got %d", len(train))
+	}
+	if train[0].ID != "mr-1" || train[1].ID != "mr-3" {
+		t.Errorf("expected [mr-1 mr-3], got [%s %s]", train[0].ID, train[1].ID)
+	}
+
+	if got := e.SelectTrain(nil); got != nil {
+		t.Errorf("expected nil train for empty queue, got %v", got)
+	}
+}
+
+func TestRunTrain_LandsPassingPrefixAndEjectsCulprit(t *testing.T) {
+	rigPath := setupTrainRig(t, map[string]string{
+		"polecat/nux":   "nux.txt",
+		"polecat/toast": "broken",
+		"polecat/ace":   "ace.txt",
+	})
+
+	r := &rig.Rig{Name: "test-rig", Path: rigPath}
+	e := NewEngineer(r)
+	e.SetOutput(io.Discard)
+	e.config.MergeTrain = true
+	e.config.MaxConcurrent = 3
+	e.config.TestCommand = "test ! -f broken"
+
+	q := mrqueue.New(rigPath)
+	for i, mr := range []*mrqueue.MR{
+		{ID: "mr-nux", Branch: "polecat/nux", Worker: "nux"},
+		{ID: "mr-toast", Branch: "polecat/toast", Worker: "toast"},
+		{ID: "mr-ace", Branch: "polecat/ace", Worker: "ace"},
+	} {
+		mr.Target = "main"
+		mr.Rig = "test-rig"
+		mr.Priority = i // Score order: nux, toast, ace
+		if err := q.Submit(mr); err != nil {
+			t.Fatalf("submit %s: %v", mr.ID, err)
+		}
+	}
+
+	result, err := e.RunTrain(context.Background(), "refinery-1")
+	if err != nil {
+		t.Fatalf("RunTrain: %v", err)
+	}
+
+	if len(result.Landed) != 1 || result.Landed[0].MR.ID != "mr-nux" {
+		t.Fatalf("expected only mr-nux to land, got %+v", result.Landed)
+	}
+	if result.Ejected == nil || result.Ejected.MR.ID != "mr-toast" {
+		t.Fatalf("expected mr-toast to be ejected, got %+v", result.Ejected)
+	}
+	if !result.Ejected.Result.TestsFailed {
+		t.Error("expected ejected car to be marked TestsFailed")
+	}
+	if len(result.Requeued) != 1 || result.Requeued[0].MR.ID != "mr-ace" {
+		t.Fatalf("expected mr-ace to be requeued, got %+v", result.Requeued)
+	}
+
+	// origin/main was fast-forwarded to the landed car
+	originMain := runGit(t, rigPath, "ls-remote", "origin", "refs/heads/main")
+	if !strings.HasPrefix(originMain, result.MergeCommit()) {
+		t.Errorf("origin/main = %q, want %s", originMain, result.MergeCommit())
+	}
+
+	// Landed MR left the queue; ejected and requeued MRs remain unclaimed
+	if _, err := q.Get("mr-nux"); !os.IsNotExist(err) {
+		t.Errorf("expected mr-nux to be removed from queue, got err=%v", err)
+	}
+	for _, id := range []string{"mr-toast", "mr-ace"} {
+		mr, err := q.Get(id)
+		if err != nil {
+			t.Fatalf("expected %s to remain in queue: %v", id, err)
+		}
+		if mr.ClaimedBy != "" {
+			t.Errorf("expected %s to be released, still claimed by %s", id, mr.ClaimedBy)
+		}
+	}
+
+	// Scratch worktrees are cleaned up
+	entries, _ := os.ReadDir(e.trainDir())
+	if len(entries) != 0 {
+		t.Errorf("expected train worktrees to be removed, found %d entries", len(entries))
+	}
+}
+
+func TestProcessNext_MergeTrain(t *testing.T) {
+	rigPath := setupTrainRig(t, map[string]string{
+		"polecat/nux":   "nux.txt",
+		"polecat/toast": "toast.txt",
+	})
+
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: rigPath})
+	e.SetOutput(io.Discard)
+	e.config.MergeTrain = true
+	e.config.MaxConcurrent = 2
+	e.config.TestCommand = "true"
+
+	if _, err := e.ProcessNext(context.Background(), "refinery-1"); err != ErrNoQueue {
+		t.Fatalf("empty queue: err = %v, want ErrNoQueue", err)
+	}
+
+	q := mrqueue.New(rigPath)
+	for _, mr := range []*mrqueue.MR{
+		{ID: "mr-nux", Branch: "polecat/nux", Worker: "nux"},
+		{ID: "mr-toast", Branch: "polecat/toast", Worker: "toast"},
+	} {
+		mr.Target = "main"
+		mr.Rig = "test-rig"
+		if err := q.Submit(mr); err != nil {
+			t.Fatalf("submit %s: %v", mr.ID, err)
+		}
+	}
+
+	result, err := e.ProcessNext(context.Background(), "refinery-1")
+	if err != nil {
+		t.Fatalf("ProcessNext: %v", err)
+	}
+	if result.Mode != "train" || result.Train == nil || result.MR != nil {
+		t.Fatalf("expected a train pass, got %+v", result)
+	}
+	if len(result.Train.Landed) != 2 {
+		t.Errorf("expected both MRs to land in one train, got %+v", result.Train.Landed)
+	}
+}
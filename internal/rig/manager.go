@@ -12,6 +12,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/templates"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -31,6 +32,7 @@ type RigConfig struct {
 	GitURL        string       `json:"git_url"`                  // repository URL
 	LocalRepo     string       `json:"local_repo,omitempty"`     // optional local reference repo
 	DefaultBranch string       `json:"default_branch,omitempty"` // main, master, etc.
+	Machine       string       `json:"machine,omitempty"`        // machine hosting agents (empty = local)
 	CreatedAt     time.Time    `json:"created_at"`               // when rig was created
 	Beads         *BeadsConfig `json:"beads,omitempty"`
 }
@@ -111,6 +113,7 @@ func (m *Manager) loadRig(name string, entry config.RigEntry) (*Rig, error) {
 		GitURL:    entry.GitURL,
 		LocalRepo: entry.LocalRepo,
 		Config:    entry.BeadsConfig,
+		Machine:   entry.Machine,
 	}
 
 	// Scan for polecats
@@ -161,6 +164,7 @@ type AddRigOptions struct {
 	BeadsPrefix   string // Beads issue prefix (defaults to derived from name)
 	LocalRepo     string // Optional local repo for reference clones
 	DefaultBranch string // Default branch (defaults to auto-detected from remote)
+	Machine       string // Optional machine to run agents on (from mayor/machines.json)
 }
 
 func resolveLocalRepo(path, gitURL string) (string, string) {
@@ -235,6 +239,19 @@ func (m *Manager) AddRig(opts AddRigOptions) (*Rig, error) {
 		fmt.Printf("  Warning: %s\n", warn)
 	}
 
+	// Resolve the target machine before touching disk
+	var remote *connection.SSHConnection
+	if opts.Machine == "local" {
+		opts.Machine = ""
+	}
+	if opts.Machine != "" {
+		conn, err := machineConnection(m.townRoot, opts.Machine)
+		if err != nil {
+			return nil, fmt.Errorf("resolving machine: %w", err)
+		}
+		remote = conn
+	}
+
 	// Create container directory
 	if err := os.MkdirAll(rigPath, 0755); err != nil {
 		return nil, fmt.Errorf("creating rig directory: %w", err)
@@ -256,6 +273,7 @@ func (m *Manager) AddRig(opts AddRigOptions) (*Rig, error) {
 		Name:      opts.Name,
 		GitURL:    opts.GitURL,
 		LocalRepo: localRepo,
+		Machine:   opts.Machine,
 		CreatedAt: time.Now(),
 		Beads: &BeadsConfig{
 			Prefix: opts.BeadsPrefix,
@@ -457,10 +475,20 @@ Use crew for your own workspace. Polecats are for batch work dispatch.
 		fmt.Printf("  Warning: Could not create plugin directories: %v\n", err)
 	}
 
+	// Mirror agent workspaces on the remote machine
+	if remote != nil {
+		fmt.Printf("  Provisioning on machine %s...\n", opts.Machine)
+		if err := provisionRemote(remote, m.townRoot, rigPath, opts.GitURL, defaultBranch); err != nil {
+			return nil, fmt.Errorf("provisioning on %s: %w", opts.Machine, err)
+		}
+		fmt.Printf("   ✓ Provisioned %s on %s\n", remote.RemotePath(m.townRoot, rigPath), opts.Machine)
+	}
+
 	// Register in town config
 	m.config.Rigs[opts.Name] = config.RigEntry{
 		GitURL:    opts.GitURL,
 		LocalRepo: localRepo,
+		Machine:   opts.Machine,
 		AddedAt:   time.Now(),
 		BeadsConfig: &config.BeadsConfig{
 			Prefix: opts.BeadsPrefix,
@@ -0,0 +1,201 @@
+package rig
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// Remote rigs keep their full layout (config, beads, mayor clone) in the
+// local town, and mirror the agent workspaces on a registered machine at
+// the same path relative to the machine's town root:
+//
+//	<town>/<rig>/.repo.git        bare clone, source for remote worktrees
+//	<town>/<rig>/refinery/rig     refinery worktree on the default branch
+//	<town>/<rig>/witness          witness home
+//	<town>/<rig>/polecats/<name>  one worktree per polecat
+//
+// Agent tmux sessions stay on the local machine so the daemon, witness
+// patrols and nudges work unchanged; the agent process inside the pane is
+// an ssh session running in the mirrored directory.
+
+// IsRemote reports whether the rig's agents run on another machine.
+func (r *Rig) IsRemote() bool {
+	return r.Machine != "" && r.Machine != "local"
+}
+
+// townRoot returns the town root containing this rig.
+func (r *Rig) townRoot() string {
+	return filepath.Dir(r.Path)
+}
+
+// RemoteConnection returns the SSH connection for the rig's machine.
+// Returns nil for local rigs.
+func (r *Rig) RemoteConnection() (*connection.SSHConnection, error) {
+	if !r.IsRemote() {
+		return nil, nil
+	}
+	return machineConnection(r.townRoot(), r.Machine)
+}
+
+// machineConnection looks up an ssh machine in the town's machine registry.
+func machineConnection(townRoot, machine string) (*connection.SSHConnection, error) {
+	registry, err := connection.NewMachineRegistry(constants.MayorMachinesPath(townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading machine registry: %w", err)
+	}
+	return registry.SSHConnection(machine)
+}
+
+// RemoteCommand prepares an agent session rooted at workDir and returns the
+// command to run in its local tmux pane. For local rigs the command is
+// returned unchanged. For remote rigs the workDir's Claude settings are
+// mirrored to the machine and the command is wrapped to run there over ssh.
+func (r *Rig) RemoteCommand(workDir, command string) (string, error) {
+	conn, err := r.RemoteConnection()
+	if err != nil || conn == nil {
+		return command, err
+	}
+
+	remoteDir := conn.RemotePath(r.townRoot(), workDir)
+	if err := mirrorClaudeSettings(conn, workDir, remoteDir); err != nil {
+		return "", err
+	}
+	return conn.WrapCommand(remoteDir, command), nil
+}
+
+// RemoteCommandForRig is RemoteCommand for callers that know the rig only
+// by name, such as the daemon's restart paths. Rigs missing from the rigs
+// config are treated as local.
+func RemoteCommandForRig(townRoot, rigName, workDir, command string) (string, error) {
+	r, err := rigByName(townRoot, rigName)
+	if err != nil || r == nil {
+		return command, err
+	}
+	return r.RemoteCommand(workDir, command)
+}
+
+// IsRemoteRig reports whether the named rig runs on a remote machine.
+// Rigs missing from the rigs config, or an unreadable config, count as local.
+func IsRemoteRig(townRoot, rigName string) bool {
+	r, err := rigByName(townRoot, rigName)
+	return err == nil && r != nil && r.IsRemote()
+}
+
+// rigByName returns a rig with its machine from the rigs config, or nil if
+// the rig isn't listed.
+func rigByName(townRoot, rigName string) (*Rig, error) {
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading rigs config: %w", err)
+	}
+	entry, ok := rigsConfig.Rigs[rigName]
+	if !ok {
+		return nil, nil
+	}
+	return &Rig{Name: rigName, Path: filepath.Join(townRoot, rigName), Machine: entry.Machine}, nil
+}
+
+// mirrorClaudeSettings copies workDir/.claude/settings.json to the remote
+// workspace so SessionStart hooks fire there too. Missing settings are fine.
+func mirrorClaudeSettings(conn *connection.SSHConnection, workDir, remoteDir string) error {
+	local := connection.NewLocalConnection()
+	data, err := local.ReadFile(filepath.Join(workDir, ".claude", "settings.json"))
+	if err != nil {
+		return nil
+	}
+	remoteClaude := path.Join(remoteDir, ".claude")
+	if err := conn.MkdirAll(remoteClaude, 0755); err != nil {
+		return fmt.Errorf("creating remote settings dir: %w", err)
+	}
+	if err := conn.WriteFile(path.Join(remoteClaude, "settings.json"), data, 0644); err != nil {
+		return fmt.Errorf("mirroring Claude settings: %w", err)
+	}
+	return nil
+}
+
+// remoteGit runs git on the rig's machine and wraps failures with its output.
+func remoteGit(conn *connection.SSHConnection, args ...string) error {
+	out, err := conn.Exec("git", args...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("git on %s: %s", conn.Name(), msg)
+		}
+		return fmt.Errorf("git on %s: %w", conn.Name(), err)
+	}
+	return nil
+}
+
+// AddRemoteWorktree mirrors a local worktree on the rig's machine: the
+// remote bare repo fetches the default branch and a worktree is created at
+// the mirrored path on a new branch of the same name.
+// No-op for local rigs.
+func (r *Rig) AddRemoteWorktree(localPath, branch string) error {
+	conn, err := r.RemoteConnection()
+	if err != nil || conn == nil {
+		return err
+	}
+
+	bare := conn.RemotePath(r.townRoot(), filepath.Join(r.Path, ".repo.git"))
+	remotePath := conn.RemotePath(r.townRoot(), localPath)
+	if err := remoteGit(conn, "--git-dir", bare, "fetch", "origin", r.DefaultBranch()); err != nil {
+		return err
+	}
+	return remoteGit(conn, "--git-dir", bare, "worktree", "add", "-b", branch, remotePath, "FETCH_HEAD")
+}
+
+// RemoveRemoteWorktree removes the mirrored worktree for localPath from the
+// rig's machine. No-op for local rigs.
+func (r *Rig) RemoveRemoteWorktree(localPath string) error {
+	conn, err := r.RemoteConnection()
+	if err != nil || conn == nil {
+		return err
+	}
+
+	bare := conn.RemotePath(r.townRoot(), filepath.Join(r.Path, ".repo.git"))
+	remotePath := conn.RemotePath(r.townRoot(), localPath)
+	if err := remoteGit(conn, "--git-dir", bare, "worktree", "remove", "--force", remotePath); err != nil {
+		// Fall back to deleting the directory, then prune the stale entry
+		if rmErr := conn.RemoveAll(remotePath); rmErr != nil {
+			return rmErr
+		}
+	}
+	_ = remoteGit(conn, "--git-dir", bare, "worktree", "prune")
+	return nil
+}
+
+// provisionRemote creates the rig's agent workspaces on its machine.
+func provisionRemote(conn *connection.SSHConnection, townRoot, rigPath, gitURL, defaultBranch string) error {
+	remoteRig := conn.RemotePath(townRoot, rigPath)
+
+	if exists, err := conn.Exists(remoteRig); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("%s already exists on %s", remoteRig, conn.Name())
+	}
+
+	if err := createRemoteLayout(conn, remoteRig, gitURL, defaultBranch); err != nil {
+		_ = conn.RemoveAll(remoteRig) // best-effort: allow a clean retry
+		return err
+	}
+	return nil
+}
+
+// createRemoteLayout creates the directories, bare clone and refinery worktree.
+func createRemoteLayout(conn *connection.SSHConnection, remoteRig, gitURL, defaultBranch string) error {
+	bare := path.Join(remoteRig, ".repo.git")
+	for _, dir := range []string{remoteRig, path.Join(remoteRig, "refinery"), path.Join(remoteRig, "witness"), path.Join(remoteRig, "polecats")} {
+		if err := conn.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
+		}
+	}
+	if err := remoteGit(conn, "clone", "--bare", gitURL, bare); err != nil {
+		return err
+	}
+	return remoteGit(conn, "--git-dir", bare, "worktree", "add", path.Join(remoteRig, "refinery", "rig"), defaultBranch)
+}
@@ -0,0 +1,185 @@
+package rig
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/connection"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// fakeSSH stands in for ssh: it drops options and the host, then runs the
+// remote command line locally, so the "remote" is just another directory.
+const fakeSSH = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+		-o|-i|-p|-O|-S) shift 2 ;;
+		--) shift; break ;;
+		-*) shift ;;
+		*) break ;;
+	esac
+done
+shift
+[ $# -eq 0 ] && exit 0
+exec sh -c "$1"
+`
+
+func gitCmd(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// setupRemoteRig creates a town with one rig hosted on a fake ssh machine
+// whose town root is a separate temp dir. Returns the rig and remote root.
+func setupRemoteRig(t *testing.T) (*Rig, string) {
+	t.Helper()
+	tmp := t.TempDir()
+	townRoot := filepath.Join(tmp, "town")
+	remoteRoot := filepath.Join(tmp, "remote")
+	origin := filepath.Join(tmp, "origin.git")
+	work := filepath.Join(tmp, "work")
+
+	gitCmd(t, tmp, "init", "--bare", "-b", "main", origin)
+	gitCmd(t, tmp, "clone", origin, work)
+	gitCmd(t, work, "checkout", "-b", "main")
+	if err := os.WriteFile(filepath.Join(work, "README.md"), []byte("# Test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitCmd(t, work, "add", ".")
+	gitCmd(t, work, "-c", "user.email=t@t", "-c", "user.name=T", "commit", "-m", "initial")
+	gitCmd(t, work, "push", "origin", "main")
+
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "ssh"), []byte(fakeSSH), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	registry, err := connection.NewMachineRegistry(constants.MayorMachinesPath(townRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Add(&connection.Machine{Name: "vm", Type: "ssh", Host: "gt@vm", TownPath: remoteRoot}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Rig{Name: "demo", Path: filepath.Join(townRoot, "demo"), GitURL: origin, Machine: "vm"}
+	if err := os.MkdirAll(r.Path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := r.RemoteConnection()
+	if err != nil {
+		t.Fatalf("RemoteConnection: %v", err)
+	}
+	if err := provisionRemote(conn, townRoot, r.Path, origin, "main"); err != nil {
+		t.Fatalf("provisionRemote: %v", err)
+	}
+	return r, remoteRoot
+}
+
+func TestRemoteRig_ProvisionAndWorktrees(t *testing.T) {
+	r, remoteRoot := setupRemoteRig(t)
+	remoteRig := filepath.Join(remoteRoot, "demo")
+
+	if _, err := os.Stat(filepath.Join(remoteRig, "refinery", "rig", "README.md")); err != nil {
+		t.Fatalf("expected remote refinery worktree: %v", err)
+	}
+	for _, dir := range []string{"witness", "polecats"} {
+		if _, err := os.Stat(filepath.Join(remoteRig, dir)); err != nil {
+			t.Errorf("expected remote %s dir: %v", dir, err)
+		}
+	}
+
+	polecatPath := filepath.Join(r.Path, "polecats", "nux")
+	if err := r.AddRemoteWorktree(polecatPath, "polecat/nux-1"); err != nil {
+		t.Fatalf("AddRemoteWorktree: %v", err)
+	}
+	remotePolecat := filepath.Join(remoteRig, "polecats", "nux")
+	if _, err := os.Stat(filepath.Join(remotePolecat, "README.md")); err != nil {
+		t.Fatalf("expected remote polecat worktree: %v", err)
+	}
+
+	if err := r.RemoveRemoteWorktree(polecatPath); err != nil {
+		t.Fatalf("RemoveRemoteWorktree: %v", err)
+	}
+	if _, err := os.Stat(remotePolecat); !os.IsNotExist(err) {
+		t.Errorf("expected remote polecat worktree to be removed")
+	}
+}
+
+func TestRemoteRig_RemoteCommand(t *testing.T) {
+	r, remoteRoot := setupRemoteRig(t)
+	workDir := filepath.Join(r.Path, "witness")
+	if err := os.MkdirAll(filepath.Join(workDir, ".claude"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, ".claude", "settings.json"), []byte(`{"hooks":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command, err := r.RemoteCommand(workDir, "pwd")
+	if err != nil {
+		t.Fatalf("RemoteCommand: %v", err)
+	}
+	out, err := exec.Command("sh", "-c", command).CombinedOutput()
+	if err != nil {
+		t.Fatalf("running %q: %v\n%s", command, err, out)
+	}
+	remoteWitness := filepath.Join(remoteRoot, "demo", "witness")
+	if strings.TrimSpace(string(out)) != remoteWitness {
+		t.Errorf("command ran in %q, want %q", strings.TrimSpace(string(out)), remoteWitness)
+	}
+	if _, err := os.Stat(filepath.Join(remoteWitness, ".claude", "settings.json")); err != nil {
+		t.Errorf("expected Claude settings mirrored to remote: %v", err)
+	}
+
+	// Local rigs pass commands through untouched
+	local := &Rig{Name: "local", Path: r.Path}
+	if got, err := local.RemoteCommand(workDir, "claude"); err != nil || got != "claude" {
+		t.Errorf("local RemoteCommand = %q, %v", got, err)
+	}
+}
+
+func TestRemoteCommandForRig(t *testing.T) {
+	r, remoteRoot := setupRemoteRig(t)
+	townRoot := filepath.Dir(r.Path)
+	rigsConfig := &config.RigsConfig{Version: 1, Rigs: map[string]config.RigEntry{
+		"demo":  {GitURL: r.GitURL, Machine: "vm"},
+		"local": {GitURL: r.GitURL},
+	}}
+	if err := config.SaveRigsConfig(constants.MayorRigsPath(townRoot), rigsConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	command, err := RemoteCommandForRig(townRoot, "demo", filepath.Join(r.Path, "witness"), "pwd")
+	if err != nil {
+		t.Fatalf("RemoteCommandForRig: %v", err)
+	}
+	out, err := exec.Command("sh", "-c", command).CombinedOutput()
+	if err != nil {
+		t.Fatalf("running %q: %v\n%s", command, err, out)
+	}
+	if got, want := strings.TrimSpace(string(out)), filepath.Join(remoteRoot, "demo", "witness"); got != want {
+		t.Errorf("command ran in %q, want %q", got, want)
+	}
+
+	for _, name := range []string{"local", "unregistered"} {
+		if got, err := RemoteCommandForRig(townRoot, name, r.Path, "claude"); err != nil || got != "claude" {
+			t.Errorf("%s: RemoteCommandForRig = %q, %v", name, got, err)
+		}
+		if IsRemoteRig(townRoot, name) {
+			t.Errorf("%s: IsRemoteRig = true", name)
+		}
+	}
+	if !IsRemoteRig(townRoot, "demo") {
+		t.Error("demo: IsRemoteRig = false")
+	}
+}
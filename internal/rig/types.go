@@ -22,6 +22,10 @@ type Rig struct {
 	// Config is the rig-level configuration.
 	Config *config.BeadsConfig `json:"config,omitempty"`
 
+	// Machine is the registered machine the rig's agents run on.
+	// Empty means the local machine.
+	Machine string `json:"machine,omitempty"`
+
 	// Polecats is the list of polecat names in this rig.
 	Polecats []string `json:"polecats,omitempty"`
 
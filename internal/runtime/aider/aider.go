@@ -0,0 +1,50 @@
+// Package aider implements the AgentRuntime for Aider.
+package aider
+
+import (
+	"regexp"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/runtime/generic"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+var (
+	// readyPattern matches Aider's prompt: "> ", or the chat mode before
+	// it ("ask> ", "architect> ").
+	readyPattern = regexp.MustCompile(`^\w*> ?$`)
+
+	// costPattern matches the session total in Aider's per-message report:
+	// "Tokens: 12k sent, 310 received. Cost: $0.02 message, $0.41 session."
+	costPattern = regexp.MustCompile(`\$(\d+(?:\.\d+)?) session`)
+)
+
+// Spec returns the Aider runtime spec.
+//
+// Aider has no session IDs; it keeps chat history in the working
+// directory, so resume restores that history.
+func Spec() generic.Spec {
+	command := config.RuntimeConfigFromPreset(config.AgentAider).BuildCommand()
+	return generic.Spec{
+		Name:         "aider",
+		Command:      command,
+		ProcessNames: []string{"aider", "python", "python3"},
+		ReadyPattern: readyPattern,
+		Delivery:     runtime.DeliveryTmux,
+		ResumeCommand: func(string) string {
+			return command + " --restore-chat-history"
+		},
+		CostPattern: costPattern,
+		ExitCommand: "/exit",
+	}
+}
+
+// New returns an Aider runtime adapter bound to a tmux instance.
+func New(t *tmux.Tmux) *generic.Runtime {
+	return generic.New(t, Spec())
+}
+
+func init() {
+	generic.Register(Spec())
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/costs"
 	"github.com/steveyegge/gastown/internal/runtime"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -137,6 +138,18 @@ func (r *Runtime) ListSessions(ctx context.Context, filter runtime.SessionFilter
 	return handles, nil
 }
 
+// Cost returns the session cost from Claude's status area.
+func (r *Runtime) Cost(ctx context.Context, handle runtime.SessionHandle) (float64, error) {
+	if r.tmux == nil {
+		return 0, errors.New("claude runtime requires tmux")
+	}
+	content, err := r.tmux.CapturePaneAll(handle.SessionID)
+	if err != nil {
+		return 0, err
+	}
+	return costs.Extract(content), nil
+}
+
 // WaitForClaudeReady polls until Claude's prompt indicator appears in the pane.
 // Claude is ready when we see "> " at the start of a line (the input prompt).
 // This is more reliable than just checking if node is running.
@@ -0,0 +1,41 @@
+// Package gemini implements the AgentRuntime for Gemini CLI.
+package gemini
+
+import (
+	"regexp"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/runtime/generic"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// readyPattern matches Gemini CLI's input box: the placeholder shown while
+// it is empty, or the "│ > " prompt once something is typed.
+var readyPattern = regexp.MustCompile(`Type your message|^\s*│\s*>\s`)
+
+// Spec returns the Gemini CLI runtime spec.
+//
+// Gemini CLI reports tokens but no cost, so Cost is always 0.
+func Spec() generic.Spec {
+	return generic.Spec{
+		Name:         "gemini",
+		Command:      config.RuntimeConfigFromPreset(config.AgentGemini).BuildCommand(),
+		ProcessNames: []string{"gemini", "node"},
+		ReadyPattern: readyPattern,
+		Delivery:     runtime.DeliveryTmux,
+		ResumeCommand: func(sessionID string) string {
+			return config.BuildResumeCommand("gemini", sessionID)
+		},
+		ExitCommand: "/quit",
+	}
+}
+
+// New returns a Gemini CLI runtime adapter bound to a tmux instance.
+func New(t *tmux.Tmux) *generic.Runtime {
+	return generic.New(t, Spec())
+}
+
+func init() {
+	generic.Register(Spec())
+}
@@ -0,0 +1,176 @@
+package generic
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+var (
+	builtinsMu sync.RWMutex
+	builtins   = make(map[string]Spec)
+)
+
+// Register registers spec as a runtime adapter under spec.Name. Built-in
+// adapters call it from init so configured entries can override them.
+func Register(spec Spec) {
+	builtinsMu.Lock()
+	builtins[spec.Name] = spec
+	builtinsMu.Unlock()
+	register(spec)
+}
+
+// Builtin returns the spec registered under name by Register.
+func Builtin(name string) (Spec, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	spec, ok := builtins[name]
+	return spec, ok
+}
+
+func register(spec Spec) {
+	runtime.Register(spec.Name, func(t *tmux.Tmux) runtime.AgentRuntime {
+		return New(t, spec)
+	})
+}
+
+// RegisterConfigured registers the runtimes declared in a runtime registry
+// config file (see config.RuntimeRegistryPath). Entries named after a
+// built-in generic adapter override its defaults. A missing file is not an
+// error.
+func RegisterConfigured(path string) error {
+	cfg, err := config.LoadRuntimeRegistryConfig(path)
+	if err != nil {
+		if errors.Is(err, config.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for name, adapter := range cfg.Runtimes {
+		base, ok := Builtin(name)
+		if !ok && isRegistered(name) {
+			errs = append(errs, fmt.Errorf("runtime %s: built-in adapter is not configurable", name))
+			continue
+		}
+		spec, err := SpecFromConfig(name, adapter, base)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("runtime %s: %w", name, err))
+			continue
+		}
+		register(spec)
+	}
+	return errors.Join(errs...)
+}
+
+// isRegistered reports whether a runtime adapter named name is registered.
+func isRegistered(name string) bool {
+	return slices.Contains(runtime.Names(), name)
+}
+
+// SpecFromConfig builds the spec for a configured runtime. Fields set in
+// cfg override base; a runtime without a base needs at least Bin.
+func SpecFromConfig(name string, cfg config.RuntimeAdapterConfig, base Spec) (Spec, error) {
+	spec := base
+	spec.Name = name
+
+	if cfg.Bin != "" {
+		spec.Command = strings.Join(append([]string{cfg.Bin}, cfg.Args...), " ")
+		if len(cfg.ProcessNames) == 0 {
+			spec.ProcessNames = []string{filepath.Base(cfg.Bin)}
+		}
+	} else if len(cfg.Args) > 0 {
+		return Spec{}, errors.New("args need bin")
+	}
+	if spec.Command == "" {
+		return Spec{}, errors.New("bin is required")
+	}
+	if len(cfg.ProcessNames) > 0 {
+		spec.ProcessNames = cfg.ProcessNames
+	}
+
+	switch cfg.Delivery {
+	case "":
+	case runtime.DeliveryTmux, runtime.DeliveryStdin:
+		spec.Delivery = cfg.Delivery
+	default:
+		return Spec{}, fmt.Errorf("invalid delivery %q (want %s or %s)", cfg.Delivery, runtime.DeliveryTmux, runtime.DeliveryStdin)
+	}
+
+	if cfg.ReadyPattern != "" {
+		re, err := regexp.Compile(cfg.ReadyPattern)
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid ready_pattern: %w", err)
+		}
+		spec.ReadyPattern = re
+	}
+	switch cfg.Readiness {
+	case "":
+	case runtime.ReadinessPrompt:
+		if spec.ReadyPattern == nil {
+			return Spec{}, errors.New("readiness prompt needs ready_pattern")
+		}
+	case runtime.ReadinessWarmup:
+		spec.ReadyPattern = nil
+	default:
+		return Spec{}, fmt.Errorf("invalid readiness %q (want %s or %s)", cfg.Readiness, runtime.ReadinessPrompt, runtime.ReadinessWarmup)
+	}
+
+	var err error
+	if spec.ReadyTimeout, err = parseDuration("ready_timeout", cfg.ReadyTimeout, spec.ReadyTimeout); err != nil {
+		return Spec{}, err
+	}
+	if spec.Warmup, err = parseDuration("warmup", cfg.Warmup, spec.Warmup); err != nil {
+		return Spec{}, err
+	}
+
+	if cfg.ResumeCommand != "" {
+		template := cfg.ResumeCommand
+		spec.ResumeCommand = func(sessionID string) string {
+			if strings.Contains(template, "{session_id}") {
+				if sessionID == "" {
+					return ""
+				}
+				return strings.ReplaceAll(template, "{session_id}", sessionID)
+			}
+			return template
+		}
+	}
+
+	if cfg.CostPattern != "" {
+		re, err := regexp.Compile(cfg.CostPattern)
+		if err != nil {
+			return Spec{}, fmt.Errorf("invalid cost_pattern: %w", err)
+		}
+		if re.NumSubexp() < 1 {
+			return Spec{}, errors.New("cost_pattern needs a group for the amount")
+		}
+		spec.CostPattern = re
+	}
+
+	if cfg.ExitCommand != "" {
+		spec.ExitCommand = cfg.ExitCommand
+	}
+	return spec, nil
+}
+
+func parseDuration(field, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", field, value)
+	}
+	return d, nil
+}
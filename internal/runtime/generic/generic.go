@@ -0,0 +1,456 @@
+// Package generic implements an AgentRuntime for CLI agents that run in a
+// tmux pane, driven by a declarative Spec.
+//
+// Readiness is detected by matching a prompt pattern against the last lines
+// of the pane, or by a fixed warmup for agents without a recognizable
+// prompt. Cost is read from the pane with a pattern too. The codex, gemini
+// and aider adapters are Specs; other agents are declared in the runtime
+// registry config (~/.gastown/runtimes.json), see RegisterConfigured.
+package generic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// EnvRuntime is the tmux session variable naming the session's runtime.
+// ListSessions uses it to find sessions whose agent has exited.
+const EnvRuntime = "GT_RUNTIME"
+
+const (
+	// defaultReadyTimeout bounds the wait for the prompt pattern.
+	defaultReadyTimeout = constants.ClaudeStartTimeout
+
+	// defaultWarmup is the wait after start for agents without a prompt pattern.
+	defaultWarmup = 5 * time.Second
+
+	// promptLines is how many trailing non-empty pane lines are searched
+	// for the prompt pattern. TUIs draw status lines below the input box.
+	promptLines = 8
+)
+
+// Spec describes how to drive a CLI agent.
+type Spec struct {
+	// Name is the runtime name, as registered.
+	Name string
+
+	// Command is the command line used when StartOptions.Command is empty.
+	Command string
+
+	// ProcessNames are the pane commands that mean the agent is running.
+	// Empty means any non-shell command.
+	ProcessNames []string
+
+	// ReadyPattern matches the agent's input prompt. Nil means readiness
+	// is a fixed Warmup after the agent process starts. Pane lines have
+	// trailing spaces stripped, so patterns shouldn't end with one.
+	ReadyPattern *regexp.Regexp
+
+	// ReadyTimeout bounds the wait for ReadyPattern (default 60s).
+	ReadyTimeout time.Duration
+
+	// Warmup is the wait used when ReadyPattern is nil (default 5s).
+	Warmup time.Duration
+
+	// Delivery is the default message delivery: runtime.DeliveryTmux
+	// (paste, pause, Enter) or runtime.DeliveryStdin (typed with Enter).
+	Delivery string
+
+	// ResumeCommand returns the command line that resumes sessionID, or ""
+	// if it can't. Nil means the agent can't resume.
+	ResumeCommand func(sessionID string) string
+
+	// CostPattern's first group is the session cost in USD. Nil means the
+	// agent doesn't report cost.
+	CostPattern *regexp.Regexp
+
+	// ExitCommand asks the agent to quit before Stop kills the session.
+	ExitCommand string
+}
+
+// Runtime is an AgentRuntime driven by a Spec.
+type Runtime struct {
+	tmux *tmux.Tmux
+	spec Spec
+}
+
+// New returns a runtime adapter for spec bound to a tmux instance.
+func New(t *tmux.Tmux, spec Spec) *Runtime {
+	return &Runtime{tmux: t, spec: spec}
+}
+
+// Spec returns the adapter's spec.
+func (r *Runtime) Spec() Spec {
+	return r.spec
+}
+
+// ReadinessMode returns runtime.ReadinessPrompt or runtime.ReadinessWarmup.
+func (r *Runtime) ReadinessMode() string {
+	if r.spec.ReadyPattern != nil {
+		return runtime.ReadinessPrompt
+	}
+	return runtime.ReadinessWarmup
+}
+
+// Start runs the agent in an existing tmux session and waits until it is
+// ready for input. An agent that is still starting when the wait times out
+// is not an error (poll IsReady); one that exited is.
+func (r *Runtime) Start(ctx context.Context, opts runtime.StartOptions) (runtime.SessionHandle, error) {
+	if err := r.check(opts.SessionID); err != nil {
+		return runtime.SessionHandle{}, err
+	}
+	command := opts.Command
+	if command == "" {
+		command = r.spec.Command
+	}
+	if command == "" {
+		return runtime.SessionHandle{}, fmt.Errorf("%s runtime requires command", r.spec.Name)
+	}
+
+	// Tag the session so ListSessions finds it after the agent exits (non-fatal)
+	_ = r.tmux.SetEnvironment(opts.SessionID, EnvRuntime, r.spec.Name)
+
+	if err := r.tmux.SendKeys(opts.SessionID, command); err != nil {
+		return runtime.SessionHandle{}, err
+	}
+	handle := runtime.SessionHandle{
+		Runtime:   r.spec.Name,
+		SessionID: opts.SessionID,
+		WorkDir:   opts.WorkDir,
+		StartedAt: time.Now(),
+	}
+
+	if err := r.startAndWait(ctx, handle); err != nil {
+		return handle, err
+	}
+	if r.atPrompt(handle.SessionID) || r.spec.ReadyPattern == nil {
+		handle.ReadyAt = time.Now()
+	}
+
+	if opts.InitialPrompt != "" {
+		if err := r.SendMessage(ctx, handle, runtime.Message{Text: opts.InitialPrompt}); err != nil {
+			return handle, fmt.Errorf("sending initial prompt: %w", err)
+		}
+	}
+	return handle, nil
+}
+
+// Resume restarts the agent on its previous session in a session whose
+// agent has exited. It is a no-op if the agent is still running.
+func (r *Runtime) Resume(ctx context.Context, handle runtime.SessionHandle) error {
+	if err := r.check(handle.SessionID); err != nil {
+		return err
+	}
+	if r.spec.ResumeCommand == nil {
+		return fmt.Errorf("%s runtime does not support resume", r.spec.Name)
+	}
+
+	exists, err := r.tmux.HasSession(handle.SessionID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s runtime session not found", r.spec.Name)
+	}
+	if running, err := r.DetectRunning(ctx, handle); err == nil && running {
+		return nil
+	}
+
+	workDir := handle.WorkDir
+	if workDir == "" {
+		workDir, _ = r.tmux.GetPaneWorkDir(handle.SessionID)
+	}
+	resumeCmd := r.spec.ResumeCommand(ReadSessionID(workDir))
+	if resumeCmd == "" {
+		return fmt.Errorf("%s runtime resume missing session id in %s", r.spec.Name, workDir)
+	}
+
+	if err := r.tmux.WaitForShellReady(handle.SessionID, constants.ShellReadyTimeout); err != nil {
+		return err
+	}
+	if err := r.tmux.SendKeys(handle.SessionID, resumeCmd); err != nil {
+		return err
+	}
+	return r.startAndWait(ctx, handle)
+}
+
+// SendMessage types a message into the agent's input. If msg.Timeout is
+// set, it first waits up to that long for the agent to be at its prompt.
+func (r *Runtime) SendMessage(ctx context.Context, handle runtime.SessionHandle, msg runtime.Message) error {
+	if err := r.check(handle.SessionID); err != nil {
+		return err
+	}
+	if msg.Timeout > 0 && r.spec.ReadyPattern != nil {
+		if err := r.waitForPrompt(ctx, handle.SessionID, msg.Timeout); err != nil {
+			return err
+		}
+	}
+
+	delivery := msg.Delivery
+	if delivery == "" {
+		delivery = r.spec.Delivery
+	}
+	switch delivery {
+	case "", runtime.DeliveryTmux:
+		return r.tmux.NudgeSession(handle.SessionID, msg.Text)
+	case runtime.DeliveryStdin:
+		return r.tmux.SendKeys(handle.SessionID, msg.Text)
+	default:
+		return fmt.Errorf("%s runtime only supports tmux/stdin delivery", r.spec.Name)
+	}
+}
+
+// Stop asks the agent to exit, then kills the session.
+func (r *Runtime) Stop(ctx context.Context, handle runtime.SessionHandle, reason string) error {
+	if err := r.check(handle.SessionID); err != nil {
+		return err
+	}
+	if r.spec.ExitCommand != "" {
+		if running, _ := r.DetectRunning(ctx, handle); running {
+			// Best-effort: the session is killed either way
+			if err := r.tmux.SendKeys(handle.SessionID, r.spec.ExitCommand); err == nil {
+				_ = r.tmux.WaitForShellReady(handle.SessionID, constants.ShellReadyTimeout)
+			}
+		}
+	}
+	return r.tmux.KillSession(handle.SessionID)
+}
+
+// IsReady reports whether the agent is running and, for prompt readiness,
+// showing its prompt.
+func (r *Runtime) IsReady(ctx context.Context, handle runtime.SessionHandle) (bool, error) {
+	running, err := r.DetectRunning(ctx, handle)
+	if err != nil || !running {
+		return false, err
+	}
+	if r.spec.ReadyPattern == nil {
+		return true, nil
+	}
+	return r.atPrompt(handle.SessionID), nil
+}
+
+// DetectRunning reports whether the agent process is in the session's pane.
+func (r *Runtime) DetectRunning(ctx context.Context, handle runtime.SessionHandle) (bool, error) {
+	if err := r.check(handle.SessionID); err != nil {
+		return false, err
+	}
+	exists, err := r.tmux.HasSession(handle.SessionID)
+	if err != nil || !exists {
+		return false, err
+	}
+	cmd, err := r.tmux.GetPaneCommand(handle.SessionID)
+	if err != nil {
+		return false, err
+	}
+	return r.isAgentCommand(cmd), nil
+}
+
+// ListSessions lists sessions started by this runtime, or running its agent.
+func (r *Runtime) ListSessions(ctx context.Context, filter runtime.SessionFilter) ([]runtime.SessionHandle, error) {
+	if r.tmux == nil {
+		return nil, fmt.Errorf("%s runtime requires tmux", r.spec.Name)
+	}
+	if filter.Runtime != "" && filter.Runtime != r.spec.Name {
+		return nil, nil
+	}
+
+	sessions, err := r.tmux.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var handles []runtime.SessionHandle
+	for _, session := range sessions {
+		if session == "" {
+			continue
+		}
+		if !r.owns(session) {
+			continue
+		}
+		workDir, _ := r.tmux.GetPaneWorkDir(session)
+		if filter.WorkDir != "" && workDir != filter.WorkDir && !strings.HasPrefix(workDir, filter.WorkDir+"/") {
+			continue
+		}
+		handles = append(handles, runtime.SessionHandle{
+			Runtime:   r.spec.Name,
+			SessionID: session,
+			WorkDir:   workDir,
+		})
+	}
+	return handles, nil
+}
+
+// Cost returns the last cost the agent printed in the pane.
+func (r *Runtime) Cost(ctx context.Context, handle runtime.SessionHandle) (float64, error) {
+	if err := r.check(handle.SessionID); err != nil {
+		return 0, err
+	}
+	if r.spec.CostPattern == nil {
+		return 0, nil
+	}
+	content, err := r.tmux.CapturePaneAll(handle.SessionID)
+	if err != nil {
+		return 0, err
+	}
+	return ExtractCost(r.spec.CostPattern, content), nil
+}
+
+// ExtractCost returns the first group of the last match of pattern in
+// content as a number, or 0 if there is none.
+func ExtractCost(pattern *regexp.Regexp, content string) float64 {
+	matches := pattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return 0
+	}
+	last := matches[len(matches)-1]
+	if len(last) < 2 {
+		return 0
+	}
+	cost, err := strconv.ParseFloat(strings.ReplaceAll(last[1], ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return cost
+}
+
+// ReadSessionID returns the agent session ID recorded in the workdir's
+// .runtime/session_id, or "".
+func ReadSessionID(workDir string) string {
+	if workDir == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, constants.DirRuntime, "session_id"))
+	if err != nil {
+		return ""
+	}
+	first, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(first)
+}
+
+func (r *Runtime) check(sessionID string) error {
+	if r.tmux == nil {
+		return fmt.Errorf("%s runtime requires tmux", r.spec.Name)
+	}
+	if sessionID == "" {
+		return fmt.Errorf("%s runtime requires session id", r.spec.Name)
+	}
+	return nil
+}
+
+// startAndWait waits for an agent just launched in handle's session to be
+// ready, failing if it exits first.
+func (r *Runtime) startAndWait(ctx context.Context, handle runtime.SessionHandle) error {
+	if r.spec.ReadyPattern == nil {
+		// Non-fatal: the agent might run under a wrapper shell.
+		_ = r.tmux.WaitForCommand(handle.SessionID, constants.SupportedShells, r.readyTimeout())
+		return sleep(ctx, r.warmup())
+	}
+
+	err := r.waitForPrompt(ctx, handle.SessionID, r.readyTimeout())
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	if running, _ := r.DetectRunning(ctx, handle); !running {
+		return fmt.Errorf("%s exited before showing its prompt", r.spec.Name)
+	}
+	// Still starting: callers poll IsReady.
+	return nil
+}
+
+// waitForPrompt polls until the prompt pattern matches or timeout passes.
+func (r *Runtime) waitForPrompt(ctx context.Context, session string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if r.atPrompt(session) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout waiting for %s prompt", r.spec.Name)
+		}
+		if err := sleep(ctx, constants.PollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// atPrompt reports whether the prompt pattern matches one of the pane's
+// last non-empty lines.
+func (r *Runtime) atPrompt(session string) bool {
+	if r.spec.ReadyPattern == nil {
+		return false
+	}
+	lines, err := r.tmux.CapturePaneLines(session, promptLines)
+	if err != nil {
+		return false
+	}
+	checked := 0
+	for i := len(lines) - 1; i >= 0 && checked < promptLines; i-- {
+		line := strings.TrimRight(lines[i], " ")
+		if line == "" {
+			continue
+		}
+		checked++
+		if r.spec.ReadyPattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// owns reports whether session was started by this runtime or runs its agent.
+func (r *Runtime) owns(session string) bool {
+	if name, err := r.tmux.GetEnvironment(session, EnvRuntime); err == nil && name != "" {
+		return name == r.spec.Name
+	}
+	if len(r.spec.ProcessNames) == 0 {
+		return false // Any non-shell command would match
+	}
+	cmd, err := r.tmux.GetPaneCommand(session)
+	return err == nil && r.isAgentCommand(cmd)
+}
+
+func (r *Runtime) isAgentCommand(cmd string) bool {
+	if len(r.spec.ProcessNames) > 0 {
+		return slices.Contains(r.spec.ProcessNames, cmd)
+	}
+	return cmd != "" && !slices.Contains(constants.SupportedShells, cmd)
+}
+
+func (r *Runtime) readyTimeout() time.Duration {
+	if r.spec.ReadyTimeout > 0 {
+		return r.spec.ReadyTimeout
+	}
+	return defaultReadyTimeout
+}
+
+func (r *Runtime) warmup() time.Duration {
+	if r.spec.Warmup > 0 {
+		return r.spec.Warmup
+	}
+	return defaultWarmup
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var _ runtime.AgentRuntime = (*Runtime)(nil)
@@ -0,0 +1,204 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/tmux"
+)
+
+// buildFakeRuntime builds cmd/gt-fake-runtime into a temp dir.
+func buildFakeRuntime(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "gt-fake-runtime")
+	cmd := exec.Command("go", "build", "-o", bin, "github.com/steveyegge/gastown/cmd/gt-fake-runtime")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building fake runtime: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// waitForPane waits until the pane shows text.
+func waitForPane(t *testing.T, tm *tmux.Tmux, session, text string) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if content, _ := tm.CapturePaneAll(session); strings.Contains(content, text) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	content, _ := tm.CapturePaneAll(session)
+	t.Fatalf("pane never showed %q:\n%s", text, content)
+}
+
+func TestRuntime_FakeLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	if testing.Short() {
+		t.Skip("builds and runs the fake runtime")
+	}
+
+	bin := buildFakeRuntime(t)
+	spec, err := SpecFromConfig("fake", config.RuntimeAdapterConfig{
+		Bin:           bin,
+		Args:          []string{"--ready-delay", "500ms"},
+		ReadyPattern:  `^fake>`,
+		ReadyTimeout:  "10s",
+		ResumeCommand: bin + " --resume {session_id}",
+		CostPattern:   `Cost: \$([0-9.]+)`,
+		ExitCommand:   "/exit",
+	}, Spec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tm := tmux.NewTmux()
+	r := New(tm, spec)
+	ctx := context.Background()
+	workDir := t.TempDir()
+	session := fmt.Sprintf("gt-test-fake-%d", os.Getpid())
+	if err := tm.NewSession(session, workDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = tm.KillSession(session) })
+
+	handle, err := r.Start(ctx, runtime.StartOptions{SessionID: session, WorkDir: workDir, InitialPrompt: "hello"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if handle.ReadyAt.IsZero() {
+		t.Error("Start returned before the prompt showed")
+	}
+	waitForPane(t, tm, session, "received: hello")
+
+	if err := r.SendMessage(ctx, handle, runtime.Message{Text: "second", Timeout: 5 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	waitForPane(t, tm, session, "received: second")
+	if err := r.waitForPrompt(ctx, session, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ready, err := r.IsReady(ctx, handle); err != nil || !ready {
+		t.Errorf("IsReady = %v, %v; want true", ready, err)
+	}
+	if cost, err := r.Cost(ctx, handle); err != nil || cost != 0.02 {
+		t.Errorf("Cost = %v, %v; want 0.02", cost, err)
+	}
+
+	sessions, err := r.ListSessions(ctx, runtime.SessionFilter{WorkDir: workDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != session {
+		t.Errorf("ListSessions = %+v, want %s", sessions, session)
+	}
+
+	// The agent quits; Resume picks up its recorded session.
+	sessionID := ReadSessionID(workDir)
+	if sessionID == "" {
+		t.Fatal("fake runtime recorded no session id")
+	}
+	if err := tm.SendKeys(session, "/exit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tm.WaitForShellReady(session, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if running, _ := r.DetectRunning(ctx, handle); running {
+		t.Fatal("DetectRunning = true after the agent exited")
+	}
+	if err := r.Resume(ctx, handle); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	waitForPane(t, tm, session, "resumed session "+sessionID)
+	if running, _ := r.DetectRunning(ctx, handle); !running {
+		t.Error("DetectRunning = false after resume")
+	}
+
+	if err := r.Stop(ctx, handle, "done"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := tm.HasSession(session); exists {
+		t.Error("session still exists after Stop")
+	}
+}
+
+func TestRuntime_StartFailsWhenAgentExits(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+
+	tm := tmux.NewTmux()
+	r := New(tm, Spec{Name: "broken", ReadyPattern: regexp.MustCompile(`^never-ready$`), ReadyTimeout: time.Second})
+	session := fmt.Sprintf("gt-test-broken-%d", os.Getpid())
+	if err := tm.NewSession(session, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = tm.KillSession(session) })
+
+	_, err := r.Start(context.Background(), runtime.StartOptions{SessionID: session, Command: "false"})
+	if err == nil || !strings.Contains(err.Error(), "exited") {
+		t.Errorf("Start = %v, want exited error", err)
+	}
+}
+
+func TestSpecFromConfig(t *testing.T) {
+	base := Spec{Name: "codex", Command: "codex --yolo", ProcessNames: []string{"codex", "node"}, ExitCommand: "/quit"}
+
+	spec, err := SpecFromConfig("codex", config.RuntimeAdapterConfig{Delivery: "stdin", Readiness: "warmup", Warmup: "2s"}, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Command != "codex --yolo" || spec.ExitCommand != "/quit" || len(spec.ProcessNames) != 2 {
+		t.Errorf("override lost base fields: %+v", spec)
+	}
+	if spec.ReadyPattern != nil || spec.Warmup != 2*time.Second || spec.Delivery != runtime.DeliveryStdin {
+		t.Errorf("override not applied: %+v", spec)
+	}
+
+	spec, err = SpecFromConfig("mine", config.RuntimeAdapterConfig{
+		Bin:           "/opt/bin/mine",
+		ResumeCommand: "mine --resume {session_id}",
+		CostPattern:   `spent \$([0-9,.]+)`,
+	}, Spec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.ProcessNames[0] != "mine" {
+		t.Errorf("ProcessNames = %v, want [mine]", spec.ProcessNames)
+	}
+	if got := spec.ResumeCommand("abc"); got != "mine --resume abc" {
+		t.Errorf("ResumeCommand = %q", got)
+	}
+	if got := spec.ResumeCommand(""); got != "" {
+		t.Errorf("ResumeCommand without id = %q, want empty", got)
+	}
+	if got := ExtractCost(spec.CostPattern, "spent $0.50\nspent $1,204.10\n"); got != 1204.10 {
+		t.Errorf("ExtractCost = %v, want last match", got)
+	}
+
+	invalid := []config.RuntimeAdapterConfig{
+		{},
+		{Bin: "x", Readiness: "prompt"},
+		{Bin: "x", Delivery: "rpc"},
+		{Bin: "x", ReadyPattern: "("},
+		{Bin: "x", CostPattern: `\$[0-9]+`},
+		{Bin: "x", Warmup: "soon"},
+	}
+	for _, cfg := range invalid {
+		if _, err := SpecFromConfig("bad", cfg, Spec{}); err == nil {
+			t.Errorf("SpecFromConfig(%+v) succeeded", cfg)
+		}
+	}
+}
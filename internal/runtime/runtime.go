@@ -15,6 +15,9 @@ type AgentRuntime interface {
 	IsReady(ctx context.Context, handle SessionHandle) (bool, error)
 	DetectRunning(ctx context.Context, handle SessionHandle) (bool, error)
 	ListSessions(ctx context.Context, filter SessionFilter) ([]SessionHandle, error)
+	// Cost returns the session's spend so far in USD, as reported by the
+	// agent. Agents that don't report cost return 0.
+	Cost(ctx context.Context, handle SessionHandle) (float64, error)
 }
 
 // StartOptions describes a new runtime session request.
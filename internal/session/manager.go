@@ -15,10 +15,14 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/runtime/generic"
 	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/transcript"
 
+	_ "github.com/steveyegge/gastown/internal/runtime/aider"
 	_ "github.com/steveyegge/gastown/internal/runtime/claude"
 	_ "github.com/steveyegge/gastown/internal/runtime/codex"
+	_ "github.com/steveyegge/gastown/internal/runtime/gemini"
 )
 
 // Common errors
@@ -143,6 +147,20 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 		}
 	}
 
+	// Sandboxed rigs never fall back to starting polecats unconfined
+	sandbox, err := config.LoadRigSandbox(m.rig.Path)
+	if err != nil {
+		return fmt.Errorf("loading sandbox settings: %w", err)
+	}
+	if sandbox.Enabled() {
+		if m.rig.Machine != "" {
+			return fmt.Errorf("rig %s runs on machine %s: sandboxes are only supported for local rigs", m.rig.Name, m.rig.Machine)
+		}
+		if err := sandbox.Check(); err != nil {
+			return err
+		}
+	}
+
 	// Create session
 	if err := m.tmux.NewSession(sessionID, workDir); err != nil {
 		return fmt.Errorf("creating session: %w", err)
@@ -185,6 +203,9 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 	agentID := fmt.Sprintf("%s/%s", m.rig.Name, polecat)
 	_ = m.tmux.SetPaneDiedHook(sessionID, agentID)
 
+	// Record the session's output for gt transcripts (non-fatal)
+	_ = transcript.Start(m.tmux, townRoot, sessionID)
+
 	// Send initial command with env vars exported inline
 	// NOTE: tmux SetEnvironment only affects NEW panes, not the current shell.
 	// We must export GT_ROLE, GT_RIG, GT_POLECAT inline for Claude to detect identity.
@@ -193,6 +214,19 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 		// Polecats run with full permissions - Gas Town is for grownups
 		// Export env vars inline so Claude's role detection works
 		command = config.BuildPolecatStartupCommand(m.rig.Name, polecat, m.rig.Path, "")
+	} else {
+		command = sandbox.WrapCommand(command, m.rig.Path, nil)
+	}
+	// Remote rigs run the agent on their machine behind ssh
+	command, err = m.rig.RemoteCommand(workDir, command)
+	if err != nil {
+		return fmt.Errorf("preparing remote session: %w", err)
+	}
+	// Runtimes declared in ~/.gastown/runtimes.json (non-fatal: built-ins still work)
+	if home, err := os.UserHomeDir(); err == nil {
+		if err := generic.RegisterConfigured(config.RuntimeRegistryPath(home)); err != nil {
+			fmt.Printf("Warning: runtime registry: %v\n", err)
+		}
 	}
 	rt, err := runtime.Get(runtimeName, m.tmux)
 	if err != nil {
@@ -208,18 +242,21 @@ func (m *Manager) Start(polecat string, opts StartOptions) error {
 		return fmt.Errorf("starting runtime: %w", err)
 	}
 
-	// Accept bypass permissions warning dialog if it appears.
-	// When Claude starts with --dangerously-skip-permissions, it shows a warning that
-	// requires pressing Down to select "Yes, I accept" and Enter to confirm.
-	// This is needed for automated polecat startup.
-	_ = m.tmux.AcceptBypassPermissionsWarning(sessionID)
-
-	// Wait for Claude to be fully ready at the prompt (not just started)
-	// PRAGMATIC APPROACH: Use fixed delay rather than detection.
-	// WaitForClaudeReady has false positives (detects > in various contexts).
-	// Claude startup takes ~5-8 seconds on typical machines.
-	// Reduced from 10s to 8s since AcceptBypassPermissionsWarning already adds ~1.2s.
-	time.Sleep(8 * time.Second)
+	if runtimeName == "claude" {
+		// Accept bypass permissions warning dialog if it appears.
+		// When Claude starts with --dangerously-skip-permissions, it shows a warning that
+		// requires pressing Down to select "Yes, I accept" and Enter to confirm.
+		// This is needed for automated polecat startup.
+		_ = m.tmux.AcceptBypassPermissionsWarning(sessionID)
+
+		// Wait for Claude to be fully ready at the prompt (not just started)
+		// PRAGMATIC APPROACH: Use fixed delay rather than detection.
+		// WaitForClaudeReady has false positives (detects > in various contexts).
+		// Claude startup takes ~5-8 seconds on typical machines.
+		// Reduced from 10s to 8s since AcceptBypassPermissionsWarning already adds ~1.2s.
+		// Other runtimes return from Start once their prompt shows.
+		time.Sleep(8 * time.Second)
+	}
 	// Inject startup nudge for predecessor discovery via /resume
 	// This becomes the session title in Claude Code's session picker
 	address := fmt.Sprintf("%s/polecats/%s", m.rig.Name, polecat)
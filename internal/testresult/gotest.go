@@ -0,0 +1,105 @@
+package testresult
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// GoTestJSON parses the event stream written by `go test -json`.
+type GoTestJSON struct{}
+
+// goTestEvent is one line of `go test -json` output (see `go doc test2json`).
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// Name implements Parser.
+func (GoTestJSON) Name() string { return "go-json" }
+
+// Parse implements Parser. Non-JSON lines (e.g., build errors on stderr)
+// are kept as Unexplained. A package that fails without any failing test
+// (build failure, panic in TestMain) is reported as a failing case named
+// after the package, carrying the package output.
+func (GoTestJSON) Parse(output []byte) (*Report, error) {
+	b := newBuilder()
+	pkgOutput := map[string]*bytes.Buffer{}
+	pkgFailed := map[string]bool{}
+	pkgHasFailingTest := map[string]bool{}
+	var pkgOrder []string
+	seen := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var ev goTestEvent
+		if line[0] != '{' || json.Unmarshal(line, &ev) != nil || ev.Action == "" {
+			b.unexplained = append(b.unexplained, string(line))
+			continue
+		}
+		seen = true
+
+		if ev.Test == "" {
+			if _, ok := pkgOutput[ev.Package]; !ok {
+				pkgOutput[ev.Package] = &bytes.Buffer{}
+				pkgOrder = append(pkgOrder, ev.Package)
+			}
+			switch ev.Action {
+			case "output":
+				pkgOutput[ev.Package].WriteString(ev.Output)
+			case "fail":
+				pkgFailed[ev.Package] = true
+			}
+			continue
+		}
+
+		tc := b.get(qualify(ev.Package, ev.Test))
+		switch ev.Action {
+		case "output":
+			tc.Output += ev.Output
+		case "pass":
+			tc.Status = StatusPass
+			tc.Duration = seconds(ev.Elapsed)
+		case "fail":
+			tc.Status = StatusFail
+			tc.Duration = seconds(ev.Elapsed)
+			pkgHasFailingTest[ev.Package] = true
+		case "skip":
+			tc.Status = StatusSkip
+		}
+	}
+	if !seen {
+		return nil, ErrNoResults
+	}
+
+	for _, pkg := range pkgOrder {
+		if pkgFailed[pkg] && !pkgHasFailingTest[pkg] {
+			tc := b.get(pkg)
+			tc.Status = StatusFail
+			tc.Output = pkgOutput[pkg].String()
+		}
+	}
+	return b.report(GoTestJSON{}.Name())
+}
+
+// qualify joins a package or suite with a test name.
+func qualify(suite, name string) string {
+	if suite == "" {
+		return name
+	}
+	return suite + "." + name
+}
+
+// seconds converts fractional seconds to a Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,123 @@
+package testresult
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// JUnit parses JUnit-style XML reports, as written by most test runners
+// (pytest --junitxml, jest-junit, gotestsum --junitfile, surefire).
+type JUnit struct{}
+
+type junitSuites struct {
+	Suites []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Cases  []junitCase  `xml:"testcase"`
+	Suites []junitSuite `xml:"testsuite"` // nested suites
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	Skipped   *junitProblem `xml:"skipped"`
+	SystemOut string        `xml:"system-out"`
+	SystemErr string        `xml:"system-err"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// Name implements Parser.
+func (JUnit) Name() string { return "junit" }
+
+// Parse implements Parser. Leading non-XML output is skipped so the report
+// can be printed after other runner output.
+func (JUnit) Parse(output []byte) (*Report, error) {
+	start := bytes.Index(output, []byte("<testsuite"))
+	if start < 0 {
+		return nil, ErrNoResults
+	}
+	doc := output[start:]
+
+	var suites []junitSuite
+	if bytes.HasPrefix(doc, []byte("<testsuites")) {
+		var root junitSuites
+		if err := xml.Unmarshal(doc, &root); err != nil {
+			return nil, ErrNoResults
+		}
+		suites = root.Suites
+	} else {
+		var suite junitSuite
+		if err := xml.Unmarshal(doc, &suite); err != nil {
+			return nil, ErrNoResults
+		}
+		suites = []junitSuite{suite}
+	}
+
+	b := newBuilder()
+	var walk func([]junitSuite)
+	walk = func(suites []junitSuite) {
+		for _, s := range suites {
+			for _, c := range s.Cases {
+				suite := c.Classname
+				if suite == "" {
+					suite = s.Name
+				}
+				tc := b.get(qualify(suite, c.Name))
+				if secs, err := strconv.ParseFloat(c.Time, 64); err == nil {
+					tc.Duration = seconds(secs)
+				}
+
+				switch {
+				case c.Failure != nil:
+					tc.Status = StatusFail
+					tc.Output = problemText(c.Failure)
+				case c.Error != nil:
+					tc.Status = StatusFail
+					tc.Output = problemText(c.Error)
+				case c.Skipped != nil:
+					tc.Status = StatusSkip
+				default:
+					tc.Status = StatusPass
+				}
+				if tc.Status == StatusFail {
+					for _, extra := range []string{c.SystemOut, c.SystemErr} {
+						if extra = strings.TrimSpace(extra); extra != "" {
+							tc.Output += "\n" + extra
+						}
+					}
+				}
+			}
+			walk(s.Suites)
+		}
+	}
+	walk(suites)
+	return b.report(JUnit{}.Name())
+}
+
+// problemText combines a failure's message and body.
+func problemText(p *junitProblem) string {
+	body := strings.TrimSpace(p.Body)
+	switch {
+	case p.Message == "":
+		return body
+	case body == "" || strings.Contains(body, p.Message):
+		if body == "" {
+			return p.Message
+		}
+		return body
+	default:
+		return p.Message + "\n" + body
+	}
+}
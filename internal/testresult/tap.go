@@ -0,0 +1,91 @@
+package testresult
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// TAP parses Test Anything Protocol output (versions 12-14).
+// Only top-level test points are reported; indented subtests and YAML
+// diagnostics are attached to the output of the preceding test point.
+type TAP struct{}
+
+var (
+	tapPlan  = regexp.MustCompile(`^1\.\.\d+`)
+	tapPoint = regexp.MustCompile(`^(not )?ok\b\s*(\d+)?\s*(?:- )?(.*)$`)
+)
+
+// Name implements Parser.
+func (TAP) Name() string { return "tap" }
+
+// Parse implements Parser. Output must contain a "TAP version" line or a
+// plan ("1..N"), which keeps plain `go test` output ("ok  pkg 0.1s") from
+// being mistaken for TAP.
+func (TAP) Parse(output []byte) (*Report, error) {
+	b := newBuilder()
+	var current *TestCase
+	isTAP := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "TAP version") || tapPlan.MatchString(line) {
+			isTAP = true
+			continue
+		}
+		if strings.HasPrefix(line, "Bail out!") {
+			b.unexplained = append(b.unexplained, line)
+			continue
+		}
+
+		m := tapPoint.FindStringSubmatch(line)
+		if m == nil {
+			// Diagnostics, YAML blocks and subtests belong to the last test
+			if current != nil && current.Status == StatusFail {
+				current.Output += line + "\n"
+			}
+			continue
+		}
+
+		desc, directive := splitDirective(m[3])
+		name := strings.TrimSpace(desc)
+		if name == "" {
+			name = "test " + m[2]
+		}
+		current = b.get(name)
+
+		failed := m[1] != ""
+		switch {
+		case strings.HasPrefix(directive, "SKIP"):
+			current.Status = StatusSkip
+		case failed && strings.HasPrefix(directive, "TODO"):
+			// Expected failure: not a regression
+			current.Status = StatusSkip
+		case failed:
+			current.Status = StatusFail
+		default:
+			current.Status = StatusPass
+		}
+	}
+	if !isTAP {
+		return nil, ErrNoResults
+	}
+	return b.report(TAP{}.Name())
+}
+
+// splitDirective separates "desc # SKIP reason" into desc and the
+// upper-cased directive.
+func splitDirective(s string) (string, string) {
+	i := strings.Index(s, " # ")
+	if i < 0 {
+		if strings.HasPrefix(s, "# ") {
+			return "", strings.ToUpper(strings.TrimSpace(s[2:]))
+		}
+		return s, ""
+	}
+	return s[:i], strings.ToUpper(strings.TrimSpace(s[i+3:]))
+}
@@ -0,0 +1,216 @@
+// Package testresult parses test runner output into per-test results.
+//
+// The refinery runs an arbitrary test command for each merge request. When
+// that command emits a structured format (go test -json, JUnit XML, TAP),
+// the parsers here recover which tests failed and their output, so a
+// MERGE_FAILED can name the broken tests instead of "tests failed".
+package testresult
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a single test.
+type Status string
+
+// Test outcomes.
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// ErrNoResults is returned by a parser when the output is not in its format.
+var ErrNoResults = errors.New("no test results found")
+
+// TestCase is the result of one test.
+type TestCase struct {
+	// Name is the test name, qualified by package or suite when known
+	// (e.g., "internal/mail.TestRouter").
+	Name string `json:"name"`
+
+	// Status is the test outcome.
+	Status Status `json:"status"`
+
+	// Duration is how long the test took, if reported.
+	Duration time.Duration `json:"duration,omitempty"`
+
+	// Output is the test's captured output or failure message.
+	Output string `json:"output,omitempty"`
+}
+
+// Report is the parsed result of one test run.
+type Report struct {
+	// Format is the name of the parser that produced the report.
+	Format string `json:"format"`
+
+	// Tests holds one entry per test, in the order first seen.
+	Tests []TestCase `json:"tests"`
+
+	// Unexplained holds output the format doesn't account for and that may
+	// have failed the run on its own, such as compiler errors on stderr or
+	// a TAP bail out. A run with unexplained output can't be judged by its
+	// test results alone.
+	Unexplained []string `json:"unexplained,omitempty"`
+}
+
+// Failed returns the failing tests.
+func (r *Report) Failed() []TestCase {
+	if r == nil {
+		return nil
+	}
+	var failed []TestCase
+	for _, tc := range r.Tests {
+		if tc.Status == StatusFail {
+			failed = append(failed, tc)
+		}
+	}
+	return failed
+}
+
+// FailedNames returns the names of failing tests.
+func (r *Report) FailedNames() []string {
+	var names []string
+	for _, tc := range r.Failed() {
+		names = append(names, tc.Name)
+	}
+	return names
+}
+
+// Passed reports whether the named test ran and passed.
+func (r *Report) Passed(name string) bool {
+	if r == nil {
+		return false
+	}
+	for _, tc := range r.Tests {
+		if tc.Name == name {
+			return tc.Status == StatusPass
+		}
+	}
+	return false
+}
+
+// Parser turns raw test output into a Report.
+type Parser interface {
+	// Name is the format name used in config (e.g., "go-json").
+	Name() string
+
+	// Parse parses output, returning ErrNoResults if the output is not
+	// in this parser's format.
+	Parse(output []byte) (*Report, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Parser{}
+)
+
+// Register makes a parser available by name. Registering a name twice
+// replaces the earlier parser.
+func Register(p Parser) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[p.Name()] = p
+}
+
+// Get returns the parser registered under name.
+func Get(name string) (Parser, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Formats returns the registered format names, sorted.
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(GoTestJSON{})
+	Register(JUnit{})
+	Register(TAP{})
+}
+
+// Parse parses output with the named format. An empty format or "auto"
+// tries every registered parser and returns the first that recognizes the
+// output.
+func Parse(format string, output []byte) (*Report, error) {
+	if format != "" && format != "auto" {
+		p, ok := Get(format)
+		if !ok {
+			return nil, fmt.Errorf("unknown test format: %s", format)
+		}
+		return p.Parse(output)
+	}
+
+	for _, name := range Formats() {
+		p, _ := Get(name)
+		if report, err := p.Parse(output); err == nil {
+			return report, nil
+		}
+	}
+	return nil, ErrNoResults
+}
+
+// TrimLog keeps the last maxLines lines of a log, noting how many were cut.
+func TrimLog(log string, maxLines int) string {
+	log = strings.TrimRight(log, "\n")
+	lines := strings.Split(log, "\n")
+	if maxLines <= 0 || len(lines) <= maxLines {
+		return log
+	}
+	cut := len(lines) - maxLines
+	return fmt.Sprintf("... (%d lines trimmed)\n", cut) + strings.Join(lines[cut:], "\n")
+}
+
+// builder accumulates test cases keyed by name, preserving first-seen order.
+type builder struct {
+	order       []string
+	tests       map[string]*TestCase
+	unexplained []string
+}
+
+func newBuilder() *builder {
+	return &builder{tests: make(map[string]*TestCase)}
+}
+
+// get returns the case for name, creating it if needed.
+func (b *builder) get(name string) *TestCase {
+	if tc, ok := b.tests[name]; ok {
+		return tc
+	}
+	tc := &TestCase{Name: name}
+	b.tests[name] = tc
+	b.order = append(b.order, name)
+	return tc
+}
+
+// report builds the final report, dropping cases that never got a status.
+func (b *builder) report(format string) (*Report, error) {
+	r := &Report{Format: format, Unexplained: b.unexplained}
+	for _, name := range b.order {
+		tc := b.tests[name]
+		if tc.Status == "" {
+			continue
+		}
+		tc.Output = strings.TrimRight(tc.Output, "\n")
+		r.Tests = append(r.Tests, *tc)
+	}
+	if len(r.Tests) == 0 {
+		return nil, ErrNoResults
+	}
+	return r, nil
+}
@@ -0,0 +1,160 @@
+package testresult
+
+import (
+	"strings"
+	"testing"
+)
+
+const goJSONOutput = `{"Action":"run","Package":"example.com/app","Test":"TestGood"}
+{"Action":"output","Package":"example.com/app","Test":"TestGood","Output":"=== RUN   TestGood\n"}
+{"Action":"pass","Package":"example.com/app","Test":"TestGood","Elapsed":0.01}
+{"Action":"run","Package":"example.com/app","Test":"TestBad"}
+{"Action":"output","Package":"example.com/app","Test":"TestBad","Output":"    app_test.go:12: want 2, got 3\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestBad","Elapsed":0.02}
+{"Action":"skip","Package":"example.com/app","Test":"TestLater"}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.5}
+# example.com/broken
+broken.go:3:1: syntax error
+{"Action":"output","Package":"example.com/broken","Output":"FAIL\texample.com/broken [build failed]\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0}
+`
+
+func TestGoTestJSON(t *testing.T) {
+	r, err := Parse("go-json", []byte(goJSONOutput))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	got := r.FailedNames()
+	want := []string{"example.com/app.TestBad", "example.com/broken"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("FailedNames = %v, want %v", got, want)
+	}
+	if !r.Passed("example.com/app.TestGood") {
+		t.Error("expected TestGood to pass")
+	}
+	failed := r.Failed()
+	if !strings.Contains(failed[0].Output, "want 2, got 3") {
+		t.Errorf("TestBad output = %q", failed[0].Output)
+	}
+	if !strings.Contains(failed[1].Output, "build failed") {
+		t.Errorf("build failure output = %q", failed[1].Output)
+	}
+	if strings.Join(r.Unexplained, "\n") != "# example.com/broken\nbroken.go:3:1: syntax error" {
+		t.Errorf("Unexplained = %q", r.Unexplained)
+	}
+}
+
+const junitOutput = `running tests...
+<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="api" tests="3">
+    <testcase classname="api.Users" name="test_create" time="0.12"/>
+    <testcase classname="api.Users" name="test_delete" time="0.30">
+      <failure message="AssertionError: 404 != 204">Traceback...
+AssertionError: 404 != 204</failure>
+      <system-out>DELETE /users/7</system-out>
+    </testcase>
+    <testcase classname="api.Users" name="test_flaky_io">
+      <error message="TimeoutError"/>
+    </testcase>
+    <testcase classname="api.Users" name="test_skip"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+
+func TestJUnit(t *testing.T) {
+	r, err := Parse("junit", []byte(junitOutput))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(r.Tests) != 4 {
+		t.Fatalf("expected 4 tests, got %d", len(r.Tests))
+	}
+
+	failed := r.Failed()
+	if len(failed) != 2 || failed[0].Name != "api.Users.test_delete" || failed[1].Name != "api.Users.test_flaky_io" {
+		t.Fatalf("Failed = %+v", failed)
+	}
+	if !strings.Contains(failed[0].Output, "404 != 204") || !strings.Contains(failed[0].Output, "DELETE /users/7") {
+		t.Errorf("failure output = %q", failed[0].Output)
+	}
+	if failed[1].Output != "TimeoutError" {
+		t.Errorf("error output = %q", failed[1].Output)
+	}
+}
+
+const tapOutput = `TAP version 13
+1..4
+ok 1 - parses config
+not ok 2 - rejects bad input
+  ---
+  message: expected error
+  ...
+ok 3 - network # SKIP offline
+not ok 4 - new feature # TODO not done
+Bail out! database unreachable
+`
+
+func TestTAP(t *testing.T) {
+	r, err := Parse("tap", []byte(tapOutput))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	failed := r.Failed()
+	if len(failed) != 1 || failed[0].Name != "rejects bad input" {
+		t.Fatalf("Failed = %+v", failed)
+	}
+	if !strings.Contains(failed[0].Output, "expected error") {
+		t.Errorf("failure output = %q", failed[0].Output)
+	}
+	if !r.Passed("parses config") {
+		t.Error("expected test 1 to pass")
+	}
+	if len(r.Unexplained) != 1 || r.Unexplained[0] != "Bail out! database unreachable" {
+		t.Errorf("Unexplained = %q", r.Unexplained)
+	}
+}
+
+func TestParseAutoDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		format string
+	}{
+		{"go json", goJSONOutput, "go-json"},
+		{"junit", junitOutput, "junit"},
+		{"tap", tapOutput, "tap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse("auto", []byte(tt.output))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if r.Format != tt.format {
+				t.Errorf("Format = %q, want %q", r.Format, tt.format)
+			}
+		})
+	}
+
+	// Plain go test output is not TAP
+	plain := "ok  \texample.com/app\t0.01s\nFAIL\texample.com/other\t0.02s\n"
+	if _, err := Parse("", []byte(plain)); err != ErrNoResults {
+		t.Errorf("expected ErrNoResults for unstructured output, got %v", err)
+	}
+
+	if _, err := Parse("nunit", nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestTrimLog(t *testing.T) {
+	log := "a\nb\nc\nd\n"
+	if got := TrimLog(log, 10); got != "a\nb\nc\nd" {
+		t.Errorf("TrimLog short = %q", got)
+	}
+	if got := TrimLog(log, 2); got != "... (2 lines trimmed)\nc\nd" {
+		t.Errorf("TrimLog = %q", got)
+	}
+}
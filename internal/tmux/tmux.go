@@ -82,10 +82,11 @@ func (t *Tmux) NewSession(name, workDir string) error {
 //
 // A session is considered a zombie if:
 // - The tmux session exists
-// - But Claude (node process) is not running in it
+// - But no agent is running in it (see IsAgentRunning)
 //
+// remote is true for sessions of rigs on a remote machine.
 // Returns nil if session was created successfully.
-func (t *Tmux) EnsureSessionFresh(name, workDir string) error {
+func (t *Tmux) EnsureSessionFresh(name, workDir string, remote bool) error {
 	// Check if session already exists
 	exists, err := t.HasSession(name)
 	if err != nil {
@@ -94,7 +95,7 @@ func (t *Tmux) EnsureSessionFresh(name, workDir string) error {
 
 	if exists {
 		// Session exists - check if it's a zombie
-		if !t.IsClaudeRunning(name) {
+		if !t.IsAgentRunning(name, remote) {
 			// Zombie session: tmux alive but Claude dead
 			// Kill it so we can create a fresh one
 			if err := t.KillSession(name); err != nil {
@@ -537,6 +538,18 @@ func (t *Tmux) IsClaudeRunning(session string) bool {
 	return cmd == "node"
 }
 
+// IsAgentRunning checks if an agent appears to be running in the session.
+// Local agents show as node (Claude). Agents of remote rigs run behind ssh,
+// which exits with the agent, so for a remote rig's session an ssh pane
+// counts as running too. Elsewhere ssh is just a client left in the pane.
+func (t *Tmux) IsAgentRunning(session string, remote bool) bool {
+	cmd, err := t.GetPaneCommand(session)
+	if err != nil {
+		return false
+	}
+	return cmd == "node" || (remote && cmd == "ssh")
+}
+
 // WaitForCommand polls until the pane is NOT running one of the excluded commands.
 // Useful for waiting until a shell has started a new process (e.g., claude).
 // Returns nil when a non-excluded command is detected, or error on timeout.
@@ -835,3 +848,19 @@ func (t *Tmux) SetPaneDiedHook(session, agentID string) error {
 	_, err := t.run("set-hook", "-t", session, "pane-died", hookCmd)
 	return err
 }
+
+// PipePane pipes everything the session's pane outputs to a shell command,
+// replacing any existing pipe.
+func (t *Tmux) PipePane(session, command string) error {
+	_, err := t.run("pipe-pane", "-t", session, command)
+	return err
+}
+
+// IsPanePiped reports whether the session's pane output is being piped.
+func (t *Tmux) IsPanePiped(session string) (bool, error) {
+	out, err := t.run("display-message", "-p", "-t", session, "#{pane_pipe}")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "1", nil
+}
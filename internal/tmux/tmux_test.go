@@ -222,7 +222,7 @@ func TestEnsureSessionFresh_NoExistingSession(t *testing.T) {
 	_ = tm.KillSession(sessionName)
 
 	// EnsureSessionFresh should create a new session
-	if err := tm.EnsureSessionFresh(sessionName, ""); err != nil {
+	if err := tm.EnsureSessionFresh(sessionName, "", false); err != nil {
 		t.Fatalf("EnsureSessionFresh: %v", err)
 	}
 	defer func() { _ = tm.KillSession(sessionName) }()
@@ -262,7 +262,7 @@ func TestEnsureSessionFresh_ZombieSession(t *testing.T) {
 
 	// EnsureSessionFresh should kill the zombie and create fresh session
 	// This should NOT error with "session already exists"
-	if err := tm.EnsureSessionFresh(sessionName, ""); err != nil {
+	if err := tm.EnsureSessionFresh(sessionName, "", false); err != nil {
 		t.Fatalf("EnsureSessionFresh on zombie: %v", err)
 	}
 
@@ -289,7 +289,7 @@ func TestEnsureSessionFresh_IdempotentOnZombie(t *testing.T) {
 
 	// Call EnsureSessionFresh multiple times - should work each time
 	for i := 0; i < 3; i++ {
-		if err := tm.EnsureSessionFresh(sessionName, ""); err != nil {
+		if err := tm.EnsureSessionFresh(sessionName, "", false); err != nil {
 			t.Fatalf("EnsureSessionFresh attempt %d: %v", i+1, err)
 		}
 	}
@@ -0,0 +1,154 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultLimit caps search results when Query.Limit is zero.
+const DefaultLimit = 50
+
+// Query selects transcript lines.
+type Query struct {
+	Text  string    // case-insensitive substring
+	Rig   string    // only this rig's agents
+	Role  string    // only this role (polecat, crew, witness, refinery, ...)
+	Agent string    // only this agent address
+	Since time.Time // only lines recorded at or after this time
+	Limit int       // maximum matches, newest first; 0 means DefaultLimit
+}
+
+// Match is a transcript line matching a query.
+type Match struct {
+	Segment
+	Time   time.Time `json:"time"`
+	LineNo int       `json:"line"`
+	Text   string    `json:"text"`
+}
+
+// LoadIndex returns the indexed segments, oldest first. Segments whose files
+// were rotated away are omitted.
+func LoadIndex(townRoot string) ([]Segment, error) {
+	f, err := os.Open(filepath.Join(Path(townRoot), IndexFile)) //nolint:gosec // G304: path is within the town
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var segs []Segment
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var seg Segment
+		if json.Unmarshal(scanner.Bytes(), &seg) != nil || seg.File == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Path(townRoot), seg.File)); err != nil {
+			continue
+		}
+		segs = append(segs, seg)
+	}
+	sort.SliceStable(segs, func(i, j int) bool { return segs[i].Started.Before(segs[j].Started) })
+	return segs, scanner.Err()
+}
+
+// List returns the segments that can hold lines matching q's agent, rig,
+// role and time filters, oldest first. Query.Text is ignored.
+func List(townRoot string, q Query) ([]Segment, error) {
+	segs, err := LoadIndex(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	var out []Segment
+	for _, seg := range segs {
+		if !q.selects(seg) {
+			continue
+		}
+		if !q.Since.IsZero() {
+			// A segment last written before Since has nothing newer.
+			info, err := os.Stat(filepath.Join(Path(townRoot), seg.File))
+			if err != nil || info.ModTime().Before(q.Since) {
+				continue
+			}
+		}
+		out = append(out, seg)
+	}
+	return out, nil
+}
+
+// Search returns the newest lines matching q.
+func Search(townRoot string, q Query) ([]Match, error) {
+	segs, err := List(townRoot, q)
+	if err != nil {
+		return nil, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	needle := strings.ToLower(q.Text)
+
+	var matches []Match
+	for _, seg := range segs {
+		found, err := searchSegment(filepath.Join(Path(townRoot), seg.File), seg, needle, q.Since)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Time.After(matches[j].Time) })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+func (q Query) selects(seg Segment) bool {
+	if q.Rig != "" && seg.Rig != q.Rig {
+		return false
+	}
+	if q.Role != "" && seg.Role != q.Role {
+		return false
+	}
+	if q.Agent != "" && seg.Agent != q.Agent {
+		return false
+	}
+	return true
+}
+
+func searchSegment(path string, seg Segment, needle string, since time.Time) ([]Match, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is from the transcript index
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // rotated away since the index was read
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var matches []Match
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		ts, text, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok || !strings.Contains(strings.ToLower(text), needle) {
+			continue
+		}
+		t, err := time.Parse(timeLayout, ts)
+		if err != nil || t.Before(since) {
+			continue
+		}
+		matches = append(matches, Match{Segment: seg, Time: t, LineNo: lineNo, Text: text})
+	}
+	return matches, scanner.Err()
+}
@@ -0,0 +1,301 @@
+// Package transcript records what agent sessions print and searches it.
+//
+// Each agent's tmux pane is piped (tmux pipe-pane) into `gt transcripts
+// record`, which strips terminal escapes and appends timestamped lines to
+// rotated segment files under logs/transcripts/<session>/. Every segment is
+// listed in logs/transcripts/index.jsonl with the agent, rig and role that
+// produced it, so searches only read the segments that can match.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/tmux"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Locations relative to the town root.
+const (
+	Dir       = "logs/transcripts"
+	IndexFile = "index.jsonl"
+)
+
+// Rotation defaults: a session keeps at most DefaultMaxSegments segments of
+// DefaultMaxSegmentBytes each, dropping the oldest.
+const (
+	DefaultMaxSegmentBytes = 8 << 20
+	DefaultMaxSegments     = 10
+)
+
+// timeLayout prefixes every transcript line, separated by a tab.
+const timeLayout = time.RFC3339
+
+// Path returns the transcripts directory for a town.
+func Path(townRoot string) string {
+	return filepath.Join(townRoot, Dir)
+}
+
+// Segment is one transcript file, as recorded in the index.
+type Segment struct {
+	Session string    `json:"session"`         // tmux session name
+	Agent   string    `json:"agent,omitempty"` // mail address, e.g. gastown/polecats/Toast
+	Rig     string    `json:"rig,omitempty"`
+	Role    string    `json:"role,omitempty"`
+	File    string    `json:"file"` // relative to the transcripts directory
+	Started time.Time `json:"started"`
+}
+
+// Start pipes a session's pane into the transcript recorder. It does
+// nothing if the pane is already piped, so it is safe to call repeatedly.
+func Start(t *tmux.Tmux, townRoot, sessionName string) error {
+	if piped, err := t.IsPanePiped(sessionName); err != nil || piped {
+		return err
+	}
+	cmd := fmt.Sprintf("exec gt transcripts record --town %s --session %s",
+		util.ShellQuote(townRoot), util.ShellQuote(sessionName))
+	return t.PipePane(sessionName, cmd)
+}
+
+// Recorder writes a session's output to rotating segment files.
+type Recorder struct {
+	townRoot string
+	meta     Segment
+
+	// MaxSegmentBytes and MaxSegments bound the session's transcript.
+	MaxSegmentBytes int64
+	MaxSegments     int
+
+	now  func() time.Time
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+	last string
+}
+
+// NewRecorder creates a recorder for a session. meta identifies the agent;
+// its File and Started are set per segment.
+func NewRecorder(townRoot string, meta Segment) *Recorder {
+	return &Recorder{
+		townRoot:        townRoot,
+		meta:            meta,
+		MaxSegmentBytes: DefaultMaxSegmentBytes,
+		MaxSegments:     DefaultMaxSegments,
+		now:             time.Now,
+	}
+}
+
+// Record copies r into the transcript until EOF. Output is flushed after
+// every read so searches see it promptly.
+func (rec *Recorder) Record(r io.Reader) error {
+	defer rec.Close()
+
+	buf := make([]byte, 32*1024)
+	var partial string
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines := strings.FieldsFunc(partial+string(buf[:n]), func(c rune) bool { return c == '\n' || c == '\r' })
+			partial = ""
+			if c := buf[n-1]; c != '\n' && c != '\r' && len(lines) > 0 {
+				partial = lines[len(lines)-1]
+				lines = lines[:len(lines)-1]
+			}
+			now := rec.now()
+			for _, line := range lines {
+				if werr := rec.writeLine(now, line); werr != nil {
+					return werr
+				}
+			}
+			if rec.w != nil {
+				if ferr := rec.w.Flush(); ferr != nil {
+					return ferr
+				}
+			}
+		}
+		if err == io.EOF {
+			if partial != "" {
+				return rec.writeLine(rec.now(), partial)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeLine appends a cleaned line, skipping blanks and immediate repeats
+// (terminal UIs redraw the same line many times).
+func (rec *Recorder) writeLine(now time.Time, raw string) error {
+	line := strings.TrimSpace(Clean(raw))
+	if line == "" || line == rec.last {
+		return nil
+	}
+	rec.last = line
+
+	if rec.f == nil || rec.size >= rec.MaxSegmentBytes {
+		if err := rec.rotate(now); err != nil {
+			return err
+		}
+	}
+	n, err := fmt.Fprintf(rec.w, "%s\t%s\n", now.UTC().Format(timeLayout), line)
+	rec.size += int64(n)
+	return err
+}
+
+// rotate closes the current segment, starts a new one and drops the oldest
+// beyond MaxSegments.
+func (rec *Recorder) rotate(now time.Time) error {
+	if err := rec.Close(); err != nil {
+		return err
+	}
+	dir := filepath.Join(Path(rec.townRoot), rec.meta.Session)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating transcript directory: %w", err)
+	}
+
+	seg := rec.meta
+	seg.Started = now.UTC()
+	seg.File = filepath.Join(rec.meta.Session, seg.Started.Format("20060102T150405.000000000Z")+".log")
+	f, err := os.OpenFile(filepath.Join(Path(rec.townRoot), seg.File), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644) //nolint:gosec // G304: path is within the town
+	if err != nil {
+		return fmt.Errorf("opening transcript: %w", err)
+	}
+	rec.f, rec.w, rec.size = f, bufio.NewWriter(f), 0
+
+	if err := appendIndex(rec.townRoot, seg); err != nil {
+		return err
+	}
+	return rec.prune(dir)
+}
+
+func (rec *Recorder) prune(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files) // names are start times
+	if len(files) <= rec.MaxSegments {
+		return nil
+	}
+	for len(files) > rec.MaxSegments {
+		_ = os.Remove(files[0])
+		files = files[1:]
+	}
+	return compactIndex(rec.townRoot)
+}
+
+// Close flushes and closes the current segment.
+func (rec *Recorder) Close() error {
+	if rec.f == nil {
+		return nil
+	}
+	err := rec.w.Flush()
+	if cerr := rec.f.Close(); err == nil {
+		err = cerr
+	}
+	rec.f, rec.w = nil, nil
+	return err
+}
+
+// appendIndex records a new segment. It holds lockIndex while appending,
+// so concurrent recorders don't interleave and compaction can't drop the
+// entry while rewriting the index.
+func appendIndex(townRoot string, seg Segment) error {
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockIndex(townRoot)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(filepath.Join(Path(townRoot), IndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644) //nolint:gosec // G304: path is within the town
+	if err != nil {
+		return fmt.Errorf("opening transcript index: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// compactIndex rewrites the index without the entries whose segment files
+// are gone, so it doesn't grow without bound as sessions rotate.
+func compactIndex(townRoot string) error {
+	unlock, err := lockIndex(townRoot)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path := filepath.Join(Path(townRoot), IndexFile)
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is within the town
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("reading transcript index: %w", err)
+	}
+	var kept []byte
+	dropped := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var seg Segment
+		if json.Unmarshal(line, &seg) != nil || seg.File == "" {
+			dropped = true
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Path(townRoot), seg.File)); err != nil {
+			dropped = true
+			continue
+		}
+		kept = append(append(kept, line...), '\n')
+	}
+	if !dropped {
+		return nil
+	}
+	if err := util.AtomicWriteFile(path, kept, 0644); err != nil {
+		return fmt.Errorf("compacting transcript index: %w", err)
+	}
+	return nil
+}
+
+// lockIndex takes an exclusive lock on the index, held while appending so a
+// compaction doesn't drop a concurrent recorder's entry.
+func lockIndex(townRoot string) (func(), error) {
+	f, err := os.OpenFile(filepath.Join(Path(townRoot), ".index.lock"), os.O_CREATE|os.O_RDWR, 0644) //nolint:gosec // G304: path is within the town
+	if err != nil {
+		return nil, fmt.Errorf("opening transcript index lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("locking transcript index: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
+
+// escapes matches terminal control sequences: CSI (colors, cursor
+// movement), OSC (titles, hyperlinks) and two-byte escapes.
+var escapes = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]|[\x00-\x08\x0b-\x1f\x7f]`)
+
+// Clean strips terminal control sequences from a line of pane output.
+func Clean(s string) string {
+	return escapes.ReplaceAllString(s, "")
+}
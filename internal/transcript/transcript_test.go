@@ -0,0 +1,143 @@
+package transcript
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClean(t *testing.T) {
+	tests := map[string]string{
+		"\x1b[1;31mError:\x1b[0m build failed": "Error: build failed",
+		"\x1b]0;claude\x07⏺ Running tests":     "⏺ Running tests",
+		"\x1b[2K\x1b[1Gprogress\x08":           "progress",
+		"plain\ttext":                          "plain\ttext",
+	}
+	for in, want := range tests {
+		if got := Clean(in); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// fakeClock advances a minute per call.
+func fakeClock(start time.Time) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+}
+
+func record(t *testing.T, townRoot string, meta Segment, start time.Time, output string) *Recorder {
+	t.Helper()
+	rec := NewRecorder(townRoot, meta)
+	rec.now = fakeClock(start)
+	if err := rec.Record(strings.NewReader(output)); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	return rec
+}
+
+func TestRecorder_RotatesAndPrunes(t *testing.T) {
+	root := t.TempDir()
+	rec := NewRecorder(root, Segment{Session: "gt-gastown-Toast", Agent: "gastown/polecats/Toast", Rig: "gastown", Role: "polecat"})
+	rec.now = fakeClock(time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC))
+	rec.MaxSegmentBytes = 40
+	rec.MaxSegments = 2
+
+	// One line per read, so each gets its own timestamp.
+	var reads []io.Reader
+	for i := 0; i < 6; i++ {
+		reads = append(reads, strings.NewReader(strings.Repeat("x", i+1)+" line\r\n"))
+	}
+	if err := rec.Record(io.MultiReader(reads...)); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(Path(root), "gt-gastown-Toast", "*.log"))
+	if len(files) != 2 {
+		t.Fatalf("segments = %d, want 2 after pruning", len(files))
+	}
+	segs, err := LoadIndex(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 2 || segs[0].Agent != "gastown/polecats/Toast" {
+		t.Errorf("index = %+v, want the 2 surviving segments", segs)
+	}
+	data, err := os.ReadFile(filepath.Join(Path(root), IndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Errorf("index has %d entries, want the pruned ones dropped", n)
+	}
+}
+
+func TestRecorder_SkipsRedraws(t *testing.T) {
+	root := t.TempDir()
+	record(t, root, Segment{Session: "gt-gastown-witness"}, time.Now(), "working\r\x1b[2Kworking\r\n\n  \nfinished\npartial")
+
+	segs, _ := LoadIndex(root)
+	if len(segs) != 1 {
+		t.Fatalf("segments = %d, want 1", len(segs))
+	}
+	data, err := os.ReadFile(filepath.Join(Path(root), segs[0].File))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		_, text, _ := strings.Cut(line, "\t")
+		texts = append(texts, text)
+	}
+	if got := strings.Join(texts, "|"); got != "working|finished|partial" {
+		t.Errorf("transcript = %q, want working|finished|partial", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	root := t.TempDir()
+	base := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	record(t, root, Segment{Session: "gt-gastown-Toast", Agent: "gastown/polecats/Toast", Rig: "gastown", Role: "polecat"},
+		base, "go test ./...\nFAIL: TestMerge panic: nil map\nfixed\n")
+	record(t, root, Segment{Session: "gt-beads-refinery", Agent: "beads/refinery", Rig: "beads", Role: "refinery"},
+		base.Add(time.Hour), "merging\npanic: nil map in refinery\n")
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"all rigs, newest first", Query{Text: "PANIC"}, []string{"beads/refinery", "gastown/polecats/Toast"}},
+		{"by rig", Query{Text: "panic", Rig: "gastown"}, []string{"gastown/polecats/Toast"}},
+		{"by role", Query{Text: "panic", Role: "refinery"}, []string{"beads/refinery"}},
+		{"since", Query{Text: "panic", Since: base.Add(30 * time.Minute)}, []string{"beads/refinery"}},
+		{"limit", Query{Text: "panic", Limit: 1}, []string{"beads/refinery"}},
+		{"no match", Query{Text: "segfault"}, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := Search(root, tc.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.Agent)
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("agents = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	matches, _ := Search(root, Query{Text: "TestMerge"})
+	if len(matches) != 1 || matches[0].LineNo != 2 || matches[0].Session != "gt-gastown-Toast" {
+		t.Errorf("match = %+v, want line 2 of gt-gastown-Toast", matches)
+	}
+}
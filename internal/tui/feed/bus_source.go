@@ -0,0 +1,58 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/steveyegge/gastown/internal/eventbus"
+)
+
+// BusSource streams gt events from the daemon's event bus. It replaces
+// GtEventsSource when a daemon is running, delivering events as they are
+// published instead of polling .events.jsonl.
+type BusSource struct {
+	stream *eventbus.Stream
+	events chan Event
+}
+
+// NewBusSource subscribes to the town's event bus. It fails if no daemon
+// is serving the bus.
+func NewBusSource(townRoot string) (*BusSource, error) {
+	stream, err := eventbus.Subscribe(context.Background(), townRoot, eventbus.SubscribeOptions{
+		From: eventbus.FromLive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	source := &BusSource{
+		stream: stream,
+		events: make(chan Event, 100),
+	}
+	go source.forward()
+
+	return source, nil
+}
+
+// forward converts bus messages into feed events.
+func (s *BusSource) forward() {
+	defer close(s.events)
+	for msg := range s.stream.Messages() {
+		raw, _ := json.Marshal(msg.Event)
+		ge := GtEvent(msg.Event)
+		if event := convertGtEvent(ge, string(raw)); event != nil {
+			s.events <- *event
+		}
+	}
+}
+
+// Events returns the event channel
+func (s *BusSource) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops the source
+func (s *BusSource) Close() error {
+	s.stream.Close()
+	return nil
+}
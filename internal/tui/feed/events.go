@@ -300,7 +300,12 @@ func parseGtEventLine(line string) *Event {
 	if err := json.Unmarshal([]byte(line), &ge); err != nil {
 		return nil
 	}
+	return convertGtEvent(ge, line)
+}
 
+// convertGtEvent converts a gt event into a feed event, or nil if the
+// event is not feed-visible. raw is kept as the event's Raw text.
+func convertGtEvent(ge GtEvent, raw string) *Event {
 	// Only show feed-visible events
 	if ge.Visibility != "feed" && ge.Visibility != "both" {
 		return nil
@@ -363,7 +368,7 @@ func parseGtEventLine(line string) *Event {
 		Message: message,
 		Rig:     rig,
 		Role:    role,
-		Raw:     line,
+		Raw:     raw,
 	}
 }
 
@@ -471,6 +476,64 @@ func buildEventMessage(eventType string, payload map[string]interface{}) string
 		}
 		return "merged"
 
+	case "autoscale":
+		action := getPayloadString(payload, "action")
+		subject := getPayloadString(payload, "polecat")
+		if bead := getPayloadString(payload, "bead"); bead != "" {
+			subject = strings.TrimSpace(subject + " " + bead)
+		}
+		reason := getPayloadString(payload, "reason")
+		switch {
+		case subject != "" && reason != "":
+			return fmt.Sprintf("autoscale %s %s: %s", action, subject, reason)
+		case reason != "":
+			return fmt.Sprintf("autoscale %s: %s", action, reason)
+		}
+		return "autoscale " + action
+
+	case "digest":
+		agent := getPayloadString(payload, "agent")
+		if period := getPayloadString(payload, "period"); period != "" && period != "cycle" {
+			return fmt.Sprintf("%s digest for %s", period, agent)
+		}
+		if bead := getPayloadString(payload, "bead"); bead != "" {
+			return fmt.Sprintf("squashed %s to digest %s", getPayloadString(payload, "molecule"), bead)
+		}
+		return "squashed " + getPayloadString(payload, "molecule")
+
+	case "escalation_acked", "escalation_resolved":
+		verb := "acknowledged"
+		if eventType == "escalation_resolved" {
+			verb = "resolved"
+		}
+		by := getPayloadString(payload, "by")
+		if reason := getPayloadString(payload, "reason"); reason != "" {
+			return fmt.Sprintf("%s %s escalation: %s", by, verb, reason)
+		}
+		return fmt.Sprintf("%s %s %s", by, verb, getPayloadString(payload, "escalation"))
+
+	case "escalation_breached":
+		return fmt.Sprintf("re-escalated %s (%s SLA missed): %s",
+			getPayloadString(payload, "escalation"), getPayloadString(payload, "breach"), getPayloadString(payload, "reason"))
+
+	case "doctor_check":
+		check := getPayloadString(payload, "check")
+		status := getPayloadString(payload, "status")
+		if prev := getPayloadString(payload, "previous"); prev != "" {
+			return fmt.Sprintf("doctor: %s %s → %s: %s", check, prev, status, getPayloadString(payload, "message"))
+		}
+		return fmt.Sprintf("doctor: %s %s: %s", check, status, getPayloadString(payload, "message"))
+
+	case "dog_job":
+		msg := fmt.Sprintf("dog job %s %s", getPayloadString(payload, "job"), getPayloadString(payload, "status"))
+		if dog := getPayloadString(payload, "dog"); dog != "" {
+			msg += " on " + dog
+		}
+		if message := getPayloadString(payload, "message"); message != "" {
+			msg += ": " + message
+		}
+		return msg
+
 	case "merge_failed":
 		reason := getPayloadString(payload, "reason")
 		if reason != "" {
@@ -145,6 +145,10 @@ func mapMQEventType(mqType mrqueue.EventType) string {
 		return "merge_failed"
 	case mrqueue.EventMergeSkipped:
 		return "merge_skipped"
+	case mrqueue.EventReverted:
+		return "reverted"
+	case mrqueue.EventBatched:
+		return "batched"
 	default:
 		return string(mqType)
 	}
@@ -183,6 +187,18 @@ func formatMQEventMessage(e mrqueue.Event) string {
 			msg += " - " + e.Reason
 		}
 		return msg
+	case mrqueue.EventReverted:
+		msg := "Reverted: " + branchInfo
+		if e.Reason != "" {
+			msg += " - " + e.Reason
+		}
+		return msg
+	case mrqueue.EventBatched:
+		msg := "Batch " + e.Batch + " " + e.Outcome + ": " + branchInfo
+		if e.Outcome == mrqueue.BatchCulprit && e.Reason != "" {
+			msg += " - " + e.Reason
+		}
+		return msg
 	default:
 		return string(e.Type) + ": " + branchInfo
 	}
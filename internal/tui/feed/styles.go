@@ -155,5 +155,16 @@ var (
 		"nudge":   "⚡",
 		"boot":    "🔌",
 		"halt":    "⏹",
+		// Daemon events
+		"autoscale": "⚖",
+		"digest":    "📜",
+		// Escalation lifecycle
+		"escalation_acked":    "👀",
+		"escalation_resolved": "✓",
+		"escalation_breached": "⏰",
+		// Health checks
+		"doctor_check": "🩺",
+		// Dog jobs
+		"dog_job": "🐕",
 	}
 )
@@ -253,7 +253,7 @@ func (m *Model) renderEvent(e Event) string {
 		symbolStyle = EventCreateStyle
 	case "update":
 		symbolStyle = EventUpdateStyle
-	case "complete", "patrol_complete", "merged", "done":
+	case "complete", "patrol_complete", "merged", "done", "escalation_resolved":
 		symbolStyle = EventCompleteStyle
 	case "fail", "merge_failed":
 		symbolStyle = EventFailStyle
@@ -265,7 +265,7 @@ func (m *Model) renderEvent(e Event) string {
 		symbolStyle = EventMergeSkippedStyle
 	case "patrol_started", "polecat_checked":
 		symbolStyle = EventUpdateStyle
-	case "polecat_nudged", "escalation_sent", "nudge":
+	case "polecat_nudged", "escalation_sent", "escalation_breached", "nudge":
 		symbolStyle = EventFailStyle // Use red/warning style for nudges and escalations
 	case "sling", "hook", "spawn", "boot":
 		symbolStyle = EventCreateStyle
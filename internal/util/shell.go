@@ -0,0 +1,22 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ShellQuote quotes s as a single word for a POSIX shell.
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// safeNameRegex matches bead IDs, rig and polecat names, agent addresses and
+// branches. Requiring a leading alphanumeric keeps values from being parsed
+// as flags.
+var safeNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/@-]*$`)
+
+// IsSafeName reports whether s can be passed to gt as an identifier
+// argument.
+func IsSafeName(s string) bool {
+	return safeNameRegex.MatchString(s)
+}
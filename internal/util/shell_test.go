@@ -0,0 +1,47 @@
+package util
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "''"},
+		{"plain", "'plain'"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME `id` \"x\"", "'$HOME `id` \"x\"'"},
+	}
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// The quoted word must reach the command unchanged.
+	for _, tt := range tests {
+		out, err := exec.Command("sh", "-c", "printf %s "+ShellQuote(tt.in)).Output()
+		if err != nil {
+			t.Fatalf("sh: %v", err)
+		}
+		if string(out) != tt.in {
+			t.Errorf("sh round trip of %q = %q", tt.in, out)
+		}
+	}
+}
+
+func TestIsSafeName(t *testing.T) {
+	for _, s := range []string{"gt-abc12", "gastown/nux", "polecat/nux@main", "v1.2:rc"} {
+		if !IsSafeName(s) {
+			t.Errorf("IsSafeName(%q) = false, want true", s)
+		}
+	}
+	for _, s := range []string{"", "-rf", "--all", "a b", "a;b", "$(id)"} {
+		if IsSafeName(s) {
+			t.Errorf("IsSafeName(%q) = true, want false", s)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Actions accepted by POST /api/actions/{action}.
+const (
+	ActionSling    = "sling"
+	ActionNudge    = "nudge"
+	ActionRetryMR  = "mq-retry"
+	ActionRejectMR = "mq-reject"
+	ActionNuke     = "polecat-nuke"
+)
+
+// actionTimeout bounds a single action. Slinging to a rig spawns a polecat,
+// which is the slowest of them.
+const actionTimeout = 2 * time.Minute
+
+// ActionRequest is the JSON body of an action. Each action uses a subset
+// of the fields.
+type ActionRequest struct {
+	Bead    string `json:"bead,omitempty"`    // sling: issue to assign
+	Target  string `json:"target,omitempty"`  // sling: rig or agent; nudge: agent address
+	Message string `json:"message,omitempty"` // nudge: text to send
+	Rig     string `json:"rig,omitempty"`     // mq-retry, mq-reject, polecat-nuke
+	MR      string `json:"mr,omitempty"`      // mq-retry: MR ID; mq-reject: MR ID or branch
+	Reason  string `json:"reason,omitempty"`  // mq-reject: why (required)
+	Polecat string `json:"polecat,omitempty"` // polecat-nuke: polecat name
+	Force   bool   `json:"force,omitempty"`   // polecat-nuke: bypass safety checks
+}
+
+// ActionResult is the JSON response of an action.
+type ActionResult struct {
+	OK     bool   `json:"ok"`
+	Action string `json:"action"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ActionRunner performs console actions.
+type ActionRunner interface {
+	Run(ctx context.Context, action string, req ActionRequest) (output string, err error)
+}
+
+// CLIActions performs actions by running gt, so the console goes through
+// the same code paths and safety checks as the command line.
+type CLIActions struct {
+	GT       string // path to gt; defaults to the running executable
+	TownRoot string // directory commands run in
+}
+
+// Run executes the gt command for an action and returns its output.
+func (a *CLIActions) Run(ctx context.Context, action string, req ActionRequest) (string, error) {
+	args, err := actionArgs(action, req)
+	if err != nil {
+		return "", err
+	}
+
+	gt := a.GT
+	if gt == "" {
+		if gt, err = os.Executable(); err != nil {
+			return "", fmt.Errorf("locating gt: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, gt, args...) //nolint:gosec // G204: args are validated by actionArgs
+	cmd.Dir = a.TownRoot
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(out.String())
+		if output == "" {
+			return "", fmt.Errorf("gt %s: %w", args[0], err)
+		}
+		return output, fmt.Errorf("gt %s: %s", args[0], lastLine(output))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// actionArgs builds and validates the gt arguments for an action.
+// Free text is always passed as --flag=value.
+func actionArgs(action string, req ActionRequest) ([]string, error) {
+	check := func(field, value string) error {
+		if value == "" {
+			return fmt.Errorf("%s is required", field)
+		}
+		if !util.IsSafeName(value) {
+			return fmt.Errorf("invalid %s %q", field, value)
+		}
+		return nil
+	}
+
+	switch action {
+	case ActionSling:
+		if err := check("bead", req.Bead); err != nil {
+			return nil, err
+		}
+		if err := check("target", req.Target); err != nil {
+			return nil, err
+		}
+		return []string{"sling", req.Bead, req.Target}, nil
+
+	case ActionNudge:
+		if err := check("target", req.Target); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(req.Message) == "" {
+			return nil, fmt.Errorf("message is required")
+		}
+		return []string{"nudge", req.Target, "--message=" + req.Message}, nil
+
+	case ActionRetryMR:
+		if err := check("rig", req.Rig); err != nil {
+			return nil, err
+		}
+		if err := check("mr", req.MR); err != nil {
+			return nil, err
+		}
+		return []string{"mq", "retry", req.Rig, req.MR}, nil
+
+	case ActionRejectMR:
+		if err := check("rig", req.Rig); err != nil {
+			return nil, err
+		}
+		if err := check("mr", req.MR); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			return nil, fmt.Errorf("reason is required")
+		}
+		return []string{"mq", "reject", req.Rig, req.MR, "--reason=" + req.Reason, "--notify"}, nil
+
+	case ActionNuke:
+		if err := check("rig", req.Rig); err != nil {
+			return nil, err
+		}
+		if err := check("polecat", req.Polecat); err != nil {
+			return nil, err
+		}
+		args := []string{"polecat", "nuke", req.Rig + "/" + req.Polecat}
+		if req.Force {
+			args = append(args, "--force")
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("unknown action %q", action)
+}
+
+// lastLine returns the last non-empty line of command output, which is
+// where gt reports errors.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
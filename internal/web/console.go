@@ -0,0 +1,410 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tokenCookie holds the console token once a browser has presented it in
+// the URL printed by gt dashboard.
+const tokenCookie = "gt_console"
+
+// maxActionBody bounds action request bodies.
+const maxActionBody = 64 << 10
+
+// ErrRigNotFound is returned by ConsoleFetcher for unknown rigs.
+var ErrRigNotFound = errors.New("rig not found")
+
+// ConsoleFetcher provides the rig, merge queue and mail data behind the
+// console pages.
+type ConsoleFetcher interface {
+	FetchRigs() ([]RigRow, error)
+	FetchRig(name string) (*RigData, error)
+	FetchQueue(rig string) ([]QueueRow, error)
+	FetchMailbox(address string) ([]MailRow, error)
+}
+
+// RigRow summarizes a rig on the rigs page.
+type RigRow struct {
+	Name            string   `json:"name"`
+	Polecats        []string `json:"polecats"`
+	Running         int      `json:"running"` // polecats with a live session
+	WitnessRunning  bool     `json:"witness_running"`
+	RefineryRunning bool     `json:"refinery_running"`
+	QueueDepth      int      `json:"queue_depth"`
+}
+
+// RigData is everything shown on a rig's page.
+type RigData struct {
+	Name     string          `json:"name"`
+	Witness  AgentRow        `json:"witness"`
+	Refinery AgentRow        `json:"refinery"`
+	Polecats []RigPolecatRow `json:"polecats"`
+	Queue    []QueueRow      `json:"queue"`
+}
+
+// AgentRow is the state of a rig's witness or refinery.
+type AgentRow struct {
+	Session    string     `json:"session"`
+	Running    bool       `json:"running"` // tmux session exists
+	State      string     `json:"state"`   // agent-reported state
+	ActiveAt   *time.Time `json:"active_at,omitempty"`
+	StatusHint string     `json:"status_hint,omitempty"`
+}
+
+// RigPolecatRow is a polecat on a rig's page.
+type RigPolecatRow struct {
+	Name       string     `json:"name"`
+	State      string     `json:"state"`
+	Issue      string     `json:"issue,omitempty"`
+	Branch     string     `json:"branch,omitempty"`
+	Session    string     `json:"session"`
+	Running    bool       `json:"running"`
+	ActiveAt   *time.Time `json:"active_at,omitempty"`
+	StatusHint string     `json:"status_hint,omitempty"`
+}
+
+// QueueRow is a merge request in a rig's queue, in processing order.
+type QueueRow struct {
+	ID          string    `json:"id"`
+	Branch      string    `json:"branch"`
+	Target      string    `json:"target"`
+	Worker      string    `json:"worker"`
+	SourceIssue string    `json:"source_issue"`
+	Title       string    `json:"title,omitempty"`
+	Priority    int       `json:"priority"`
+	Score       float64   `json:"score"`
+	CreatedAt   time.Time `json:"created_at"`
+	RetryCount  int       `json:"retry_count,omitempty"`
+	ClaimedBy   string    `json:"claimed_by,omitempty"`
+	BlockedBy   string    `json:"blocked_by,omitempty"`
+	ConvoyID    string    `json:"convoy_id,omitempty"`
+	PRURL       string    `json:"pr_url,omitempty"`
+}
+
+// MailRow is a message in a mailbox.
+type MailRow struct {
+	ID       string    `json:"id"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Subject  string    `json:"subject"`
+	Body     string    `json:"body"`
+	Priority string    `json:"priority"`
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Read     bool      `json:"read"`
+}
+
+// ConsoleOptions configures a console.
+type ConsoleOptions struct {
+	// Token authenticates browsers and API clients. When set, every
+	// request must present it; without it the console is read-only.
+	Token string
+
+	// Actions performs sling, nudge, MR and nuke actions. Nil makes the
+	// console read-only.
+	Actions ActionRunner
+
+	// Updates feeds live page updates. Nil disables them; pages then
+	// refresh on a timer.
+	Updates UpdateSource
+}
+
+// Console is the web console: the convoy dashboard plus per-rig pages, the
+// merge queue, mail, a JSON API with actions, and live updates.
+type Console struct {
+	mux      *http.ServeMux
+	convoys  *ConvoyHandler
+	fetcher  ConsoleFetcher
+	actions  ActionRunner
+	updates  UpdateSource
+	token    string
+	template *template.Template
+}
+
+// consolePage is the data passed to console page templates.
+type consolePage struct {
+	Title     string
+	Actions   bool
+	Rigs      []RigRow
+	Rig       *RigData
+	RigName   string
+	Queue     []QueueRow
+	Address   string
+	Addresses []string
+	Mail      []MailRow
+	Error     string
+}
+
+// NewConsole creates a console serving convoys from convoyFetcher and
+// everything else from fetcher.
+func NewConsole(convoyFetcher ConvoyFetcher, fetcher ConsoleFetcher, opts ConsoleOptions) (*Console, error) {
+	if opts.Actions != nil && opts.Token == "" {
+		return nil, errors.New("console actions require a token")
+	}
+	convoys, err := NewConvoyHandler(convoyFetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Console{
+		mux:      http.NewServeMux(),
+		convoys:  convoys,
+		fetcher:  fetcher,
+		actions:  opts.Actions,
+		updates:  opts.Updates,
+		token:    opts.Token,
+		template: convoys.template,
+	}
+
+	c.mux.Handle("GET /{$}", convoys)
+	c.mux.HandleFunc("GET /rigs", c.serveRigs)
+	c.mux.HandleFunc("GET /rigs/{rig}", c.serveRig)
+	c.mux.HandleFunc("GET /rigs/{rig}/mq", c.serveQueue)
+	c.mux.HandleFunc("GET /mail", c.serveMail)
+	c.mux.HandleFunc("GET /events", c.serveEvents)
+
+	c.mux.HandleFunc("GET /api/convoys", c.apiConvoys)
+	c.mux.HandleFunc("GET /api/rigs", c.apiRigs)
+	c.mux.HandleFunc("GET /api/rigs/{rig}", c.apiRig)
+	c.mux.HandleFunc("GET /api/rigs/{rig}/mq", c.apiQueue)
+	c.mux.HandleFunc("GET /api/mail", c.apiMail)
+	c.mux.HandleFunc("POST /api/actions/{action}", c.apiAction)
+	return c, nil
+}
+
+// ServeHTTP authenticates the request and dispatches it.
+func (c *Console) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c.token != "" {
+		// A token in the URL logs the browser in and is then dropped from
+		// the address bar.
+		if t := r.URL.Query().Get("token"); t != "" && r.Method == http.MethodGet {
+			if !c.validToken(t) {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookie,
+				Value:    t,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			q := r.URL.Query()
+			q.Del("token")
+			u := *r.URL
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.RequestURI(), http.StatusSeeOther)
+			return
+		}
+		if !c.authenticated(r) {
+			if isAPI(r) {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			} else {
+				http.Error(w, "Unauthorized: open the URL printed by gt dashboard, which includes ?token=", http.StatusUnauthorized)
+			}
+			return
+		}
+	}
+	c.mux.ServeHTTP(w, r)
+}
+
+// authenticated reports whether a request carries the console token as a
+// bearer token or cookie.
+func (c *Console) authenticated(r *http.Request) bool {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return c.validToken(token)
+	}
+	if cookie, err := r.Cookie(tokenCookie); err == nil {
+		return c.validToken(cookie.Value)
+	}
+	return false
+}
+
+func (c *Console) validToken(t string) bool {
+	return subtle.ConstantTimeCompare([]byte(t), []byte(c.token)) == 1
+}
+
+// actionsEnabled reports whether the console accepts actions.
+func (c *Console) actionsEnabled() bool {
+	return c.actions != nil && c.token != ""
+}
+
+func isAPI(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// render executes a page template.
+func (c *Console) render(w http.ResponseWriter, name string, page consolePage) {
+	page.Actions = c.actionsEnabled()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := c.template.ExecuteTemplate(w, name, page); err != nil {
+		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	}
+}
+
+func (c *Console) serveRigs(w http.ResponseWriter, r *http.Request) {
+	page := consolePage{Title: "Rigs"}
+	rigs, err := c.fetcher.FetchRigs()
+	if err != nil {
+		page.Error = err.Error()
+	}
+	page.Rigs = rigs
+	c.render(w, "rigs.html", page)
+}
+
+func (c *Console) serveRig(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("rig")
+	data, err := c.fetcher.FetchRig(name)
+	if errors.Is(err, ErrRigNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	page := consolePage{Title: name, RigName: name, Rig: data}
+	if err != nil {
+		page.Error = err.Error()
+	}
+	c.render(w, "rig.html", page)
+}
+
+func (c *Console) serveQueue(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("rig")
+	queue, err := c.fetcher.FetchQueue(name)
+	if errors.Is(err, ErrRigNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	page := consolePage{Title: name + " merge queue", RigName: name, Queue: queue}
+	if err != nil {
+		page.Error = err.Error()
+	}
+	c.render(w, "mq.html", page)
+}
+
+func (c *Console) serveMail(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	page := consolePage{Title: "Mail", Address: address}
+
+	if rigs, err := c.fetcher.FetchRigs(); err == nil {
+		page.Addresses = mailAddresses(rigs)
+	}
+	if address != "" {
+		mail, err := c.fetcher.FetchMailbox(address)
+		if err != nil {
+			page.Error = err.Error()
+		}
+		page.Mail = mail
+	}
+	c.render(w, "mail.html", page)
+}
+
+// mailAddresses lists the mailboxes offered on the mail page: town agents,
+// then each rig's witness, refinery and polecats.
+func mailAddresses(rigs []RigRow) []string {
+	addresses := []string{"overseer", "mayor/", "deacon/"}
+	for _, rig := range rigs {
+		addresses = append(addresses, rig.Name+"/witness", rig.Name+"/refinery")
+		polecats := append([]string(nil), rig.Polecats...)
+		sort.Strings(polecats)
+		for _, p := range polecats {
+			addresses = append(addresses, rig.Name+"/"+p)
+		}
+	}
+	return addresses
+}
+
+func (c *Console) apiConvoys(w http.ResponseWriter, r *http.Request) {
+	convoys, err := c.convoys.fetcher.FetchConvoys()
+	writeResult(w, convoys, err)
+}
+
+func (c *Console) apiRigs(w http.ResponseWriter, r *http.Request) {
+	rigs, err := c.fetcher.FetchRigs()
+	writeResult(w, rigs, err)
+}
+
+func (c *Console) apiRig(w http.ResponseWriter, r *http.Request) {
+	data, err := c.fetcher.FetchRig(r.PathValue("rig"))
+	writeResult(w, data, err)
+}
+
+func (c *Console) apiQueue(w http.ResponseWriter, r *http.Request) {
+	queue, err := c.fetcher.FetchQueue(r.PathValue("rig"))
+	writeResult(w, queue, err)
+}
+
+func (c *Console) apiMail(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "address is required"})
+		return
+	}
+	mail, err := c.fetcher.FetchMailbox(address)
+	writeResult(w, mail, err)
+}
+
+// apiAction validates and performs an action.
+func (c *Console) apiAction(w http.ResponseWriter, r *http.Request) {
+	action := r.PathValue("action")
+	result := ActionResult{Action: action}
+	if !c.actionsEnabled() {
+		result.Error = "console is read-only"
+		writeJSON(w, http.StatusForbidden, result)
+		return
+	}
+	// Requiring JSON means a cross-site form post can't trigger an action.
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != "application/json" {
+		result.Error = "Content-Type must be application/json"
+		writeJSON(w, http.StatusUnsupportedMediaType, result)
+		return
+	}
+
+	var req ActionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxActionBody)).Decode(&req); err != nil {
+		result.Error = "invalid request body: " + err.Error()
+		writeJSON(w, http.StatusBadRequest, result)
+		return
+	}
+	if _, err := actionArgs(action, req); err != nil {
+		result.Error = err.Error()
+		writeJSON(w, http.StatusBadRequest, result)
+		return
+	}
+
+	output, err := c.actions.Run(r.Context(), action, req)
+	result.Output = output
+	if err != nil {
+		result.Error = err.Error()
+		writeJSON(w, http.StatusInternalServerError, result)
+		return
+	}
+	result.OK = true
+	writeJSON(w, http.StatusOK, result)
+}
+
+// writeResult writes v as JSON, or the error with a matching status.
+func writeResult(w http.ResponseWriter, v interface{}, err error) {
+	switch {
+	case errors.Is(err, ErrRigNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
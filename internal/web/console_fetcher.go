@@ -0,0 +1,247 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/mrqueue"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/witness"
+)
+
+// LiveConsoleFetcher reads rig, merge queue and mail state for a town.
+type LiveConsoleFetcher struct {
+	townRoot string
+}
+
+// NewLiveConsoleFetcher creates a fetcher for the town at townRoot.
+func NewLiveConsoleFetcher(townRoot string) *LiveConsoleFetcher {
+	return &LiveConsoleFetcher{townRoot: townRoot}
+}
+
+// rigManager loads rigs.json on every call so rigs added while the
+// console runs show up.
+func (f *LiveConsoleFetcher) rigManager() *rig.Manager {
+	rigsConfig, err := config.LoadRigsConfig(constants.MayorRigsPath(f.townRoot))
+	if err != nil {
+		rigsConfig = &config.RigsConfig{Rigs: make(map[string]config.RigEntry)}
+	}
+	return rig.NewManager(f.townRoot, rigsConfig, git.NewGit(f.townRoot))
+}
+
+func (f *LiveConsoleFetcher) getRig(name string) (*rig.Rig, error) {
+	r, err := f.rigManager().GetRig(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrRigNotFound, name)
+	}
+	return r, nil
+}
+
+// FetchRigs summarizes every rig in the town.
+func (f *LiveConsoleFetcher) FetchRigs() ([]RigRow, error) {
+	rigs, err := f.rigManager().DiscoverRigs()
+	if err != nil {
+		return nil, fmt.Errorf("discovering rigs: %w", err)
+	}
+	sessions := sessionActivity()
+
+	rows := make([]RigRow, 0, len(rigs))
+	for _, r := range rigs {
+		row := RigRow{
+			Name:       r.Name,
+			Polecats:   r.Polecats,
+			QueueDepth: mrqueue.New(r.Path).Count(),
+		}
+		_, row.WitnessRunning = sessions[session.WitnessSessionName(r.Name)]
+		_, row.RefineryRunning = sessions[session.RefinerySessionName(r.Name)]
+		for _, p := range r.Polecats {
+			if _, ok := sessions[session.PolecatSessionName(r.Name, p)]; ok {
+				row.Running++
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+	return rows, nil
+}
+
+// FetchRig returns a rig's witness, refinery, polecats and queue.
+func (f *LiveConsoleFetcher) FetchRig(name string) (*RigData, error) {
+	r, err := f.getRig(name)
+	if err != nil {
+		return nil, err
+	}
+	sessions := sessionActivity()
+
+	data := &RigData{Name: r.Name}
+
+	data.Witness = agentRow(sessions, session.WitnessSessionName(r.Name))
+	if w, err := witness.NewManager(r).Status(); err == nil {
+		data.Witness.State = string(w.State)
+	}
+
+	data.Refinery = agentRow(sessions, session.RefinerySessionName(r.Name))
+	if ref, err := refinery.NewManager(r).Status(); err == nil {
+		data.Refinery.State = string(ref.State)
+		if ref.CurrentMR != nil {
+			data.Refinery.StatusHint = "Merging " + ref.CurrentMR.Branch
+		}
+	}
+
+	polecats, err := polecat.NewManager(r, git.NewGit(r.Path)).List()
+	if err != nil {
+		return nil, fmt.Errorf("listing polecats: %w", err)
+	}
+	for _, p := range polecats {
+		row := RigPolecatRow{
+			Name:    p.Name,
+			State:   string(p.State),
+			Issue:   p.Issue,
+			Branch:  p.Branch,
+			Session: session.PolecatSessionName(r.Name, p.Name),
+		}
+		if at, ok := sessions[row.Session]; ok {
+			row.Running = true
+			row.ActiveAt = at
+			row.StatusHint = paneHint(row.Session)
+		}
+		data.Polecats = append(data.Polecats, row)
+	}
+	sort.Slice(data.Polecats, func(i, j int) bool { return data.Polecats[i].Name < data.Polecats[j].Name })
+
+	data.Queue, err = f.queue(r)
+	if err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// FetchQueue returns a rig's merge queue in processing order.
+func (f *LiveConsoleFetcher) FetchQueue(name string) ([]QueueRow, error) {
+	r, err := f.getRig(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.queue(r)
+}
+
+func (f *LiveConsoleFetcher) queue(r *rig.Rig) ([]QueueRow, error) {
+	mrs, err := mrqueue.New(r.Path).ListByScore()
+	if err != nil {
+		return nil, fmt.Errorf("reading merge queue: %w", err)
+	}
+	now := time.Now()
+	rows := make([]QueueRow, 0, len(mrs))
+	for _, mr := range mrs {
+		rows = append(rows, QueueRow{
+			ID:          mr.ID,
+			Branch:      mr.Branch,
+			Target:      mr.Target,
+			Worker:      mr.Worker,
+			SourceIssue: mr.SourceIssue,
+			Title:       mr.Title,
+			Priority:    mr.Priority,
+			Score:       mr.ScoreAt(now),
+			CreatedAt:   mr.CreatedAt,
+			RetryCount:  mr.RetryCount,
+			ClaimedBy:   mr.ClaimedBy,
+			BlockedBy:   mr.BlockedBy,
+			ConvoyID:    mr.ConvoyID,
+			PRURL:       mr.PRURL,
+		})
+	}
+	return rows, nil
+}
+
+// FetchMailbox returns the messages in an agent's mailbox, newest first.
+func (f *LiveConsoleFetcher) FetchMailbox(address string) ([]MailRow, error) {
+	mailbox, err := mail.NewRouterWithTownRoot(f.townRoot, f.townRoot).GetMailbox(address)
+	if err != nil {
+		return nil, fmt.Errorf("opening mailbox %s: %w", address, err)
+	}
+	messages, err := mailbox.List()
+	if err != nil {
+		return nil, fmt.Errorf("listing mail for %s: %w", address, err)
+	}
+
+	rows := make([]MailRow, 0, len(messages))
+	for _, m := range messages {
+		rows = append(rows, MailRow{
+			ID:       m.ID,
+			From:     m.From,
+			To:       m.To,
+			Subject:  m.Subject,
+			Body:     m.Body,
+			Priority: string(m.Priority),
+			Type:     string(m.Type),
+			Time:     m.Timestamp,
+			Read:     m.Read,
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Time.After(rows[j].Time) })
+	return rows, nil
+}
+
+// agentRow returns the session state of a witness or refinery.
+func agentRow(sessions map[string]*time.Time, name string) AgentRow {
+	row := AgentRow{Session: name}
+	if at, ok := sessions[name]; ok {
+		row.Running = true
+		row.ActiveAt = at
+	}
+	return row
+}
+
+// sessionActivity returns the running tmux sessions and their last window
+// activity (nil when tmux doesn't report one).
+func sessionActivity() map[string]*time.Time {
+	sessions := make(map[string]*time.Time)
+	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}|#{window_activity}")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		// tmux not running or no sessions
+		return sessions
+	}
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		name, ts, _ := strings.Cut(line, "|")
+		if name == "" {
+			continue
+		}
+		if unix, ok := parseActivityTimestamp(ts); ok {
+			t := time.Unix(unix, 0)
+			sessions[name] = &t
+		} else {
+			sessions[name] = nil
+		}
+	}
+	return sessions
+}
+
+// paneHint returns the last non-empty line of a session's pane.
+func paneHint(sessionName string) string {
+	cmd := exec.Command("tmux", "capture-pane", "-t", sessionName, "-p", "-J") //nolint:gosec // G204: session name is built from rig config
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(lines[i]); line != "" {
+			return truncateStatusHint(line)
+		}
+	}
+	return ""
+}
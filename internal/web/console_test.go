@@ -0,0 +1,287 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "s3cret"
+
+// mockConsoleFetcher serves canned rig, queue and mail data.
+type mockConsoleFetcher struct {
+	Rigs []RigRow
+	Rig  *RigData
+	Mail map[string][]MailRow
+}
+
+func (m *mockConsoleFetcher) FetchRigs() ([]RigRow, error) { return m.Rigs, nil }
+
+func (m *mockConsoleFetcher) FetchRig(name string) (*RigData, error) {
+	if m.Rig == nil || m.Rig.Name != name {
+		return nil, fmt.Errorf("%w: %s", ErrRigNotFound, name)
+	}
+	return m.Rig, nil
+}
+
+func (m *mockConsoleFetcher) FetchQueue(name string) ([]QueueRow, error) {
+	rig, err := m.FetchRig(name)
+	if err != nil {
+		return nil, err
+	}
+	return rig.Queue, nil
+}
+
+func (m *mockConsoleFetcher) FetchMailbox(address string) ([]MailRow, error) {
+	return m.Mail[address], nil
+}
+
+// recordingActions records the actions it is asked to run.
+type recordingActions struct {
+	calls []string
+	reqs  []ActionRequest
+}
+
+func (a *recordingActions) Run(_ context.Context, action string, req ActionRequest) (string, error) {
+	a.calls = append(a.calls, action)
+	a.reqs = append(a.reqs, req)
+	return "done", nil
+}
+
+// chanUpdates hands out a fixed channel of updates.
+type chanUpdates chan Update
+
+func (c chanUpdates) Updates(context.Context) (<-chan Update, error) { return c, nil }
+
+func testFetcher() *mockConsoleFetcher {
+	return &mockConsoleFetcher{
+		Rigs: []RigRow{{Name: "gastown", Polecats: []string{"nux", "furiosa"}, Running: 1, WitnessRunning: true, QueueDepth: 1}},
+		Rig: &RigData{
+			Name:     "gastown",
+			Witness:  AgentRow{Session: "gt-gastown-witness", Running: true, State: "running"},
+			Refinery: AgentRow{Session: "gt-gastown-refinery", State: "stopped"},
+			Polecats: []RigPolecatRow{{Name: "nux", State: "working", Issue: "gt-42", Session: "gt-gastown-nux", Running: true}},
+			Queue:    []QueueRow{{ID: "gt-mr-1", Branch: "polecat/nux", Target: "main", Worker: "nux", SourceIssue: "gt-42"}},
+		},
+		Mail: map[string][]MailRow{
+			"mayor/": {{ID: "hq-m1", From: "gastown/witness", To: "mayor/", Subject: "Polecat stuck", Body: "nux idle for 2h", Priority: "high"}},
+		},
+	}
+}
+
+func newTestConsole(t *testing.T, opts ConsoleOptions) *Console {
+	t.Helper()
+	c, err := NewConsole(&MockConvoyFetcher{}, testFetcher(), opts)
+	if err != nil {
+		t.Fatalf("NewConsole() error = %v", err)
+	}
+	return c
+}
+
+func serve(c *Console, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, r)
+	return w
+}
+
+func TestConsole_TokenAuth(t *testing.T) {
+	c := newTestConsole(t, ConsoleOptions{Token: testToken})
+
+	if w := serve(c, httptest.NewRequest("GET", "/rigs", nil)); w.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", w.Code)
+	}
+	if w := serve(c, httptest.NewRequest("GET", "/api/rigs", nil)); w.Code != http.StatusUnauthorized ||
+		!strings.Contains(w.Body.String(), `"unauthorized"`) {
+		t.Errorf("API without token: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(c, httptest.NewRequest("GET", "/?token=wrong", nil)); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", w.Code)
+	}
+
+	// The token in the URL sets a cookie and redirects it out of the URL.
+	w := serve(c, httptest.NewRequest("GET", "/rigs?token="+testToken, nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/rigs" {
+		t.Fatalf("login: status = %d, location = %q", w.Code, w.Header().Get("Location"))
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("cookies = %+v", cookies)
+	}
+
+	req := httptest.NewRequest("GET", "/rigs", nil)
+	req.AddCookie(cookies[0])
+	if w := serve(c, req); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "gastown") {
+		t.Errorf("with cookie: status = %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/api/rigs", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	w = serve(c, req)
+	var rigs []RigRow
+	if err := json.Unmarshal(w.Body.Bytes(), &rigs); err != nil || len(rigs) != 1 || rigs[0].QueueDepth != 1 {
+		t.Errorf("api/rigs = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConsole_Actions(t *testing.T) {
+	post := func(c *Console, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		return serve(c, req)
+	}
+
+	readOnly := newTestConsole(t, ConsoleOptions{Token: testToken})
+	if w := post(readOnly, "/api/actions/sling", "application/json", `{"bead":"gt-1","target":"gastown"}`); w.Code != http.StatusForbidden {
+		t.Errorf("read-only console: status = %d, want 403", w.Code)
+	}
+
+	if _, err := NewConsole(&MockConvoyFetcher{}, testFetcher(), ConsoleOptions{Actions: &recordingActions{}}); err == nil {
+		t.Error("actions without a token should be refused")
+	}
+
+	actions := &recordingActions{}
+	c := newTestConsole(t, ConsoleOptions{Token: testToken, Actions: actions})
+
+	if w := post(c, "/api/actions/sling", "application/x-www-form-urlencoded", "bead=gt-1"); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("form post: status = %d, want 415", w.Code)
+	}
+	if w := post(c, "/api/actions/sling", "application/json", `{"bead":"--force","target":"gastown"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("flag-like bead: status = %d, want 400", w.Code)
+	}
+	if w := post(c, "/api/actions/mq-reject", "application/json", `{"rig":"gastown","mr":"gt-mr-1"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("reject without reason: status = %d, want 400", w.Code)
+	}
+	if len(actions.calls) != 0 {
+		t.Fatalf("invalid requests reached the runner: %v", actions.calls)
+	}
+
+	w := post(c, "/api/actions/polecat-nuke", "application/json", `{"rig":"gastown","polecat":"nux"}`)
+	var result ActionResult
+	_ = json.Unmarshal(w.Body.Bytes(), &result)
+	if w.Code != http.StatusOK || !result.OK || result.Output != "done" {
+		t.Fatalf("nuke: %d %+v", w.Code, result)
+	}
+	if len(actions.reqs) != 1 || actions.calls[0] != ActionNuke || actions.reqs[0].Polecat != "nux" {
+		t.Errorf("runner got %v %+v", actions.calls, actions.reqs)
+	}
+}
+
+func TestActionArgs(t *testing.T) {
+	tests := []struct {
+		action string
+		req    ActionRequest
+		want   []string
+	}{
+		{ActionSling, ActionRequest{Bead: "gt-abc", Target: "gastown"}, []string{"sling", "gt-abc", "gastown"}},
+		{ActionNudge, ActionRequest{Target: "gastown/nux", Message: "-rf check mail"}, []string{"nudge", "gastown/nux", "--message=-rf check mail"}},
+		{ActionRetryMR, ActionRequest{Rig: "gastown", MR: "gt-mr-1"}, []string{"mq", "retry", "gastown", "gt-mr-1"}},
+		{ActionRejectMR, ActionRequest{Rig: "gastown", MR: "polecat/nux", Reason: "superseded"},
+			[]string{"mq", "reject", "gastown", "polecat/nux", "--reason=superseded", "--notify"}},
+		{ActionNuke, ActionRequest{Rig: "gastown", Polecat: "nux", Force: true}, []string{"polecat", "nuke", "gastown/nux", "--force"}},
+	}
+	for _, tt := range tests {
+		got, err := actionArgs(tt.action, tt.req)
+		if err != nil {
+			t.Errorf("%s: %v", tt.action, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: args = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+
+	if _, err := actionArgs(ActionNudge, ActionRequest{Target: "gastown/nux"}); err == nil {
+		t.Error("nudge without a message should fail")
+	}
+	if _, err := actionArgs("rm", ActionRequest{}); err == nil {
+		t.Error("unknown action should fail")
+	}
+}
+
+func TestConsole_Pages(t *testing.T) {
+	c := newTestConsole(t, ConsoleOptions{Token: testToken, Actions: &recordingActions{}})
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: tokenCookie, Value: testToken})
+		return serve(c, req)
+	}
+
+	w := get("/rigs/gastown")
+	body := w.Body.String()
+	for _, want := range []string{"gt-gastown-witness", "nux", "gt-42", "gt-mr-1", "Nuke", "Sling to gastown", `hx-trigger="gt:update from:body"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("rig page missing %q", want)
+		}
+	}
+	if w := get("/rigs/nowhere"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown rig: status = %d, want 404", w.Code)
+	}
+
+	body = get("/rigs/gastown/mq").Body.String()
+	if !strings.Contains(body, "polecat/nux") || !strings.Contains(body, "Reject") {
+		t.Error("mq page should list the MR with actions")
+	}
+
+	body = get("/mail?address=mayor/").Body.String()
+	for _, want := range []string{"Polecat stuck", "nux idle for 2h", "gastown/furiosa"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("mail page missing %q", want)
+		}
+	}
+
+	readOnly := newTestConsole(t, ConsoleOptions{})
+	w = httptest.NewRecorder()
+	readOnly.ServeHTTP(w, httptest.NewRequest("GET", "/rigs/gastown", nil))
+	if strings.Contains(w.Body.String(), "Nuke") {
+		t.Error("read-only rig page should not offer actions")
+	}
+}
+
+func TestConsole_EventStream(t *testing.T) {
+	updates := make(chanUpdates, 1)
+	c := newTestConsole(t, ConsoleOptions{Token: testToken, Updates: updates})
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/events", nil)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	updates <- Update{Topic: "merge.merged", Type: "merged", Actor: "gastown/refinery"}
+
+	scanner := bufio.NewScanner(resp.Body)
+	var event string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && event == "update" {
+			var u Update
+			if err := json.Unmarshal([]byte(data), &u); err != nil || u.Topic != "merge.merged" {
+				t.Errorf("update = %s", data)
+			}
+			return
+		}
+	}
+	t.Fatalf("no update received: %v", scanner.Err())
+}
@@ -10,12 +10,14 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/activity"
+	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // LiveConvoyFetcher fetches convoy data from beads.
 type LiveConvoyFetcher struct {
 	townBeads string
+	store     beads.Store
 }
 
 // NewLiveConvoyFetcher creates a fetcher for the current workspace.
@@ -27,6 +29,7 @@ func NewLiveConvoyFetcher() (*LiveConvoyFetcher, error) {
 
 	return &LiveConvoyFetcher{
 		townBeads: filepath.Join(townRoot, ".beads"),
+		store:     beads.SharedStore(townRoot),
 	}, nil
 }
 
@@ -34,27 +37,11 @@ func NewLiveConvoyFetcher() (*LiveConvoyFetcher, error) {
 // FetchConvoys fetches all open convoys with their activity data.
 func (f *LiveConvoyFetcher) FetchConvoys() ([]ConvoyRow, error) {
 	// List all open convoy-type issues
-	listArgs := []string{"list", "--type=convoy", "--status=open", "--json"}
-	listCmd := exec.Command("bd", listArgs...)
-	listCmd.Dir = f.townBeads
-
-	var stdout bytes.Buffer
-	listCmd.Stdout = &stdout
-
-	if err := listCmd.Run(); err != nil {
+	convoys, err := f.store.List(beads.ListOptions{Type: "convoy", Status: "open", Priority: -1})
+	if err != nil {
 		return nil, fmt.Errorf("listing convoys: %w", err)
 	}
 
-	var convoys []struct {
-		ID        string `json:"id"`
-		Title     string `json:"title"`
-		Status    string `json:"status"`
-		CreatedAt string `json:"created_at"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &convoys); err != nil {
-		return nil, fmt.Errorf("parsing convoy list: %w", err)
-	}
-
 	// Build convoy rows with activity data
 	rows := make([]ConvoyRow, 0, len(convoys))
 	for _, c := range convoys {
@@ -152,32 +139,18 @@ type trackedIssueInfo struct {
 
 // getTrackedIssues fetches tracked issues for a convoy.
 func (f *LiveConvoyFetcher) getTrackedIssues(convoyID string) []trackedIssueInfo {
-	dbPath := filepath.Join(f.townBeads, "beads.db")
-
-	// Query tracked dependencies from SQLite
-	safeConvoyID := strings.ReplaceAll(convoyID, "'", "''")
-	// #nosec G204 -- sqlite3 path is from trusted config, convoyID is escaped
-	queryCmd := exec.Command("sqlite3", "-json", dbPath,
-		fmt.Sprintf(`SELECT depends_on_id, type FROM dependencies WHERE issue_id = '%s' AND type = 'tracks'`, safeConvoyID))
-
-	var stdout bytes.Buffer
-	queryCmd.Stdout = &stdout
-	if err := queryCmd.Run(); err != nil {
-		return nil
-	}
-
-	var deps []struct {
-		DependsOnID string `json:"depends_on_id"`
-		Type        string `json:"type"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &deps); err != nil {
+	convoy, err := f.store.Show(convoyID)
+	if err != nil {
 		return nil
 	}
 
 	// Collect issue IDs (normalize external refs)
-	issueIDs := make([]string, 0, len(deps))
-	for _, dep := range deps {
-		issueID := dep.DependsOnID
+	issueIDs := make([]string, 0, len(convoy.Dependencies))
+	for _, dep := range convoy.Dependencies {
+		if dep.DependencyType != "tracks" {
+			continue
+		}
+		issueID := dep.ID
 		if strings.HasPrefix(issueID, "external:") {
 			parts := strings.SplitN(issueID, ":", 3)
 			if len(parts) == 3 {
@@ -234,30 +207,12 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) map[string]*
 		return result
 	}
 
-	args := append([]string{"show"}, issueIDs...)
-	args = append(args, "--json")
-
-	// #nosec G204 -- bd is a trusted internal tool, args are issue IDs
-	showCmd := exec.Command("bd", args...)
-	var stdout bytes.Buffer
-	showCmd.Stdout = &stdout
-
-	if err := showCmd.Run(); err != nil {
-		return result
-	}
-
-	var issues []struct {
-		ID        string `json:"id"`
-		Title     string `json:"title"`
-		Status    string `json:"status"`
-		Assignee  string `json:"assignee"`
-		UpdatedAt string `json:"updated_at"`
-	}
-	if err := json.Unmarshal(stdout.Bytes(), &issues); err != nil {
+	issues, err := f.store.ShowMultiple(issueIDs)
+	if err != nil {
 		return result
 	}
 
-	for _, issue := range issues {
+	for id, issue := range issues {
 		detail := &issueDetail{
 			ID:       issue.ID,
 			Title:    issue.Title,
@@ -270,7 +225,7 @@ func (f *LiveConvoyFetcher) getIssueDetailsBatch(issueIDs []string) map[string]*
 				detail.UpdatedAt = t
 			}
 		}
-		result[issue.ID] = detail
+		result[id] = detail
 	}
 
 	return result
@@ -669,26 +624,7 @@ func (f *LiveConvoyFetcher) FetchPolecats() ([]PolecatRow, error) {
 
 // getPolecatStatusHint captures the last non-empty line from a polecat's pane.
 func (f *LiveConvoyFetcher) getPolecatStatusHint(sessionName string) string {
-	cmd := exec.Command("tmux", "capture-pane", "-t", sessionName, "-p", "-J")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-	if err := cmd.Run(); err != nil {
-		return ""
-	}
-
-	// Get last non-empty line
-	lines := strings.Split(stdout.String(), "\n")
-	for i := len(lines) - 1; i >= 0; i-- {
-		line := strings.TrimSpace(lines[i])
-		if line != "" {
-			// Truncate long lines
-			if len(line) > 60 {
-				line = line[:57] + "..."
-			}
-			return line
-		}
-	}
-	return ""
+	return paneHint(sessionName)
 }
 
 // getMergeQueueCount returns the total number of open PRs across all repos.
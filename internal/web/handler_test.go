@@ -686,7 +686,7 @@ func TestE2E_Server_FullDashboard(t *testing.T) {
 		{"Polecat section", "Polecat Workers"},
 		{"Polecat name", "furiosa"},
 		{"Polecat status", "Running E2E tests"},
-		{"HTMX auto-refresh", `hx-trigger="every 10s"`},
+		{"Live updates", `hx-trigger="gt:update from:body"`},
 	}
 
 	for _, check := range checks {
@@ -0,0 +1,140 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/eventbus"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// TopicRefresh is sent when there is no event bus to follow, so pages
+// still refresh periodically.
+const TopicRefresh = "refresh"
+
+const (
+	// fallbackInterval is how often pages refresh, and the bus is retried,
+	// while the daemon isn't running.
+	fallbackInterval = 10 * time.Second
+
+	// keepaliveInterval keeps idle event streams from being closed by
+	// proxies.
+	keepaliveInterval = 30 * time.Second
+)
+
+// Update is a town event pushed to console pages over server-sent events.
+type Update struct {
+	Topic   string `json:"topic"`
+	Type    string `json:"type,omitempty"`
+	Actor   string `json:"actor,omitempty"`
+	Time    string `json:"time,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// UpdateSource streams updates until ctx is cancelled.
+type UpdateSource interface {
+	Updates(ctx context.Context) (<-chan Update, error)
+}
+
+// BusUpdates streams updates from the daemon's event bus. While the daemon
+// is down it sends TopicRefresh every few seconds and keeps retrying.
+type BusUpdates struct {
+	TownRoot string
+}
+
+// Updates starts following the bus.
+func (b *BusUpdates) Updates(ctx context.Context) (<-chan Update, error) {
+	out := make(chan Update, 16)
+	go b.run(ctx, out)
+	return out, nil
+}
+
+func (b *BusUpdates) run(ctx context.Context, out chan<- Update) {
+	defer close(out)
+	for {
+		stream, err := eventbus.Subscribe(ctx, b.TownRoot, eventbus.SubscribeOptions{From: eventbus.FromLive})
+		if err == nil {
+			for msg := range stream.Messages() {
+				select {
+				case out <- updateFor(msg.Topic, msg.Event):
+				case <-ctx.Done():
+				}
+			}
+			stream.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(fallbackInterval):
+		}
+		select {
+		case out <- Update{Topic: TopicRefresh}:
+		default:
+		}
+	}
+}
+
+// updateFor summarizes a bus event for the browser.
+func updateFor(topic string, ev events.Event) Update {
+	summary := ev.Type
+	if ev.Actor != "" {
+		summary = fmt.Sprintf("%s: %s", ev.Actor, ev.Type)
+	}
+	return Update{
+		Topic:   topic,
+		Type:    ev.Type,
+		Actor:   ev.Actor,
+		Time:    ev.Timestamp,
+		Summary: summary,
+	}
+}
+
+// serveEvents streams updates as server-sent events. Pages listen on
+// /events and re-fetch their content when something happens.
+func (c *Console) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if c.updates == nil {
+		http.Error(w, "live updates are not available", http.StatusServiceUnavailable)
+		return
+	}
+	updates, err := c.updates.Updates(r.Context())
+	if err != nil {
+		http.Error(w, "subscribing to updates: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Event streams outlive the server's write timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	fmt.Fprintf(w, "retry: %d\n\n", fallbackInterval.Milliseconds())
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case u, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(u)
+			fmt.Fprintf(w, "event: update\ndata: %s\n\n", data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
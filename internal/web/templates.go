@@ -5,6 +5,7 @@ import (
 	"embed"
 	"html/template"
 	"io/fs"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/activity"
 )
@@ -68,6 +69,15 @@ func LoadTemplates() (*template.Template, error) {
 		"statusClass":     statusClass,
 		"workStatusClass": workStatusClass,
 		"progressPercent": progressPercent,
+		"activityOf":      activityOf,
+		"age":             age,
+		"inc":             func(i int) int { return i + 1 },
+		"agentRow": func(role string, agent AgentRow, actions bool) agentRowData {
+			return agentRowData{Role: role, Agent: agent, Actions: actions}
+		},
+		"queueTable": func(rig string, queue []QueueRow, actions bool) queueTableData {
+			return queueTableData{Rig: rig, Queue: queue, Actions: actions}
+		},
 	}
 
 	// Get the templates subdirectory
@@ -136,3 +146,33 @@ func progressPercent(completed, total int) int {
 	}
 	return (completed * 100) / total
 }
+
+// agentRowData is the data for the agent-row template.
+type agentRowData struct {
+	Role    string
+	Agent   AgentRow
+	Actions bool
+}
+
+// queueTableData is the data for the queue-table template.
+type queueTableData struct {
+	Rig     string
+	Queue   []QueueRow
+	Actions bool
+}
+
+// activityOf returns activity info for an optional last-activity time.
+func activityOf(t *time.Time) activity.Info {
+	if t == nil {
+		return activity.Info{FormattedAge: "-", ColorClass: activity.ColorUnknown}
+	}
+	return activity.Calculate(*t)
+}
+
+// age formats the time since t, e.g. "5m" or "2h".
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return activity.Calculate(t).FormattedAge
+}
@@ -0,0 +1,286 @@
+package wisp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Digest kinds.
+const (
+	KindPatrol = "patrol" // a deacon, witness or refinery patrol cycle
+	KindWork   = "work"   // a piece of work, such as a polecat's molecule
+)
+
+// Digest periods. A cycle digest records squashed molecules; the others
+// are rollups of the cycle digests of one agent over a calendar period.
+const (
+	PeriodCycle = "cycle"
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// maxRollupSummaries caps the summaries carried by a folded digest or rollup.
+const maxRollupSummaries = 20
+
+// DigestStep is one step of a squashed molecule.
+type DigestStep struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Status  string            `json:"status"`
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// Digest is the compact record left by squashing a molecule, or a rollup
+// of an agent's digests over a day, week or month.
+type Digest struct {
+	Period   string `json:"period"`
+	Kind     string `json:"kind"`
+	Agent    string `json:"agent"`
+	Molecule string `json:"molecule,omitempty"`
+	Formula  string `json:"formula,omitempty"`
+	BeadID   string `json:"bead_id,omitempty"` // the closed digest bead, if one was created
+
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Cycles int       `json:"cycles"` // squashed molecules covered
+
+	Steps     []DigestStep       `json:"steps,omitempty"` // of the latest molecule
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Summaries []string           `json:"summaries,omitempty"`
+
+	// Body is the rendered summary.
+	Body string `json:"body,omitempty"`
+}
+
+// Duration returns the time the digest covers.
+func (d *Digest) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Summary returns the digest's summaries, one per line.
+func (d *Digest) Summary() string {
+	return strings.Join(d.Summaries, "\n")
+}
+
+// Metric returns a metric, or 0 if it wasn't recorded.
+func (d *Digest) Metric(name string) float64 {
+	return d.Metrics[name]
+}
+
+// Output returns the latest value of a named step output, or "".
+func (d *Digest) Output(name string) string {
+	for i := len(d.Steps) - 1; i >= 0; i-- {
+		if v, ok := d.Steps[i].Outputs[name]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// DoneSteps returns the number of closed steps.
+func (d *Digest) DoneSteps() int {
+	n := 0
+	for _, s := range d.Steps {
+		if s.Status == "closed" {
+			n++
+		}
+	}
+	return n
+}
+
+// TotalSteps returns the number of steps.
+func (d *Digest) TotalSteps() int {
+	return len(d.Steps)
+}
+
+// MetricNames returns the digest's metric names, sorted.
+func (d *Digest) MetricNames() []string {
+	names := make([]string, 0, len(d.Metrics))
+	for name := range d.Metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddOutputMetrics adds every numeric step output to the digest's metrics,
+// except those already set explicitly.
+func (d *Digest) AddOutputMetrics() {
+	explicit := make(map[string]bool, len(d.Metrics))
+	for name := range d.Metrics {
+		explicit[name] = true
+	}
+	for _, s := range d.Steps {
+		for name, v := range s.Outputs {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || explicit[name] {
+				continue
+			}
+			if d.Metrics == nil {
+				d.Metrics = make(map[string]float64)
+			}
+			d.Metrics[name] += f
+		}
+	}
+}
+
+// Merge folds other into d: the covered time is widened, cycles and metrics
+// are summed, summaries appended and the latest steps kept.
+func (d *Digest) Merge(other *Digest) {
+	if d.Start.IsZero() || (!other.Start.IsZero() && other.Start.Before(d.Start)) {
+		d.Start = other.Start
+	}
+	if other.End.After(d.End) {
+		d.End = other.End
+		if len(other.Steps) > 0 {
+			d.Steps = other.Steps
+		}
+		if other.Molecule != "" {
+			d.Molecule = other.Molecule
+		}
+	}
+	d.Cycles += other.Cycles
+	for name, v := range other.Metrics {
+		if d.Metrics == nil {
+			d.Metrics = make(map[string]float64)
+		}
+		d.Metrics[name] += v
+	}
+	d.Summaries = append(d.Summaries, other.Summaries...)
+	if len(d.Summaries) > maxRollupSummaries {
+		d.Summaries = d.Summaries[len(d.Summaries)-maxRollupSummaries:]
+	}
+}
+
+// Default digest templates, chosen by kind when a formula doesn't define one.
+const (
+	PatrolTemplate = `## Patrol Digest: {{.Agent}}
+
+**Cycles:** {{.Cycles}} | **Duration:** {{duration .Duration}}
+{{- if .Steps}} | **Steps:** {{.DoneSteps}}/{{.TotalSteps}}{{end}}
+{{- range .MetricNames}}
+- {{.}}: {{number ($.Metric .)}}
+{{- end}}
+{{- with .Summaries}}
+
+### Summary
+{{- range .}}
+- {{.}}
+{{- end}}
+{{- end}}
+`
+
+	WorkTemplate = `## Work Digest: {{.Molecule}}
+
+**Agent:** {{.Agent}} | **Duration:** {{duration .Duration}}
+{{- with .Summary}}
+
+{{.}}
+{{- end}}
+{{- with .Steps}}
+
+### Steps
+{{- range .}}
+- [{{if eq .Status "closed"}}x{{else}} {{end}}] {{.Title}}
+{{- end}}
+{{- end}}
+{{- range .MetricNames}}
+- {{.}}: {{number ($.Metric .)}}
+{{- end}}
+`
+
+	RollupTemplate = `## {{periodLabel .Period}} Digest: {{.Agent}}
+
+**{{.Start.Format "2006-01-02"}} – {{(.End.Add -1).Format "2006-01-02"}}** | **Cycles:** {{.Cycles}}
+{{- range .MetricNames}}
+- {{.}}: {{number ($.Metric .)}}
+{{- end}}
+{{- with .Summaries}}
+
+### Recent
+{{- range .}}
+- {{.}}
+{{- end}}
+{{- end}}
+`
+
+	// minimalTemplate is the last resort when a digest can't be rendered.
+	minimalTemplate = `{{.Agent}}: {{.Molecule}} ({{.DoneSteps}}/{{.TotalSteps}} steps)`
+)
+
+// templateFuncs are available to digest templates.
+var templateFuncs = template.FuncMap{
+	"duration": func(d time.Duration) string {
+		if d < time.Minute {
+			return d.Round(time.Second).String()
+		}
+		return d.Round(time.Minute).String()
+	},
+	"number": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	},
+	"join": strings.Join,
+	"periodLabel": func(period string) string {
+		switch period {
+		case PeriodDay:
+			return "Daily"
+		case PeriodWeek:
+			return "Weekly"
+		case PeriodMonth:
+			return "Monthly"
+		}
+		return "Patrol"
+	},
+}
+
+// DefaultTemplate returns the default template for a digest.
+func DefaultTemplate(d *Digest) string {
+	switch {
+	case d.Period != PeriodCycle && d.Period != "":
+		return RollupTemplate
+	case d.Kind == KindPatrol:
+		return PatrolTemplate
+	case d.Kind == KindWork:
+		return WorkTemplate
+	}
+	return minimalTemplate
+}
+
+// Render renders a digest template.
+func Render(tmpl string, d *Digest) (string, error) {
+	t, err := template.New("digest").Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parsing digest template: %w", err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, d); err != nil {
+		return "", fmt.Errorf("rendering digest template: %w", err)
+	}
+	return strings.TrimSpace(sb.String()) + "\n", nil
+}
+
+// RenderDigest sets d.Body from custom (a formula's template), falling back
+// to the default template for the digest and then to a one-line summary.
+// The error reports why custom couldn't be used; d.Body is always set.
+func RenderDigest(d *Digest, custom string) error {
+	var customErr error
+	if custom != "" {
+		body, err := Render(custom, d)
+		if err == nil {
+			d.Body = body
+			return nil
+		}
+		customErr = err
+	}
+	body, err := Render(DefaultTemplate(d), d)
+	if err != nil {
+		body, _ = Render(minimalTemplate, d)
+	}
+	d.Body = body
+	return customErr
+}
@@ -0,0 +1,196 @@
+package wisp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestRenderDigest(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	d := &Digest{
+		Period:    PeriodCycle,
+		Kind:      KindPatrol,
+		Agent:     "gastown/witness",
+		Molecule:  "gt-wisp-1",
+		Start:     start,
+		End:       start.Add(12 * time.Minute),
+		Cycles:    1,
+		Metrics:   map[string]float64{"nudges": 1},
+		Summaries: []string{"All polecats healthy"},
+		Steps: []DigestStep{
+			{ID: "gt-wisp-1.1", Title: "Check inbox", Status: "closed", Outputs: map[string]string{"inbox_count": "3"}},
+			{ID: "gt-wisp-1.2", Title: "Survey workers", Status: "closed", Outputs: map[string]string{"issues_filed": "2", "verdict": "ok"}},
+		},
+	}
+	d.AddOutputMetrics()
+	if d.Metric("inbox_count") != 3 || d.Metric("issues_filed") != 2 || d.Metric("nudges") != 1 {
+		t.Errorf("Metrics = %v", d.Metrics)
+	}
+	if _, ok := d.Metrics["verdict"]; ok {
+		t.Error("non-numeric output became a metric")
+	}
+
+	if err := RenderDigest(d, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Patrol Digest: gastown/witness", "**Cycles:** 1 | **Duration:** 12m0s | **Steps:** 2/2", "- issues_filed: 2", "- All polecats healthy"} {
+		if !strings.Contains(d.Body, want) {
+			t.Errorf("patrol body missing %q:\n%s", want, d.Body)
+		}
+	}
+
+	if err := RenderDigest(d, `{{.Agent}} filed {{.Metric "issues_filed"}}, verdict {{.Output "verdict"}}`); err != nil {
+		t.Fatal(err)
+	}
+	if d.Body != "gastown/witness filed 2, verdict ok\n" {
+		t.Errorf("custom body = %q", d.Body)
+	}
+
+	// A broken formula template falls back to the default.
+	if err := RenderDigest(d, "{{.Nope"); err == nil || !strings.HasPrefix(d.Body, "## Patrol Digest") {
+		t.Errorf("err = %v, body = %q", err, d.Body)
+	}
+}
+
+func TestFold(t *testing.T) {
+	root := t.TempDir()
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+	cycle := func(n int) *Digest {
+		s := start.Add(time.Duration(n) * 20 * time.Minute)
+		return &Digest{Kind: KindPatrol, Agent: "deacon", Start: s, End: s.Add(5 * time.Minute), Cycles: 1,
+			Metrics: map[string]float64{"nudges": 1}, Summaries: []string{"cycle"}}
+	}
+
+	for n := 0; n < 3; n++ {
+		if due, err := Fold(root, cycle(n), time.Hour, ""); err != nil || due != nil {
+			t.Fatalf("cycle %d: due = %v, err = %v", n, due, err)
+		}
+	}
+	due, err := Fold(root, cycle(3), time.Hour, "")
+	if err != nil || due == nil {
+		t.Fatalf("cadence reached: due = %v, err = %v", due, err)
+	}
+	if due.Cycles != 4 || due.Metric("nudges") != 4 || !due.Start.Equal(start) || len(due.Summaries) != 4 {
+		t.Errorf("folded digest = %+v", due)
+	}
+
+	// A due digest stays pending until it is recorded, so nothing is lost
+	// if recording fails.
+	if due, _ := Fold(root, cycle(4), 0, ""); due == nil || due.Cycles != 5 {
+		t.Errorf("uncleared digest: due = %+v, want the earlier cycles refolded", due)
+	}
+	if err := ClearPending(root, "deacon"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once its bead exists, a digest that failed to be recorded is only
+	// recorded by the next squash, not folded into a second bead.
+	due, _ = Fold(root, cycle(4), 0, "")
+	due.BeadID = "gt-digest-1"
+	if err := SetPendingBead(root, due); err != nil {
+		t.Fatal(err)
+	}
+	if next, err := Fold(root, cycle(5), 0, ""); err != nil || next == nil || next.Cycles != 1 || next.BeadID != "" {
+		t.Fatalf("after bead: due = %+v, err = %v", next, err)
+	}
+	recorded, _ := LoadDigests(root, "deacon", time.Now(), time.Now().Add(24*time.Hour))
+	if len(recorded) != 1 || recorded[0].BeadID != "gt-digest-1" {
+		t.Errorf("recorded = %+v, want the bead's digest once", recorded)
+	}
+	if err := ClearPending(root, "deacon"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a cadence every squash is due.
+	if due, _ := Fold(root, cycle(4), 0, ""); due == nil || due.Cycles != 1 {
+		t.Errorf("no cadence: due = %+v", due)
+	}
+	if err := ClearPending(root, "deacon"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A patrol that stops squashing is flushed once its cadence passes.
+	if _, err := Fold(root, cycle(5), time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	if flushed, _ := FlushPending(root, start.Add(2*time.Hour)); len(flushed) != 0 {
+		t.Errorf("flushed %d digests before the cadence", len(flushed))
+	}
+	flushed, err := FlushPending(root, start.Add(3*time.Hour))
+	if err != nil || len(flushed) != 1 || flushed[0].Body == "" {
+		t.Fatalf("FlushPending = %v, %v", flushed, err)
+	}
+	if got, _ := LoadDigests(root, "deacon", start.Add(3*time.Hour), start.Add(27*time.Hour)); len(got) != 1 {
+		t.Errorf("flushed digest recorded %d times", len(got))
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	root := t.TempDir()
+	// Monday 2026-03-02 through Wednesday 2026-04-01.
+	first := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+	for day := 0; day < 31; day++ {
+		on := first.AddDate(0, 0, day).Add(10 * time.Hour)
+		for _, agent := range []string{"deacon", "gastown/refinery"} {
+			d := &Digest{Kind: KindPatrol, Agent: agent, Start: on, End: on.Add(time.Minute), Cycles: 1, Metrics: map[string]float64{"merges": 2}}
+			if err := appendDigestOn(root, d, on); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	now := time.Date(2026, 4, 1, 12, 0, 0, 0, time.Local)
+	weekly := false
+	cfg := &config.RetentionConfig{DigestActiveDays: 7, RollupWeekly: &weekly}
+	if _, err := ApplyRetention(root, cfg, now); err != nil {
+		t.Fatal(err)
+	}
+
+	rollups, _ := LoadRollups(root)
+	counts := make(map[string]int)
+	for _, r := range rollups {
+		counts[r.Period]++
+		if r.Period == PeriodMonth && (r.Cycles != 30 || r.Metric("merges") != 60 || !r.Start.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local))) {
+			t.Errorf("monthly rollup = %+v", r)
+		}
+	}
+	// Today (April 1) isn't rolled up; March is, and weeks are disabled.
+	if counts[PeriodDay] != 60 || counts[PeriodMonth] != 2 || counts[PeriodWeek] != 0 {
+		t.Errorf("rollups by period = %v", counts)
+	}
+	files, _ := os.ReadDir(filepath.Join(DigestDir(root), "cycles"))
+	if len(files) != 8 {
+		t.Errorf("%d days of cycle digests kept, want 8 (7 active days and today)", len(files))
+	}
+
+	// Weekly rollups are made from the kept daily rollups; reruns add nothing.
+	cfg.RollupWeekly = nil
+	result, err := ApplyRetention(root, cfg, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rollups) != 8 { // four full weeks, two agents
+		t.Errorf("created %d weekly rollups, want 8", len(result.Rollups))
+	}
+	if result, _ := ApplyRetention(root, cfg, now); len(result.Rollups) != 0 {
+		t.Errorf("rerun created %d rollups", len(result.Rollups))
+	}
+
+	// The current week is summarized from daily rollups and today's digests.
+	week, err := Summarize(root, "deacon", PeriodWeek, now)
+	if err != nil || len(week) != 1 {
+		t.Fatalf("Summarize = %v, %v", week, err)
+	}
+	if week[0].Cycles != 3 || !strings.Contains(week[0].Body, "Weekly Digest: deacon") {
+		t.Errorf("current week = %d cycles:\n%s", week[0].Cycles, week[0].Body)
+	}
+	past, _ := Summarize(root, "", PeriodWeek, first)
+	if len(past) != 2 || past[0].Cycles != 7 {
+		t.Errorf("stored week = %+v", past)
+	}
+}
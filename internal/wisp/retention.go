@@ -0,0 +1,286 @@
+package wisp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// startOfDay returns local midnight of the day containing t.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Local().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// PeriodStart returns the start of the local day, week (from Monday) or
+// month containing t.
+func PeriodStart(period string, t time.Time) time.Time {
+	day := startOfDay(t)
+	switch period {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// PeriodEnd returns the end of the period beginning at start.
+func PeriodEnd(period string, start time.Time) time.Time {
+	switch period {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// rollupsFile is the JSON structure of digests/rollups.json.
+type rollupsFile struct {
+	Version int       `json:"version"`
+	Rollups []*Digest `json:"rollups"`
+}
+
+func rollupsPath(townRoot string) string {
+	return filepath.Join(DigestDir(townRoot), "rollups.json")
+}
+
+// LoadRollups returns the town's daily, weekly and monthly rollups.
+func LoadRollups(townRoot string) ([]*Digest, error) {
+	data, err := os.ReadFile(rollupsPath(townRoot)) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rf rollupsFile
+	if err := json.Unmarshal(data, &rf); err != nil {
+		return nil, fmt.Errorf("parse rollups: %w", err)
+	}
+	return rf.Rollups, nil
+}
+
+func saveRollups(townRoot string, rollups []*Digest) error {
+	sort.SliceStable(rollups, func(i, j int) bool {
+		a, b := rollups[i], rollups[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		return a.Agent < b.Agent
+	})
+	if err := os.MkdirAll(DigestDir(townRoot), 0755); err != nil {
+		return fmt.Errorf("create digest dir: %w", err)
+	}
+	return writeJSON(rollupsPath(townRoot), &rollupsFile{Version: 1, Rollups: rollups})
+}
+
+// rollupKey identifies an agent's rollup for one period.
+func rollupKey(period, agent string, start time.Time) string {
+	return period + "|" + agent + "|" + start.Format(time.RFC3339)
+}
+
+// rollup merges an agent's digests into a rollup covering a whole period.
+func rollup(period, agent string, start time.Time, parts []*Digest) *Digest {
+	r := &Digest{Period: period, Kind: parts[0].Kind, Agent: agent}
+	for _, p := range parts {
+		r.Merge(p)
+	}
+	r.Start, r.End = start, PeriodEnd(period, start)
+	r.Steps, r.Molecule = nil, ""
+	_ = RenderDigest(r, "")
+	return r
+}
+
+// RetentionResult reports what ApplyRetention did.
+type RetentionResult struct {
+	Rollups       []*Digest // rollups created
+	PrunedDays    int       // days of cycle digests deleted
+	PrunedRollups int       // daily rollups deleted
+}
+
+// ApplyRetention rolls each finished day's cycle digests into a daily
+// rollup per agent, and daily rollups into weekly and monthly ones once
+// those periods have ended. Cycle digests older than the active window
+// and daily rollups older than the archive window are then deleted;
+// weekly and monthly rollups are kept.
+func ApplyRetention(townRoot string, cfg *config.RetentionConfig, now time.Time) (*RetentionResult, error) {
+	result := &RetentionResult{}
+	today := startOfDay(now)
+
+	rollups, err := LoadRollups(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool, len(rollups))
+	rolledDays := make(map[int64]bool)
+	for _, r := range rollups {
+		have[rollupKey(r.Period, r.Agent, r.Start)] = true
+		if r.Period == PeriodDay {
+			rolledDays[r.Start.Unix()] = true
+		}
+	}
+
+	// Finished days become daily rollups.
+	days, err := cycleDays(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		if !day.Before(today) || rolledDays[day.Unix()] {
+			continue
+		}
+		digests, err := loadDay(townRoot, day)
+		if err != nil {
+			return nil, err
+		}
+		byAgent := make(map[string][]*Digest)
+		for _, d := range digests {
+			byAgent[d.Agent] = append(byAgent[d.Agent], d)
+		}
+		for agent, parts := range byAgent {
+			r := rollup(PeriodDay, agent, day, parts)
+			rollups = append(rollups, r)
+			have[rollupKey(PeriodDay, agent, day)] = true
+			result.Rollups = append(result.Rollups, r)
+		}
+		rolledDays[day.Unix()] = true
+	}
+
+	// Finished weeks and months are rolled up from the daily rollups.
+	for _, period := range []string{PeriodWeek, PeriodMonth} {
+		if (period == PeriodWeek && !cfg.Weekly()) || (period == PeriodMonth && !cfg.Monthly()) {
+			continue
+		}
+		type group struct {
+			agent string
+			start time.Time
+			parts []*Digest
+		}
+		groups := make(map[string]*group)
+		var order []string
+		for _, r := range rollups {
+			if r.Period != PeriodDay {
+				continue
+			}
+			start := PeriodStart(period, r.Start)
+			key := rollupKey(period, r.Agent, start)
+			if have[key] || PeriodEnd(period, start).After(today) {
+				continue
+			}
+			if groups[key] == nil {
+				groups[key] = &group{agent: r.Agent, start: start}
+				order = append(order, key)
+			}
+			groups[key].parts = append(groups[key].parts, r)
+		}
+		for _, key := range order {
+			g := groups[key]
+			r := rollup(period, g.agent, g.start, g.parts)
+			rollups = append(rollups, r)
+			have[key] = true
+			result.Rollups = append(result.Rollups, r)
+		}
+	}
+
+	// Rolled-up cycle digests are deleted after the active window.
+	activeCutoff := today.AddDate(0, 0, -cfg.ActiveDays())
+	for _, day := range days {
+		if day.Before(activeCutoff) && rolledDays[day.Unix()] {
+			if err := os.Remove(filepath.Join(cycleDir(townRoot), day.Format(dayLayout)+".jsonl")); err == nil {
+				result.PrunedDays++
+			}
+		}
+	}
+
+	// Daily rollups outlive the cycle digests they were made from.
+	archiveCutoff := today.AddDate(0, 0, -max(cfg.ArchiveDays(), cfg.ActiveDays()))
+	kept := rollups[:0]
+	for _, r := range rollups {
+		if r.Period == PeriodDay && r.Start.Before(archiveCutoff) {
+			result.PrunedRollups++
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	if len(result.Rollups) > 0 || result.PrunedRollups > 0 {
+		if err := saveRollups(townRoot, kept); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Summarize returns a rollup per agent for the period containing at,
+// sorted by agent. Periods that haven't been rolled up yet (such as the
+// current week) are summarized from the daily rollups and the cycle
+// digests recorded so far. agent filters by agent when non-empty.
+func Summarize(townRoot, agent, period string, at time.Time) ([]*Digest, error) {
+	start := PeriodStart(period, at)
+	end := PeriodEnd(period, start)
+
+	rollups, err := LoadRollups(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]*Digest)
+	rolledDays := make(map[int64]bool)
+	parts := make(map[string][]*Digest)
+	for _, r := range rollups {
+		if r.Period == PeriodDay {
+			rolledDays[r.Start.Unix()] = true
+		}
+		if agent != "" && r.Agent != agent {
+			continue
+		}
+		switch {
+		case r.Period == period && r.Start.Equal(start):
+			stored[r.Agent] = r
+		case r.Period == PeriodDay && !r.Start.Before(start) && r.Start.Before(end):
+			parts[r.Agent] = append(parts[r.Agent], r)
+		}
+	}
+
+	// Days without a daily rollup yet are read from their cycle digests.
+	days, err := cycleDays(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		if day.Before(start) || !day.Before(end) || rolledDays[day.Unix()] {
+			continue
+		}
+		digests, err := loadDay(townRoot, day)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range digests {
+			if agent == "" || d.Agent == agent {
+				parts[d.Agent] = append(parts[d.Agent], d)
+			}
+		}
+	}
+
+	var summaries []*Digest
+	for a, ps := range parts {
+		if _, ok := stored[a]; ok {
+			continue
+		}
+		summaries = append(summaries, rollup(period, a, start, ps))
+	}
+	for _, r := range stored {
+		summaries = append(summaries, r)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Agent < summaries[j].Agent })
+	return summaries, nil
+}
@@ -0,0 +1,256 @@
+package wisp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Digests are kept under .beads/digests/ at the town root:
+//
+//	cycles/<date>.jsonl   cycle digests, one file per local day
+//	pending/<agent>.json  patrol cycles being folded until their cadence is due
+//	rollups.json          daily, weekly and monthly rollups
+//
+// A digest is filed under the day it is recorded, which may be after the
+// day it ended. Files are only appended to on the day they are named for,
+// so retention can roll up a finished day without racing agents that are
+// still squashing.
+
+// DigestDir returns the digest directory of a town.
+func DigestDir(townRoot string) string {
+	return WispPath(townRoot, "digests")
+}
+
+// dayLayout names cycle digest files.
+const dayLayout = "2006-01-02"
+
+func cycleDir(townRoot string) string {
+	return filepath.Join(DigestDir(townRoot), "cycles")
+}
+
+func pendingDir(townRoot string) string {
+	return filepath.Join(DigestDir(townRoot), "pending")
+}
+
+func pendingPath(townRoot, agent string) string {
+	return filepath.Join(pendingDir(townRoot), strings.ReplaceAll(agent, "/", "--")+".json")
+}
+
+// AppendDigest records a cycle digest in today's file.
+func AppendDigest(townRoot string, d *Digest) error {
+	return appendDigestOn(townRoot, d, time.Now())
+}
+
+// appendDigestOn records a cycle digest in the file for the local day of on.
+func appendDigestOn(townRoot string, d *Digest, on time.Time) error {
+	if d.Period == "" {
+		d.Period = PeriodCycle
+	}
+	dir := cycleDir(townRoot)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create digest dir: %w", err)
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("marshal digest: %w", err)
+	}
+	path := filepath.Join(dir, on.Local().Format(dayLayout)+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302,G304: digests are non-sensitive, path is constructed internally
+	if err != nil {
+		return fmt.Errorf("open digest log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write digest: %w", err)
+	}
+	return nil
+}
+
+// cycleDays returns the local days that have a cycle digest file, sorted.
+func cycleDays(townRoot string) ([]time.Time, error) {
+	entries, err := os.ReadDir(cycleDir(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var days []time.Time
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok {
+			continue
+		}
+		if day, err := time.ParseInLocation(dayLayout, name, time.Local); err == nil {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+// loadDay returns the cycle digests recorded on a local day.
+// Lines that can't be parsed (such as a torn final write) are skipped.
+func loadDay(townRoot string, day time.Time) ([]*Digest, error) {
+	f, err := os.Open(filepath.Join(cycleDir(townRoot), day.Format(dayLayout)+".jsonl")) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var digests []*Digest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var d Digest
+		if err := json.Unmarshal(scanner.Bytes(), &d); err == nil {
+			digests = append(digests, &d)
+		}
+	}
+	return digests, scanner.Err()
+}
+
+// LoadDigests returns the cycle digests recorded on the local days from
+// from up to (not including) to, oldest first. agent filters by agent when
+// non-empty.
+func LoadDigests(townRoot, agent string, from, to time.Time) ([]*Digest, error) {
+	days, err := cycleDays(townRoot)
+	if err != nil {
+		return nil, err
+	}
+	from, to = startOfDay(from), startOfDay(to)
+	var digests []*Digest
+	for _, day := range days {
+		if day.Before(from) || !day.Before(to) {
+			continue
+		}
+		dayDigests, err := loadDay(townRoot, day)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dayDigests {
+			if agent == "" || d.Agent == agent {
+				digests = append(digests, d)
+			}
+		}
+	}
+	return digests, nil
+}
+
+// pendingDigest is a patrol's folded cycles waiting for their cadence.
+type pendingDigest struct {
+	Cadence  string  `json:"cadence"`
+	Template string  `json:"template,omitempty"`
+	Digest   *Digest `json:"digest"`
+}
+
+// Fold adds a squashed cycle to the agent's pending digest. The folded
+// digest is returned as due once cadence has passed since its first cycle
+// (or at once when cadence is zero); otherwise nil is returned. Either way
+// the folded digest stays pending until ClearPending is called, so a due
+// digest that fails to be recorded is folded into the next squash rather
+// than lost. Once its bead exists (see SetPendingBead) it is no longer
+// folded: the next Fold only retries recording it. template is kept with a
+// pending digest so it can be rendered the same way when it is flushed.
+func Fold(townRoot string, d *Digest, cadence time.Duration, template string) (*Digest, error) {
+	path := pendingPath(townRoot, d.Agent)
+	folded := d
+	if data, err := os.ReadFile(path); err == nil { //nolint:gosec // G304: path is constructed internally
+		var p pendingDigest
+		if json.Unmarshal(data, &p) == nil && p.Digest != nil {
+			if p.Digest.BeadID != "" {
+				// Its bead was created but the digest never made the log
+				if err := AppendDigest(townRoot, p.Digest); err != nil {
+					return nil, err
+				}
+			} else {
+				folded = p.Digest
+				folded.Merge(d)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(pendingDir(townRoot), 0755); err != nil {
+		return nil, fmt.Errorf("create pending dir: %w", err)
+	}
+	if err := writeJSON(path, &pendingDigest{Cadence: cadence.String(), Template: template, Digest: folded}); err != nil {
+		return nil, err
+	}
+	if cadence > 0 && folded.Duration() < cadence {
+		return nil, nil
+	}
+	return folded, nil
+}
+
+// SetPendingBead records that the bead for the agent's due pending digest
+// d has been created, so a digest that then fails to be recorded is retried
+// without creating its bead again.
+func SetPendingBead(townRoot string, d *Digest) error {
+	path := pendingPath(townRoot, d.Agent)
+	var p pendingDigest
+	if data, err := os.ReadFile(path); err == nil { //nolint:gosec // G304: path is constructed internally
+		_ = json.Unmarshal(data, &p)
+	}
+	p.Digest = d
+	return writeJSON(path, &p)
+}
+
+// ClearPending removes the agent's pending digest once the digest Fold
+// returned as due has been recorded.
+func ClearPending(townRoot, agent string) error {
+	if err := os.Remove(pendingPath(townRoot, agent)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("clear pending digest: %w", err)
+	}
+	return nil
+}
+
+// FlushPending records the pending digests whose cadence has passed, so a
+// patrol that stopped squashing still leaves its digest on time. The
+// recorded digests are returned.
+func FlushPending(townRoot string, now time.Time) ([]*Digest, error) {
+	dir := pendingDir(townRoot)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var flushed []*Digest
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+		if err != nil {
+			continue
+		}
+		var p pendingDigest
+		if err := json.Unmarshal(data, &p); err != nil || p.Digest == nil {
+			_ = os.Remove(path)
+			continue
+		}
+		cadence, _ := time.ParseDuration(p.Cadence)
+		if now.Sub(p.Digest.Start) < cadence {
+			continue
+		}
+		_ = RenderDigest(p.Digest, p.Template)
+		if err := appendDigestOn(townRoot, p.Digest, now); err != nil {
+			return flushed, err
+		}
+		_ = os.Remove(path)
+		flushed = append(flushed, p.Digest)
+	}
+	return flushed, nil
+}
@@ -2,7 +2,9 @@
 //
 // This package was originally for "hook files" but those are now deprecated
 // in favor of pinned beads. The remaining utilities help with directory
-// management for the beads system.
+// management for the beads system, and the digest engine: the templated
+// records left by squashed molecules and their daily, weekly and monthly
+// rollups.
 package wisp
 
 // WispDir is the directory where beads data is stored.
@@ -12,6 +12,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/protocol/envelope"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -164,6 +165,13 @@ func HandleHelp(workDir, rigName string, msg *mail.Message, router *mail.Router)
 		result.Error = fmt.Errorf("parsing HELP: %w", err)
 		return result
 	}
+	// Hand-written requests often omit these fields
+	if payload.Agent == "" {
+		payload.Agent = msg.From
+	}
+	if payload.Problem == "" {
+		payload.Problem = strings.TrimSpace(envelope.Strip(msg.Body))
+	}
 
 	// Assess the help request
 	assessment := AssessHelpRequest(payload)
@@ -518,35 +526,25 @@ func getCleanupStatus(workDir, rigName, polecatName string) string {
 
 // escalateToMayor sends an escalation mail to the Mayor.
 func escalateToMayor(router *mail.Router, rigName string, payload *HelpPayload, reason string) (string, error) {
-	msg := &mail.Message{
-		From:     fmt.Sprintf("%s/witness", rigName),
-		To:       "mayor/",
-		Subject:  fmt.Sprintf("Escalation: %s needs help", payload.Agent),
-		Priority: mail.PriorityHigh,
-		Body: fmt.Sprintf(`Agent: %s
-Issue: %s
-Topic: %s
-Problem: %s
-Tried: %s
-Escalation reason: %s
-Requested at: %s`,
-			payload.Agent,
-			payload.IssueID,
-			payload.Topic,
-			payload.Problem,
-			payload.Tried,
-			reason,
-			payload.RequestedAt.Format(time.RFC3339),
-		),
-	}
-
+	msg := helpEscalation(rigName, payload, reason)
 	if err := router.Send(msg); err != nil {
 		return "", err
 	}
-
 	return msg.ID, nil
 }
 
+// helpEscalation forwards a HELP request to the Mayor as HELP mail, with
+// the Witness's reason for escalating ahead of the request itself.
+func helpEscalation(rigName string, payload *HelpPayload, reason string) *mail.Message {
+	return &mail.Message{
+		From:     fmt.Sprintf("%s/witness", rigName),
+		To:       "mayor/",
+		Subject:  fmt.Sprintf("HELP: %s", payload.Topic),
+		Priority: mail.PriorityHigh,
+		Body:     fmt.Sprintf("Escalation reason: %s\n", reason) + FormatHelp(*payload),
+	}
+}
+
 // RecoveryPayload contains data for RECOVERY_NEEDED escalation.
 type RecoveryPayload struct {
 	PolecatName   string
@@ -0,0 +1,29 @@
+package witness
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpEscalation(t *testing.T) {
+	payload := &HelpPayload{Topic: "Tests hang", Agent: "gastown/nux", IssueID: "gt-abc", Problem: "go test hangs\nin CI"}
+	msg := helpEscalation("gastown", payload, "needs a human")
+
+	if msg.From != "gastown/witness" || msg.To != "mayor/" || msg.Subject != "HELP: Tests hang" {
+		t.Errorf("message = %+v", msg)
+	}
+	if !strings.HasPrefix(msg.Body, "Escalation reason: needs a human\n") {
+		t.Errorf("body does not lead with the reason:\n%s", msg.Body)
+	}
+	if v := Validate(msg.Subject, msg.Body); !v.Valid() {
+		t.Errorf("escalation is not a valid HELP message: %v", v)
+	}
+
+	got, err := ParseHelp(msg.Subject, msg.Body)
+	if err != nil {
+		t.Fatalf("ParseHelp() error = %v", err)
+	}
+	if got.Agent != payload.Agent || got.IssueID != payload.IssueID || got.Problem != payload.Problem {
+		t.Errorf("payload = %+v", got)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/steveyegge/gastown/internal/protocol/envelope"
 )
 
 // Protocol message patterns for Witness inbox routing.
@@ -42,24 +44,40 @@ const (
 	ProtoUnknown           ProtocolType = "unknown"
 )
 
+// Envelope types for witness protocol messages (see envelope.Encode).
+const (
+	EnvelopePolecatDone = "POLECAT_DONE"
+	EnvelopeHelp        = "HELP"
+	EnvelopeSwarmStart  = "SWARM_START"
+	EnvelopeMerged      = "MERGED"
+)
+
+// Valid POLECAT_DONE exit types.
+const (
+	ExitCompleted     = "COMPLETED"
+	ExitEscalated     = "ESCALATED"
+	ExitDeferred      = "DEFERRED"
+	ExitPhaseComplete = "PHASE_COMPLETE"
+)
+
 // PolecatDonePayload contains parsed data from a POLECAT_DONE message.
 type PolecatDonePayload struct {
-	PolecatName string
-	Exit        string // COMPLETED, ESCALATED, DEFERRED, PHASE_COMPLETE
-	IssueID     string
-	MRID        string
-	Branch      string
-	Gate        string // Gate ID when Exit is PHASE_COMPLETE
+	PolecatName string `json:"polecat"`
+	Exit        string `json:"exit"` // COMPLETED, ESCALATED, DEFERRED, PHASE_COMPLETE
+	IssueID     string `json:"issue,omitempty"`
+	MRID        string `json:"mr,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Gate        string `json:"gate,omitempty"` // Gate ID when Exit is PHASE_COMPLETE
 }
 
 // HelpPayload contains parsed data from a HELP message.
 type HelpPayload struct {
-	Topic       string
-	Agent       string
-	IssueID     string
-	Problem     string
-	Tried       string
-	RequestedAt time.Time
+	Topic       string    `json:"topic"`
+	Agent       string    `json:"agent,omitempty"`
+	IssueID     string    `json:"issue,omitempty"`
+	Problem     string    `json:"problem,omitempty"`
+	Tried       string    `json:"tried,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
 }
 
 // MergedPayload contains parsed data from a MERGED message.
@@ -70,12 +88,20 @@ type MergedPayload struct {
 	MergedAt    time.Time
 }
 
+// mergedEnvelope is the subset of the Refinery's MERGED payload
+// (protocol.MergedPayload) the Witness reads.
+type mergedEnvelope struct {
+	Branch   string    `json:"branch"`
+	Issue    string    `json:"issue"`
+	MergedAt time.Time `json:"merged_at"`
+}
+
 // SwarmStartPayload contains parsed data from a SWARM_START message.
 type SwarmStartPayload struct {
-	SwarmID   string
-	BeadIDs   []string
-	Total     int
-	StartedAt time.Time
+	SwarmID   string    `json:"swarm_id"`
+	BeadIDs   []string  `json:"beads,omitempty"`
+	Total     int       `json:"total"`
+	StartedAt time.Time `json:"started_at"`
 }
 
 // ClassifyMessage determines the protocol type from a message subject.
@@ -99,8 +125,9 @@ func ClassifyMessage(subject string) ProtocolType {
 }
 
 // ParsePolecatDone extracts payload from a POLECAT_DONE message.
+// The body's protocol envelope is preferred; legacy bodies are parsed as text.
 // Subject format: POLECAT_DONE <polecat-name>
-// Body format:
+// Legacy body format:
 //
 //	Exit: COMPLETED|ESCALATED|DEFERRED|PHASE_COMPLETE
 //	Issue: <issue-id>
@@ -113,11 +140,19 @@ func ParsePolecatDone(subject, body string) (*PolecatDonePayload, error) {
 		return nil, fmt.Errorf("invalid POLECAT_DONE subject: %s", subject)
 	}
 
-	payload := &PolecatDonePayload{
+	payload := &PolecatDonePayload{}
+	if envelope.Decode(body, EnvelopePolecatDone, payload) == nil {
+		if payload.PolecatName == "" {
+			payload.PolecatName = matches[1]
+		}
+		return payload, nil
+	}
+	payload = &PolecatDonePayload{
 		PolecatName: matches[1],
 	}
 
 	// Parse body for structured fields
+	body = envelope.Strip(body)
 	for _, line := range strings.Split(body, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "Exit:") {
@@ -137,8 +172,9 @@ func ParsePolecatDone(subject, body string) (*PolecatDonePayload, error) {
 }
 
 // ParseHelp extracts payload from a HELP message.
+// The body's protocol envelope is preferred; legacy bodies are parsed as text.
 // Subject format: HELP: <topic>
-// Body format:
+// Legacy body format:
 //
 //	Agent: <agent-id>
 //	Issue: <issue-id>
@@ -150,12 +186,20 @@ func ParseHelp(subject, body string) (*HelpPayload, error) {
 		return nil, fmt.Errorf("invalid HELP subject: %s", subject)
 	}
 
-	payload := &HelpPayload{
+	payload := &HelpPayload{}
+	if envelope.Decode(body, EnvelopeHelp, payload) == nil {
+		if payload.Topic == "" {
+			payload.Topic = matches[1]
+		}
+		return payload, nil
+	}
+	payload = &HelpPayload{
 		Topic:       matches[1],
 		RequestedAt: time.Now(),
 	}
 
 	// Parse body for structured fields
+	body = envelope.Strip(body)
 	for _, line := range strings.Split(body, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "Agent:") {
@@ -173,8 +217,9 @@ func ParseHelp(subject, body string) (*HelpPayload, error) {
 }
 
 // ParseMerged extracts payload from a MERGED message.
+// MERGED is sent by the Refinery, so its envelope is protocol.MergedPayload.
 // Subject format: MERGED <polecat-name>
-// Body format:
+// Legacy body format:
 //
 //	Branch: <branch>
 //	Issue: <issue-id>
@@ -185,11 +230,22 @@ func ParseMerged(subject, body string) (*MergedPayload, error) {
 		return nil, fmt.Errorf("invalid MERGED subject: %s", subject)
 	}
 
+	var env mergedEnvelope
+	if envelope.Decode(body, EnvelopeMerged, &env) == nil {
+		return &MergedPayload{
+			PolecatName: matches[1],
+			Branch:      env.Branch,
+			IssueID:     env.Issue,
+			MergedAt:    env.MergedAt,
+		}, nil
+	}
+
 	payload := &MergedPayload{
 		PolecatName: matches[1],
 	}
 
 	// Parse body for structured fields
+	body = envelope.Strip(body)
 	for _, line := range strings.Split(body, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "Branch:") {
@@ -208,14 +264,21 @@ func ParseMerged(subject, body string) (*MergedPayload, error) {
 }
 
 // ParseSwarmStart extracts payload from a SWARM_START message.
-// Body format is JSON: {"swarm_id": "batch-123", "beads": ["bd-a", "bd-b"]}
+// The body's protocol envelope is preferred; legacy bodies carry
+// "SwarmID:" and "Total:" lines.
 func ParseSwarmStart(body string) (*SwarmStartPayload, error) {
-	payload := &SwarmStartPayload{
+	payload := &SwarmStartPayload{}
+	if envelope.Decode(body, EnvelopeSwarmStart, payload) == nil {
+		if payload.Total == 0 {
+			payload.Total = len(payload.BeadIDs)
+		}
+		return payload, nil
+	}
+	payload = &SwarmStartPayload{
 		StartedAt: time.Now(),
 	}
 
-	// Parse the JSON-like body (simplified parsing for key-value extraction)
-	// Full JSON parsing would require encoding/json import
+	body = envelope.Strip(body)
 	for _, line := range strings.Split(body, "\n") {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "SwarmID:") || strings.HasPrefix(line, "swarm_id:") {
@@ -228,6 +291,101 @@ func ParseSwarmStart(body string) (*SwarmStartPayload, error) {
 	return payload, nil
 }
 
+// FormatPolecatDone formats a POLECAT_DONE body: the legacy text fields
+// followed by the protocol envelope.
+func FormatPolecatDone(p PolecatDonePayload) string {
+	lines := []string{fmt.Sprintf("Exit: %s", p.Exit)}
+	if p.IssueID != "" {
+		lines = append(lines, fmt.Sprintf("Issue: %s", p.IssueID))
+	}
+	if p.MRID != "" {
+		lines = append(lines, fmt.Sprintf("MR: %s", p.MRID))
+	}
+	if p.Gate != "" {
+		lines = append(lines, fmt.Sprintf("Gate: %s", p.Gate))
+	}
+	lines = append(lines, fmt.Sprintf("Branch: %s", p.Branch))
+	return envelope.Encode(strings.Join(lines, "\n"), EnvelopePolecatDone, p)
+}
+
+// FormatHelp formats a HELP body: the legacy text fields followed by the
+// protocol envelope.
+func FormatHelp(p HelpPayload) string {
+	var lines []string
+	if p.Agent != "" {
+		lines = append(lines, fmt.Sprintf("Agent: %s", p.Agent))
+	}
+	if p.IssueID != "" {
+		lines = append(lines, fmt.Sprintf("Issue: %s", p.IssueID))
+	}
+	lines = append(lines, fmt.Sprintf("Problem: %s", p.Problem))
+	if p.Tried != "" {
+		lines = append(lines, fmt.Sprintf("Tried: %s", p.Tried))
+	}
+	return envelope.Encode(strings.Join(lines, "\n"), EnvelopeHelp, p)
+}
+
+// Validate checks a POLECAT_DONE, HELP or SWARM_START message. Returns nil
+// for other mail; Witness/Refinery messages such as MERGED are checked by
+// protocol.Validate.
+func Validate(subject, body string) *envelope.Validation {
+	switch ClassifyMessage(subject) {
+	case ProtoPolecatDone:
+		var env PolecatDonePayload
+		v := envelope.Check(EnvelopePolecatDone, body, &env)
+		if !v.Valid() {
+			return v
+		}
+		p, err := ParsePolecatDone(subject, body)
+		if err != nil {
+			v.Problemf("%v", err)
+			return v
+		}
+		switch p.Exit {
+		case ExitCompleted, ExitEscalated, ExitDeferred:
+		case ExitPhaseComplete:
+			v.Require("gate", p.Gate)
+		case "":
+			v.Require("exit", p.Exit)
+		default:
+			v.Problemf("unknown exit type %q", p.Exit)
+		}
+		if name := PatternPolecatDone.FindStringSubmatch(subject)[1]; p.PolecatName != name {
+			v.Problemf("subject names polecat %s but payload has %s", name, p.PolecatName)
+		}
+		return v
+
+	case ProtoHelp:
+		var env HelpPayload
+		v := envelope.Check(EnvelopeHelp, body, &env)
+		if !v.Valid() {
+			return v
+		}
+		p, err := ParseHelp(subject, body)
+		if err != nil {
+			v.Problemf("%v", err)
+			return v
+		}
+		v.Require("topic", p.Topic)
+		if !v.Legacy() {
+			v.Require("problem", p.Problem)
+		}
+		return v
+
+	case ProtoSwarmStart:
+		var env SwarmStartPayload
+		v := envelope.Check(EnvelopeSwarmStart, body, &env)
+		if !v.Valid() {
+			return v
+		}
+		p, _ := ParseSwarmStart(body)
+		v.Require("swarm_id", p.SwarmID)
+		return v
+	}
+
+	return nil
+}
+
 // CleanupWispLabels generates labels for a cleanup wisp.
 func CleanupWispLabels(polecatName, state string) []string {
 	return []string{
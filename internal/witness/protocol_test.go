@@ -1,6 +1,7 @@
 package witness
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -82,6 +83,76 @@ func TestParsePolecatDone_MinimalBody(t *testing.T) {
 	}
 }
 
+func TestParsePolecatDone_Envelope(t *testing.T) {
+	body := FormatPolecatDone(PolecatDonePayload{
+		PolecatName: "nux",
+		Exit:        ExitPhaseComplete,
+		IssueID:     "gt-abc123",
+		Branch:      "polecat/nux",
+		Gate:        "gt-gate-1",
+	})
+	if !strings.HasPrefix(body, "Exit: PHASE_COMPLETE\nIssue: gt-abc123\nGate: gt-gate-1\nBranch: polecat/nux\n") {
+		t.Errorf("legacy text missing or reordered:\n%s", body)
+	}
+
+	payload, err := ParsePolecatDone("POLECAT_DONE nux", body)
+	if err != nil {
+		t.Fatalf("ParsePolecatDone() error = %v", err)
+	}
+	if payload.Exit != ExitPhaseComplete || payload.Gate != "gt-gate-1" || payload.Branch != "polecat/nux" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestParseHelp_MultiLineProblem(t *testing.T) {
+	problem := "go test hangs\nTried: this line is part of the problem"
+	body := FormatHelp(HelpPayload{Agent: "gastown/nux", Problem: problem, Tried: "rerunning"})
+
+	payload, err := ParseHelp("HELP: Tests hang", body)
+	if err != nil {
+		t.Fatalf("ParseHelp() error = %v", err)
+	}
+	if payload.Topic != "Tests hang" || payload.Problem != problem || payload.Tried != "rerunning" {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		problem string
+	}{
+		{"envelope", "POLECAT_DONE nux", FormatPolecatDone(PolecatDonePayload{PolecatName: "nux", Exit: ExitCompleted, Branch: "b"}), ""},
+		{"legacy", "POLECAT_DONE nux", "Exit: DEFERRED", ""},
+		{"unknown exit", "POLECAT_DONE nux", "Exit: MAYBE", "unknown exit type"},
+		{"phase without gate", "POLECAT_DONE nux", "Exit: PHASE_COMPLETE", `"gate"`},
+		{"wrong polecat", "POLECAT_DONE ace", FormatPolecatDone(PolecatDonePayload{PolecatName: "nux", Exit: ExitCompleted}), "subject names polecat ace"},
+		{"help envelope without problem", "HELP: stuck", FormatHelp(HelpPayload{}), `"problem"`},
+		{"legacy help", "HELP: stuck", "free-form plea", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := Validate(tt.subject, tt.body)
+			if v == nil {
+				t.Fatal("Validate returned nil for protocol message")
+			}
+			got := strings.Join(v.Problems, "; ")
+			if tt.problem == "" && !v.Valid() {
+				t.Errorf("unexpected problems: %s", got)
+			}
+			if tt.problem != "" && !strings.Contains(got, tt.problem) {
+				t.Errorf("Problems = %q, want one containing %q", got, tt.problem)
+			}
+		})
+	}
+
+	if Validate("MERGED nux", "Branch: x") != nil {
+		t.Error("MERGED is validated by the protocol package")
+	}
+}
+
 func TestParsePolecatDone_InvalidSubject(t *testing.T) {
 	_, err := ParsePolecatDone("Invalid subject", "body")
 	if err == nil {